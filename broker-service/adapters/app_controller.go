@@ -1,7 +1,28 @@
 package adapters
 
-import "github.com/spriigan/broker/user/interface/controller"
+import (
+	"firebase.google.com/go/auth"
+	privacy "github.com/spriigan/broker/privacy/interface/controller"
+	product "github.com/spriigan/broker/product/interface/controller"
+	"github.com/spriigan/broker/user/interface/controller"
+)
 
 type AppController struct {
-	User interface{ controller.UserController }
+	User         interface{ controller.UserController }
+	Totp         interface{ controller.TotpController }
+	Session      interface{ controller.SessionController }
+	Profile      interface{ controller.ProfileController }
+	Admin        interface{ controller.AdminController }
+	Product      interface{ product.ProductController }
+	Review       interface{ product.ReviewController }
+	Image        interface{ product.ImageController }
+	Catalog      interface{ product.CatalogController }
+	Promotion    interface{ product.PromotionController }
+	Order        interface{ product.OrderController }
+	Wishlist     interface{ product.WishlistController }
+	Notification interface{ product.NotificationController }
+	ApiKey       interface{ product.ApiKeyController }
+	Privacy      interface{ privacy.PrivacyController }
+	// Firebase verifies the ID tokens of the users who sign in with Firebase.
+	Firebase *auth.Client
 }
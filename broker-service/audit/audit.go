@@ -0,0 +1,118 @@
+// Package audit tells the services who made each request, so the entries
+// they write to the audit log name the actor, the request id and the address
+// the request came from.
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader carries the request id, a client may send its own to find
+// the request in the audit log later.
+const RequestIDHeader = "X-Request-ID"
+
+// The metadata keys the services read the request from.
+const (
+	RequestIDKey    = "x-request-id"
+	ClientIPKey     = "x-client-ip"
+	ActorKey        = "x-actor"
+	ImpersonatorKey = "x-impersonator"
+)
+
+// requestIDPattern keeps ids sent by clients short and printable.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Request is who made a request and from where. Actor is the email of the
+// signed in user, or the API key a store integration used, Impersonator the
+// admin acting as the user.
+type Request struct {
+	ID           string
+	IP           string
+	Actor        string
+	Impersonator string
+}
+
+type requestKey struct{}
+
+func WithRequest(ctx context.Context, request Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, request)
+}
+
+// RequestFrom returns the request of ctx, empty outside of a request.
+func RequestFrom(ctx context.Context) Request {
+	request, _ := ctx.Value(requestKey{}).(Request)
+	return request
+}
+
+// Detach returns a context that outlives the request of ctx but still tells
+// who made it, for work that goes on in the background.
+func Detach(ctx context.Context) context.Context {
+	return WithRequest(context.Background(), RequestFrom(ctx))
+}
+
+// RequestID gives every request an id and puts the request into its context.
+// The id is sent back in the X-Request-ID header. The IP comes from
+// X-Forwarded-For only when the request came through one of the trusted
+// proxies the router is given, otherwise it is the address of the peer.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(WithRequest(c.Request.Context(), Request{ID: id, IP: c.ClientIP()}))
+		c.Next()
+	}
+}
+
+// SetActor records who the request was authenticated as.
+func SetActor(c *gin.Context, actor, impersonator string) {
+	request := RequestFrom(c.Request.Context())
+	request.Actor, request.Impersonator = actor, impersonator
+	c.Request = c.Request.WithContext(WithRequest(c.Request.Context(), request))
+}
+
+// UnaryClientInterceptor sends the request of ctx along with every call.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(outgoing(ctx), method, req, reply, cc, opts...)
+}
+
+// StreamClientInterceptor sends the request of ctx along with every stream.
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(outgoing(ctx), desc, cc, method, opts...)
+}
+
+func outgoing(ctx context.Context) context.Context {
+	request := RequestFrom(ctx)
+	pairs := make([]string, 0, 8)
+	for key, value := range map[string]string{
+		RequestIDKey:    request.ID,
+		ClientIPKey:     request.IP,
+		ActorKey:        request.Actor,
+		ImpersonatorKey: request.Impersonator,
+	} {
+		if value != "" {
+			pairs = append(pairs, key, value)
+		}
+	}
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,89 @@
+package audit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/audit"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRequestID(t *testing.T) {
+	testTabel := map[string]struct {
+		header string
+		assert func(t *testing.T, sent string, request audit.Request)
+	}{
+		"id of the client": {
+			header: "req-1",
+			assert: func(t *testing.T, sent string, request audit.Request) {
+				require.Equal(t, "req-1", sent)
+				require.Equal(t, "req-1", request.ID)
+			},
+		},
+		"no id": {
+			assert: func(t *testing.T, sent string, request audit.Request) {
+				require.Len(t, sent, 32)
+				require.Equal(t, sent, request.ID)
+			},
+		},
+		"unprintable id": {
+			header: "req 1\t<script>",
+			assert: func(t *testing.T, sent string, request audit.Request) {
+				require.Len(t, sent, 32)
+				require.Equal(t, sent, request.ID)
+			},
+		},
+	}
+	for k, v := range testTabel {
+		t.Run(k, func(t *testing.T) {
+			var request audit.Request
+			m := gin.New()
+			m.Use(audit.RequestID())
+			m.GET("/", func(c *gin.Context) {
+				audit.SetActor(c, "jane@mail.com", "root")
+				request = audit.RequestFrom(c.Request.Context())
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if v.header != "" {
+				req.Header.Set(audit.RequestIDHeader, v.header)
+			}
+			req.RemoteAddr = "10.0.0.1:5000"
+			rr := httptest.NewRecorder()
+			m.ServeHTTP(rr, req)
+
+			require.Equal(t, "10.0.0.1", request.IP)
+			require.Equal(t, "jane@mail.com", request.Actor)
+			require.Equal(t, "root", request.Impersonator)
+			v.assert(t, rr.Header().Get(audit.RequestIDHeader), request)
+		})
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	var md metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+
+	ctx := audit.WithRequest(context.Background(), audit.Request{ID: "req-1", IP: "10.0.0.1", Actor: "jane@mail.com"})
+	// a job going on after the request still names it
+	ctx = audit.Detach(ctx)
+	err := audit.UnaryClientInterceptor(ctx, "/models.UserService/Update", nil, nil, nil, invoker)
+	require.NoError(t, err)
+	require.Equal(t, []string{"req-1"}, md.Get(audit.RequestIDKey))
+	require.Equal(t, []string{"10.0.0.1"}, md.Get(audit.ClientIPKey))
+	require.Equal(t, []string{"jane@mail.com"}, md.Get(audit.ActorKey))
+	require.Empty(t, md.Get(audit.ImpersonatorKey))
+
+	md = nil
+	err = audit.UnaryClientInterceptor(context.Background(), "/models.UserService/Update", nil, nil, nil, invoker)
+	require.NoError(t, err)
+	require.Empty(t, md)
+}
@@ -1,19 +1,87 @@
 package authentication
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"firebase.google.com/go/auth"
 	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/audit"
 )
 
+// EmailKey is the context key holding the email of the verified user.
+const EmailKey = "email"
+
+// SessionKey is the context key holding the session id when the request came
+// with an access token of the user service.
+const SessionKey = "session"
+
+// sessionTokenPrefix marks the access tokens the user service issues at
+// login, every other bearer token is taken for a Firebase ID token.
+const sessionTokenPrefix = "at_"
+
+// ImpersonatorKey is the context key holding the username of the admin that
+// acts as the user, set only for impersonated sessions.
+const ImpersonatorKey = "impersonator"
+
+// passwordResetRoute is all a session whose user must choose a new password
+// may call.
+const passwordResetRoute = http.MethodPatch + " /auth/user"
+
+// Session is what the user service knows of a verified access token.
+type Session struct {
+	ID    int64
+	Email string
+	// Impersonator is the username of the admin that started the session,
+	// empty for a login.
+	Impersonator          string
+	PasswordResetRequired bool
+}
+
+// SessionVerifier checks an access token of the user service, it fails once
+// the session is revoked. VerifyAccount checks the account a Firebase ID token
+// signs in to the same way, it fails once the account is suspended, deleted
+// or deactivated.
+type SessionVerifier interface {
+	VerifySession(ctx context.Context, token string) (Session, error)
+	VerifyAccount(ctx context.Context, email string) (Session, error)
+}
+
+// IDTokenVerifier checks a Firebase ID token, *auth.Client is one.
+type IDTokenVerifier interface {
+	VerifyIDTokenAndCheckRevoked(ctx context.Context, idToken string) (*auth.Token, error)
+}
+
+// ApiKeyKey is the context key holding the ApiKey a store integration
+// authenticated with.
+const ApiKeyKey = "apiKey"
+
+// ApiKey is what the product service knows of a verified key. Requests made
+// with it act for the owner of the store.
+type ApiKey struct {
+	ID         int64
+	StoreID    int64
+	OwnerEmail string
+	Scopes     []string
+}
+
+// ApiKeyVerifier checks a store API key, it fails once the key is revoked or
+// expired.
+type ApiKeyVerifier interface {
+	VerifyApiKey(ctx context.Context, key string) (ApiKey, error)
+}
+
 type authentication struct {
-	authClient *auth.Client
+	idTokens IDTokenVerifier
+	sessions SessionVerifier
+	apiKeys  ApiKeyVerifier
 }
 
-func NewAuthentication(auth *auth.Client) *authentication {
-	return &authentication{auth}
+func NewAuthentication(idTokens IDTokenVerifier, sessions SessionVerifier, apiKeys ApiKeyVerifier) *authentication {
+	return &authentication{idTokens: idTokens, sessions: sessions, apiKeys: apiKeys}
 }
 
 func (a *authentication) Authenticate() gin.HandlerFunc {
@@ -26,19 +94,95 @@ func (a *authentication) Authenticate() gin.HandlerFunc {
 			return
 		}
 		idToken := getTokenFromAuthHeader(authHeader)
-
-		// verify the token
-		_, err := a.authClient.VerifyIDToken(c, idToken)
+		var session Session
+		var err error
+		if strings.HasPrefix(idToken, sessionTokenPrefix) {
+			session, err = a.sessions.VerifySession(c, idToken)
+		} else {
+			session, err = a.verifyIDToken(c, idToken)
+		}
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unathorized"})
 			return
 		}
+		if session.PasswordResetRequired && c.Request.Method+" "+c.FullPath() != passwordResetRoute {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "a new password must be set first", "passwordResetRequired": true})
+			return
+		}
+		c.Set(EmailKey, session.Email)
+		c.Set(SessionKey, session.ID)
+		if session.Impersonator != "" {
+			c.Set(ImpersonatorKey, session.Impersonator)
+		}
+		audit.SetActor(c, session.Email, session.Impersonator)
 
 		// continue to the next handler
 		c.Next()
 	}
 }
 
+// verifyIDToken checks a Firebase ID token and then the account it signs in
+// to. Asking Firebase whether the token was revoked costs a round trip on
+// every request, which is taken on purpose: signing out everywhere, a
+// suspension or a forced password reset revoke the user's Firebase tokens,
+// and the token is refused on its next request instead of when it expires up
+// to an hour later.
+func (a *authentication) verifyIDToken(ctx context.Context, idToken string) (Session, error) {
+	token, err := a.idTokens.VerifyIDTokenAndCheckRevoked(ctx, idToken)
+	if err != nil {
+		return Session{}, err
+	}
+	email, _ := token.Claims["email"].(string)
+	if email == "" {
+		return Session{}, errors.New("the token carries no email")
+	}
+	return a.sessions.VerifyAccount(ctx, email)
+}
+
+// AuthenticateStore guards the routes of a store an integration may call. It
+// takes an "Authorization: ApiKey ..." header granted the scope for the
+// store in the path, any other request goes through Authenticate.
+func (a *authentication) AuthenticateStore(scope string) gin.HandlerFunc {
+	authenticate := a.Authenticate()
+	return func(c *gin.Context) {
+		key := getApiKeyFromAuthHeader(c.GetHeader("Authorization"))
+		if key == "" {
+			authenticate(c)
+			return
+		}
+		apiKey, err := a.apiKeys.VerifyApiKey(c, key)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unathorized"})
+			return
+		}
+		if strconv.FormatInt(apiKey.StoreID, 10) != c.Param("storeId") || !hasScope(apiKey.Scopes, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "api key is not allowed to do this"})
+			return
+		}
+		c.Set(EmailKey, apiKey.OwnerEmail)
+		c.Set(ApiKeyKey, apiKey)
+		audit.SetActor(c, "api-key:"+strconv.FormatInt(apiKey.ID, 10), "")
+		c.Next()
+	}
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func getApiKeyFromAuthHeader(header string) string {
+	prefix := "ApiKey "
+	if len(header) > len(prefix) && header[:len(prefix)] == prefix {
+		return strings.TrimSpace(header[len(prefix):])
+	}
+	return ""
+}
+
 func getTokenFromAuthHeader(header string) string {
 	prefix := "Bearer "
 	if len(header) > len(prefix) && header[:len(prefix)] == prefix {
@@ -46,3 +190,21 @@ func getTokenFromAuthHeader(header string) string {
 	}
 	return ""
 }
+
+// Email returns the email of the user verified by Authenticate, or an empty
+// string on routes that are not authenticated.
+func Email(c *gin.Context) string {
+	return c.GetString(EmailKey)
+}
+
+// Impersonator returns the username of the admin acting as the user, empty
+// unless the request came with an impersonated session.
+func Impersonator(c *gin.Context) string {
+	return c.GetString(ImpersonatorKey)
+}
+
+// SessionID returns the session of the access token the request came with,
+// zero when it came with a Firebase ID token.
+func SessionID(c *gin.Context) int64 {
+	return c.GetInt64(SessionKey)
+}
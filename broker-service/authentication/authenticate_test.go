@@ -0,0 +1,199 @@
+package authentication_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"firebase.google.com/go/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSessions knows a live session, one started by an admin and one whose
+// user must set a new password, every other token is revoked.
+type fakeSessions struct{}
+
+func (fakeSessions) VerifySession(ctx context.Context, token string) (authentication.Session, error) {
+	switch token {
+	case "at_live":
+		return authentication.Session{ID: 7, Email: "owner@mail.com"}, nil
+	case "at_impersonated":
+		return authentication.Session{ID: 8, Email: "owner@mail.com", Impersonator: "admin"}, nil
+	case "at_reset":
+		return authentication.Session{ID: 9, Email: "owner@mail.com", PasswordResetRequired: true}, nil
+	}
+	return authentication.Session{}, errors.New("session is revoked")
+}
+
+// VerifyAccount knows the owner and a user who must set a new password, every
+// other account is suspended.
+func (fakeSessions) VerifyAccount(ctx context.Context, email string) (authentication.Session, error) {
+	switch email {
+	case "owner@mail.com":
+		return authentication.Session{Email: email}, nil
+	case "reset@mail.com":
+		return authentication.Session{Email: email, PasswordResetRequired: true}, nil
+	}
+	return authentication.Session{}, errors.New("user is suspended")
+}
+
+// fakeIDTokens takes "id_" and an email for a Firebase ID token of that
+// email, every other token is revoked.
+type fakeIDTokens struct{}
+
+func (fakeIDTokens) VerifyIDTokenAndCheckRevoked(ctx context.Context, idToken string) (*auth.Token, error) {
+	if !strings.HasPrefix(idToken, "id_") {
+		return nil, errors.New("ID token has been revoked")
+	}
+	return &auth.Token{Claims: map[string]interface{}{"email": strings.TrimPrefix(idToken, "id_")}}, nil
+}
+
+// fakeApiKeys knows one key of store 3 that may read the catalog.
+type fakeApiKeys struct{}
+
+func (fakeApiKeys) VerifyApiKey(ctx context.Context, key string) (authentication.ApiKey, error) {
+	if key != "rpk_live" {
+		return authentication.ApiKey{}, errors.New("api key is not valid")
+	}
+	return authentication.ApiKey{ID: 1, StoreID: 3, OwnerEmail: "owner@mail.com", Scopes: []string{"catalog:read"}}, nil
+}
+
+func TestAuthenticateSession(t *testing.T) {
+	m := gin.New()
+	m.GET("/me", authentication.NewAuthentication(nil, fakeSessions{}, fakeApiKeys{}).Authenticate(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"email": authentication.Email(c), "session": authentication.SessionID(c)})
+	})
+	testTable := map[string]struct {
+		header string
+		status int
+	}{
+		"live session":    {header: "Bearer at_live", status: http.StatusOK},
+		"revoked session": {header: "Bearer at_revoked", status: http.StatusUnauthorized},
+		"password reset":  {header: "Bearer at_reset", status: http.StatusForbidden},
+		"no token":        {status: http.StatusUnauthorized},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+			if v.header != "" {
+				req.Header.Set("Authorization", v.header)
+			}
+			rr := httptest.NewRecorder()
+			m.ServeHTTP(rr, req)
+
+			require.Equal(t, v.status, rr.Code)
+			if v.status == http.StatusOK {
+				require.JSONEq(t, `{"email": "owner@mail.com", "session": 7}`, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthenticateIDToken(t *testing.T) {
+	m := gin.New()
+	m.GET("/me", authentication.NewAuthentication(fakeIDTokens{}, fakeSessions{}, fakeApiKeys{}).Authenticate(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"email": authentication.Email(c), "session": authentication.SessionID(c)})
+	})
+	testTable := map[string]struct {
+		header string
+		status int
+	}{
+		"live token":        {header: "Bearer id_owner@mail.com", status: http.StatusOK},
+		"revoked token":     {header: "Bearer revoked", status: http.StatusUnauthorized},
+		"suspended account": {header: "Bearer id_banned@mail.com", status: http.StatusUnauthorized},
+		"password reset":    {header: "Bearer id_reset@mail.com", status: http.StatusForbidden},
+		"no email":          {header: "Bearer id_", status: http.StatusUnauthorized},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", v.header)
+			rr := httptest.NewRecorder()
+			m.ServeHTTP(rr, req)
+
+			require.Equal(t, v.status, rr.Code)
+			if v.status == http.StatusOK {
+				require.JSONEq(t, `{"email": "owner@mail.com", "session": 0}`, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthenticateStore(t *testing.T) {
+	auth := authentication.NewAuthentication(nil, fakeSessions{}, fakeApiKeys{})
+	m := gin.New()
+	m.GET("/stores/:storeId/products/export", auth.AuthenticateStore("catalog:read"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"email": authentication.Email(c)})
+	})
+	m.POST("/stores/:storeId/products/import", auth.AuthenticateStore("catalog:write"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"email": authentication.Email(c)})
+	})
+	testTable := map[string]struct {
+		method string
+		uri    string
+		header string
+		status int
+	}{
+		"api key":           {method: http.MethodGet, uri: "/stores/3/products/export", header: "ApiKey rpk_live", status: http.StatusOK},
+		"revoked api key":   {method: http.MethodGet, uri: "/stores/3/products/export", header: "ApiKey rpk_revoked", status: http.StatusUnauthorized},
+		"another store":     {method: http.MethodGet, uri: "/stores/4/products/export", header: "ApiKey rpk_live", status: http.StatusForbidden},
+		"scope not granted": {method: http.MethodPost, uri: "/stores/3/products/import", header: "ApiKey rpk_live", status: http.StatusForbidden},
+		"bearer token":      {method: http.MethodPost, uri: "/stores/3/products/import", header: "Bearer at_live", status: http.StatusOK},
+		"no token":          {method: http.MethodGet, uri: "/stores/3/products/export", status: http.StatusUnauthorized},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			req, _ := http.NewRequest(v.method, v.uri, nil)
+			if v.header != "" {
+				req.Header.Set("Authorization", v.header)
+			}
+			rr := httptest.NewRecorder()
+			m.ServeHTTP(rr, req)
+
+			require.Equal(t, v.status, rr.Code)
+			if v.status == http.StatusOK {
+				require.JSONEq(t, `{"email": "owner@mail.com"}`, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthenticateRestrictedSession(t *testing.T) {
+	auth := authentication.NewAuthentication(nil, fakeSessions{}, fakeApiKeys{})
+	m := gin.New()
+	m.PATCH("/auth/user", auth.Authenticate(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"impersonator": authentication.Impersonator(c)})
+	})
+	m.GET("/auth/sessions", auth.Authenticate(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"impersonator": authentication.Impersonator(c)})
+	})
+	testTable := map[string]struct {
+		method string
+		uri    string
+		header string
+		status int
+		body   string
+	}{
+		"new password":          {method: http.MethodPatch, uri: "/auth/user", header: "Bearer at_reset", status: http.StatusOK, body: `{"impersonator": ""}`},
+		"before a new password": {method: http.MethodGet, uri: "/auth/sessions", header: "Bearer at_reset", status: http.StatusForbidden},
+		"impersonated":          {method: http.MethodGet, uri: "/auth/sessions", header: "Bearer at_impersonated", status: http.StatusOK, body: `{"impersonator": "admin"}`},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			req, _ := http.NewRequest(v.method, v.uri, nil)
+			req.Header.Set("Authorization", v.header)
+			rr := httptest.NewRecorder()
+			m.ServeHTTP(rr, req)
+
+			require.Equal(t, v.status, rr.Code)
+			if v.body != "" {
+				require.JSONEq(t, v.body, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package authentication
+
+import (
+	"context"
+
+	"firebase.google.com/go/auth"
+)
+
+// TokenRevoker signs a user out of Firebase, their refresh tokens stop working
+// and the ID tokens issued before are refused.
+type TokenRevoker interface {
+	RevokeTokens(ctx context.Context, email string) error
+}
+
+type firebaseRevoker struct {
+	client *auth.Client
+}
+
+func NewFirebaseRevoker(client *auth.Client) *firebaseRevoker {
+	return &firebaseRevoker{client: client}
+}
+
+func (r *firebaseRevoker) RevokeTokens(ctx context.Context, email string) error {
+	user, err := r.client.GetUserByEmail(ctx, email)
+	if auth.IsUserNotFound(err) {
+		// the user only signs in with the access tokens of the user service
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return r.client.RevokeRefreshTokens(ctx, user.UID)
+}
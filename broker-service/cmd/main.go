@@ -13,7 +13,7 @@ func main() {
 	register := registry.New()
 	appController, close := register.NewAppController()
 	defer close()
-	if err := app.Serve(router.Route(appController)); err != nil {
+	if err := app.Serve(router.Route(appController, app.Cfg.TrustedProxies)); err != nil {
 		log.Fatal(err)
 	}
 }
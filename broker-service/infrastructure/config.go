@@ -9,9 +9,28 @@ type service struct {
 	ServicePort int    `mapstructure:"servicePort"`
 }
 
+type storage struct {
+	// Driver is either "local" or "s3"
+	Driver string `mapstructure:"driver"`
+	// Dir is where the local driver keeps its files
+	Dir       string `mapstructure:"dir"`
+	Endpoint  string `mapstructure:"endpoint"`
+	AccessKey string `mapstructure:"accessKey"`
+	SecretKey string `mapstructure:"secretKey"`
+	Bucket    string `mapstructure:"bucket"`
+	UseSSL    bool   `mapstructure:"useSSL"`
+	// PublicURL is the address clients use to reach the broker's image route
+	PublicURL string `mapstructure:"publicUrl"`
+}
+
 type Config struct {
 	Services map[string]service `mapstructure:"services"`
 	Port     int                `mapstructure:"port"`
+	Storage  storage            `mapstructure:"storage"`
+	// TrustedProxies are the addresses or CIDRs of the load balancers in front
+	// of the broker. Only their X-Forwarded-For names the client, without any
+	// the client is the peer of the connection.
+	TrustedProxies []string `mapstructure:"trustedProxies"`
 }
 
 var config Config
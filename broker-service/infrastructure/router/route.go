@@ -1,45 +1,102 @@
 package router
 
 import (
-	"context"
 	"log"
 	"net/http"
 
-	firebase "firebase.google.com/go"
 	"github.com/gin-gonic/gin"
 	"github.com/spriigan/broker/adapters"
+	"github.com/spriigan/broker/audit"
 	"github.com/spriigan/broker/authentication"
-	"google.golang.org/api/option"
 )
 
-func Route(cont *adapters.AppController) *gin.Engine {
+// Route serves the API. The client IP that login lockouts and the audit log
+// go by is only taken from X-Forwarded-For when the request came through one
+// of trustedProxies.
+func Route(cont *adapters.AppController, trustedProxies []string) *gin.Engine {
 	mux := gin.Default()
-
-	config := firebase.Config{
-		ProjectID: "orbit-app-145b9",
-	}
-	opt := option.WithCredentialsFile("./orbit-app-145b9-firebase-adminsdk-7ycvp-6ab97f8272.json")
-	app, err := firebase.NewApp(context.Background(), &config, opt)
-	if err != nil {
+	if err := mux.SetTrustedProxies(trustedProxies); err != nil {
 		log.Fatal(err)
 	}
+	mux.Use(audit.RequestID())
 
-	authClient, err := app.Auth(context.Background())
-	if err != nil {
-		log.Fatal(err)
-	}
-	auth := authentication.NewAuthentication(authClient)
+	auth := authentication.NewAuthentication(cont.Firebase, cont.Session, cont.ApiKey)
 
 	protected := mux.Group("/auth")
 	protected.Use(auth.Authenticate())
 	{
-		protected.GET("/user", cont.User.FindUsers)
 		protected.GET("/user/:username", cont.User.FindByUsername)
 		protected.DELETE("/user/:username", cont.User.DeleteByUsername)
+		protected.POST("/user/:username/deactivate", cont.User.Deactivate)
+		protected.POST("/user/:username/export", cont.Privacy.Export)
+		protected.POST("/user/:username/erase", cont.Privacy.Erase)
+		protected.GET("/privacy/jobs/:id", cont.Privacy.Job)
+		protected.GET("/privacy/jobs/:id/archive", cont.Privacy.Archive)
 		protected.PATCH("/user", cont.User.Update)
+		protected.POST("/totp", cont.Totp.Enroll)
+		protected.POST("/totp/confirm", cont.Totp.Confirm)
+		protected.POST("/totp/disable", cont.Totp.Disable)
+		protected.GET("/sessions", cont.Session.List)
+		protected.DELETE("/sessions", cont.Session.RevokeAll)
+		protected.DELETE("/sessions/:id", cont.Session.Revoke)
+		protected.GET("/profile", cont.Profile.Get)
+		protected.PATCH("/profile", cont.Profile.Update)
+		protected.POST("/profile/avatar", cont.Profile.UploadAvatar)
+		protected.DELETE("/profile/avatar", cont.Profile.RemoveAvatar)
+		protected.POST("/products/images", cont.Image.Upload)
+		protected.POST("/products/:id/reviews", cont.Review.Create)
+		protected.PATCH("/products/:id/reviews/:reviewId", cont.Review.Update)
+		protected.POST("/stores/:storeId/api-keys", cont.ApiKey.Create)
+		protected.GET("/stores/:storeId/api-keys", cont.ApiKey.List)
+		protected.DELETE("/stores/:storeId/api-keys/:id", cont.ApiKey.Revoke)
+		protected.POST("/orders", cont.Order.Place)
+		protected.POST("/orders/:id/cancel", cont.Order.Cancel)
+		protected.GET("/wishlist", cont.Wishlist.List)
+		protected.POST("/wishlist", cont.Wishlist.Add)
+		protected.DELETE("/wishlist/:productId", cont.Wishlist.Remove)
+		protected.POST("/wishlist/:productId/cart", cont.Wishlist.MoveToCart)
+		protected.GET("/notifications", cont.Notification.List)
+		protected.POST("/notifications/read", cont.Notification.MarkRead)
+		protected.GET("/notifications/preferences", cont.Notification.Preferences)
+		protected.PUT("/notifications/preferences", cont.Notification.UpdatePreferences)
+	}
+	// the user service checks the role of the signed in user for each of these
+	admin := mux.Group("/admin/users")
+	admin.Use(auth.Authenticate())
+	{
+		admin.GET("", cont.Admin.Search)
+		admin.POST("/:username/suspend", cont.Admin.Suspend)
+		admin.POST("/:username/unsuspend", cont.Admin.Unsuspend)
+		admin.POST("/:username/reactivate", cont.Admin.Reactivate)
+		admin.POST("/:username/unlock", cont.Admin.Unlock)
+		admin.POST("/:username/password-reset", cont.Admin.ForcePasswordReset)
+		admin.POST("/:username/impersonate", cont.Admin.Impersonate)
+		admin.PUT("/:username/role", cont.Admin.SetRole)
+	}
+	// only admins may read the audit log, the user service checks the role
+	auditLog := mux.Group("/admin/audit")
+	auditLog.Use(auth.Authenticate())
+	{
+		auditLog.GET("", cont.Admin.AuditLog)
+		auditLog.GET("/export", cont.Admin.ExportAuditLog)
+	}
+	// the routes a store integration may call with an API key as well
+	store := mux.Group("/auth/stores/:storeId")
+	{
+		store.POST("/products/import", auth.AuthenticateStore("catalog:write"), cont.Catalog.Import)
+		store.GET("/products/export", auth.AuthenticateStore("catalog:read"), cont.Catalog.Export)
+		store.POST("/promotions", auth.AuthenticateStore("promotions:write"), cont.Promotion.Create)
+		store.GET("/promotions", auth.AuthenticateStore("promotions:read"), cont.Promotion.List)
 	}
 	public := mux.Group("/public")
 	public.POST("/user", cont.User.Create)
+	public.POST("/login", cont.User.Login)
+	public.POST("/token/refresh", cont.Session.Refresh)
+	public.GET("/user/:username/available", cont.User.CheckUsername)
+	public.GET("/products/search", cont.Product.Search)
+	public.GET("/products/:id/reviews", cont.Review.List)
+	public.GET("/images/*key", cont.Image.Serve)
+	public.POST("/cart/price", cont.Order.PriceCart)
 	public.GET("/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "hello from kubernetes world"})
 	})
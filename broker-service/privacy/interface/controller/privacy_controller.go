@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/privacy"
+	"github.com/spriigan/broker/response"
+)
+
+type PrivacyController interface {
+	Export(ctx *gin.Context)
+	Erase(ctx *gin.Context)
+	Job(ctx *gin.Context)
+	Archive(ctx *gin.Context)
+}
+
+type privacyController struct {
+	service *privacy.Service
+}
+
+type UsernameUri struct {
+	Username string `uri:"username" binding:"required,min=3"`
+}
+
+type JobUri struct {
+	Id string `uri:"id" binding:"required"`
+}
+
+func NewPrivacyController(service *privacy.Service) *privacyController {
+	return &privacyController{service: service}
+}
+
+// Export starts an export of the user's data, the archive can be downloaded
+// once the job is done.
+func (pc *privacyController) Export(c *gin.Context) {
+	pc.start(c, pc.service.StartExport)
+}
+
+// Erase starts wiping the user's personal data, orders are kept.
+func (pc *privacyController) Erase(c *gin.Context) {
+	pc.start(c, pc.service.StartErase)
+}
+
+func (pc *privacyController) Job(c *gin.Context) {
+	var uri JobUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	job, err := pc.service.Job(uri.Id, authentication.Email(c))
+	if err != nil {
+		pc.error(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+func (pc *privacyController) Archive(c *gin.Context) {
+	var uri JobUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	job, archive, err := pc.service.Archive(uri.Id, authentication.Email(c))
+	if err != nil {
+		pc.error(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-data.json"`, job.Username))
+	c.Data(http.StatusOK, "application/json", archive)
+}
+
+func (pc *privacyController) start(c *gin.Context, start func(ctx context.Context, username, requester string) (privacy.Job, error)) {
+	var uri UsernameUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	job, err := start(ctx, uri.Username, authentication.Email(c))
+	if err != nil {
+		pc.error(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": job})
+}
+
+func (pc *privacyController) error(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, privacy.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, privacy.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, privacy.ErrNotReady):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	response.GrpcError(c, err)
+}
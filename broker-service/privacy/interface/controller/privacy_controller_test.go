@@ -0,0 +1,153 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/privacy"
+	"github.com/spriigan/broker/privacy/interface/controller"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/spriigan/broker/user/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type userClient struct {
+	models.UserServiceClient
+	mock.Mock
+}
+
+func (mc *userClient) ExportUserData(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*models.UserData, error) {
+	args := mc.Called(in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserData), args.Error(1)
+}
+
+func (mc *userClient) EraseUser(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(in)
+	return &emptypb.Empty{}, args.Error(0)
+}
+
+type productClient struct {
+	product.ProductServiceClient
+	mock.Mock
+}
+
+func (mc *productClient) ExportCustomerData(ctx context.Context, in *product.CustomerDataRequest, opts ...grpc.CallOption) (*product.CustomerData, error) {
+	args := mc.Called(in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.CustomerData), args.Error(1)
+}
+
+var users *userClient
+var products *productClient
+var mux *gin.Engine
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	users, products = new(userClient), new(productClient)
+	pc := controller.NewPrivacyController(privacy.NewService(users, products))
+	mux = gin.New()
+	protected := mux.Group("/auth", func(c *gin.Context) {
+		c.Set(authentication.EmailKey, "jane@example.com")
+	})
+	protected.POST("/user/:username/export", pc.Export)
+	protected.POST("/user/:username/erase", pc.Erase)
+	protected.GET("/privacy/jobs/:id", pc.Job)
+	protected.GET("/privacy/jobs/:id/archive", pc.Archive)
+	os.Exit(m.Run())
+}
+
+func serve(method, uri string) (*httptest.ResponseRecorder, gin.H) {
+	req, _ := http.NewRequest(method, uri, nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	var res gin.H
+	_ = json.Unmarshal(rr.Body.Bytes(), &res)
+	return rr, res
+}
+
+func TestExport(t *testing.T) {
+	users.On("ExportUserData", &models.Username{Username: "jane"}).
+		Return(&models.UserData{Bio: &models.UserBio{Username: "jane", Email: "jane@example.com"}}, nil).Once()
+	products.On("ExportCustomerData", mock.Anything).Return(&product.CustomerData{}, nil).Once()
+
+	rr, res := serve(http.MethodPost, "/auth/user/jane/export")
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	id := res["data"].(map[string]interface{})["id"].(string)
+
+	require.Eventually(t, func() bool {
+		rr, res := serve(http.MethodGet, "/auth/privacy/jobs/"+id)
+		require.Equal(t, http.StatusOK, rr.Code)
+		return res["data"].(map[string]interface{})["status"] == string(privacy.Done)
+	}, time.Second, 5*time.Millisecond)
+
+	rr, _ = serve(http.MethodGet, "/auth/privacy/jobs/"+id+"/archive")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, `attachment; filename="jane-data.json"`, rr.Header().Get("Content-Disposition"))
+	var archive privacy.Archive
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &archive))
+	require.Equal(t, "jane", archive.Profile.Bio.Username)
+}
+
+func TestStartErrors(t *testing.T) {
+	testTable := map[string]struct {
+		uri     string
+		arrange func(t *testing.T)
+		status  int
+	}{
+		"someone else's data": {
+			uri: "/auth/user/john/erase",
+			arrange: func(t *testing.T) {
+				users.On("ExportUserData", &models.Username{Username: "john"}).
+					Return(&models.UserData{Bio: &models.UserBio{Username: "john", Email: "john@example.com"}}, nil).Once()
+			},
+			status: http.StatusForbidden,
+		},
+		"unknown user": {
+			uri: "/auth/user/nobody/export",
+			arrange: func(t *testing.T) {
+				users.On("ExportUserData", &models.Username{Username: "nobody"}).
+					Return(nil, status.Error(codes.NotFound, "user is not registered yet")).Once()
+			},
+			status: http.StatusNotFound,
+		},
+		"bad uri": {
+			uri:     "/auth/user/jo/export",
+			arrange: func(t *testing.T) {},
+			status:  http.StatusBadRequest,
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			rr, res := serve(http.MethodPost, v.uri)
+
+			require.Equal(t, v.status, rr.Code)
+			require.NotNil(t, res["error"])
+		})
+	}
+}
+
+func TestUnknownJob(t *testing.T) {
+	rr, _ := serve(http.MethodGet, "/auth/privacy/jobs/nope")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	rr, _ = serve(http.MethodGet, "/auth/privacy/jobs/nope/archive")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
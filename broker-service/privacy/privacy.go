@@ -0,0 +1,225 @@
+package privacy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spriigan/broker/audit"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/spriigan/broker/user/user-proto/grpc/models"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrNotReady    = errors.New("the archive is not ready")
+	ErrForbidden   = errors.New("only the user themselves can ask for their data")
+)
+
+type Kind string
+
+const (
+	Export Kind = "export"
+	Erase  Kind = "erase"
+)
+
+type Status string
+
+const (
+	Running Status = "running"
+	Done    Status = "done"
+	Failed  Status = "failed"
+)
+
+// jobTTL is how long a finished job, and the archive it made, is kept.
+const jobTTL = 24 * time.Hour
+
+// Job is a data subject request being carried out in the background.
+type Job struct {
+	ID         string     `json:"id"`
+	Kind       Kind       `json:"kind"`
+	Username   string     `json:"username"`
+	Status     Status     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	requester  string
+	archive    []byte
+}
+
+// Archive is the document a user gets back from an export.
+type Archive struct {
+	ExportedAt time.Time               `json:"exportedAt"`
+	Profile    *models.UserData        `json:"profile"`
+	Orders     []*product.Order        `json:"orders"`
+	Reviews    []*product.Review       `json:"reviews"`
+	Cart       []*product.CartItem     `json:"cart"`
+	Wishlist   []*product.WishlistItem `json:"wishlist"`
+}
+
+// Service runs exports and erasures across the user and product services and
+// keeps track of them in memory.
+type Service struct {
+	users    models.UserServiceClient
+	products product.ProductServiceClient
+	// Timeout bounds the work done for one job.
+	Timeout time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+func NewService(users models.UserServiceClient, products product.ProductServiceClient) *Service {
+	return &Service{
+		users:    users,
+		products: products,
+		Timeout:  time.Minute,
+		jobs:     make(map[string]*Job),
+	}
+}
+
+// StartExport starts gathering everything kept about the user into an
+// archive. Only the user themselves, identified by the requester email, can
+// ask for it.
+func (s *Service) StartExport(ctx context.Context, username, requester string) (Job, error) {
+	profile, err := s.subject(ctx, username, requester)
+	if err != nil {
+		return Job{}, err
+	}
+	return s.start(ctx, Export, username, requester, func(ctx context.Context, job *Job) error {
+		data, err := s.products.ExportCustomerData(ctx, &product.CustomerDataRequest{UserEmail: profile.Bio.Email})
+		if err != nil {
+			return err
+		}
+		archive, err := json.MarshalIndent(Archive{
+			ExportedAt: time.Now().UTC(),
+			Profile:    profile,
+			Orders:     data.Orders,
+			Reviews:    data.Reviews,
+			Cart:       data.Cart,
+			Wishlist:   data.Wishlist,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		job.archive = archive
+		return nil
+	}), nil
+}
+
+// StartErase starts removing the personal data of the user. The product
+// service goes first as it finds the user by the email the user service
+// erases.
+func (s *Service) StartErase(ctx context.Context, username, requester string) (Job, error) {
+	profile, err := s.subject(ctx, username, requester)
+	if err != nil {
+		return Job{}, err
+	}
+	return s.start(ctx, Erase, username, requester, func(ctx context.Context, job *Job) error {
+		_, err := s.products.EraseCustomerData(ctx, &product.CustomerDataRequest{UserEmail: profile.Bio.Email})
+		if err != nil {
+			return err
+		}
+		_, err = s.users.EraseUser(ctx, &models.Username{Username: username})
+		return err
+	}), nil
+}
+
+// Job returns a job started by the requester.
+func (s *Service) Job(id, requester string) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok || job.requester != requester {
+		return Job{}, ErrJobNotFound
+	}
+	return *job, nil
+}
+
+// Archive returns the archive made by a finished export.
+func (s *Service) Archive(id, requester string) (Job, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok || job.requester != requester || job.Kind != Export {
+		return Job{}, nil, ErrJobNotFound
+	}
+	if job.Status != Done {
+		return *job, nil, ErrNotReady
+	}
+	return *job, job.archive, nil
+}
+
+func (s *Service) subject(ctx context.Context, username, requester string) (*models.UserData, error) {
+	profile, err := s.users.ExportUserData(ctx, &models.Username{Username: username})
+	if err != nil {
+		return nil, err
+	}
+	if requester == "" || !strings.EqualFold(profile.Bio.GetEmail(), requester) {
+		return nil, ErrForbidden
+	}
+	return profile, nil
+}
+
+func (s *Service) start(ctx context.Context, kind Kind, username, requester string, work func(ctx context.Context, job *Job) error) Job {
+	job := &Job{
+		ID:        newID(),
+		Kind:      kind,
+		Username:  username,
+		Status:    Running,
+		CreatedAt: time.Now().UTC(),
+		requester: requester,
+	}
+	s.mu.Lock()
+	s.prune(job.CreatedAt)
+	s.jobs[job.ID] = job
+	started := *job
+	s.mu.Unlock()
+
+	go func() {
+		// the job outlives the request but is still recorded as made by it
+		ctx, cancel := context.WithTimeout(audit.Detach(ctx), s.Timeout)
+		defer cancel()
+		// work fills a copy so readers never see a half made job
+		result := started
+		err := work(ctx, &result)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		finished := time.Now().UTC()
+		result.FinishedAt = &finished
+		result.Status = Done
+		if err != nil {
+			log.Printf("%s of %s failed: %v", kind, username, err)
+			result.Status = Failed
+			result.Error = status.Convert(err).Message()
+			result.archive = nil
+		}
+		s.jobs[job.ID] = &result
+	}()
+	return started
+}
+
+// prune drops the jobs that finished more than jobTTL ago.
+func (s *Service) prune(now time.Time) {
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > jobTTL {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,159 @@
+package privacy_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/spriigan/broker/privacy"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/spriigan/broker/user/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// userClient and productClient only implement the calls the service makes.
+type userClient struct {
+	models.UserServiceClient
+	mock.Mock
+}
+
+func (mc *userClient) ExportUserData(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*models.UserData, error) {
+	args := mc.Called(in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserData), args.Error(1)
+}
+
+func (mc *userClient) EraseUser(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(in)
+	return &emptypb.Empty{}, args.Error(0)
+}
+
+type productClient struct {
+	product.ProductServiceClient
+	mock.Mock
+}
+
+func (mc *productClient) ExportCustomerData(ctx context.Context, in *product.CustomerDataRequest, opts ...grpc.CallOption) (*product.CustomerData, error) {
+	args := mc.Called(in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.CustomerData), args.Error(1)
+}
+
+func (mc *productClient) EraseCustomerData(ctx context.Context, in *product.CustomerDataRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(in)
+	return &emptypb.Empty{}, args.Error(0)
+}
+
+var jane = &models.UserData{
+	Bio:       &models.UserBio{Id: 5, Username: "jane", Email: "Jane@example.com"},
+	Addresses: []*models.Address{{City: "Bandung"}},
+}
+
+func finished(t *testing.T, service *privacy.Service, id string) privacy.Job {
+	var job privacy.Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = service.Job(id, "jane@example.com")
+		require.NoError(t, err)
+		return job.Status != privacy.Running
+	}, time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestExport(t *testing.T) {
+	users, products := new(userClient), new(productClient)
+	service := privacy.NewService(users, products)
+	release := make(chan struct{})
+	users.On("ExportUserData", &models.Username{Username: "jane"}).Return(jane, nil).Once()
+	products.On("ExportCustomerData", &product.CustomerDataRequest{UserEmail: "Jane@example.com"}).
+		Run(func(mock.Arguments) { <-release }).
+		Return(&product.CustomerData{Orders: []*product.Order{{Id: 12}}}, nil).Once()
+
+	job, err := service.StartExport(context.Background(), "jane", "jane@example.com")
+	require.NoError(t, err)
+	require.Equal(t, privacy.Running, job.Status)
+	_, _, err = service.Archive(job.ID, "jane@example.com")
+	require.ErrorIs(t, err, privacy.ErrNotReady)
+	_, err = service.Job(job.ID, "john@example.com")
+	require.ErrorIs(t, err, privacy.ErrJobNotFound, "jobs are only shown to who started them")
+
+	close(release)
+	job = finished(t, service, job.ID)
+	require.Equal(t, privacy.Done, job.Status)
+	require.NotNil(t, job.FinishedAt)
+	_, archive, err := service.Archive(job.ID, "jane@example.com")
+	require.NoError(t, err)
+	var doc privacy.Archive
+	require.NoError(t, json.Unmarshal(archive, &doc))
+	require.Equal(t, "jane", doc.Profile.Bio.Username)
+	require.Len(t, doc.Profile.Addresses, 1)
+	require.Len(t, doc.Orders, 1)
+	users.AssertExpectations(t)
+	products.AssertExpectations(t)
+}
+
+func TestExportSomeoneElse(t *testing.T) {
+	users, products := new(userClient), new(productClient)
+	service := privacy.NewService(users, products)
+	users.On("ExportUserData", mock.Anything).Return(jane, nil).Once()
+
+	_, err := service.StartExport(context.Background(), "jane", "john@example.com")
+
+	require.ErrorIs(t, err, privacy.ErrForbidden)
+	products.AssertNotCalled(t, "ExportCustomerData", mock.Anything)
+}
+
+func TestErase(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(users *userClient, products *productClient)
+		assert  func(t *testing.T, job privacy.Job)
+	}{
+		"succes call": {
+			arrange: func(users *userClient, products *productClient) {
+				products.On("EraseCustomerData", &product.CustomerDataRequest{UserEmail: "Jane@example.com"}).Return(nil).Once()
+				users.On("EraseUser", &models.Username{Username: "jane"}).Return(nil).Once()
+			},
+			assert: func(t *testing.T, job privacy.Job) {
+				require.Equal(t, privacy.Done, job.Status)
+				require.Empty(t, job.Error)
+			},
+		},
+		"product service fails": {
+			arrange: func(users *userClient, products *productClient) {
+				products.On("EraseCustomerData", mock.Anything).Return(status.Error(codes.Unavailable, "product service is down")).Once()
+			},
+			assert: func(t *testing.T, job privacy.Job) {
+				require.Equal(t, privacy.Failed, job.Status)
+				require.Equal(t, "product service is down", job.Error)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			users, products := new(userClient), new(productClient)
+			service := privacy.NewService(users, products)
+			users.On("ExportUserData", &models.Username{Username: "jane"}).Return(jane, nil).Once()
+			v.arrange(users, products)
+
+			job, err := service.StartErase(context.Background(), "jane", "jane@example.com")
+			require.NoError(t, err)
+			require.Equal(t, privacy.Erase, job.Kind)
+
+			v.assert(t, finished(t, service, job.ID))
+			_, _, err = service.Archive(job.ID, "jane@example.com")
+			require.ErrorIs(t, err, privacy.ErrJobNotFound, "an erasure has no archive")
+			users.AssertExpectations(t)
+			products.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+)
+
+var ErrInvalidFile = errors.New("invalid product file")
+
+// Columns is the header of an exported file. An imported file must have the
+// required columns and may leave out the others, in any order.
+var Columns = []string{"sku", "name", "description", "price", "currency", "stock", "image_url", "category_id"}
+
+var requiredColumns = []string{"sku", "name", "price", "currency"}
+
+// ReadCSV hands every line of a csv file with a header to fn. Lines whose
+// numbers cannot be parsed are not handed over but returned as failed results,
+// so they can be reported next to the ones the product service rejects.
+func ReadCSV(r io.Reader, fn func(row *product.ProductRow) error) ([]*product.ImportRowResult, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	index, err := columnIndex(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var failed []*product.ImportRowResult
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return failed, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := index[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := product.ProductRow{
+			Line:        int32(line),
+			Sku:         field("sku"),
+			Name:        field("name"),
+			Description: field("description"),
+			Price:       field("price"),
+			Currency:    field("currency"),
+			ImageUrl:    field("image_url"),
+		}
+		var errs []string
+		if stock := field("stock"); stock != "" {
+			n, err := strconv.ParseInt(stock, 10, 32)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("stock %q is not a whole number", stock))
+			}
+			row.Stock = int32(n)
+		}
+		if category := field("category_id"); category != "" {
+			n, err := strconv.ParseInt(category, 10, 32)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("category id %q is not a whole number", category))
+			}
+			row.CategoryId = n
+		}
+		if len(errs) > 0 {
+			failed = append(failed, &product.ImportRowResult{
+				Line:   row.Line,
+				Sku:    row.Sku,
+				Action: product.ImportAction_FAILED,
+				Errors: errs,
+			})
+			continue
+		}
+		if err = fn(&row); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func columnIndex(header []string) (map[string]int, error) {
+	known := make(map[string]bool, len(Columns))
+	for _, c := range Columns {
+		known[c] = true
+	}
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(h))
+		// a byte order mark is common in files saved by spreadsheets
+		name = strings.TrimPrefix(name, "\ufeff")
+		if !known[name] {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidFile, h)
+		}
+		if _, ok := index[name]; ok {
+			return nil, fmt.Errorf("%w: column %q is given twice", ErrInvalidFile, name)
+		}
+		index[name] = i
+	}
+	for _, c := range requiredColumns {
+		if _, ok := index[c]; !ok {
+			return nil, fmt.Errorf("%w: column %q is required", ErrInvalidFile, c)
+		}
+	}
+	return index, nil
+}
+
+// ReadJSON hands every element of a json array of rows to fn, numbering them
+// from 1, without loading the whole array at once.
+func ReadJSON(r io.Reader, fn func(row *product.ProductRow) error) error {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return jsonError(err, "expected an array of products")
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("%w: expected an array of products", ErrInvalidFile)
+	}
+	var line int32
+	for decoder.More() {
+		line++
+		var row product.ProductRow
+		if err = decoder.Decode(&row); err != nil {
+			return jsonError(err, fmt.Sprintf("product %d", line))
+		}
+		row.Line = line
+		if err = fn(&row); err != nil {
+			return err
+		}
+	}
+	if _, err = decoder.Token(); err != nil {
+		return jsonError(err, "unterminated array")
+	}
+	return nil
+}
+
+// jsonError tells a malformed file apart from a failed read, such as a body
+// over its size limit, which is returned as is.
+func jsonError(err error, context string) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidFile, context, err)
+	}
+	return err
+}
+
+type CSVWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter writes the header right away so an empty export is still a
+// valid file.
+func NewCSVWriter(w io.Writer) (*CSVWriter, error) {
+	writer := &CSVWriter{w: csv.NewWriter(w)}
+	if err := writer.w.Write(Columns); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (cw *CSVWriter) Write(row *product.ProductRow) error {
+	category := ""
+	if row.CategoryId != 0 {
+		category = strconv.FormatInt(row.CategoryId, 10)
+	}
+	return cw.w.Write([]string{
+		row.Sku,
+		row.Name,
+		row.Description,
+		row.Price,
+		row.Currency,
+		strconv.FormatInt(int64(row.Stock), 10),
+		row.ImageUrl,
+		category,
+	})
+}
+
+func (cw *CSVWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
@@ -0,0 +1,104 @@
+package catalog_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spriigan/broker/product/catalog"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCSV(t *testing.T) {
+	testTable := map[string]struct {
+		file   string
+		assert func(t *testing.T, rows []*product.ProductRow, failed []*product.ImportRowResult, err error)
+	}{
+		"columns in any order": {
+			file: "\ufeffName,SKU,price,currency,stock\nMug,MUG-1,12.50,USD,4\n\"Tee, large\",TEE-L,20,USD,\n",
+			assert: func(t *testing.T, rows []*product.ProductRow, failed []*product.ImportRowResult, err error) {
+				require.NoError(t, err)
+				require.Empty(t, failed)
+				require.Equal(t, []*product.ProductRow{
+					{Line: 2, Sku: "MUG-1", Name: "Mug", Price: "12.50", Currency: "USD", Stock: 4},
+					{Line: 3, Sku: "TEE-L", Name: "Tee, large", Price: "20", Currency: "USD"},
+				}, rows)
+			},
+		},
+		"bad numbers": {
+			file: "sku,name,price,currency,stock,category_id\nMUG-1,Mug,1,USD,many,x\nTEE-1,Tee,1,USD,1,\n",
+			assert: func(t *testing.T, rows []*product.ProductRow, failed []*product.ImportRowResult, err error) {
+				require.NoError(t, err)
+				require.Len(t, rows, 1)
+				require.Len(t, failed, 1)
+				require.Equal(t, int32(2), failed[0].Line)
+				require.Equal(t, product.ImportAction_FAILED, failed[0].Action)
+				require.Len(t, failed[0].Errors, 2)
+			},
+		},
+		"missing column": {
+			file: "sku,name,price\nMUG-1,Mug,1\n",
+			assert: func(t *testing.T, rows []*product.ProductRow, failed []*product.ImportRowResult, err error) {
+				require.ErrorIs(t, err, catalog.ErrInvalidFile)
+			},
+		},
+		"unknown column": {
+			file: "sku,name,price,currency,colour\n",
+			assert: func(t *testing.T, rows []*product.ProductRow, failed []*product.ImportRowResult, err error) {
+				require.ErrorIs(t, err, catalog.ErrInvalidFile)
+			},
+		},
+		"empty file": {
+			file: "",
+			assert: func(t *testing.T, rows []*product.ProductRow, failed []*product.ImportRowResult, err error) {
+				require.ErrorIs(t, err, catalog.ErrInvalidFile)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			var rows []*product.ProductRow
+			failed, err := catalog.ReadCSV(strings.NewReader(v.file), func(row *product.ProductRow) error {
+				rows = append(rows, row)
+				return nil
+			})
+
+			v.assert(t, rows, failed, err)
+		})
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	var rows []*product.ProductRow
+	err := catalog.ReadJSON(strings.NewReader(`[{"sku":"MUG-1","name":"Mug","price":"1","stock":2},{"sku":"TEE-1"}]`), func(row *product.ProductRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, int32(2), rows[0].Stock)
+	require.Equal(t, int32(2), rows[1].Line)
+
+	err = catalog.ReadJSON(strings.NewReader(`{"sku":"MUG-1"}`), func(row *product.ProductRow) error { return nil })
+	require.ErrorIs(t, err, catalog.ErrInvalidFile)
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := catalog.NewCSVWriter(&buf)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(&product.ProductRow{Sku: "MUG-1", Name: "Mug, blue", Price: "12.50", Currency: "USD", Stock: 3}))
+	require.NoError(t, w.Flush())
+
+	require.Equal(t, "sku,name,description,price,currency,stock,image_url,category_id\nMUG-1,\"Mug, blue\",,12.50,USD,3,,\n", buf.String())
+
+	// what is exported can be imported back
+	var rows []*product.ProductRow
+	_, err = catalog.ReadCSV(&buf, func(row *product.ProductRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Mug, blue", rows[0].Name)
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+type ApiKeyPayload struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type ApiKeyUri struct {
+	StoreId int64 `uri:"storeId" binding:"required,min=1"`
+	Id      int64 `uri:"id" binding:"required,min=1"`
+}
@@ -0,0 +1,13 @@
+package domain
+
+type StoreUri struct {
+	StoreId int64 `uri:"storeId" binding:"required,min=1"`
+}
+
+type ImportQuery struct {
+	DryRun bool `form:"dryRun"`
+}
+
+type ExportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv json"`
+}
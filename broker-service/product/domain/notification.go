@@ -0,0 +1,24 @@
+package domain
+
+type NotificationsQuery struct {
+	Unread   bool  `form:"unread"`
+	PageSize int32 `form:"pageSize" binding:"min=0,max=100"`
+	BeforeId int64 `form:"beforeId" binding:"min=0"`
+}
+
+// NotificationsReadPayload names the notifications to mark read, all marks
+// every one of them.
+type NotificationsReadPayload struct {
+	Ids []int64 `json:"ids" binding:"required_without=All,dive,min=1"`
+	All bool    `json:"all"`
+}
+
+type NotificationPreference struct {
+	Category string `json:"category" binding:"required,oneof=account orders wishlist"`
+	Email    *bool  `json:"email" binding:"required"`
+	InApp    *bool  `json:"inApp" binding:"required"`
+}
+
+type NotificationPreferencesPayload struct {
+	Preferences []NotificationPreference `json:"preferences" binding:"required,min=1,dive"`
+}
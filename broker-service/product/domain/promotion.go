@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+type PromotionPayload struct {
+	Name       string     `json:"name" binding:"required,max=200"`
+	Kind       string     `json:"kind" binding:"required,oneof=percentage fixed"`
+	PercentOff string     `json:"percentOff" binding:"required_if=Kind percentage"`
+	AmountOff  int64      `json:"amountOff" binding:"required_if=Kind fixed,min=0"`
+	Currency   string     `json:"currency" binding:"required_if=Kind fixed"`
+	CategoryId int64      `json:"categoryId" binding:"min=0"`
+	CouponCode string     `json:"couponCode" binding:"max=64"`
+	UsageLimit int32      `json:"usageLimit" binding:"min=0"`
+	StartsAt   *time.Time `json:"startsAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+}
+
+type CartItem struct {
+	ProductId int64 `json:"productId" binding:"required,min=1"`
+	VariantId int64 `json:"variantId" binding:"min=0"`
+	Quantity  int32 `json:"quantity" binding:"required,min=1"`
+}
+
+type CartPayload struct {
+	Items      []CartItem `json:"items" binding:"required,min=1,max=100,dive"`
+	CouponCode string     `json:"couponCode" binding:"max=64"`
+}
+
+type OrderUri struct {
+	Id int64 `uri:"id" binding:"required,min=1"`
+}
+
+type CancelOrderPayload struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
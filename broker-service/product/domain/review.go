@@ -0,0 +1,20 @@
+package domain
+
+type ReviewPayload struct {
+	Rating int32  `json:"rating" binding:"required,min=1,max=5"`
+	Body   string `json:"body" binding:"max=5000"`
+}
+
+type ProductUri struct {
+	ProductId int64 `uri:"id" binding:"required,min=1"`
+}
+
+type ReviewUri struct {
+	ProductId int64 `uri:"id" binding:"required,min=1"`
+	ReviewId  int64 `uri:"reviewId" binding:"required,min=1"`
+}
+
+type Page struct {
+	Limit  int32 `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset int32 `form:"offset" binding:"omitempty,min=0"`
+}
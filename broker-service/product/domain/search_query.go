@@ -0,0 +1,14 @@
+package domain
+
+// SearchQuery is bound from the query string of a product search. Prices are
+// given in minor units of currency, the same way the API returns them.
+type SearchQuery struct {
+	Query      string `form:"q" binding:"required"`
+	CategoryId int64  `form:"category" binding:"omitempty,min=1"`
+	StoreId    int64  `form:"store" binding:"omitempty,min=1"`
+	Currency   string `form:"currency" binding:"required_with=MinPrice MaxPrice,omitempty,len=3,uppercase"`
+	MinPrice   int64  `form:"minPrice" binding:"omitempty,min=0"`
+	MaxPrice   int64  `form:"maxPrice" binding:"omitempty,min=0"`
+	Limit      int32  `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset     int32  `form:"offset" binding:"omitempty,min=0"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+type WishlistPayload struct {
+	ProductId int64 `json:"productId" binding:"required,min=1"`
+	VariantId int64 `json:"variantId" binding:"min=0"`
+}
+
+type WishlistUri struct {
+	ProductId int64 `uri:"productId" binding:"required,min=1"`
+}
+
+type WishlistQuery struct {
+	VariantId int64 `form:"variantId" binding:"min=0"`
+}
+
+type MoveToCartPayload struct {
+	VariantId int64 `json:"variantId" binding:"min=0"`
+	Quantity  int32 `json:"quantity" binding:"min=0,max=1000"`
+}
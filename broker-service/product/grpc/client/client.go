@@ -0,0 +1,18 @@
+package client
+
+import (
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"google.golang.org/grpc"
+)
+
+type Close func()
+
+func GrpcClient(addr string, opts ...grpc.DialOption) (product.ProductServiceClient, Close, error) {
+	conn, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	return product.NewProductServiceClient(conn), func() {
+		conn.Close()
+	}, nil
+}
@@ -0,0 +1,118 @@
+package imaging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooLarge        = errors.New("image dimensions are too large")
+)
+
+const (
+	// MaxPixels guards against decompression bombs, a small file can declare
+	// a huge canvas that would be allocated on decode.
+	MaxPixels     = 40_000_000
+	ThumbnailSize = 320
+)
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type Image struct {
+	Data        []byte
+	ContentType string
+	Ext         string
+}
+
+type Processed struct {
+	// Hash is the hex sha256 of the original bytes and makes a stable key.
+	Hash      string
+	Original  Image
+	Thumbnail Image
+}
+
+// Process sniffs the content type from the bytes rather than trusting the
+// client, validates the dimensions and renders a thumbnail whose longest edge
+// is ThumbnailSize.
+func Process(data []byte) (*Processed, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	thumb, err := thumbnail(src, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	return &Processed{
+		Hash:      hex.EncodeToString(sum[:]),
+		Original:  Image{Data: data, ContentType: contentType, Ext: ext},
+		Thumbnail: *thumb,
+	}, nil
+}
+
+func thumbnail(src image.Image, contentType string) (*Image, error) {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > ThumbnailSize || h > ThumbnailSize {
+		if w >= h {
+			w, h = ThumbnailSize, atLeastOne(h*ThumbnailSize/b.Dx())
+		} else {
+			w, h = atLeastOne(w*ThumbnailSize/b.Dy()), ThumbnailSize
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+
+	var buf bytes.Buffer
+	// formats that may carry transparency keep it as png
+	if contentType == "image/png" || contentType == "image/gif" {
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, err
+		}
+		return &Image{Data: buf.Bytes(), ContentType: "image/png", Ext: ".png"}, nil
+	}
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return &Image{Data: buf.Bytes(), ContentType: "image/jpeg", Ext: ".jpg"}, nil
+}
+
+func atLeastOne(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
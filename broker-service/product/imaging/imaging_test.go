@@ -0,0 +1,112 @@
+package imaging_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/spriigan/broker/product/imaging"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil))
+	return buf.Bytes()
+}
+
+// withDimensions rewrites the IHDR chunk of a png so it declares a canvas of
+// w x h without carrying the pixels for it.
+func withDimensions(data []byte, w, h uint32) []byte {
+	out := append([]byte(nil), data...)
+	// 8 byte signature, 4 byte length, then "IHDR" and its 13 byte payload
+	ihdr := out[12 : 12+4+13]
+	binary.BigEndian.PutUint32(ihdr[4:8], w)
+	binary.BigEndian.PutUint32(ihdr[8:12], h)
+	binary.BigEndian.PutUint32(out[12+4+13:], crc32.ChecksumIEEE(ihdr))
+	return out
+}
+
+func TestProcess(t *testing.T) {
+	testTable := map[string]struct {
+		data   func(t *testing.T) []byte
+		assert func(t *testing.T, actual *imaging.Processed, err error)
+	}{
+		"landscape png": {
+			data: func(t *testing.T) []byte { return encodePNG(t, 800, 400) },
+			assert: func(t *testing.T, actual *imaging.Processed, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "image/png", actual.Original.ContentType)
+				require.Equal(t, ".png", actual.Original.Ext)
+				require.Len(t, actual.Hash, 64)
+				cfg, err := png.DecodeConfig(bytes.NewReader(actual.Thumbnail.Data))
+				require.NoError(t, err)
+				require.Equal(t, imaging.ThumbnailSize, cfg.Width)
+				require.Equal(t, imaging.ThumbnailSize/2, cfg.Height)
+			},
+		},
+		"portrait jpeg": {
+			data: func(t *testing.T) []byte { return encodeJPEG(t, 300, 900) },
+			assert: func(t *testing.T, actual *imaging.Processed, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "image/jpeg", actual.Thumbnail.ContentType)
+				cfg, err := jpeg.DecodeConfig(bytes.NewReader(actual.Thumbnail.Data))
+				require.NoError(t, err)
+				require.Equal(t, 106, cfg.Width)
+				require.Equal(t, imaging.ThumbnailSize, cfg.Height)
+			},
+		},
+		"small image is not upscaled": {
+			data: func(t *testing.T) []byte { return encodePNG(t, 40, 20) },
+			assert: func(t *testing.T, actual *imaging.Processed, err error) {
+				require.NoError(t, err)
+				cfg, err := png.DecodeConfig(bytes.NewReader(actual.Thumbnail.Data))
+				require.NoError(t, err)
+				require.Equal(t, 40, cfg.Width)
+			},
+		},
+		"not an image": {
+			data: func(t *testing.T) []byte { return []byte("<html><body>hi</body></html>") },
+			assert: func(t *testing.T, actual *imaging.Processed, err error) {
+				require.ErrorIs(t, err, imaging.ErrUnsupportedType)
+				require.Nil(t, actual)
+			},
+		},
+		"decompression bomb": {
+			data: func(t *testing.T) []byte { return withDimensions(encodePNG(t, 1, 1), 50000, 50000) },
+			assert: func(t *testing.T, actual *imaging.Processed, err error) {
+				require.ErrorIs(t, err, imaging.ErrTooLarge)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			actual, err := imaging.Process(v.data(t))
+			v.assert(t, actual, err)
+		})
+	}
+}
+
+func TestProcessIsStable(t *testing.T) {
+	data := encodePNG(t, 10, 10)
+	first, err := imaging.Process(data)
+	require.NoError(t, err)
+	second, err := imaging.Process(data)
+	require.NoError(t, err)
+	require.Equal(t, first.Hash, second.Hash)
+}
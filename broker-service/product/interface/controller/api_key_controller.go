@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/product/domain"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/spriigan/broker/response"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type ApiKeyController interface {
+	Create(ctx *gin.Context)
+	List(ctx *gin.Context)
+	Revoke(ctx *gin.Context)
+	VerifyApiKey(ctx context.Context, key string) (authentication.ApiKey, error)
+}
+
+// apiKeyController lets the owner of a store manage the keys its
+// integrations call the broker with.
+type apiKeyController struct {
+	client product.ProductServiceClient
+}
+
+func NewApiKeyController(client product.ProductServiceClient) *apiKeyController {
+	return &apiKeyController{client: client}
+}
+
+// VerifyApiKey is what the authentication middleware asks for every request
+// made with a key.
+func (ac *apiKeyController) VerifyApiKey(ctx context.Context, key string) (authentication.ApiKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	principal, err := ac.client.VerifyApiKey(ctx, &product.ApiKeySecret{Key: key})
+	if err != nil {
+		return authentication.ApiKey{}, err
+	}
+	return authentication.ApiKey{
+		ID:         principal.KeyId,
+		StoreID:    principal.StoreId,
+		OwnerEmail: principal.OwnerEmail,
+		Scopes:     principal.Scopes,
+	}, nil
+}
+
+// Create answers with the key itself, it can not be read again afterwards.
+func (ac *apiKeyController) Create(c *gin.Context) {
+	var uri domain.StoreUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var payload domain.ApiKeyPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := &product.ApiKeyPayload{
+		StoreId:    uri.StoreId,
+		OwnerEmail: authentication.Email(c),
+		Name:       payload.Name,
+		Scopes:     payload.Scopes,
+	}
+	if payload.ExpiresAt != nil {
+		req.ExpiresAt = timestamppb.New(*payload.ExpiresAt)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	key, err := ac.client.CreateApiKey(ctx, req)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": key})
+}
+
+func (ac *apiKeyController) List(c *gin.Context) {
+	var uri domain.StoreUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	keys, err := ac.client.ListApiKeys(ctx, &product.StoreApiKeysRequest{
+		StoreId:    uri.StoreId,
+		OwnerEmail: authentication.Email(c),
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	if keys.Keys == nil {
+		keys.Keys = []*product.ApiKey{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": keys.Keys})
+}
+
+func (ac *apiKeyController) Revoke(c *gin.Context) {
+	var uri domain.ApiKeyUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	_, err := ac.client.RevokeApiKey(ctx, &product.ApiKeyRequest{
+		StoreId:    uri.StoreId,
+		OwnerEmail: authentication.Email(c),
+		Id:         uri.Id,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "api key is revoked"})
+}
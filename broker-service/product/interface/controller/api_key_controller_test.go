@@ -0,0 +1,133 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/product/interface/controller"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestApiKeys(t *testing.T) {
+	testTable := map[string]struct {
+		method  string
+		uri     string
+		body    gin.H
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"create": {
+			method: http.MethodPost,
+			uri:    "/auth/stores/3/api-keys",
+			body:   gin.H{"name": "ERP", "scopes": []string{"catalog:read", "catalog:write"}, "expiresAt": "2030-01-01T00:00:00Z"},
+			arrange: func(t *testing.T) {
+				client.On("CreateApiKey", mock.Anything, mock.MatchedBy(func(req *product.ApiKeyPayload) bool {
+					return req.StoreId == 3 && req.OwnerEmail == "jane@example.com" && req.Name == "ERP" &&
+						len(req.Scopes) == 2 && req.ExpiresAt.AsTime().Year() == 2030
+				})).Return(&product.ApiKey{Id: 1, Prefix: "rpk_abcdefgh", Key: "rpk_abcdefgh_secret"}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusCreated, statusCode)
+				require.Equal(t, "rpk_abcdefgh_secret", data["data"].(map[string]interface{})["key"])
+			},
+		},
+		"create without scopes": {
+			method:  http.MethodPost,
+			uri:     "/auth/stores/3/api-keys",
+			body:    gin.H{"name": "ERP"},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+		"create for someone else's store": {
+			method: http.MethodPost,
+			uri:    "/auth/stores/4/api-keys",
+			body:   gin.H{"name": "ERP", "scopes": []string{"catalog:read"}},
+			arrange: func(t *testing.T) {
+				client.On("CreateApiKey", mock.Anything, mock.Anything).
+					Return(nil, status.Error(codes.PermissionDenied, "only the owner of the store can do this")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusForbidden, statusCode)
+			},
+		},
+		"list": {
+			method: http.MethodGet,
+			uri:    "/auth/stores/3/api-keys",
+			arrange: func(t *testing.T) {
+				client.On("ListApiKeys", mock.Anything, &product.StoreApiKeysRequest{StoreId: 3, OwnerEmail: "jane@example.com"}).
+					Return(&product.ApiKeys{}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Equal(t, []interface{}{}, data["data"])
+			},
+		},
+		"revoke": {
+			method: http.MethodDelete,
+			uri:    "/auth/stores/3/api-keys/1",
+			arrange: func(t *testing.T) {
+				client.On("RevokeApiKey", mock.Anything, &product.ApiKeyRequest{StoreId: 3, OwnerEmail: "jane@example.com", Id: 1}).
+					Return(&emptypb.Empty{}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+			},
+		},
+		"revoke an unknown key": {
+			method: http.MethodDelete,
+			uri:    "/auth/stores/3/api-keys/9",
+			arrange: func(t *testing.T) {
+				client.On("RevokeApiKey", mock.Anything, mock.Anything).
+					Return(nil, status.Error(codes.NotFound, "api key not found")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusNotFound, statusCode)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			var body bytes.Buffer
+			if v.body != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(v.body))
+			}
+			req, _ := http.NewRequest(v.method, v.uri, &body)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+	client.AssertExpectations(t)
+}
+
+func TestVerifyApiKey(t *testing.T) {
+	ac := controller.NewApiKeyController(client)
+	client.On("VerifyApiKey", mock.Anything, &product.ApiKeySecret{Key: "rpk_abcdefgh_secret"}).
+		Return(&product.ApiKeyPrincipal{KeyId: 1, StoreId: 3, OwnerEmail: "jane@example.com", Scopes: []string{"catalog:read"}}, nil).Once()
+	key, err := ac.VerifyApiKey(context.Background(), "rpk_abcdefgh_secret")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), key.StoreID)
+	require.Equal(t, "jane@example.com", key.OwnerEmail)
+
+	client.On("VerifyApiKey", mock.Anything, &product.ApiKeySecret{Key: "rpk_revoked0_secret"}).
+		Return(nil, status.Error(codes.Unauthenticated, "api key is not valid")).Once()
+	_, err = ac.VerifyApiKey(context.Background(), "rpk_revoked0_secret")
+	require.Error(t, err)
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/product/catalog"
+	"github.com/spriigan/broker/product/domain"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/spriigan/broker/response"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const MaxImportSize = 10 << 20
+
+type CatalogController interface {
+	Import(ctx *gin.Context)
+	Export(ctx *gin.Context)
+}
+
+type catalogController struct {
+	client product.ProductServiceClient
+}
+
+func NewCatalogController(client product.ProductServiceClient) *catalogController {
+	return &catalogController{client: client}
+}
+
+// Import streams the rows of an uploaded csv file, or of a json array sent as
+// the body, to the product service and responds with the result of every row.
+func (cc *catalogController) Import(c *gin.Context) {
+	var uri domain.StoreUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var query domain.ImportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportSize)
+	read := func(fn func(row *product.ProductRow) error) ([]*product.ImportRowResult, error) {
+		return nil, catalog.ReadJSON(c.Request.Body, fn)
+	}
+	if c.ContentType() != gin.MIMEJSON {
+		header, err := c.FormFile("file")
+		if err != nil {
+			cc.fileError(c, err)
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+		read = func(fn func(row *product.ProductRow) error) ([]*product.ImportRowResult, error) {
+			return catalog.ReadCSV(file, fn)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Minute)
+	defer cancel()
+	stream, err := cc.client.ImportProducts(ctx)
+	if err != nil {
+		cc.error(c, err)
+		return
+	}
+	// io.EOF from Send means the server ended the stream early, the reason is
+	// returned by CloseAndRecv
+	send := func(req *product.ImportProductsRequest) error {
+		if err := stream.Send(req); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	}
+	err = send(&product.ImportProductsRequest{Payload: &product.ImportProductsRequest_Options{Options: &product.ImportOptions{
+		StoreId:    uri.StoreId,
+		DryRun:     query.DryRun,
+		OwnerEmail: authentication.Email(c),
+	}}})
+	if err != nil {
+		cc.error(c, err)
+		return
+	}
+	failed, err := read(func(row *product.ProductRow) error {
+		return send(&product.ImportProductsRequest{Payload: &product.ImportProductsRequest_Row{Row: row}})
+	})
+	if err != nil {
+		cc.fileError(c, err)
+		return
+	}
+	summary, err := stream.CloseAndRecv()
+	if err != nil {
+		cc.error(c, err)
+		return
+	}
+
+	summary.Failed += int32(len(failed))
+	summary.Results = append(summary.Results, failed...)
+	sort.SliceStable(summary.Results, func(i, j int) bool {
+		return summary.Results[i].Line < summary.Results[j].Line
+	})
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
+
+// Export sends the products of a store as a csv file that Import accepts, or
+// as json when asked for.
+func (cc *catalogController) Export(c *gin.Context) {
+	var uri domain.StoreUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var query domain.ExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Minute)
+	defer cancel()
+	stream, err := cc.client.ExportProducts(ctx, &product.ExportRequest{
+		StoreId:    uri.StoreId,
+		OwnerEmail: authentication.Email(c),
+	})
+	if err != nil {
+		cc.error(c, err)
+		return
+	}
+	// the status of the call arrives with the first message, read it before
+	// committing to a response
+	first, err := stream.Recv()
+	if err != nil && !errors.Is(err, io.EOF) {
+		cc.error(c, err)
+		return
+	}
+
+	if query.Format == "json" {
+		rows := []*product.ProductRow{}
+		for row := first; row != nil; {
+			rows = append(rows, row)
+			row, err = stream.Recv()
+			if err != nil && !errors.Is(err, io.EOF) {
+				cc.error(c, err)
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"data": rows})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="store-%d-products.csv"`, uri.StoreId))
+	c.Status(http.StatusOK)
+	writer, err := catalog.NewCSVWriter(c.Writer)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	for row := first; row != nil; {
+		if err = writer.Write(row); err != nil {
+			break
+		}
+		row, err = stream.Recv()
+		if err != nil {
+			break
+		}
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		// too late to change the status, the file is cut short
+		_ = c.Error(err)
+	}
+	if err = writer.Flush(); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+func (cc *catalogController) fileError(c *gin.Context, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file must not exceed 10MB"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// error answers an import over the row limit with 413, the other failures
+// like every controller.
+func (cc *catalogController) error(c *gin.Context, err error) {
+	if st, ok := status.FromError(err); ok && st.Code() == codes.ResourceExhausted {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": st.Message(), "code": st.Code()})
+		return
+	}
+	response.GrpcError(c, err)
+}
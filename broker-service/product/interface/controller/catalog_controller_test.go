@@ -0,0 +1,268 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// importStream records what the controller sends and answers with summary.
+type importStream struct {
+	grpc.ClientStream
+	sent    []*product.ImportProductsRequest
+	summary *product.ImportSummary
+	err     error
+}
+
+func (s *importStream) Send(req *product.ImportProductsRequest) error {
+	s.sent = append(s.sent, req)
+	return nil
+}
+
+func (s *importStream) CloseAndRecv() (*product.ImportSummary, error) {
+	return s.summary, s.err
+}
+
+// exportStream hands out rows and then ends with err, or io.EOF when nil.
+type exportStream struct {
+	grpc.ClientStream
+	rows []*product.ProductRow
+	err  error
+}
+
+func (s *exportStream) Recv() (*product.ProductRow, error) {
+	if len(s.rows) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	return row, nil
+}
+
+func csvUpload(t *testing.T, file string) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "products.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(file))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return &body, writer.FormDataContentType()
+}
+
+func TestImport(t *testing.T) {
+	file := "sku,name,price,currency,stock\nMUG-1,Mug,12.50,USD,4\nTEE-1,Tee,20,USD,lots\n"
+	testTable := map[string]struct {
+		uri         string
+		body        func(t *testing.T) (*bytes.Buffer, string)
+		arrange     func(t *testing.T) *importStream
+		assert      func(t *testing.T, statusCode int, data gin.H)
+		assertSends func(t *testing.T, sent []*product.ImportProductsRequest)
+	}{
+		"csv upload": {
+			uri:  "/auth/stores/3/products/import?dryRun=true",
+			body: func(t *testing.T) (*bytes.Buffer, string) { return csvUpload(t, file) },
+			arrange: func(t *testing.T) *importStream {
+				stream := &importStream{summary: &product.ImportSummary{
+					DryRun:  true,
+					Created: 1,
+					Results: []*product.ImportRowResult{{Line: 2, Sku: "MUG-1", Action: product.ImportAction_CREATED}},
+				}}
+				client.On("ImportProducts", mock.Anything).Return(stream, nil).Once()
+				return stream
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				summary := data["data"].(map[string]interface{})
+				require.Equal(t, float64(1), summary["failed"])
+				results := summary["results"].([]interface{})
+				require.Len(t, results, 2)
+				require.Equal(t, "TEE-1", results[1].(map[string]interface{})["sku"])
+			},
+			assertSends: func(t *testing.T, sent []*product.ImportProductsRequest) {
+				require.Len(t, sent, 2)
+				opts := sent[0].GetOptions()
+				require.Equal(t, int64(3), opts.StoreId)
+				require.True(t, opts.DryRun)
+				require.Equal(t, "jane@example.com", opts.OwnerEmail)
+				require.Equal(t, "MUG-1", sent[1].GetRow().Sku)
+			},
+		},
+		"json body": {
+			uri: "/auth/stores/3/products/import",
+			body: func(t *testing.T) (*bytes.Buffer, string) {
+				return bytes.NewBufferString(`[{"sku":"MUG-1","name":"Mug","price":"1","currency":"USD"}]`), gin.MIMEJSON
+			},
+			arrange: func(t *testing.T) *importStream {
+				stream := &importStream{summary: &product.ImportSummary{Created: 1}}
+				client.On("ImportProducts", mock.Anything).Return(stream, nil).Once()
+				return stream
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+			},
+			assertSends: func(t *testing.T, sent []*product.ImportProductsRequest) {
+				require.Len(t, sent, 2)
+				require.Equal(t, int32(1), sent[1].GetRow().Line)
+			},
+		},
+		"not the owner": {
+			uri:  "/auth/stores/3/products/import",
+			body: func(t *testing.T) (*bytes.Buffer, string) { return csvUpload(t, file) },
+			arrange: func(t *testing.T) *importStream {
+				stream := &importStream{err: status.Error(codes.PermissionDenied, "only the owner of the store can do this")}
+				client.On("ImportProducts", mock.Anything).Return(stream, nil).Once()
+				return stream
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusForbidden, statusCode)
+			},
+		},
+		"bad header": {
+			uri:  "/auth/stores/3/products/import",
+			body: func(t *testing.T) (*bytes.Buffer, string) { return csvUpload(t, "sku,colour\n") },
+			arrange: func(t *testing.T) *importStream {
+				stream := &importStream{}
+				client.On("ImportProducts", mock.Anything).Return(stream, nil).Once()
+				return stream
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+				require.Contains(t, data["error"], "unknown column")
+			},
+		},
+		"missing file": {
+			uri: "/auth/stores/3/products/import",
+			body: func(t *testing.T) (*bytes.Buffer, string) {
+				return &bytes.Buffer{}, "text/csv"
+			},
+			arrange: func(t *testing.T) *importStream { return nil },
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+		"invalid store": {
+			uri:     "/auth/stores/0/products/import",
+			body:    func(t *testing.T) (*bytes.Buffer, string) { return csvUpload(t, file) },
+			arrange: func(t *testing.T) *importStream { return nil },
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			stream := v.arrange(t)
+
+			body, contentType := v.body(t)
+			req, _ := http.NewRequest(http.MethodPost, v.uri, body)
+			req.Header.Set("Content-Type", contentType)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+			if v.assertSends != nil {
+				v.assertSends(t, stream.sent)
+			}
+		})
+	}
+	client.AssertExpectations(t)
+}
+
+func TestExport(t *testing.T) {
+	rows := func() []*product.ProductRow {
+		return []*product.ProductRow{
+			{Line: 1, Sku: "MUG-1", Name: "Mug", Price: "12.50", Currency: "USD", Stock: 4},
+			{Line: 2, Sku: "TEE-1", Name: "Tee", Price: "20.00", Currency: "USD"},
+		}
+	}
+	testTable := map[string]struct {
+		uri     string
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, rr *httptest.ResponseRecorder)
+	}{
+		"csv by default": {
+			uri: "/auth/stores/3/products/export",
+			arrange: func(t *testing.T) {
+				client.On("ExportProducts", mock.Anything, &product.ExportRequest{StoreId: 3, OwnerEmail: "jane@example.com"}).
+					Return(&exportStream{rows: rows()}, nil).Once()
+			},
+			assert: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rr.Code)
+				require.Contains(t, rr.Header().Get("Content-Disposition"), "store-3-products.csv")
+				lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+				require.Len(t, lines, 3)
+				require.Equal(t, "MUG-1,Mug,,12.50,USD,4,,", lines[1])
+			},
+		},
+		"json": {
+			uri: "/auth/stores/3/products/export?format=json",
+			arrange: func(t *testing.T) {
+				client.On("ExportProducts", mock.Anything, mock.Anything).Return(&exportStream{rows: rows()}, nil).Once()
+			},
+			assert: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rr.Code)
+				var res gin.H
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&res))
+				require.Len(t, res["data"], 2)
+			},
+		},
+		"empty store": {
+			uri: "/auth/stores/3/products/export",
+			arrange: func(t *testing.T) {
+				client.On("ExportProducts", mock.Anything, mock.Anything).Return(&exportStream{}, nil).Once()
+			},
+			assert: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rr.Code)
+				require.Equal(t, "sku,name,description,price,currency,stock,image_url,category_id\n", rr.Body.String())
+			},
+		},
+		"not the owner": {
+			uri: "/auth/stores/3/products/export",
+			arrange: func(t *testing.T) {
+				client.On("ExportProducts", mock.Anything, mock.Anything).
+					Return(&exportStream{err: status.Error(codes.PermissionDenied, "not the owner")}, nil).Once()
+			},
+			assert: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rr.Code)
+			},
+		},
+		"unknown format": {
+			uri:     "/auth/stores/3/products/export?format=xml",
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rr.Code)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			req, _ := http.NewRequest(http.MethodGet, v.uri, nil)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			v.assert(t, rr)
+		})
+	}
+	client.AssertExpectations(t)
+}
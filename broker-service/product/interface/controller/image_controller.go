@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/product/imaging"
+	"github.com/spriigan/broker/storage"
+)
+
+const (
+	MaxImageSize = 5 << 20
+	imagePrefix  = "products/"
+	avatarPrefix = "avatars/"
+)
+
+type ImageController interface {
+	Upload(ctx *gin.Context)
+	Serve(ctx *gin.Context)
+}
+
+type imageController struct {
+	store   storage.BlobStore
+	baseURL string
+}
+
+// NewImageController serves stored images under baseURL, which must point
+// at the route handled by Serve, e.g. "https://example.com/public/images".
+func NewImageController(store storage.BlobStore, baseURL string) *imageController {
+	return &imageController{store: store, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (ic *imageController) Upload(c *gin.Context) {
+	// leave room for the multipart envelope around the file itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxImageSize+1<<20)
+	header, err := c.FormFile("image")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "image must not exceed 5MB"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if header.Size > MaxImageSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "image must not exceed 5MB"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, MaxImageSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	img, err := imaging.Process(data)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, imaging.ErrUnsupportedType) {
+			status = http.StatusUnsupportedMediaType
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	// keys are derived from the content, so uploading the same image twice
+	// yields the same urls
+	originalKey := imagePrefix + img.Hash + img.Original.Ext
+	thumbnailKey := imagePrefix + img.Hash + "_thumb" + img.Thumbnail.Ext
+	for key, image := range map[string]imaging.Image{originalKey: img.Original, thumbnailKey: img.Thumbnail} {
+		err = ic.store.Put(ctx, key, bytes.NewReader(image.Data), int64(len(image.Data)), image.ContentType)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store image"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data": gin.H{
+			"url":          ic.baseURL + "/" + originalKey,
+			"thumbnailUrl": ic.baseURL + "/" + thumbnailKey,
+			"contentType":  img.Original.ContentType,
+			"size":         len(data),
+		},
+	})
+}
+
+func (ic *imageController) Serve(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if !strings.HasPrefix(key, imagePrefix) && !strings.HasPrefix(key, avatarPrefix) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "image not found"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	body, contentType, err := ic.store.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "image not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read image"})
+		return
+	}
+	defer body.Close()
+
+	// content addressed, the bytes behind a key never change
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.DataFromReader(http.StatusOK, -1, contentType, body, nil)
+}
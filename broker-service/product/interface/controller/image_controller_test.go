@@ -0,0 +1,107 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/product/interface/controller"
+	"github.com/stretchr/testify/require"
+)
+
+func multipartBody(t *testing.T, field string, data []byte) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile(field, "upload.png")
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return &body, w.FormDataContentType()
+}
+
+func pngBytes(t *testing.T) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 640, 480))))
+	return buf.Bytes()
+}
+
+func TestUpload(t *testing.T) {
+	testTable := map[string]struct {
+		field  string
+		data   func(t *testing.T) []byte
+		assert func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"success api call": {
+			field: "image",
+			data:  pngBytes,
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusCreated, statusCode)
+				res := data["data"].(map[string]interface{})
+				url := res["url"].(string)
+				require.True(t, strings.HasPrefix(url, "http://localhost/public/images/products/"))
+				require.True(t, strings.HasSuffix(url, ".png"))
+				require.True(t, strings.HasSuffix(res["thumbnailUrl"].(string), "_thumb.png"))
+
+				rr := httptest.NewRecorder()
+				req, _ := http.NewRequest(http.MethodGet, strings.TrimPrefix(url, "http://localhost"), nil)
+				mux.ServeHTTP(rr, req)
+				require.Equal(t, http.StatusOK, rr.Code)
+				require.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+				require.Equal(t, pngBytes(t), rr.Body.Bytes())
+			},
+		},
+		"wrong content type": {
+			field: "image",
+			data:  func(t *testing.T) []byte { return []byte("#!/bin/sh\necho hi\n") },
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusUnsupportedMediaType, statusCode)
+				require.NotNil(t, data["error"])
+			},
+		},
+		"too large": {
+			field: "image",
+			data:  func(t *testing.T) []byte { return make([]byte, controller.MaxImageSize+1) },
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, statusCode)
+			},
+		},
+		"missing file": {
+			field: "file",
+			data:  pngBytes,
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+	}
+
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			body, contentType := multipartBody(t, v.field, v.data(t))
+			req, _ := http.NewRequest(http.MethodPost, "/auth/products/images", body)
+			req.Header.Set("Content-Type", contentType)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+}
+
+func TestServe(t *testing.T) {
+	for _, uri := range []string{"/public/images/products/missing.png", "/public/images/../../etc/passwd", "/public/images/other/key.png"} {
+		req, _ := http.NewRequest(http.MethodGet, uri, nil)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusNotFound, rr.Code, uri)
+	}
+}
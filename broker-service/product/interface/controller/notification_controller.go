@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/product/domain"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/spriigan/broker/response"
+)
+
+type NotificationController interface {
+	List(ctx *gin.Context)
+	MarkRead(ctx *gin.Context)
+	Preferences(ctx *gin.Context)
+	UpdatePreferences(ctx *gin.Context)
+}
+
+type notificationController struct {
+	client product.ProductServiceClient
+}
+
+func NewNotificationController(client product.ProductServiceClient) *notificationController {
+	return &notificationController{client: client}
+}
+
+// List returns a page of the inbox of the signed in user, newest first, with
+// the number of unread notifications. The next page is asked for with the id
+// of the last notification as beforeId.
+func (nc *notificationController) List(c *gin.Context) {
+	var query domain.NotificationsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	notifications, err := nc.client.ListNotifications(ctx, &product.NotificationsRequest{
+		UserEmail:  authentication.Email(c),
+		UnreadOnly: query.Unread,
+		PageSize:   query.PageSize,
+		BeforeId:   query.BeforeId,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	if notifications.Notifications == nil {
+		notifications.Notifications = []*product.Notification{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": notifications.Notifications, "unread": notifications.Unread})
+}
+
+func (nc *notificationController) MarkRead(c *gin.Context) {
+	var payload domain.NotificationsReadPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	result, err := nc.client.MarkNotificationsRead(ctx, &product.NotificationsRead{
+		UserEmail: authentication.Email(c),
+		Ids:       payload.Ids,
+		All:       payload.All,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"marked": result.Marked, "unread": result.Unread})
+}
+
+func (nc *notificationController) Preferences(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	preferences, err := nc.client.GetNotificationPreferences(ctx, &product.NotificationPreferencesRequest{
+		UserEmail: authentication.Email(c),
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": preferences.Preferences})
+}
+
+// UpdatePreferences turns the channels of the categories in the payload on
+// and off, the other categories are left as they are.
+func (nc *notificationController) UpdatePreferences(c *gin.Context) {
+	var payload domain.NotificationPreferencesPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	update := product.NotificationPreferencesUpdate{
+		UserEmail:   authentication.Email(c),
+		Preferences: make([]*product.NotificationPreference, 0, len(payload.Preferences)),
+	}
+	for _, p := range payload.Preferences {
+		update.Preferences = append(update.Preferences, &product.NotificationPreference{
+			Category: p.Category,
+			Email:    *p.Email,
+			InApp:    *p.InApp,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	preferences, err := nc.client.UpdateNotificationPreferences(ctx, &update)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": preferences.Preferences})
+}
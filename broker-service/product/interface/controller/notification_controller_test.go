@@ -0,0 +1,180 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNotifications(t *testing.T) {
+	preferences := &product.NotificationPreferences{Preferences: []*product.NotificationPreference{
+		{Category: "account", Email: true, InApp: true},
+		{Category: "orders", InApp: true},
+		{Category: "wishlist", Email: true, InApp: true},
+	}}
+	testTable := map[string]struct {
+		method  string
+		uri     string
+		body    gin.H
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"list notifications": {
+			method: http.MethodGet,
+			uri:    "/auth/notifications?unread=true&pageSize=10&beforeId=40",
+			arrange: func(t *testing.T) {
+				client.On("ListNotifications", mock.Anything, &product.NotificationsRequest{
+					UserEmail:  "jane@example.com",
+					UnreadOnly: true,
+					PageSize:   10,
+					BeforeId:   40,
+				}).Return(&product.Notifications{Notifications: []*product.Notification{{Id: 39, Kind: "order_placed"}}, Unread: 3}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Len(t, data["data"], 1)
+				require.Equal(t, float64(3), data["unread"])
+			},
+		},
+		"empty inbox": {
+			method: http.MethodGet,
+			uri:    "/auth/notifications",
+			arrange: func(t *testing.T) {
+				client.On("ListNotifications", mock.Anything, mock.Anything).Return(&product.Notifications{}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.NotNil(t, data["data"])
+				require.Len(t, data["data"], 0)
+			},
+		},
+		"page too big": {
+			method:  http.MethodGet,
+			uri:     "/auth/notifications?pageSize=1000",
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+		"unknown customer": {
+			method: http.MethodGet,
+			uri:    "/auth/notifications",
+			arrange: func(t *testing.T) {
+				client.On("ListNotifications", mock.Anything, mock.Anything).
+					Return(nil, status.Error(codes.PermissionDenied, "customer not found")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusForbidden, statusCode)
+			},
+		},
+		"mark read": {
+			method: http.MethodPost,
+			uri:    "/auth/notifications/read",
+			body:   gin.H{"ids": []int64{3, 4}},
+			arrange: func(t *testing.T) {
+				client.On("MarkNotificationsRead", mock.Anything, &product.NotificationsRead{UserEmail: "jane@example.com", Ids: []int64{3, 4}}).
+					Return(&product.NotificationsReadResult{Marked: 2, Unread: 1}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Equal(t, float64(2), data["marked"])
+				require.Equal(t, float64(1), data["unread"])
+			},
+		},
+		"mark all read": {
+			method: http.MethodPost,
+			uri:    "/auth/notifications/read",
+			body:   gin.H{"all": true},
+			arrange: func(t *testing.T) {
+				client.On("MarkNotificationsRead", mock.Anything, &product.NotificationsRead{UserEmail: "jane@example.com", All: true}).
+					Return(&product.NotificationsReadResult{Marked: 5}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+			},
+		},
+		"mark nothing": {
+			method:  http.MethodPost,
+			uri:     "/auth/notifications/read",
+			body:    gin.H{},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+				require.NotNil(t, data["error"])
+			},
+		},
+		"get preferences": {
+			method: http.MethodGet,
+			uri:    "/auth/notifications/preferences",
+			arrange: func(t *testing.T) {
+				client.On("GetNotificationPreferences", mock.Anything, &product.NotificationPreferencesRequest{UserEmail: "jane@example.com"}).
+					Return(preferences, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Len(t, data["data"], 3)
+			},
+		},
+		"update preferences": {
+			method: http.MethodPut,
+			uri:    "/auth/notifications/preferences",
+			body:   gin.H{"preferences": []gin.H{{"category": "orders", "email": false, "inApp": true}}},
+			arrange: func(t *testing.T) {
+				client.On("UpdateNotificationPreferences", mock.Anything, &product.NotificationPreferencesUpdate{
+					UserEmail:   "jane@example.com",
+					Preferences: []*product.NotificationPreference{{Category: "orders", InApp: true}},
+				}).Return(preferences, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Len(t, data["data"], 3)
+			},
+		},
+		"update unknown category": {
+			method:  http.MethodPut,
+			uri:     "/auth/notifications/preferences",
+			body:    gin.H{"preferences": []gin.H{{"category": "newsletter", "email": false, "inApp": false}}},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+		"update without channels": {
+			method:  http.MethodPut,
+			uri:     "/auth/notifications/preferences",
+			body:    gin.H{"preferences": []gin.H{{"category": "orders"}}},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+	}
+
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			var body bytes.Buffer
+			if v.body != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(v.body))
+			}
+			req, _ := http.NewRequest(v.method, v.uri, &body)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+	client.AssertExpectations(t)
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/product/domain"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/spriigan/broker/response"
+)
+
+type OrderController interface {
+	PriceCart(ctx *gin.Context)
+	Place(ctx *gin.Context)
+	Cancel(ctx *gin.Context)
+}
+
+type orderController struct {
+	client product.ProductServiceClient
+}
+
+func NewOrderController(client product.ProductServiceClient) *orderController {
+	return &orderController{client: client}
+}
+
+// PriceCart shows what a cart costs with the promotions and coupon that apply
+// to it right now, without placing an order.
+func (oc *orderController) PriceCart(c *gin.Context) {
+	var payload domain.CartPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	price, err := oc.client.PriceCart(ctx, &product.PriceCartRequest{
+		Items:      toCartItems(payload.Items),
+		CouponCode: payload.CouponCode,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": price})
+}
+
+func (oc *orderController) Place(c *gin.Context) {
+	var payload domain.CartPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	order, err := oc.client.PlaceOrder(ctx, &product.PlaceOrderRequest{
+		UserEmail:  authentication.Email(c),
+		Items:      toCartItems(payload.Items),
+		CouponCode: payload.CouponCode,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": order})
+}
+
+// Cancel cancels a pending order of the signed in user, the items go back
+// into stock shortly after.
+func (oc *orderController) Cancel(c *gin.Context) {
+	var uri domain.OrderUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var payload domain.CancelOrderPayload
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	order, err := oc.client.CancelOrder(ctx, &product.OrderCancellation{
+		Id:        uri.Id,
+		UserEmail: authentication.Email(c),
+		Reason:    payload.Reason,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": order})
+}
+
+func toCartItems(items []domain.CartItem) []*product.CartItem {
+	result := make([]*product.CartItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, &product.CartItem{
+			ProductId: item.ProductId,
+			VariantId: item.VariantId,
+			Quantity:  item.Quantity,
+		})
+	}
+	return result
+}
@@ -0,0 +1,155 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestOrders(t *testing.T) {
+	price := &product.CartPrice{
+		Subtotal: &product.Money{MinorUnits: 5000, Currency: "USD"},
+		Discount: &product.Money{MinorUnits: 500, Currency: "USD"},
+		Total:    &product.Money{MinorUnits: 4500, Currency: "USD"},
+	}
+	testTable := map[string]struct {
+		method  string
+		uri     string
+		body    gin.H
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"price cart": {
+			method: http.MethodPost,
+			uri:    "/public/cart/price",
+			body:   gin.H{"items": []gin.H{{"productId": 1, "quantity": 2}, {"productId": 2, "variantId": 4, "quantity": 1}}, "couponCode": "FIVE"},
+			arrange: func(t *testing.T) {
+				client.On("PriceCart", mock.Anything, mock.MatchedBy(func(req *product.PriceCartRequest) bool {
+					return len(req.Items) == 2 && req.Items[1].VariantId == 4 && req.CouponCode == "FIVE"
+				})).Return(price, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				total := data["data"].(map[string]interface{})["total"].(map[string]interface{})
+				require.Equal(t, float64(4500), total["minorUnits"])
+			},
+		},
+		"unknown coupon": {
+			method: http.MethodPost,
+			uri:    "/public/cart/price",
+			body:   gin.H{"items": []gin.H{{"productId": 1, "quantity": 1}}, "couponCode": "NOPE"},
+			arrange: func(t *testing.T) {
+				client.On("PriceCart", mock.Anything, mock.Anything).
+					Return(nil, status.Error(codes.NotFound, "coupon not found")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusNotFound, statusCode)
+			},
+		},
+		"empty cart": {
+			method:  http.MethodPost,
+			uri:     "/public/cart/price",
+			body:    gin.H{"items": []gin.H{}},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+		"zero quantity": {
+			method:  http.MethodPost,
+			uri:     "/public/cart/price",
+			body:    gin.H{"items": []gin.H{{"productId": 1, "quantity": 0}}},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+		"place order": {
+			method: http.MethodPost,
+			uri:    "/auth/orders",
+			body:   gin.H{"items": []gin.H{{"productId": 1, "quantity": 2}}},
+			arrange: func(t *testing.T) {
+				client.On("PlaceOrder", mock.Anything, mock.MatchedBy(func(req *product.PlaceOrderRequest) bool {
+					return req.UserEmail == "jane@example.com" && req.Items[0].Quantity == 2
+				})).Return(&product.Order{Id: 12, Status: "pending", Price: price}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusCreated, statusCode)
+				require.NotNil(t, data["data"])
+			},
+		},
+		"coupon used up": {
+			method: http.MethodPost,
+			uri:    "/auth/orders",
+			body:   gin.H{"items": []gin.H{{"productId": 1, "quantity": 2}}, "couponCode": "FIVE"},
+			arrange: func(t *testing.T) {
+				client.On("PlaceOrder", mock.Anything, mock.Anything).
+					Return(nil, status.Error(codes.FailedPrecondition, "promotion usage limit reached")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusConflict, statusCode)
+			},
+		},
+		"cancel order": {
+			method: http.MethodPost,
+			uri:    "/auth/orders/12/cancel",
+			body:   gin.H{"reason": "changed my mind"},
+			arrange: func(t *testing.T) {
+				client.On("CancelOrder", mock.Anything, mock.MatchedBy(func(req *product.OrderCancellation) bool {
+					return req.Id == 12 && req.UserEmail == "jane@example.com" && req.Reason == "changed my mind"
+				})).Return(&product.Order{Id: 12, Status: "cancelled", Price: price}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Equal(t, "cancelled", data["data"].(map[string]interface{})["status"])
+			},
+		},
+		"cancel without reason": {
+			method: http.MethodPost,
+			uri:    "/auth/orders/13/cancel",
+			arrange: func(t *testing.T) {
+				client.On("CancelOrder", mock.Anything, mock.MatchedBy(func(req *product.OrderCancellation) bool {
+					return req.Id == 13 && req.Reason == ""
+				})).Return(nil, status.Error(codes.FailedPrecondition, "no pending order to cancel")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusConflict, statusCode)
+			},
+		},
+		"cancel invalid id": {
+			method:  http.MethodPost,
+			uri:     "/auth/orders/abc/cancel",
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			var body bytes.Buffer
+			if v.body != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(v.body))
+			}
+			req, _ := http.NewRequest(v.method, v.uri, &body)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+	client.AssertExpectations(t)
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/product/domain"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"google.golang.org/grpc/status"
+)
+
+type ProductController interface {
+	Search(ctx *gin.Context)
+}
+
+type productController struct {
+	client product.ProductServiceClient
+}
+
+func NewProductController(client product.ProductServiceClient) *productController {
+	return &productController{client: client}
+}
+
+func (pc *productController) Search(c *gin.Context) {
+	var query domain.SearchQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := product.SearchRequest{
+		Query:      query.Query,
+		CategoryId: query.CategoryId,
+		StoreId:    query.StoreId,
+		Limit:      query.Limit,
+		Offset:     query.Offset,
+	}
+	if query.MinPrice != 0 {
+		req.MinPrice = &product.Money{MinorUnits: query.MinPrice, Currency: query.Currency}
+	}
+	if query.MaxPrice != 0 {
+		req.MaxPrice = &product.Money{MinorUnits: query.MaxPrice, Currency: query.Currency}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	result, err := pc.client.SearchProducts(ctx, &req)
+	if err != nil {
+		st, ok := status.FromError(err)
+		if !ok {
+			panic(err)
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": st.Message(), "code": st.Code()})
+		return
+	}
+	if result.Hits == nil {
+		result.Hits = []*product.SearchHit{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
@@ -0,0 +1,398 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/product/interface/controller"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/spriigan/broker/storage"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type mockClient struct {
+	mock.Mock
+}
+
+func (mc *mockClient) Create(ctx context.Context, in *product.ProductPayload, opts ...grpc.CallOption) (*product.Product, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Product), args.Error(1)
+}
+
+func (mc *mockClient) AdjustStock(ctx context.Context, in *product.StockAdjustment, opts ...grpc.CallOption) (*product.StockMovement, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.StockMovement), args.Error(1)
+}
+
+func (mc *mockClient) ListStockMovements(ctx context.Context, in *product.StockMovementsRequest, opts ...grpc.CallOption) (*product.StockMovements, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.StockMovements), args.Error(1)
+}
+
+func (mc *mockClient) SearchProducts(ctx context.Context, in *product.SearchRequest, opts ...grpc.CallOption) (*product.SearchResult, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.SearchResult), args.Error(1)
+}
+
+func (mc *mockClient) CreateVariant(ctx context.Context, in *product.VariantPayload, opts ...grpc.CallOption) (*product.Variant, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Variant), args.Error(1)
+}
+
+func (mc *mockClient) ListVariants(ctx context.Context, in *product.ProductId, opts ...grpc.CallOption) (*product.Variants, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Variants), args.Error(1)
+}
+
+func (mc *mockClient) CreateReview(ctx context.Context, in *product.ReviewPayload, opts ...grpc.CallOption) (*product.Review, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Review), args.Error(1)
+}
+
+func (mc *mockClient) UpdateReview(ctx context.Context, in *product.ReviewUpdate, opts ...grpc.CallOption) (*product.Review, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Review), args.Error(1)
+}
+
+func (mc *mockClient) ModerateReview(ctx context.Context, in *product.ReviewModeration, opts ...grpc.CallOption) (*product.Review, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Review), args.Error(1)
+}
+
+func (mc *mockClient) ListReviews(ctx context.Context, in *product.ReviewsRequest, opts ...grpc.CallOption) (*product.Reviews, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Reviews), args.Error(1)
+}
+
+func (mc *mockClient) ImportProducts(ctx context.Context, opts ...grpc.CallOption) (product.ProductService_ImportProductsClient, error) {
+	args := mc.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(product.ProductService_ImportProductsClient), args.Error(1)
+}
+
+func (mc *mockClient) ExportProducts(ctx context.Context, in *product.ExportRequest, opts ...grpc.CallOption) (product.ProductService_ExportProductsClient, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(product.ProductService_ExportProductsClient), args.Error(1)
+}
+
+func (mc *mockClient) CreatePromotion(ctx context.Context, in *product.PromotionPayload, opts ...grpc.CallOption) (*product.Promotion, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Promotion), args.Error(1)
+}
+
+func (mc *mockClient) ListPromotions(ctx context.Context, in *product.StorePromotionsRequest, opts ...grpc.CallOption) (*product.Promotions, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Promotions), args.Error(1)
+}
+
+func (mc *mockClient) PriceCart(ctx context.Context, in *product.PriceCartRequest, opts ...grpc.CallOption) (*product.CartPrice, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.CartPrice), args.Error(1)
+}
+
+func (mc *mockClient) PlaceOrder(ctx context.Context, in *product.PlaceOrderRequest, opts ...grpc.CallOption) (*product.Order, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Order), args.Error(1)
+}
+
+func (mc *mockClient) CancelOrder(ctx context.Context, in *product.OrderCancellation, opts ...grpc.CallOption) (*product.Order, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Order), args.Error(1)
+}
+
+func (mc *mockClient) AddToWishlist(ctx context.Context, in *product.WishlistItemRequest, opts ...grpc.CallOption) (*product.WishlistItem, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.WishlistItem), args.Error(1)
+}
+
+func (mc *mockClient) RemoveFromWishlist(ctx context.Context, in *product.WishlistItemRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (mc *mockClient) ListWishlist(ctx context.Context, in *product.WishlistRequest, opts ...grpc.CallOption) (*product.Wishlist, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Wishlist), args.Error(1)
+}
+
+func (mc *mockClient) ListNotifications(ctx context.Context, in *product.NotificationsRequest, opts ...grpc.CallOption) (*product.Notifications, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Notifications), args.Error(1)
+}
+
+func (mc *mockClient) MarkNotificationsRead(ctx context.Context, in *product.NotificationsRead, opts ...grpc.CallOption) (*product.NotificationsReadResult, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.NotificationsReadResult), args.Error(1)
+}
+
+func (mc *mockClient) GetNotificationPreferences(ctx context.Context, in *product.NotificationPreferencesRequest, opts ...grpc.CallOption) (*product.NotificationPreferences, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.NotificationPreferences), args.Error(1)
+}
+
+func (mc *mockClient) UpdateNotificationPreferences(ctx context.Context, in *product.NotificationPreferencesUpdate, opts ...grpc.CallOption) (*product.NotificationPreferences, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.NotificationPreferences), args.Error(1)
+}
+
+func (mc *mockClient) MoveWishlistItemToCart(ctx context.Context, in *product.WishlistItemRequest, opts ...grpc.CallOption) (*product.CartItem, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.CartItem), args.Error(1)
+}
+
+func (mc *mockClient) ExportCustomerData(ctx context.Context, in *product.CustomerDataRequest, opts ...grpc.CallOption) (*product.CustomerData, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.CustomerData), args.Error(1)
+}
+
+func (mc *mockClient) EraseCustomerData(ctx context.Context, in *product.CustomerDataRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (mc *mockClient) CreateApiKey(ctx context.Context, in *product.ApiKeyPayload, opts ...grpc.CallOption) (*product.ApiKey, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.ApiKey), args.Error(1)
+}
+
+func (mc *mockClient) ListApiKeys(ctx context.Context, in *product.StoreApiKeysRequest, opts ...grpc.CallOption) (*product.ApiKeys, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.ApiKeys), args.Error(1)
+}
+
+func (mc *mockClient) RevokeApiKey(ctx context.Context, in *product.ApiKeyRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (mc *mockClient) VerifyApiKey(ctx context.Context, in *product.ApiKeySecret, opts ...grpc.CallOption) (*product.ApiKeyPrincipal, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.ApiKeyPrincipal), args.Error(1)
+}
+
+var client *mockClient
+var mux *gin.Engine
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	client = new(mockClient)
+	pc := controller.NewProductController(client)
+	dir, err := os.MkdirTemp("", "images")
+	if err != nil {
+		log.Fatal(err)
+	}
+	store, err := storage.NewLocalStore(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ic := controller.NewImageController(store, "http://localhost/public/images/")
+	rc := controller.NewReviewController(client)
+	cc := controller.NewCatalogController(client)
+	prc := controller.NewPromotionController(client)
+	oc := controller.NewOrderController(client)
+	wc := controller.NewWishlistController(client)
+	nc := controller.NewNotificationController(client)
+	ac := controller.NewApiKeyController(client)
+	mux = gin.New()
+	mux.GET("/public/products/search", pc.Search)
+	mux.GET("/public/products/:id/reviews", rc.List)
+	mux.GET("/public/images/*key", ic.Serve)
+	mux.POST("/public/cart/price", oc.PriceCart)
+	protected := mux.Group("/auth", func(c *gin.Context) {
+		c.Set(authentication.EmailKey, "jane@example.com")
+	})
+	protected.POST("/products/images", ic.Upload)
+	protected.POST("/products/:id/reviews", rc.Create)
+	protected.PATCH("/products/:id/reviews/:reviewId", rc.Update)
+	protected.POST("/stores/:storeId/products/import", cc.Import)
+	protected.GET("/stores/:storeId/products/export", cc.Export)
+	protected.POST("/stores/:storeId/promotions", prc.Create)
+	protected.GET("/stores/:storeId/promotions", prc.List)
+	protected.POST("/stores/:storeId/api-keys", ac.Create)
+	protected.GET("/stores/:storeId/api-keys", ac.List)
+	protected.DELETE("/stores/:storeId/api-keys/:id", ac.Revoke)
+	protected.POST("/orders", oc.Place)
+	protected.POST("/orders/:id/cancel", oc.Cancel)
+	protected.GET("/wishlist", wc.List)
+	protected.POST("/wishlist", wc.Add)
+	protected.DELETE("/wishlist/:productId", wc.Remove)
+	protected.POST("/wishlist/:productId/cart", wc.MoveToCart)
+	protected.GET("/notifications", nc.List)
+	protected.POST("/notifications/read", nc.MarkRead)
+	protected.GET("/notifications/preferences", nc.Preferences)
+	protected.PUT("/notifications/preferences", nc.UpdatePreferences)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestSearch(t *testing.T) {
+	result := &product.SearchResult{
+		Hits: []*product.SearchHit{
+			{Product: &product.Product{Name: "MacBook"}},
+		},
+	}
+	testTable := map[string]struct {
+		uri     string
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"success api call": {
+			uri: "/public/products/search?q=macbook&currency=USD&minPrice=100000&limit=10",
+			arrange: func(t *testing.T) {
+				client.On("SearchProducts", mock.Anything, mock.MatchedBy(func(req *product.SearchRequest) bool {
+					return req.Query == "macbook" && req.MinPrice.GetMinorUnits() == 100000 && req.MaxPrice == nil
+				})).Return(result, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.NotNil(t, data["data"])
+			},
+		},
+		"failed call": {
+			uri: "/public/products/search?q=macbook",
+			arrange: func(t *testing.T) {
+				client.On("SearchProducts", mock.Anything, mock.Anything).Return(nil, status.Error(codes.InvalidArgument, "got an error")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+				require.NotNil(t, data["error"])
+			},
+		},
+		"missing query": {
+			uri:     "/public/products/search",
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+				require.NotNil(t, data["error"])
+			},
+		},
+		"price without currency": {
+			uri:     "/public/products/search?q=macbook&minPrice=100",
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+				require.NotNil(t, data["error"])
+			},
+		},
+	}
+
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			req, _ := http.NewRequest(http.MethodGet, v.uri, nil)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+	client.AssertExpectations(t)
+}
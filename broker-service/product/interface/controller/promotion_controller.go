@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/product/domain"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/spriigan/broker/response"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type PromotionController interface {
+	Create(ctx *gin.Context)
+	List(ctx *gin.Context)
+}
+
+type promotionController struct {
+	client product.ProductServiceClient
+}
+
+func NewPromotionController(client product.ProductServiceClient) *promotionController {
+	return &promotionController{client: client}
+}
+
+func (pc *promotionController) Create(c *gin.Context) {
+	var uri domain.StoreUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var payload domain.PromotionPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := &product.PromotionPayload{
+		Name:       payload.Name,
+		PercentOff: payload.PercentOff,
+		StoreId:    uri.StoreId,
+		CategoryId: payload.CategoryId,
+		CouponCode: payload.CouponCode,
+		UsageLimit: payload.UsageLimit,
+		OwnerEmail: authentication.Email(c),
+	}
+	switch payload.Kind {
+	case "percentage":
+		req.Kind = product.PromotionKind_PERCENTAGE
+	case "fixed":
+		req.Kind = product.PromotionKind_FIXED
+		req.AmountOff = &product.Money{MinorUnits: payload.AmountOff, Currency: payload.Currency}
+	}
+	if payload.StartsAt != nil {
+		req.StartsAt = timestamppb.New(*payload.StartsAt)
+	}
+	if payload.ExpiresAt != nil {
+		req.ExpiresAt = timestamppb.New(*payload.ExpiresAt)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	promotion, err := pc.client.CreatePromotion(ctx, req)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": promotion})
+}
+
+func (pc *promotionController) List(c *gin.Context) {
+	var uri domain.StoreUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	promotions, err := pc.client.ListPromotions(ctx, &product.StorePromotionsRequest{
+		StoreId:    uri.StoreId,
+		OwnerEmail: authentication.Email(c),
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	if promotions.Promotions == nil {
+		promotions.Promotions = []*product.Promotion{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": promotions.Promotions})
+}
@@ -0,0 +1,129 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPromotions(t *testing.T) {
+	testTable := map[string]struct {
+		method  string
+		uri     string
+		body    gin.H
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"create coupon": {
+			method: http.MethodPost,
+			uri:    "/auth/stores/3/promotions",
+			body:   gin.H{"name": "Five off", "kind": "fixed", "amountOff": 500, "currency": "USD", "couponCode": "FIVE", "usageLimit": 10},
+			arrange: func(t *testing.T) {
+				client.On("CreatePromotion", mock.Anything, mock.MatchedBy(func(req *product.PromotionPayload) bool {
+					return req.StoreId == 3 && req.OwnerEmail == "jane@example.com" && req.Kind == product.PromotionKind_FIXED &&
+						req.AmountOff.MinorUnits == 500 && req.CouponCode == "FIVE"
+				})).Return(&product.Promotion{Id: 1, CouponCode: "FIVE"}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusCreated, statusCode)
+				require.NotNil(t, data["data"])
+			},
+		},
+		"create percentage promotion": {
+			method: http.MethodPost,
+			uri:    "/auth/stores/3/promotions",
+			body:   gin.H{"name": "Sale", "kind": "percentage", "percentOff": "12.5", "expiresAt": "2030-01-01T00:00:00Z"},
+			arrange: func(t *testing.T) {
+				client.On("CreatePromotion", mock.Anything, mock.MatchedBy(func(req *product.PromotionPayload) bool {
+					return req.Kind == product.PromotionKind_PERCENTAGE && req.PercentOff == "12.5" && req.AmountOff == nil &&
+						req.ExpiresAt.AsTime().Year() == 2030
+				})).Return(&product.Promotion{Id: 2}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusCreated, statusCode)
+			},
+		},
+		"duplicate coupon": {
+			method: http.MethodPost,
+			uri:    "/auth/stores/3/promotions",
+			body:   gin.H{"name": "Five off", "kind": "fixed", "amountOff": 500, "currency": "USD", "couponCode": "FIVE"},
+			arrange: func(t *testing.T) {
+				client.On("CreatePromotion", mock.Anything, mock.Anything).
+					Return(nil, status.Error(codes.AlreadyExists, "coupon code already exists")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusConflict, statusCode)
+			},
+		},
+		"not the owner": {
+			method: http.MethodPost,
+			uri:    "/auth/stores/3/promotions",
+			body:   gin.H{"name": "Sale", "kind": "percentage", "percentOff": "10"},
+			arrange: func(t *testing.T) {
+				client.On("CreatePromotion", mock.Anything, mock.Anything).
+					Return(nil, status.Error(codes.PermissionDenied, "only the owner of the store can do this")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusForbidden, statusCode)
+			},
+		},
+		"fixed without an amount": {
+			method:  http.MethodPost,
+			uri:     "/auth/stores/3/promotions",
+			body:    gin.H{"name": "Nothing off", "kind": "fixed"},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+				require.NotNil(t, data["error"])
+			},
+		},
+		"unknown kind": {
+			method:  http.MethodPost,
+			uri:     "/auth/stores/3/promotions",
+			body:    gin.H{"name": "Free", "kind": "free"},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+		"list promotions": {
+			method: http.MethodGet,
+			uri:    "/auth/stores/3/promotions",
+			arrange: func(t *testing.T) {
+				client.On("ListPromotions", mock.Anything, &product.StorePromotionsRequest{StoreId: 3, OwnerEmail: "jane@example.com"}).
+					Return(&product.Promotions{}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Equal(t, []interface{}{}, data["data"])
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			var body bytes.Buffer
+			if v.body != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(v.body))
+			}
+			req, _ := http.NewRequest(v.method, v.uri, &body)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+	client.AssertExpectations(t)
+}
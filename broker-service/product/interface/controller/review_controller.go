@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/product/domain"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/spriigan/broker/response"
+)
+
+type ReviewController interface {
+	List(ctx *gin.Context)
+	Create(ctx *gin.Context)
+	Update(ctx *gin.Context)
+}
+
+type reviewController struct {
+	client product.ProductServiceClient
+}
+
+func NewReviewController(client product.ProductServiceClient) *reviewController {
+	return &reviewController{client: client}
+}
+
+func (rc *reviewController) List(c *gin.Context) {
+	var uri domain.ProductUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var page domain.Page
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	reviews, err := rc.client.ListReviews(ctx, &product.ReviewsRequest{
+		ProductId: uri.ProductId,
+		Limit:     page.Limit,
+		Offset:    page.Offset,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	if reviews.Reviews == nil {
+		reviews.Reviews = []*product.Review{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": reviews.Reviews})
+}
+
+func (rc *reviewController) Create(c *gin.Context) {
+	var uri domain.ProductUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var payload domain.ReviewPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	review, err := rc.client.CreateReview(ctx, &product.ReviewPayload{
+		ProductId: uri.ProductId,
+		UserEmail: authentication.Email(c),
+		Rating:    payload.Rating,
+		Body:      payload.Body,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": review})
+}
+
+func (rc *reviewController) Update(c *gin.Context) {
+	var uri domain.ReviewUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var payload domain.ReviewPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	review, err := rc.client.UpdateReview(ctx, &product.ReviewUpdate{
+		Id:        uri.ReviewId,
+		UserEmail: authentication.Email(c),
+		Rating:    payload.Rating,
+		Body:      payload.Body,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": review})
+}
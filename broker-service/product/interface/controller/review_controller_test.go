@@ -0,0 +1,119 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestReviews(t *testing.T) {
+	testTable := map[string]struct {
+		method  string
+		uri     string
+		body    gin.H
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"list reviews": {
+			method: http.MethodGet,
+			uri:    "/public/products/3/reviews?limit=5",
+			arrange: func(t *testing.T) {
+				client.On("ListReviews", mock.Anything, &product.ReviewsRequest{ProductId: 3, Limit: 5}).
+					Return(&product.Reviews{Reviews: []*product.Review{{Id: 1}}}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Len(t, data["data"], 1)
+			},
+		},
+		"create review as the verified user": {
+			method: http.MethodPost,
+			uri:    "/auth/products/3/reviews",
+			body:   gin.H{"rating": 5, "body": "great"},
+			arrange: func(t *testing.T) {
+				client.On("CreateReview", mock.Anything, mock.MatchedBy(func(req *product.ReviewPayload) bool {
+					return req.ProductId == 3 && req.UserEmail == "jane@example.com" && req.Rating == 5
+				})).Return(&product.Review{Id: 1, Rating: 5}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusCreated, statusCode)
+				require.NotNil(t, data["data"])
+			},
+		},
+		"second review": {
+			method: http.MethodPost,
+			uri:    "/auth/products/3/reviews",
+			body:   gin.H{"rating": 4},
+			arrange: func(t *testing.T) {
+				client.On("CreateReview", mock.Anything, mock.Anything).
+					Return(nil, status.Error(codes.AlreadyExists, "user already reviewed this product")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusConflict, statusCode)
+			},
+		},
+		"rating out of range": {
+			method:  http.MethodPost,
+			uri:     "/auth/products/3/reviews",
+			body:    gin.H{"rating": 9},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+				require.NotNil(t, data["error"])
+			},
+		},
+		"edit review": {
+			method: http.MethodPatch,
+			uri:    "/auth/products/3/reviews/7",
+			body:   gin.H{"rating": 2, "body": "changed my mind"},
+			arrange: func(t *testing.T) {
+				client.On("UpdateReview", mock.Anything, mock.MatchedBy(func(req *product.ReviewUpdate) bool {
+					return req.Id == 7 && req.UserEmail == "jane@example.com"
+				})).Return(&product.Review{Id: 7, Rating: 2}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+			},
+		},
+		"edit someone else's review": {
+			method: http.MethodPatch,
+			uri:    "/auth/products/3/reviews/8",
+			body:   gin.H{"rating": 2},
+			arrange: func(t *testing.T) {
+				client.On("UpdateReview", mock.Anything, mock.Anything).
+					Return(nil, status.Error(codes.NotFound, "review not found")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusNotFound, statusCode)
+			},
+		},
+	}
+
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			var body bytes.Buffer
+			if v.body != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(v.body))
+			}
+			req, _ := http.NewRequest(v.method, v.uri, &body)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+	client.AssertExpectations(t)
+}
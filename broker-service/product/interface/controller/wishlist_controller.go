@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/product/domain"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/spriigan/broker/response"
+)
+
+type WishlistController interface {
+	List(ctx *gin.Context)
+	Add(ctx *gin.Context)
+	Remove(ctx *gin.Context)
+	MoveToCart(ctx *gin.Context)
+}
+
+type wishlistController struct {
+	client product.ProductServiceClient
+}
+
+func NewWishlistController(client product.ProductServiceClient) *wishlistController {
+	return &wishlistController{client: client}
+}
+
+func (wc *wishlistController) List(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	wishlist, err := wc.client.ListWishlist(ctx, &product.WishlistRequest{UserEmail: authentication.Email(c)})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	if wishlist.Items == nil {
+		wishlist.Items = []*product.WishlistItem{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": wishlist.Items})
+}
+
+func (wc *wishlistController) Add(c *gin.Context) {
+	var payload domain.WishlistPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	item, err := wc.client.AddToWishlist(ctx, &product.WishlistItemRequest{
+		UserEmail: authentication.Email(c),
+		ProductId: payload.ProductId,
+		VariantId: payload.VariantId,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": item})
+}
+
+func (wc *wishlistController) Remove(c *gin.Context) {
+	var uri domain.WishlistUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var query domain.WishlistQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	_, err := wc.client.RemoveFromWishlist(ctx, &product.WishlistItemRequest{
+		UserEmail: authentication.Email(c),
+		ProductId: uri.ProductId,
+		VariantId: query.VariantId,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// MoveToCart takes a saved item off the wishlist and puts it in the cart.
+func (wc *wishlistController) MoveToCart(c *gin.Context) {
+	var uri domain.WishlistUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var payload domain.MoveToCartPayload
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	item, err := wc.client.MoveWishlistItemToCart(ctx, &product.WishlistItemRequest{
+		UserEmail: authentication.Email(c),
+		ProductId: uri.ProductId,
+		VariantId: payload.VariantId,
+		Quantity:  payload.Quantity,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": item})
+}
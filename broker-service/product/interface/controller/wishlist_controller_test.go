@@ -0,0 +1,152 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestWishlist(t *testing.T) {
+	testTable := map[string]struct {
+		method  string
+		uri     string
+		body    gin.H
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"list wishlist": {
+			method: http.MethodGet,
+			uri:    "/auth/wishlist",
+			arrange: func(t *testing.T) {
+				client.On("ListWishlist", mock.Anything, &product.WishlistRequest{UserEmail: "jane@example.com"}).
+					Return(&product.Wishlist{Items: []*product.WishlistItem{{Id: 1, ProductId: 3}}}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Len(t, data["data"], 1)
+			},
+		},
+		"empty wishlist": {
+			method: http.MethodGet,
+			uri:    "/auth/wishlist",
+			arrange: func(t *testing.T) {
+				client.On("ListWishlist", mock.Anything, mock.Anything).Return(&product.Wishlist{}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.NotNil(t, data["data"])
+				require.Len(t, data["data"], 0)
+			},
+		},
+		"add to wishlist": {
+			method: http.MethodPost,
+			uri:    "/auth/wishlist",
+			body:   gin.H{"productId": 3, "variantId": 2},
+			arrange: func(t *testing.T) {
+				client.On("AddToWishlist", mock.Anything, &product.WishlistItemRequest{UserEmail: "jane@example.com", ProductId: 3, VariantId: 2}).
+					Return(&product.WishlistItem{Id: 1, ProductId: 3, VariantId: 2}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusCreated, statusCode)
+				require.NotNil(t, data["data"])
+			},
+		},
+		"add unknown product": {
+			method: http.MethodPost,
+			uri:    "/auth/wishlist",
+			body:   gin.H{"productId": 99},
+			arrange: func(t *testing.T) {
+				client.On("AddToWishlist", mock.Anything, mock.Anything).
+					Return(nil, status.Error(codes.NotFound, "product not found")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusNotFound, statusCode)
+			},
+		},
+		"add without product": {
+			method:  http.MethodPost,
+			uri:     "/auth/wishlist",
+			body:    gin.H{},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+				require.NotNil(t, data["error"])
+			},
+		},
+		"remove from wishlist": {
+			method: http.MethodDelete,
+			uri:    "/auth/wishlist/3?variantId=2",
+			arrange: func(t *testing.T) {
+				client.On("RemoveFromWishlist", mock.Anything, &product.WishlistItemRequest{UserEmail: "jane@example.com", ProductId: 3, VariantId: 2}).
+					Return(&emptypb.Empty{}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusNoContent, statusCode)
+			},
+		},
+		"remove item not saved": {
+			method: http.MethodDelete,
+			uri:    "/auth/wishlist/4",
+			arrange: func(t *testing.T) {
+				client.On("RemoveFromWishlist", mock.Anything, mock.Anything).
+					Return(nil, status.Error(codes.NotFound, "wishlist item not found")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusNotFound, statusCode)
+			},
+		},
+		"move to cart": {
+			method: http.MethodPost,
+			uri:    "/auth/wishlist/3/cart",
+			body:   gin.H{"quantity": 2},
+			arrange: func(t *testing.T) {
+				client.On("MoveWishlistItemToCart", mock.Anything, &product.WishlistItemRequest{UserEmail: "jane@example.com", ProductId: 3, Quantity: 2}).
+					Return(&product.CartItem{ProductId: 3, Quantity: 2}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.NotNil(t, data["data"])
+			},
+		},
+		"move to cart without body": {
+			method: http.MethodPost,
+			uri:    "/auth/wishlist/3/cart",
+			arrange: func(t *testing.T) {
+				client.On("MoveWishlistItemToCart", mock.Anything, &product.WishlistItemRequest{UserEmail: "jane@example.com", ProductId: 3}).
+					Return(&product.CartItem{ProductId: 3, Quantity: 1}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+			},
+		},
+	}
+
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			var body bytes.Buffer
+			if v.body != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(v.body))
+			}
+			req, _ := http.NewRequest(v.method, v.uri, &body)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+	client.AssertExpectations(t)
+}
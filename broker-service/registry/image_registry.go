@@ -0,0 +1,49 @@
+package registry
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/spriigan/broker/infrastructure"
+	"github.com/spriigan/broker/product/interface/controller"
+	"github.com/spriigan/broker/storage"
+)
+
+func (r registry) NewImageController() controller.ImageController {
+	config := infrastructure.LoadConfig()
+	return controller.NewImageController(r.BlobStore(), config.Storage.PublicURL)
+}
+
+func (r registry) BlobStore() storage.BlobStore {
+	config := infrastructure.LoadConfig().Storage
+	switch config.Driver {
+	case "s3":
+		client, err := minio.New(config.Endpoint, &minio.Options{
+			Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+			Secure: config.UseSSL,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		store, err := storage.NewS3Store(ctx, client, config.Bucket)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return store
+	default:
+		dir := config.Dir
+		if dir == "" {
+			dir = "./images"
+		}
+		store, err := storage.NewLocalStore(dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return store
+	}
+}
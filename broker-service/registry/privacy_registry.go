@@ -0,0 +1,12 @@
+package registry
+
+import (
+	"github.com/spriigan/broker/privacy"
+	"github.com/spriigan/broker/privacy/interface/controller"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"github.com/spriigan/broker/user/user-proto/grpc/models"
+)
+
+func (r registry) NewPrivacyController(users models.UserServiceClient, products product.ProductServiceClient) controller.PrivacyController {
+	return controller.NewPrivacyController(privacy.NewService(users, products))
+}
@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spriigan/broker/audit"
+	"github.com/spriigan/broker/infrastructure"
+	"github.com/spriigan/broker/product/grpc/client"
+	"github.com/spriigan/broker/product/interface/controller"
+	"github.com/spriigan/broker/product/product-proto/grpc/product"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func (r registry) NewProductController(c product.ProductServiceClient) controller.ProductController {
+	return controller.NewProductController(c)
+}
+
+func (r registry) NewReviewController(c product.ProductServiceClient) controller.ReviewController {
+	return controller.NewReviewController(c)
+}
+
+func (r registry) NewCatalogController(c product.ProductServiceClient) controller.CatalogController {
+	return controller.NewCatalogController(c)
+}
+
+func (r registry) NewPromotionController(c product.ProductServiceClient) controller.PromotionController {
+	return controller.NewPromotionController(c)
+}
+
+func (r registry) NewOrderController(c product.ProductServiceClient) controller.OrderController {
+	return controller.NewOrderController(c)
+}
+
+func (r registry) NewWishlistController(c product.ProductServiceClient) controller.WishlistController {
+	return controller.NewWishlistController(c)
+}
+
+func (r registry) NewNotificationController(c product.ProductServiceClient) controller.NotificationController {
+	return controller.NewNotificationController(c)
+}
+
+func (r registry) NewApiKeyController(c product.ProductServiceClient) controller.ApiKeyController {
+	return controller.NewApiKeyController(c)
+}
+
+func (r registry) GrpcProductClient() (product.ProductServiceClient, client.Close) {
+	config := infrastructure.LoadConfig()
+	srv := config.Services["productservice"]
+	c, close, err := client.GrpcClient(fmt.Sprintf("%s:%d", srv.Address, srv.ServicePort), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock(),
+		grpc.WithUnaryInterceptor(audit.UnaryClientInterceptor), grpc.WithStreamInterceptor(audit.StreamClientInterceptor))
+	if err != nil {
+		log.Fatal(err)
+	}
+	return c, close
+}
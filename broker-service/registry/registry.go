@@ -2,6 +2,7 @@ package registry
 
 import (
 	"github.com/spriigan/broker/adapters"
+	"github.com/spriigan/broker/authentication"
 	"github.com/spriigan/broker/user/grpc/client"
 )
 
@@ -17,8 +18,29 @@ func New() *registry {
 }
 
 func (r registry) NewAppController() (*adapters.AppController, client.Close) {
-	user, close := r.NewUserController()
-	return &adapters.AppController{User: user}, func() {
-		close()
+	userClient, closeUser := r.GrpcUserClient()
+	productClient, closeProduct := r.GrpcProductClient()
+	firebaseAuth := r.FirebaseAuth()
+	revoker := authentication.NewFirebaseRevoker(firebaseAuth)
+	return &adapters.AppController{
+		User:         r.NewUserController(userClient),
+		Totp:         r.NewTotpController(userClient),
+		Session:      r.NewSessionController(userClient, revoker),
+		Profile:      r.NewProfileController(userClient),
+		Admin:        r.NewAdminController(userClient, revoker),
+		Product:      r.NewProductController(productClient),
+		Review:       r.NewReviewController(productClient),
+		Image:        r.NewImageController(),
+		Catalog:      r.NewCatalogController(productClient),
+		Promotion:    r.NewPromotionController(productClient),
+		Order:        r.NewOrderController(productClient),
+		Wishlist:     r.NewWishlistController(productClient),
+		Notification: r.NewNotificationController(productClient),
+		ApiKey:       r.NewApiKeyController(productClient),
+		Privacy:      r.NewPrivacyController(userClient, productClient),
+		Firebase:     firebaseAuth,
+	}, func() {
+		closeUser()
+		closeProduct()
 	}
 }
@@ -1,10 +1,17 @@
 package registry
 
 import (
+	"context"
 	"fmt"
+	"github.com/spriigan/broker/audit"
+	"github.com/spriigan/broker/authentication"
 	"github.com/spriigan/broker/infrastructure"
 	"log"
 
+	firebase "firebase.google.com/go"
+	"firebase.google.com/go/auth"
+	"google.golang.org/api/option"
+
 	"github.com/spriigan/broker/user/grpc/client"
 	"github.com/spriigan/broker/user/interface/controller"
 	"github.com/spriigan/broker/user/user-proto/grpc/models"
@@ -12,16 +19,49 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 )
 
-func (r registry) NewUserController() (controller.UserController, client.Close) {
-	c, close := r.GrpcUserClient()
-	return controller.NewUserController(c), close
+func (r registry) NewUserController(c models.UserServiceClient) controller.UserController {
+	return controller.NewUserController(c)
+}
+
+func (r registry) NewTotpController(c models.UserServiceClient) controller.TotpController {
+	return controller.NewTotpController(c)
+}
+
+func (r registry) NewSessionController(c models.UserServiceClient, revoker authentication.TokenRevoker) controller.SessionController {
+	return controller.NewSessionController(c, revoker)
+}
+
+func (r registry) NewProfileController(c models.UserServiceClient) controller.ProfileController {
+	config := infrastructure.LoadConfig()
+	return controller.NewProfileController(c, r.BlobStore(), config.Storage.PublicURL)
+}
+
+func (r registry) NewAdminController(c models.UserServiceClient, revoker authentication.TokenRevoker) controller.AdminController {
+	return controller.NewAdminController(c, revoker)
+}
+
+func (r registry) FirebaseAuth() *auth.Client {
+	config := firebase.Config{
+		ProjectID: "orbit-app-145b9",
+	}
+	opt := option.WithCredentialsFile("./orbit-app-145b9-firebase-adminsdk-7ycvp-6ab97f8272.json")
+	app, err := firebase.NewApp(context.Background(), &config, opt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err := app.Auth(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	return client
 }
 
 func (r registry) GrpcUserClient() (models.UserServiceClient, client.Close) {
 	config := infrastructure.LoadConfig()
 	srv := config.Services["userservice"]
 	fmt.Println(config)
-	c, close, err := client.GrpcClient(fmt.Sprintf("%s:%d", srv.Address, srv.ServicePort), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	c, close, err := client.GrpcClient(fmt.Sprintf("%s:%d", srv.Address, srv.ServicePort), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock(),
+		grpc.WithUnaryInterceptor(audit.UnaryClientInterceptor), grpc.WithStreamInterceptor(audit.StreamClientInterceptor))
 	if err != nil {
 		log.Fatal(err)
 	}
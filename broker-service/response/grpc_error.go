@@ -0,0 +1,40 @@
+package response
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HTTPStatus is the status an HTTP client gets for a gRPC call that failed
+// with code, anything the client fixes by changing the request is a 400.
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.FailedPrecondition:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Internal:
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// GrpcError writes the status of a failed gRPC call as the JSON error of the
+// response. It panics on an error that is not a status, the recovery
+// middleware answers those.
+func GrpcError(c *gin.Context, err error) {
+	st, ok := status.FromError(err)
+	if !ok {
+		panic(err)
+	}
+	c.JSON(HTTPStatus(st.Code()), gin.H{"error": st.Message(), "code": st.Code()})
+}
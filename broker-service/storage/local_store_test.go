@@ -0,0 +1,55 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spriigan/broker/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	testBlobStore(t, store)
+
+	err = store.Put(context.Background(), "../outside.txt", strings.NewReader("x"), 1, "text/plain")
+	require.NoError(t, err)
+	_, _, err = store.Get(context.Background(), "outside.txt")
+	require.NoError(t, err, "keys are confined to the store directory")
+
+	err = store.Put(context.Background(), "/", strings.NewReader("x"), 1, "text/plain")
+	require.Error(t, err)
+}
+
+// testBlobStore runs the behaviour every BlobStore implementation must share.
+func testBlobStore(t *testing.T, store storage.BlobStore) {
+	ctx := context.Background()
+	body := "not really a png"
+
+	err := store.Put(ctx, "products/abc.png", strings.NewReader(body), int64(len(body)), "image/png")
+	require.NoError(t, err)
+
+	r, contentType, err := store.Get(ctx, "products/abc.png")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, body, string(data))
+	require.Equal(t, "image/png", contentType)
+
+	err = store.Put(ctx, "products/abc.png", strings.NewReader("replaced"), 8, "image/png")
+	require.NoError(t, err)
+	r, _, err = store.Get(ctx, "products/abc.png")
+	require.NoError(t, err)
+	data, _ = io.ReadAll(r)
+	r.Close()
+	require.Equal(t, "replaced", string(data))
+
+	require.NoError(t, store.Delete(ctx, "products/abc.png"))
+	_, _, err = store.Get(ctx, "products/abc.png")
+	require.ErrorIs(t, err, storage.ErrNotFound)
+	require.ErrorIs(t, store.Delete(ctx, "products/abc.png"), storage.ErrNotFound)
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+)
+
+type s3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store stores blobs in bucket on any S3-compatible service such as
+// MinIO. The bucket is created when it does not exist yet.
+func NewS3Store(ctx context.Context, client *minio.Client, bucket string) (*s3Store, error) {
+	exists, err := client.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err = client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, err
+		}
+	}
+	return &s3Store{client: client, bucket: bucket}, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, "", s.mapError(err)
+	}
+	// GetObject is lazy, Stat issues the request and surfaces a missing key
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, "", s.mapError(err)
+	}
+	return obj, info.ContentType, nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	if _, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{}); err != nil {
+		return s.mapError(err)
+	}
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *s3Store) mapError(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
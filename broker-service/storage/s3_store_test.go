@@ -0,0 +1,59 @@
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/ory/dockertest/v3"
+	"github.com/spriigan/broker/storage"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	minioUser     = "minio"
+	minioPassword = "minio-secret"
+)
+
+func TestS3Store(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err, "cant connect to docker, make sure that docker is running")
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "minio/minio",
+		Tag:        "RELEASE.2023-03-13T19-46-17Z",
+		Cmd:        []string{"server", "/data"},
+		Env: []string{
+			"MINIO_ROOT_USER=" + minioUser,
+			"MINIO_ROOT_PASSWORD=" + minioPassword,
+		},
+	})
+	require.NoError(t, err, "couldn't start minio")
+	defer pool.Purge(resource)
+
+	endpoint := fmt.Sprintf("localhost:%s", resource.GetPort("9000/tcp"))
+	err = pool.Retry(func() error {
+		res, err := http.Get(fmt.Sprintf("http://%s/minio/health/live", endpoint))
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return fmt.Errorf("minio is not ready: %s", res.Status)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds: credentials.NewStaticV4(minioUser, minioPassword, ""),
+	})
+	require.NoError(t, err)
+
+	store, err := storage.NewS3Store(context.Background(), client, "product-images")
+	require.NoError(t, err)
+	testBlobStore(t, store)
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// AdminActionPayload is the reason an admin gives for acting on a user, it is
+// kept with the record of the action.
+type AdminActionPayload struct {
+	Reason string `json:"reason"`
+}
+
+type RolePayload struct {
+	Role   string `json:"role" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// UserSearchQuery filters the users an admin looks through, After is the id
+// of the last user of the previous page.
+type UserSearchQuery struct {
+	Query  string `form:"q"`
+	Role   string `form:"role"`
+	Status string `form:"status"`
+	Limit  int32  `form:"limit" binding:"omitempty,min=1,max=200"`
+	After  int64  `form:"after" binding:"omitempty,min=1"`
+}
+
+// AuditQuery filters the audit log, Actor is who performed the actions and
+// Before the id of the last entry of the previous page. From and To take
+// RFC 3339 times.
+type AuditQuery struct {
+	Actor      string    `form:"actor"`
+	Action     string    `form:"action"`
+	TargetType string    `form:"targetType"`
+	TargetID   string    `form:"targetId"`
+	RequestID  string    `form:"requestId"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit      int32     `form:"limit" binding:"omitempty,min=1,max=200"`
+	Before     int64     `form:"before" binding:"omitempty,min=1"`
+}
@@ -0,0 +1,16 @@
+package domain
+
+// ProfilePayload is a partial update, only the fields sent are changed and
+// sending an empty string clears one.
+type ProfilePayload struct {
+	DisplayName *string               `json:"displayName"`
+	Phone       *string               `json:"phone"`
+	Locale      *string               `json:"locale"`
+	Timezone    *string               `json:"timezone"`
+	Marketing   *MarketingPreferences `json:"marketing"`
+}
+
+type MarketingPreferences struct {
+	Email bool `json:"email"`
+	Sms   bool `json:"sms"`
+}
@@ -0,0 +1,5 @@
+package domain
+
+type RefreshPayload struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
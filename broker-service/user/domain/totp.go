@@ -0,0 +1,15 @@
+package domain
+
+type TotpCode struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type Credentials struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	// Otp is asked for once the password is right when two-factor
+	// authentication is on.
+	Otp string `json:"otp"`
+	// Device names the device in the list of sessions, like "Pixel 7".
+	Device string `json:"device"`
+}
@@ -0,0 +1,372 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/response"
+	"github.com/spriigan/broker/user/domain"
+	"github.com/spriigan/broker/user/user-proto/grpc/models"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type AdminController interface {
+	Search(ctx *gin.Context)
+	Suspend(ctx *gin.Context)
+	Unsuspend(ctx *gin.Context)
+	Reactivate(ctx *gin.Context)
+	Unlock(ctx *gin.Context)
+	ForcePasswordReset(ctx *gin.Context)
+	Impersonate(ctx *gin.Context)
+	SetRole(ctx *gin.Context)
+	AuditLog(ctx *gin.Context)
+	ExportAuditLog(ctx *gin.Context)
+}
+
+// adminController lets staff look after users. The user service decides what
+// the signed in user's role allows, every action is kept with its reason.
+type adminController struct {
+	client  models.UserServiceClient
+	revoker authentication.TokenRevoker
+}
+
+func NewAdminController(client models.UserServiceClient, revoker authentication.TokenRevoker) *adminController {
+	return &adminController{client: client, revoker: revoker}
+}
+
+// Search pages through the users by id, the next page starts after the last
+// id of this one.
+func (ac *adminController) Search(c *gin.Context) {
+	var query domain.UserSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	actor, err := requester(ctx, c, ac.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	accounts, err := ac.client.SearchUsers(ctx, &models.UserSearch{
+		Actor:   actor,
+		Query:   query.Query,
+		Role:    query.Role,
+		Status:  query.Status,
+		Limit:   query.Limit,
+		AfterId: query.After,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	data := make([]gin.H, 0, len(accounts.Accounts))
+	for _, account := range accounts.Accounts {
+		data = append(data, accountJSON(account))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// Suspend ends the user's sessions and signs them out of Firebase.
+func (ac *adminController) Suspend(c *gin.Context) {
+	ac.act(c, ac.client.SuspendUser, "suspended", ac.signOut)
+}
+
+func (ac *adminController) Unsuspend(c *gin.Context) {
+	ac.act(c, ac.client.UnsuspendUser, "unsuspended", nil)
+}
+
+// Reactivate brings back an account its user deactivated, they can't sign in
+// to do it themselves.
+func (ac *adminController) Reactivate(c *gin.Context) {
+	ac.act(c, ac.client.ReactivateUser, "reactivated", nil)
+}
+
+// Unlock lets a user locked out by failed logins try again straight away.
+func (ac *adminController) Unlock(c *gin.Context) {
+	ac.act(c, ac.client.UnlockUser, "unlocked", nil)
+}
+
+// ForcePasswordReset signs the user out of Firebase as well, the user service
+// ends their sessions.
+func (ac *adminController) ForcePasswordReset(c *gin.Context) {
+	ac.act(c, ac.client.ForcePasswordReset, "password reset required", ac.signOut)
+}
+
+// Impersonate returns an access token acting as the user. It can't be
+// refreshed and every request made with it names the admin.
+func (ac *adminController) Impersonate(c *gin.Context) {
+	action, ok := ac.action(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	actor, err := requester(ctx, c, ac.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	action.Actor = actor
+	tokens, err := ac.client.Impersonate(ctx, action)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"sessionId":       tokens.SessionId,
+		"accessToken":     tokens.AccessToken,
+		"accessExpiresAt": tokens.AccessExpiresAt.AsTime(),
+	}})
+}
+
+func (ac *adminController) SetRole(c *gin.Context) {
+	var uri Uri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var payload domain.RolePayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	actor, err := requester(ctx, c, ac.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	_, err = ac.client.SetRole(ctx, &models.RoleChange{
+		Actor:    actor,
+		Username: uri.Username,
+		Role:     payload.Role,
+		Reason:   payload.Reason,
+	})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": "role changed"})
+}
+
+// AuditLog pages through the audit log, newest first. The next page starts
+// before the last id of this one.
+func (ac *adminController) AuditLog(c *gin.Context) {
+	query, ok := ac.auditQuery(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	if query.Actor, ok = ac.auditActor(ctx, c); !ok {
+		return
+	}
+	entries, err := ac.client.SearchAuditLog(ctx, query)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	data := make([]gin.H, 0, len(entries.Entries))
+	for _, entry := range entries.Entries {
+		data = append(data, auditEntryJSON(entry))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// ExportAuditLog writes every entry matching the filters as JSON lines,
+// newest first, fetching the log a page at a time.
+func (ac *adminController) ExportAuditLog(c *gin.Context) {
+	query, ok := ac.auditQuery(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Minute)
+	defer cancel()
+	if query.Actor, ok = ac.auditActor(ctx, c); !ok {
+		return
+	}
+	query.Limit = auditExportPage
+	// the first page is read before committing to a response, so a denied
+	// request still gets its status
+	entries, err := ac.client.SearchAuditLog(ctx, query)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit-log.jsonl"`)
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	for {
+		for _, entry := range entries.Entries {
+			if err = encoder.Encode(auditEntryJSON(entry)); err != nil {
+				_ = c.Error(err)
+				return
+			}
+		}
+		if len(entries.Entries) < auditExportPage {
+			return
+		}
+		query.BeforeId = entries.Entries[len(entries.Entries)-1].Id
+		if entries, err = ac.client.SearchAuditLog(ctx, query); err != nil {
+			// too late to change the status, the file is cut short
+			_ = c.Error(err)
+			return
+		}
+	}
+}
+
+// auditExportPage is how many entries an export asks for at a time, the most
+// the user service returns.
+const auditExportPage = 200
+
+func (ac *adminController) auditQuery(c *gin.Context) (*models.AuditQuery, bool) {
+	var query domain.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	search := &models.AuditQuery{
+		PerformedBy: query.Actor,
+		Action:      query.Action,
+		TargetType:  query.TargetType,
+		TargetId:    query.TargetID,
+		RequestId:   query.RequestID,
+		Limit:       query.Limit,
+		BeforeId:    query.Before,
+	}
+	if !query.From.IsZero() {
+		search.From = timestamppb.New(query.From)
+	}
+	if !query.To.IsZero() {
+		search.To = timestamppb.New(query.To)
+	}
+	return search, true
+}
+
+// auditActor resolves the signed in admin, it answers the request itself
+// when that fails.
+func (ac *adminController) auditActor(ctx context.Context, c *gin.Context) (string, bool) {
+	actor, err := requester(ctx, c, ac.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return "", false
+	}
+	return actor, true
+}
+
+// act runs one of the admin actions that return nothing on the user in the
+// path and answers with done.
+// act makes the call for the user of the path, then runs after, when given,
+// with their username.
+func (ac *adminController) act(c *gin.Context, call func(context.Context, *models.AdminAction, ...grpc.CallOption) (*emptypb.Empty, error), done string, after func(ctx context.Context, username string) error) {
+	action, ok := ac.action(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	actor, err := requester(ctx, c, ac.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	action.Actor = actor
+	if _, err = call(ctx, action); err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	if after != nil {
+		if err = after(ctx, action.Username); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sign the user out of firebase"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": done})
+}
+
+// signOut revokes the Firebase tokens of the user, so the ID token they hold
+// is refused on its next request.
+func (ac *adminController) signOut(ctx context.Context, username string) error {
+	user, err := ac.client.FindByUsername(ctx, &models.Username{Username: username})
+	if err != nil {
+		return err
+	}
+	return ac.revoker.RevokeTokens(ctx, user.Email)
+}
+
+// action reads the user of the path and the reason of the body, the body may
+// be left out.
+func (ac *adminController) action(c *gin.Context) (*models.AdminAction, bool) {
+	var uri Uri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	var payload domain.AdminActionPayload
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+	}
+	return &models.AdminAction{Username: uri.Username, Reason: payload.Reason}, true
+}
+
+func accountJSON(account *models.UserAccount) gin.H {
+	data := gin.H{
+		"id":                    account.Bio.GetId(),
+		"username":              account.Bio.GetUsername(),
+		"email":                 account.Bio.GetEmail(),
+		"fname":                 account.Bio.GetFname(),
+		"lname":                 account.Bio.GetLname(),
+		"role":                  account.Role,
+		"createdAt":             account.CreatedAt.AsTime(),
+		"suspendedAt":           nil,
+		"suspensionReason":      account.SuspensionReason,
+		"deactivatedAt":         nil,
+		"passwordResetRequired": account.PasswordResetRequired,
+	}
+	if account.SuspendedAt != nil {
+		data["suspendedAt"] = account.SuspendedAt.AsTime()
+	}
+	if account.DeactivatedAt != nil {
+		data["deactivatedAt"] = account.DeactivatedAt.AsTime()
+	}
+	return data
+}
+
+func auditEntryJSON(entry *models.AuditEntry) gin.H {
+	return gin.H{
+		"id":           entry.Id,
+		"service":      entry.Service,
+		"actor":        entry.Actor,
+		"impersonator": entry.Impersonator,
+		"action":       entry.Action,
+		"targetType":   entry.TargetType,
+		"targetId":     entry.TargetId,
+		"before":       json.RawMessage(rawObject(entry.Before)),
+		"after":        json.RawMessage(rawObject(entry.After)),
+		"requestId":    entry.RequestId,
+		"ip":           entry.Ip,
+		"createdAt":    entry.CreatedAt.AsTime(),
+	}
+}
+
+// rawObject keeps an empty diff a valid JSON object.
+func rawObject(s string) string {
+	if s == "" {
+		return "{}"
+	}
+	return s
+}
@@ -0,0 +1,309 @@
+package controller_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/user/interface/controller"
+	"github.com/spriigan/broker/user/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// adminMux serves the admin routes as the signed in admin@mail.com.
+func adminMux(signedOut *revoker) *gin.Engine {
+	adc := controller.NewAdminController(client, signedOut)
+	m := gin.New()
+	admin := m.Group("/admin/users", func(c *gin.Context) {
+		c.Set(authentication.EmailKey, "admin@mail.com")
+	})
+	admin.GET("", adc.Search)
+	admin.POST("/:username/suspend", adc.Suspend)
+	admin.POST("/:username/unsuspend", adc.Unsuspend)
+	admin.POST("/:username/reactivate", adc.Reactivate)
+	admin.POST("/:username/unlock", adc.Unlock)
+	admin.POST("/:username/password-reset", adc.ForcePasswordReset)
+	admin.POST("/:username/impersonate", adc.Impersonate)
+	admin.PUT("/:username/role", adc.SetRole)
+	auditLog := m.Group("/admin/audit", func(c *gin.Context) {
+		c.Set(authentication.EmailKey, "admin@mail.com")
+	})
+	auditLog.GET("", adc.AuditLog)
+	auditLog.GET("/export", adc.ExportAuditLog)
+	return m
+}
+
+func TestAdmin(t *testing.T) {
+	admin := &models.UserBio{Username: "admin", Email: "admin@mail.com"}
+	john := &models.UserBio{Username: "john", Email: "john@mail.com"}
+	signedOut := &revoker{}
+	testTabel := map[string]struct {
+		method  string
+		uri     string
+		body    string
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"search": {
+			method: http.MethodGet,
+			uri:    "/admin/users?q=jo&status=suspended&limit=10&after=4",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "admin@mail.com"}).Return(admin, nil).Once()
+				client.On("SearchUsers", mock.Anything, &models.UserSearch{Actor: "admin", Query: "jo", Status: "suspended", Limit: 10, AfterId: 4}).
+					Return(&models.UserAccounts{Accounts: []*models.UserAccount{{
+						Bio:              &models.UserBio{Id: 5, Username: "john"},
+						Role:             "user",
+						CreatedAt:        timestamppb.Now(),
+						SuspendedAt:      timestamppb.Now(),
+						SuspensionReason: "spam",
+					}}}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				accounts := data["data"].([]interface{})
+				require.Len(t, accounts, 1)
+				account := accounts[0].(map[string]interface{})
+				require.Equal(t, "john", account["username"])
+				require.Equal(t, "spam", account["suspensionReason"])
+				require.NotNil(t, account["suspendedAt"])
+				require.Nil(t, account["deactivatedAt"])
+			},
+		},
+		"search with a bad limit": {
+			method:  http.MethodGet,
+			uri:     "/admin/users?limit=1000",
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+		"suspend": {
+			method: http.MethodPost,
+			uri:    "/admin/users/john/suspend",
+			body:   `{"reason": "spam"}`,
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "admin@mail.com"}).Return(admin, nil).Once()
+				client.On("SuspendUser", mock.Anything, &models.AdminAction{Actor: "admin", Username: "john", Reason: "spam"}).Return(nil, nil).Once()
+				client.On("FindByUsername", mock.Anything, &models.Username{Username: "john"}).Return(john, nil).Once()
+				signedOut.emails = nil
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Equal(t, "suspended", data["data"])
+				require.Equal(t, []string{"john@mail.com"}, signedOut.emails, "signed out of firebase")
+			},
+		},
+		"unsuspend without a body": {
+			method: http.MethodPost,
+			uri:    "/admin/users/john/unsuspend",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "admin@mail.com"}).Return(admin, nil).Once()
+				client.On("UnsuspendUser", mock.Anything, &models.AdminAction{Actor: "admin", Username: "john"}).Return(nil, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+			},
+		},
+		"reactivate": {
+			method: http.MethodPost,
+			uri:    "/admin/users/john/reactivate",
+			body:   `{"reason": "asked by email"}`,
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "admin@mail.com"}).Return(admin, nil).Once()
+				client.On("ReactivateUser", mock.Anything, &models.AdminAction{Actor: "admin", Username: "john", Reason: "asked by email"}).Return(nil, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Equal(t, "reactivated", data["data"])
+			},
+		},
+		"unlock": {
+			method: http.MethodPost,
+			uri:    "/admin/users/john/unlock",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "admin@mail.com"}).Return(admin, nil).Once()
+				client.On("UnlockUser", mock.Anything, &models.AdminAction{Actor: "admin", Username: "john"}).Return(nil, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Equal(t, "unlocked", data["data"])
+			},
+		},
+		"force a password reset": {
+			method: http.MethodPost,
+			uri:    "/admin/users/john/password-reset",
+			body:   `{"reason": "leaked"}`,
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "admin@mail.com"}).Return(admin, nil).Once()
+				client.On("ForcePasswordReset", mock.Anything, &models.AdminAction{Actor: "admin", Username: "john", Reason: "leaked"}).Return(nil, nil).Once()
+				client.On("FindByUsername", mock.Anything, &models.Username{Username: "john"}).Return(john, nil).Once()
+				signedOut.emails = nil
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Equal(t, []string{"john@mail.com"}, signedOut.emails, "signed out of firebase")
+			},
+		},
+		"force a password reset without the role": {
+			method: http.MethodPost,
+			uri:    "/admin/users/john/password-reset",
+			body:   `{"reason": "leaked"}`,
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "admin@mail.com"}).Return(admin, nil).Once()
+				client.On("ForcePasswordReset", mock.Anything, &models.AdminAction{Actor: "admin", Username: "john", Reason: "leaked"}).
+					Return(nil, status.Error(codes.PermissionDenied, "not allowed to do this")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusForbidden, statusCode)
+			},
+		},
+		"impersonate": {
+			method: http.MethodPost,
+			uri:    "/admin/users/john/impersonate",
+			body:   `{"reason": "ticket 12"}`,
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "admin@mail.com"}).Return(admin, nil).Once()
+				client.On("Impersonate", mock.Anything, &models.AdminAction{Actor: "admin", Username: "john", Reason: "ticket 12"}).
+					Return(&models.AuthTokens{SessionId: 3, AccessToken: "at_token", AccessExpiresAt: timestamppb.Now()}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				tokens := data["data"].(map[string]interface{})
+				require.Equal(t, "at_token", tokens["accessToken"])
+				require.NotContains(t, tokens, "refreshToken")
+			},
+		},
+		"role of an unknown user": {
+			method: http.MethodPut,
+			uri:    "/admin/users/nobody/role",
+			body:   `{"role": "support"}`,
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "admin@mail.com"}).Return(admin, nil).Once()
+				client.On("SetRole", mock.Anything, &models.RoleChange{Actor: "admin", Username: "nobody", Role: "support"}).
+					Return(nil, status.Error(codes.NotFound, "user is not registered yet")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusNotFound, statusCode)
+			},
+		},
+		"audit log": {
+			method: http.MethodGet,
+			uri:    "/admin/audit?actor=root@mail.com&action=user.delete&from=2026-01-02T00:00:00Z&limit=20&before=90",
+			arrange: func(t *testing.T) {
+				from := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "admin@mail.com"}).Return(admin, nil).Once()
+				client.On("SearchAuditLog", mock.Anything, &models.AuditQuery{
+					Actor:       "admin",
+					PerformedBy: "root@mail.com",
+					Action:      "user.delete",
+					From:        timestamppb.New(from),
+					Limit:       20,
+					BeforeId:    90,
+				}).Return(&models.AuditEntries{Entries: []*models.AuditEntry{{
+					Id:         89,
+					Service:    "user",
+					Actor:      "root@mail.com",
+					Action:     "user.delete",
+					TargetType: "user",
+					TargetId:   "john",
+					Before:     `{"email":"john@mail.com"}`,
+					CreatedAt:  timestamppb.Now(),
+				}}}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				entries := data["data"].([]interface{})
+				require.Len(t, entries, 1)
+				entry := entries[0].(map[string]interface{})
+				require.Equal(t, "john", entry["targetId"])
+				require.Equal(t, map[string]interface{}{"email": "john@mail.com"}, entry["before"])
+				require.Equal(t, map[string]interface{}{}, entry["after"])
+			},
+		},
+		"audit log without the role": {
+			method: http.MethodGet,
+			uri:    "/admin/audit",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "admin@mail.com"}).Return(admin, nil).Once()
+				client.On("SearchAuditLog", mock.Anything, &models.AuditQuery{Actor: "admin"}).
+					Return(nil, status.Error(codes.PermissionDenied, "not allowed to do this")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusForbidden, statusCode)
+			},
+		},
+		"audit log with a bad time": {
+			method:  http.MethodGet,
+			uri:     "/admin/audit?from=yesterday",
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+		"role missing": {
+			method:  http.MethodPut,
+			uri:     "/admin/users/john/role",
+			body:    `{}`,
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+	}
+
+	m := adminMux(signedOut)
+	for k, v := range testTabel {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			req, _ := http.NewRequest(v.method, v.uri, bytes.NewBufferString(v.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			m.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+}
+
+func TestExportAuditLog(t *testing.T) {
+	admin := &models.UserBio{Username: "admin", Email: "admin@mail.com"}
+	full := make([]*models.AuditEntry, 200)
+	for i := range full {
+		full[i] = &models.AuditEntry{Id: int64(300 - i), Action: "user.update", After: `{"fname":"john"}`, CreatedAt: timestamppb.Now()}
+	}
+	client.On("FindByEmail", mock.Anything, &models.Email{Email: "admin@mail.com"}).Return(admin, nil).Once()
+	client.On("SearchAuditLog", mock.Anything, &models.AuditQuery{Actor: "admin", TargetType: "user", Limit: 200}).
+		Return(&models.AuditEntries{Entries: full}, nil).Once()
+	client.On("SearchAuditLog", mock.Anything, &models.AuditQuery{Actor: "admin", TargetType: "user", Limit: 200, BeforeId: 101}).
+		Return(&models.AuditEntries{Entries: []*models.AuditEntry{{Id: 7, Action: "user.delete", CreatedAt: timestamppb.Now()}}}, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/admin/audit/export?targetType=user", nil)
+	rr := httptest.NewRecorder()
+	adminMux(&revoker{}).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	var lines []gin.H
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		var line gin.H
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 201)
+	require.Equal(t, "user.delete", lines[200]["action"])
+	client.AssertExpectations(t)
+}
@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/product/imaging"
+	"github.com/spriigan/broker/response"
+	"github.com/spriigan/broker/storage"
+	"github.com/spriigan/broker/user/domain"
+	"github.com/spriigan/broker/user/user-proto/grpc/models"
+	field_mask "google.golang.org/genproto/protobuf/field_mask"
+)
+
+const (
+	MaxAvatarSize = 5 << 20
+	// AvatarPrefix is where avatars are stored, the image route serves them
+	// next to product images.
+	AvatarPrefix = "avatars/"
+)
+
+type ProfileController interface {
+	Get(ctx *gin.Context)
+	Update(ctx *gin.Context)
+	UploadAvatar(ctx *gin.Context)
+	RemoveAvatar(ctx *gin.Context)
+}
+
+// profileController edits the profile of the signed in user, who is found by
+// the email of their token.
+type profileController struct {
+	client  models.UserServiceClient
+	store   storage.BlobStore
+	baseURL string
+}
+
+// NewProfileController stores avatars in store and links them under baseURL,
+// the address of the public image route.
+func NewProfileController(client models.UserServiceClient, store storage.BlobStore, baseURL string) *profileController {
+	return &profileController{client: client, store: store, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (pc *profileController) Get(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	username, err := requester(ctx, c, pc.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	profile, err := pc.client.GetProfile(ctx, &models.Username{Username: username})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profileJSON(profile)})
+}
+
+// Update changes the fields in the body and leaves the others as they are.
+func (pc *profileController) Update(c *gin.Context) {
+	var payload domain.ProfilePayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	profile := &models.Profile{}
+	mask := &field_mask.FieldMask{}
+	if payload.DisplayName != nil {
+		mask.Paths = append(mask.Paths, "displayName")
+		profile.DisplayName = *payload.DisplayName
+	}
+	if payload.Phone != nil {
+		mask.Paths = append(mask.Paths, "phone")
+		profile.Phone = *payload.Phone
+	}
+	if payload.Locale != nil {
+		mask.Paths = append(mask.Paths, "locale")
+		profile.Locale = *payload.Locale
+	}
+	if payload.Timezone != nil {
+		mask.Paths = append(mask.Paths, "timezone")
+		profile.Timezone = *payload.Timezone
+	}
+	if payload.Marketing != nil {
+		mask.Paths = append(mask.Paths, "marketing")
+		profile.Marketing = &models.MarketingPreferences{Email: payload.Marketing.Email, Sms: payload.Marketing.Sms}
+	}
+	if len(mask.Paths) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nothing to update"})
+		return
+	}
+	pc.update(c, profile, mask)
+}
+
+// UploadAvatar stores the thumbnail of the image in the "image" form field
+// and makes it the avatar of the user.
+func (pc *profileController) UploadAvatar(c *gin.Context) {
+	// leave room for the multipart envelope around the file itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxAvatarSize+1<<20)
+	header, err := c.FormFile("image")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "avatar must not exceed 5MB"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if header.Size > MaxAvatarSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "avatar must not exceed 5MB"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, MaxAvatarSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	img, err := imaging.Process(data)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, imaging.ErrUnsupportedType) {
+			code = http.StatusUnsupportedMediaType
+		}
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	// an avatar is never shown larger than a thumbnail, the original is not
+	// kept
+	key := AvatarPrefix + img.Hash + img.Thumbnail.Ext
+	storeCtx, cancelStore := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancelStore()
+	err = pc.store.Put(storeCtx, key, bytes.NewReader(img.Thumbnail.Data), int64(len(img.Thumbnail.Data)), img.Thumbnail.ContentType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store avatar"})
+		return
+	}
+	pc.update(c, &models.Profile{AvatarUrl: pc.baseURL + "/" + key}, &field_mask.FieldMask{Paths: []string{"avatarUrl"}})
+}
+
+// RemoveAvatar unsets the avatar. The image stays stored, its key comes from
+// its content and another user may have uploaded the same one.
+func (pc *profileController) RemoveAvatar(c *gin.Context) {
+	pc.update(c, &models.Profile{}, &field_mask.FieldMask{Paths: []string{"avatarUrl"}})
+}
+
+func (pc *profileController) update(c *gin.Context, profile *models.Profile, mask *field_mask.FieldMask) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	username, err := requester(ctx, c, pc.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	updated, err := pc.client.UpdateProfile(ctx, &models.ProfileUpdate{Username: username, Profile: profile, UpdateMask: mask})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profileJSON(updated)})
+}
+
+func profileJSON(profile *models.Profile) gin.H {
+	data := gin.H{
+		"displayName": profile.DisplayName,
+		"avatarUrl":   profile.AvatarUrl,
+		"phone":       profile.Phone,
+		"locale":      profile.Locale,
+		"timezone":    profile.Timezone,
+		"marketing": gin.H{
+			"email": profile.GetMarketing().GetEmail(),
+			"sms":   profile.GetMarketing().GetSms(),
+		},
+		"updatedAt": nil,
+	}
+	if profile.UpdatedAt != nil {
+		data["updatedAt"] = profile.UpdatedAt.AsTime()
+	}
+	return data
+}
@@ -0,0 +1,181 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/storage"
+	"github.com/spriigan/broker/user/interface/controller"
+	"github.com/spriigan/broker/user/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// profileMux serves the profile routes as the signed in owner@mail.com.
+func profileMux(t *testing.T) *gin.Engine {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	pc := controller.NewProfileController(client, store, "http://localhost/public/images/")
+	m := gin.New()
+	protected := m.Group("/auth", func(c *gin.Context) {
+		c.Set(authentication.EmailKey, "owner@mail.com")
+	})
+	protected.GET("/profile", pc.Get)
+	protected.PATCH("/profile", pc.Update)
+	protected.POST("/profile/avatar", pc.UploadAvatar)
+	protected.DELETE("/profile/avatar", pc.RemoveAvatar)
+	return m
+}
+
+func avatarBody(t *testing.T) (*bytes.Buffer, string) {
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 640, 480))))
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("image", "avatar.png")
+	require.NoError(t, err)
+	_, err = part.Write(img.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return &body, w.FormDataContentType()
+}
+
+func TestProfile(t *testing.T) {
+	owner := &models.UserBio{Username: "owner", Email: "owner@mail.com"}
+	testTabel := map[string]struct {
+		method  string
+		uri     string
+		body    func(t *testing.T) (*bytes.Buffer, string)
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"get": {
+			method: http.MethodGet,
+			uri:    "/auth/profile",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "owner@mail.com"}).Return(owner, nil).Once()
+				client.On("GetProfile", mock.Anything, &models.Username{Username: "owner"}).
+					Return(&models.Profile{DisplayName: "Owner", Timezone: "Europe/Berlin"}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				profile := data["data"].(map[string]interface{})
+				require.Equal(t, "Owner", profile["displayName"])
+				require.Equal(t, false, profile["marketing"].(map[string]interface{})["sms"])
+			},
+		},
+		"update the fields sent": {
+			method: http.MethodPatch,
+			uri:    "/auth/profile",
+			body:   jsonBody(`{"phone": "+1 415 555 0123", "marketing": {"email": true, "sms": true}}`),
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, mock.Anything).Return(owner, nil).Once()
+				client.On("UpdateProfile", mock.Anything, mock.MatchedBy(func(in *models.ProfileUpdate) bool {
+					return in.Username == "owner" && in.Profile.Phone == "+1 415 555 0123" && in.Profile.Marketing.Sms &&
+						strings.Join(in.UpdateMask.Paths, ",") == "phone,marketing"
+				})).Return(&models.Profile{Phone: "+14155550123"}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Equal(t, "+14155550123", data["data"].(map[string]interface{})["phone"])
+			},
+		},
+		"nothing to update": {
+			method:  http.MethodPatch,
+			uri:     "/auth/profile",
+			body:    jsonBody(`{}`),
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+		"invalid profile": {
+			method: http.MethodPatch,
+			uri:    "/auth/profile",
+			body:   jsonBody(`{"timezone": "Mars/Olympus"}`),
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, mock.Anything).Return(owner, nil).Once()
+				client.On("UpdateProfile", mock.Anything, mock.Anything).Return(nil, status.Error(codes.InvalidArgument, "invalid profile")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+				require.NotNil(t, data["error"])
+			},
+		},
+		"upload avatar": {
+			method: http.MethodPost,
+			uri:    "/auth/profile/avatar",
+			body:   avatarBody,
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, mock.Anything).Return(owner, nil).Once()
+				client.On("UpdateProfile", mock.Anything, mock.MatchedBy(func(in *models.ProfileUpdate) bool {
+					return strings.HasPrefix(in.Profile.AvatarUrl, "http://localhost/public/images/avatars/") &&
+						strings.HasSuffix(in.Profile.AvatarUrl, ".png") && in.UpdateMask.Paths[0] == "avatarUrl"
+				})).Return(&models.Profile{AvatarUrl: "http://localhost/public/images/avatars/abc.png"}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.NotEmpty(t, data["data"].(map[string]interface{})["avatarUrl"])
+			},
+		},
+		"upload something else": {
+			method:  http.MethodPost,
+			uri:     "/auth/profile/avatar",
+			body:    jsonBody(`not an image`),
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+		"remove avatar": {
+			method: http.MethodDelete,
+			uri:    "/auth/profile/avatar",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, mock.Anything).Return(owner, nil).Once()
+				client.On("UpdateProfile", mock.Anything, mock.MatchedBy(func(in *models.ProfileUpdate) bool {
+					return in.Profile.AvatarUrl == "" && in.UpdateMask.Paths[0] == "avatarUrl"
+				})).Return(&models.Profile{}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+			},
+		},
+	}
+
+	m := profileMux(t)
+	for k, v := range testTabel {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			body, contentType := &bytes.Buffer{}, "application/json"
+			if v.body != nil {
+				body, contentType = v.body(t)
+			}
+			req, _ := http.NewRequest(v.method, v.uri, body)
+			req.Header.Set("Content-Type", contentType)
+			rr := httptest.NewRecorder()
+			m.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+}
+
+func jsonBody(body string) func(t *testing.T) (*bytes.Buffer, string) {
+	return func(t *testing.T) (*bytes.Buffer, string) {
+		return bytes.NewBufferString(body), "application/json"
+	}
+}
@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/response"
+	"github.com/spriigan/broker/user/domain"
+	"github.com/spriigan/broker/user/user-proto/grpc/models"
+)
+
+type SessionController interface {
+	Refresh(ctx *gin.Context)
+	List(ctx *gin.Context)
+	Revoke(ctx *gin.Context)
+	RevokeAll(ctx *gin.Context)
+	VerifySession(ctx context.Context, token string) (authentication.Session, error)
+	VerifyAccount(ctx context.Context, email string) (authentication.Session, error)
+}
+
+// sessionController handles the sessions the user service starts at login,
+// the signed in user is found by the email of their token. Signing out
+// everywhere signs the user out of Firebase as well.
+type sessionController struct {
+	client  models.UserServiceClient
+	revoker authentication.TokenRevoker
+}
+
+func NewSessionController(client models.UserServiceClient, revoker authentication.TokenRevoker) *sessionController {
+	return &sessionController{client: client, revoker: revoker}
+}
+
+// VerifySession is what the authentication middleware asks for every access
+// token, so a revoked session is turned away on its next request.
+func (sc *sessionController) VerifySession(ctx context.Context, token string) (authentication.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	user, err := sc.client.Authenticate(ctx, &models.AccessToken{Token: token})
+	if err != nil {
+		return authentication.Session{}, err
+	}
+	return authentication.Session{
+		ID:                    user.SessionId,
+		Email:                 user.User.Email,
+		Impersonator:          user.Impersonator,
+		PasswordResetRequired: user.PasswordResetRequired,
+	}, nil
+}
+
+// VerifyAccount is what the authentication middleware asks for every Firebase
+// ID token, so a suspended account is turned away on its next request.
+func (sc *sessionController) VerifyAccount(ctx context.Context, email string) (authentication.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	user, err := sc.client.AuthenticateAccount(ctx, &models.Email{Email: email})
+	if err != nil {
+		return authentication.Session{}, err
+	}
+	return authentication.Session{
+		Email:                 user.User.Email,
+		PasswordResetRequired: user.PasswordResetRequired,
+	}, nil
+}
+
+// Refresh trades a refresh token for a new pair. A refresh token works once,
+// sending one a second time revokes its session.
+func (sc *sessionController) Refresh(c *gin.Context) {
+	var payload domain.RefreshPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	tokens, err := sc.client.RefreshSession(ctx, &models.RefreshToken{Token: payload.RefreshToken})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tokensJSON(tokens)})
+}
+
+// List returns where the user is logged in, the session of the request is
+// marked as current.
+func (sc *sessionController) List(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	username, err := requester(ctx, c, sc.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	sessions, err := sc.client.ListSessions(ctx, &models.Username{Username: username})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	current := authentication.SessionID(c)
+	data := make([]gin.H, 0, len(sessions.Sessions))
+	for _, session := range sessions.Sessions {
+		data = append(data, gin.H{
+			"id":         session.Id,
+			"device":     session.Device,
+			"ip":         session.Ip,
+			"userAgent":  session.UserAgent,
+			"createdAt":  session.CreatedAt.AsTime(),
+			"lastSeenAt": session.LastSeenAt.AsTime(),
+			"current":    session.Id == current,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+func (sc *sessionController) Revoke(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "session id must be a number"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	username, err := requester(ctx, c, sc.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	_, err = sc.client.RevokeSession(ctx, &models.SessionRef{Username: username, SessionId: sessionID})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": "revoked"})
+}
+
+// RevokeAll logs the user out everywhere, the current session included.
+func (sc *sessionController) RevokeAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	username, err := requester(ctx, c, sc.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	_, err = sc.client.RevokeAllSessions(ctx, &models.Username{Username: username})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	if err = sc.revoker.RevokeTokens(ctx, authentication.Email(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sign out of firebase"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": "revoked"})
+}
+
+func tokensJSON(tokens *models.AuthTokens) gin.H {
+	return gin.H{
+		"sessionId":        tokens.SessionId,
+		"accessToken":      tokens.AccessToken,
+		"accessExpiresAt":  tokens.AccessExpiresAt.AsTime(),
+		"refreshToken":     tokens.RefreshToken,
+		"refreshExpiresAt": tokens.RefreshExpiresAt.AsTime(),
+	}
+}
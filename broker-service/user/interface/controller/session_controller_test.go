@@ -0,0 +1,187 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/user/interface/controller"
+	"github.com/spriigan/broker/user/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// revoker records whose Firebase tokens were revoked.
+type revoker struct {
+	emails []string
+}
+
+func (r *revoker) RevokeTokens(ctx context.Context, email string) error {
+	r.emails = append(r.emails, email)
+	return nil
+}
+
+// sessionMux serves the session routes as owner@mail.com signed in with
+// session 7.
+func sessionMux(signedOut *revoker) *gin.Engine {
+	sc := controller.NewSessionController(client, signedOut)
+	m := gin.New()
+	m.POST("/public/token/refresh", sc.Refresh)
+	protected := m.Group("/auth", func(c *gin.Context) {
+		c.Set(authentication.EmailKey, "owner@mail.com")
+		c.Set(authentication.SessionKey, int64(7))
+	})
+	protected.GET("/sessions", sc.List)
+	protected.DELETE("/sessions", sc.RevokeAll)
+	protected.DELETE("/sessions/:id", sc.Revoke)
+	return m
+}
+
+func TestSessions(t *testing.T) {
+	owner := &models.UserBio{Username: "owner", Email: "owner@mail.com"}
+	signedOut := &revoker{}
+	testTabel := map[string]struct {
+		method  string
+		uri     string
+		body    string
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"refresh": {
+			method: http.MethodPost,
+			uri:    "/public/token/refresh",
+			body:   `{"refreshToken": "rt_old"}`,
+			arrange: func(t *testing.T) {
+				client.On("RefreshSession", mock.Anything, &models.RefreshToken{Token: "rt_old"}).
+					Return(&models.AuthTokens{SessionId: 7, AccessToken: "at_new", RefreshToken: "rt_new", AccessExpiresAt: timestamppb.Now(), RefreshExpiresAt: timestamppb.Now()}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Equal(t, "rt_new", data["data"].(map[string]interface{})["refreshToken"])
+			},
+		},
+		"refresh token reused": {
+			method: http.MethodPost,
+			uri:    "/public/token/refresh",
+			body:   `{"refreshToken": "rt_old"}`,
+			arrange: func(t *testing.T) {
+				client.On("RefreshSession", mock.Anything, mock.Anything).Return(nil, status.Error(codes.Unauthenticated, "refresh token was already used")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusUnauthorized, statusCode)
+			},
+		},
+		"list": {
+			method: http.MethodGet,
+			uri:    "/auth/sessions",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "owner@mail.com"}).Return(owner, nil).Once()
+				client.On("ListSessions", mock.Anything, &models.Username{Username: "owner"}).Return(&models.Sessions{Sessions: []*models.Session{
+					{Id: 7, Device: "phone", CreatedAt: timestamppb.Now(), LastSeenAt: timestamppb.Now()},
+					{Id: 8, Device: "laptop", CreatedAt: timestamppb.Now(), LastSeenAt: timestamppb.Now()},
+				}}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				sessions := data["data"].([]interface{})
+				require.Len(t, sessions, 2)
+				require.Equal(t, true, sessions[0].(map[string]interface{})["current"])
+				require.Equal(t, false, sessions[1].(map[string]interface{})["current"])
+			},
+		},
+		"revoke": {
+			method: http.MethodDelete,
+			uri:    "/auth/sessions/8",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, mock.Anything).Return(owner, nil).Once()
+				client.On("RevokeSession", mock.Anything, &models.SessionRef{Username: "owner", SessionId: 8}).Return(nil, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+			},
+		},
+		"revoke someone else's": {
+			method: http.MethodDelete,
+			uri:    "/auth/sessions/9",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, mock.Anything).Return(owner, nil).Once()
+				client.On("RevokeSession", mock.Anything, mock.Anything).Return(nil, status.Error(codes.NotFound, "session is not found")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusNotFound, statusCode)
+			},
+		},
+		"revoke with a bad id": {
+			method:  http.MethodDelete,
+			uri:     "/auth/sessions/phone",
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+		"revoke all": {
+			method: http.MethodDelete,
+			uri:    "/auth/sessions",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, mock.Anything).Return(owner, nil).Once()
+				client.On("RevokeAllSessions", mock.Anything, &models.Username{Username: "owner"}).Return(nil, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				// the Firebase tokens go with the sessions
+				require.Equal(t, []string{"owner@mail.com"}, signedOut.emails)
+			},
+		},
+	}
+
+	m := sessionMux(signedOut)
+	for k, v := range testTabel {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			req, _ := http.NewRequest(v.method, v.uri, bytes.NewReader([]byte(v.body)))
+			rr := httptest.NewRecorder()
+			m.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+}
+
+func TestVerifySession(t *testing.T) {
+	sc := controller.NewSessionController(client, &revoker{})
+	client.On("Authenticate", mock.Anything, &models.AccessToken{Token: "at_token"}).
+		Return(&models.SessionUser{User: &models.UserBio{Email: "owner@mail.com"}, SessionId: 7, Impersonator: "admin"}, nil).Once()
+	session, err := sc.VerifySession(context.Background(), "at_token")
+	require.NoError(t, err)
+	require.Equal(t, authentication.Session{ID: 7, Email: "owner@mail.com", Impersonator: "admin"}, session)
+
+	client.On("Authenticate", mock.Anything, &models.AccessToken{Token: "at_revoked"}).
+		Return(nil, status.Error(codes.Unauthenticated, "session is not found or no longer valid")).Once()
+	_, err = sc.VerifySession(context.Background(), "at_revoked")
+	require.Error(t, err)
+}
+
+func TestVerifyAccount(t *testing.T) {
+	sc := controller.NewSessionController(client, &revoker{})
+	client.On("AuthenticateAccount", mock.Anything, &models.Email{Email: "owner@mail.com"}).
+		Return(&models.SessionUser{User: &models.UserBio{Email: "owner@mail.com"}, PasswordResetRequired: true}, nil).Once()
+	session, err := sc.VerifyAccount(context.Background(), "owner@mail.com")
+	require.NoError(t, err)
+	require.Equal(t, authentication.Session{Email: "owner@mail.com", PasswordResetRequired: true}, session)
+
+	client.On("AuthenticateAccount", mock.Anything, &models.Email{Email: "suspended@mail.com"}).
+		Return(nil, status.Error(codes.Unauthenticated, "user is suspended")).Once()
+	_, err = sc.VerifyAccount(context.Background(), "suspended@mail.com")
+	require.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/response"
+	"github.com/spriigan/broker/user/domain"
+	"github.com/spriigan/broker/user/user-proto/grpc/models"
+)
+
+type TotpController interface {
+	Enroll(ctx *gin.Context)
+	Confirm(ctx *gin.Context)
+	Disable(ctx *gin.Context)
+}
+
+// totpController sets up two-factor authentication for the signed in user,
+// who is found by the email of their token.
+type totpController struct {
+	client models.UserServiceClient
+}
+
+func NewTotpController(client models.UserServiceClient) *totpController {
+	return &totpController{client: client}
+}
+
+// Enroll returns the secret, the otpauth URI and a QR code to scan, the
+// second factor is only on once Confirm got a code.
+func (tc *totpController) Enroll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	username, err := requester(ctx, c, tc.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	enrollment, err := tc.client.EnrollTotp(ctx, &models.Username{Username: username})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": gin.H{
+		"secret": enrollment.Secret,
+		"uri":    enrollment.Uri,
+		"qrPng":  enrollment.QrPng,
+	}})
+}
+
+// Confirm turns the second factor on and returns the recovery codes, they
+// are not shown again.
+func (tc *totpController) Confirm(c *gin.Context) {
+	var payload domain.TotpCode
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	username, err := requester(ctx, c, tc.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	recoveryCodes, err := tc.client.ConfirmTotp(ctx, &models.TotpCode{Username: username, Code: payload.Code})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"recoveryCodes": recoveryCodes.Codes}})
+}
+
+func (tc *totpController) Disable(c *gin.Context) {
+	var payload domain.TotpCode
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	username, err := requester(ctx, c, tc.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	_, err = tc.client.DisableTotp(ctx, &models.TotpCode{Username: username, Code: payload.Code})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": "disabled"})
+}
@@ -0,0 +1,178 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/user/interface/controller"
+	"github.com/spriigan/broker/user/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// totpMux serves the TOTP routes as the signed in owner@mail.com.
+func totpMux() *gin.Engine {
+	tc := controller.NewTotpController(client)
+	m := gin.New()
+	protected := m.Group("/auth", func(c *gin.Context) {
+		c.Set(authentication.EmailKey, "owner@mail.com")
+	})
+	protected.POST("/totp", tc.Enroll)
+	protected.POST("/totp/confirm", tc.Confirm)
+	protected.POST("/totp/disable", tc.Disable)
+	return m
+}
+
+func TestTotp(t *testing.T) {
+	owner := &models.UserBio{Username: "owner", Email: "owner@mail.com"}
+	testTabel := map[string]struct {
+		uri     string
+		body    string
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"enroll": {
+			uri: "/auth/totp",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "owner@mail.com"}).Return(owner, nil).Once()
+				client.On("EnrollTotp", mock.Anything, &models.Username{Username: "owner"}).
+					Return(&models.TotpEnrollment{Secret: "SECRET", Uri: "otpauth://totp/RPApp:owner", QrPng: []byte{1}}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusCreated, statusCode)
+				require.Equal(t, "SECRET", data["data"].(map[string]interface{})["secret"])
+			},
+		},
+		"enroll twice": {
+			uri: "/auth/totp",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, mock.Anything).Return(owner, nil).Once()
+				client.On("EnrollTotp", mock.Anything, mock.Anything).Return(nil, status.Error(codes.AlreadyExists, "already enabled")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusConflict, statusCode)
+			},
+		},
+		"confirm": {
+			uri:  "/auth/totp/confirm",
+			body: `{"code": "123456"}`,
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, mock.Anything).Return(owner, nil).Once()
+				client.On("ConfirmTotp", mock.Anything, &models.TotpCode{Username: "owner", Code: "123456"}).
+					Return(&models.RecoveryCodes{Codes: []string{"abcde-fghij"}}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Len(t, data["data"].(map[string]interface{})["recoveryCodes"], 1)
+			},
+		},
+		"confirm without code": {
+			uri:     "/auth/totp/confirm",
+			body:    `{}`,
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+		"disable with a wrong code": {
+			uri:  "/auth/totp/disable",
+			body: `{"code": "000000"}`,
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, mock.Anything).Return(owner, nil).Once()
+				client.On("DisableTotp", mock.Anything, mock.Anything).Return(nil, status.Error(codes.InvalidArgument, "wrong one-time code")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+				require.NotNil(t, data["error"])
+			},
+		},
+	}
+
+	m := totpMux()
+	for k, v := range testTabel {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			req, _ := http.NewRequest(http.MethodPost, v.uri, bytes.NewReader([]byte(v.body)))
+			rr := httptest.NewRecorder()
+			m.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	testTabel := map[string]struct {
+		body    string
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"success api call": {
+			body: `{"username": "owner", "password": "secret", "otp": "123456"}`,
+			arrange: func(t *testing.T) {
+				client.On("Login", mock.Anything, mock.MatchedBy(func(in *models.Credentials) bool {
+					return in.Username == "owner" && in.Otp == "123456" && in.Ip != ""
+				})).Return(&models.LoginResult{
+					User:   &models.UserBio{Username: "owner"},
+					Tokens: &models.AuthTokens{SessionId: 7, AccessToken: "at_token", RefreshToken: "rt_token"},
+				}, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				tokens := data["data"].(map[string]interface{})["tokens"].(map[string]interface{})
+				require.Equal(t, "rt_token", tokens["refreshToken"])
+			},
+		},
+		"code needed": {
+			body: `{"username": "owner", "password": "secret"}`,
+			arrange: func(t *testing.T) {
+				client.On("Login", mock.Anything, mock.Anything).Return(nil, status.Error(codes.FailedPrecondition, "a one-time code is required")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusUnauthorized, statusCode)
+				require.Equal(t, true, data["otpRequired"])
+			},
+		},
+		"locked": {
+			body: `{"username": "owner", "password": "guess"}`,
+			arrange: func(t *testing.T) {
+				client.On("Login", mock.Anything, mock.Anything).Return(nil, status.Error(codes.ResourceExhausted, "too many failed logins")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusTooManyRequests, statusCode)
+			},
+		},
+		"missing password": {
+			body:    `{"username": "owner"}`,
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+			},
+		},
+	}
+
+	for k, v := range testTabel {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			req, _ := http.NewRequest(http.MethodPost, "/public/login", bytes.NewReader([]byte(v.body)))
+			req.RemoteAddr = "10.0.0.1:5000"
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+}
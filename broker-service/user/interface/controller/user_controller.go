@@ -3,21 +3,27 @@ package controller
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spriigan/broker/authentication"
+	"github.com/spriigan/broker/response"
 	"github.com/spriigan/broker/user/domain"
 	"github.com/spriigan/broker/user/user-proto/grpc/models"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 type UserController interface {
 	Create(ctx *gin.Context)
-	FindUsers(ctx *gin.Context)
+	Login(ctx *gin.Context)
 	FindByUsername(ctx *gin.Context)
+	CheckUsername(ctx *gin.Context)
 	DeleteByUsername(ctx *gin.Context)
 	Update(ctx *gin.Context)
+	Deactivate(ctx *gin.Context)
 }
 
 type userController struct {
@@ -41,7 +47,7 @@ func (uc *userController) Create(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
 	defer cancel()
 
 	payloadPB := models.UserPayload{
@@ -60,10 +66,10 @@ func (uc *userController) Create(c *gin.Context) {
 		if !ok {
 			panic(err)
 		}
-		c.JSON(http.StatusBadRequest, gin.H{
+		c.JSON(http.StatusBadRequest, withViolations(gin.H{
 			"error": st.Message(),
 			"code":  st.Code(),
-		})
+		}, st))
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{
@@ -71,23 +77,51 @@ func (uc *userController) Create(c *gin.Context) {
 	})
 }
 
-func (uc *userController) FindUsers(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+// Login checks the password and, for users with two-factor authentication,
+// the one-time code, and returns the tokens of the new session. A 401 with
+// otpRequired set asks the client to send the login again with the code.
+// With passwordResetRequired set the session can only set a new password.
+func (uc *userController) Login(c *gin.Context) {
+	var payload domain.Credentials
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
 	defer cancel()
-	users, err := uc.client.FindUsers(ctx, &emptypb.Empty{})
+	result, err := uc.client.Login(ctx, &models.Credentials{
+		Username:  payload.Username,
+		Password:  payload.Password,
+		Otp:       payload.Otp,
+		Ip:        c.ClientIP(),
+		Device:    payload.Device,
+		UserAgent: c.Request.UserAgent(),
+	})
 	if err != nil {
 		st, ok := status.FromError(err)
 		if !ok {
 			panic(err)
 		}
-		c.JSON(http.StatusBadRequest, gin.H{"error": st.Message(), "code": st.Code()})
-		return
-	}
-	if users.User == nil {
-		c.JSON(http.StatusOK, gin.H{"data": []models.UserBio{}})
+		switch st.Code() {
+		case codes.Unauthenticated:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": st.Message()})
+		case codes.FailedPrecondition:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": st.Message(), "otpRequired": true})
+		case codes.ResourceExhausted:
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": st.Message()})
+		case codes.PermissionDenied:
+			c.JSON(http.StatusForbidden, gin.H{"error": st.Message()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": st.Message(), "code": st.Code()})
+		}
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": users.User})
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"user":                  result.User,
+		"tokens":                tokensJSON(result.Tokens),
+		"passwordResetRequired": result.PasswordResetRequired,
+	}})
 }
 
 func (uc *userController) FindByUsername(c *gin.Context) {
@@ -98,7 +132,7 @@ func (uc *userController) FindByUsername(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
 	defer cancel()
 	user, err := uc.client.FindByUsername(ctx, &models.Username{Username: uri.Username})
 	if err != nil {
@@ -116,7 +150,8 @@ func (uc *userController) FindByUsername(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": user})
 }
 
-func (uc *userController) DeleteByUsername(c *gin.Context) {
+// CheckUsername tells the signup form whether a username can still be taken.
+func (uc *userController) CheckUsername(c *gin.Context) {
 	var uri Uri
 	err := c.ShouldBindUri(&uri)
 	if err != nil {
@@ -124,13 +159,41 @@ func (uc *userController) DeleteByUsername(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
 	defer cancel()
-	_, err = uc.client.DeleteByUsername(ctx, &models.Username{Username: uri.Username})
+	result, err := uc.client.IsUsernameAvailable(ctx, &models.Username{Username: uri.Username})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"username": uri.Username, "available": result.Available}})
+}
+
+// DeleteByUsername deletes the account of the signed in user, nobody else's.
+func (uc *userController) DeleteByUsername(c *gin.Context) {
+	var uri Uri
+	err := c.ShouldBindUri(&uri)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	username, err := requester(ctx, c, uc.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	if !strings.EqualFold(username, uri.Username) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can delete an account"})
+		return
+	}
+	_, err = uc.client.DeleteByUsername(ctx, &models.Username{Username: username})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"data": "deleted"})
 }
 
@@ -143,7 +206,7 @@ func (uc *userController) Update(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
 	defer cancel()
 
 	payloadPB := models.UserPayload{
@@ -163,8 +226,68 @@ func (uc *userController) Update(c *gin.Context) {
 		if !ok {
 			panic(err)
 		}
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": st.Code()})
+		c.JSON(http.StatusBadRequest, withViolations(gin.H{"error": err.Error(), "code": st.Code()}, st))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": "updated"})
 }
+
+// Deactivate hides the account of the signed in user until staff reactivate
+// it, nobody else's.
+func (uc *userController) Deactivate(c *gin.Context) {
+	var uri Uri
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	username, err := requester(ctx, c, uc.client)
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	if !strings.EqualFold(username, uri.Username) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can deactivate an account"})
+		return
+	}
+	_, err = uc.client.DeactivateUser(ctx, &models.Username{Username: username})
+	if err != nil {
+		response.GrpcError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": "deactivated"})
+}
+
+// withViolations adds the fields the user service rejected, such as every
+// rule a new password broke, to an error response.
+func withViolations(body gin.H, st *status.Status) gin.H {
+	for _, detail := range st.Details() {
+		badRequest, ok := detail.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		violations := make([]gin.H, 0, len(badRequest.FieldViolations))
+		for _, v := range badRequest.FieldViolations {
+			violations = append(violations, gin.H{"field": v.Field, "description": v.Description})
+		}
+		body["violations"] = violations
+	}
+	return body
+}
+
+// requester is the username of the signed in user, found by the email of the
+// token.
+func requester(ctx context.Context, c *gin.Context, client models.UserServiceClient) (string, error) {
+	email := authentication.Email(c)
+	if email == "" {
+		return "", status.Error(codes.Unauthenticated, "the token carries no email")
+	}
+	user, err := client.FindByEmail(ctx, &models.Email{Email: email})
+	if err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
@@ -12,11 +12,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/spriigan/broker/adapters"
+	"github.com/spriigan/broker/authentication"
 	"github.com/spriigan/broker/infrastructure/router"
 	"github.com/spriigan/broker/user/interface/controller"
 	"github.com/spriigan/broker/user/user-proto/grpc/models"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -35,15 +37,56 @@ func (mc mockClient) RegisterUser(ctx context.Context, in *models.UserPayload, o
 	return args.Get(0).(*models.UserBio), args.Error(1)
 }
 
-func (mc mockClient) FindUsers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*models.Users, error) {
+func (mc mockClient) FindByUsername(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*models.UserBio, error) {
 	args := mc.Called(ctx, in)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.Users), args.Error(1)
+	return args.Get(0).(*models.UserBio), args.Error(1)
 }
 
-func (mc mockClient) FindByUsername(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*models.UserBio, error) {
+func (mc mockClient) DeleteByUsername(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	return nil, args.Error(1)
+}
+
+func (mc mockClient) Update(ctx context.Context, in *models.UserPayload, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	return nil, args.Error(1)
+}
+
+func (mc mockClient) DeactivateUser(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	return nil, args.Error(1)
+}
+
+func (mc mockClient) ReactivateUser(ctx context.Context, in *models.AdminAction, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	return nil, args.Error(1)
+}
+
+func (mc mockClient) ExportUserData(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*models.UserData, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserData), args.Error(1)
+}
+
+func (mc mockClient) EraseUser(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	return nil, args.Error(1)
+}
+
+func (mc mockClient) IsUsernameAvailable(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*models.UsernameAvailability, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UsernameAvailability), args.Error(1)
+}
+
+func (mc mockClient) FindById(ctx context.Context, in *models.UserId, opts ...grpc.CallOption) (*models.UserBio, error) {
 	args := mc.Called(ctx, in)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -51,12 +94,154 @@ func (mc mockClient) FindByUsername(ctx context.Context, in *models.Username, op
 	return args.Get(0).(*models.UserBio), args.Error(1)
 }
 
-func (mc mockClient) DeleteByUsername(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+func (mc mockClient) FindByEmail(ctx context.Context, in *models.Email, opts ...grpc.CallOption) (*models.UserBio, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserBio), args.Error(1)
+}
+
+func (mc mockClient) BatchGetUsers(ctx context.Context, in *models.UserIds, opts ...grpc.CallOption) (*models.Users, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Users), args.Error(1)
+}
+
+func (mc mockClient) Login(ctx context.Context, in *models.Credentials, opts ...grpc.CallOption) (*models.LoginResult, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LoginResult), args.Error(1)
+}
+
+func (mc mockClient) RefreshSession(ctx context.Context, in *models.RefreshToken, opts ...grpc.CallOption) (*models.AuthTokens, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuthTokens), args.Error(1)
+}
+
+func (mc mockClient) Authenticate(ctx context.Context, in *models.AccessToken, opts ...grpc.CallOption) (*models.SessionUser, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SessionUser), args.Error(1)
+}
+
+func (mc mockClient) AuthenticateAccount(ctx context.Context, in *models.Email, opts ...grpc.CallOption) (*models.SessionUser, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SessionUser), args.Error(1)
+}
+
+func (mc mockClient) ListSessions(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*models.Sessions, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Sessions), args.Error(1)
+}
+
+func (mc mockClient) RevokeSession(ctx context.Context, in *models.SessionRef, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	args := mc.Called(ctx, in)
 	return nil, args.Error(1)
 }
 
-func (mc mockClient) Update(ctx context.Context, in *models.UserPayload, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+func (mc mockClient) RevokeAllSessions(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	return nil, args.Error(1)
+}
+
+func (mc mockClient) UnlockUser(ctx context.Context, in *models.AdminAction, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	return nil, args.Error(1)
+}
+
+func (mc mockClient) EnrollTotp(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*models.TotpEnrollment, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TotpEnrollment), args.Error(1)
+}
+
+func (mc mockClient) ConfirmTotp(ctx context.Context, in *models.TotpCode, opts ...grpc.CallOption) (*models.RecoveryCodes, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RecoveryCodes), args.Error(1)
+}
+
+func (mc mockClient) DisableTotp(ctx context.Context, in *models.TotpCode, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	return nil, args.Error(1)
+}
+
+func (mc mockClient) GetProfile(ctx context.Context, in *models.Username, opts ...grpc.CallOption) (*models.Profile, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Profile), args.Error(1)
+}
+
+func (mc mockClient) UpdateProfile(ctx context.Context, in *models.ProfileUpdate, opts ...grpc.CallOption) (*models.Profile, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Profile), args.Error(1)
+}
+
+func (mc mockClient) SearchUsers(ctx context.Context, in *models.UserSearch, opts ...grpc.CallOption) (*models.UserAccounts, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserAccounts), args.Error(1)
+}
+
+func (mc mockClient) SearchAuditLog(ctx context.Context, in *models.AuditQuery, opts ...grpc.CallOption) (*models.AuditEntries, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuditEntries), args.Error(1)
+}
+
+func (mc mockClient) SuspendUser(ctx context.Context, in *models.AdminAction, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	return nil, args.Error(1)
+}
+
+func (mc mockClient) UnsuspendUser(ctx context.Context, in *models.AdminAction, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	return nil, args.Error(1)
+}
+
+func (mc mockClient) ForcePasswordReset(ctx context.Context, in *models.AdminAction, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := mc.Called(ctx, in)
+	return nil, args.Error(1)
+}
+
+func (mc mockClient) Impersonate(ctx context.Context, in *models.AdminAction, opts ...grpc.CallOption) (*models.AuthTokens, error) {
+	args := mc.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuthTokens), args.Error(1)
+}
+
+func (mc mockClient) SetRole(ctx context.Context, in *models.RoleChange, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	args := mc.Called(ctx, in)
 	return nil, args.Error(1)
 }
@@ -68,9 +253,11 @@ var mux *gin.Engine
 func TestMain(m *testing.M) {
 	client = new(mockClient)
 	ac = &adapters.AppController{
-		User: controller.NewUserController(client),
+		User:    controller.NewUserController(client),
+		Totp:    controller.NewTotpController(client),
+		Session: controller.NewSessionController(client, &revoker{}),
 	}
-	mux = router.Route(ac)
+	mux = router.Route(ac, nil)
 	os.Exit(m.Run())
 }
 
@@ -117,6 +304,24 @@ func TestRegisterUser(t *testing.T) {
 				require.Zero(t, data["data"])
 			},
 		},
+		"weak password": {
+			json: jsonReq,
+			arrange: func(t *testing.T) {
+				st, err := status.New(codes.InvalidArgument, "password does not meet the policy").WithDetails(&errdetails.BadRequest{
+					FieldViolations: []*errdetails.BadRequest_FieldViolation{
+						{Field: "password", Description: "must mix at least 2 of lower case letters, upper case letters, digits and symbols"},
+					},
+				})
+				require.NoError(t, err)
+				client.On("RegisterUser", mock.Anything, mock.Anything).Return(nil, st.Err()).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+				violations := data["violations"].([]interface{})
+				require.Len(t, violations, 1)
+				require.Equal(t, "password", violations[0].(map[string]interface{})["field"])
+			},
+		},
 		"wrong validation": {
 			json:    wrongValidation,
 			arrange: func(t *testing.T) {},
@@ -142,34 +347,42 @@ func TestRegisterUser(t *testing.T) {
 	}
 }
 
-func TestFindUsers(t *testing.T) {
-	users := &models.Users{
-		User: []*models.UserBio{
-			{},
-			{},
-			{},
-		},
-	}
+func TestFindByUsername(t *testing.T) {
+	user := &models.UserBio{Lname: "connor"}
 	testTabel := map[string]struct {
+		uri     string
 		arrange func(t *testing.T)
 		assert  func(t *testing.T, statusCode int, data gin.H)
 	}{
 		"success api call": {
+			uri: "/user/ryanpuj0",
 			arrange: func(t *testing.T) {
-				client.On("FindUsers", mock.Anything, mock.Anything).Return(users, nil).Once()
+				client.On("FindByUsername", mock.Anything, mock.Anything).Return(user, nil).Once()
 			},
 			assert: func(t *testing.T, statusCode int, data gin.H) {
 				require.Equal(t, http.StatusOK, statusCode)
-				require.NotNil(t, data["data"])
+				require.Nil(t, data["error"])
+				require.NotNil(t, data)
 			},
 		},
-		"failure call": {
+		"failed call": {
+			uri: "/user/ryanpujo",
 			arrange: func(t *testing.T) {
-				client.On("FindUsers", mock.Anything, mock.Anything).Return(nil, status.Error(codes.Internal, "got an error")).Once()
+				client.On("FindByUsername", mock.Anything, mock.Anything).Return(nil, status.Error(codes.NotFound, errors.New("got an error").Error())).Once()
 			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusBadRequest, statusCode)
+				require.Zero(t, data["data"])
+				require.NotNil(t, data["error"])
+			},
+		},
+		"bad uri": {
+			uri:     "/user/rt",
+			arrange: func(t *testing.T) {},
 			assert: func(t *testing.T, statusCode int, data gin.H) {
 				require.Equal(t, http.StatusBadRequest, statusCode)
 				require.Nil(t, data["data"])
+				require.NotNil(t, data["error"])
 			},
 		},
 	}
@@ -178,7 +391,7 @@ func TestFindUsers(t *testing.T) {
 		t.Run(k, func(t *testing.T) {
 			v.arrange(t)
 
-			req, _ := http.NewRequest(http.MethodGet, "/user", nil)
+			req, _ := http.NewRequest(http.MethodGet, v.uri, nil)
 			rr := httptest.NewRecorder()
 			mux.ServeHTTP(rr, req)
 			var res gin.H
@@ -189,42 +402,38 @@ func TestFindUsers(t *testing.T) {
 	}
 }
 
-func TestFindByUsername(t *testing.T) {
-	user := &models.UserBio{Lname: "connor"}
+func TestCheckUsername(t *testing.T) {
 	testTabel := map[string]struct {
 		uri     string
 		arrange func(t *testing.T)
 		assert  func(t *testing.T, statusCode int, data gin.H)
 	}{
 		"success api call": {
-			uri: "/user/ryanpuj0",
+			uri: "/public/user/ryanpujo/available",
 			arrange: func(t *testing.T) {
-				client.On("FindByUsername", mock.Anything, mock.Anything).Return(user, nil).Once()
+				client.On("IsUsernameAvailable", mock.Anything, &models.Username{Username: "ryanpujo"}).Return(&models.UsernameAvailability{Available: true}, nil).Once()
 			},
 			assert: func(t *testing.T, statusCode int, data gin.H) {
 				require.Equal(t, http.StatusOK, statusCode)
-				require.Nil(t, data["error"])
-				require.NotNil(t, data)
+				require.Equal(t, true, data["data"].(map[string]interface{})["available"])
 			},
 		},
 		"failed call": {
-			uri: "/user/ryanpujo",
+			uri: "/public/user/ryanpujo/available",
 			arrange: func(t *testing.T) {
-				client.On("FindByUsername", mock.Anything, mock.Anything).Return(nil, status.Error(codes.NotFound, errors.New("got an error").Error())).Once()
+				client.On("IsUsernameAvailable", mock.Anything, mock.Anything).Return(nil, status.Error(codes.Internal, "got an error")).Once()
 			},
 			assert: func(t *testing.T, statusCode int, data gin.H) {
-				require.Equal(t, http.StatusBadRequest, statusCode)
-				require.Zero(t, data["data"])
+				require.Equal(t, http.StatusInternalServerError, statusCode)
 				require.NotNil(t, data["error"])
 			},
 		},
 		"bad uri": {
-			uri:     "/user/rt",
+			uri:     "/public/user/rt/available",
 			arrange: func(t *testing.T) {},
 			assert: func(t *testing.T, statusCode int, data gin.H) {
 				require.Equal(t, http.StatusBadRequest, statusCode)
 				require.Nil(t, data["data"])
-				require.NotNil(t, data["error"])
 			},
 		},
 	}
@@ -245,33 +454,59 @@ func TestFindByUsername(t *testing.T) {
 }
 
 func TestDeleteByUsername(t *testing.T) {
+	owner := &models.UserBio{Username: "ryanpujo", Email: "owner@mail.com"}
+	uc := controller.NewUserController(client)
+	m := gin.New()
+	m.DELETE("/auth/user/:username", func(c *gin.Context) {
+		c.Set(authentication.EmailKey, "owner@mail.com")
+	}, uc.DeleteByUsername)
 	testTable := map[string]struct {
 		uri     string
 		arrange func(t *testing.T)
 		assert  func(t *testing.T, statusCode int, data gin.H)
 	}{
 		"success api call": {
-			uri: "/user/ryanpuj0",
+			uri: "/auth/user/ryanpujo",
 			arrange: func(t *testing.T) {
-				client.On("DeleteByUsername", mock.Anything, mock.Anything).Return(nil, nil).Once()
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "owner@mail.com"}).Return(owner, nil).Once()
+				client.On("DeleteByUsername", mock.Anything, &models.Username{Username: "ryanpujo"}).Return(nil, nil).Once()
 			},
 			assert: func(t *testing.T, statusCode int, data gin.H) {
 				require.Equal(t, http.StatusOK, statusCode)
 				require.Equal(t, "deleted", data["data"])
 			},
 		},
+		"someone else": {
+			uri: "/auth/user/endeavour",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "owner@mail.com"}).Return(owner, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusForbidden, statusCode)
+			},
+		},
 		"failed call": {
-			uri: "/user/ryanpujo",
+			uri: "/auth/user/ryanpujo",
 			arrange: func(t *testing.T) {
-				client.On("DeleteByUsername", mock.Anything, mock.Anything).Return(nil, errors.New("got an error")).Once()
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "owner@mail.com"}).Return(owner, nil).Once()
+				client.On("DeleteByUsername", mock.Anything, mock.Anything).Return(nil, status.Error(codes.Internal, "got an error")).Once()
 			},
 			assert: func(t *testing.T, statusCode int, data gin.H) {
-				require.Equal(t, http.StatusBadRequest, statusCode)
+				require.Equal(t, http.StatusInternalServerError, statusCode)
 				require.NotNil(t, data["error"])
 			},
 		},
+		"unknown user": {
+			uri: "/auth/user/ryanpujo",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, mock.Anything).Return(nil, status.Error(codes.NotFound, "user is not registered yet")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusNotFound, statusCode)
+			},
+		},
 		"bad uri": {
-			uri:     "/user/rt",
+			uri:     "/auth/user/rt",
 			arrange: func(t *testing.T) {},
 			assert: func(t *testing.T, statusCode int, data gin.H) {
 				require.Equal(t, http.StatusBadRequest, statusCode)
@@ -286,13 +521,14 @@ func TestDeleteByUsername(t *testing.T) {
 
 			req, _ := http.NewRequest(http.MethodDelete, v.uri, nil)
 			rr := httptest.NewRecorder()
-			mux.ServeHTTP(rr, req)
+			m.ServeHTTP(rr, req)
 			var res gin.H
 			_ = json.NewDecoder(rr.Body).Decode(&res)
 
 			v.assert(t, rr.Code, res)
 		})
 	}
+	client.AssertNotCalled(t, "DeleteByUsername", mock.Anything, &models.Username{Username: "endeavour"})
 }
 
 func TestUpdate(t *testing.T) {
@@ -363,3 +599,62 @@ func TestUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestDeactivation(t *testing.T) {
+	owner := &models.UserBio{Username: "ryanpujo", Email: "owner@mail.com"}
+	uc := controller.NewUserController(client)
+	m := gin.New()
+	m.POST("/auth/user/:username/deactivate", func(c *gin.Context) {
+		c.Set(authentication.EmailKey, "owner@mail.com")
+	}, uc.Deactivate)
+	testTable := map[string]struct {
+		uri     string
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, statusCode int, data gin.H)
+	}{
+		"deactivate": {
+			uri: "/auth/user/ryanpujo/deactivate",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "owner@mail.com"}).Return(owner, nil).Once()
+				client.On("DeactivateUser", mock.Anything, &models.Username{Username: "ryanpujo"}).Return(nil, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusOK, statusCode)
+				require.Equal(t, "deactivated", data["data"])
+			},
+		},
+		"someone else": {
+			uri: "/auth/user/endeavour/deactivate",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, &models.Email{Email: "owner@mail.com"}).Return(owner, nil).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusForbidden, statusCode)
+			},
+		},
+		"unknown user": {
+			uri: "/auth/user/ryanpujo/deactivate",
+			arrange: func(t *testing.T) {
+				client.On("FindByEmail", mock.Anything, mock.Anything).Return(nil, status.Error(codes.NotFound, "user is not registered yet")).Once()
+			},
+			assert: func(t *testing.T, statusCode int, data gin.H) {
+				require.Equal(t, http.StatusNotFound, statusCode)
+			},
+		},
+	}
+
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			req, _ := http.NewRequest(http.MethodPost, v.uri, nil)
+			rr := httptest.NewRecorder()
+			m.ServeHTTP(rr, req)
+			var res gin.H
+			_ = json.NewDecoder(rr.Body).Decode(&res)
+
+			v.assert(t, rr.Code, res)
+		})
+	}
+	client.AssertNotCalled(t, "DeactivateUser", mock.Anything, &models.Username{Username: "endeavour"})
+}
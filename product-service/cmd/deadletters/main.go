@@ -0,0 +1,67 @@
+// Command deadletters lists the events the consumers gave up on and replays
+// them.
+//
+// Without flags it prints the dead letters that have not been replayed yet.
+// Pass -consumer to show the ones of one consumer, -all to include the
+// replayed ones, and -replay with the id of a dead letter to hand it to its
+// consumer again once the cause is fixed.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/infrastructure"
+	"github.com/ryanpujo/product-service/internal/registry"
+)
+
+func main() {
+	consumer := flag.String("consumer", "", "only list the dead letters of this consumer")
+	all := flag.Bool("all", false, "include the dead letters already replayed")
+	limit := flag.Int("limit", 100, "how many dead letters to list")
+	replay := flag.Int64("replay", 0, "replay the dead letter with this id")
+	flag.Parse()
+
+	app := infrastructure.Application()
+	db := infrastructure.ConnectToDB()
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	register := registry.New(db)
+	err := register.SetupNotifications(app.Config.NOTIFY_EMAIL, app.Config.SMTP_URL, app.Config.SMTP_FROM)
+	if err != nil {
+		log.Fatal("invalid notifications config: ", err)
+	}
+	deadLetters := register.NewDeadLetters()
+	if *replay != 0 {
+		if err := deadLetters.Replay(ctx, *replay); err != nil {
+			log.Fatalf("failed to replay dead letter %d: %v", *replay, err)
+		}
+		fmt.Printf("replayed dead letter %d\n", *replay)
+		return
+	}
+
+	letters, err := deadLetters.List(ctx, *consumer, *all, *limit)
+	if err != nil {
+		log.Fatal("failed to list dead letters: ", err)
+	}
+	if len(letters) == 0 {
+		fmt.Println("no dead letters")
+		return
+	}
+	for _, l := range letters {
+		state := "pending"
+		if l.ReplayedAt.Valid {
+			state = "replayed " + l.ReplayedAt.Time.Format(time.RFC3339)
+		}
+		fmt.Printf("%d %s %s key=%s event=%s attempts=%d at=%s %s\n  %s\n",
+			l.ID, l.Consumer, l.Topic, l.PartitionKey, l.EventID, l.Attempts,
+			l.CreatedAt.Format(time.RFC3339), state, strings.TrimSpace(l.Error))
+	}
+}
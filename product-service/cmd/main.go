@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ryanpujo/product-service/internal/infrastructure"
+	"github.com/ryanpujo/product-service/internal/registry"
+)
+
+func main() {
+	app := infrastructure.Application()
+	db := infrastructure.ConnectToDB()
+	defer db.Close()
+	register := registry.New(db)
+	err := register.SetupNotifications(app.Config.NOTIFY_EMAIL, app.Config.SMTP_URL, app.Config.SMTP_FROM)
+	if err != nil {
+		log.Fatal("invalid notifications config: ", err)
+	}
+	bus, err := register.NewEventBus(app.Config.EVENTS_DRIVER, app.Config.EVENTS_URL)
+	if err != nil {
+		log.Fatal("invalid events config: ", err)
+	}
+	for _, consumer := range register.NewConsumers() {
+		bus.Subscribe(consumer.Topic, consumer.Consume)
+	}
+	stopListener := app.StartListener(bus)
+	defer stopListener()
+	stopRelay := app.StartRelay(register.NewEventRelay(bus))
+	defer stopRelay()
+	stopMailer := app.StartMailer(register.NewMailer())
+	defer stopMailer()
+	close, err := app.StartGrpcServer(register.NewProductServer())
+	if err != nil {
+		close()
+		log.Fatal("failed to start the server", err)
+	}
+	fmt.Println("server started")
+	defer close()
+}
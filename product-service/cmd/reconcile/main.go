@@ -0,0 +1,55 @@
+// Command reconcile compares the stock of products and variants with the
+// stock_movements ledger and reports every one where the two have drifted
+// apart.
+//
+// It exits with status 1 when drift is found, so it can run from cron or CI.
+// Pass -fix to reset the stock columns to the ledger total. Databases created
+// before the ledger need sql/migration/0001_stock_opening_balance.sql first,
+// otherwise -fix zeroes the stock of every product that predates it.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/infrastructure"
+	"github.com/ryanpujo/product-service/internal/registry"
+)
+
+func main() {
+	fix := flag.Bool("fix", false, "reset the stock columns to the ledger total")
+	flag.Parse()
+
+	infrastructure.Application()
+	db := infrastructure.ConnectToDB()
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	drift, err := registry.New(db).NewProductInteractor().ReconcileStock(ctx, *fix)
+	if err != nil {
+		log.Fatal("failed to reconcile stock: ", err)
+	}
+	if len(drift) == 0 {
+		fmt.Println("stock is in sync with the ledger")
+		return
+	}
+
+	for _, d := range drift {
+		if d.VariantID != 0 {
+			fmt.Printf("product %d variant %d: stock=%d ledger=%d drift=%d\n", d.ProductID, d.VariantID, d.Stock, d.LedgerStock, d.Stock-d.LedgerStock)
+			continue
+		}
+		fmt.Printf("product %d: stock=%d ledger=%d drift=%d\n", d.ProductID, d.Stock, d.LedgerStock, d.Stock-d.LedgerStock)
+	}
+	if *fix {
+		fmt.Printf("reset stock of %d product(s) and variant(s) to the ledger\n", len(drift))
+		return
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,68 @@
+// Package audit keeps the append-only record of who changed what. The user
+// and product services write the same entries to the shared audit_log table,
+// the broker sends who made each request along with the call. An entry is
+// written in the transaction of the change it describes, so a change is
+// never made without its entry.
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+)
+
+// Auditor appends entries to the audit log with q, the querier of the
+// transaction making the change. Entries are never changed once written.
+type Auditor interface {
+	Record(ctx context.Context, q repository.Querier, entry Entry) error
+}
+
+// Service names the entries this service writes to the shared log.
+const Service = "product"
+
+// Log appends the entries to the audit_log table.
+type Log struct{}
+
+func (Log) Record(ctx context.Context, q repository.Querier, entry Entry) error {
+	before, err := json.Marshal(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := json.Marshal(entry.After)
+	if err != nil {
+		return err
+	}
+	return q.InsertAuditEntry(ctx, repository.InsertAuditEntryParams{
+		Service:      Service,
+		Actor:        nullString(entry.Actor),
+		Impersonator: nullString(entry.Impersonator),
+		Action:       entry.Action,
+		TargetType:   entry.TargetType,
+		TargetID:     entry.TargetID,
+		Before:       before,
+		After:        after,
+		RequestID:    nullString(entry.RequestID),
+		Ip:           nullString(entry.IP),
+	})
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// LogAuditor only logs the entries, it is used until a real log is set.
+type LogAuditor struct{}
+
+func (LogAuditor) Record(ctx context.Context, q repository.Querier, entry Entry) error {
+	log.Printf("audit %s on %s %s by %q from %s, request %s", entry.Action, entry.TargetType, entry.TargetID, entry.Actor, entry.IP, entry.RequestID)
+	return nil
+}
+
+// Record completes the entry and writes it with q. Call it inside the
+// transaction of the change and fail the change when it fails.
+func Record(ctx context.Context, auditor Auditor, q repository.Querier, entry Entry) error {
+	return auditor.Record(ctx, q, Complete(ctx, entry))
+}
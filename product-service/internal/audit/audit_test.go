@@ -0,0 +1,54 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ryanpujo/product-service/internal/audit"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// querier only answers the audit insert, the embedded interface panics on
+// any other query.
+type querier struct {
+	repository.Querier
+	mock.Mock
+}
+
+func (q *querier) InsertAuditEntry(ctx context.Context, arg repository.InsertAuditEntryParams) error {
+	return q.Called(arg).Error(0)
+}
+
+func TestLog(t *testing.T) {
+	q := &querier{}
+	q.On("InsertAuditEntry", mock.MatchedBy(func(arg repository.InsertAuditEntryParams) bool {
+		var after map[string]interface{}
+		return arg.Service == audit.Service &&
+			arg.Actor.String == "owner@mail.com" &&
+			!arg.Impersonator.Valid &&
+			arg.Action == "product.create" &&
+			arg.TargetID == "7" &&
+			string(arg.Before) == "{}" &&
+			json.Unmarshal(arg.After, &after) == nil && after["name"] == "Mug" &&
+			arg.RequestID.String == "req-1"
+	})).Return(nil).Once()
+
+	ctx := audit.WithRequest(context.Background(), audit.Request{ID: "req-1", Actor: "owner@mail.com"})
+	err := audit.Record(ctx, audit.Log{}, q, audit.Entry{
+		Action:     "product.create",
+		TargetType: "product",
+		TargetID:   "7",
+		After:      map[string]interface{}{"name": "Mug"},
+	})
+	require.NoError(t, err)
+	q.AssertExpectations(t)
+
+	// a failing log fails the change, its transaction rolls back
+	q.On("InsertAuditEntry", mock.Anything).Return(errors.New("log is down")).Once()
+	err = audit.Record(ctx, audit.Log{}, q, audit.Entry{Action: "product.delete"})
+	require.Error(t, err)
+}
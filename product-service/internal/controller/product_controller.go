@@ -0,0 +1,341 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/notification"
+	"github.com/ryanpujo/product-service/internal/promotion"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// MaxImportRows caps the rows of one ImportProducts stream, which are held in
+// memory until the stream ends.
+const MaxImportRows = 10000
+
+type productServer struct {
+	product.UnimplementedProductServiceServer
+	interactor interactor.ProductInteractor
+}
+
+func NewProductServer(i interactor.ProductInteractor) *productServer {
+	return &productServer{interactor: i}
+}
+
+func (ps *productServer) Create(ctx context.Context, payload *product.ProductPayload) (*product.Product, error) {
+	created, err := ps.interactor.Create(ctx, payload)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return created, nil
+}
+
+func (ps *productServer) AdjustStock(ctx context.Context, adjustment *product.StockAdjustment) (*product.StockMovement, error) {
+	movement, err := ps.interactor.AdjustStock(ctx, adjustment)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return movement, nil
+}
+
+func (ps *productServer) ListStockMovements(ctx context.Context, req *product.StockMovementsRequest) (*product.StockMovements, error) {
+	movements, err := ps.interactor.ListStockMovements(ctx, req)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return movements, nil
+}
+
+func (ps *productServer) SearchProducts(ctx context.Context, req *product.SearchRequest) (*product.SearchResult, error) {
+	result, err := ps.interactor.SearchProducts(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return result, nil
+}
+
+func (ps *productServer) CreateVariant(ctx context.Context, payload *product.VariantPayload) (*product.Variant, error) {
+	variant, err := ps.interactor.CreateVariant(ctx, payload)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return variant, nil
+}
+
+func (ps *productServer) ListVariants(ctx context.Context, id *product.ProductId) (*product.Variants, error) {
+	variants, err := ps.interactor.ListVariants(ctx, id)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return variants, nil
+}
+
+func (ps *productServer) CreateReview(ctx context.Context, payload *product.ReviewPayload) (*product.Review, error) {
+	review, err := ps.interactor.CreateReview(ctx, payload)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return review, nil
+}
+
+func (ps *productServer) UpdateReview(ctx context.Context, update *product.ReviewUpdate) (*product.Review, error) {
+	review, err := ps.interactor.UpdateReview(ctx, update)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return review, nil
+}
+
+func (ps *productServer) ModerateReview(ctx context.Context, moderation *product.ReviewModeration) (*product.Review, error) {
+	review, err := ps.interactor.ModerateReview(ctx, moderation)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return review, nil
+}
+
+func (ps *productServer) ListReviews(ctx context.Context, req *product.ReviewsRequest) (*product.Reviews, error) {
+	reviews, err := ps.interactor.ListReviews(ctx, req)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return reviews, nil
+}
+
+// ImportProducts reads the options, which must come first, and then every row
+// of the stream before importing them in one go.
+func (ps *productServer) ImportProducts(stream product.ProductService_ImportProductsServer) error {
+	var opts *product.ImportOptions
+	var rows []*product.ProductRow
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch payload := req.Payload.(type) {
+		case *product.ImportProductsRequest_Options:
+			if opts != nil || len(rows) > 0 {
+				return status.Error(codes.InvalidArgument, "options must be sent once, before the rows")
+			}
+			opts = payload.Options
+		case *product.ImportProductsRequest_Row:
+			if opts == nil {
+				return status.Error(codes.InvalidArgument, "options must be sent before the rows")
+			}
+			if len(rows) == MaxImportRows {
+				return status.Error(codes.ResourceExhausted, fmt.Sprintf("an import is limited to %d rows", MaxImportRows))
+			}
+			rows = append(rows, payload.Row)
+		}
+	}
+	if opts == nil {
+		return status.Error(codes.InvalidArgument, "options are required")
+	}
+
+	summary, err := ps.interactor.ImportProducts(stream.Context(), opts, rows)
+	if err != nil {
+		return toStatus(err)
+	}
+	return stream.SendAndClose(summary)
+}
+
+func (ps *productServer) ExportProducts(req *product.ExportRequest, stream product.ProductService_ExportProductsServer) error {
+	err := ps.interactor.ExportProducts(stream.Context(), req, stream.Send)
+	if err != nil {
+		return toStatus(err)
+	}
+	return nil
+}
+
+func (ps *productServer) CreatePromotion(ctx context.Context, payload *product.PromotionPayload) (*product.Promotion, error) {
+	promotion, err := ps.interactor.CreatePromotion(ctx, payload)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return promotion, nil
+}
+
+func (ps *productServer) ListPromotions(ctx context.Context, req *product.StorePromotionsRequest) (*product.Promotions, error) {
+	promotions, err := ps.interactor.ListPromotions(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return promotions, nil
+}
+
+func (ps *productServer) PriceCart(ctx context.Context, req *product.PriceCartRequest) (*product.CartPrice, error) {
+	price, err := ps.interactor.PriceCart(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return price, nil
+}
+
+func (ps *productServer) PlaceOrder(ctx context.Context, req *product.PlaceOrderRequest) (*product.Order, error) {
+	order, err := ps.interactor.PlaceOrder(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return order, nil
+}
+
+func (ps *productServer) CancelOrder(ctx context.Context, req *product.OrderCancellation) (*product.Order, error) {
+	order, err := ps.interactor.CancelOrder(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return order, nil
+}
+
+func (ps *productServer) AddToWishlist(ctx context.Context, req *product.WishlistItemRequest) (*product.WishlistItem, error) {
+	item, err := ps.interactor.AddToWishlist(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return item, nil
+}
+
+func (ps *productServer) RemoveFromWishlist(ctx context.Context, req *product.WishlistItemRequest) (*emptypb.Empty, error) {
+	empty, err := ps.interactor.RemoveFromWishlist(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return empty, nil
+}
+
+func (ps *productServer) ListWishlist(ctx context.Context, req *product.WishlistRequest) (*product.Wishlist, error) {
+	wishlist, err := ps.interactor.ListWishlist(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return wishlist, nil
+}
+
+func (ps *productServer) MoveWishlistItemToCart(ctx context.Context, req *product.WishlistItemRequest) (*product.CartItem, error) {
+	item, err := ps.interactor.MoveWishlistItemToCart(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return item, nil
+}
+
+func (ps *productServer) ExportCustomerData(ctx context.Context, req *product.CustomerDataRequest) (*product.CustomerData, error) {
+	data, err := ps.interactor.ExportCustomerData(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return data, nil
+}
+
+func (ps *productServer) EraseCustomerData(ctx context.Context, req *product.CustomerDataRequest) (*emptypb.Empty, error) {
+	empty, err := ps.interactor.EraseCustomerData(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return empty, nil
+}
+
+func (ps *productServer) CreateApiKey(ctx context.Context, payload *product.ApiKeyPayload) (*product.ApiKey, error) {
+	key, err := ps.interactor.CreateApiKey(ctx, payload)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return key, nil
+}
+
+func (ps *productServer) ListApiKeys(ctx context.Context, req *product.StoreApiKeysRequest) (*product.ApiKeys, error) {
+	keys, err := ps.interactor.ListApiKeys(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return keys, nil
+}
+
+func (ps *productServer) RevokeApiKey(ctx context.Context, req *product.ApiKeyRequest) (*emptypb.Empty, error) {
+	empty, err := ps.interactor.RevokeApiKey(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return empty, nil
+}
+
+func (ps *productServer) VerifyApiKey(ctx context.Context, secret *product.ApiKeySecret) (*product.ApiKeyPrincipal, error) {
+	principal, err := ps.interactor.VerifyApiKey(ctx, secret)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return principal, nil
+}
+
+func (ps *productServer) ListNotifications(ctx context.Context, req *product.NotificationsRequest) (*product.Notifications, error) {
+	notifications, err := ps.interactor.ListNotifications(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return notifications, nil
+}
+
+func (ps *productServer) MarkNotificationsRead(ctx context.Context, req *product.NotificationsRead) (*product.NotificationsReadResult, error) {
+	result, err := ps.interactor.MarkNotificationsRead(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return result, nil
+}
+
+func (ps *productServer) GetNotificationPreferences(ctx context.Context, req *product.NotificationPreferencesRequest) (*product.NotificationPreferences, error) {
+	preferences, err := ps.interactor.GetNotificationPreferences(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return preferences, nil
+}
+
+func (ps *productServer) UpdateNotificationPreferences(ctx context.Context, update *product.NotificationPreferencesUpdate) (*product.NotificationPreferences, error) {
+	preferences, err := ps.interactor.UpdateNotificationPreferences(ctx, update)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return preferences, nil
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, interactor.ErrProductNotFound), errors.Is(err, interactor.ErrVariantNotFound),
+		errors.Is(err, interactor.ErrReviewNotFound), errors.Is(err, interactor.ErrCouponNotFound),
+		errors.Is(err, interactor.ErrWishlistItemNotFound), errors.Is(err, interactor.ErrAccountNotFound),
+		errors.Is(err, interactor.ErrApiKeyNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, interactor.ErrInsufficientStock), errors.Is(err, interactor.ErrCouponExpired),
+		errors.Is(err, interactor.ErrPromotionExhausted), errors.Is(err, promotion.ErrCouponNotApplicable),
+		errors.Is(err, interactor.ErrOrderNotCancellable):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, interactor.ErrInvalidStockReason), errors.Is(err, interactor.ErrZeroQuantity),
+		errors.Is(err, interactor.ErrInvalidPrice), errors.Is(err, interactor.ErrEmptyQuery),
+		errors.Is(err, interactor.ErrInvalidVariant), errors.Is(err, interactor.ErrInvalidReview),
+		errors.Is(err, interactor.ErrInvalidModeration), errors.Is(err, interactor.ErrStoreRequired),
+		errors.Is(err, interactor.ErrInvalidPromotion), errors.Is(err, promotion.ErrEmptyCart),
+		errors.Is(err, promotion.ErrInvalidQuantity), errors.Is(err, promotion.ErrMixedCartCurrencies),
+		errors.Is(err, interactor.ErrInvalidApiKey), errors.Is(err, notification.ErrUnknownCategory):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, interactor.ErrReviewerNotFound), errors.Is(err, interactor.ErrNotStoreOwner),
+		errors.Is(err, interactor.ErrCustomerNotFound):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, interactor.ErrDuplicateSku), errors.Is(err, interactor.ErrDuplicateVariant),
+		errors.Is(err, interactor.ErrReviewExists), errors.Is(err, interactor.ErrDuplicateCoupon):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, interactor.ErrApiKeyRejected):
+		return status.Error(codes.Unauthenticated, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
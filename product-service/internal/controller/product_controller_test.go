@@ -0,0 +1,1411 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/controller"
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/notification"
+	"github.com/ryanpujo/product-service/internal/promotion"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type interactorMock struct {
+	mock.Mock
+}
+
+func (in *interactorMock) Create(ctx context.Context, payload *product.ProductPayload) (*product.Product, error) {
+	args := in.Called(payload)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Product), args.Error(1)
+}
+
+func (in *interactorMock) AdjustStock(ctx context.Context, adjustment *product.StockAdjustment) (*product.StockMovement, error) {
+	args := in.Called(adjustment)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.StockMovement), args.Error(1)
+}
+
+func (in *interactorMock) ListStockMovements(ctx context.Context, req *product.StockMovementsRequest) (*product.StockMovements, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.StockMovements), args.Error(1)
+}
+
+func (in *interactorMock) ReconcileStock(ctx context.Context, fix bool) ([]repository.ListStockDriftRow, error) {
+	args := in.Called(fix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ListStockDriftRow), args.Error(1)
+}
+
+func (in *interactorMock) SearchProducts(ctx context.Context, req *product.SearchRequest) (*product.SearchResult, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.SearchResult), args.Error(1)
+}
+
+func (in *interactorMock) CreateVariant(ctx context.Context, payload *product.VariantPayload) (*product.Variant, error) {
+	args := in.Called(payload)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Variant), args.Error(1)
+}
+
+func (in *interactorMock) ListVariants(ctx context.Context, id *product.ProductId) (*product.Variants, error) {
+	args := in.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Variants), args.Error(1)
+}
+
+func (in *interactorMock) CreateReview(ctx context.Context, payload *product.ReviewPayload) (*product.Review, error) {
+	args := in.Called(payload)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Review), args.Error(1)
+}
+
+func (in *interactorMock) UpdateReview(ctx context.Context, update *product.ReviewUpdate) (*product.Review, error) {
+	args := in.Called(update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Review), args.Error(1)
+}
+
+func (in *interactorMock) ModerateReview(ctx context.Context, moderation *product.ReviewModeration) (*product.Review, error) {
+	args := in.Called(moderation)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Review), args.Error(1)
+}
+
+func (in *interactorMock) ListReviews(ctx context.Context, req *product.ReviewsRequest) (*product.Reviews, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Reviews), args.Error(1)
+}
+
+func (in *interactorMock) ImportProducts(ctx context.Context, opts *product.ImportOptions, rows []*product.ProductRow) (*product.ImportSummary, error) {
+	args := in.Called(opts, rows)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.ImportSummary), args.Error(1)
+}
+
+// ExportProducts sends the rows given to Return before returning its error.
+func (in *interactorMock) ExportProducts(ctx context.Context, req *product.ExportRequest, send func(*product.ProductRow) error) error {
+	args := in.Called(req)
+	if rows, ok := args.Get(0).([]*product.ProductRow); ok {
+		for _, row := range rows {
+			if err := send(row); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (in *interactorMock) CreatePromotion(ctx context.Context, payload *product.PromotionPayload) (*product.Promotion, error) {
+	args := in.Called(payload)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Promotion), args.Error(1)
+}
+
+func (in *interactorMock) ListPromotions(ctx context.Context, req *product.StorePromotionsRequest) (*product.Promotions, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Promotions), args.Error(1)
+}
+
+func (in *interactorMock) PriceCart(ctx context.Context, req *product.PriceCartRequest) (*product.CartPrice, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.CartPrice), args.Error(1)
+}
+
+func (in *interactorMock) PlaceOrder(ctx context.Context, req *product.PlaceOrderRequest) (*product.Order, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Order), args.Error(1)
+}
+
+func (in *interactorMock) CancelOrder(ctx context.Context, req *product.OrderCancellation) (*product.Order, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Order), args.Error(1)
+}
+
+func (in *interactorMock) AddToWishlist(ctx context.Context, req *product.WishlistItemRequest) (*product.WishlistItem, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.WishlistItem), args.Error(1)
+}
+
+func (in *interactorMock) RemoveFromWishlist(ctx context.Context, req *product.WishlistItemRequest) (*emptypb.Empty, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (in *interactorMock) ListWishlist(ctx context.Context, req *product.WishlistRequest) (*product.Wishlist, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Wishlist), args.Error(1)
+}
+
+func (in *interactorMock) ExportCustomerData(ctx context.Context, req *product.CustomerDataRequest) (*product.CustomerData, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.CustomerData), args.Error(1)
+}
+
+func (in *interactorMock) EraseCustomerData(ctx context.Context, req *product.CustomerDataRequest) (*emptypb.Empty, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (in *interactorMock) CreateApiKey(ctx context.Context, payload *product.ApiKeyPayload) (*product.ApiKey, error) {
+	args := in.Called(payload)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.ApiKey), args.Error(1)
+}
+
+func (in *interactorMock) ListApiKeys(ctx context.Context, req *product.StoreApiKeysRequest) (*product.ApiKeys, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.ApiKeys), args.Error(1)
+}
+
+func (in *interactorMock) RevokeApiKey(ctx context.Context, req *product.ApiKeyRequest) (*emptypb.Empty, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (in *interactorMock) VerifyApiKey(ctx context.Context, secret *product.ApiKeySecret) (*product.ApiKeyPrincipal, error) {
+	args := in.Called(secret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.ApiKeyPrincipal), args.Error(1)
+}
+
+func (in *interactorMock) ListNotifications(ctx context.Context, req *product.NotificationsRequest) (*product.Notifications, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.Notifications), args.Error(1)
+}
+
+func (in *interactorMock) MarkNotificationsRead(ctx context.Context, req *product.NotificationsRead) (*product.NotificationsReadResult, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.NotificationsReadResult), args.Error(1)
+}
+
+func (in *interactorMock) GetNotificationPreferences(ctx context.Context, req *product.NotificationPreferencesRequest) (*product.NotificationPreferences, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.NotificationPreferences), args.Error(1)
+}
+
+func (in *interactorMock) UpdateNotificationPreferences(ctx context.Context, update *product.NotificationPreferencesUpdate) (*product.NotificationPreferences, error) {
+	args := in.Called(update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.NotificationPreferences), args.Error(1)
+}
+
+func (in *interactorMock) MoveWishlistItemToCart(ctx context.Context, req *product.WishlistItemRequest) (*product.CartItem, error) {
+	args := in.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*product.CartItem), args.Error(1)
+}
+
+var mockInteractor *interactorMock
+var client product.ProductServiceClient
+var lis *bufconn.Listener
+
+func bufDialer(ctx context.Context, s string) (net.Conn, error) {
+	return lis.Dial()
+}
+
+func TestMain(m *testing.M) {
+	lis = bufconn.Listen(1024 * 1024)
+	defer lis.Close()
+	s := grpc.NewServer()
+	defer s.Stop()
+	mockInteractor = new(interactorMock)
+	product.RegisterProductServiceServer(s, controller.NewProductServer(mockInteractor))
+	conn, err := grpc.DialContext(context.Background(), "buffnet", grpc.WithContextDialer(bufDialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+	client = product.NewProductServiceClient(conn)
+	go func() {
+		if err = s.Serve(lis); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	os.Exit(m.Run())
+}
+
+func TestCreate(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Product, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("Create", mock.Anything).Return(&product.Product{Id: 1}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Product, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(1), actual.Id)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("Create", mock.Anything).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.Product, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.Create(ctx, &product.ProductPayload{})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestAdjustStock(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.StockMovement, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("AdjustStock", mock.Anything).Return(&product.StockMovement{Quantity: 3}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovement, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(3), actual.Quantity)
+			},
+		},
+		"insufficient stock": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("AdjustStock", mock.Anything).Return(nil, interactor.ErrInsufficientStock).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovement, err error) {
+				require.Nil(t, actual)
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		"product not found": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("AdjustStock", mock.Anything).Return(nil, interactor.ErrProductNotFound).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovement, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		"invalid reason": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("AdjustStock", mock.Anything).Return(nil, interactor.ErrInvalidStockReason).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovement, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.AdjustStock(ctx, &product.StockAdjustment{ProductId: 1})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestListStockMovements(t *testing.T) {
+	movements := &product.StockMovements{
+		Movements: []*product.StockMovement{
+			{},
+			{},
+		},
+	}
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.StockMovements, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ListStockMovements", mock.Anything).Return(movements, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovements, err error) {
+				require.NoError(t, err)
+				require.Equal(t, len(movements.Movements), len(actual.Movements))
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ListStockMovements", mock.Anything).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovements, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.ListStockMovements(ctx, &product.StockMovementsRequest{ProductId: 1})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestSearchProducts(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.SearchResult, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("SearchProducts", mock.Anything).Return(&product.SearchResult{Hits: []*product.SearchHit{{}}}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.SearchResult, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Hits, 1)
+			},
+		},
+		"empty query": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("SearchProducts", mock.Anything).Return(nil, interactor.ErrEmptyQuery).Once()
+			},
+			assert: func(t *testing.T, actual *product.SearchResult, err error) {
+				require.Nil(t, actual)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("SearchProducts", mock.Anything).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.SearchResult, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.SearchProducts(ctx, &product.SearchRequest{Query: "macbook"})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestCreateVariant(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Variant, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CreateVariant", mock.Anything).Return(&product.Variant{Id: 1, Sku: "TEE-M-RED"}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Variant, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "TEE-M-RED", actual.Sku)
+			},
+		},
+		"duplicate sku": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CreateVariant", mock.Anything).Return(nil, interactor.ErrDuplicateSku).Once()
+			},
+			assert: func(t *testing.T, actual *product.Variant, err error) {
+				require.Nil(t, actual)
+				require.Equal(t, codes.AlreadyExists, status.Code(err))
+			},
+		},
+		"invalid variant": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CreateVariant", mock.Anything).Return(nil, interactor.ErrInvalidVariant).Once()
+			},
+			assert: func(t *testing.T, actual *product.Variant, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.CreateVariant(ctx, &product.VariantPayload{ProductId: 1, Sku: "TEE-M-RED"})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestListVariants(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Variants, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ListVariants", mock.Anything).Return(&product.Variants{Variants: []*product.Variant{{}, {}}}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Variants, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Variants, 2)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ListVariants", mock.Anything).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.Variants, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.ListVariants(ctx, &product.ProductId{Id: 1})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestCreateReview(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Review, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CreateReview", mock.Anything).Return(&product.Review{Id: 1, Rating: 5}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(5), actual.Rating)
+			},
+		},
+		"already reviewed": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CreateReview", mock.Anything).Return(nil, interactor.ErrReviewExists).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.Nil(t, actual)
+				require.Equal(t, codes.AlreadyExists, status.Code(err))
+			},
+		},
+		"unknown reviewer": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CreateReview", mock.Anything).Return(nil, interactor.ErrReviewerNotFound).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		"invalid rating": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CreateReview", mock.Anything).Return(nil, interactor.ErrInvalidReview).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.CreateReview(ctx, &product.ReviewPayload{ProductId: 1, Rating: 5})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestUpdateReview(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Review, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("UpdateReview", mock.Anything).Return(&product.Review{Id: 1, Status: product.ReviewStatus_PENDING}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.NoError(t, err)
+				require.Equal(t, product.ReviewStatus_PENDING, actual.Status)
+			},
+		},
+		"not found": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("UpdateReview", mock.Anything).Return(nil, interactor.ErrReviewNotFound).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.UpdateReview(ctx, &product.ReviewUpdate{Id: 1, Rating: 4})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestModerateReview(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Review, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ModerateReview", mock.Anything).Return(&product.Review{Id: 1, Status: product.ReviewStatus_APPROVED}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.NoError(t, err)
+				require.Equal(t, product.ReviewStatus_APPROVED, actual.Status)
+			},
+		},
+		"invalid status": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ModerateReview", mock.Anything).Return(nil, interactor.ErrInvalidModeration).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.ModerateReview(ctx, &product.ReviewModeration{Id: 1, Status: product.ReviewStatus_APPROVED})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestListReviews(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Reviews, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ListReviews", mock.Anything).Return(&product.Reviews{Reviews: []*product.Review{{}, {}}}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Reviews, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Reviews, 2)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ListReviews", mock.Anything).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.Reviews, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.ListReviews(ctx, &product.ReviewsRequest{ProductId: 1})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestImportProducts(t *testing.T) {
+	options := func(storeID int64) *product.ImportProductsRequest {
+		return &product.ImportProductsRequest{Payload: &product.ImportProductsRequest_Options{Options: &product.ImportOptions{StoreId: storeID}}}
+	}
+	row := func(sku string) *product.ImportProductsRequest {
+		return &product.ImportProductsRequest{Payload: &product.ImportProductsRequest_Row{Row: &product.ProductRow{Sku: sku}}}
+	}
+	testTable := map[string]struct {
+		requests []*product.ImportProductsRequest
+		arrange  func(t *testing.T)
+		assert   func(t *testing.T, actual *product.ImportSummary, err error)
+	}{
+		"succes call": {
+			requests: []*product.ImportProductsRequest{options(3), row("A"), row("B")},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ImportProducts", mock.MatchedBy(func(opts *product.ImportOptions) bool {
+					return opts.StoreId == 3
+				}), mock.MatchedBy(func(rows []*product.ProductRow) bool {
+					return len(rows) == 2 && rows[1].Sku == "B"
+				})).Return(&product.ImportSummary{Created: 2}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.ImportSummary, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(2), actual.Created)
+			},
+		},
+		"rows before options": {
+			requests: []*product.ImportProductsRequest{row("A"), options(3)},
+			arrange:  func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.ImportSummary, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		"missing store": {
+			requests: []*product.ImportProductsRequest{options(0)},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ImportProducts", mock.Anything, mock.Anything).Return(nil, interactor.ErrStoreRequired).Once()
+			},
+			assert: func(t *testing.T, actual *product.ImportSummary, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			stream, err := client.ImportProducts(ctx)
+			require.NoError(t, err)
+			for _, req := range v.requests {
+				if err = stream.Send(req); err != nil {
+					break
+				}
+			}
+			result, err := stream.CloseAndRecv()
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestExportProducts(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual []*product.ProductRow, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ExportProducts", mock.Anything).Return([]*product.ProductRow{{Sku: "A"}, {Sku: "B"}}, nil).Once()
+			},
+			assert: func(t *testing.T, actual []*product.ProductRow, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual, 2)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ExportProducts", mock.Anything).Return(nil, interactor.ErrStoreRequired).Once()
+			},
+			assert: func(t *testing.T, actual []*product.ProductRow, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+				require.Empty(t, actual)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			stream, err := client.ExportProducts(ctx, &product.ExportRequest{StoreId: 3})
+			require.NoError(t, err)
+			var rows []*product.ProductRow
+			for {
+				var row *product.ProductRow
+				row, err = stream.Recv()
+				if err != nil {
+					break
+				}
+				rows = append(rows, row)
+			}
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+
+			v.assert(t, rows, err)
+		})
+	}
+}
+
+func TestCreatePromotion(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Promotion, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CreatePromotion", mock.Anything).Return(&product.Promotion{Id: 1, CouponCode: "FIVE"}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotion, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "FIVE", actual.CouponCode)
+			},
+		},
+		"duplicate coupon": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CreatePromotion", mock.Anything).Return(nil, interactor.ErrDuplicateCoupon).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotion, err error) {
+				require.Nil(t, actual)
+				require.Equal(t, codes.AlreadyExists, status.Code(err))
+			},
+		},
+		"invalid promotion": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CreatePromotion", mock.Anything).Return(nil, interactor.ErrInvalidPromotion).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotion, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		"not the owner": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CreatePromotion", mock.Anything).Return(nil, interactor.ErrNotStoreOwner).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotion, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.CreatePromotion(ctx, &product.PromotionPayload{Name: "Five off", StoreId: 3, CouponCode: "FIVE"})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestListPromotions(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Promotions, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ListPromotions", mock.Anything).Return(&product.Promotions{Promotions: []*product.Promotion{{}, {}}}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotions, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Promotions, 2)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ListPromotions", mock.Anything).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotions, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.ListPromotions(ctx, &product.StorePromotionsRequest{StoreId: 3})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestPriceCart(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.CartPrice, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("PriceCart", mock.Anything).Return(&product.CartPrice{Total: &product.Money{MinorUnits: 3750, Currency: "USD"}}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.CartPrice, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(3750), actual.Total.MinorUnits)
+			},
+		},
+		"unknown coupon": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("PriceCart", mock.Anything).Return(nil, interactor.ErrCouponNotFound).Once()
+			},
+			assert: func(t *testing.T, actual *product.CartPrice, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		"coupon not applicable": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("PriceCart", mock.Anything).Return(nil, promotion.ErrCouponNotApplicable).Once()
+			},
+			assert: func(t *testing.T, actual *product.CartPrice, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		"empty cart": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("PriceCart", mock.Anything).Return(nil, promotion.ErrEmptyCart).Once()
+			},
+			assert: func(t *testing.T, actual *product.CartPrice, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.PriceCart(ctx, &product.PriceCartRequest{Items: []*product.CartItem{{ProductId: 1, Quantity: 1}}})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestPlaceOrder(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Order, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("PlaceOrder", mock.Anything).Return(&product.Order{Id: 12, Status: "pending"}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(12), actual.Id)
+			},
+		},
+		"promotion used up": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("PlaceOrder", mock.Anything).Return(nil, interactor.ErrPromotionExhausted).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		"unknown customer": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("PlaceOrder", mock.Anything).Return(nil, interactor.ErrCustomerNotFound).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		"out of stock": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("PlaceOrder", mock.Anything).Return(nil, interactor.ErrInsufficientStock).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.PlaceOrder(ctx, &product.PlaceOrderRequest{UserEmail: "jane@mail.com", Items: []*product.CartItem{{ProductId: 1, Quantity: 1}}})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Order, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CancelOrder", mock.Anything).Return(&product.Order{Id: 12, Status: "cancelled"}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "cancelled", actual.Status)
+			},
+		},
+		"not pending": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CancelOrder", mock.Anything).Return(nil, interactor.ErrOrderNotCancellable).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		"unknown customer": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CancelOrder", mock.Anything).Return(nil, interactor.ErrCustomerNotFound).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.CancelOrder(ctx, &product.OrderCancellation{Id: 12, UserEmail: "jane@mail.com"})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestWishlist(t *testing.T) {
+	req := &product.WishlistItemRequest{UserEmail: "jane@mail.com", ProductId: 1}
+	testTable := map[string]struct {
+		call    func(ctx context.Context) error
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, err error)
+	}{
+		"add": {
+			call: func(ctx context.Context) error {
+				_, err := client.AddToWishlist(ctx, req)
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("AddToWishlist", mock.Anything).Return(&product.WishlistItem{Id: 4}, nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"add unknown product": {
+			call: func(ctx context.Context) error {
+				_, err := client.AddToWishlist(ctx, req)
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("AddToWishlist", mock.Anything).Return(nil, interactor.ErrProductNotFound).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		"remove item not saved": {
+			call: func(ctx context.Context) error {
+				_, err := client.RemoveFromWishlist(ctx, req)
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("RemoveFromWishlist", mock.Anything).Return(nil, interactor.ErrWishlistItemNotFound).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		"list": {
+			call: func(ctx context.Context) error {
+				_, err := client.ListWishlist(ctx, &product.WishlistRequest{UserEmail: "jane@mail.com"})
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ListWishlist", mock.Anything).Return(&product.Wishlist{}, nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"move to cart": {
+			call: func(ctx context.Context) error {
+				_, err := client.MoveWishlistItemToCart(ctx, req)
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("MoveWishlistItemToCart", mock.Anything).Return(&product.CartItem{ProductId: 1, Quantity: 1}, nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			err := v.call(ctx)
+
+			v.assert(t, err)
+		})
+	}
+}
+
+func TestCustomerData(t *testing.T) {
+	req := &product.CustomerDataRequest{UserEmail: "jane@mail.com"}
+	testTable := map[string]struct {
+		call    func(ctx context.Context) error
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, err error)
+	}{
+		"export": {
+			call: func(ctx context.Context) error {
+				data, err := client.ExportCustomerData(ctx, req)
+				if err == nil {
+					require.Len(t, data.Orders, 1)
+				}
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ExportCustomerData", mock.Anything).Return(&product.CustomerData{Orders: []*product.Order{{Id: 12}}}, nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"export unknown account": {
+			call: func(ctx context.Context) error {
+				_, err := client.ExportCustomerData(ctx, req)
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ExportCustomerData", mock.Anything).Return(nil, interactor.ErrAccountNotFound).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		"erase": {
+			call: func(ctx context.Context) error {
+				_, err := client.EraseCustomerData(ctx, req)
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("EraseCustomerData", mock.Anything).Return(&emptypb.Empty{}, nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"erase fails": {
+			call: func(ctx context.Context) error {
+				_, err := client.EraseCustomerData(ctx, req)
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("EraseCustomerData", mock.Anything).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			err := v.call(ctx)
+
+			v.assert(t, err)
+		})
+	}
+}
+
+func TestApiKeys(t *testing.T) {
+	testTable := map[string]struct {
+		call    func(ctx context.Context) error
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, err error)
+	}{
+		"create": {
+			call: func(ctx context.Context) error {
+				key, err := client.CreateApiKey(ctx, &product.ApiKeyPayload{StoreId: 1, OwnerEmail: "owner@mail.com", Name: "erp", Scopes: []string{"catalog:write"}})
+				if err == nil && key.Key != "rpk_abcdefgh_secret" {
+					return errors.New("the key is returned once")
+				}
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CreateApiKey", mock.Anything).Return(&product.ApiKey{Id: 1, Prefix: "rpk_abcdefgh", Key: "rpk_abcdefgh_secret"}, nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"create with an unknown scope": {
+			call: func(ctx context.Context) error {
+				_, err := client.CreateApiKey(ctx, &product.ApiKeyPayload{StoreId: 1, Scopes: []string{"orders:write"}})
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("CreateApiKey", mock.Anything).Return(nil, interactor.ErrInvalidApiKey).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		"list for someone else's store": {
+			call: func(ctx context.Context) error {
+				_, err := client.ListApiKeys(ctx, &product.StoreApiKeysRequest{StoreId: 1, OwnerEmail: "other@mail.com"})
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ListApiKeys", mock.Anything).Return(nil, interactor.ErrNotStoreOwner).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		"revoke unknown": {
+			call: func(ctx context.Context) error {
+				_, err := client.RevokeApiKey(ctx, &product.ApiKeyRequest{StoreId: 1, Id: 9})
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("RevokeApiKey", mock.Anything).Return(nil, interactor.ErrApiKeyNotFound).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		"verify rejected": {
+			call: func(ctx context.Context) error {
+				_, err := client.VerifyApiKey(ctx, &product.ApiKeySecret{Key: "rpk_abcdefgh_wrong"})
+				return err
+			},
+			arrange: func(t *testing.T) {
+				mockInteractor.On("VerifyApiKey", mock.Anything).Return(nil, interactor.ErrApiKeyRejected).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			err := v.call(ctx)
+
+			v.assert(t, err)
+		})
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Notifications, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ListNotifications", mock.Anything).Return(&product.Notifications{
+					Notifications: []*product.Notification{{Id: 3, Kind: "order_placed"}},
+					Unread:        1,
+				}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Notifications, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Notifications, 1)
+				require.Equal(t, int64(1), actual.Unread)
+			},
+		},
+		"unknown customer": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ListNotifications", mock.Anything).Return(nil, interactor.ErrCustomerNotFound).Once()
+			},
+			assert: func(t *testing.T, actual *product.Notifications, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.ListNotifications(ctx, &product.NotificationsRequest{UserEmail: "jane@mail.com"})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestUpdateNotificationPreferences(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.NotificationPreferences, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("UpdateNotificationPreferences", mock.Anything).Return(&product.NotificationPreferences{
+					Preferences: []*product.NotificationPreference{{Category: "orders", InApp: true}},
+				}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.NotificationPreferences, err error) {
+				require.NoError(t, err)
+				require.False(t, actual.Preferences[0].Email)
+			},
+		},
+		"unknown category": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("UpdateNotificationPreferences", mock.Anything).Return(nil, notification.ErrUnknownCategory).Once()
+			},
+			assert: func(t *testing.T, actual *product.NotificationPreferences, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.UpdateNotificationPreferences(ctx, &product.NotificationPreferencesUpdate{
+				UserEmail:   "jane@mail.com",
+				Preferences: []*product.NotificationPreference{{Category: "orders", InApp: true}},
+			})
+
+			v.assert(t, result, err)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	_ "github.com/jackc/pgx/v5"
+	_ "github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/ryanpujo/product-service/internal/audit"
+	"github.com/ryanpujo/product-service/internal/messaging"
+	"github.com/ryanpujo/product-service/internal/notification"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+)
+
+type application struct {
+	Config config
+}
+
+func Application() application {
+	viper.SetConfigName("config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath(".")
+	viper.AddConfigPath("/app/")
+	viper.SetDefault("events.driver", "nats")
+	viper.SetDefault("events.url", "nats://nats-srv:4222")
+	viper.SetDefault("events.relay_interval", time.Second)
+	viper.SetDefault("notifications.email", "log")
+	viper.SetDefault("notifications.smtp_from", "RPApp <no-reply@rpapp.io>")
+	if err := viper.ReadInConfig(); err != nil {
+		panic(err)
+	}
+	return application{
+		Config: config{
+			GRPC_PORT:      viper.GetInt("port"),
+			DSN:            viper.GetString("dsn"),
+			EVENTS_DRIVER:  viper.GetString("events.driver"),
+			EVENTS_URL:     viper.GetString("events.url"),
+			RELAY_INTERVAL: viper.GetDuration("events.relay_interval"),
+			NOTIFY_EMAIL:   viper.GetString("notifications.email"),
+			SMTP_URL:       viper.GetString("notifications.smtp_url"),
+			SMTP_FROM:      viper.GetString("notifications.smtp_from"),
+		},
+	}
+}
+
+func (app *application) StartGrpcServer(server product.ProductServiceServer) (func(), error) {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", app.Config.GRPC_PORT))
+	if err != nil {
+		return func() {}, err
+	}
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(audit.UnaryServerInterceptor),
+		grpc.StreamInterceptor(audit.StreamServerInterceptor),
+	)
+	product.RegisterProductServiceServer(s, server)
+
+	if err = s.Serve(lis); err != nil {
+		return func() {
+			lis.Close()
+			s.Stop()
+		}, err
+	}
+
+	return func() {
+		lis.Close()
+		s.Stop()
+	}, nil
+}
+
+// StartRelay publishes the events of the outbox once every relay interval,
+// the returned func stops it. An event that failed is tried again on the
+// next tick.
+func (app *application) StartRelay(relay *messaging.Relay) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(app.Config.RELAY_INTERVAL)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			if _, err := relay.Flush(ctx); err != nil && ctx.Err() == nil {
+				log.Println("failed to publish events:", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		relay.Publisher.Close()
+	}
+}
+
+// StartMailer sends the queued notification emails once every relay
+// interval, the returned func stops it. An email that failed is tried again
+// on the next tick.
+func (app *application) StartMailer(mailer *notification.Mailer) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(app.Config.RELAY_INTERVAL)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			if _, err := mailer.Flush(ctx); err != nil && ctx.Err() == nil {
+				log.Println("failed to send emails:", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return cancel
+}
+
+// StartListener hands the messages of the subscribed topics to the consumers
+// until the returned func is called.
+func (app *application) StartListener(subscriber messaging.Subscriber) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		if err := subscriber.Listen(ctx); err != nil {
+			log.Println("stopped listening for events:", err)
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,20 @@
+package infrastructure
+
+import "time"
+
+type config struct {
+	GRPC_PORT int
+	DSN       string
+	// EVENTS_DRIVER picks where the relay publishes the outbox, "memory"
+	// keeps the events in the process and "nats" sends them to EVENTS_URL.
+	// The consumers of the user events need "nats", the service does not
+	// start with "memory".
+	EVENTS_DRIVER  string
+	EVENTS_URL     string
+	RELAY_INTERVAL time.Duration
+	// NOTIFY_EMAIL picks how notification emails go out, "log" only logs
+	// them and "smtp" sends them through SMTP_URL from SMTP_FROM.
+	NOTIFY_EMAIL string
+	SMTP_URL     string
+	SMTP_FROM    string
+}
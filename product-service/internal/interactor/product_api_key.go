@@ -0,0 +1,228 @@
+package interactor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrInvalidApiKey  = errors.New("invalid api key")
+	ErrApiKeyNotFound = errors.New("api key not found")
+	// ErrApiKeyRejected is all a caller learns about a key that is unknown,
+	// revoked or expired.
+	ErrApiKeyRejected = errors.New("api key is not valid")
+)
+
+// The scopes an API key can be given.
+const (
+	ScopeCatalogRead     = "catalog:read"
+	ScopeCatalogWrite    = "catalog:write"
+	ScopePromotionsRead  = "promotions:read"
+	ScopePromotionsWrite = "promotions:write"
+)
+
+var apiKeyScopes = map[string]bool{
+	ScopeCatalogRead:     true,
+	ScopeCatalogWrite:    true,
+	ScopePromotionsRead:  true,
+	ScopePromotionsWrite: true,
+}
+
+// An API key reads "rpk_" followed by an 8 character prefix, an underscore
+// and the secret. The prefix is stored as is to find the key, the whole key
+// only hashed.
+const (
+	apiKeyMarker    = "rpk_"
+	apiKeyPrefixLen = len(apiKeyMarker) + 8
+)
+
+var apiKeyPrefixEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// CreateApiKey makes a key for an integration of the store. The key is only
+// returned here, later only its prefix is shown.
+func (in *productInteractor) CreateApiKey(ctx context.Context, payload *product.ApiKeyPayload) (*product.ApiKey, error) {
+	if err := in.checkStoreOwner(ctx, payload.StoreId, payload.OwnerEmail); err != nil {
+		return nil, err
+	}
+	params, err := toCreateApiKeyParams(payload)
+	if err != nil {
+		return nil, err
+	}
+	key, err := newApiKey()
+	if err != nil {
+		return nil, err
+	}
+	params.Prefix = key[:apiKeyPrefixLen]
+	params.KeyHash = hashApiKey(key)
+
+	var result *product.ApiKey
+	err = in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		created, err := q.CreateApiKey(ctx, params)
+		if err != nil {
+			return err
+		}
+		result = toApiKey(created)
+		return in.audit(ctx, q, AuditApiKeyCreate, "api_key", result.Id, map[string]interface{}{
+			"storeId": result.StoreId,
+			"name":    result.Name,
+			"prefix":  result.Prefix,
+			"scopes":  result.Scopes,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Key = key
+	return result, nil
+}
+
+func (in *productInteractor) ListApiKeys(ctx context.Context, req *product.StoreApiKeysRequest) (*product.ApiKeys, error) {
+	if err := in.checkStoreOwner(ctx, req.StoreId, req.OwnerEmail); err != nil {
+		return nil, err
+	}
+	keys, err := in.Repo.ListStoreApiKeys(ctx, int32(req.StoreId))
+	if err != nil {
+		return nil, err
+	}
+	result := product.ApiKeys{Keys: make([]*product.ApiKey, 0, len(keys))}
+	for _, k := range keys {
+		result.Keys = append(result.Keys, toApiKey(k))
+	}
+	return &result, nil
+}
+
+func (in *productInteractor) RevokeApiKey(ctx context.Context, req *product.ApiKeyRequest) (*emptypb.Empty, error) {
+	if err := in.checkStoreOwner(ctx, req.StoreId, req.OwnerEmail); err != nil {
+		return nil, err
+	}
+	err := in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		revoked, err := q.RevokeApiKey(ctx, repository.RevokeApiKeyParams{ID: int32(req.Id), StoreID: int32(req.StoreId)})
+		if err != nil {
+			return err
+		}
+		if revoked == 0 {
+			return ErrApiKeyNotFound
+		}
+		return in.audit(ctx, q, AuditApiKeyRevoke, "api_key", req.Id, map[string]interface{}{"storeId": req.StoreId, "revoked": true})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// VerifyApiKey returns the store and scopes of a key and records that it was
+// used. Requests made with the key act for the owner of the store.
+func (in *productInteractor) VerifyApiKey(ctx context.Context, secret *product.ApiKeySecret) (*product.ApiKeyPrincipal, error) {
+	key := strings.TrimSpace(secret.Key)
+	if !strings.HasPrefix(key, apiKeyMarker) || len(key) <= apiKeyPrefixLen || key[apiKeyPrefixLen] != '_' {
+		return nil, ErrApiKeyRejected
+	}
+	stored, err := in.Repo.GetApiKeyByPrefix(ctx, key[:apiKeyPrefixLen])
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrApiKeyRejected
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(stored.KeyHash), []byte(hashApiKey(key))) != 1 {
+		return nil, ErrApiKeyRejected
+	}
+	if stored.RevokedAt.Valid || (stored.ExpiresAt.Valid && !time.Now().Before(stored.ExpiresAt.Time)) || !stored.OwnerEmail.Valid {
+		return nil, ErrApiKeyRejected
+	}
+	if err = in.Repo.TouchApiKey(ctx, stored.ID); err != nil {
+		return nil, err
+	}
+	return &product.ApiKeyPrincipal{
+		KeyId:      int64(stored.ID),
+		StoreId:    int64(stored.StoreID),
+		OwnerEmail: stored.OwnerEmail.String,
+		Scopes:     stored.Scopes,
+	}, nil
+}
+
+func toCreateApiKeyParams(payload *product.ApiKeyPayload) (repository.CreateApiKeyParams, error) {
+	params := repository.CreateApiKeyParams{
+		StoreID: int32(payload.StoreId),
+		Name:    strings.TrimSpace(payload.Name),
+	}
+	if params.Name == "" {
+		return params, fmt.Errorf("%w: name is required", ErrInvalidApiKey)
+	}
+	if len(payload.Scopes) == 0 {
+		return params, fmt.Errorf("%w: at least one scope is required", ErrInvalidApiKey)
+	}
+	seen := make(map[string]bool, len(payload.Scopes))
+	for _, scope := range payload.Scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !apiKeyScopes[scope] {
+			return params, fmt.Errorf("%w: unknown scope %q", ErrInvalidApiKey, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			params.Scopes = append(params.Scopes, scope)
+		}
+	}
+	sort.Strings(params.Scopes)
+	if payload.ExpiresAt != nil {
+		expiresAt := payload.ExpiresAt.AsTime()
+		if !expiresAt.After(time.Now()) {
+			return params, fmt.Errorf("%w: a key must expire in the future", ErrInvalidApiKey)
+		}
+		params.ExpiresAt = sql.NullTime{Time: expiresAt, Valid: true}
+	}
+	return params, nil
+}
+
+// newApiKey returns a key with a 40 bit prefix and a 256 bit secret.
+func newApiKey() (string, error) {
+	prefix := make([]byte, 5)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return apiKeyMarker + strings.ToLower(apiKeyPrefixEncoding.EncodeToString(prefix)) + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func toApiKey(k repository.ApiKey) *product.ApiKey {
+	result := product.ApiKey{
+		Id:        int64(k.ID),
+		StoreId:   int64(k.StoreID),
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: timestamppb.New(k.CreatedAt),
+	}
+	if k.ExpiresAt.Valid {
+		result.ExpiresAt = timestamppb.New(k.ExpiresAt.Time)
+	}
+	if k.LastUsedAt.Valid {
+		result.LastUsedAt = timestamppb.New(k.LastUsedAt.Time)
+	}
+	return &result
+}
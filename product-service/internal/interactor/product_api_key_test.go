@@ -0,0 +1,182 @@
+package interactor_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCreateApiKey(t *testing.T) {
+	testTable := map[string]struct {
+		payload *product.ApiKeyPayload
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.ApiKey, err error)
+	}{
+		"succes call": {
+			payload: &product.ApiKeyPayload{StoreId: 3, OwnerEmail: "owner@mail.com", Name: " ERP ", Scopes: []string{"Catalog:Write", "catalog:read", "catalog:write"}},
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Once()
+				repo.On("CreateApiKey", mock.MatchedBy(func(arg repository.CreateApiKeyParams) bool {
+					return arg.StoreID == 3 && arg.Name == "ERP" &&
+						strings.HasPrefix(arg.Prefix, "rpk_") && len(arg.Prefix) == 12 &&
+						len(arg.KeyHash) == 64 &&
+						len(arg.Scopes) == 2 && arg.Scopes[0] == "catalog:read"
+				})).Return(repository.ApiKey{ID: 1, StoreID: 3, Name: "ERP", Prefix: "rpk_abcdefgh", Scopes: []string{"catalog:read", "catalog:write"}}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.ApiKey, err error) {
+				require.NoError(t, err)
+				require.Regexp(t, `^rpk_[a-z2-7]{8}_[A-Za-z0-9_-]{43}$`, actual.Key)
+				require.Nil(t, actual.ExpiresAt)
+			},
+		},
+		"unknown scope": {
+			payload: &product.ApiKeyPayload{StoreId: 3, OwnerEmail: "owner@mail.com", Name: "ERP", Scopes: []string{"orders:write"}},
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.ApiKey, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidApiKey)
+			},
+		},
+		"already expired": {
+			payload: &product.ApiKeyPayload{StoreId: 3, OwnerEmail: "owner@mail.com", Name: "ERP", Scopes: []string{"catalog:read"}, ExpiresAt: timestamppb.New(time.Now().Add(-time.Hour))},
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.ApiKey, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidApiKey)
+			},
+		},
+		"not the owner": {
+			payload: &product.ApiKeyPayload{StoreId: 3, OwnerEmail: "owner@mail.com", Name: "ERP", Scopes: []string{"catalog:read"}},
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(false, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.ApiKey, err error) {
+				require.ErrorIs(t, err, interactor.ErrNotStoreOwner)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := productInteractor.CreateApiKey(context.Background(), v.payload)
+
+			v.assert(t, actual, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestRevokeApiKey(t *testing.T) {
+	req := &product.ApiKeyRequest{StoreId: 3, OwnerEmail: "owner@mail.com", Id: 1}
+	repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Twice()
+	repo.On("RevokeApiKey", repository.RevokeApiKeyParams{ID: 1, StoreID: 3}).Return(int64(1), nil).Once()
+	repo.On("RevokeApiKey", repository.RevokeApiKeyParams{ID: 1, StoreID: 3}).Return(int64(0), nil).Once()
+
+	_, err := productInteractor.RevokeApiKey(context.Background(), req)
+	require.NoError(t, err)
+	_, err = productInteractor.RevokeApiKey(context.Background(), req)
+	require.ErrorIs(t, err, interactor.ErrApiKeyNotFound)
+	repo.AssertExpectations(t)
+}
+
+func TestVerifyApiKey(t *testing.T) {
+	const key = "rpk_abcdefgh_c2VjcmV0"
+	sum := sha256.Sum256([]byte(key))
+	stored := repository.GetApiKeyByPrefixRow{
+		ID:         1,
+		StoreID:    3,
+		KeyHash:    hex.EncodeToString(sum[:]),
+		Scopes:     []string{"catalog:write"},
+		OwnerEmail: sql.NullString{String: "owner@mail.com", Valid: true},
+	}
+	testTable := map[string]struct {
+		key     string
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.ApiKeyPrincipal, err error)
+	}{
+		"succes call": {
+			key: key,
+			arrange: func(t *testing.T) {
+				repo.On("GetApiKeyByPrefix", "rpk_abcdefgh").Return(stored, nil).Once()
+				repo.On("TouchApiKey", int32(1)).Return(nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.ApiKeyPrincipal, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(3), actual.StoreId)
+				require.Equal(t, "owner@mail.com", actual.OwnerEmail)
+				require.Equal(t, []string{"catalog:write"}, actual.Scopes)
+			},
+		},
+		"wrong secret": {
+			key: "rpk_abcdefgh_d3Jvbmc",
+			arrange: func(t *testing.T) {
+				repo.On("GetApiKeyByPrefix", "rpk_abcdefgh").Return(stored, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.ApiKeyPrincipal, err error) {
+				require.ErrorIs(t, err, interactor.ErrApiKeyRejected)
+			},
+		},
+		"revoked": {
+			key: key,
+			arrange: func(t *testing.T) {
+				revoked := stored
+				revoked.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				repo.On("GetApiKeyByPrefix", "rpk_abcdefgh").Return(revoked, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.ApiKeyPrincipal, err error) {
+				require.ErrorIs(t, err, interactor.ErrApiKeyRejected)
+			},
+		},
+		"expired": {
+			key: key,
+			arrange: func(t *testing.T) {
+				expired := stored
+				expired.ExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+				repo.On("GetApiKeyByPrefix", "rpk_abcdefgh").Return(expired, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.ApiKeyPrincipal, err error) {
+				require.ErrorIs(t, err, interactor.ErrApiKeyRejected)
+			},
+		},
+		"unknown": {
+			key: key,
+			arrange: func(t *testing.T) {
+				repo.On("GetApiKeyByPrefix", "rpk_abcdefgh").Return(repository.GetApiKeyByPrefixRow{}, sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.ApiKeyPrincipal, err error) {
+				require.ErrorIs(t, err, interactor.ErrApiKeyRejected)
+			},
+		},
+		"not a key": {
+			key:     "Bearer something",
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.ApiKeyPrincipal, err error) {
+				require.ErrorIs(t, err, interactor.ErrApiKeyRejected)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := productInteractor.VerifyApiKey(context.Background(), &product.ApiKeySecret{Key: v.key})
+
+			v.assert(t, actual, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
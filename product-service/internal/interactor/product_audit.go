@@ -0,0 +1,45 @@
+package interactor
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/ryanpujo/product-service/internal/audit"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+)
+
+// The actions this service writes to the audit log.
+const (
+	AuditProductCreate   = "product.create"
+	AuditVariantCreate   = "variant.create"
+	AuditStockAdjust     = "stock.adjust"
+	AuditReviewModerate  = "review.moderate"
+	AuditCatalogImport   = "catalog.import"
+	AuditPromotionCreate = "promotion.create"
+	AuditApiKeyCreate    = "api_key.create"
+	AuditApiKeyRevoke    = "api_key.revoke"
+	AuditCustomerErase   = "customer.erase"
+	AuditOrderPlace      = "order.place"
+	AuditOrderCancel     = "order.cancel"
+)
+
+// audit records a change to the target of the given type and id with q, the
+// querier of the transaction making the change. The actor comes with the
+// request.
+func (in *productInteractor) audit(ctx context.Context, q repository.Querier, action, targetType string, targetID int64, after map[string]interface{}) error {
+	return audit.Record(ctx, in.Audit, q, audit.Entry{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   strconv.FormatInt(targetID, 10),
+		After:      after,
+	})
+}
+
+// auditedMoney keeps amounts as they are stored, in minor units.
+func auditedMoney(m *product.Money) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	return map[string]interface{}{"minorUnits": m.MinorUnits, "currency": m.Currency}
+}
@@ -0,0 +1,52 @@
+package interactor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ryanpujo/product-service/internal/audit"
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingAuditor keeps the entries it is given.
+type recordingAuditor struct {
+	entries []audit.Entry
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, q repository.Querier, entry audit.Entry) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func TestAuditRevokeApiKey(t *testing.T) {
+	auditor := &recordingAuditor{}
+	in := interactor.NewProductInteractor(repo)
+	in.Audit = auditor
+
+	req := &product.ApiKeyRequest{StoreId: 3, OwnerEmail: "owner@mail.com", Id: 1}
+	repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Twice()
+	repo.On("RevokeApiKey", repository.RevokeApiKeyParams{ID: 1, StoreID: 3}).Return(int64(1), nil).Once()
+	repo.On("RevokeApiKey", repository.RevokeApiKeyParams{ID: 1, StoreID: 3}).Return(int64(0), nil).Once()
+
+	ctx := audit.WithRequest(context.Background(), audit.Request{ID: "req-1", IP: "10.0.0.1", Actor: "owner@mail.com"})
+	_, err := in.RevokeApiKey(ctx, req)
+	require.NoError(t, err)
+	// nothing was revoked, so nothing is recorded
+	_, err = in.RevokeApiKey(ctx, req)
+	require.ErrorIs(t, err, interactor.ErrApiKeyNotFound)
+
+	require.Equal(t, []audit.Entry{{
+		Actor:      "owner@mail.com",
+		Action:     interactor.AuditApiKeyRevoke,
+		TargetType: "api_key",
+		TargetID:   "1",
+		Before:     map[string]interface{}{},
+		After:      map[string]interface{}{"storeId": int64(3), "revoked": true},
+		RequestID:  "req-1",
+		IP:         "10.0.0.1",
+	}}, auditor.entries)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,86 @@
+package interactor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ryanpujo/product-service/internal/messaging"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/events"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+)
+
+// The consumers of the events this service reacts to.
+const (
+	ConsumerCartCleanup  = "cart-cleanup"
+	ConsumerStockRelease = "stock-release"
+)
+
+// Consumers returns the event consumers of the service, the caller subscribes
+// each to its topic.
+func (in *productInteractor) Consumers() []*messaging.Consumer {
+	release := messaging.NewConsumer(ConsumerStockRelease, messaging.TopicOrderCancelled, in.Repo, in.ReleaseStock)
+	release.Committed = in.stockReleased
+	consumers := []*messaging.Consumer{
+		messaging.NewConsumer(ConsumerCartCleanup, messaging.TopicUserDeleted, in.Repo, in.CleanupCart),
+		release,
+	}
+	return append(consumers, in.notificationConsumers()...)
+}
+
+// ReleaseStock puts the items of a cancelled order back into stock and
+// records them in the ledger as returned. It is the handler of the
+// stock-release consumer.
+func (in *productInteractor) ReleaseStock(ctx context.Context, q repository.Querier, envelope *events.Envelope) error {
+	cancelled := envelope.GetOrderCancelled()
+	if cancelled == nil {
+		return fmt.Errorf("%w: %T", messaging.ErrUnknownEvent, envelope.Event)
+	}
+	reference := fmt.Sprintf("order:%d", cancelled.OrderId)
+	for _, item := range cancelled.Items {
+		productID, variantID := int32(item.ProductId), int32(item.VariantId)
+		var err error
+		if variantID != 0 {
+			_, err = q.AddVariantStock(ctx, repository.AddVariantStockParams{ID: variantID, ProductID: productID, Quantity: item.Quantity})
+		} else {
+			_, err = q.AddProductStock(ctx, repository.AddProductStockParams{ID: productID, Quantity: item.Quantity})
+		}
+		if err != nil {
+			return err
+		}
+		_, err = q.CreateStockMovement(ctx, repository.CreateStockMovementParams{
+			ProductID: productID,
+			VariantID: sql.NullInt32{Int32: variantID, Valid: variantID != 0},
+			Quantity:  item.Quantity,
+			Reason:    stockReasons[product.StockReason_RETURN],
+			Reference: sql.NullString{String: reference, Valid: true},
+			Note:      sql.NullString{String: "order cancelled", Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stockReleased tells the customers waiting for the products of a cancelled
+// order that they are back in stock, once the stock is committed.
+func (in *productInteractor) stockReleased(ctx context.Context, envelope *events.Envelope) {
+	cancelled := envelope.GetOrderCancelled()
+	productIDs := make([]int32, 0, len(cancelled.GetItems()))
+	for _, item := range cancelled.GetItems() {
+		productIDs = append(productIDs, int32(item.ProductId))
+	}
+	in.notifyWishlists(ctx, productIDs)
+}
+
+// CleanupCart empties the cart of a deleted user. It is the handler of the
+// cart-cleanup consumer.
+func (in *productInteractor) CleanupCart(ctx context.Context, q repository.Querier, envelope *events.Envelope) error {
+	deleted := envelope.GetUserDeleted()
+	if deleted == nil {
+		return fmt.Errorf("%w: %T", messaging.ErrUnknownEvent, envelope.Event)
+	}
+	return q.DeleteCartItemsByUser(ctx, int32(deleted.UserId))
+}
@@ -0,0 +1,119 @@
+package interactor_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/messaging"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseStock(t *testing.T) {
+	envelope := &events.Envelope{Id: "event-1", Event: &events.Envelope_OrderCancelled{OrderCancelled: &events.OrderCancelled{
+		OrderId: 12,
+		Items: []*events.OrderItem{
+			{ProductId: 1, Quantity: 2},
+			{ProductId: 2, VariantId: 4, Quantity: 1},
+		},
+	}}}
+	testTable := map[string]struct {
+		envelope *events.Envelope
+		arrange  func(t *testing.T)
+		assert   func(t *testing.T, err error)
+	}{
+		"succes call": {
+			envelope: envelope,
+			arrange: func(t *testing.T) {
+				repo.On("AddProductStock", repository.AddProductStockParams{ID: 1, Quantity: 2}).Return(sql.NullInt32{Int32: 5, Valid: true}, nil).Once()
+				repo.On("AddVariantStock", repository.AddVariantStockParams{ID: 4, ProductID: 2, Quantity: 1}).Return(int32(3), nil).Once()
+				repo.On("CreateStockMovement", repository.CreateStockMovementParams{
+					ProductID: 1,
+					Quantity:  2,
+					Reason:    "return",
+					Reference: sql.NullString{String: "order:12", Valid: true},
+					Note:      sql.NullString{String: "order cancelled", Valid: true},
+				}).Return(repository.StockMovement{}, nil).Once()
+				repo.On("CreateStockMovement", repository.CreateStockMovementParams{
+					ProductID: 2,
+					VariantID: sql.NullInt32{Int32: 4, Valid: true},
+					Quantity:  1,
+					Reason:    "return",
+					Reference: sql.NullString{String: "order:12", Valid: true},
+					Note:      sql.NullString{String: "order cancelled", Valid: true},
+				}).Return(repository.StockMovement{}, nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"fail call": {
+			envelope: envelope,
+			arrange: func(t *testing.T) {
+				repo.On("AddProductStock", repository.AddProductStockParams{ID: 1, Quantity: 2}).Return(sql.NullInt32{}, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Error(t, err)
+			},
+		},
+		"other event": {
+			envelope: &events.Envelope{Id: "event-2", Event: &events.Envelope_OrderPlaced{OrderPlaced: &events.OrderPlaced{}}},
+			arrange:  func(t *testing.T) {},
+			assert: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, messaging.ErrUnknownEvent)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			err := interactor.NewProductInteractor(repo).ReleaseStock(context.Background(), repo, v.envelope)
+
+			v.assert(t, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestStockReleasedNotifiesWishlists(t *testing.T) {
+	envelope := &events.Envelope{Id: "event-1", Event: &events.Envelope_OrderCancelled{OrderCancelled: &events.OrderCancelled{
+		OrderId: 12,
+		Items:   []*events.OrderItem{{ProductId: 1, Quantity: 2}, {ProductId: 2, VariantId: 4, Quantity: 1}},
+	}}}
+	repo.On("SyncWishlistItems", []int32{1, 2}).Return([]repository.SyncWishlistItemsRow{}, nil).Once()
+
+	for _, c := range interactor.NewProductInteractor(repo).Consumers() {
+		if c.Name == interactor.ConsumerStockRelease {
+			c.Committed(context.Background(), envelope)
+		}
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestCleanupCart(t *testing.T) {
+	repo.On("DeleteCartItemsByUser", int32(5)).Return(nil).Once()
+	envelope := &events.Envelope{Id: "event-1", Event: &events.Envelope_UserDeleted{UserDeleted: &events.UserDeleted{UserId: 5}}}
+
+	err := interactor.NewProductInteractor(repo).CleanupCart(context.Background(), repo, envelope)
+
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestConsumers(t *testing.T) {
+	consumers := interactor.NewProductInteractor(repo).Consumers()
+
+	topics := map[string]string{}
+	for _, c := range consumers {
+		topics[c.Name] = c.Topic
+	}
+	require.Equal(t, map[string]string{
+		interactor.ConsumerCartCleanup:  messaging.TopicUserDeleted,
+		interactor.ConsumerStockRelease: messaging.TopicOrderCancelled,
+	}, topics)
+}
@@ -0,0 +1,222 @@
+package interactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/ryanpujo/product-service/internal/money"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var ErrAccountNotFound = errors.New("account not found")
+
+// ExportCustomerData collects the orders, reviews, cart, wishlist and
+// notifications of a user to answer a data subject request.
+func (in *productInteractor) ExportCustomerData(ctx context.Context, req *product.CustomerDataRequest) (*product.CustomerData, error) {
+	userID, err := accountID(ctx, in.Repo, req.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := in.Repo.ListOrdersByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	orderItems, err := in.Repo.ListOrderItemsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := in.Repo.ListReviewsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cart, err := in.Repo.ListCartItemsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	wishlist, err := in.Repo.ListWishlistItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	notifications, err := in.allNotifications(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := product.CustomerData{
+		Orders:        make([]*product.Order, 0, len(orders)),
+		Reviews:       make([]*product.Review, 0, len(reviews)),
+		Cart:          make([]*product.CartItem, 0, len(cart)),
+		Wishlist:      make([]*product.WishlistItem, 0, len(wishlist)),
+		Notifications: make([]*product.Notification, 0, len(notifications)),
+	}
+	itemsByOrder := make(map[int32][]repository.ListOrderItemsByUserRow)
+	for _, item := range orderItems {
+		itemsByOrder[item.OrderID.Int32] = append(itemsByOrder[item.OrderID.Int32], item)
+	}
+	for _, o := range orders {
+		order, err := toOrderRecord(o, itemsByOrder[o.ID])
+		if err != nil {
+			return nil, err
+		}
+		data.Orders = append(data.Orders, order)
+	}
+	for _, r := range reviews {
+		data.Reviews = append(data.Reviews, toReview(r))
+	}
+	for _, c := range cart {
+		data.Cart = append(data.Cart, &product.CartItem{
+			ProductId: int64(c.ProductID.Int32),
+			VariantId: int64(c.VariantID.Int32),
+			Quantity:  c.Quantity.Int32,
+		})
+	}
+	for _, row := range wishlist {
+		item, err := toWishlistItem(row)
+		if err != nil {
+			return nil, err
+		}
+		data.Wishlist = append(data.Wishlist, item)
+	}
+	for _, n := range notifications {
+		data.Notifications = append(data.Notifications, toNotification(n))
+	}
+	return &data, nil
+}
+
+// allNotifications pages through the whole inbox of the user.
+func (in *productInteractor) allNotifications(ctx context.Context, userID int32) ([]repository.Notification, error) {
+	var all []repository.Notification
+	var before int64
+	for {
+		page, err := in.Repo.ListNotifications(ctx, repository.ListNotificationsParams{
+			UserID:   userID,
+			BeforeID: before,
+			PageSize: maxNotificationPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < maxNotificationPageSize {
+			return all, nil
+		}
+		before = page[len(page)-1].ID
+	}
+}
+
+// EraseCustomerData removes the cart, wishlist, reviews, notifications and
+// queued emails of a user. Orders are kept for accounting, they only point at
+// the user whose personal data the user service erases.
+func (in *productInteractor) EraseCustomerData(ctx context.Context, req *product.CustomerDataRequest) (*emptypb.Empty, error) {
+	err := in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		userID, err := accountID(ctx, q, req.UserEmail)
+		if err != nil {
+			return err
+		}
+		if err = q.DeleteCartItemsByUser(ctx, userID); err != nil {
+			return err
+		}
+		if err = q.DeleteWishlistItemsByUser(ctx, userID); err != nil {
+			return err
+		}
+		if err = q.DeleteNotificationsByUser(ctx, userID); err != nil {
+			return err
+		}
+		if err = q.DeleteEmailsByUser(ctx, userID); err != nil {
+			return err
+		}
+		if err = q.DeleteNotificationPreferencesByUser(ctx, userID); err != nil {
+			return err
+		}
+		reviewed, err := q.DeleteReviewsByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, productID := range reviewed {
+			if err = q.RefreshProductRating(ctx, productID); err != nil {
+				return err
+			}
+		}
+		return in.audit(ctx, q, AuditCustomerErase, "user", int64(userID), map[string]interface{}{"erased": true})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func accountID(ctx context.Context, q repository.Querier, email string) (int32, error) {
+	if email == "" {
+		return 0, ErrAccountNotFound
+	}
+	id, err := q.GetAccountIDByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrAccountNotFound
+	}
+	return id, err
+}
+
+// toOrderRecord rebuilds a placed order from what was stored for it.
+func toOrderRecord(o repository.Order, items []repository.ListOrderItemsByUserRow) (*product.Order, error) {
+	currency := o.Currency.String
+	price := product.CartPrice{Lines: make([]*product.CartLine, 0, len(items))}
+	var err error
+	if price.Subtotal, err = toMoney(o.SubtotalAmount, currency); err != nil {
+		return nil, err
+	}
+	if price.Discount, err = toMoney(sql.NullString{String: o.DiscountAmount, Valid: true}, currency); err != nil {
+		return nil, err
+	}
+	if price.Total, err = toMoney(o.TotalAmount, currency); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		unit, err := money.Parse(item.Price.String, currency)
+		if err != nil {
+			return nil, err
+		}
+		discount, err := money.Parse(item.Discount, currency)
+		if err != nil {
+			return nil, err
+		}
+		subtotal, err := unit.Mul(int64(item.Quantity.Int32))
+		if err != nil {
+			return nil, err
+		}
+		off, err := discount.Mul(-1)
+		if err != nil {
+			return nil, err
+		}
+		total, err := subtotal.Add(off)
+		if err != nil {
+			return nil, err
+		}
+		price.Lines = append(price.Lines, &product.CartLine{
+			ProductId: int64(item.ProductID.Int32),
+			VariantId: int64(item.VariantID.Int32),
+			Name:      item.Name.String,
+			Quantity:  item.Quantity.Int32,
+			UnitPrice: toProtoMoney(unit),
+			Subtotal:  toProtoMoney(subtotal),
+			Discount:  toProtoMoney(discount),
+			Total:     toProtoMoney(total),
+		})
+	}
+
+	order := product.Order{
+		Id:         int64(o.ID),
+		UserId:     int64(o.UserID.Int32),
+		StoreId:    int64(o.StoreID.Int32),
+		Status:     o.Status.String,
+		Price:      &price,
+		CouponCode: o.CouponCode.String,
+	}
+	if o.OrderDate.Valid {
+		order.CreatedAt = timestamppb.New(o.OrderDate.Time)
+	}
+	return &order, nil
+}
@@ -0,0 +1,150 @@
+package interactor_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCustomerData(t *testing.T) {
+	req := &product.CustomerDataRequest{UserEmail: "jane@mail.com"}
+	order := repository.Order{
+		ID:             12,
+		UserID:         sql.NullInt32{Int32: 5, Valid: true},
+		StoreID:        sql.NullInt32{Int32: 3, Valid: true},
+		OrderDate:      sql.NullTime{Time: time.Now(), Valid: true},
+		Currency:       sql.NullString{String: "USD", Valid: true},
+		SubtotalAmount: sql.NullString{String: "20.00", Valid: true},
+		DiscountAmount: "2.00",
+		TotalAmount:    sql.NullString{String: "18.00", Valid: true},
+		Status:         sql.NullString{String: "pending", Valid: true},
+	}
+	item := repository.ListOrderItemsByUserRow{
+		OrderID:   sql.NullInt32{Int32: 12, Valid: true},
+		ProductID: sql.NullInt32{Int32: 1, Valid: true},
+		Name:      sql.NullString{String: "Mug", Valid: true},
+		Quantity:  sql.NullInt32{Int32: 2, Valid: true},
+		Price:     sql.NullString{String: "10.00", Valid: true},
+		Discount:  "2.00",
+	}
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.CustomerData, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				repo.On("GetAccountIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("ListOrdersByUser", int32(5)).Return([]repository.Order{order}, nil).Once()
+				repo.On("ListOrderItemsByUser", int32(5)).Return([]repository.ListOrderItemsByUserRow{item}, nil).Once()
+				repo.On("ListReviewsByUser", int32(5)).Return([]repository.Review{{ID: 2, ProductID: 1, UserID: 5, Rating: 4, Status: "approved"}}, nil).Once()
+				repo.On("ListCartItemsByUser", int32(5)).Return([]repository.Cart{{ProductID: sql.NullInt32{Int32: 2, Valid: true}, Quantity: sql.NullInt32{Int32: 1, Valid: true}}}, nil).Once()
+				repo.On("ListWishlistItems", int32(5)).Return([]repository.ListWishlistItemsRow{wishlistRow}, nil).Once()
+				repo.On("ListNotifications", repository.ListNotificationsParams{UserID: 5, PageSize: 100}).Return([]repository.Notification{
+					{ID: 9, UserID: 5, Kind: "welcome", Subject: "Welcome", CreatedAt: time.Now()},
+				}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.CustomerData, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Orders, 1)
+				order := actual.Orders[0]
+				require.Equal(t, int64(1800), order.Price.Total.MinorUnits)
+				require.Len(t, order.Price.Lines, 1)
+				require.Equal(t, int64(2000), order.Price.Lines[0].Subtotal.MinorUnits)
+				require.Equal(t, int64(1800), order.Price.Lines[0].Total.MinorUnits)
+				require.Len(t, actual.Reviews, 1)
+				require.Equal(t, product.ReviewStatus_APPROVED, actual.Reviews[0].Status)
+				require.Len(t, actual.Cart, 1)
+				require.Len(t, actual.Wishlist, 1)
+				require.Len(t, actual.Notifications, 1)
+			},
+		},
+		"deleted account": {
+			arrange: func(t *testing.T) {
+				repo.On("GetAccountIDByEmail", "jane@mail.com").Return(int32(0), sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.CustomerData, err error) {
+				require.ErrorIs(t, err, interactor.ErrAccountNotFound)
+				require.Nil(t, actual)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				repo.On("GetAccountIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("ListOrdersByUser", int32(5)).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.CustomerData, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := productInteractor.ExportCustomerData(context.Background(), req)
+
+			v.assert(t, actual, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestEraseCustomerData(t *testing.T) {
+	req := &product.CustomerDataRequest{UserEmail: "jane@mail.com"}
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				repo.On("GetAccountIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("DeleteCartItemsByUser", int32(5)).Return(nil).Once()
+				repo.On("DeleteWishlistItemsByUser", int32(5)).Return(nil).Once()
+				repo.On("DeleteNotificationsByUser", int32(5)).Return(nil).Once()
+				repo.On("DeleteEmailsByUser", int32(5)).Return(nil).Once()
+				repo.On("DeleteNotificationPreferencesByUser", int32(5)).Return(nil).Once()
+				repo.On("DeleteReviewsByUser", int32(5)).Return([]int32{1, 4}, nil).Once()
+				repo.On("RefreshProductRating", int32(1)).Return(nil).Once()
+				repo.On("RefreshProductRating", int32(4)).Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"unknown account": {
+			arrange: func(t *testing.T) {
+				repo.On("GetAccountIDByEmail", "jane@mail.com").Return(int32(0), sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, interactor.ErrAccountNotFound)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				repo.On("GetAccountIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("DeleteCartItemsByUser", int32(5)).Return(errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			_, err := productInteractor.EraseCustomerData(context.Background(), req)
+
+			v.assert(t, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
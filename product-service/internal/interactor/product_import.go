@@ -0,0 +1,313 @@
+package interactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ryanpujo/product-service/internal/money"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+)
+
+var (
+	ErrStoreRequired = errors.New("store is required")
+	ErrNotStoreOwner = errors.New("only the owner of the store can do this")
+)
+
+const (
+	// importBatchSize is the number of rows written by one UpsertProducts call.
+	importBatchSize = 500
+	exportPageSize  = 500
+	importReference = "import"
+)
+
+// importRow is a row that passed validation, with its price formatted for the
+// price column.
+type importRow struct {
+	result      *product.ImportRowResult
+	sku         string
+	name        string
+	description string
+	price       string
+	currency    string
+	imageURL    string
+	stock       int32
+	categoryID  int32
+}
+
+// ImportProducts creates or updates the products of a store by sku. Every row
+// is validated on its own and reported in the summary; the rows that fail are
+// skipped while the others are saved in one transaction. Stock changes go
+// through the ledger like any other adjustment. A dry run reports what would
+// happen without writing anything.
+func (in *productInteractor) ImportProducts(ctx context.Context, opts *product.ImportOptions, rows []*product.ProductRow) (*product.ImportSummary, error) {
+	if err := in.checkStoreOwner(ctx, opts.GetStoreId(), opts.GetOwnerEmail()); err != nil {
+		return nil, err
+	}
+	summary := product.ImportSummary{
+		DryRun:  opts.DryRun,
+		Results: make([]*product.ImportRowResult, 0, len(rows)),
+	}
+	valid := make([]importRow, 0, len(rows))
+	seen := make(map[string]int32, len(rows))
+	for _, r := range rows {
+		row, errs := validateImportRow(r)
+		if line, ok := seen[row.sku]; ok && row.sku != "" {
+			errs = append(errs, fmt.Sprintf("sku %q is already used on line %d", row.sku, line))
+		} else {
+			seen[row.sku] = r.Line
+		}
+		summary.Results = append(summary.Results, row.result)
+		if len(errs) > 0 {
+			row.result.Action = product.ImportAction_FAILED
+			row.result.Errors = errs
+			summary.Failed++
+			continue
+		}
+		valid = append(valid, row)
+	}
+
+	storeID := int32(opts.StoreId)
+	var updated []int32
+	err := in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		for start := 0; start < len(valid); start += importBatchSize {
+			end := start + importBatchSize
+			if end > len(valid) {
+				end = len(valid)
+			}
+			if err := importBatch(ctx, q, storeID, valid[start:end], opts.DryRun); err != nil {
+				return err
+			}
+		}
+		for _, row := range valid {
+			if row.result.Action == product.ImportAction_CREATED {
+				summary.Created++
+			} else {
+				summary.Updated++
+				updated = append(updated, int32(row.result.ProductId))
+			}
+		}
+		if opts.DryRun {
+			return nil
+		}
+		return in.audit(ctx, q, AuditCatalogImport, "store", opts.StoreId, map[string]interface{}{
+			"created": summary.Created,
+			"updated": summary.Updated,
+			"failed":  summary.Failed,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !opts.DryRun {
+		in.notifyWishlists(ctx, updated)
+	}
+	return &summary, nil
+}
+
+func (in *productInteractor) checkStoreOwner(ctx context.Context, storeID int64, email string) error {
+	if storeID <= 0 {
+		return ErrStoreRequired
+	}
+	if email == "" {
+		return ErrNotStoreOwner
+	}
+	owner, err := in.Repo.IsStoreOwner(ctx, repository.IsStoreOwnerParams{StoreID: int32(storeID), Email: email})
+	if err != nil {
+		return err
+	}
+	if !owner {
+		return ErrNotStoreOwner
+	}
+	return nil
+}
+
+// importBatch upserts one batch of valid rows and records the stock they add
+// or remove, filling in the result of each row. The rows it updates stay
+// locked from reading their stock until the import commits.
+func importBatch(ctx context.Context, q repository.Querier, storeID int32, rows []importRow, dryRun bool) error {
+	skus := make([]string, 0, len(rows))
+	for _, row := range rows {
+		skus = append(skus, row.sku)
+	}
+	existing, err := q.ListProductStockBySku(ctx, repository.ListProductStockBySkuParams{StoreID: storeID, Skus: skus})
+	if err != nil {
+		return err
+	}
+	current := make(map[string]repository.ListProductStockBySkuRow, len(existing))
+	for _, e := range existing {
+		current[e.Sku.String] = e
+	}
+	for _, row := range rows {
+		row.result.Action = product.ImportAction_CREATED
+		if e, ok := current[row.sku]; ok {
+			row.result.Action = product.ImportAction_UPDATED
+			row.result.ProductId = int64(e.ID)
+		}
+	}
+	if dryRun {
+		return nil
+	}
+
+	params := repository.UpsertProductsParams{StoreID: storeID}
+	for _, row := range rows {
+		params.Skus = append(params.Skus, row.sku)
+		params.Names = append(params.Names, row.name)
+		params.Descriptions = append(params.Descriptions, row.description)
+		params.Prices = append(params.Prices, row.price)
+		params.Currencies = append(params.Currencies, row.currency)
+		params.ImageUrls = append(params.ImageUrls, row.imageURL)
+		params.Stocks = append(params.Stocks, row.stock)
+		params.CategoryIds = append(params.CategoryIds, row.categoryID)
+	}
+	upserted, err := q.UpsertProducts(ctx, params)
+	if err != nil {
+		return err
+	}
+	ids := make(map[string]int32, len(upserted))
+	for _, u := range upserted {
+		ids[u.Sku.String] = u.ID
+	}
+
+	movements := repository.CreateStockMovementsParams{Reference: importReference}
+	for _, row := range rows {
+		row.result.ProductId = int64(ids[row.sku])
+		reason, note := product.StockReason_ADJUSTMENT, "import"
+		quantity := row.stock
+		if e, ok := current[row.sku]; ok {
+			quantity -= e.Stock
+		} else {
+			reason, note = product.StockReason_RESTOCK, "initial stock"
+		}
+		if quantity == 0 {
+			continue
+		}
+		movements.ProductIds = append(movements.ProductIds, ids[row.sku])
+		movements.Quantities = append(movements.Quantities, quantity)
+		movements.Reasons = append(movements.Reasons, stockReasons[reason])
+		movements.Notes = append(movements.Notes, note)
+	}
+	if len(movements.ProductIds) == 0 {
+		return nil
+	}
+	return q.CreateStockMovements(ctx, movements)
+}
+
+// validateImportRow normalizes a row and collects everything wrong with it, so
+// the caller can fix the whole file in one go.
+func validateImportRow(r *product.ProductRow) (importRow, []string) {
+	row := importRow{
+		result:      &product.ImportRowResult{Line: r.Line, Sku: strings.TrimSpace(r.Sku)},
+		sku:         strings.TrimSpace(r.Sku),
+		name:        strings.TrimSpace(r.Name),
+		description: strings.TrimSpace(r.Description),
+		currency:    strings.ToUpper(strings.TrimSpace(r.Currency)),
+		imageURL:    strings.TrimSpace(r.ImageUrl),
+		stock:       r.Stock,
+		categoryID:  int32(r.CategoryId),
+	}
+	var errs []string
+	if row.sku == "" {
+		errs = append(errs, "sku is required")
+	}
+	if row.name == "" {
+		errs = append(errs, "name is required")
+	}
+	if row.stock < 0 {
+		errs = append(errs, "stock must not be negative")
+	}
+	if r.CategoryId < 0 {
+		errs = append(errs, "category id must not be negative")
+	}
+	price, err := parseImportPrice(r.Price, row.currency)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	row.price = price
+	return row, errs
+}
+
+func parseImportPrice(amount, currency string) (string, error) {
+	amount = strings.TrimSpace(amount)
+	if amount == "" {
+		return "", fmt.Errorf("%w: price is required", ErrInvalidPrice)
+	}
+	m, err := money.Parse(amount, currency)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPrice, err)
+	}
+	if m.Minor < 0 {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPrice, money.ErrNegativeAmount)
+	}
+	decimal, err := m.Numeric(pricePrecision, priceScale)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPrice, err)
+	}
+	return decimal, nil
+}
+
+// ExportProducts sends every product of a store, in id order, in the same
+// shape ImportProducts accepts, so an export can be edited and imported back.
+func (in *productInteractor) ExportProducts(ctx context.Context, req *product.ExportRequest, send func(*product.ProductRow) error) error {
+	if err := in.checkStoreOwner(ctx, req.GetStoreId(), req.GetOwnerEmail()); err != nil {
+		return err
+	}
+	var afterID int32
+	var line int32
+	for {
+		page, err := in.Repo.ListStoreProducts(ctx, repository.ListStoreProductsParams{
+			StoreID:  int32(req.StoreId),
+			AfterID:  afterID,
+			RowLimit: exportPageSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, p := range page {
+			line++
+			row, err := toProductRow(p, line)
+			if err != nil {
+				return err
+			}
+			if err = send(row); err != nil {
+				return err
+			}
+			afterID = p.ID
+		}
+		if len(page) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+func toProductRow(p repository.Product, line int32) (*product.ProductRow, error) {
+	row := product.ProductRow{
+		Line:        line,
+		Sku:         p.Sku.String,
+		Name:        p.Name.String,
+		Description: p.Description.String,
+		Currency:    p.Currency,
+		Stock:       p.Stock.Int32,
+		ImageUrl:    p.ImageUrl.String,
+		CategoryId:  int64(p.CategoryID.Int32),
+	}
+	if !p.Price.Valid {
+		return &row, nil
+	}
+	m, err := money.Parse(p.Price.String, p.Currency)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := money.Exponent(p.Currency)
+	if err != nil {
+		return nil, err
+	}
+	// written with the digits of the currency, "1500" yen rather than "1500.00"
+	row.Price, err = m.Decimal(exponent)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
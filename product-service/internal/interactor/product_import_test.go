@@ -0,0 +1,189 @@
+package interactor_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportProducts(t *testing.T) {
+	rows := []*product.ProductRow{
+		{Line: 2, Sku: " MUG-1 ", Name: "Mug", Price: "12.5", Currency: "usd", Stock: 5},
+		{Line: 3, Sku: "TEE-1", Name: "Tee", Price: "20.00", Currency: "USD", Stock: 7},
+		{Line: 4, Sku: "CAP-1", Price: "1.234", Currency: "USD", Stock: -1},
+	}
+	lookup := repository.ListProductStockBySkuParams{StoreID: 3, Skus: []string{"MUG-1", "TEE-1"}}
+	existing := []repository.ListProductStockBySkuRow{{ID: 9, Sku: sql.NullString{String: "TEE-1", Valid: true}, Stock: 2}}
+	owner := repository.IsStoreOwnerParams{StoreID: 3, Email: "owner@mail.com"}
+
+	testTable := map[string]struct {
+		opts    *product.ImportOptions
+		rows    []*product.ProductRow
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.ImportSummary, err error)
+	}{
+		"creates and updates": {
+			opts: &product.ImportOptions{StoreId: 3, OwnerEmail: "owner@mail.com"},
+			rows: rows,
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", owner).Return(true, nil).Once()
+				repo.On("ListProductStockBySku", lookup).Return(existing, nil).Once()
+				repo.On("UpsertProducts", mock.MatchedBy(func(arg repository.UpsertProductsParams) bool {
+					return arg.StoreID == 3 && len(arg.Skus) == 2 && arg.Prices[0] == "12.50" && arg.Currencies[0] == "USD"
+				})).Return([]repository.UpsertProductsRow{
+					{ID: 1, Sku: sql.NullString{String: "MUG-1", Valid: true}},
+					{ID: 9, Sku: sql.NullString{String: "TEE-1", Valid: true}},
+				}, nil).Once()
+				repo.On("CreateStockMovements", repository.CreateStockMovementsParams{
+					Reference:  "import",
+					ProductIds: []int32{1, 9},
+					Quantities: []int32{5, 5},
+					Reasons:    []string{"restock", "adjustment"},
+					Notes:      []string{"initial stock", "import"},
+				}).Return(nil).Once()
+				repo.On("SyncWishlistItems", []int32{9}).Return([]repository.SyncWishlistItemsRow{}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.ImportSummary, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(1), actual.Created)
+				require.Equal(t, int32(1), actual.Updated)
+				require.Equal(t, int32(1), actual.Failed)
+				require.Equal(t, int64(1), actual.Results[0].ProductId)
+				require.Equal(t, product.ImportAction_UPDATED, actual.Results[1].Action)
+				require.Equal(t, product.ImportAction_FAILED, actual.Results[2].Action)
+				require.Len(t, actual.Results[2].Errors, 3)
+			},
+		},
+		"dry run": {
+			opts: &product.ImportOptions{StoreId: 3, OwnerEmail: "owner@mail.com", DryRun: true},
+			rows: rows,
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", owner).Return(true, nil).Once()
+				repo.On("ListProductStockBySku", lookup).Return(existing, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.ImportSummary, err error) {
+				require.NoError(t, err)
+				require.True(t, actual.DryRun)
+				require.Equal(t, int32(1), actual.Created)
+				require.Equal(t, int32(1), actual.Updated)
+				require.Equal(t, int64(0), actual.Results[0].ProductId)
+				require.Equal(t, int64(9), actual.Results[1].ProductId)
+			},
+		},
+		"sku repeated in the file": {
+			opts: &product.ImportOptions{StoreId: 3, OwnerEmail: "owner@mail.com", DryRun: true},
+			rows: []*product.ProductRow{rows[1], {Line: 5, Sku: "TEE-1", Name: "Tee", Price: "1", Currency: "USD"}},
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", owner).Return(true, nil).Once()
+				repo.On("ListProductStockBySku", repository.ListProductStockBySkuParams{StoreID: 3, Skus: []string{"TEE-1"}}).Return(existing, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.ImportSummary, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(1), actual.Failed)
+				require.Equal(t, `sku "TEE-1" is already used on line 3`, actual.Results[1].Errors[0])
+			},
+		},
+		"not the owner": {
+			opts: &product.ImportOptions{StoreId: 3, OwnerEmail: "owner@mail.com"},
+			rows: rows,
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", owner).Return(false, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.ImportSummary, err error) {
+				require.ErrorIs(t, err, interactor.ErrNotStoreOwner)
+			},
+		},
+		"missing store": {
+			opts:    &product.ImportOptions{},
+			rows:    rows,
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.ImportSummary, err error) {
+				require.ErrorIs(t, err, interactor.ErrStoreRequired)
+			},
+		},
+		"fail call": {
+			opts: &product.ImportOptions{StoreId: 3, OwnerEmail: "owner@mail.com"},
+			rows: rows,
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", owner).Return(true, nil).Once()
+				repo.On("ListProductStockBySku", lookup).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.ImportSummary, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := productInteractor.ImportProducts(ctx, v.opts, v.rows)
+
+			v.assert(t, result, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestExportProducts(t *testing.T) {
+	page := repository.ListStoreProductsParams{StoreID: 3, RowLimit: 500}
+	owner := repository.IsStoreOwnerParams{StoreID: 3, Email: "owner@mail.com"}
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual []*product.ProductRow, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", owner).Return(true, nil).Once()
+				repo.On("ListStoreProducts", page).Return([]repository.Product{
+					{ID: 4, Sku: sql.NullString{String: "MUG-1", Valid: true}, Price: sql.NullString{String: "12.50", Valid: true}, Currency: "USD"},
+					{ID: 6, Sku: sql.NullString{String: "TEA-1", Valid: true}, Price: sql.NullString{String: "1500.00", Valid: true}, Currency: "JPY"},
+				}, nil).Once()
+			},
+			assert: func(t *testing.T, actual []*product.ProductRow, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual, 2)
+				require.Equal(t, "12.50", actual[0].Price)
+				require.Equal(t, "1500", actual[1].Price)
+				require.Equal(t, int32(2), actual[1].Line)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", owner).Return(true, nil).Once()
+				repo.On("ListStoreProducts", page).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual []*product.ProductRow, err error) {
+				require.Error(t, err)
+				require.Empty(t, actual)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			var rows []*product.ProductRow
+			err := productInteractor.ExportProducts(ctx, &product.ExportRequest{StoreId: 3, OwnerEmail: "owner@mail.com"}, func(row *product.ProductRow) error {
+				rows = append(rows, row)
+				return nil
+			})
+
+			v.assert(t, rows, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,363 @@
+package interactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ryanpujo/product-service/internal/audit"
+	"github.com/ryanpujo/product-service/internal/messaging"
+	"github.com/ryanpujo/product-service/internal/money"
+	"github.com/ryanpujo/product-service/internal/notification"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/events"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type ProductInteractor interface {
+	Create(ctx context.Context, payload *product.ProductPayload) (*product.Product, error)
+	AdjustStock(ctx context.Context, adjustment *product.StockAdjustment) (*product.StockMovement, error)
+	ListStockMovements(ctx context.Context, req *product.StockMovementsRequest) (*product.StockMovements, error)
+	ReconcileStock(ctx context.Context, fix bool) ([]repository.ListStockDriftRow, error)
+	SearchProducts(ctx context.Context, req *product.SearchRequest) (*product.SearchResult, error)
+	CreateVariant(ctx context.Context, payload *product.VariantPayload) (*product.Variant, error)
+	ListVariants(ctx context.Context, id *product.ProductId) (*product.Variants, error)
+	CreateReview(ctx context.Context, payload *product.ReviewPayload) (*product.Review, error)
+	UpdateReview(ctx context.Context, update *product.ReviewUpdate) (*product.Review, error)
+	ModerateReview(ctx context.Context, moderation *product.ReviewModeration) (*product.Review, error)
+	ListReviews(ctx context.Context, req *product.ReviewsRequest) (*product.Reviews, error)
+	ImportProducts(ctx context.Context, opts *product.ImportOptions, rows []*product.ProductRow) (*product.ImportSummary, error)
+	ExportProducts(ctx context.Context, req *product.ExportRequest, send func(*product.ProductRow) error) error
+	CreatePromotion(ctx context.Context, payload *product.PromotionPayload) (*product.Promotion, error)
+	ListPromotions(ctx context.Context, req *product.StorePromotionsRequest) (*product.Promotions, error)
+	PriceCart(ctx context.Context, req *product.PriceCartRequest) (*product.CartPrice, error)
+	PlaceOrder(ctx context.Context, req *product.PlaceOrderRequest) (*product.Order, error)
+	CancelOrder(ctx context.Context, req *product.OrderCancellation) (*product.Order, error)
+	AddToWishlist(ctx context.Context, req *product.WishlistItemRequest) (*product.WishlistItem, error)
+	RemoveFromWishlist(ctx context.Context, req *product.WishlistItemRequest) (*emptypb.Empty, error)
+	ListWishlist(ctx context.Context, req *product.WishlistRequest) (*product.Wishlist, error)
+	MoveWishlistItemToCart(ctx context.Context, req *product.WishlistItemRequest) (*product.CartItem, error)
+	ExportCustomerData(ctx context.Context, req *product.CustomerDataRequest) (*product.CustomerData, error)
+	EraseCustomerData(ctx context.Context, req *product.CustomerDataRequest) (*emptypb.Empty, error)
+	CreateApiKey(ctx context.Context, payload *product.ApiKeyPayload) (*product.ApiKey, error)
+	ListApiKeys(ctx context.Context, req *product.StoreApiKeysRequest) (*product.ApiKeys, error)
+	RevokeApiKey(ctx context.Context, req *product.ApiKeyRequest) (*emptypb.Empty, error)
+	VerifyApiKey(ctx context.Context, secret *product.ApiKeySecret) (*product.ApiKeyPrincipal, error)
+	ListNotifications(ctx context.Context, req *product.NotificationsRequest) (*product.Notifications, error)
+	MarkNotificationsRead(ctx context.Context, req *product.NotificationsRead) (*product.NotificationsReadResult, error)
+	GetNotificationPreferences(ctx context.Context, req *product.NotificationPreferencesRequest) (*product.NotificationPreferences, error)
+	UpdateNotificationPreferences(ctx context.Context, update *product.NotificationPreferencesUpdate) (*product.NotificationPreferences, error)
+}
+
+var (
+	ErrProductNotFound    = errors.New("product not found")
+	ErrInsufficientStock  = errors.New("insufficient stock")
+	ErrInvalidStockReason = errors.New("invalid stock reason")
+	ErrZeroQuantity       = errors.New("quantity must not be zero")
+	ErrInvalidPrice       = errors.New("invalid price")
+)
+
+const (
+	defaultMovementLimit = 50
+	// pricePrecision and priceScale are those of the numeric(12,2) price
+	// columns.
+	pricePrecision = 12
+	priceScale     = 2
+)
+
+var stockReasons = map[product.StockReason]string{
+	product.StockReason_RESTOCK:     "restock",
+	product.StockReason_SALE:        "sale",
+	product.StockReason_RESERVATION: "reservation",
+	product.StockReason_ADJUSTMENT:  "adjustment",
+	product.StockReason_RETURN:      "return",
+}
+
+type productInteractor struct {
+	Repo repository.TxQuerier
+	// Notifier is told when wishlisted products drop in price or come back
+	// in stock.
+	Notifier WishlistNotifier
+	// Audit keeps the record of who changed products, stores and orders.
+	Audit audit.Auditor
+	// Notifications tells users about their account and orders, the events
+	// behind them are not consumed while it is nil.
+	Notifications *notification.Service
+}
+
+func NewProductInteractor(repo repository.TxQuerier) *productInteractor {
+	return &productInteractor{Repo: repo, Notifier: LogNotifier{}, Audit: audit.LogAuditor{}}
+}
+
+func (in *productInteractor) Create(ctx context.Context, payload *product.ProductPayload) (*product.Product, error) {
+	price, err := toPriceColumn(payload.Price)
+	if err != nil {
+		return nil, err
+	}
+
+	var created repository.Product
+	err = in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		var err error
+		created, err = q.CreateProduct(ctx, repository.CreateProductParams{
+			Name:        sql.NullString{String: payload.Name, Valid: true},
+			Description: sql.NullString{String: payload.Description, Valid: true},
+			Price:       sql.NullString{String: price, Valid: true},
+			Currency:    payload.Price.Currency,
+			ImageUrl:    sql.NullString{String: payload.ImageUrl, Valid: true},
+			Stock:       sql.NullInt32{Int32: payload.Stock, Valid: true},
+			Sku:         sql.NullString{String: payload.Sku, Valid: payload.Sku != ""},
+		})
+		if err != nil {
+			return err
+		}
+		if payload.Stock != 0 {
+			// the opening stock is the first entry of the ledger
+			_, err = q.CreateStockMovement(ctx, repository.CreateStockMovementParams{
+				ProductID: created.ID,
+				Quantity:  payload.Stock,
+				Reason:    stockReasons[product.StockReason_RESTOCK],
+				Note:      sql.NullString{String: "initial stock", Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return in.audit(ctx, q, AuditProductCreate, "product", int64(created.ID), map[string]interface{}{
+			"name":  payload.Name,
+			"sku":   payload.Sku,
+			"price": auditedMoney(payload.Price),
+			"stock": payload.Stock,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toProduct(created)
+}
+
+// AdjustStock appends a movement to the ledger and applies it to the stock of
+// the product, or of one of its variants, in the same transaction, so the two
+// never disagree.
+func (in *productInteractor) AdjustStock(ctx context.Context, adjustment *product.StockAdjustment) (*product.StockMovement, error) {
+	reason, ok := stockReasons[adjustment.Reason]
+	if !ok {
+		return nil, ErrInvalidStockReason
+	}
+	if adjustment.Quantity == 0 {
+		return nil, ErrZeroQuantity
+	}
+
+	var movement repository.StockMovement
+	err := in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		var err error
+		if adjustment.VariantId != 0 {
+			_, err = q.AddVariantStock(ctx, repository.AddVariantStockParams{
+				ID:        int32(adjustment.VariantId),
+				ProductID: int32(adjustment.ProductId),
+				Quantity:  adjustment.Quantity,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				return in.missingVariantStock(ctx, q, adjustment.ProductId, adjustment.VariantId)
+			}
+		} else {
+			_, err = q.AddProductStock(ctx, repository.AddProductStockParams{
+				ID:       int32(adjustment.ProductId),
+				Quantity: adjustment.Quantity,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				return in.missingStock(ctx, q, adjustment.ProductId)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		movement, err = q.CreateStockMovement(ctx, repository.CreateStockMovementParams{
+			ProductID: int32(adjustment.ProductId),
+			VariantID: sql.NullInt32{Int32: int32(adjustment.VariantId), Valid: adjustment.VariantId != 0},
+			Quantity:  adjustment.Quantity,
+			Reason:    reason,
+			Reference: sql.NullString{String: adjustment.Reference, Valid: adjustment.Reference != ""},
+			Note:      sql.NullString{String: adjustment.Note, Valid: adjustment.Note != ""},
+		})
+		if err != nil {
+			return err
+		}
+		envelope := messaging.NewEnvelope(ctx)
+		envelope.Event = &events.Envelope_StockAdjusted{StockAdjusted: &events.StockAdjusted{
+			ProductId:  adjustment.ProductId,
+			VariantId:  adjustment.VariantId,
+			Quantity:   adjustment.Quantity,
+			Reason:     reason,
+			Reference:  adjustment.Reference,
+			MovementId: movement.ID,
+		}}
+		if err = messaging.Enqueue(ctx, q, envelope); err != nil {
+			return err
+		}
+		return in.audit(ctx, q, AuditStockAdjust, "product", adjustment.ProductId, map[string]interface{}{
+			"variantId": adjustment.VariantId,
+			"quantity":  adjustment.Quantity,
+			"reason":    reason,
+			"reference": adjustment.Reference,
+			"movement":  movement.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	in.notifyWishlists(ctx, []int32{int32(adjustment.ProductId)})
+	return toStockMovement(movement), nil
+}
+
+// missingStock tells apart an unknown product from one whose stock would go
+// negative, both of which make AddProductStock return no rows.
+func (in *productInteractor) missingStock(ctx context.Context, q repository.Querier, id int64) error {
+	_, err := q.GetProduct(ctx, int32(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	return ErrInsufficientStock
+}
+
+func (in *productInteractor) ListStockMovements(ctx context.Context, req *product.StockMovementsRequest) (*product.StockMovements, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultMovementLimit
+	}
+	movements, err := in.Repo.ListStockMovements(ctx, repository.ListStockMovementsParams{
+		ProductID: int32(req.ProductId),
+		Limit:     limit,
+		Offset:    req.Offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := product.StockMovements{
+		Movements: make([]*product.StockMovement, 0, len(movements)),
+	}
+	for _, m := range movements {
+		result.Movements = append(result.Movements, toStockMovement(m))
+	}
+	return &result, nil
+}
+
+// ReconcileStock reports every product and variant whose stock column
+// disagrees with the sum of its ledger. When fix is true the stock column is
+// reset to the ledger, with the drift read again while every stock row is
+// locked so adjustments made meanwhile are not undone.
+func (in *productInteractor) ReconcileStock(ctx context.Context, fix bool) ([]repository.ListStockDriftRow, error) {
+	if !fix {
+		return in.Repo.ListStockDrift(ctx)
+	}
+
+	var drift []repository.ListStockDriftRow
+	err := in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		if err := q.LockProductStock(ctx); err != nil {
+			return err
+		}
+		if err := q.LockVariantStock(ctx); err != nil {
+			return err
+		}
+		var err error
+		drift, err = q.ListStockDrift(ctx)
+		if err != nil {
+			return err
+		}
+		for _, d := range drift {
+			if d.VariantID != 0 {
+				err = q.SetVariantStock(ctx, repository.SetVariantStockParams{ID: d.VariantID, Stock: d.LedgerStock})
+			} else {
+				err = q.SetProductStock(ctx, repository.SetProductStockParams{
+					ID:    d.ProductID,
+					Stock: sql.NullInt32{Int32: d.LedgerStock, Valid: true},
+				})
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return drift, nil
+}
+
+// toPriceColumn validates a price from a request and formats it for the
+// numeric(12,2) price column, refusing amounts that would need rounding.
+func toPriceColumn(price *product.Money) (string, error) {
+	if price == nil {
+		return "", fmt.Errorf("%w: price is required", ErrInvalidPrice)
+	}
+	if price.MinorUnits < 0 {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPrice, money.ErrNegativeAmount)
+	}
+	m, err := money.New(price.MinorUnits, price.Currency)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPrice, err)
+	}
+	decimal, err := m.Numeric(pricePrecision, priceScale)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPrice, err)
+	}
+	return decimal, nil
+}
+
+func toMoney(amount sql.NullString, currency string) (*product.Money, error) {
+	if !amount.Valid {
+		return nil, nil
+	}
+	m, err := money.Parse(amount.String, currency)
+	if err != nil {
+		return nil, err
+	}
+	return &product.Money{MinorUnits: m.Minor, Currency: m.Currency}, nil
+}
+
+func toProduct(p repository.Product) (*product.Product, error) {
+	price, err := toMoney(p.Price, p.Currency)
+	if err != nil {
+		return nil, err
+	}
+	result := product.Product{
+		Id:            int64(p.ID),
+		Sku:           p.Sku.String,
+		Name:          p.Name.String,
+		Description:   p.Description.String,
+		Price:         price,
+		ImageUrl:      p.ImageUrl.String,
+		Stock:         p.Stock.Int32,
+		RatingAverage: toRating(p.RatingAverage),
+		RatingCount:   p.RatingCount,
+	}
+	if p.CreatedAt.Valid {
+		result.CreatedAt = timestamppb.New(p.CreatedAt.Time)
+	}
+	return &result, nil
+}
+
+func toStockMovement(m repository.StockMovement) *product.StockMovement {
+	movement := product.StockMovement{
+		Id:        m.ID,
+		ProductId: int64(m.ProductID),
+		VariantId: int64(m.VariantID.Int32),
+		Quantity:  m.Quantity,
+		Reference: m.Reference.String,
+		Note:      m.Note.String,
+		CreatedAt: timestamppb.New(m.CreatedAt),
+	}
+	for reason, name := range stockReasons {
+		if name == m.Reason {
+			movement.Reason = reason
+		}
+	}
+	return &movement
+}
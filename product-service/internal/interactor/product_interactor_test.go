@@ -0,0 +1,906 @@
+package interactor_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/messaging"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRepo struct {
+	mock.Mock
+}
+
+func (m *mockRepo) ExecTx(ctx context.Context, fn func(q repository.Querier) error) error {
+	return fn(m)
+}
+
+func (m *mockRepo) AddProductStock(ctx context.Context, arg repository.AddProductStockParams) (sql.NullInt32, error) {
+	args := m.Called(arg)
+	return args.Get(0).(sql.NullInt32), args.Error(1)
+}
+
+func (m *mockRepo) CreateProduct(ctx context.Context, arg repository.CreateProductParams) (repository.Product, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.Product), args.Error(1)
+}
+
+func (m *mockRepo) CreateStockMovement(ctx context.Context, arg repository.CreateStockMovementParams) (repository.StockMovement, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.StockMovement), args.Error(1)
+}
+
+func (m *mockRepo) GetProduct(ctx context.Context, id int32) (repository.Product, error) {
+	args := m.Called(id)
+	return args.Get(0).(repository.Product), args.Error(1)
+}
+
+func (m *mockRepo) ListStockDrift(ctx context.Context) ([]repository.ListStockDriftRow, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ListStockDriftRow), args.Error(1)
+}
+
+func (m *mockRepo) ListStockMovements(ctx context.Context, arg repository.ListStockMovementsParams) ([]repository.StockMovement, error) {
+	args := m.Called(arg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.StockMovement), args.Error(1)
+}
+
+func (m *mockRepo) SetProductStock(ctx context.Context, arg repository.SetProductStockParams) error {
+	args := m.Called(arg)
+	return args.Error(0)
+}
+
+func (m *mockRepo) SearchProducts(ctx context.Context, arg repository.SearchProductsParams) ([]repository.SearchProductsRow, error) {
+	args := m.Called(arg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.SearchProductsRow), args.Error(1)
+}
+
+func (m *mockRepo) SearchCategoryFacets(ctx context.Context, arg repository.SearchCategoryFacetsParams) ([]repository.SearchCategoryFacetsRow, error) {
+	args := m.Called(arg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.SearchCategoryFacetsRow), args.Error(1)
+}
+
+func (m *mockRepo) SearchStoreFacets(ctx context.Context, arg repository.SearchStoreFacetsParams) ([]repository.SearchStoreFacetsRow, error) {
+	args := m.Called(arg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.SearchStoreFacetsRow), args.Error(1)
+}
+
+func (m *mockRepo) SearchPriceFacets(ctx context.Context, arg repository.SearchPriceFacetsParams) ([]repository.SearchPriceFacetsRow, error) {
+	args := m.Called(arg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.SearchPriceFacetsRow), args.Error(1)
+}
+
+func (m *mockRepo) AddVariantOptionValue(ctx context.Context, arg repository.AddVariantOptionValueParams) error {
+	args := m.Called(arg)
+	return args.Error(0)
+}
+
+func (m *mockRepo) AddVariantStock(ctx context.Context, arg repository.AddVariantStockParams) (int32, error) {
+	args := m.Called(arg)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *mockRepo) CreateVariant(ctx context.Context, arg repository.CreateVariantParams) (repository.ProductVariant, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.ProductVariant), args.Error(1)
+}
+
+func (m *mockRepo) GetVariant(ctx context.Context, id int32) (repository.ProductVariant, error) {
+	args := m.Called(id)
+	return args.Get(0).(repository.ProductVariant), args.Error(1)
+}
+
+func (m *mockRepo) GetVariantBySku(ctx context.Context, sku string) (repository.ProductVariant, error) {
+	args := m.Called(sku)
+	return args.Get(0).(repository.ProductVariant), args.Error(1)
+}
+
+func (m *mockRepo) ListOptionTypes(ctx context.Context, productID int32) ([]repository.OptionType, error) {
+	args := m.Called(productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.OptionType), args.Error(1)
+}
+
+func (m *mockRepo) ListOptionValues(ctx context.Context, productID int32) ([]repository.OptionValue, error) {
+	args := m.Called(productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.OptionValue), args.Error(1)
+}
+
+func (m *mockRepo) ListVariantOptions(ctx context.Context, productID int32) ([]repository.ListVariantOptionsRow, error) {
+	args := m.Called(productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ListVariantOptionsRow), args.Error(1)
+}
+
+func (m *mockRepo) ListVariants(ctx context.Context, productID int32) ([]repository.ProductVariant, error) {
+	args := m.Called(productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ProductVariant), args.Error(1)
+}
+
+func (m *mockRepo) SetVariantStock(ctx context.Context, arg repository.SetVariantStockParams) error {
+	args := m.Called(arg)
+	return args.Error(0)
+}
+
+func (m *mockRepo) UpsertOptionType(ctx context.Context, arg repository.UpsertOptionTypeParams) (repository.OptionType, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.OptionType), args.Error(1)
+}
+
+func (m *mockRepo) UpsertOptionValue(ctx context.Context, arg repository.UpsertOptionValueParams) (repository.OptionValue, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.OptionValue), args.Error(1)
+}
+
+func (m *mockRepo) CreateReview(ctx context.Context, arg repository.CreateReviewParams) (repository.Review, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.Review), args.Error(1)
+}
+
+func (m *mockRepo) GetReview(ctx context.Context, id int32) (repository.Review, error) {
+	args := m.Called(id)
+	return args.Get(0).(repository.Review), args.Error(1)
+}
+
+func (m *mockRepo) GetUserIDByEmail(ctx context.Context, email string) (int32, error) {
+	args := m.Called(email)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *mockRepo) HasPurchased(ctx context.Context, arg repository.HasPurchasedParams) (bool, error) {
+	args := m.Called(arg)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepo) ListReviews(ctx context.Context, arg repository.ListReviewsParams) ([]repository.ListReviewsRow, error) {
+	args := m.Called(arg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ListReviewsRow), args.Error(1)
+}
+
+func (m *mockRepo) RefreshProductRating(ctx context.Context, productID int32) error {
+	args := m.Called(productID)
+	return args.Error(0)
+}
+
+func (m *mockRepo) SetReviewStatus(ctx context.Context, arg repository.SetReviewStatusParams) (repository.Review, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.Review), args.Error(1)
+}
+
+func (m *mockRepo) UpdateReview(ctx context.Context, arg repository.UpdateReviewParams) (repository.Review, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.Review), args.Error(1)
+}
+
+func (m *mockRepo) CreateStockMovements(ctx context.Context, arg repository.CreateStockMovementsParams) error {
+	args := m.Called(arg)
+	return args.Error(0)
+}
+
+func (m *mockRepo) IsStoreOwner(ctx context.Context, arg repository.IsStoreOwnerParams) (bool, error) {
+	args := m.Called(arg)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepo) ListProductStockBySku(ctx context.Context, arg repository.ListProductStockBySkuParams) ([]repository.ListProductStockBySkuRow, error) {
+	args := m.Called(arg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ListProductStockBySkuRow), args.Error(1)
+}
+
+func (m *mockRepo) ListStoreProducts(ctx context.Context, arg repository.ListStoreProductsParams) ([]repository.Product, error) {
+	args := m.Called(arg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.Product), args.Error(1)
+}
+
+func (m *mockRepo) UpsertProducts(ctx context.Context, arg repository.UpsertProductsParams) ([]repository.UpsertProductsRow, error) {
+	args := m.Called(arg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.UpsertProductsRow), args.Error(1)
+}
+
+func (m *mockRepo) InsertAuditEntry(ctx context.Context, arg repository.InsertAuditEntryParams) error {
+	args := m.Called(arg)
+	return args.Error(0)
+}
+
+func (m *mockRepo) InsertOutboxEvent(ctx context.Context, arg repository.InsertOutboxEventParams) error {
+	args := m.Called(arg.Topic, arg.PartitionKey)
+	return args.Error(0)
+}
+
+func (m *mockRepo) ListPendingOutboxEvents(ctx context.Context, arg repository.ListPendingOutboxEventsParams) ([]repository.ListPendingOutboxEventsRow, error) {
+	args := m.Called(arg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ListPendingOutboxEventsRow), args.Error(1)
+}
+
+func (m *mockRepo) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockRepo) MarkOutboxEventFailed(ctx context.Context, arg repository.MarkOutboxEventFailedParams) (bool, error) {
+	args := m.Called(arg)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepo) CreateApiKey(ctx context.Context, arg repository.CreateApiKeyParams) (repository.ApiKey, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.ApiKey), args.Error(1)
+}
+
+func (m *mockRepo) GetApiKeyByPrefix(ctx context.Context, prefix string) (repository.GetApiKeyByPrefixRow, error) {
+	args := m.Called(prefix)
+	return args.Get(0).(repository.GetApiKeyByPrefixRow), args.Error(1)
+}
+
+func (m *mockRepo) ListStoreApiKeys(ctx context.Context, storeID int32) ([]repository.ApiKey, error) {
+	args := m.Called(storeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ApiKey), args.Error(1)
+}
+
+func (m *mockRepo) RevokeApiKey(ctx context.Context, arg repository.RevokeApiKeyParams) (int64, error) {
+	args := m.Called(arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepo) TouchApiKey(ctx context.Context, id int32) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockRepo) CreatePromotion(ctx context.Context, arg repository.CreatePromotionParams) (repository.Promotion, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.Promotion), args.Error(1)
+}
+
+func (m *mockRepo) CreatePromotionRedemption(ctx context.Context, arg repository.CreatePromotionRedemptionParams) error {
+	args := m.Called(arg)
+	return args.Error(0)
+}
+
+func (m *mockRepo) ReleaseOrderPromotions(ctx context.Context, orderID int32) error {
+	args := m.Called(orderID)
+	return args.Error(0)
+}
+
+func (m *mockRepo) GetPromotionByCoupon(ctx context.Context, code string) (repository.Promotion, error) {
+	args := m.Called(code)
+	return args.Get(0).(repository.Promotion), args.Error(1)
+}
+
+func (m *mockRepo) ListAutomaticPromotions(ctx context.Context, storeIds []int32) ([]repository.Promotion, error) {
+	args := m.Called(storeIds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.Promotion), args.Error(1)
+}
+
+func (m *mockRepo) ListStorePromotions(ctx context.Context, storeID sql.NullInt32) ([]repository.Promotion, error) {
+	args := m.Called(storeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.Promotion), args.Error(1)
+}
+
+func (m *mockRepo) RedeemPromotion(ctx context.Context, id int32) (int32, error) {
+	args := m.Called(id)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *mockRepo) CreateOrder(ctx context.Context, arg repository.CreateOrderParams) (repository.Order, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.Order), args.Error(1)
+}
+
+func (m *mockRepo) CreateOrderItem(ctx context.Context, arg repository.CreateOrderItemParams) error {
+	args := m.Called(arg)
+	return args.Error(0)
+}
+
+func (m *mockRepo) ListProductsForPricing(ctx context.Context, ids []int32) ([]repository.ListProductsForPricingRow, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ListProductsForPricingRow), args.Error(1)
+}
+
+func (m *mockRepo) ListVariantsForPricing(ctx context.Context, ids []int32) ([]repository.ListVariantsForPricingRow, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ListVariantsForPricingRow), args.Error(1)
+}
+
+func (m *mockRepo) AddWishlistItem(ctx context.Context, arg repository.AddWishlistItemParams) (repository.WishlistItem, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.WishlistItem), args.Error(1)
+}
+
+func (m *mockRepo) DeleteWishlistItem(ctx context.Context, arg repository.DeleteWishlistItemParams) (int64, error) {
+	args := m.Called(arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepo) GetWishlistItem(ctx context.Context, id int32) (repository.GetWishlistItemRow, error) {
+	args := m.Called(id)
+	return args.Get(0).(repository.GetWishlistItemRow), args.Error(1)
+}
+
+func (m *mockRepo) ListWishlistItems(ctx context.Context, userID int32) ([]repository.ListWishlistItemsRow, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ListWishlistItemsRow), args.Error(1)
+}
+
+func (m *mockRepo) SyncWishlistItems(ctx context.Context, productIds []int32) ([]repository.SyncWishlistItemsRow, error) {
+	args := m.Called(productIds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.SyncWishlistItemsRow), args.Error(1)
+}
+
+func (m *mockRepo) AddCartItem(ctx context.Context, arg repository.AddCartItemParams) (repository.Cart, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.Cart), args.Error(1)
+}
+
+func (m *mockRepo) GetAccountIDByEmail(ctx context.Context, email string) (int32, error) {
+	args := m.Called(email)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *mockRepo) ListOrdersByUser(ctx context.Context, userID int32) ([]repository.Order, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.Order), args.Error(1)
+}
+
+func (m *mockRepo) ListOrderItemsByUser(ctx context.Context, userID int32) ([]repository.ListOrderItemsByUserRow, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ListOrderItemsByUserRow), args.Error(1)
+}
+
+func (m *mockRepo) ListReviewsByUser(ctx context.Context, userID int32) ([]repository.Review, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.Review), args.Error(1)
+}
+
+func (m *mockRepo) ListCartItemsByUser(ctx context.Context, userID int32) ([]repository.Cart, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.Cart), args.Error(1)
+}
+
+func (m *mockRepo) DeleteCartItemsByUser(ctx context.Context, userID int32) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *mockRepo) DeleteWishlistItemsByUser(ctx context.Context, userID int32) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *mockRepo) GetNotificationRecipient(ctx context.Context, id int32) (repository.GetNotificationRecipientRow, error) {
+	args := m.Called(id)
+	return args.Get(0).(repository.GetNotificationRecipientRow), args.Error(1)
+}
+
+func (m *mockRepo) CreateNotification(ctx context.Context, arg repository.CreateNotificationParams) (repository.Notification, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.Notification), args.Error(1)
+}
+
+func (m *mockRepo) ListNotifications(ctx context.Context, arg repository.ListNotificationsParams) ([]repository.Notification, error) {
+	args := m.Called(arg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.Notification), args.Error(1)
+}
+
+func (m *mockRepo) CountUnreadNotifications(ctx context.Context, userID int32) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepo) MarkNotificationsRead(ctx context.Context, arg repository.MarkNotificationsReadParams) (int64, error) {
+	args := m.Called(arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepo) MarkAllNotificationsRead(ctx context.Context, userID int32) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepo) DeleteNotificationsByUser(ctx context.Context, userID int32) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *mockRepo) DeleteEmailsByUser(ctx context.Context, userID int32) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *mockRepo) QueueEmail(ctx context.Context, arg repository.QueueEmailParams) error {
+	args := m.Called(arg)
+	return args.Error(0)
+}
+
+func (m *mockRepo) ListPendingEmails(ctx context.Context, limit int32) ([]repository.OutgoingEmail, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]repository.OutgoingEmail), args.Error(1)
+}
+
+func (m *mockRepo) MarkEmailSent(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockRepo) MarkEmailFailed(ctx context.Context, arg repository.MarkEmailFailedParams) (bool, error) {
+	args := m.Called(arg)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepo) ListNotificationPreferences(ctx context.Context, userID int32) ([]repository.NotificationPreference, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.NotificationPreference), args.Error(1)
+}
+
+func (m *mockRepo) GetNotificationPreference(ctx context.Context, arg repository.GetNotificationPreferenceParams) (repository.NotificationPreference, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.NotificationPreference), args.Error(1)
+}
+
+func (m *mockRepo) UpsertNotificationPreference(ctx context.Context, arg repository.UpsertNotificationPreferenceParams) (repository.NotificationPreference, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.NotificationPreference), args.Error(1)
+}
+
+func (m *mockRepo) DeleteNotificationPreferencesByUser(ctx context.Context, userID int32) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *mockRepo) DeleteReviewsByUser(ctx context.Context, userID int32) ([]int32, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int32), args.Error(1)
+}
+
+var productInteractor interactor.ProductInteractor
+var repo *mockRepo
+
+func TestMain(m *testing.M) {
+	repo = new(mockRepo)
+	productInteractor = interactor.NewProductInteractor(repo)
+	os.Exit(m.Run())
+}
+
+func TestCreate(t *testing.T) {
+	usd := &product.Money{MinorUnits: 125050, Currency: "USD"}
+	testTable := map[string]struct {
+		stock   int32
+		price   *product.Money
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Product, err error)
+	}{
+		"records opening stock": {
+			stock: 10,
+			price: usd,
+			arrange: func(t *testing.T) {
+				repo.On("CreateProduct", mock.MatchedBy(func(arg repository.CreateProductParams) bool {
+					return arg.Price.String == "1250.50" && arg.Currency == "USD"
+				})).Return(repository.Product{
+					ID:       1,
+					Price:    sql.NullString{String: "1250.50", Valid: true},
+					Currency: "USD",
+					Stock:    sql.NullInt32{Int32: 10, Valid: true},
+				}, nil).Once()
+				repo.On("CreateStockMovement", mock.MatchedBy(func(arg repository.CreateStockMovementParams) bool {
+					return arg.Quantity == 10 && arg.Reason == "restock"
+				})).Return(repository.StockMovement{}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Product, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(10), actual.Stock)
+				require.Equal(t, usd.MinorUnits, actual.Price.MinorUnits)
+			},
+		},
+		"no movement without stock": {
+			price: usd,
+			arrange: func(t *testing.T) {
+				repo.On("CreateProduct", mock.Anything).Return(repository.Product{ID: 2}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Product, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(2), actual.Id)
+			},
+		},
+		"fail call": {
+			price: usd,
+			arrange: func(t *testing.T) {
+				repo.On("CreateProduct", mock.Anything).Return(repository.Product{}, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.Product, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+		"missing price": {
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.Product, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidPrice)
+			},
+		},
+		"price would lose precision": {
+			price:   &product.Money{MinorUnits: 1255, Currency: "KWD"},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.Product, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidPrice)
+			},
+		},
+		"negative price": {
+			price:   &product.Money{MinorUnits: -1, Currency: "USD"},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.Product, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidPrice)
+			},
+		},
+		"price too large for the column": {
+			price:   &product.Money{MinorUnits: 1000000000000, Currency: "USD"},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.Product, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidPrice)
+			},
+		},
+		"unknown currency": {
+			price:   &product.Money{MinorUnits: 100, Currency: "DOGE"},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.Product, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidPrice)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := productInteractor.Create(ctx, &product.ProductPayload{Name: "MacBook", Price: v.price, Stock: v.stock})
+
+			v.assert(t, result, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestAdjustStock(t *testing.T) {
+	testTable := map[string]struct {
+		adjustment *product.StockAdjustment
+		arrange    func(t *testing.T)
+		assert     func(t *testing.T, actual *product.StockMovement, err error)
+	}{
+		"succes call": {
+			adjustment: &product.StockAdjustment{ProductId: 1, Quantity: -2, Reason: product.StockReason_SALE, Reference: "order-1"},
+			arrange: func(t *testing.T) {
+				repo.On("AddProductStock", repository.AddProductStockParams{ID: 1, Quantity: -2}).Return(sql.NullInt32{Int32: 8, Valid: true}, nil).Once()
+				repo.On("CreateStockMovement", mock.Anything).Return(repository.StockMovement{ID: 3, ProductID: 1, Quantity: -2, Reason: "sale"}, nil).Once()
+				repo.On("InsertOutboxEvent", messaging.TopicStockAdjusted, "1").Return(nil).Once()
+				repo.On("SyncWishlistItems", []int32{1}).Return([]repository.SyncWishlistItemsRow{}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovement, err error) {
+				require.NoError(t, err)
+				require.Equal(t, product.StockReason_SALE, actual.Reason)
+				require.Equal(t, int32(-2), actual.Quantity)
+			},
+		},
+		"insufficient stock": {
+			adjustment: &product.StockAdjustment{ProductId: 1, Quantity: -20, Reason: product.StockReason_SALE},
+			arrange: func(t *testing.T) {
+				repo.On("AddProductStock", mock.Anything).Return(sql.NullInt32{}, sql.ErrNoRows).Once()
+				repo.On("GetProduct", int32(1)).Return(repository.Product{ID: 1}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovement, err error) {
+				require.ErrorIs(t, err, interactor.ErrInsufficientStock)
+				require.Nil(t, actual)
+			},
+		},
+		"unknown product": {
+			adjustment: &product.StockAdjustment{ProductId: 9, Quantity: 5, Reason: product.StockReason_RESTOCK},
+			arrange: func(t *testing.T) {
+				repo.On("AddProductStock", mock.Anything).Return(sql.NullInt32{}, sql.ErrNoRows).Once()
+				repo.On("GetProduct", int32(9)).Return(repository.Product{}, sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovement, err error) {
+				require.ErrorIs(t, err, interactor.ErrProductNotFound)
+			},
+		},
+		"variant stock": {
+			adjustment: &product.StockAdjustment{ProductId: 1, VariantId: 4, Quantity: 3, Reason: product.StockReason_RESTOCK},
+			arrange: func(t *testing.T) {
+				repo.On("AddVariantStock", repository.AddVariantStockParams{ID: 4, ProductID: 1, Quantity: 3}).Return(int32(3), nil).Once()
+				repo.On("CreateStockMovement", mock.MatchedBy(func(arg repository.CreateStockMovementParams) bool {
+					return arg.VariantID == sql.NullInt32{Int32: 4, Valid: true}
+				})).Return(repository.StockMovement{ProductID: 1, VariantID: sql.NullInt32{Int32: 4, Valid: true}, Quantity: 3, Reason: "restock"}, nil).Once()
+				repo.On("InsertOutboxEvent", messaging.TopicStockAdjusted, "1").Return(nil).Once()
+				repo.On("SyncWishlistItems", []int32{1}).Return([]repository.SyncWishlistItemsRow{}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovement, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(4), actual.VariantId)
+			},
+		},
+		"variant of another product": {
+			adjustment: &product.StockAdjustment{ProductId: 2, VariantId: 4, Quantity: 3, Reason: product.StockReason_RESTOCK},
+			arrange: func(t *testing.T) {
+				repo.On("AddVariantStock", mock.Anything).Return(int32(0), sql.ErrNoRows).Once()
+				repo.On("GetVariant", int32(4)).Return(repository.ProductVariant{ID: 4, ProductID: 1}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovement, err error) {
+				require.ErrorIs(t, err, interactor.ErrVariantNotFound)
+			},
+		},
+		"insufficient variant stock": {
+			adjustment: &product.StockAdjustment{ProductId: 1, VariantId: 4, Quantity: -30, Reason: product.StockReason_SALE},
+			arrange: func(t *testing.T) {
+				repo.On("AddVariantStock", mock.Anything).Return(int32(0), sql.ErrNoRows).Once()
+				repo.On("GetVariant", int32(4)).Return(repository.ProductVariant{ID: 4, ProductID: 1}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovement, err error) {
+				require.ErrorIs(t, err, interactor.ErrInsufficientStock)
+			},
+		},
+		"missing reason": {
+			adjustment: &product.StockAdjustment{ProductId: 1, Quantity: 5},
+			arrange:    func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.StockMovement, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidStockReason)
+			},
+		},
+		"zero quantity": {
+			adjustment: &product.StockAdjustment{ProductId: 1, Reason: product.StockReason_ADJUSTMENT},
+			arrange:    func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.StockMovement, err error) {
+				require.ErrorIs(t, err, interactor.ErrZeroQuantity)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := productInteractor.AdjustStock(ctx, v.adjustment)
+
+			v.assert(t, result, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestListStockMovements(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.StockMovements, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				repo.On("ListStockMovements", repository.ListStockMovementsParams{ProductID: 1, Limit: 50}).
+					Return([]repository.StockMovement{{Reason: "restock"}, {Reason: "return"}}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovements, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Movements, 2)
+				require.Equal(t, product.StockReason_RETURN, actual.Movements[1].Reason)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				repo.On("ListStockMovements", mock.Anything).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.StockMovements, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := productInteractor.ListStockMovements(ctx, &product.StockMovementsRequest{ProductId: 1})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestReconcileStock(t *testing.T) {
+	drift := []repository.ListStockDriftRow{
+		{ProductID: 1, Stock: 12, LedgerStock: 10},
+		{ProductID: 2, VariantID: 5, Stock: 1, LedgerStock: 4},
+	}
+	testTable := map[string]struct {
+		fix     bool
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual []repository.ListStockDriftRow, err error)
+	}{
+		"report only": {
+			arrange: func(t *testing.T) {
+				repo.On("ListStockDrift").Return(drift, nil).Once()
+			},
+			assert: func(t *testing.T, actual []repository.ListStockDriftRow, err error) {
+				require.NoError(t, err)
+				require.Equal(t, drift, actual)
+			},
+		},
+		"fix drift": {
+			fix: true,
+			arrange: func(t *testing.T) {
+				repo.On("LockProductStock").Return(nil).Once()
+				repo.On("LockVariantStock").Return(nil).Once()
+				repo.On("ListStockDrift").Return(drift, nil).Once()
+				repo.On("SetProductStock", repository.SetProductStockParams{ID: 1, Stock: sql.NullInt32{Int32: 10, Valid: true}}).Return(nil).Once()
+				repo.On("SetVariantStock", repository.SetVariantStockParams{ID: 5, Stock: 4}).Return(nil).Once()
+			},
+			assert: func(t *testing.T, actual []repository.ListStockDriftRow, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual, 2)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				repo.On("ListStockDrift").Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual []repository.ListStockDriftRow, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := productInteractor.ReconcileStock(ctx, v.fix)
+
+			v.assert(t, result, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func (m *mockRepo) LockProductStock(ctx context.Context) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *mockRepo) LockVariantStock(ctx context.Context) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *mockRepo) CancelOrder(ctx context.Context, arg repository.CancelOrderParams) (repository.Order, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.Order), args.Error(1)
+}
+
+func (m *mockRepo) ListOrderItems(ctx context.Context, orderID sql.NullInt32) ([]repository.ListOrderItemsRow, error) {
+	args := m.Called(orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.ListOrderItemsRow), args.Error(1)
+}
+
+func (m *mockRepo) MarkEventProcessed(ctx context.Context, arg repository.MarkEventProcessedParams) (int64, error) {
+	args := m.Called(arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepo) CreateDeadLetter(ctx context.Context, arg repository.CreateDeadLetterParams) (repository.DeadLetter, error) {
+	args := m.Called(arg)
+	return args.Get(0).(repository.DeadLetter), args.Error(1)
+}
+
+func (m *mockRepo) GetDeadLetter(ctx context.Context, id int64) (repository.DeadLetter, error) {
+	args := m.Called(id)
+	return args.Get(0).(repository.DeadLetter), args.Error(1)
+}
+
+func (m *mockRepo) ListDeadLetters(ctx context.Context, arg repository.ListDeadLettersParams) ([]repository.DeadLetter, error) {
+	args := m.Called(arg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.DeadLetter), args.Error(1)
+}
+
+func (m *mockRepo) MarkDeadLetterReplayed(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
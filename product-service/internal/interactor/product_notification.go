@@ -0,0 +1,260 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ryanpujo/product-service/internal/messaging"
+	"github.com/ryanpujo/product-service/internal/notification"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/events"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The consumers that turn events into notifications.
+const (
+	ConsumerNotifyWelcome        = "notify-welcome"
+	ConsumerNotifyOrderPlaced    = "notify-order-placed"
+	ConsumerNotifyOrderCancelled = "notify-order-cancelled"
+)
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+// notificationConsumers are the consumers that notify users, they run only
+// when the interactor has a notification service.
+func (in *productInteractor) notificationConsumers() []*messaging.Consumer {
+	if in.Notifications == nil {
+		return nil
+	}
+	return []*messaging.Consumer{
+		messaging.NewConsumer(ConsumerNotifyWelcome, messaging.TopicUserRegistered, in.Repo, in.NotifyWelcome),
+		messaging.NewConsumer(ConsumerNotifyOrderPlaced, messaging.TopicOrderPlaced, in.Repo, in.NotifyOrderPlaced),
+		messaging.NewConsumer(ConsumerNotifyOrderCancelled, messaging.TopicOrderCancelled, in.Repo, in.NotifyOrderCancelled),
+	}
+}
+
+// NotifyWelcome greets a user who just signed up.
+func (in *productInteractor) NotifyWelcome(ctx context.Context, q repository.Querier, envelope *events.Envelope) error {
+	registered := envelope.GetUserRegistered()
+	if registered == nil {
+		return fmt.Errorf("%w: %T", messaging.ErrUnknownEvent, envelope.Event)
+	}
+	return in.Notifications.Notify(ctx, q, notification.Notification{
+		UserID: int32(registered.UserId),
+		Kind:   notification.KindWelcome,
+		Data:   registered,
+	})
+}
+
+// NotifyOrderPlaced confirms an order to the user who placed it.
+func (in *productInteractor) NotifyOrderPlaced(ctx context.Context, q repository.Querier, envelope *events.Envelope) error {
+	placed := envelope.GetOrderPlaced()
+	if placed == nil {
+		return fmt.Errorf("%w: %T", messaging.ErrUnknownEvent, envelope.Event)
+	}
+	return in.Notifications.Notify(ctx, q, notification.Notification{
+		UserID: int32(placed.UserId),
+		Kind:   notification.KindOrderPlaced,
+		Data:   placed,
+	})
+}
+
+// NotifyOrderCancelled tells the user an order of theirs is cancelled.
+func (in *productInteractor) NotifyOrderCancelled(ctx context.Context, q repository.Querier, envelope *events.Envelope) error {
+	cancelled := envelope.GetOrderCancelled()
+	if cancelled == nil {
+		return fmt.Errorf("%w: %T", messaging.ErrUnknownEvent, envelope.Event)
+	}
+	return in.Notifications.Notify(ctx, q, notification.Notification{
+		UserID: int32(cancelled.UserId),
+		Kind:   notification.KindOrderCancelled,
+		Data:   cancelled,
+	})
+}
+
+// NotificationNotifier delivers wishlist alerts as notifications, each in
+// its own transaction so one failing user does not hold back the others.
+type NotificationNotifier struct {
+	Repo    repository.TxQuerier
+	Service *notification.Service
+}
+
+var wishlistKinds = map[WishlistAlertKind]notification.Kind{
+	PriceDropped: notification.KindPriceDropped,
+	BackInStock:  notification.KindBackInStock,
+}
+
+func (n NotificationNotifier) NotifyWishlist(ctx context.Context, alerts []WishlistAlert) error {
+	var failed error
+	for _, alert := range alerts {
+		kind, ok := wishlistKinds[alert.Kind]
+		if !ok {
+			failed = fmt.Errorf("%w: %q", notification.ErrUnknownKind, alert.Kind)
+			continue
+		}
+		err := n.Repo.ExecTx(ctx, func(q repository.Querier) error {
+			userID, err := customerID(ctx, q, alert.Email)
+			if err != nil {
+				return err
+			}
+			return n.Service.Notify(ctx, q, notification.Notification{UserID: userID, Kind: kind, Data: alert})
+		})
+		if err != nil {
+			log.Printf("failed to notify %s of %s for product %d: %v", alert.Email, alert.Kind, alert.ProductID, err)
+			failed = err
+		}
+	}
+	return failed
+}
+
+// ListNotifications returns a page of the inbox of the user, newest first,
+// with the number of unread notifications.
+func (in *productInteractor) ListNotifications(ctx context.Context, req *product.NotificationsRequest) (*product.Notifications, error) {
+	userID, err := in.customerID(ctx, req.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultNotificationPageSize
+	}
+	if pageSize > maxNotificationPageSize {
+		pageSize = maxNotificationPageSize
+	}
+	rows, err := in.Repo.ListNotifications(ctx, repository.ListNotificationsParams{
+		UserID:     userID,
+		UnreadOnly: req.UnreadOnly,
+		BeforeID:   req.BeforeId,
+		PageSize:   pageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	unread, err := in.Repo.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	notifications := product.Notifications{
+		Notifications: make([]*product.Notification, 0, len(rows)),
+		Unread:        unread,
+	}
+	for _, row := range rows {
+		notifications.Notifications = append(notifications.Notifications, toNotification(row))
+	}
+	return &notifications, nil
+}
+
+// MarkNotificationsRead marks the notifications read, ids that are read
+// already or belong to someone else are skipped.
+func (in *productInteractor) MarkNotificationsRead(ctx context.Context, req *product.NotificationsRead) (*product.NotificationsReadResult, error) {
+	userID, err := in.customerID(ctx, req.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	var marked int64
+	if req.All {
+		marked, err = in.Repo.MarkAllNotificationsRead(ctx, userID)
+	} else if len(req.Ids) > 0 {
+		marked, err = in.Repo.MarkNotificationsRead(ctx, repository.MarkNotificationsReadParams{
+			UserID: userID,
+			Ids:    req.Ids,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	unread, err := in.Repo.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &product.NotificationsReadResult{Marked: marked, Unread: unread}, nil
+}
+
+// GetNotificationPreferences returns the preference of every category, the
+// ones the user never changed are all on.
+func (in *productInteractor) GetNotificationPreferences(ctx context.Context, req *product.NotificationPreferencesRequest) (*product.NotificationPreferences, error) {
+	userID, err := in.customerID(ctx, req.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	return notificationPreferences(ctx, in.Repo, userID)
+}
+
+// UpdateNotificationPreferences stores the preferences of the categories in
+// the update, all of them or none.
+func (in *productInteractor) UpdateNotificationPreferences(ctx context.Context, update *product.NotificationPreferencesUpdate) (*product.NotificationPreferences, error) {
+	for _, p := range update.Preferences {
+		if !notification.ValidCategory(p.Category) {
+			return nil, fmt.Errorf("%w: %q", notification.ErrUnknownCategory, p.Category)
+		}
+	}
+	var preferences *product.NotificationPreferences
+	err := in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		userID, err := customerID(ctx, q, update.UserEmail)
+		if err != nil {
+			return err
+		}
+		for _, p := range update.Preferences {
+			_, err = q.UpsertNotificationPreference(ctx, repository.UpsertNotificationPreferenceParams{
+				UserID:   userID,
+				Category: p.Category,
+				Email:    p.Email,
+				InApp:    p.InApp,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		preferences, err = notificationPreferences(ctx, q, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+func notificationPreferences(ctx context.Context, q repository.Querier, userID int32) (*product.NotificationPreferences, error) {
+	rows, err := q.ListNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]repository.NotificationPreference, len(rows))
+	for _, row := range rows {
+		stored[row.Category] = row
+	}
+	preferences := product.NotificationPreferences{
+		Preferences: make([]*product.NotificationPreference, 0, len(notification.Categories)),
+	}
+	for _, category := range notification.Categories {
+		p, ok := stored[string(category)]
+		if !ok {
+			p = notification.DefaultPreference(userID, category)
+		}
+		preferences.Preferences = append(preferences.Preferences, &product.NotificationPreference{
+			Category: p.Category,
+			Email:    p.Email,
+			InApp:    p.InApp,
+		})
+	}
+	return &preferences, nil
+}
+
+func toNotification(n repository.Notification) *product.Notification {
+	notification := product.Notification{
+		Id:        n.ID,
+		Kind:      n.Kind,
+		Subject:   n.Subject,
+		Body:      n.Body,
+		CreatedAt: timestamppb.New(n.CreatedAt),
+	}
+	if n.ReadAt.Valid {
+		notification.ReadAt = timestamppb.New(n.ReadAt.Time)
+	}
+	return &notification
+}
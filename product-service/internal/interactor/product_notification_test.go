@@ -0,0 +1,296 @@
+package interactor_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log"
+	"testing"
+
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/money"
+	"github.com/ryanpujo/product-service/internal/notification"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/events"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func notificationService(t *testing.T) (*notification.Service, *bytes.Buffer) {
+	templates, err := notification.DefaultTemplates()
+	require.NoError(t, err)
+	var emails bytes.Buffer
+	return notification.NewService(templates, map[notification.Channel]notification.Sender{
+		notification.ChannelInApp: notification.Inbox{},
+		notification.ChannelEmail: notification.LogSink{Logger: log.New(&emails, "", 0)},
+	}), &emails
+}
+
+var recipient = repository.GetNotificationRecipientRow{
+	ID:       5,
+	Username: sql.NullString{String: "jane", Valid: true},
+	Email:    sql.NullString{String: "jane@mail.com", Valid: true},
+	Locale:   "en",
+}
+
+func TestNotifyOrderPlaced(t *testing.T) {
+	envelope := &events.Envelope{Id: "event-1", Event: &events.Envelope_OrderPlaced{OrderPlaced: &events.OrderPlaced{
+		OrderId:         12,
+		UserId:          5,
+		Items:           []*events.OrderItem{{ProductId: 1, Quantity: 2}},
+		TotalMinorUnits: 1250,
+		Currency:        "USD",
+	}}}
+	preference := repository.GetNotificationPreferenceParams{UserID: 5, Category: "orders"}
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, emails string, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				repo.On("GetNotificationRecipient", int32(5)).Return(recipient, nil).Once()
+				repo.On("GetNotificationPreference", preference).Return(repository.NotificationPreference{}, sql.ErrNoRows).Once()
+				repo.On("CreateNotification", mock.MatchedBy(func(arg repository.CreateNotificationParams) bool {
+					return arg.UserID == 5 && arg.Kind == "order_placed" && arg.Subject == "Order #12 is confirmed"
+				})).Return(repository.Notification{ID: 1}, nil).Once()
+			},
+			assert: func(t *testing.T, emails string, err error) {
+				require.NoError(t, err)
+				require.Contains(t, emails, "to jane@mail.com: Order #12 is confirmed")
+				require.Contains(t, emails, "Total: 12.50 USD")
+			},
+		},
+		"email off": {
+			arrange: func(t *testing.T) {
+				repo.On("GetNotificationRecipient", int32(5)).Return(recipient, nil).Once()
+				repo.On("GetNotificationPreference", preference).Return(repository.NotificationPreference{UserID: 5, Category: "orders", InApp: true}, nil).Once()
+				repo.On("CreateNotification", mock.Anything).Return(repository.Notification{ID: 1}, nil).Once()
+			},
+			assert: func(t *testing.T, emails string, err error) {
+				require.NoError(t, err)
+				require.Empty(t, emails)
+			},
+		},
+		"deleted user": {
+			arrange: func(t *testing.T) {
+				repo.On("GetNotificationRecipient", int32(5)).Return(repository.GetNotificationRecipientRow{}, sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, emails string, err error) {
+				require.NoError(t, err)
+				require.Empty(t, emails)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+			in := interactor.NewProductInteractor(repo)
+			service, emails := notificationService(t)
+			in.Notifications = service
+
+			err := in.NotifyOrderPlaced(context.Background(), repo, envelope)
+
+			v.assert(t, emails.String(), err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestNotificationNotifier(t *testing.T) {
+	service, emails := notificationService(t)
+	notifier := interactor.NotificationNotifier{Repo: repo, Service: service}
+	repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+	repo.On("GetNotificationRecipient", int32(5)).Return(recipient, nil).Once()
+	repo.On("GetNotificationPreference", repository.GetNotificationPreferenceParams{UserID: 5, Category: "wishlist"}).
+		Return(repository.NotificationPreference{UserID: 5, Category: "wishlist", Email: true}, nil).Once()
+
+	err := notifier.NotifyWishlist(context.Background(), []interactor.WishlistAlert{{
+		Kind:          interactor.PriceDropped,
+		Email:         "jane@mail.com",
+		ProductID:     1,
+		Name:          "Mug",
+		Price:         money.Money{Minor: 800, Currency: "USD"},
+		PreviousPrice: money.Money{Minor: 1000, Currency: "USD"},
+	}})
+
+	require.NoError(t, err)
+	require.Contains(t, emails.String(), "dropped from 10.00 USD to 8.00 USD")
+	repo.AssertExpectations(t)
+}
+
+func TestNotificationConsumers(t *testing.T) {
+	in := interactor.NewProductInteractor(repo)
+	in.Notifications, _ = notificationService(t)
+
+	topics := map[string]string{}
+	for _, c := range in.Consumers() {
+		topics[c.Name] = c.Topic
+	}
+
+	require.Equal(t, "user.registered", topics[interactor.ConsumerNotifyWelcome])
+	require.Equal(t, "order.placed", topics[interactor.ConsumerNotifyOrderPlaced])
+	require.Equal(t, "order.cancelled", topics[interactor.ConsumerNotifyOrderCancelled])
+}
+
+func TestListNotifications(t *testing.T) {
+	testTable := map[string]struct {
+		req     *product.NotificationsRequest
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Notifications, err error)
+	}{
+		"succes call": {
+			req: &product.NotificationsRequest{UserEmail: "jane@mail.com", UnreadOnly: true, BeforeId: 40},
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("ListNotifications", repository.ListNotificationsParams{UserID: 5, UnreadOnly: true, BeforeID: 40, PageSize: 20}).
+					Return([]repository.Notification{{ID: 39, Kind: "welcome"}}, nil).Once()
+				repo.On("CountUnreadNotifications", int32(5)).Return(int64(3), nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Notifications, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Notifications, 1)
+				require.Nil(t, actual.Notifications[0].ReadAt)
+				require.Equal(t, int64(3), actual.Unread)
+			},
+		},
+		"page too big": {
+			req: &product.NotificationsRequest{UserEmail: "jane@mail.com", PageSize: 1000},
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("ListNotifications", repository.ListNotificationsParams{UserID: 5, PageSize: 100}).
+					Return([]repository.Notification{}, nil).Once()
+				repo.On("CountUnreadNotifications", int32(5)).Return(int64(0), nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Notifications, err error) {
+				require.NoError(t, err)
+				require.Empty(t, actual.Notifications)
+			},
+		},
+		"unknown customer": {
+			req: &product.NotificationsRequest{UserEmail: "nobody@mail.com"},
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "nobody@mail.com").Return(int32(0), sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.Notifications, err error) {
+				require.ErrorIs(t, err, interactor.ErrCustomerNotFound)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := productInteractor.ListNotifications(context.Background(), v.req)
+
+			v.assert(t, actual, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestMarkNotificationsRead(t *testing.T) {
+	testTable := map[string]struct {
+		req     *product.NotificationsRead
+		arrange func(t *testing.T)
+		marked  int64
+	}{
+		"succes call": {
+			req: &product.NotificationsRead{UserEmail: "jane@mail.com", Ids: []int64{3, 4}},
+			arrange: func(t *testing.T) {
+				repo.On("MarkNotificationsRead", repository.MarkNotificationsReadParams{UserID: 5, Ids: []int64{3, 4}}).Return(int64(2), nil).Once()
+			},
+			marked: 2,
+		},
+		"all": {
+			req: &product.NotificationsRead{UserEmail: "jane@mail.com", All: true},
+			arrange: func(t *testing.T) {
+				repo.On("MarkAllNotificationsRead", int32(5)).Return(int64(7), nil).Once()
+			},
+			marked: 7,
+		},
+		"nothing": {
+			req:     &product.NotificationsRead{UserEmail: "jane@mail.com"},
+			arrange: func(t *testing.T) {},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+			repo.On("CountUnreadNotifications", int32(5)).Return(int64(1), nil).Once()
+			v.arrange(t)
+
+			actual, err := productInteractor.MarkNotificationsRead(context.Background(), v.req)
+
+			require.NoError(t, err)
+			require.Equal(t, v.marked, actual.Marked)
+			require.Equal(t, int64(1), actual.Unread)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+	repo.On("ListNotificationPreferences", int32(5)).Return([]repository.NotificationPreference{
+		{UserID: 5, Category: "wishlist", InApp: true},
+	}, nil).Once()
+
+	actual, err := productInteractor.GetNotificationPreferences(context.Background(), &product.NotificationPreferencesRequest{UserEmail: "jane@mail.com"})
+
+	require.NoError(t, err)
+	require.Equal(t, []*product.NotificationPreference{
+		{Category: "account", Email: true, InApp: true},
+		{Category: "orders", Email: true, InApp: true},
+		{Category: "wishlist", InApp: true},
+	}, actual.Preferences)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdateNotificationPreferences(t *testing.T) {
+	testTable := map[string]struct {
+		update  *product.NotificationPreferencesUpdate
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.NotificationPreferences, err error)
+	}{
+		"succes call": {
+			update: &product.NotificationPreferencesUpdate{
+				UserEmail:   "jane@mail.com",
+				Preferences: []*product.NotificationPreference{{Category: "orders", InApp: true}},
+			},
+			arrange: func(t *testing.T) {
+				stored := repository.NotificationPreference{UserID: 5, Category: "orders", InApp: true}
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("UpsertNotificationPreference", repository.UpsertNotificationPreferenceParams{UserID: 5, Category: "orders", InApp: true}).
+					Return(stored, nil).Once()
+				repo.On("ListNotificationPreferences", int32(5)).Return([]repository.NotificationPreference{stored}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.NotificationPreferences, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Preferences, 3)
+				require.False(t, actual.Preferences[1].Email)
+			},
+		},
+		"unknown category": {
+			update: &product.NotificationPreferencesUpdate{
+				UserEmail:   "jane@mail.com",
+				Preferences: []*product.NotificationPreference{{Category: "newsletter"}},
+			},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.NotificationPreferences, err error) {
+				require.ErrorIs(t, err, notification.ErrUnknownCategory)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := productInteractor.UpdateNotificationPreferences(context.Background(), v.update)
+
+			v.assert(t, actual, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,270 @@
+package interactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ryanpujo/product-service/internal/messaging"
+	"github.com/ryanpujo/product-service/internal/money"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/events"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrCustomerNotFound    = errors.New("customer not found")
+	ErrOrderNotCancellable = errors.New("no pending order to cancel")
+)
+
+const orderPending = "pending"
+
+// PlaceOrder prices the items the same way PriceCart does and, in one
+// transaction, records the order with its discounts, counts a use of every
+// applied promotion and takes the items out of stock.
+func (in *productInteractor) PlaceOrder(ctx context.Context, req *product.PlaceOrderRequest) (*product.Order, error) {
+	var order *product.Order
+	err := in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		userID, err := customerID(ctx, q, req.UserEmail)
+		if err != nil {
+			return err
+		}
+		cart, err := priceCart(ctx, q, req.Items, req.CouponCode)
+		if err != nil {
+			return err
+		}
+		// counting the use checks the limit again, another order may have
+		// taken the last one since the cart was priced
+		for _, applied := range cart.result.Applied {
+			if _, err = q.RedeemPromotion(ctx, applied.Rule.ID); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return ErrPromotionExhausted
+				}
+				return err
+			}
+		}
+
+		r := cart.result
+		subtotal, err := orderAmount(r.Subtotal)
+		if err != nil {
+			return err
+		}
+		discount, err := orderAmount(r.Discount)
+		if err != nil {
+			return err
+		}
+		total, err := orderAmount(r.Total)
+		if err != nil {
+			return err
+		}
+		created, err := q.CreateOrder(ctx, repository.CreateOrderParams{
+			UserID:         sql.NullInt32{Int32: userID, Valid: true},
+			StoreID:        sql.NullInt32{Int32: cart.storeID, Valid: cart.storeID != 0},
+			Currency:       sql.NullString{String: r.Total.Currency, Valid: true},
+			SubtotalAmount: sql.NullString{String: subtotal, Valid: true},
+			DiscountAmount: discount,
+			TotalAmount:    sql.NullString{String: total, Valid: true},
+			CouponCode:     sql.NullString{String: cart.coupon, Valid: cart.coupon != ""},
+			Status:         sql.NullString{String: orderPending, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		reference := fmt.Sprintf("order:%d", created.ID)
+		for _, l := range r.Lines {
+			if err = in.takeStock(ctx, q, l.ProductID, l.VariantID, int32(l.Quantity), reference); err != nil {
+				return err
+			}
+			price, err := orderAmount(l.UnitPrice)
+			if err != nil {
+				return err
+			}
+			lineDiscount, err := orderAmount(l.Discount)
+			if err != nil {
+				return err
+			}
+			err = q.CreateOrderItem(ctx, repository.CreateOrderItemParams{
+				OrderID:   sql.NullInt32{Int32: created.ID, Valid: true},
+				ProductID: sql.NullInt32{Int32: l.ProductID, Valid: true},
+				VariantID: sql.NullInt32{Int32: l.VariantID, Valid: l.VariantID != 0},
+				Quantity:  sql.NullInt32{Int32: int32(l.Quantity), Valid: true},
+				Price:     sql.NullString{String: price, Valid: true},
+				Discount:  lineDiscount,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, applied := range r.Applied {
+			amount, err := orderAmount(applied.Discount)
+			if err != nil {
+				return err
+			}
+			err = q.CreatePromotionRedemption(ctx, repository.CreatePromotionRedemptionParams{
+				PromotionID: applied.Rule.ID,
+				OrderID:     created.ID,
+				Amount:      amount,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		items := make([]*events.OrderItem, 0, len(r.Lines))
+		for _, l := range r.Lines {
+			items = append(items, &events.OrderItem{
+				ProductId: int64(l.ProductID),
+				VariantId: int64(l.VariantID),
+				Quantity:  int32(l.Quantity),
+			})
+		}
+		envelope := messaging.NewEnvelope(ctx)
+		envelope.Event = &events.Envelope_OrderPlaced{OrderPlaced: &events.OrderPlaced{
+			OrderId:         int64(created.ID),
+			UserId:          int64(userID),
+			StoreId:         int64(cart.storeID),
+			Items:           items,
+			TotalMinorUnits: cart.price.GetTotal().GetMinorUnits(),
+			Currency:        cart.price.GetTotal().GetCurrency(),
+			CouponCode:      cart.coupon,
+		}}
+		if err = messaging.Enqueue(ctx, q, envelope); err != nil {
+			return err
+		}
+
+		order = &product.Order{
+			Id:         int64(created.ID),
+			UserId:     int64(userID),
+			StoreId:    int64(cart.storeID),
+			Status:     created.Status.String,
+			Price:      cart.price,
+			CouponCode: cart.coupon,
+		}
+		if created.OrderDate.Valid {
+			order.CreatedAt = timestamppb.New(created.OrderDate.Time)
+		}
+		return in.audit(ctx, q, AuditOrderPlace, "order", order.Id, map[string]interface{}{
+			"userId":     order.UserId,
+			"storeId":    order.StoreId,
+			"status":     order.Status,
+			"couponCode": order.CouponCode,
+			"total":      auditedMoney(order.Price.GetTotal()),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	productIDs := make([]int32, 0, len(req.Items))
+	for _, item := range req.Items {
+		productIDs = append(productIDs, int32(item.ProductId))
+	}
+	in.notifyWishlists(ctx, productIDs)
+	return order, nil
+}
+
+// CancelOrder cancels a pending order of the user. The items go back into
+// stock once the stock-release consumer handles the OrderCancelled event
+// written in the same transaction.
+func (in *productInteractor) CancelOrder(ctx context.Context, req *product.OrderCancellation) (*product.Order, error) {
+	var order *product.Order
+	err := in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		userID, err := customerID(ctx, q, req.UserEmail)
+		if err != nil {
+			return err
+		}
+		cancelled, err := q.CancelOrder(ctx, repository.CancelOrderParams{
+			ID:     int32(req.Id),
+			UserID: sql.NullInt32{Int32: userID, Valid: true},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOrderNotCancellable
+		}
+		if err != nil {
+			return err
+		}
+		// the promotions the order used can be used again
+		if err = q.ReleaseOrderPromotions(ctx, cancelled.ID); err != nil {
+			return err
+		}
+		rows, err := q.ListOrderItems(ctx, sql.NullInt32{Int32: cancelled.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+
+		lines := make([]repository.ListOrderItemsByUserRow, 0, len(rows))
+		items := make([]*events.OrderItem, 0, len(rows))
+		for _, row := range rows {
+			lines = append(lines, repository.ListOrderItemsByUserRow(row))
+			items = append(items, &events.OrderItem{
+				ProductId: int64(row.ProductID.Int32),
+				VariantId: int64(row.VariantID.Int32),
+				Quantity:  row.Quantity.Int32,
+			})
+		}
+		envelope := messaging.NewEnvelope(ctx)
+		envelope.Event = &events.Envelope_OrderCancelled{OrderCancelled: &events.OrderCancelled{
+			OrderId: int64(cancelled.ID),
+			UserId:  int64(userID),
+			StoreId: int64(cancelled.StoreID.Int32),
+			Items:   items,
+			Reason:  req.Reason,
+		}}
+		if err = messaging.Enqueue(ctx, q, envelope); err != nil {
+			return err
+		}
+		order, err = toOrderRecord(cancelled, lines)
+		if err != nil {
+			return err
+		}
+		return in.audit(ctx, q, AuditOrderCancel, "order", order.Id, map[string]interface{}{
+			"userId": order.UserId,
+			"status": order.Status,
+			"reason": req.Reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// orderAmount formats an amount of an order for its numeric(12,2) columns, an
+// order too large for them is refused like a price that is.
+func orderAmount(m money.Money) (string, error) {
+	decimal, err := m.Numeric(pricePrecision, priceScale)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPrice, err)
+	}
+	return decimal, nil
+}
+
+// takeStock removes a sold line from the stock of its product or variant and
+// records the sale in the ledger.
+func (in *productInteractor) takeStock(ctx context.Context, q repository.Querier, productID, variantID, quantity int32, reference string) error {
+	var err error
+	if variantID != 0 {
+		_, err = q.AddVariantStock(ctx, repository.AddVariantStockParams{ID: variantID, ProductID: productID, Quantity: -quantity})
+		if errors.Is(err, sql.ErrNoRows) {
+			return in.missingVariantStock(ctx, q, int64(productID), int64(variantID))
+		}
+	} else {
+		_, err = q.AddProductStock(ctx, repository.AddProductStockParams{ID: productID, Quantity: -quantity})
+		if errors.Is(err, sql.ErrNoRows) {
+			return in.missingStock(ctx, q, int64(productID))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	_, err = q.CreateStockMovement(ctx, repository.CreateStockMovementParams{
+		ProductID: productID,
+		VariantID: sql.NullInt32{Int32: variantID, Valid: variantID != 0},
+		Quantity:  -quantity,
+		Reason:    stockReasons[product.StockReason_SALE],
+		Reference: sql.NullString{String: reference, Valid: true},
+	})
+	return err
+}
@@ -0,0 +1,223 @@
+package interactor_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/messaging"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceOrder(t *testing.T) {
+	req := &product.PlaceOrderRequest{
+		UserEmail:  "jane@mail.com",
+		Items:      []*product.CartItem{{ProductId: 1, Quantity: 2}, {ProductId: 2, Quantity: 1}},
+		CouponCode: "FIVE",
+	}
+	created := repository.Order{
+		ID:        12,
+		OrderDate: sql.NullTime{Time: time.Now(), Valid: true},
+		Status:    sql.NullString{String: "pending", Valid: true},
+	}
+	priced := func() {
+		repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+		repo.On("ListProductsForPricing", []int32{1, 2}).Return(pricingRows, nil).Once()
+		repo.On("ListAutomaticPromotions", []int32{3}).Return([]repository.Promotion{shirtSale}, nil).Once()
+		repo.On("GetPromotionByCoupon", "FIVE").Return(fiveOff, nil).Once()
+	}
+
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Order, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				priced()
+				repo.On("RedeemPromotion", int32(7)).Return(int32(1), nil).Once()
+				repo.On("RedeemPromotion", int32(8)).Return(int32(1), nil).Once()
+				repo.On("CreateOrder", repository.CreateOrderParams{
+					UserID:         sql.NullInt32{Int32: 5, Valid: true},
+					StoreID:        sql.NullInt32{Int32: 3, Valid: true},
+					Currency:       sql.NullString{String: "USD", Valid: true},
+					SubtotalAmount: sql.NullString{String: "50.00", Valid: true},
+					DiscountAmount: "12.50",
+					TotalAmount:    sql.NullString{String: "37.50", Valid: true},
+					CouponCode:     sql.NullString{String: "FIVE", Valid: true},
+					Status:         sql.NullString{String: "pending", Valid: true},
+				}).Return(created, nil).Once()
+				repo.On("AddProductStock", repository.AddProductStockParams{ID: 1, Quantity: -2}).Return(sql.NullInt32{Int32: 3, Valid: true}, nil).Once()
+				repo.On("AddProductStock", repository.AddProductStockParams{ID: 2, Quantity: -1}).Return(sql.NullInt32{Int32: 0, Valid: true}, nil).Once()
+				repo.On("CreateStockMovement", mock.MatchedBy(func(arg repository.CreateStockMovementParams) bool {
+					return arg.Reason == "sale" && arg.Reference.String == "order:12"
+				})).Return(repository.StockMovement{}, nil).Twice()
+				repo.On("CreateOrderItem", repository.CreateOrderItemParams{
+					OrderID:   sql.NullInt32{Int32: 12, Valid: true},
+					ProductID: sql.NullInt32{Int32: 1, Valid: true},
+					Quantity:  sql.NullInt32{Int32: 2, Valid: true},
+					Price:     sql.NullString{String: "10.00", Valid: true},
+					Discount:  "2.35",
+				}).Return(nil).Once()
+				repo.On("CreateOrderItem", mock.MatchedBy(func(arg repository.CreateOrderItemParams) bool {
+					return arg.ProductID.Int32 == 2 && arg.Discount == "10.15"
+				})).Return(nil).Once()
+				repo.On("CreatePromotionRedemption", repository.CreatePromotionRedemptionParams{PromotionID: 7, OrderID: 12, Amount: "7.50"}).Return(nil).Once()
+				repo.On("CreatePromotionRedemption", repository.CreatePromotionRedemptionParams{PromotionID: 8, OrderID: 12, Amount: "5.00"}).Return(nil).Once()
+				repo.On("InsertOutboxEvent", messaging.TopicOrderPlaced, "12").Return(nil).Once()
+				repo.On("SyncWishlistItems", []int32{1, 2}).Return([]repository.SyncWishlistItemsRow{}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(12), actual.Id)
+				require.Equal(t, int64(3), actual.StoreId)
+				require.Equal(t, "pending", actual.Status)
+				require.Equal(t, int64(3750), actual.Price.Total.MinorUnits)
+				require.NotNil(t, actual.CreatedAt)
+			},
+		},
+		"coupon used up meanwhile": {
+			arrange: func(t *testing.T) {
+				priced()
+				repo.On("RedeemPromotion", int32(7)).Return(int32(1), nil).Once()
+				repo.On("RedeemPromotion", int32(8)).Return(int32(0), sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.ErrorIs(t, err, interactor.ErrPromotionExhausted)
+				require.Nil(t, actual)
+			},
+		},
+		"unknown customer": {
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(0), sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.ErrorIs(t, err, interactor.ErrCustomerNotFound)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				priced()
+				repo.On("RedeemPromotion", mock.Anything).Return(int32(1), nil).Twice()
+				repo.On("CreateOrder", mock.Anything).Return(repository.Order{}, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := productInteractor.PlaceOrder(context.Background(), req)
+
+			v.assert(t, actual, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestPlaceOrderTooLarge(t *testing.T) {
+	// 2,000,000,000 mugs at 10.00 do not fit the numeric(12,2) total
+	req := &product.PlaceOrderRequest{
+		UserEmail: "jane@mail.com",
+		Items:     []*product.CartItem{{ProductId: 1, Quantity: 2000000000}},
+	}
+	repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+	repo.On("ListProductsForPricing", []int32{1}).Return(pricingRows[:1], nil).Once()
+	repo.On("ListAutomaticPromotions", []int32{3}).Return([]repository.Promotion{}, nil).Once()
+
+	actual, err := productInteractor.PlaceOrder(context.Background(), req)
+	require.ErrorIs(t, err, interactor.ErrInvalidPrice)
+	require.Nil(t, actual)
+	repo.AssertExpectations(t)
+}
+
+func TestCancelOrder(t *testing.T) {
+	req := &product.OrderCancellation{Id: 12, UserEmail: "jane@mail.com", Reason: "changed my mind"}
+	cancelled := repository.Order{
+		ID:             12,
+		UserID:         sql.NullInt32{Int32: 5, Valid: true},
+		StoreID:        sql.NullInt32{Int32: 3, Valid: true},
+		Currency:       sql.NullString{String: "USD", Valid: true},
+		SubtotalAmount: sql.NullString{String: "20.00", Valid: true},
+		DiscountAmount: "0",
+		TotalAmount:    sql.NullString{String: "20.00", Valid: true},
+		Status:         sql.NullString{String: "cancelled", Valid: true},
+	}
+	params := repository.CancelOrderParams{ID: 12, UserID: sql.NullInt32{Int32: 5, Valid: true}}
+
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Order, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("CancelOrder", params).Return(cancelled, nil).Once()
+				repo.On("ReleaseOrderPromotions", int32(12)).Return(nil).Once()
+				repo.On("ListOrderItems", sql.NullInt32{Int32: 12, Valid: true}).Return([]repository.ListOrderItemsRow{{
+					OrderID:   sql.NullInt32{Int32: 12, Valid: true},
+					ProductID: sql.NullInt32{Int32: 1, Valid: true},
+					Name:      sql.NullString{String: "shirt", Valid: true},
+					Quantity:  sql.NullInt32{Int32: 2, Valid: true},
+					Price:     sql.NullString{String: "10.00", Valid: true},
+					Discount:  "0",
+				}}, nil).Once()
+				repo.On("InsertOutboxEvent", messaging.TopicOrderCancelled, "12").Return(nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "cancelled", actual.Status)
+				require.Len(t, actual.Price.Lines, 1)
+				require.Equal(t, int64(2000), actual.Price.Total.MinorUnits)
+			},
+		},
+		"failing release": {
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("CancelOrder", params).Return(cancelled, nil).Once()
+				repo.On("ReleaseOrderPromotions", int32(12)).Return(errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+		"not pending": {
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("CancelOrder", params).Return(repository.Order{}, sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.ErrorIs(t, err, interactor.ErrOrderNotCancellable)
+				require.Nil(t, actual)
+			},
+		},
+		"unknown customer": {
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(0), sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.Order, err error) {
+				require.ErrorIs(t, err, interactor.ErrCustomerNotFound)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := productInteractor.CancelOrder(context.Background(), req)
+
+			v.assert(t, actual, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
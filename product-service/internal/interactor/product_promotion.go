@@ -0,0 +1,415 @@
+package interactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/money"
+	"github.com/ryanpujo/product-service/internal/promotion"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrInvalidPromotion   = errors.New("invalid promotion")
+	ErrDuplicateCoupon    = errors.New("coupon code already exists")
+	ErrCouponNotFound     = errors.New("coupon not found")
+	ErrCouponExpired      = errors.New("coupon is not valid at this time")
+	ErrPromotionExhausted = errors.New("promotion usage limit reached")
+)
+
+var promotionKinds = map[product.PromotionKind]promotion.Kind{
+	product.PromotionKind_PERCENTAGE: promotion.Percentage,
+	product.PromotionKind_FIXED:      promotion.Fixed,
+}
+
+// pricedCart is a priced cart together with what placing it as an order needs.
+type pricedCart struct {
+	price  *product.CartPrice
+	result promotion.Result
+	coupon string
+	// storeID is the store all items come from, or zero for a mixed cart.
+	storeID int32
+}
+
+// CreatePromotion lets the owner of a store run a promotion on its products.
+func (in *productInteractor) CreatePromotion(ctx context.Context, payload *product.PromotionPayload) (*product.Promotion, error) {
+	if err := in.checkStoreOwner(ctx, payload.StoreId, payload.OwnerEmail); err != nil {
+		return nil, err
+	}
+	params, err := toCreatePromotionParams(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var created repository.Promotion
+	err = in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		if params.CouponCode.Valid {
+			if _, err := q.GetPromotionByCoupon(ctx, params.CouponCode.String); err == nil {
+				return ErrDuplicateCoupon
+			} else if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+		var err error
+		created, err = q.CreatePromotion(ctx, params)
+		// another create may take the code between the check and the insert
+		if repository.IsUniqueViolation(err) {
+			return ErrDuplicateCoupon
+		}
+		if err != nil {
+			return err
+		}
+		return in.audit(ctx, q, AuditPromotionCreate, "promotion", int64(created.ID), map[string]interface{}{
+			"storeId":    payload.StoreId,
+			"name":       payload.Name,
+			"couponCode": params.CouponCode.String,
+			"percentOff": payload.PercentOff,
+			"amountOff":  auditedMoney(payload.AmountOff),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toPromotion(created)
+}
+
+func (in *productInteractor) ListPromotions(ctx context.Context, req *product.StorePromotionsRequest) (*product.Promotions, error) {
+	if err := in.checkStoreOwner(ctx, req.StoreId, req.OwnerEmail); err != nil {
+		return nil, err
+	}
+	promotions, err := in.Repo.ListStorePromotions(ctx, sql.NullInt32{Int32: int32(req.StoreId), Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	result := product.Promotions{Promotions: make([]*product.Promotion, 0, len(promotions))}
+	for _, p := range promotions {
+		promo, err := toPromotion(p)
+		if err != nil {
+			return nil, err
+		}
+		result.Promotions = append(result.Promotions, promo)
+	}
+	return &result, nil
+}
+
+// PriceCart prices the items at their current price with the promotions that
+// apply now. Nothing is reserved, the order may still come out different.
+func (in *productInteractor) PriceCart(ctx context.Context, req *product.PriceCartRequest) (*product.CartPrice, error) {
+	cart, err := priceCart(ctx, in.Repo, req.Items, req.CouponCode)
+	if err != nil {
+		return nil, err
+	}
+	return cart.price, nil
+}
+
+func priceCart(ctx context.Context, q repository.Querier, items []*product.CartItem, couponCode string) (*pricedCart, error) {
+	if len(items) == 0 {
+		return nil, promotion.ErrEmptyCart
+	}
+	var productIDs, variantIDs []int32
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, promotion.ErrInvalidQuantity
+		}
+		productIDs = append(productIDs, int32(item.ProductId))
+		if item.VariantId != 0 {
+			variantIDs = append(variantIDs, int32(item.VariantId))
+		}
+	}
+	products, err := q.ListProductsForPricing(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int32]repository.ListProductsForPricingRow, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	variants := make(map[int32]repository.ListVariantsForPricingRow)
+	if len(variantIDs) > 0 {
+		rows, err := q.ListVariantsForPricing(ctx, variantIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range rows {
+			variants[v.ID] = v
+		}
+	}
+
+	lines := make([]promotion.Line, 0, len(items))
+	names := make([]string, 0, len(items))
+	stores := make(map[int32]bool)
+	for _, item := range items {
+		p, ok := byID[int32(item.ProductId)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrProductNotFound, item.ProductId)
+		}
+		amount, currency := p.Price, p.Currency
+		if item.VariantId != 0 {
+			v, ok := variants[int32(item.VariantId)]
+			if !ok || v.ProductID != p.ID {
+				return nil, fmt.Errorf("%w: %d", ErrVariantNotFound, item.VariantId)
+			}
+			amount, currency = sql.NullString{String: v.Price, Valid: true}, v.Currency
+		}
+		if !amount.Valid {
+			return nil, fmt.Errorf("%w: product %d has no price", ErrInvalidPrice, p.ID)
+		}
+		price, err := money.Parse(amount.String, currency)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, promotion.Line{
+			ProductID:  p.ID,
+			VariantID:  int32(item.VariantId),
+			StoreID:    p.StoreID.Int32,
+			CategoryID: p.CategoryID.Int32,
+			Quantity:   int64(item.Quantity),
+			UnitPrice:  price,
+		})
+		names = append(names, p.Name.String)
+		stores[p.StoreID.Int32] = true
+	}
+
+	storeIDs := make([]int32, 0, len(stores))
+	for id := range stores {
+		storeIDs = append(storeIDs, id)
+	}
+	sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i] < storeIDs[j] })
+	promotions, err := q.ListAutomaticPromotions(ctx, storeIDs)
+	if err != nil {
+		return nil, err
+	}
+	automatic := make([]promotion.Rule, 0, len(promotions))
+	for _, p := range promotions {
+		rule, err := toRule(p)
+		if err != nil {
+			return nil, err
+		}
+		automatic = append(automatic, rule)
+	}
+	cart := pricedCart{}
+	var coupon *promotion.Rule
+	if code := strings.TrimSpace(couponCode); code != "" {
+		rule, err := couponRule(ctx, q, code)
+		if err != nil {
+			return nil, err
+		}
+		coupon = &rule
+		cart.coupon = strings.ToUpper(code)
+	}
+
+	cart.result, err = promotion.Apply(lines, automatic, coupon)
+	if err != nil {
+		return nil, err
+	}
+	if len(storeIDs) == 1 {
+		cart.storeID = storeIDs[0]
+	}
+	cart.price = toCartPrice(cart.result, names, cart.coupon, coupon)
+	return &cart, nil
+}
+
+// couponRule looks up a coupon and checks that it can be used right now.
+func couponRule(ctx context.Context, q repository.Querier, code string) (promotion.Rule, error) {
+	p, err := q.GetPromotionByCoupon(ctx, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return promotion.Rule{}, ErrCouponNotFound
+	}
+	if err != nil {
+		return promotion.Rule{}, err
+	}
+	now := time.Now()
+	if !p.Active || now.Before(p.StartsAt) || (p.ExpiresAt.Valid && !now.Before(p.ExpiresAt.Time)) {
+		return promotion.Rule{}, ErrCouponExpired
+	}
+	if p.UsageLimit.Valid && p.UsageCount >= p.UsageLimit.Int32 {
+		return promotion.Rule{}, ErrPromotionExhausted
+	}
+	return toRule(p)
+}
+
+func toCreatePromotionParams(payload *product.PromotionPayload) (repository.CreatePromotionParams, error) {
+	params := repository.CreatePromotionParams{
+		Name:       strings.TrimSpace(payload.Name),
+		StoreID:    sql.NullInt32{Int32: int32(payload.StoreId), Valid: true},
+		CategoryID: sql.NullInt32{Int32: int32(payload.CategoryId), Valid: payload.CategoryId != 0},
+		UsageLimit: sql.NullInt32{Int32: payload.UsageLimit, Valid: payload.UsageLimit != 0},
+	}
+	if params.Name == "" {
+		return params, fmt.Errorf("%w: name is required", ErrInvalidPromotion)
+	}
+	kind, ok := promotionKinds[payload.Kind]
+	if !ok {
+		return params, fmt.Errorf("%w: kind is required", ErrInvalidPromotion)
+	}
+	params.Kind = string(kind)
+	switch kind {
+	case promotion.Percentage:
+		if payload.AmountOff != nil {
+			return params, fmt.Errorf("%w: a percentage promotion has no amount off", ErrInvalidPromotion)
+		}
+		bp, err := toBasisPoints(payload.PercentOff)
+		if err != nil || bp <= 0 || bp > promotion.BasisPoints {
+			return params, fmt.Errorf("%w: percent off must be a number above 0 and up to 100 with at most 2 decimals", ErrInvalidPromotion)
+		}
+		params.PercentOff = sql.NullString{String: big.NewRat(bp, 100).FloatString(2), Valid: true}
+	case promotion.Fixed:
+		if payload.PercentOff != "" {
+			return params, fmt.Errorf("%w: a fixed promotion has no percent off", ErrInvalidPromotion)
+		}
+		amount, err := toPriceColumn(payload.AmountOff)
+		if err != nil {
+			return params, err
+		}
+		if payload.AmountOff.MinorUnits == 0 {
+			return params, fmt.Errorf("%w: amount off must be above 0", ErrInvalidPromotion)
+		}
+		params.AmountOff = sql.NullString{String: amount, Valid: true}
+		params.Currency = sql.NullString{String: payload.AmountOff.Currency, Valid: true}
+	}
+	if payload.UsageLimit < 0 {
+		return params, fmt.Errorf("%w: usage limit must not be negative", ErrInvalidPromotion)
+	}
+	if code := strings.TrimSpace(payload.CouponCode); code != "" {
+		params.CouponCode = sql.NullString{String: strings.ToUpper(code), Valid: true}
+	}
+
+	startsAt := time.Now()
+	if payload.StartsAt != nil {
+		startsAt = payload.StartsAt.AsTime()
+		params.StartsAt = sql.NullTime{Time: startsAt, Valid: true}
+	}
+	if payload.ExpiresAt != nil {
+		expiresAt := payload.ExpiresAt.AsTime()
+		if !expiresAt.After(startsAt) {
+			return params, fmt.Errorf("%w: a promotion must expire after it starts", ErrInvalidPromotion)
+		}
+		params.ExpiresAt = sql.NullTime{Time: expiresAt, Valid: true}
+	}
+	return params, nil
+}
+
+// toBasisPoints reads a decimal percentage such as "12.5" as 1250.
+func toBasisPoints(percent string) (int64, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(percent))
+	if !ok || strings.ContainsAny(percent, "eE/") {
+		return 0, fmt.Errorf("invalid percentage %q", percent)
+	}
+	r.Mul(r, big.NewRat(100, 1))
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return 0, fmt.Errorf("invalid percentage %q", percent)
+	}
+	return r.Num().Int64(), nil
+}
+
+func toRule(p repository.Promotion) (promotion.Rule, error) {
+	rule := promotion.Rule{
+		ID:         p.ID,
+		Name:       p.Name,
+		Kind:       promotion.Kind(p.Kind),
+		StoreID:    p.StoreID.Int32,
+		CategoryID: p.CategoryID.Int32,
+	}
+	switch rule.Kind {
+	case promotion.Percentage:
+		bp, err := toBasisPoints(p.PercentOff.String)
+		if err != nil {
+			return rule, err
+		}
+		rule.PercentOff = bp
+	case promotion.Fixed:
+		amount, err := money.Parse(p.AmountOff.String, p.Currency.String)
+		if err != nil {
+			return rule, err
+		}
+		rule.AmountOff = amount
+	}
+	return rule, nil
+}
+
+func toPromotion(p repository.Promotion) (*product.Promotion, error) {
+	result := product.Promotion{
+		Id:         int64(p.ID),
+		Name:       p.Name,
+		StoreId:    int64(p.StoreID.Int32),
+		CategoryId: int64(p.CategoryID.Int32),
+		CouponCode: p.CouponCode.String,
+		UsageLimit: p.UsageLimit.Int32,
+		UsageCount: p.UsageCount,
+		StartsAt:   timestamppb.New(p.StartsAt),
+		Active:     p.Active,
+		CreatedAt:  timestamppb.New(p.CreatedAt),
+	}
+	for kind, name := range promotionKinds {
+		if string(name) == p.Kind {
+			result.Kind = kind
+		}
+	}
+	if p.PercentOff.Valid {
+		bp, err := toBasisPoints(p.PercentOff.String)
+		if err != nil {
+			return nil, err
+		}
+		// "12.50" is shown as "12.5"
+		result.PercentOff = strings.TrimSuffix(strings.TrimRight(big.NewRat(bp, 100).FloatString(2), "0"), ".")
+	}
+	amount, err := toMoney(p.AmountOff, p.Currency.String)
+	if err != nil {
+		return nil, err
+	}
+	result.AmountOff = amount
+	if p.ExpiresAt.Valid {
+		result.ExpiresAt = timestamppb.New(p.ExpiresAt.Time)
+	}
+	return &result, nil
+}
+
+func toCartPrice(r promotion.Result, names []string, couponCode string, coupon *promotion.Rule) *product.CartPrice {
+	price := product.CartPrice{
+		Lines:      make([]*product.CartLine, 0, len(r.Lines)),
+		Subtotal:   toProtoMoney(r.Subtotal),
+		Discount:   toProtoMoney(r.Discount),
+		Total:      toProtoMoney(r.Total),
+		Promotions: make([]*product.AppliedPromotion, 0, len(r.Applied)),
+	}
+	for i, l := range r.Lines {
+		line := product.CartLine{
+			ProductId: int64(l.ProductID),
+			VariantId: int64(l.VariantID),
+			Name:      names[i],
+			Quantity:  int32(l.Quantity),
+			UnitPrice: toProtoMoney(l.UnitPrice),
+			Subtotal:  toProtoMoney(l.Subtotal),
+			Discount:  toProtoMoney(l.Discount),
+			Total:     toProtoMoney(l.Total),
+		}
+		for _, id := range l.PromotionIDs {
+			line.PromotionIds = append(line.PromotionIds, int64(id))
+		}
+		price.Lines = append(price.Lines, &line)
+	}
+	for _, a := range r.Applied {
+		applied := product.AppliedPromotion{
+			PromotionId: int64(a.Rule.ID),
+			Name:        a.Rule.Name,
+			Discount:    toProtoMoney(a.Discount),
+		}
+		if coupon != nil && a.Rule.ID == coupon.ID {
+			applied.CouponCode = couponCode
+		}
+		price.Promotions = append(price.Promotions, &applied)
+	}
+	return &price
+}
+
+func toProtoMoney(m money.Money) *product.Money {
+	return &product.Money{MinorUnits: m.Minor, Currency: m.Currency}
+}
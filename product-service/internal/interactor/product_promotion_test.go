@@ -0,0 +1,363 @@
+package interactor_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/promotion"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	promotionOwner = repository.IsStoreOwnerParams{StoreID: 3, Email: "owner@mail.com"}
+	pricingRows    = []repository.ListProductsForPricingRow{
+		{
+			ID:         1,
+			Name:       sql.NullString{String: "Mug", Valid: true},
+			StoreID:    sql.NullInt32{Int32: 3, Valid: true},
+			CategoryID: sql.NullInt32{Int32: 10, Valid: true},
+			Price:      sql.NullString{String: "10.00", Valid: true},
+			Currency:   "USD",
+		},
+		{
+			ID:         2,
+			Name:       sql.NullString{String: "Tee", Valid: true},
+			StoreID:    sql.NullInt32{Int32: 3, Valid: true},
+			CategoryID: sql.NullInt32{Int32: 20, Valid: true},
+			Price:      sql.NullString{String: "30.00", Valid: true},
+			Currency:   "USD",
+		},
+	}
+	shirtSale = repository.Promotion{
+		ID:         7,
+		Name:       "Shirt sale",
+		Kind:       "percentage",
+		PercentOff: sql.NullString{String: "25.00", Valid: true},
+		StoreID:    sql.NullInt32{Int32: 3, Valid: true},
+		CategoryID: sql.NullInt32{Int32: 20, Valid: true},
+		StartsAt:   time.Now().Add(-time.Hour),
+		Active:     true,
+	}
+	fiveOff = repository.Promotion{
+		ID:         8,
+		Name:       "Five off",
+		Kind:       "fixed",
+		AmountOff:  sql.NullString{String: "5.00", Valid: true},
+		Currency:   sql.NullString{String: "USD", Valid: true},
+		StoreID:    sql.NullInt32{Int32: 3, Valid: true},
+		CouponCode: sql.NullString{String: "FIVE", Valid: true},
+		UsageLimit: sql.NullInt32{Int32: 10, Valid: true},
+		StartsAt:   time.Now().Add(-time.Hour),
+		Active:     true,
+	}
+)
+
+func TestCreatePromotion(t *testing.T) {
+	percentage := &product.PromotionPayload{
+		Name:       " Shirt sale ",
+		Kind:       product.PromotionKind_PERCENTAGE,
+		PercentOff: "12.5",
+		StoreId:    3,
+		CategoryId: 20,
+		OwnerEmail: "owner@mail.com",
+	}
+	coupon := &product.PromotionPayload{
+		Name:       "Five off",
+		Kind:       product.PromotionKind_FIXED,
+		AmountOff:  &product.Money{MinorUnits: 500, Currency: "USD"},
+		StoreId:    3,
+		CouponCode: "five",
+		UsageLimit: 10,
+		OwnerEmail: "owner@mail.com",
+	}
+
+	testTable := map[string]struct {
+		payload *product.PromotionPayload
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Promotion, err error)
+	}{
+		"succes call": {
+			payload: percentage,
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Once()
+				repo.On("CreatePromotion", repository.CreatePromotionParams{
+					Name:       "Shirt sale",
+					Kind:       "percentage",
+					PercentOff: sql.NullString{String: "12.50", Valid: true},
+					StoreID:    sql.NullInt32{Int32: 3, Valid: true},
+					CategoryID: sql.NullInt32{Int32: 20, Valid: true},
+				}).Return(repository.Promotion{
+					ID:         1,
+					Name:       "Shirt sale",
+					Kind:       "percentage",
+					PercentOff: sql.NullString{String: "12.50", Valid: true},
+					StoreID:    sql.NullInt32{Int32: 3, Valid: true},
+					CategoryID: sql.NullInt32{Int32: 20, Valid: true},
+					Active:     true,
+				}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotion, err error) {
+				require.NoError(t, err)
+				require.Equal(t, product.PromotionKind_PERCENTAGE, actual.Kind)
+				require.Equal(t, "12.5", actual.PercentOff)
+				require.Nil(t, actual.AmountOff)
+			},
+		},
+		"coupon": {
+			payload: coupon,
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Once()
+				repo.On("GetPromotionByCoupon", "FIVE").Return(repository.Promotion{}, sql.ErrNoRows).Once()
+				repo.On("CreatePromotion", mock.MatchedBy(func(arg repository.CreatePromotionParams) bool {
+					return arg.CouponCode.String == "FIVE" && arg.AmountOff.String == "5.00" && arg.UsageLimit.Int32 == 10
+				})).Return(fiveOff, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotion, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "FIVE", actual.CouponCode)
+				require.Equal(t, int64(500), actual.AmountOff.MinorUnits)
+			},
+		},
+		"duplicate coupon": {
+			payload: coupon,
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Once()
+				repo.On("GetPromotionByCoupon", "FIVE").Return(fiveOff, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotion, err error) {
+				require.ErrorIs(t, err, interactor.ErrDuplicateCoupon)
+			},
+		},
+		"coupon taken meanwhile": {
+			payload: coupon,
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Once()
+				repo.On("GetPromotionByCoupon", "FIVE").Return(repository.Promotion{}, sql.ErrNoRows).Once()
+				repo.On("CreatePromotion", mock.Anything).Return(repository.Promotion{}, &pgconn.PgError{Code: "23505"}).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotion, err error) {
+				require.ErrorIs(t, err, interactor.ErrDuplicateCoupon)
+			},
+		},
+		"percent over 100": {
+			payload: &product.PromotionPayload{Name: "Too much", Kind: product.PromotionKind_PERCENTAGE, PercentOff: "120", StoreId: 3, OwnerEmail: "owner@mail.com"},
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotion, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidPromotion)
+			},
+		},
+		"expires before it starts": {
+			payload: &product.PromotionPayload{
+				Name:       "Backwards",
+				Kind:       product.PromotionKind_PERCENTAGE,
+				PercentOff: "10",
+				StoreId:    3,
+				OwnerEmail: "owner@mail.com",
+				StartsAt:   timestamppb.New(time.Now().Add(time.Hour)),
+				ExpiresAt:  timestamppb.Now(),
+			},
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotion, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidPromotion)
+			},
+		},
+		"not the owner": {
+			payload: percentage,
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(false, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotion, err error) {
+				require.ErrorIs(t, err, interactor.ErrNotStoreOwner)
+			},
+		},
+		"fail call": {
+			payload: percentage,
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Once()
+				repo.On("CreatePromotion", mock.Anything).Return(repository.Promotion{}, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotion, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := productInteractor.CreatePromotion(context.Background(), v.payload)
+
+			v.assert(t, actual, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestListPromotions(t *testing.T) {
+	req := &product.StorePromotionsRequest{StoreId: 3, OwnerEmail: "owner@mail.com"}
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Promotions, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Once()
+				repo.On("ListStorePromotions", sql.NullInt32{Int32: 3, Valid: true}).Return([]repository.Promotion{shirtSale, fiveOff}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotions, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Promotions, 2)
+				require.Equal(t, "25", actual.Promotions[0].PercentOff)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				repo.On("IsStoreOwner", promotionOwner).Return(true, nil).Once()
+				repo.On("ListStorePromotions", mock.Anything).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.Promotions, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := productInteractor.ListPromotions(context.Background(), req)
+
+			v.assert(t, actual, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestPriceCart(t *testing.T) {
+	items := []*product.CartItem{{ProductId: 1, Quantity: 2}, {ProductId: 2, Quantity: 1}}
+	expired := fiveOff
+	expired.ExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+	usedUp := fiveOff
+	usedUp.UsageCount = 10
+
+	testTable := map[string]struct {
+		req     *product.PriceCartRequest
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.CartPrice, err error)
+	}{
+		"succes call": {
+			req: &product.PriceCartRequest{Items: items, CouponCode: " five "},
+			arrange: func(t *testing.T) {
+				repo.On("ListProductsForPricing", []int32{1, 2}).Return(pricingRows, nil).Once()
+				repo.On("ListAutomaticPromotions", []int32{3}).Return([]repository.Promotion{shirtSale}, nil).Once()
+				repo.On("GetPromotionByCoupon", "five").Return(fiveOff, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.CartPrice, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(5000), actual.Subtotal.MinorUnits)
+				// 7.50 off the tee and 5.00 split over what is left
+				require.Equal(t, int64(1250), actual.Discount.MinorUnits)
+				require.Equal(t, int64(3750), actual.Total.MinorUnits)
+				require.Len(t, actual.Promotions, 2)
+				require.Equal(t, "FIVE", actual.Promotions[1].CouponCode)
+				require.Equal(t, "Mug", actual.Lines[0].Name)
+				require.Equal(t, []int64{7, 8}, actual.Lines[1].PromotionIds)
+			},
+		},
+		"variant price": {
+			req: &product.PriceCartRequest{Items: []*product.CartItem{{ProductId: 2, VariantId: 4, Quantity: 1}}},
+			arrange: func(t *testing.T) {
+				repo.On("ListProductsForPricing", []int32{2}).Return(pricingRows[1:], nil).Once()
+				repo.On("ListVariantsForPricing", []int32{4}).Return([]repository.ListVariantsForPricingRow{{ID: 4, ProductID: 2, Price: "32.00", Currency: "USD"}}, nil).Once()
+				repo.On("ListAutomaticPromotions", []int32{3}).Return([]repository.Promotion{}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.CartPrice, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(3200), actual.Total.MinorUnits)
+			},
+		},
+		"unknown product": {
+			req: &product.PriceCartRequest{Items: []*product.CartItem{{ProductId: 9, Quantity: 1}}},
+			arrange: func(t *testing.T) {
+				repo.On("ListProductsForPricing", []int32{9}).Return([]repository.ListProductsForPricingRow{}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.CartPrice, err error) {
+				require.ErrorIs(t, err, interactor.ErrProductNotFound)
+			},
+		},
+		"unknown coupon": {
+			req: &product.PriceCartRequest{Items: items, CouponCode: "NOPE"},
+			arrange: func(t *testing.T) {
+				repo.On("ListProductsForPricing", []int32{1, 2}).Return(pricingRows, nil).Once()
+				repo.On("ListAutomaticPromotions", []int32{3}).Return([]repository.Promotion{}, nil).Once()
+				repo.On("GetPromotionByCoupon", "NOPE").Return(repository.Promotion{}, sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.CartPrice, err error) {
+				require.ErrorIs(t, err, interactor.ErrCouponNotFound)
+			},
+		},
+		"expired coupon": {
+			req: &product.PriceCartRequest{Items: items, CouponCode: "FIVE"},
+			arrange: func(t *testing.T) {
+				repo.On("ListProductsForPricing", []int32{1, 2}).Return(pricingRows, nil).Once()
+				repo.On("ListAutomaticPromotions", []int32{3}).Return([]repository.Promotion{}, nil).Once()
+				repo.On("GetPromotionByCoupon", "FIVE").Return(expired, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.CartPrice, err error) {
+				require.ErrorIs(t, err, interactor.ErrCouponExpired)
+			},
+		},
+		"coupon used up": {
+			req: &product.PriceCartRequest{Items: items, CouponCode: "FIVE"},
+			arrange: func(t *testing.T) {
+				repo.On("ListProductsForPricing", []int32{1, 2}).Return(pricingRows, nil).Once()
+				repo.On("ListAutomaticPromotions", []int32{3}).Return([]repository.Promotion{}, nil).Once()
+				repo.On("GetPromotionByCoupon", "FIVE").Return(usedUp, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.CartPrice, err error) {
+				require.ErrorIs(t, err, interactor.ErrPromotionExhausted)
+			},
+		},
+		"empty cart": {
+			req:     &product.PriceCartRequest{},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.CartPrice, err error) {
+				require.ErrorIs(t, err, promotion.ErrEmptyCart)
+			},
+		},
+		"fail call": {
+			req: &product.PriceCartRequest{Items: items},
+			arrange: func(t *testing.T) {
+				repo.On("ListProductsForPricing", []int32{1, 2}).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.CartPrice, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := productInteractor.PriceCart(context.Background(), v.req)
+
+			v.assert(t, actual, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,219 @@
+package interactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrInvalidReview     = errors.New("invalid review")
+	ErrReviewExists      = errors.New("user already reviewed this product")
+	ErrReviewNotFound    = errors.New("review not found")
+	ErrReviewerNotFound  = errors.New("reviewer not found")
+	ErrInvalidModeration = errors.New("invalid moderation status")
+)
+
+const (
+	defaultReviewLimit = 20
+	maxReviewLength    = 5000
+)
+
+var reviewStatuses = map[product.ReviewStatus]string{
+	product.ReviewStatus_PENDING:  "pending",
+	product.ReviewStatus_APPROVED: "approved",
+	product.ReviewStatus_REJECTED: "rejected",
+}
+
+// CreateReview stores a pending review. A user reviews a product at most once,
+// and the review is flagged as a verified purchase when the user has ordered
+// the product.
+func (in *productInteractor) CreateReview(ctx context.Context, payload *product.ReviewPayload) (*product.Review, error) {
+	body, err := validateReview(payload.Rating, payload.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var created repository.Review
+	err = in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		userID, err := reviewer(ctx, q, payload.UserEmail)
+		if err != nil {
+			return err
+		}
+		productID := int32(payload.ProductId)
+		if _, err = q.GetProduct(ctx, productID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+		verified, err := q.HasPurchased(ctx, repository.HasPurchasedParams{UserID: userID, ProductID: productID})
+		if err != nil {
+			return err
+		}
+		created, err = q.CreateReview(ctx, repository.CreateReviewParams{
+			ProductID:        productID,
+			UserID:           userID,
+			Rating:           int16(payload.Rating),
+			Body:             body,
+			VerifiedPurchase: verified,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReviewExists
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toReview(created), nil
+}
+
+// UpdateReview lets the author change their review, which sends it back to
+// moderation and takes it out of the product rating until approved again.
+func (in *productInteractor) UpdateReview(ctx context.Context, update *product.ReviewUpdate) (*product.Review, error) {
+	body, err := validateReview(update.Rating, update.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated repository.Review
+	err = in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		userID, err := reviewer(ctx, q, update.UserEmail)
+		if err != nil {
+			return err
+		}
+		// someone else's review looks the same as a missing one
+		updated, err = q.UpdateReview(ctx, repository.UpdateReviewParams{
+			ID:     int32(update.Id),
+			UserID: userID,
+			Rating: int16(update.Rating),
+			Body:   body,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReviewNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return q.RefreshProductRating(ctx, updated.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toReview(updated), nil
+}
+
+func (in *productInteractor) ModerateReview(ctx context.Context, moderation *product.ReviewModeration) (*product.Review, error) {
+	status, ok := reviewStatuses[moderation.Status]
+	if !ok {
+		return nil, ErrInvalidModeration
+	}
+
+	var moderated repository.Review
+	err := in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		var err error
+		moderated, err = q.SetReviewStatus(ctx, repository.SetReviewStatusParams{ID: int32(moderation.Id), Status: status})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReviewNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err = q.RefreshProductRating(ctx, moderated.ProductID); err != nil {
+			return err
+		}
+		return in.audit(ctx, q, AuditReviewModerate, "review", moderation.Id, map[string]interface{}{"status": status})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toReview(moderated), nil
+}
+
+// ListReviews returns the approved reviews of a product, newest first.
+func (in *productInteractor) ListReviews(ctx context.Context, req *product.ReviewsRequest) (*product.Reviews, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultReviewLimit
+	}
+	reviews, err := in.Repo.ListReviews(ctx, repository.ListReviewsParams{
+		ProductID: int32(req.ProductId),
+		Limit:     limit,
+		Offset:    req.Offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := product.Reviews{Reviews: make([]*product.Review, 0, len(reviews))}
+	for _, r := range reviews {
+		review := toReview(repository.Review{
+			ID:               r.ID,
+			ProductID:        r.ProductID,
+			UserID:           r.UserID,
+			Rating:           r.Rating,
+			Body:             r.Body,
+			VerifiedPurchase: r.VerifiedPurchase,
+			Status:           r.Status,
+			CreatedAt:        r.CreatedAt,
+			UpdatedAt:        r.UpdatedAt,
+		})
+		review.Username = r.Username
+		result.Reviews = append(result.Reviews, review)
+	}
+	return &result, nil
+}
+
+func validateReview(rating int32, body string) (string, error) {
+	if rating < 1 || rating > 5 {
+		return "", fmt.Errorf("%w: rating must be between 1 and 5", ErrInvalidReview)
+	}
+	body = strings.TrimSpace(body)
+	if len([]rune(body)) > maxReviewLength {
+		return "", fmt.Errorf("%w: review must not exceed %d characters", ErrInvalidReview, maxReviewLength)
+	}
+	return body, nil
+}
+
+func reviewer(ctx context.Context, q repository.Querier, email string) (int32, error) {
+	if email == "" {
+		return 0, ErrReviewerNotFound
+	}
+	id, err := q.GetUserIDByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrReviewerNotFound
+	}
+	return id, err
+}
+
+func toRating(average string) float64 {
+	rating, _ := strconv.ParseFloat(average, 64)
+	return rating
+}
+
+func toReview(r repository.Review) *product.Review {
+	review := product.Review{
+		Id:               int64(r.ID),
+		ProductId:        int64(r.ProductID),
+		UserId:           int64(r.UserID),
+		Rating:           int32(r.Rating),
+		Body:             r.Body,
+		VerifiedPurchase: r.VerifiedPurchase,
+		CreatedAt:        timestamppb.New(r.CreatedAt),
+		UpdatedAt:        timestamppb.New(r.UpdatedAt),
+	}
+	for status, name := range reviewStatuses {
+		if name == r.Status {
+			review.Status = status
+		}
+	}
+	return &review
+}
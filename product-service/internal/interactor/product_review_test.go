@@ -0,0 +1,233 @@
+package interactor_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateReview(t *testing.T) {
+	payload := &product.ReviewPayload{ProductId: 1, UserEmail: "jane@example.com", Rating: 4, Body: "  fits well  "}
+	testTable := map[string]struct {
+		payload *product.ReviewPayload
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Review, err error)
+	}{
+		"verified purchase": {
+			payload: payload,
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@example.com").Return(int32(3), nil).Once()
+				repo.On("GetProduct", int32(1)).Return(repository.Product{ID: 1}, nil).Once()
+				repo.On("HasPurchased", repository.HasPurchasedParams{UserID: 3, ProductID: 1}).Return(true, nil).Once()
+				repo.On("CreateReview", repository.CreateReviewParams{
+					ProductID:        1,
+					UserID:           3,
+					Rating:           4,
+					Body:             "fits well",
+					VerifiedPurchase: true,
+				}).Return(repository.Review{ID: 9, ProductID: 1, UserID: 3, Rating: 4, VerifiedPurchase: true, Status: "pending"}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.NoError(t, err)
+				require.True(t, actual.VerifiedPurchase)
+				require.Equal(t, product.ReviewStatus_PENDING, actual.Status)
+			},
+		},
+		"second review": {
+			payload: payload,
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@example.com").Return(int32(3), nil).Once()
+				repo.On("GetProduct", int32(1)).Return(repository.Product{ID: 1}, nil).Once()
+				repo.On("HasPurchased", mock.Anything).Return(false, nil).Once()
+				repo.On("CreateReview", mock.Anything).Return(repository.Review{}, sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.ErrorIs(t, err, interactor.ErrReviewExists)
+			},
+		},
+		"unknown reviewer": {
+			payload: payload,
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@example.com").Return(int32(0), sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.ErrorIs(t, err, interactor.ErrReviewerNotFound)
+			},
+		},
+		"unknown product": {
+			payload: payload,
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@example.com").Return(int32(3), nil).Once()
+				repo.On("GetProduct", int32(1)).Return(repository.Product{}, sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.ErrorIs(t, err, interactor.ErrProductNotFound)
+			},
+		},
+		"rating out of range": {
+			payload: &product.ReviewPayload{ProductId: 1, UserEmail: "jane@example.com", Rating: 6},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidReview)
+			},
+		},
+		"body too long": {
+			payload: &product.ReviewPayload{ProductId: 1, UserEmail: "jane@example.com", Rating: 3, Body: strings.Repeat("a", 5001)},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidReview)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := productInteractor.CreateReview(ctx, v.payload)
+
+			v.assert(t, result, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestUpdateReview(t *testing.T) {
+	update := &product.ReviewUpdate{Id: 9, UserEmail: "jane@example.com", Rating: 2, Body: "shrunk"}
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Review, err error)
+	}{
+		"refreshes rating": {
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@example.com").Return(int32(3), nil).Once()
+				repo.On("UpdateReview", repository.UpdateReviewParams{ID: 9, UserID: 3, Rating: 2, Body: "shrunk"}).
+					Return(repository.Review{ID: 9, ProductID: 1, Rating: 2, Status: "pending"}, nil).Once()
+				repo.On("RefreshProductRating", int32(1)).Return(nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(2), actual.Rating)
+			},
+		},
+		"not the author": {
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@example.com").Return(int32(4), nil).Once()
+				repo.On("UpdateReview", mock.Anything).Return(repository.Review{}, sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.ErrorIs(t, err, interactor.ErrReviewNotFound)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := productInteractor.UpdateReview(ctx, update)
+
+			v.assert(t, result, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestModerateReview(t *testing.T) {
+	testTable := map[string]struct {
+		moderation *product.ReviewModeration
+		arrange    func(t *testing.T)
+		assert     func(t *testing.T, actual *product.Review, err error)
+	}{
+		"approve": {
+			moderation: &product.ReviewModeration{Id: 9, Status: product.ReviewStatus_APPROVED},
+			arrange: func(t *testing.T) {
+				repo.On("SetReviewStatus", repository.SetReviewStatusParams{ID: 9, Status: "approved"}).
+					Return(repository.Review{ID: 9, ProductID: 1, Status: "approved"}, nil).Once()
+				repo.On("RefreshProductRating", int32(1)).Return(nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.NoError(t, err)
+				require.Equal(t, product.ReviewStatus_APPROVED, actual.Status)
+			},
+		},
+		"unknown review": {
+			moderation: &product.ReviewModeration{Id: 9, Status: product.ReviewStatus_REJECTED},
+			arrange: func(t *testing.T) {
+				repo.On("SetReviewStatus", mock.Anything).Return(repository.Review{}, sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.ErrorIs(t, err, interactor.ErrReviewNotFound)
+			},
+		},
+		"missing status": {
+			moderation: &product.ReviewModeration{Id: 9},
+			arrange:    func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.Review, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidModeration)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := productInteractor.ModerateReview(ctx, v.moderation)
+
+			v.assert(t, result, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestListReviews(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Reviews, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				repo.On("ListReviews", repository.ListReviewsParams{ProductID: 1, Limit: 20}).
+					Return([]repository.ListReviewsRow{{ID: 1, Rating: 5, Status: "approved", Username: "jane"}}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Reviews, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Reviews, 1)
+				require.Equal(t, "jane", actual.Reviews[0].Username)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				repo.On("ListReviews", mock.Anything).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.Reviews, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := productInteractor.ListReviews(ctx, &product.ReviewsRequest{ProductId: 1})
+
+			v.assert(t, result, err)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package interactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var ErrEmptyQuery = errors.New("search query must not be empty")
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	priceFacetBuckets  = 5
+)
+
+// searchFilter holds the filters shared by the search and facet queries.
+type searchFilter struct {
+	fuzzy      bool
+	query      string
+	categoryID sql.NullInt32
+	storeID    sql.NullInt32
+	currency   sql.NullString
+	minPrice   sql.NullString
+	maxPrice   sql.NullString
+}
+
+// SearchProducts runs a ranked full-text search. When the query matches nothing
+// it falls back to trigram similarity on the product name, so small typos still
+// return results. Facets are computed over the same set of matches.
+func (in *productInteractor) SearchProducts(ctx context.Context, req *product.SearchRequest) (*product.SearchResult, error) {
+	filter, err := toSearchFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	hits, err := in.Repo.SearchProducts(ctx, filter.searchParams(limit, req.Offset))
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 && req.Offset == 0 {
+		filter.fuzzy = true
+		hits, err = in.Repo.SearchProducts(ctx, filter.searchParams(limit, req.Offset))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := product.SearchResult{
+		Hits:  make([]*product.SearchHit, 0, len(hits)),
+		Fuzzy: filter.fuzzy,
+	}
+	for _, h := range hits {
+		p, err := toSearchProduct(h)
+		if err != nil {
+			return nil, err
+		}
+		result.Hits = append(result.Hits, &product.SearchHit{Product: p, Rank: h.Rank})
+	}
+	if len(hits) == 0 {
+		return &result, nil
+	}
+
+	if err = in.searchFacets(ctx, filter, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (in *productInteractor) searchFacets(ctx context.Context, filter searchFilter, result *product.SearchResult) error {
+	categories, err := in.Repo.SearchCategoryFacets(ctx, repository.SearchCategoryFacetsParams(filter.facetParams()))
+	if err != nil {
+		return err
+	}
+	for _, c := range categories {
+		result.Categories = append(result.Categories, &product.FacetCount{Id: int64(c.ID), Name: c.Name.String, Count: c.Count})
+	}
+
+	stores, err := in.Repo.SearchStoreFacets(ctx, repository.SearchStoreFacetsParams(filter.facetParams()))
+	if err != nil {
+		return err
+	}
+	for _, s := range stores {
+		result.Stores = append(result.Stores, &product.FacetCount{Id: int64(s.ID), Name: s.StoreName.String, Count: s.Count})
+	}
+
+	params := filter.facetParams()
+	prices, err := in.Repo.SearchPriceFacets(ctx, repository.SearchPriceFacetsParams{
+		Fuzzy:      params.Fuzzy,
+		Query:      params.Query,
+		CategoryID: params.CategoryID,
+		StoreID:    params.StoreID,
+		Currency:   params.Currency,
+		MinPrice:   params.MinPrice,
+		MaxPrice:   params.MaxPrice,
+		Buckets:    priceFacetBuckets,
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range prices {
+		min, err := toMoney(sql.NullString{String: p.MinPrice, Valid: true}, p.Currency)
+		if err != nil {
+			return err
+		}
+		max, err := toMoney(sql.NullString{String: p.MaxPrice, Valid: true}, p.Currency)
+		if err != nil {
+			return err
+		}
+		result.PriceRanges = append(result.PriceRanges, &product.PriceRangeFacet{Min: min, Max: max, Count: p.Count})
+	}
+	return nil
+}
+
+func toSearchFilter(req *product.SearchRequest) (searchFilter, error) {
+	filter := searchFilter{
+		query:      strings.TrimSpace(req.Query),
+		categoryID: sql.NullInt32{Int32: int32(req.CategoryId), Valid: req.CategoryId != 0},
+		storeID:    sql.NullInt32{Int32: int32(req.StoreId), Valid: req.StoreId != 0},
+	}
+	if filter.query == "" {
+		return filter, ErrEmptyQuery
+	}
+
+	for _, bound := range []struct {
+		price  *product.Money
+		target *sql.NullString
+	}{
+		{req.MinPrice, &filter.minPrice},
+		{req.MaxPrice, &filter.maxPrice},
+	} {
+		if bound.price == nil {
+			continue
+		}
+		if filter.currency.Valid && filter.currency.String != bound.price.Currency {
+			return filter, fmt.Errorf("%w: price range mixes %s and %s", ErrInvalidPrice, filter.currency.String, bound.price.Currency)
+		}
+		decimal, err := toPriceColumn(bound.price)
+		if err != nil {
+			return filter, err
+		}
+		*bound.target = sql.NullString{String: decimal, Valid: true}
+		filter.currency = sql.NullString{String: bound.price.Currency, Valid: true}
+	}
+	return filter, nil
+}
+
+func (f searchFilter) searchParams(limit, offset int32) repository.SearchProductsParams {
+	return repository.SearchProductsParams{
+		Fuzzy:      f.fuzzy,
+		Query:      f.query,
+		CategoryID: f.categoryID,
+		StoreID:    f.storeID,
+		Currency:   f.currency,
+		MinPrice:   f.minPrice,
+		MaxPrice:   f.maxPrice,
+		RowLimit:   limit,
+		RowOffset:  offset,
+	}
+}
+
+func (f searchFilter) facetParams() repository.SearchCategoryFacetsParams {
+	return repository.SearchCategoryFacetsParams{
+		Fuzzy:      f.fuzzy,
+		Query:      f.query,
+		CategoryID: f.categoryID,
+		StoreID:    f.storeID,
+		Currency:   f.currency,
+		MinPrice:   f.minPrice,
+		MaxPrice:   f.maxPrice,
+	}
+}
+
+func toSearchProduct(h repository.SearchProductsRow) (*product.Product, error) {
+	price, err := toMoney(h.Price, h.Currency)
+	if err != nil {
+		return nil, err
+	}
+	p := product.Product{
+		Id:            int64(h.ID),
+		Name:          h.Name.String,
+		Description:   h.Description.String,
+		Price:         price,
+		ImageUrl:      h.ImageUrl.String,
+		Stock:         h.Stock.Int32,
+		RatingAverage: toRating(h.RatingAverage),
+		RatingCount:   h.RatingCount,
+	}
+	if h.CreatedAt.Valid {
+		p.CreatedAt = timestamppb.New(h.CreatedAt.Time)
+	}
+	return &p, nil
+}
@@ -0,0 +1,153 @@
+package interactor_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func fuzzy(value bool) interface{} {
+	return mock.MatchedBy(func(arg repository.SearchProductsParams) bool {
+		return arg.Fuzzy == value
+	})
+}
+
+func TestSearchProducts(t *testing.T) {
+	hit := repository.SearchProductsRow{
+		ID:       1,
+		Name:     sql.NullString{String: "MacBook Pro", Valid: true},
+		Price:    sql.NullString{String: "2000.00", Valid: true},
+		Currency: "USD",
+		Rank:     0.6,
+	}
+	arrangeFacets := func() {
+		repo.On("SearchCategoryFacets", mock.Anything).Return([]repository.SearchCategoryFacetsRow{{ID: 2, Count: 1}}, nil).Once()
+		repo.On("SearchStoreFacets", mock.Anything).Return([]repository.SearchStoreFacetsRow{{ID: 3, Count: 1}}, nil).Once()
+		repo.On("SearchPriceFacets", mock.Anything).Return([]repository.SearchPriceFacetsRow{{Currency: "USD", Bucket: 1, MinPrice: "2000.00", MaxPrice: "2000.00", Count: 1}}, nil).Once()
+	}
+	testTable := map[string]struct {
+		req     *product.SearchRequest
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.SearchResult, err error)
+	}{
+		"full-text match": {
+			req: &product.SearchRequest{Query: "macbook"},
+			arrange: func(t *testing.T) {
+				repo.On("SearchProducts", fuzzy(false)).Return([]repository.SearchProductsRow{hit}, nil).Once()
+				arrangeFacets()
+			},
+			assert: func(t *testing.T, actual *product.SearchResult, err error) {
+				require.NoError(t, err)
+				require.False(t, actual.Fuzzy)
+				require.Len(t, actual.Hits, 1)
+				require.Equal(t, int64(200000), actual.Hits[0].Product.Price.MinorUnits)
+				require.Equal(t, int64(2), actual.Categories[0].Id)
+				require.Equal(t, int64(3), actual.Stores[0].Id)
+				require.Equal(t, int64(200000), actual.PriceRanges[0].Min.MinorUnits)
+			},
+		},
+		"falls back to trigram": {
+			req: &product.SearchRequest{Query: "mcbook"},
+			arrange: func(t *testing.T) {
+				repo.On("SearchProducts", fuzzy(false)).Return([]repository.SearchProductsRow{}, nil).Once()
+				repo.On("SearchProducts", fuzzy(true)).Return([]repository.SearchProductsRow{hit}, nil).Once()
+				arrangeFacets()
+			},
+			assert: func(t *testing.T, actual *product.SearchResult, err error) {
+				require.NoError(t, err)
+				require.True(t, actual.Fuzzy)
+				require.Len(t, actual.Hits, 1)
+			},
+		},
+		"nothing found": {
+			req: &product.SearchRequest{Query: "zzz"},
+			arrange: func(t *testing.T) {
+				repo.On("SearchProducts", fuzzy(false)).Return([]repository.SearchProductsRow{}, nil).Once()
+				repo.On("SearchProducts", fuzzy(true)).Return([]repository.SearchProductsRow{}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.SearchResult, err error) {
+				require.NoError(t, err)
+				require.Empty(t, actual.Hits)
+				require.Empty(t, actual.Categories)
+			},
+		},
+		"price filter": {
+			req: &product.SearchRequest{
+				Query:    "macbook",
+				MinPrice: &product.Money{MinorUnits: 100000, Currency: "USD"},
+				MaxPrice: &product.Money{MinorUnits: 250000, Currency: "USD"},
+			},
+			arrange: func(t *testing.T) {
+				repo.On("SearchProducts", mock.MatchedBy(func(arg repository.SearchProductsParams) bool {
+					return arg.MinPrice.String == "1000.00" && arg.MaxPrice.String == "2500.00" && arg.Currency.String == "USD"
+				})).Return([]repository.SearchProductsRow{hit}, nil).Once()
+				arrangeFacets()
+			},
+			assert: func(t *testing.T, actual *product.SearchResult, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Hits, 1)
+			},
+		},
+		"limit above the maximum": {
+			req: &product.SearchRequest{Query: "macbook", Limit: 500},
+			arrange: func(t *testing.T) {
+				repo.On("SearchProducts", mock.MatchedBy(func(arg repository.SearchProductsParams) bool {
+					return arg.RowLimit == 100
+				})).Return([]repository.SearchProductsRow{hit}, nil).Once()
+				arrangeFacets()
+			},
+			assert: func(t *testing.T, actual *product.SearchResult, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Hits, 1)
+			},
+		},
+		"mixed currencies": {
+			req: &product.SearchRequest{
+				Query:    "macbook",
+				MinPrice: &product.Money{MinorUnits: 100000, Currency: "USD"},
+				MaxPrice: &product.Money{MinorUnits: 250000, Currency: "EUR"},
+			},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.SearchResult, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidPrice)
+			},
+		},
+		"empty query": {
+			req:     &product.SearchRequest{Query: "  "},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.SearchResult, err error) {
+				require.ErrorIs(t, err, interactor.ErrEmptyQuery)
+			},
+		},
+		"fail call": {
+			req: &product.SearchRequest{Query: "macbook"},
+			arrange: func(t *testing.T) {
+				repo.On("SearchProducts", fuzzy(false)).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.SearchResult, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := productInteractor.SearchProducts(ctx, v.req)
+
+			v.assert(t, result, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
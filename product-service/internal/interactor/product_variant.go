@@ -0,0 +1,300 @@
+package interactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrVariantNotFound  = errors.New("variant not found")
+	ErrInvalidVariant   = errors.New("invalid variant")
+	ErrDuplicateSku     = errors.New("sku already exists")
+	ErrDuplicateVariant = errors.New("a variant with the same options already exists")
+)
+
+// CreateVariant adds a sellable variant to a product. Every variant of a
+// product picks one value for each of the same option types, and no two
+// variants may pick the same combination.
+func (in *productInteractor) CreateVariant(ctx context.Context, payload *product.VariantPayload) (*product.Variant, error) {
+	sku := strings.TrimSpace(payload.Sku)
+	if sku == "" {
+		return nil, fmt.Errorf("%w: sku is required", ErrInvalidVariant)
+	}
+	if payload.Stock < 0 {
+		return nil, fmt.Errorf("%w: stock must not be negative", ErrInvalidVariant)
+	}
+	options, err := normalizeOptions(payload.Options)
+	if err != nil {
+		return nil, err
+	}
+	price, err := toPriceColumn(payload.Price)
+	if err != nil {
+		return nil, err
+	}
+
+	var created repository.ProductVariant
+	err = in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		productID := int32(payload.ProductId)
+		if _, err := q.GetProduct(ctx, productID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+		if _, err := q.GetVariantBySku(ctx, sku); err == nil {
+			return ErrDuplicateSku
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err := checkCombination(ctx, q, productID, options); err != nil {
+			return err
+		}
+
+		valueIDs, err := upsertOptions(ctx, q, productID, options)
+		if err != nil {
+			return err
+		}
+		created, err = q.CreateVariant(ctx, repository.CreateVariantParams{
+			ProductID: productID,
+			Sku:       sku,
+			Price:     price,
+			Currency:  payload.Price.Currency,
+			Stock:     payload.Stock,
+			ImageUrl:  sql.NullString{String: payload.ImageUrl, Valid: payload.ImageUrl != ""},
+		})
+		if err != nil {
+			return err
+		}
+		for _, id := range valueIDs {
+			err = q.AddVariantOptionValue(ctx, repository.AddVariantOptionValueParams{VariantID: created.ID, OptionValueID: id})
+			if err != nil {
+				return err
+			}
+		}
+		if payload.Stock != 0 {
+			_, err = q.CreateStockMovement(ctx, repository.CreateStockMovementParams{
+				ProductID: productID,
+				VariantID: sql.NullInt32{Int32: created.ID, Valid: true},
+				Quantity:  payload.Stock,
+				Reason:    stockReasons[product.StockReason_RESTOCK],
+				Note:      sql.NullString{String: "initial stock", Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return in.audit(ctx, q, AuditVariantCreate, "variant", int64(created.ID), map[string]interface{}{
+			"productId": payload.ProductId,
+			"sku":       payload.Sku,
+			"price":     auditedMoney(payload.Price),
+			"stock":     payload.Stock,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toVariant(created, options)
+}
+
+// ListVariants returns the option types of a product together with all of
+// its variants.
+func (in *productInteractor) ListVariants(ctx context.Context, id *product.ProductId) (*product.Variants, error) {
+	productID := int32(id.Id)
+	types, err := in.Repo.ListOptionTypes(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	values, err := in.Repo.ListOptionValues(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	variants, err := in.Repo.ListVariants(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	options, err := in.Repo.ListVariantOptions(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := product.Variants{
+		OptionTypes: make([]*product.OptionType, 0, len(types)),
+		Variants:    make([]*product.Variant, 0, len(variants)),
+	}
+	byType := make(map[int32]*product.OptionType, len(types))
+	for _, t := range types {
+		optionType := &product.OptionType{Id: int64(t.ID), Name: t.Name}
+		byType[t.ID] = optionType
+		result.OptionTypes = append(result.OptionTypes, optionType)
+	}
+	for _, v := range values {
+		if t, ok := byType[v.OptionTypeID]; ok {
+			t.Values = append(t.Values, &product.OptionValue{Id: int64(v.ID), Value: v.Value})
+		}
+	}
+
+	byVariant := make(map[int32][]*product.VariantOption)
+	for _, o := range options {
+		byVariant[o.VariantID] = append(byVariant[o.VariantID], &product.VariantOption{Name: o.Name, Value: o.Value})
+	}
+	for _, v := range variants {
+		variant, err := toVariant(v, byVariant[v.ID])
+		if err != nil {
+			return nil, err
+		}
+		result.Variants = append(result.Variants, variant)
+	}
+	return &result, nil
+}
+
+// missingVariantStock is the variant counterpart of missingStock.
+func (in *productInteractor) missingVariantStock(ctx context.Context, q repository.Querier, productID, variantID int64) error {
+	variant, err := q.GetVariant(ctx, int32(variantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrVariantNotFound
+		}
+		return err
+	}
+	if int64(variant.ProductID) != productID {
+		return ErrVariantNotFound
+	}
+	return ErrInsufficientStock
+}
+
+// normalizeOptions trims the options and rejects blanks and option types that
+// are picked twice.
+func normalizeOptions(options []*product.VariantOption) ([]*product.VariantOption, error) {
+	result := make([]*product.VariantOption, 0, len(options))
+	seen := make(map[string]bool, len(options))
+	for _, o := range options {
+		name, value := strings.TrimSpace(o.GetName()), strings.TrimSpace(o.GetValue())
+		if name == "" || value == "" {
+			return nil, fmt.Errorf("%w: option name and value are required", ErrInvalidVariant)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: option %q is given twice", ErrInvalidVariant, name)
+		}
+		seen[name] = true
+		result = append(result, &product.VariantOption{Name: name, Value: value})
+	}
+	return result, nil
+}
+
+// checkCombination makes sure a new variant uses the same option types as the
+// existing variants of the product and does not repeat their combination.
+func checkCombination(ctx context.Context, q repository.Querier, productID int32, options []*product.VariantOption) error {
+	variants, err := q.ListVariants(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if len(variants) == 0 {
+		return nil
+	}
+	types, err := q.ListOptionTypes(ctx, productID)
+	if err != nil {
+		return err
+	}
+	expected := make([]string, 0, len(types))
+	for _, t := range types {
+		expected = append(expected, t.Name)
+	}
+	given := make([]string, 0, len(options))
+	for _, o := range options {
+		given = append(given, o.Name)
+	}
+	sort.Strings(expected)
+	sort.Strings(given)
+	if strings.Join(expected, ",") != strings.Join(given, ",") {
+		return fmt.Errorf("%w: options must be exactly [%s]", ErrInvalidVariant, strings.Join(expected, ", "))
+	}
+
+	existing, err := q.ListVariantOptions(ctx, productID)
+	if err != nil {
+		return err
+	}
+	combinations := make(map[int32][]*product.VariantOption, len(variants))
+	for _, o := range existing {
+		combinations[o.VariantID] = append(combinations[o.VariantID], &product.VariantOption{Name: o.Name, Value: o.Value})
+	}
+	key := combinationKey(options)
+	for _, v := range variants {
+		if combinationKey(combinations[v.ID]) == key {
+			return ErrDuplicateVariant
+		}
+	}
+	return nil
+}
+
+func combinationKey(options []*product.VariantOption) string {
+	pairs := make([]string, 0, len(options))
+	for _, o := range options {
+		pairs = append(pairs, o.Name+"="+o.Value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\x00")
+}
+
+// upsertOptions creates the option types and values that do not exist yet and
+// returns the ids of the picked values.
+func upsertOptions(ctx context.Context, q repository.Querier, productID int32, options []*product.VariantOption) ([]int32, error) {
+	types, err := q.ListOptionTypes(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	values, err := q.ListOptionValues(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	valueCount := make(map[int32]int32, len(types))
+	for _, v := range values {
+		valueCount[v.OptionTypeID]++
+	}
+
+	ids := make([]int32, 0, len(options))
+	for i, o := range options {
+		optionType, err := q.UpsertOptionType(ctx, repository.UpsertOptionTypeParams{
+			ProductID: productID,
+			Name:      o.Name,
+			Position:  int32(len(types) + i),
+		})
+		if err != nil {
+			return nil, err
+		}
+		value, err := q.UpsertOptionValue(ctx, repository.UpsertOptionValueParams{
+			OptionTypeID: optionType.ID,
+			Value:        o.Value,
+			Position:     valueCount[optionType.ID],
+		})
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, value.ID)
+	}
+	return ids, nil
+}
+
+func toVariant(v repository.ProductVariant, options []*product.VariantOption) (*product.Variant, error) {
+	price, err := toMoney(sql.NullString{String: v.Price, Valid: true}, v.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return &product.Variant{
+		Id:        int64(v.ID),
+		ProductId: int64(v.ProductID),
+		Sku:       v.Sku,
+		Price:     price,
+		Stock:     v.Stock,
+		ImageUrl:  v.ImageUrl.String,
+		Options:   options,
+		CreatedAt: timestamppb.New(v.CreatedAt),
+	}, nil
+}
@@ -0,0 +1,192 @@
+package interactor_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateVariant(t *testing.T) {
+	usd := &product.Money{MinorUnits: 1999, Currency: "USD"}
+	payload := func(options ...*product.VariantOption) *product.VariantPayload {
+		return &product.VariantPayload{ProductId: 1, Sku: " TEE-M-RED ", Price: usd, Stock: 4, Options: options}
+	}
+	medium := &product.VariantOption{Name: "size", Value: "M"}
+	red := &product.VariantOption{Name: "colour", Value: "red"}
+	existing := []repository.ProductVariant{{ID: 7, ProductID: 1}}
+	existingTypes := []repository.OptionType{{ID: 1, Name: "size"}, {ID: 2, Name: "colour"}}
+
+	testTable := map[string]struct {
+		payload *product.VariantPayload
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Variant, err error)
+	}{
+		"first variant creates its options": {
+			payload: payload(medium, red),
+			arrange: func(t *testing.T) {
+				repo.On("GetProduct", int32(1)).Return(repository.Product{ID: 1}, nil).Once()
+				repo.On("GetVariantBySku", "TEE-M-RED").Return(repository.ProductVariant{}, sql.ErrNoRows).Once()
+				repo.On("ListVariants", int32(1)).Return([]repository.ProductVariant{}, nil).Once()
+				repo.On("ListOptionTypes", int32(1)).Return([]repository.OptionType{}, nil).Once()
+				repo.On("ListOptionValues", int32(1)).Return([]repository.OptionValue{}, nil).Once()
+				repo.On("UpsertOptionType", repository.UpsertOptionTypeParams{ProductID: 1, Name: "size", Position: 0}).Return(repository.OptionType{ID: 1}, nil).Once()
+				repo.On("UpsertOptionValue", repository.UpsertOptionValueParams{OptionTypeID: 1, Value: "M"}).Return(repository.OptionValue{ID: 10}, nil).Once()
+				repo.On("UpsertOptionType", repository.UpsertOptionTypeParams{ProductID: 1, Name: "colour", Position: 1}).Return(repository.OptionType{ID: 2}, nil).Once()
+				repo.On("UpsertOptionValue", repository.UpsertOptionValueParams{OptionTypeID: 2, Value: "red"}).Return(repository.OptionValue{ID: 20}, nil).Once()
+				repo.On("CreateVariant", mock.MatchedBy(func(arg repository.CreateVariantParams) bool {
+					return arg.Sku == "TEE-M-RED" && arg.Price == "19.99" && arg.Currency == "USD"
+				})).Return(repository.ProductVariant{ID: 8, ProductID: 1, Sku: "TEE-M-RED", Price: "19.99", Currency: "USD", Stock: 4}, nil).Once()
+				repo.On("AddVariantOptionValue", repository.AddVariantOptionValueParams{VariantID: 8, OptionValueID: 10}).Return(nil).Once()
+				repo.On("AddVariantOptionValue", repository.AddVariantOptionValueParams{VariantID: 8, OptionValueID: 20}).Return(nil).Once()
+				repo.On("CreateStockMovement", mock.MatchedBy(func(arg repository.CreateStockMovementParams) bool {
+					return arg.VariantID == sql.NullInt32{Int32: 8, Valid: true} && arg.Quantity == 4
+				})).Return(repository.StockMovement{}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Variant, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(8), actual.Id)
+				require.Equal(t, int64(1999), actual.Price.MinorUnits)
+				require.Len(t, actual.Options, 2)
+			},
+		},
+		"same combination": {
+			payload: payload(red, medium),
+			arrange: func(t *testing.T) {
+				repo.On("GetProduct", int32(1)).Return(repository.Product{ID: 1}, nil).Once()
+				repo.On("GetVariantBySku", "TEE-M-RED").Return(repository.ProductVariant{}, sql.ErrNoRows).Once()
+				repo.On("ListVariants", int32(1)).Return(existing, nil).Once()
+				repo.On("ListOptionTypes", int32(1)).Return(existingTypes, nil).Once()
+				repo.On("ListVariantOptions", int32(1)).Return([]repository.ListVariantOptionsRow{
+					{VariantID: 7, Name: "size", Value: "M"},
+					{VariantID: 7, Name: "colour", Value: "red"},
+				}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Variant, err error) {
+				require.ErrorIs(t, err, interactor.ErrDuplicateVariant)
+			},
+		},
+		"different option types": {
+			payload: payload(medium),
+			arrange: func(t *testing.T) {
+				repo.On("GetProduct", int32(1)).Return(repository.Product{ID: 1}, nil).Once()
+				repo.On("GetVariantBySku", "TEE-M-RED").Return(repository.ProductVariant{}, sql.ErrNoRows).Once()
+				repo.On("ListVariants", int32(1)).Return(existing, nil).Once()
+				repo.On("ListOptionTypes", int32(1)).Return(existingTypes, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Variant, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidVariant)
+			},
+		},
+		"duplicate sku": {
+			payload: payload(medium),
+			arrange: func(t *testing.T) {
+				repo.On("GetProduct", int32(1)).Return(repository.Product{ID: 1}, nil).Once()
+				repo.On("GetVariantBySku", "TEE-M-RED").Return(repository.ProductVariant{ID: 7}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Variant, err error) {
+				require.ErrorIs(t, err, interactor.ErrDuplicateSku)
+			},
+		},
+		"unknown product": {
+			payload: payload(medium),
+			arrange: func(t *testing.T) {
+				repo.On("GetProduct", int32(1)).Return(repository.Product{}, sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.Variant, err error) {
+				require.ErrorIs(t, err, interactor.ErrProductNotFound)
+			},
+		},
+		"option given twice": {
+			payload: payload(medium, &product.VariantOption{Name: "size", Value: "L"}),
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.Variant, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidVariant)
+			},
+		},
+		"missing sku": {
+			payload: &product.VariantPayload{ProductId: 1, Price: usd},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.Variant, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidVariant)
+			},
+		},
+		"missing price": {
+			payload: &product.VariantPayload{ProductId: 1, Sku: "TEE"},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *product.Variant, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidPrice)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := productInteractor.CreateVariant(ctx, v.payload)
+
+			v.assert(t, result, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestListVariants(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Variants, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				repo.On("ListOptionTypes", int32(3)).Return([]repository.OptionType{{ID: 1, Name: "size"}}, nil).Once()
+				repo.On("ListOptionValues", int32(3)).Return([]repository.OptionValue{{ID: 10, OptionTypeID: 1, Value: "S"}, {ID: 11, OptionTypeID: 1, Value: "M"}}, nil).Once()
+				repo.On("ListVariants", int32(3)).Return([]repository.ProductVariant{
+					{ID: 5, ProductID: 3, Price: "10.00", Currency: "USD"},
+					{ID: 6, ProductID: 3, Price: "12.00", Currency: "USD"},
+				}, nil).Once()
+				repo.On("ListVariantOptions", int32(3)).Return([]repository.ListVariantOptionsRow{
+					{VariantID: 5, Name: "size", Value: "S"},
+					{VariantID: 6, Name: "size", Value: "M"},
+				}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Variants, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.OptionTypes, 1)
+				require.Len(t, actual.OptionTypes[0].Values, 2)
+				require.Len(t, actual.Variants, 2)
+				require.Equal(t, "M", actual.Variants[1].Options[0].Value)
+				require.Equal(t, int64(1200), actual.Variants[1].Price.MinorUnits)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				repo.On("ListOptionTypes", int32(3)).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.Variants, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := productInteractor.ListVariants(ctx, &product.ProductId{Id: 3})
+
+			v.assert(t, result, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,283 @@
+package interactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/ryanpujo/product-service/internal/money"
+	"github.com/ryanpujo/product-service/internal/promotion"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var ErrWishlistItemNotFound = errors.New("wishlist item not found")
+
+type WishlistAlertKind string
+
+const (
+	PriceDropped WishlistAlertKind = "price_dropped"
+	BackInStock  WishlistAlertKind = "back_in_stock"
+)
+
+// WishlistAlert tells a user that a product they saved got cheaper or can be
+// bought again.
+type WishlistAlert struct {
+	Kind      WishlistAlertKind
+	Email     string
+	ProductID int32
+	VariantID int32
+	Name      string
+	Price     money.Money
+	// PreviousPrice is set for PriceDropped.
+	PreviousPrice money.Money
+}
+
+// WishlistNotifier is the hook that delivers wishlist alerts. It is called
+// once the change behind the alerts is committed.
+type WishlistNotifier interface {
+	NotifyWishlist(ctx context.Context, alerts []WishlistAlert) error
+}
+
+// LogNotifier only logs the alerts, it is used until a real notifier is set.
+type LogNotifier struct{}
+
+func (LogNotifier) NotifyWishlist(ctx context.Context, alerts []WishlistAlert) error {
+	for _, a := range alerts {
+		log.Printf("wishlist alert %s for %s: product %d variant %d now %s", a.Kind, a.Email, a.ProductID, a.VariantID, a.Price)
+	}
+	return nil
+}
+
+func (in *productInteractor) AddToWishlist(ctx context.Context, req *product.WishlistItemRequest) (*product.WishlistItem, error) {
+	userID, err := in.customerID(ctx, req.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := in.Repo.AddWishlistItem(ctx, repository.AddWishlistItemParams{
+		UserID:    userID,
+		ProductID: int32(req.ProductId),
+		VariantID: sql.NullInt32{Int32: int32(req.VariantId), Valid: req.VariantId != 0},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		if req.VariantId != 0 {
+			return nil, ErrVariantNotFound
+		}
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	row, err := in.Repo.GetWishlistItem(ctx, saved.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toWishlistItem(repository.ListWishlistItemsRow(row))
+}
+
+func (in *productInteractor) RemoveFromWishlist(ctx context.Context, req *product.WishlistItemRequest) (*emptypb.Empty, error) {
+	userID, err := in.customerID(ctx, req.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := in.Repo.DeleteWishlistItem(ctx, repository.DeleteWishlistItemParams{
+		UserID:    userID,
+		ProductID: int32(req.ProductId),
+		VariantID: int32(req.VariantId),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if removed == 0 {
+		return nil, ErrWishlistItemNotFound
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// ListWishlist returns the saved items, newest first, with their current
+// price and stock.
+func (in *productInteractor) ListWishlist(ctx context.Context, req *product.WishlistRequest) (*product.Wishlist, error) {
+	userID, err := in.customerID(ctx, req.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := in.Repo.ListWishlistItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	wishlist := product.Wishlist{Items: make([]*product.WishlistItem, 0, len(rows))}
+	for _, row := range rows {
+		item, err := toWishlistItem(row)
+		if err != nil {
+			return nil, err
+		}
+		wishlist.Items = append(wishlist.Items, item)
+	}
+	return &wishlist, nil
+}
+
+// MoveWishlistItemToCart puts a saved item in the cart at its current price
+// and takes it off the wishlist.
+func (in *productInteractor) MoveWishlistItemToCart(ctx context.Context, req *product.WishlistItemRequest) (*product.CartItem, error) {
+	if req.Quantity < 0 {
+		return nil, promotion.ErrInvalidQuantity
+	}
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	var line repository.Cart
+	err := in.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		userID, err := customerID(ctx, q, req.UserEmail)
+		if err != nil {
+			return err
+		}
+		removed, err := q.DeleteWishlistItem(ctx, repository.DeleteWishlistItemParams{
+			UserID:    userID,
+			ProductID: int32(req.ProductId),
+			VariantID: int32(req.VariantId),
+		})
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			return ErrWishlistItemNotFound
+		}
+		cart, err := priceCart(ctx, q, []*product.CartItem{{ProductId: req.ProductId, VariantId: req.VariantId, Quantity: quantity}}, "")
+		if err != nil {
+			return err
+		}
+		price, err := cart.result.Lines[0].UnitPrice.Decimal(priceScale)
+		if err != nil {
+			return err
+		}
+		line, err = q.AddCartItem(ctx, repository.AddCartItemParams{
+			UserID:    sql.NullInt32{Int32: userID, Valid: true},
+			ProductID: sql.NullInt32{Int32: int32(req.ProductId), Valid: true},
+			VariantID: sql.NullInt32{Int32: int32(req.VariantId), Valid: req.VariantId != 0},
+			Quantity:  sql.NullInt32{Int32: quantity, Valid: true},
+			Price:     sql.NullString{String: price, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &product.CartItem{
+		ProductId: int64(line.ProductID.Int32),
+		VariantId: int64(line.VariantID.Int32),
+		Quantity:  line.Quantity.Int32,
+	}, nil
+}
+
+// notifyWishlists records the current price and stock of the wishlisted
+// products and hands the price drops and restocks to the notifier. The change
+// that triggered it is already committed, so failures are only logged.
+func (in *productInteractor) notifyWishlists(ctx context.Context, productIDs []int32) {
+	if len(productIDs) == 0 {
+		return
+	}
+	rows, err := in.Repo.SyncWishlistItems(ctx, productIDs)
+	if err != nil {
+		log.Println("failed to check wishlists:", err)
+		return
+	}
+	var alerts []WishlistAlert
+	for _, row := range rows {
+		alert, ok, err := toWishlistAlert(row)
+		if err != nil {
+			log.Println("failed to check wishlists:", err)
+			continue
+		}
+		if ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	if len(alerts) == 0 {
+		return
+	}
+	if err = in.Notifier.NotifyWishlist(ctx, alerts); err != nil {
+		log.Println("failed to send wishlist alerts:", err)
+	}
+}
+
+func (in *productInteractor) customerID(ctx context.Context, email string) (int32, error) {
+	return customerID(ctx, in.Repo, email)
+}
+
+func customerID(ctx context.Context, q repository.Querier, email string) (int32, error) {
+	if email == "" {
+		return 0, ErrCustomerNotFound
+	}
+	id, err := q.GetUserIDByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrCustomerNotFound
+	}
+	return id, err
+}
+
+// toWishlistAlert turns a changed item into an alert, ok is false for changes
+// nobody wants to hear about such as a price rise.
+func toWishlistAlert(row repository.SyncWishlistItemsRow) (alert WishlistAlert, ok bool, err error) {
+	alert = WishlistAlert{
+		Email:     row.Email.String,
+		ProductID: row.ProductID,
+		VariantID: row.VariantID.Int32,
+		Name:      row.Name.String,
+	}
+	current := row.VariantPrice
+	if !current.Valid {
+		current = row.ProductPrice
+	}
+	if current.Valid {
+		if alert.Price, err = money.Parse(current.String, row.Currency); err != nil {
+			return alert, false, err
+		}
+	}
+	if !row.InStock || alert.Email == "" {
+		return alert, false, nil
+	}
+	if !row.PreviousInStock {
+		alert.Kind = BackInStock
+		return alert, true, nil
+	}
+	if row.PreviousPrice.Valid && current.Valid {
+		if alert.PreviousPrice, err = money.Parse(row.PreviousPrice.String, row.Currency); err != nil {
+			return alert, false, err
+		}
+		if alert.Price.Minor < alert.PreviousPrice.Minor {
+			alert.Kind = PriceDropped
+			return alert, true, nil
+		}
+	}
+	return alert, false, nil
+}
+
+func toWishlistItem(row repository.ListWishlistItemsRow) (*product.WishlistItem, error) {
+	item := product.WishlistItem{
+		Id:        int64(row.ID),
+		ProductId: int64(row.ProductID),
+		VariantId: int64(row.VariantID.Int32),
+		Name:      row.Name.String,
+		Stock:     row.ProductStock.Int32,
+		CreatedAt: timestamppb.New(row.CreatedAt),
+	}
+	price, currency := row.ProductPrice, row.ProductCurrency
+	if row.VariantID.Valid {
+		price, currency = row.VariantPrice, row.VariantCurrency.String
+		item.Stock = row.VariantStock.Int32
+	}
+	item.InStock = item.Stock > 0
+	var err error
+	if item.Price, err = toMoney(price, currency); err != nil {
+		return nil, err
+	}
+	if item.AddedPrice, err = toMoney(row.AddedPrice, currency); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
@@ -0,0 +1,280 @@
+package interactor_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/messaging"
+	"github.com/ryanpujo/product-service/internal/money"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingNotifier keeps the alerts it is given.
+type recordingNotifier struct {
+	alerts []interactor.WishlistAlert
+}
+
+func (n *recordingNotifier) NotifyWishlist(ctx context.Context, alerts []interactor.WishlistAlert) error {
+	n.alerts = append(n.alerts, alerts...)
+	return nil
+}
+
+var wishlistRow = repository.ListWishlistItemsRow{
+	ID:              4,
+	ProductID:       1,
+	Name:            sql.NullString{String: "Mug", Valid: true},
+	ProductPrice:    sql.NullString{String: "8.00", Valid: true},
+	ProductCurrency: "USD",
+	ProductStock:    sql.NullInt32{Int32: 3, Valid: true},
+	AddedPrice:      sql.NullString{String: "10.00", Valid: true},
+	CreatedAt:       time.Now(),
+}
+
+func TestAddToWishlist(t *testing.T) {
+	testTable := map[string]struct {
+		req     *product.WishlistItemRequest
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.WishlistItem, err error)
+	}{
+		"succes call": {
+			req: &product.WishlistItemRequest{UserEmail: "jane@mail.com", ProductId: 1},
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("AddWishlistItem", repository.AddWishlistItemParams{UserID: 5, ProductID: 1}).Return(repository.WishlistItem{ID: 4}, nil).Once()
+				repo.On("GetWishlistItem", int32(4)).Return(repository.GetWishlistItemRow(wishlistRow), nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.WishlistItem, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(800), actual.Price.MinorUnits)
+				require.Equal(t, int64(1000), actual.AddedPrice.MinorUnits)
+				require.True(t, actual.InStock)
+			},
+		},
+		"unknown variant": {
+			req: &product.WishlistItemRequest{UserEmail: "jane@mail.com", ProductId: 1, VariantId: 9},
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("AddWishlistItem", repository.AddWishlistItemParams{UserID: 5, ProductID: 1, VariantID: sql.NullInt32{Int32: 9, Valid: true}}).
+					Return(repository.WishlistItem{}, sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.WishlistItem, err error) {
+				require.ErrorIs(t, err, interactor.ErrVariantNotFound)
+			},
+		},
+		"unknown user": {
+			req: &product.WishlistItemRequest{UserEmail: "nobody@mail.com", ProductId: 1},
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "nobody@mail.com").Return(int32(0), sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, actual *product.WishlistItem, err error) {
+				require.ErrorIs(t, err, interactor.ErrCustomerNotFound)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := productInteractor.AddToWishlist(context.Background(), v.req)
+
+			v.assert(t, actual, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestRemoveFromWishlist(t *testing.T) {
+	req := &product.WishlistItemRequest{UserEmail: "jane@mail.com", ProductId: 1}
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("DeleteWishlistItem", repository.DeleteWishlistItemParams{UserID: 5, ProductID: 1}).Return(int64(1), nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"not saved": {
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("DeleteWishlistItem", mock.Anything).Return(int64(0), nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, interactor.ErrWishlistItemNotFound)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			_, err := productInteractor.RemoveFromWishlist(context.Background(), req)
+
+			v.assert(t, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestListWishlist(t *testing.T) {
+	variant := repository.ListWishlistItemsRow{
+		ID:              5,
+		ProductID:       2,
+		VariantID:       sql.NullInt32{Int32: 7, Valid: true},
+		ProductPrice:    sql.NullString{String: "30.00", Valid: true},
+		ProductCurrency: "USD",
+		ProductStock:    sql.NullInt32{Int32: 9, Valid: true},
+		VariantPrice:    sql.NullString{String: "32.00", Valid: true},
+		VariantCurrency: sql.NullString{String: "USD", Valid: true},
+		VariantStock:    sql.NullInt32{Int32: 0, Valid: true},
+	}
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.Wishlist, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("ListWishlistItems", int32(5)).Return([]repository.ListWishlistItemsRow{wishlistRow, variant}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.Wishlist, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Items, 2)
+				require.Equal(t, int64(3200), actual.Items[1].Price.MinorUnits, "the variant price")
+				require.False(t, actual.Items[1].InStock, "the variant stock")
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("ListWishlistItems", int32(5)).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *product.Wishlist, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := productInteractor.ListWishlist(context.Background(), &product.WishlistRequest{UserEmail: "jane@mail.com"})
+
+			v.assert(t, actual, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestMoveWishlistItemToCart(t *testing.T) {
+	testTable := map[string]struct {
+		req     *product.WishlistItemRequest
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *product.CartItem, err error)
+	}{
+		"succes call": {
+			req: &product.WishlistItemRequest{UserEmail: "jane@mail.com", ProductId: 1},
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("DeleteWishlistItem", repository.DeleteWishlistItemParams{UserID: 5, ProductID: 1}).Return(int64(1), nil).Once()
+				repo.On("ListProductsForPricing", []int32{1}).Return(pricingRows[:1], nil).Once()
+				repo.On("ListAutomaticPromotions", []int32{3}).Return([]repository.Promotion{}, nil).Once()
+				repo.On("AddCartItem", repository.AddCartItemParams{
+					UserID:    sql.NullInt32{Int32: 5, Valid: true},
+					ProductID: sql.NullInt32{Int32: 1, Valid: true},
+					Quantity:  sql.NullInt32{Int32: 1, Valid: true},
+					Price:     sql.NullString{String: "10.00", Valid: true},
+				}).Return(repository.Cart{
+					ProductID: sql.NullInt32{Int32: 1, Valid: true},
+					Quantity:  sql.NullInt32{Int32: 3, Valid: true},
+				}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.CartItem, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(3), actual.Quantity, "added to what was in the cart")
+			},
+		},
+		"not saved": {
+			req: &product.WishlistItemRequest{UserEmail: "jane@mail.com", ProductId: 1},
+			arrange: func(t *testing.T) {
+				repo.On("GetUserIDByEmail", "jane@mail.com").Return(int32(5), nil).Once()
+				repo.On("DeleteWishlistItem", mock.Anything).Return(int64(0), nil).Once()
+			},
+			assert: func(t *testing.T, actual *product.CartItem, err error) {
+				require.ErrorIs(t, err, interactor.ErrWishlistItemNotFound)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := productInteractor.MoveWishlistItemToCart(context.Background(), v.req)
+
+			v.assert(t, actual, err)
+		})
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestWishlistAlerts(t *testing.T) {
+	notifier := &recordingNotifier{}
+	in := interactor.NewProductInteractor(repo)
+	in.Notifier = notifier
+
+	changed := []repository.SyncWishlistItemsRow{
+		{
+			ID:              1,
+			ProductID:       1,
+			Email:           sql.NullString{String: "jane@mail.com", Valid: true},
+			Currency:        "USD",
+			PreviousPrice:   sql.NullString{String: "10.00", Valid: true},
+			PreviousInStock: true,
+			ProductPrice:    sql.NullString{String: "8.00", Valid: true},
+			InStock:         true,
+		},
+		{
+			ID:           2,
+			ProductID:    1,
+			Email:        sql.NullString{String: "john@mail.com", Valid: true},
+			Currency:     "USD",
+			ProductPrice: sql.NullString{String: "8.00", Valid: true},
+			InStock:      true,
+		},
+		{
+			ID:              3,
+			ProductID:       1,
+			Email:           sql.NullString{String: "jack@mail.com", Valid: true},
+			Currency:        "USD",
+			PreviousPrice:   sql.NullString{String: "8.00", Valid: true},
+			PreviousInStock: true,
+			ProductPrice:    sql.NullString{String: "8.00", Valid: true},
+		},
+	}
+	repo.On("AddProductStock", repository.AddProductStockParams{ID: 1, Quantity: 5}).Return(sql.NullInt32{Int32: 5, Valid: true}, nil).Once()
+	repo.On("CreateStockMovement", mock.Anything).Return(repository.StockMovement{ProductID: 1, Quantity: 5, Reason: "restock"}, nil).Once()
+	repo.On("InsertOutboxEvent", messaging.TopicStockAdjusted, "1").Return(nil).Once()
+	repo.On("SyncWishlistItems", []int32{1}).Return(changed, nil).Once()
+
+	_, err := in.AdjustStock(context.Background(), &product.StockAdjustment{ProductId: 1, Quantity: 5, Reason: product.StockReason_RESTOCK})
+
+	require.NoError(t, err)
+	require.Len(t, notifier.alerts, 2, "nothing for an item that went out of stock")
+	require.Equal(t, interactor.PriceDropped, notifier.alerts[0].Kind)
+	require.Equal(t, money.Money{Minor: 1000, Currency: "USD"}, notifier.alerts[0].PreviousPrice)
+	require.Equal(t, interactor.BackInStock, notifier.alerts[1].Kind)
+	require.Equal(t, "john@mail.com", notifier.alerts[1].Email)
+	repo.AssertExpectations(t)
+}
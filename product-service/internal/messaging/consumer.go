@@ -0,0 +1,212 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/events"
+)
+
+// The retry policy of a consumer when none is given: five attempts, waiting
+// 200ms after the first failure and twice as long after each next one.
+const (
+	DefaultAttempts = 5
+	DefaultBackoff  = 200 * time.Millisecond
+	maxBackoff      = 5 * time.Second
+)
+
+var (
+	ErrNoEventID     = errors.New("event has no id")
+	ErrWrongConsumer = errors.New("dead letter belongs to another consumer")
+)
+
+// TxHandler handles an event with q, inside the transaction that records the
+// event as processed.
+type TxHandler func(ctx context.Context, q repository.Querier, envelope *events.Envelope) error
+
+// Consumer handles the events of a topic for one purpose. Every event is
+// handled once per consumer: its id is stored in the transaction of the
+// handler, an event published again is skipped. A handler that keeps failing
+// is given up on after Attempts and the message is moved to the dead letters,
+// where an admin can look at it and replay it.
+type Consumer struct {
+	Name     string
+	Topic    string
+	Repo     repository.TxQuerier
+	Handle   TxHandler
+	Attempts int
+	Backoff  time.Duration
+	// Committed, when set, runs once the transaction of a handled event has
+	// committed, for work that must not follow a change that is rolled back,
+	// such as telling customers. It also runs for an event that was skipped
+	// as already handled.
+	Committed func(ctx context.Context, envelope *events.Envelope)
+}
+
+func NewConsumer(name, topic string, repo repository.TxQuerier, handle TxHandler) *Consumer {
+	return &Consumer{
+		Name:     name,
+		Topic:    topic,
+		Repo:     repo,
+		Handle:   handle,
+		Attempts: DefaultAttempts,
+		Backoff:  DefaultBackoff,
+	}
+}
+
+// Consume is the Handler of the consumer. It returns an error only when the
+// message could be neither handled nor stored as a dead letter, or ctx is
+// done while it waits to retry.
+func (c *Consumer) Consume(ctx context.Context, msg Message) error {
+	envelope, err := Decode(msg)
+	if err == nil && envelope.Id == "" {
+		err = ErrNoEventID
+	}
+	if err != nil {
+		// a message that can't be read fails the same way every time
+		return c.deadLetter(ctx, msg, "", 1, err)
+	}
+	if msg.Key == "" {
+		// a broker does not carry the key
+		if _, key, err := route(envelope); err == nil {
+			msg.Key = key
+		}
+	}
+
+	delay := c.Backoff
+	attempt := 1
+	for {
+		err = c.Repo.ExecTx(ctx, func(q repository.Querier) error {
+			return c.process(ctx, q, envelope)
+		})
+		if err == nil || attempt >= c.Attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		attempt++
+		if delay *= 2; delay > maxBackoff {
+			delay = maxBackoff
+		}
+	}
+	if err != nil {
+		return c.deadLetter(ctx, msg, envelope.Id, attempt, err)
+	}
+	c.committed(ctx, envelope)
+	return nil
+}
+
+// Replay handles a dead letter of the consumer once more and marks it
+// replayed, both in one transaction. An event handled in the meantime is
+// only marked.
+func (c *Consumer) Replay(ctx context.Context, letter repository.DeadLetter) error {
+	if letter.Consumer != c.Name {
+		return fmt.Errorf("%w: %s", ErrWrongConsumer, letter.Consumer)
+	}
+	envelope, err := Decode(Message{Topic: letter.Topic, Key: letter.PartitionKey, Payload: letter.Payload})
+	if err != nil {
+		return err
+	}
+	if envelope.Id == "" {
+		return ErrNoEventID
+	}
+	err = c.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		if err := c.process(ctx, q, envelope); err != nil {
+			return err
+		}
+		return q.MarkDeadLetterReplayed(ctx, letter.ID)
+	})
+	if err != nil {
+		return err
+	}
+	c.committed(ctx, envelope)
+	return nil
+}
+
+func (c *Consumer) committed(ctx context.Context, envelope *events.Envelope) {
+	if c.Committed != nil {
+		c.Committed(ctx, envelope)
+	}
+}
+
+func (c *Consumer) process(ctx context.Context, q repository.Querier, envelope *events.Envelope) error {
+	n, err := q.MarkEventProcessed(ctx, repository.MarkEventProcessedParams{
+		Consumer: c.Name,
+		EventID:  envelope.Id,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+	return c.Handle(ctx, q, envelope)
+}
+
+func (c *Consumer) deadLetter(ctx context.Context, msg Message, eventID string, attempts int, cause error) error {
+	letter, err := c.Repo.CreateDeadLetter(ctx, repository.CreateDeadLetterParams{
+		Consumer:     c.Name,
+		Topic:        msg.Topic,
+		PartitionKey: msg.Key,
+		EventID:      eventID,
+		Payload:      msg.Payload,
+		Error:        cause.Error(),
+		Attempts:     int32(attempts),
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %v, dead letter not stored: %w", c.Name, cause, err)
+	}
+	log.Printf("%s gave up on event %q after %d attempts, dead letter %d: %v", c.Name, eventID, attempts, letter.ID, cause)
+	return nil
+}
+
+var (
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	ErrAlreadyReplayed    = errors.New("dead letter already replayed")
+	ErrUnknownConsumer    = errors.New("unknown consumer")
+)
+
+// DeadLetters lets an admin look at the messages the consumers gave up on
+// and replay them once the cause is fixed.
+type DeadLetters struct {
+	Repo      repository.Querier
+	Consumers []*Consumer
+}
+
+// List returns up to limit dead letters of the consumer, of every consumer
+// when it is empty. Replayed ones are left out unless all is set.
+func (d *DeadLetters) List(ctx context.Context, consumer string, all bool, limit int) ([]repository.DeadLetter, error) {
+	return d.Repo.ListDeadLetters(ctx, repository.ListDeadLettersParams{
+		Consumer:        consumer,
+		IncludeReplayed: all,
+		PageSize:        int32(limit),
+	})
+}
+
+// Replay hands the dead letter to its consumer again.
+func (d *DeadLetters) Replay(ctx context.Context, id int64) error {
+	letter, err := d.Repo.GetDeadLetter(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if letter.ReplayedAt.Valid {
+		return ErrAlreadyReplayed
+	}
+	for _, c := range d.Consumers {
+		if c.Name == letter.Consumer {
+			return c.Replay(ctx, letter)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownConsumer, letter.Consumer)
+}
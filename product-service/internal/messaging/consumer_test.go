@@ -0,0 +1,211 @@
+package messaging_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/messaging"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/events"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (q *querier) MarkEventProcessed(ctx context.Context, arg repository.MarkEventProcessedParams) (int64, error) {
+	args := q.Called(arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (q *querier) CreateDeadLetter(ctx context.Context, arg repository.CreateDeadLetterParams) (repository.DeadLetter, error) {
+	args := q.Called(arg)
+	return args.Get(0).(repository.DeadLetter), args.Error(1)
+}
+
+func (q *querier) GetDeadLetter(ctx context.Context, id int64) (repository.DeadLetter, error) {
+	args := q.Called(id)
+	return args.Get(0).(repository.DeadLetter), args.Error(1)
+}
+
+func (q *querier) MarkDeadLetterReplayed(ctx context.Context, id int64) error {
+	return q.Called(id).Error(0)
+}
+
+func cancelledOrder(t *testing.T) messaging.Message {
+	envelope := messaging.NewEnvelope(context.Background())
+	envelope.Id = "event-1"
+	envelope.Event = &events.Envelope_OrderCancelled{OrderCancelled: &events.OrderCancelled{OrderId: 12}}
+	msg, err := messaging.Encode(envelope)
+	require.NoError(t, err)
+	return msg
+}
+
+func TestConsume(t *testing.T) {
+	processed := repository.MarkEventProcessedParams{Consumer: "stock-release", EventID: "event-1"}
+	testTable := map[string]struct {
+		msg     func(t *testing.T) messaging.Message
+		arrange func(q *querier)
+		fails   int
+		handled int
+		// committed is how often the work after the commit ran
+		committed int
+	}{
+		"succes call": {
+			msg: cancelledOrder,
+			arrange: func(q *querier) {
+				q.On("MarkEventProcessed", processed).Return(int64(1), nil).Once()
+			},
+			handled:   1,
+			committed: 1,
+		},
+		"handled already": {
+			msg: cancelledOrder,
+			arrange: func(q *querier) {
+				q.On("MarkEventProcessed", processed).Return(int64(0), nil).Once()
+			},
+			committed: 1,
+		},
+		"retried": {
+			msg: cancelledOrder,
+			arrange: func(q *querier) {
+				q.On("MarkEventProcessed", processed).Return(int64(1), nil).Times(2)
+			},
+			fails:     1,
+			handled:   2,
+			committed: 1,
+		},
+		"given up": {
+			msg: cancelledOrder,
+			arrange: func(q *querier) {
+				q.On("MarkEventProcessed", processed).Return(int64(1), nil).Times(3)
+				q.On("CreateDeadLetter", mock.MatchedBy(func(arg repository.CreateDeadLetterParams) bool {
+					return arg.EventID == "event-1" && arg.PartitionKey == "12" && arg.Attempts == 3 && arg.Error == "stock is locked"
+				})).Return(repository.DeadLetter{ID: 7}, nil).Once()
+			},
+			fails:   3,
+			handled: 3,
+		},
+		"unreadable": {
+			msg: func(t *testing.T) messaging.Message {
+				return messaging.Message{Topic: messaging.TopicOrderCancelled, Payload: []byte("not a proto")}
+			},
+			arrange: func(q *querier) {
+				q.On("CreateDeadLetter", mock.MatchedBy(func(arg repository.CreateDeadLetterParams) bool {
+					return arg.EventID == "" && arg.Attempts == 1
+				})).Return(repository.DeadLetter{ID: 8}, nil).Once()
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			q := &querier{}
+			v.arrange(q)
+			handled := 0
+			consumer := messaging.NewConsumer("stock-release", messaging.TopicOrderCancelled, q, func(ctx context.Context, _ repository.Querier, envelope *events.Envelope) error {
+				handled++
+				require.Equal(t, int64(12), envelope.GetOrderCancelled().GetOrderId())
+				if handled <= v.fails {
+					return errors.New("stock is locked")
+				}
+				return nil
+			})
+			consumer.Attempts = 3
+			consumer.Backoff = time.Millisecond
+			committed := 0
+			consumer.Committed = func(ctx context.Context, envelope *events.Envelope) {
+				committed++
+			}
+
+			err := consumer.Consume(context.Background(), v.msg(t))
+
+			require.NoError(t, err)
+			require.Equal(t, v.handled, handled)
+			require.Equal(t, v.committed, committed)
+			q.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConsumeDeadLetterFails(t *testing.T) {
+	q := &querier{}
+	q.On("MarkEventProcessed", mock.Anything).Return(int64(1), nil).Once()
+	q.On("CreateDeadLetter", mock.Anything).Return(repository.DeadLetter{}, errors.New("db is down")).Once()
+	consumer := messaging.NewConsumer("stock-release", messaging.TopicOrderCancelled, q, func(ctx context.Context, _ repository.Querier, envelope *events.Envelope) error {
+		return errors.New("stock is locked")
+	})
+	consumer.Attempts = 1
+
+	err := consumer.Consume(context.Background(), cancelledOrder(t))
+
+	require.ErrorContains(t, err, "dead letter not stored")
+	q.AssertExpectations(t)
+}
+
+func TestReplayDeadLetter(t *testing.T) {
+	msg := cancelledOrder(t)
+	letter := repository.DeadLetter{ID: 7, Consumer: "stock-release", Topic: msg.Topic, EventID: "event-1", Payload: msg.Payload}
+	testTable := map[string]struct {
+		arrange func(q *querier)
+		assert  func(t *testing.T, handled int, err error)
+	}{
+		"succes call": {
+			arrange: func(q *querier) {
+				q.On("GetDeadLetter", int64(7)).Return(letter, nil).Once()
+				q.On("MarkEventProcessed", repository.MarkEventProcessedParams{Consumer: "stock-release", EventID: "event-1"}).Return(int64(1), nil).Once()
+				q.On("MarkDeadLetterReplayed", int64(7)).Return(nil).Once()
+			},
+			assert: func(t *testing.T, handled int, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 1, handled)
+			},
+		},
+		"replayed already": {
+			arrange: func(q *querier) {
+				replayed := letter
+				replayed.ReplayedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				q.On("GetDeadLetter", int64(7)).Return(replayed, nil).Once()
+			},
+			assert: func(t *testing.T, handled int, err error) {
+				require.ErrorIs(t, err, messaging.ErrAlreadyReplayed)
+				require.Zero(t, handled)
+			},
+		},
+		"unknown consumer": {
+			arrange: func(q *querier) {
+				other := letter
+				other.Consumer = "gone"
+				q.On("GetDeadLetter", int64(7)).Return(other, nil).Once()
+			},
+			assert: func(t *testing.T, handled int, err error) {
+				require.ErrorIs(t, err, messaging.ErrUnknownConsumer)
+			},
+		},
+		"not found": {
+			arrange: func(q *querier) {
+				q.On("GetDeadLetter", int64(7)).Return(repository.DeadLetter{}, sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, handled int, err error) {
+				require.ErrorIs(t, err, messaging.ErrDeadLetterNotFound)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			q := &querier{}
+			v.arrange(q)
+			handled := 0
+			consumer := messaging.NewConsumer("stock-release", messaging.TopicOrderCancelled, q, func(ctx context.Context, _ repository.Querier, envelope *events.Envelope) error {
+				handled++
+				return nil
+			})
+			deadLetters := messaging.DeadLetters{Repo: q, Consumers: []*messaging.Consumer{consumer}}
+
+			err := deadLetters.Replay(context.Background(), 7)
+
+			v.assert(t, handled, err)
+			q.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Package messaging publishes and consumes the domain events of the
+// service. An event is written to the outbox in the transaction of the
+// change it describes, a Relay hands it to a Publisher once that transaction
+// has committed. Events are published at least once, a Consumer skips the
+// ones it handled already.
+package messaging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ryanpujo/product-service/internal/audit"
+	"github.com/ryanpujo/product-service/product-proto/grpc/events"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Source names the events this service writes to the outbox.
+const Source = "product"
+
+// The topics events are published on, one for each kind of event.
+const (
+	TopicUserRegistered = "user.registered"
+	TopicUserDeleted    = "user.deleted"
+	TopicOrderPlaced    = "order.placed"
+	TopicStockAdjusted  = "stock.adjusted"
+	TopicOrderCancelled = "order.cancelled"
+)
+
+// userTopics are published by the user service. Only a broker both services
+// connect to, such as NATS, brings them here.
+var userTopics = map[string]bool{TopicUserRegistered: true, TopicUserDeleted: true}
+
+// Local reports whether this service publishes the events of topic, the
+// in-process bus delivers no others.
+func Local(topic string) bool {
+	return !userTopics[topic]
+}
+
+var ErrUnknownEvent = errors.New("unknown event")
+
+// Message is an event as it is kept in the outbox and sent on the bus.
+// Messages with the same key are published in the order they were written.
+type Message struct {
+	ID      int64
+	Topic   string
+	Key     string
+	Payload []byte
+}
+
+// Publisher sends messages to the bus, a nil error means the bus accepted it.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// NewEnvelope returns an envelope for an event caused by the request of ctx,
+// the caller sets the event.
+func NewEnvelope(ctx context.Context) *events.Envelope {
+	request := audit.RequestFrom(ctx)
+	return &events.Envelope{
+		Id:         newID(),
+		Source:     Source,
+		OccurredAt: timestamppb.Now(),
+		RequestId:  request.ID,
+		Actor:      request.Actor,
+	}
+}
+
+// Encode turns an envelope into the message the outbox keeps.
+func Encode(envelope *events.Envelope) (Message, error) {
+	topic, key, err := route(envelope)
+	if err != nil {
+		return Message{}, err
+	}
+	payload, err := proto.Marshal(envelope)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Topic: topic, Key: key, Payload: payload}, nil
+}
+
+// Decode reads the envelope a message carries.
+func Decode(msg Message) (*events.Envelope, error) {
+	var envelope events.Envelope
+	if err := proto.Unmarshal(msg.Payload, &envelope); err != nil {
+		return nil, err
+	}
+	return &envelope, nil
+}
+
+// route returns the topic of an event and the key that keeps the events of
+// one user, order or product in order.
+func route(envelope *events.Envelope) (string, string, error) {
+	switch e := envelope.Event.(type) {
+	case *events.Envelope_UserRegistered:
+		return TopicUserRegistered, key(e.UserRegistered.UserId), nil
+	case *events.Envelope_UserDeleted:
+		return TopicUserDeleted, key(e.UserDeleted.UserId), nil
+	case *events.Envelope_OrderPlaced:
+		return TopicOrderPlaced, key(e.OrderPlaced.OrderId), nil
+	case *events.Envelope_StockAdjusted:
+		return TopicStockAdjusted, key(e.StockAdjusted.ProductId), nil
+	case *events.Envelope_OrderCancelled:
+		return TopicOrderCancelled, key(e.OrderCancelled.OrderId), nil
+	}
+	return "", "", fmt.Errorf("%w: %T", ErrUnknownEvent, envelope.Event)
+}
+
+func key(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,213 @@
+package messaging_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/audit"
+	"github.com/ryanpujo/product-service/internal/messaging"
+	"github.com/ryanpujo/product-service/product-proto/grpc/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	ctx := audit.WithRequest(context.Background(), audit.Request{ID: "req-1", Actor: "root@mail.com"})
+	envelope := messaging.NewEnvelope(ctx)
+	envelope.Event = &events.Envelope_OrderPlaced{OrderPlaced: &events.OrderPlaced{
+		OrderId: 7,
+		Items:   []*events.OrderItem{{ProductId: 1, Quantity: 2}},
+	}}
+
+	msg, err := messaging.Encode(envelope)
+	require.NoError(t, err)
+	require.Equal(t, messaging.TopicOrderPlaced, msg.Topic)
+	require.Equal(t, "7", msg.Key)
+
+	decoded, err := messaging.Decode(msg)
+	require.NoError(t, err)
+	require.Equal(t, envelope.Id, decoded.Id)
+	require.Equal(t, messaging.Source, decoded.Source)
+	require.Equal(t, "req-1", decoded.RequestId)
+	require.Equal(t, "root@mail.com", decoded.Actor)
+	require.Equal(t, int32(2), decoded.GetOrderPlaced().Items[0].Quantity)
+
+	_, err = messaging.Encode(messaging.NewEnvelope(context.Background()))
+	require.ErrorIs(t, err, messaging.ErrUnknownEvent)
+}
+
+func TestBus(t *testing.T) {
+	bus := messaging.NewBus()
+	var got []string
+	bus.Subscribe(messaging.TopicUserDeleted, func(ctx context.Context, msg messaging.Message) error {
+		got = append(got, "first "+msg.Key)
+		return errors.New("cart service is down")
+	})
+	bus.Subscribe(messaging.TopicUserDeleted, func(ctx context.Context, msg messaging.Message) error {
+		got = append(got, "second "+msg.Key)
+		return nil
+	})
+
+	err := bus.Publish(context.Background(), messaging.Message{Topic: messaging.TopicUserDeleted, Key: "7"})
+	require.EqualError(t, err, "cart service is down")
+	// a failing handler does not keep the others from the message
+	require.Equal(t, []string{"first 7", "second 7"}, got)
+
+	require.NoError(t, bus.Publish(context.Background(), messaging.Message{Topic: messaging.TopicUserRegistered}))
+}
+
+func TestLocal(t *testing.T) {
+	require.True(t, messaging.Local(messaging.TopicOrderCancelled))
+	require.True(t, messaging.Local(messaging.TopicStockAdjusted))
+	// the user service publishes these, the in-process bus never sees them
+	require.False(t, messaging.Local(messaging.TopicUserDeleted))
+	require.False(t, messaging.Local(messaging.TopicUserRegistered))
+}
+
+// outbox hands out its messages in batches and keeps those publish refused.
+type outbox struct {
+	pending   []messaging.Message
+	published []messaging.Message
+}
+
+func (o *outbox) Relay(ctx context.Context, limit int, publish func(ctx context.Context, msg messaging.Message) error) (int, error) {
+	n := 0
+	for len(o.pending) > 0 && n < limit {
+		if err := publish(ctx, o.pending[0]); err != nil {
+			return n, err
+		}
+		o.published = append(o.published, o.pending[0])
+		o.pending = o.pending[1:]
+		n++
+	}
+	return n, nil
+}
+
+func TestRelay(t *testing.T) {
+	box := &outbox{}
+	for i := int64(1); i <= 5; i++ {
+		box.pending = append(box.pending, messaging.Message{ID: i, Topic: messaging.TopicUserRegistered})
+	}
+	bus := messaging.NewBus()
+	fail := true
+	bus.Subscribe(messaging.TopicUserRegistered, func(ctx context.Context, msg messaging.Message) error {
+		if msg.ID == 4 && fail {
+			return errors.New("not now")
+		}
+		return nil
+	})
+	relay := messaging.NewRelay(box, bus)
+	relay.Batch = 2
+
+	published, err := relay.Flush(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 3, published)
+	require.Len(t, box.pending, 2)
+
+	fail = false
+	published, err = relay.Flush(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, published)
+	require.Len(t, box.published, 5)
+}
+
+// natsServer answers like a NATS server and hands over what it is sent. It
+// sends a message with the payload "event" to every subscription.
+func natsServer(t *testing.T, reject string) (string, <-chan string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+	lines := make(chan string, 16)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			switch {
+			case line == "PING":
+				conn.Write([]byte("PONG\r\n"))
+			case strings.HasPrefix(line, "PUB "):
+				payload, _ := r.ReadString('\n')
+				lines <- line + " " + strings.TrimSpace(payload)
+				if reject != "" {
+					conn.Write([]byte("-ERR '" + reject + "'\r\n"))
+				}
+			case strings.HasPrefix(line, "CONNECT "):
+				lines <- line
+			case strings.HasPrefix(line, "SUB "):
+				lines <- line
+				fields := strings.Fields(line)
+				conn.Write([]byte("PING\r\nMSG " + fields[1] + " " + fields[3] + " 5\r\nevent\r\n"))
+			}
+		}
+	}()
+	return lis.Addr().String(), lines
+}
+
+func TestNATS(t *testing.T) {
+	addr, lines := natsServer(t, "")
+	nats, err := messaging.NewNATS("nats://app:secret@"+addr, time.Second)
+	require.NoError(t, err)
+	defer nats.Close()
+
+	err = nats.Publish(context.Background(), messaging.Message{Topic: messaging.TopicUserDeleted, Payload: []byte("event")})
+	require.NoError(t, err)
+	require.Contains(t, <-lines, `"user":"app"`)
+	require.Equal(t, "PUB user.deleted 5 event", <-lines)
+
+	addr, _ = natsServer(t, "Permissions Violation for Publish")
+	nats, err = messaging.NewNATS("nats://"+addr, time.Second)
+	require.NoError(t, err)
+	defer nats.Close()
+	err = nats.Publish(context.Background(), messaging.Message{Topic: messaging.TopicUserDeleted, Payload: []byte("event")})
+	require.EqualError(t, err, "NATS: Permissions Violation for Publish")
+
+	_, err = messaging.NewNATS("http://localhost:4222", time.Second)
+	require.Error(t, err)
+}
+
+func TestNATSListen(t *testing.T) {
+	addr, lines := natsServer(t, "")
+	nats, err := messaging.NewNATS("nats://"+addr, time.Second)
+	require.NoError(t, err)
+
+	received := make(chan messaging.Message, 1)
+	nats.Subscribe(messaging.TopicOrderCancelled, func(ctx context.Context, msg messaging.Message) error {
+		received <- msg
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- nats.Listen(ctx) }()
+
+	require.Contains(t, <-lines, "CONNECT ")
+	require.Equal(t, "SUB order.cancelled product 1", <-lines)
+	select {
+	case msg := <-received:
+		require.Equal(t, messaging.TopicOrderCancelled, msg.Topic)
+		require.Equal(t, []byte("event"), msg.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+
+	cancel()
+	select {
+	case err = <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Listen did not return")
+	}
+}
@@ -0,0 +1,327 @@
+package messaging
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATS publishes the messages to a NATS server and receives the ones of the
+// topics subscribed to, each topic is a subject. It speaks the text protocol
+// of the server directly and waits for the server to answer a PING after
+// every message, so a message it reports as published has reached the
+// server. A lost connection is dialed again on the next message.
+type NATS struct {
+	addr    string
+	user    string
+	pass    string
+	token   string
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn *natsConn
+
+	subMu    sync.Mutex
+	handlers map[string][]Handler
+}
+
+// natsConn is a connection to the server with the reader of its replies.
+type natsConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// DefaultNATSTimeout bounds each exchange with the server when no timeout is
+// given.
+const DefaultNATSTimeout = 5 * time.Second
+
+// NewNATS takes the address of the server as nats://[user:pass@]host:port,
+// or nats://token@host:port.
+func NewNATS(address string, timeout time.Duration) (*NATS, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "nats" || u.Host == "" {
+		return nil, fmt.Errorf("invalid NATS address %q", address)
+	}
+	if timeout <= 0 {
+		timeout = DefaultNATSTimeout
+	}
+	n := &NATS{addr: u.Host, timeout: timeout, handlers: map[string][]Handler{}}
+	if u.Port() == "" {
+		n.addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			n.user, n.pass = u.User.Username(), pass
+		} else {
+			n.token = u.User.Username()
+		}
+	}
+	return n, nil
+}
+
+func (n *NATS) Publish(ctx context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == nil {
+		conn, err := n.connect(ctx)
+		if err != nil {
+			return err
+		}
+		n.conn = conn
+	}
+	err := n.publish(ctx, msg)
+	if err != nil {
+		n.conn.Close()
+		n.conn = nil
+	}
+	return err
+}
+
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn = nil
+	return err
+}
+
+// Subscribe adds a handler for the messages of topic, call it before Listen.
+func (n *NATS) Subscribe(topic string, handler Handler) {
+	n.subMu.Lock()
+	defer n.subMu.Unlock()
+	n.handlers[topic] = append(n.handlers[topic], handler)
+}
+
+// Listen receives the messages of the subscribed topics on a connection of
+// its own until ctx is done, a lost connection is dialed again after a
+// growing pause. The instances of the service subscribe as one queue group,
+// so each message goes to one of them. The server keeps no messages: one
+// published while no instance listens is lost, as is one in flight when the
+// listener stops.
+func (n *NATS) Listen(ctx context.Context) error {
+	pause := time.Second
+	for {
+		subscribed, err := n.listen(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if subscribed {
+			pause = time.Second
+		}
+		log.Println("lost the NATS subscription:", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pause):
+		}
+		if pause *= 2; pause > 30*time.Second {
+			pause = 30 * time.Second
+		}
+	}
+}
+
+func (n *NATS) listen(ctx context.Context) (bool, error) {
+	conn, err := n.connect(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	n.subMu.Lock()
+	topics := make([]string, 0, len(n.handlers))
+	for topic := range n.handlers {
+		topics = append(topics, topic)
+	}
+	n.subMu.Unlock()
+	sort.Strings(topics)
+	w := bufio.NewWriter(conn)
+	for i, topic := range topics {
+		if !validSubject(topic) {
+			return false, fmt.Errorf("invalid subject %q", topic)
+		}
+		fmt.Fprintf(w, "SUB %s %s %d\r\n", topic, Source, i+1)
+	}
+	if err = w.Flush(); err != nil {
+		return false, err
+	}
+	// messages may be far apart, the PINGs of the server find a dead
+	// connection
+	conn.SetDeadline(time.Time{})
+
+	for {
+		line, err := conn.r.ReadString('\n')
+		if err != nil {
+			return true, err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PING":
+			if _, err = conn.Write([]byte("PONG\r\n")); err != nil {
+				return true, err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return true, natsError(line)
+		case strings.HasPrefix(line, "MSG "):
+			msg, err := conn.readMsg(line)
+			if err != nil {
+				return true, err
+			}
+			n.deliver(ctx, msg)
+		}
+	}
+}
+
+// readMsg reads the payload of a message, line is its header
+// MSG <subject> <sid> [reply-to] <#bytes>.
+func (c *natsConn) readMsg(line string) (Message, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 && len(fields) != 5 {
+		return Message{}, fmt.Errorf("invalid NATS message header %q", line)
+	}
+	size, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || size < 0 {
+		return Message{}, fmt.Errorf("invalid NATS message header %q", line)
+	}
+	payload := make([]byte, size+2)
+	if _, err = io.ReadFull(c.r, payload); err != nil {
+		return Message{}, err
+	}
+	return Message{Topic: fields[1], Payload: payload[:size]}, nil
+}
+
+func (n *NATS) deliver(ctx context.Context, msg Message) {
+	n.subMu.Lock()
+	handlers := n.handlers[msg.Topic]
+	n.subMu.Unlock()
+	for _, handle := range handlers {
+		if err := handle(ctx, msg); err != nil {
+			log.Printf("failed to handle a message of %s: %v", msg.Topic, err)
+		}
+	}
+}
+
+func (n *NATS) connect(ctx context.Context) (*natsConn, error) {
+	dialer := net.Dialer{Timeout: n.timeout}
+	c, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &natsConn{Conn: c, r: bufio.NewReader(c)}
+	n.deadline(ctx, conn)
+	// the server greets with its INFO first
+	line, err := conn.r.ReadString('\n')
+	if err == nil && !strings.HasPrefix(line, "INFO ") {
+		err = fmt.Errorf("unexpected greeting from NATS: %q", strings.TrimSpace(line))
+	}
+	if err == nil {
+		err = n.handshake(conn)
+	}
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (n *NATS) handshake(conn *natsConn) error {
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     Source,
+		"lang":     "go",
+		"protocol": 0,
+	}
+	if n.user != "" {
+		options["user"], options["pass"] = n.user, n.pass
+	}
+	if n.token != "" {
+		options["auth_token"] = n.token
+	}
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return err
+	}
+	return conn.pong()
+}
+
+func (n *NATS) publish(ctx context.Context, msg Message) error {
+	if !validSubject(msg.Topic) {
+		return fmt.Errorf("invalid subject %q", msg.Topic)
+	}
+	n.deadline(ctx, n.conn)
+	w := bufio.NewWriter(n.conn)
+	fmt.Fprintf(w, "PUB %s %d\r\n", msg.Topic, len(msg.Payload))
+	w.Write(msg.Payload)
+	w.WriteString("\r\nPING\r\n")
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return n.conn.pong()
+}
+
+// pong reads until the server answers the PING, it answers the PINGs of the
+// server on the way.
+func (c *natsConn) pong() error {
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err = c.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return natsError(line)
+		}
+	}
+}
+
+func (n *NATS) deadline(ctx context.Context, conn net.Conn) {
+	deadline := time.Now().Add(n.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+}
+
+func natsError(line string) error {
+	return errors.New("NATS: " + strings.Trim(strings.TrimSpace(line[4:]), "'"))
+}
+
+func validSubject(subject string) bool {
+	return subject != "" && !strings.ContainsAny(subject, " \t\r\n")
+}
@@ -0,0 +1,74 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/events"
+)
+
+// queryOutbox keeps the events of the product service in the outbox table
+// until the relay has published them.
+type queryOutbox struct {
+	repo repository.TxQuerier
+}
+
+func NewOutbox(repo repository.TxQuerier) *queryOutbox {
+	return &queryOutbox{repo: repo}
+}
+
+func (o *queryOutbox) Relay(ctx context.Context, limit int, publish func(ctx context.Context, msg Message) error) (int, error) {
+	published := 0
+	var publishErr error
+	// a failed message is recorded and the transaction still committed, so
+	// the ones before it are not sent again
+	err := o.repo.ExecTx(ctx, func(q repository.Querier) error {
+		pending, err := q.ListPendingOutboxEvents(ctx, repository.ListPendingOutboxEventsParams{
+			Service: Source,
+			Limit:   int32(limit),
+		})
+		if err != nil {
+			return err
+		}
+		for _, row := range pending {
+			msg := Message{ID: row.ID, Topic: row.Topic, Key: row.PartitionKey, Payload: row.Payload}
+			if publishErr = publish(ctx, msg); publishErr != nil {
+				parked, err := q.MarkOutboxEventFailed(ctx, repository.MarkOutboxEventFailedParams{
+					ID:          row.ID,
+					LastError:   sql.NullString{String: publishErr.Error(), Valid: true},
+					MaxAttempts: MaxAttempts,
+				})
+				if parked {
+					log.Printf("parked outbox event %d on %s after %d attempts: %v", row.ID, row.Topic, MaxAttempts, publishErr)
+				}
+				return err
+			}
+			if err = q.MarkOutboxEventPublished(ctx, row.ID); err != nil {
+				return err
+			}
+			published++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return published, publishErr
+}
+
+// Enqueue writes an event to the outbox with q, call it inside the
+// transaction of the change the event describes.
+func Enqueue(ctx context.Context, q repository.Querier, envelope *events.Envelope) error {
+	msg, err := Encode(envelope)
+	if err != nil {
+		return err
+	}
+	return q.InsertOutboxEvent(ctx, repository.InsertOutboxEventParams{
+		Service:      Source,
+		Topic:        msg.Topic,
+		PartitionKey: msg.Key,
+		Payload:      msg.Payload,
+	})
+}
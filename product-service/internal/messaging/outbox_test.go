@@ -0,0 +1,68 @@
+package messaging_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/ryanpujo/product-service/internal/messaging"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// querier only answers the outbox queries, the embedded interface panics on
+// any other query.
+type querier struct {
+	repository.Querier
+	mock.Mock
+}
+
+func (q *querier) ExecTx(ctx context.Context, fn func(q repository.Querier) error) error {
+	return fn(q)
+}
+
+func (q *querier) ListPendingOutboxEvents(ctx context.Context, arg repository.ListPendingOutboxEventsParams) ([]repository.ListPendingOutboxEventsRow, error) {
+	args := q.Called(arg)
+	return args.Get(0).([]repository.ListPendingOutboxEventsRow), args.Error(1)
+}
+
+func (q *querier) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	return q.Called(id).Error(0)
+}
+
+func (q *querier) MarkOutboxEventFailed(ctx context.Context, arg repository.MarkOutboxEventFailedParams) (bool, error) {
+	args := q.Called(arg)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestOutbox(t *testing.T) {
+	q := &querier{}
+	q.On("ListPendingOutboxEvents", repository.ListPendingOutboxEventsParams{Service: messaging.Source, Limit: 10}).
+		Return([]repository.ListPendingOutboxEventsRow{
+			{ID: 1, Topic: messaging.TopicOrderPlaced, PartitionKey: "12"},
+			{ID: 2, Topic: messaging.TopicStockAdjusted, PartitionKey: "1"},
+			{ID: 3, Topic: messaging.TopicOrderPlaced, PartitionKey: "13"},
+		}, nil).Once()
+	q.On("MarkOutboxEventPublished", int64(1)).Return(nil).Once()
+	q.On("MarkOutboxEventFailed", repository.MarkOutboxEventFailedParams{
+		ID:          2,
+		LastError:   sql.NullString{String: "bus is down", Valid: true},
+		MaxAttempts: messaging.MaxAttempts,
+	}).Return(false, nil).Once()
+
+	var sent []int64
+	published, err := messaging.NewOutbox(q).Relay(context.Background(), 10, func(ctx context.Context, msg messaging.Message) error {
+		if msg.ID == 2 {
+			return errors.New("bus is down")
+		}
+		sent = append(sent, msg.ID)
+		return nil
+	})
+	require.EqualError(t, err, "bus is down")
+	require.Equal(t, 1, published)
+	// the messages after a failed one wait, so the order is kept
+	require.Equal(t, []int64{1}, sent)
+	q.AssertExpectations(t)
+}
@@ -0,0 +1,161 @@
+// Package money represents monetary amounts as integer minor units with an
+// ISO 4217 currency, and converts them to and from the decimal strings stored
+// in numeric columns without going through floating point.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrNegativeAmount   = errors.New("amount must not be negative")
+	ErrPrecisionLoss    = errors.New("amount cannot be represented without losing precision")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrOutOfRange       = errors.New("amount out of range")
+)
+
+// exponents holds the number of minor unit digits of the supported currencies.
+// Currencies not listed here are rejected.
+var exponents = map[string]int{
+	"AUD": 2, "BHD": 3, "CAD": 2, "CHF": 2, "CNY": 2, "EUR": 2, "GBP": 2,
+	"HKD": 2, "IDR": 2, "INR": 2, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3,
+	"MYR": 2, "NZD": 2, "OMR": 3, "PHP": 2, "SGD": 2, "THB": 2, "TND": 3,
+	"USD": 2, "VND": 0,
+}
+
+// Money is an amount of minor units (cents for USD, yen for JPY) in a currency.
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+// Exponent returns the number of minor unit digits used by currency.
+func Exponent(currency string) (int, error) {
+	exp, ok := exponents[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return exp, nil
+}
+
+// New validates the currency and returns minor units of it.
+func New(minor int64, currency string) (Money, error) {
+	if _, err := Exponent(currency); err != nil {
+		return Money{}, err
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// Parse reads an exact decimal string such as "1250.50" in the given currency.
+// Digits beyond the currency's exponent are only accepted when they are zero.
+func Parse(amount, currency string) (Money, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok || strings.ContainsAny(amount, "eE/") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	minor := r.Mul(r, pow10(exp))
+	if !minor.IsInt() {
+		return Money{}, fmt.Errorf("%w: %s %s", ErrPrecisionLoss, amount, currency)
+	}
+	if !minor.Num().IsInt64() {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	return Money{Minor: minor.Num().Int64(), Currency: currency}, nil
+}
+
+// Decimal formats m with exactly scale fractional digits, as expected by a
+// numeric(p, scale) column. It fails instead of rounding when the currency has
+// more minor digits than the column can hold and some of them are non-zero.
+func (m Money) Decimal(scale int) (string, error) {
+	exp, err := Exponent(m.Currency)
+	if err != nil {
+		return "", err
+	}
+	r := new(big.Rat).SetFrac(big.NewInt(m.Minor), pow10(exp).Num())
+	scaled := new(big.Rat).Mul(r, pow10(scale))
+	if !scaled.IsInt() {
+		return "", fmt.Errorf("%w: %s to %d decimal places", ErrPrecisionLoss, m, scale)
+	}
+	return r.FloatString(scale), nil
+}
+
+// Numeric formats m like Decimal for a numeric(precision, scale) column and
+// fails when the amount has more integer digits than the column holds.
+func (m Money) Numeric(precision, scale int) (string, error) {
+	decimal, err := m.Decimal(scale)
+	if err != nil {
+		return "", err
+	}
+	exp, _ := Exponent(m.Currency)
+	limit := new(big.Rat).Mul(pow10(precision-scale), pow10(exp)).Num()
+	if new(big.Int).Abs(big.NewInt(m.Minor)).Cmp(limit) >= 0 {
+		return "", fmt.Errorf("%w: %s does not fit numeric(%d, %d)", ErrOutOfRange, m, precision, scale)
+	}
+	return decimal, nil
+}
+
+// String formats m using the currency's own exponent, e.g. "12.50 USD".
+func (m Money) String() string {
+	exp, ok := exponents[m.Currency]
+	if !ok {
+		return fmt.Sprintf("%d %s", m.Minor, m.Currency)
+	}
+	r := new(big.Rat).SetFrac(big.NewInt(m.Minor), pow10(exp).Num())
+	return r.FloatString(exp) + " " + m.Currency
+}
+
+// Add returns m+o. Both amounts must share a currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return m.result(new(big.Int).Add(big.NewInt(m.Minor), big.NewInt(o.Minor)))
+}
+
+// Mul returns m multiplied by an integer quantity.
+func (m Money) Mul(quantity int64) (Money, error) {
+	return m.result(new(big.Int).Mul(big.NewInt(m.Minor), big.NewInt(quantity)))
+}
+
+// MulRat returns m*num/den rounded to the nearest minor unit, with ties going
+// to the even unit (banker's rounding) so repeated splits don't drift upwards.
+func (m Money) MulRat(num, den int64) (Money, error) {
+	r := new(big.Rat).SetFrac(new(big.Int).Mul(big.NewInt(m.Minor), big.NewInt(num)), big.NewInt(den))
+	return m.result(roundHalfEven(r))
+}
+
+// result returns minor units of the currency of m, failing instead of
+// wrapping around when they do not fit an int64.
+func (m Money) result(minor *big.Int) (Money, error) {
+	if !minor.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s minor units of %s", ErrOutOfRange, minor, m.Currency)
+	}
+	return Money{Minor: minor.Int64(), Currency: m.Currency}, nil
+}
+
+func roundHalfEven(r *big.Rat) *big.Int {
+	q, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return q
+	}
+	// compare 2*|rem| with the denominator to find out which side of .5 we are
+	twice := new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2))
+	cmp := twice.Cmp(r.Denom())
+	if cmp > 0 || (cmp == 0 && q.Bit(0) == 1) {
+		return q.Add(q, big.NewInt(int64(r.Num().Sign())))
+	}
+	return q
+}
+
+func pow10(n int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+}
@@ -0,0 +1,128 @@
+package money_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ryanpujo/product-service/internal/money"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testTable := map[string]struct {
+		amount   string
+		currency string
+		expected int64
+		err      error
+	}{
+		"two decimals":        {amount: "1250.50", currency: "USD", expected: 125050},
+		"no decimals":         {amount: "2000", currency: "IDR", expected: 200000},
+		"zero exponent":       {amount: "1500", currency: "JPY", expected: 1500},
+		"trailing zeros":      {amount: "1500.00", currency: "JPY", expected: 1500},
+		"three decimals":      {amount: "1.125", currency: "KWD", expected: 1125},
+		"too many decimals":   {amount: "10.005", currency: "USD", err: money.ErrPrecisionLoss},
+		"fraction of yen":     {amount: "1500.5", currency: "JPY", err: money.ErrPrecisionLoss},
+		"unknown currency":    {amount: "1", currency: "XXX", err: money.ErrUnknownCurrency},
+		"not a number":        {amount: "ten", currency: "USD", err: money.ErrInvalidAmount},
+		"exponent notation":   {amount: "1e3", currency: "USD", err: money.ErrInvalidAmount},
+		"fraction notation":   {amount: "1/3", currency: "USD", err: money.ErrInvalidAmount},
+		"too large for minor": {amount: "99999999999999999999", currency: "USD", err: money.ErrInvalidAmount},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			actual, err := money.Parse(v.amount, v.currency)
+			if v.err != nil {
+				require.ErrorIs(t, err, v.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, money.Money{Minor: v.expected, Currency: v.currency}, actual)
+		})
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	testTable := map[string]struct {
+		money    money.Money
+		expected string
+		err      error
+	}{
+		"cents":              {money: money.Money{Minor: 125050, Currency: "USD"}, expected: "1250.50"},
+		"yen":                {money: money.Money{Minor: 1500, Currency: "JPY"}, expected: "1500.00"},
+		"dinar without fils": {money: money.Money{Minor: 1250, Currency: "KWD"}, expected: "1.25"},
+		"dinar with fils":    {money: money.Money{Minor: 1255, Currency: "KWD"}, err: money.ErrPrecisionLoss},
+		"negative":           {money: money.Money{Minor: -5, Currency: "USD"}, expected: "-0.05"},
+		"unknown currency":   {money: money.Money{Minor: 5, Currency: "ABC"}, err: money.ErrUnknownCurrency},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			actual, err := v.money.Decimal(2)
+			if v.err != nil {
+				require.ErrorIs(t, err, v.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, v.expected, actual)
+		})
+	}
+}
+
+func TestMulRat(t *testing.T) {
+	testTable := map[string]struct {
+		minor    int64
+		num, den int64
+		expected int64
+	}{
+		"exact":             {minor: 1000, num: 15, den: 100, expected: 150},
+		"round down":        {minor: 1001, num: 1, den: 3, expected: 334},
+		"half to even down": {minor: 25, num: 1, den: 10, expected: 2},
+		"half to even up":   {minor: 35, num: 1, den: 10, expected: 4},
+		"negative half":     {minor: -25, num: 1, den: 10, expected: -2},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			actual, err := money.Money{Minor: v.minor, Currency: "USD"}.MulRat(v.num, v.den)
+			require.NoError(t, err)
+			require.Equal(t, v.expected, actual.Minor)
+		})
+	}
+
+	// the product is taken in full, only a result outside int64 fails
+	half, err := money.Money{Minor: math.MaxInt64, Currency: "USD"}.MulRat(5000, 10000)
+	require.NoError(t, err)
+	require.Equal(t, int64(math.MaxInt64/2+1), half.Minor)
+	_, err = money.Money{Minor: math.MaxInt64, Currency: "USD"}.MulRat(3, 2)
+	require.ErrorIs(t, err, money.ErrOutOfRange)
+}
+
+func TestMul(t *testing.T) {
+	total, err := money.Money{Minor: 1250, Currency: "USD"}.Mul(3)
+	require.NoError(t, err)
+	require.Equal(t, int64(3750), total.Minor)
+
+	_, err = money.Money{Minor: math.MaxInt64 / 2, Currency: "USD"}.Mul(3)
+	require.ErrorIs(t, err, money.ErrOutOfRange)
+}
+
+func TestNumeric(t *testing.T) {
+	decimal, err := money.Money{Minor: 999999999999, Currency: "USD"}.Numeric(12, 2)
+	require.NoError(t, err)
+	require.Equal(t, "9999999999.99", decimal)
+
+	_, err = money.Money{Minor: 1000000000000, Currency: "USD"}.Numeric(12, 2)
+	require.ErrorIs(t, err, money.ErrOutOfRange)
+	_, err = money.Money{Minor: -1000000000000, Currency: "USD"}.Numeric(12, 2)
+	require.ErrorIs(t, err, money.ErrOutOfRange)
+}
+
+func TestAdd(t *testing.T) {
+	sum, err := money.Money{Minor: 150, Currency: "USD"}.Add(money.Money{Minor: 250, Currency: "USD"})
+	require.NoError(t, err)
+	require.Equal(t, int64(400), sum.Minor)
+
+	_, err = money.Money{Minor: 150, Currency: "USD"}.Add(money.Money{Minor: 250, Currency: "EUR"})
+	require.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	_, err = money.Money{Minor: math.MaxInt64, Currency: "USD"}.Add(money.Money{Minor: 1, Currency: "USD"})
+	require.ErrorIs(t, err, money.ErrOutOfRange)
+}
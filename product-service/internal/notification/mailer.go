@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+)
+
+// DefaultMailBatch is how many emails a mailer takes from the queue at a
+// time.
+const DefaultMailBatch = 50
+
+// MaxMailAttempts is how often an email is tried before the mailer parks it,
+// a parked email keeps its last error and is not tried again.
+const MaxMailAttempts = 10
+
+// EmailQueue keeps the emails in the outgoing_emails table, written in the
+// transaction of the notification. A Mailer sends them once it committed, so
+// a notification that is rolled back sends no email.
+type EmailQueue struct{}
+
+func (EmailQueue) Send(ctx context.Context, q repository.Querier, msg Message) error {
+	return q.QueueEmail(ctx, repository.QueueEmailParams{
+		UserID:  msg.UserID,
+		Email:   msg.Email,
+		Kind:    string(msg.Kind),
+		Subject: msg.Subject,
+		Body:    msg.Body,
+	})
+}
+
+// Mailer sends the queued emails with Sender, such as SMTP.
+type Mailer struct {
+	Repo   repository.TxQuerier
+	Sender Sender
+	Batch  int
+}
+
+func NewMailer(repo repository.TxQuerier, sender Sender) *Mailer {
+	return &Mailer{Repo: repo, Sender: sender, Batch: DefaultMailBatch}
+}
+
+// Flush sends until the queue is empty or an email fails, it returns how
+// many were sent.
+func (m *Mailer) Flush(ctx context.Context) (int, error) {
+	sent := 0
+	for {
+		n, err := m.Deliver(ctx)
+		sent += n
+		if err != nil || n < m.Batch {
+			return sent, err
+		}
+	}
+}
+
+// Deliver sends up to Batch of the oldest queued emails and returns how many
+// were sent. An email that fails is tried again on the next call and does not
+// hold back the others, the last error is returned. An email whose sending is
+// not recorded, when the commit fails, is sent again.
+func (m *Mailer) Deliver(ctx context.Context) (int, error) {
+	sent := 0
+	var sendErr error
+	err := m.Repo.ExecTx(ctx, func(q repository.Querier) error {
+		pending, err := q.ListPendingEmails(ctx, int32(m.Batch))
+		if err != nil {
+			return err
+		}
+		for _, email := range pending {
+			msg := Message{
+				UserID:  email.UserID,
+				Email:   email.Email,
+				Kind:    Kind(email.Kind),
+				Subject: email.Subject,
+				Body:    email.Body,
+			}
+			if err := m.Sender.Send(ctx, q, msg); err != nil {
+				sendErr = err
+				parked, err := q.MarkEmailFailed(ctx, repository.MarkEmailFailedParams{
+					ID:          email.ID,
+					LastError:   sql.NullString{String: sendErr.Error(), Valid: true},
+					MaxAttempts: MaxMailAttempts,
+				})
+				if err != nil {
+					return err
+				}
+				if parked {
+					log.Printf("parked %s email %d after %d attempts: %v", email.Kind, email.ID, MaxMailAttempts, sendErr)
+				}
+				continue
+			}
+			if err = q.MarkEmailSent(ctx, email.ID); err != nil {
+				return err
+			}
+			sent++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sent, sendErr
+}
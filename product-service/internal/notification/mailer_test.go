@@ -0,0 +1,93 @@
+package notification_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/ryanpujo/product-service/internal/notification"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func (q *querier) ExecTx(ctx context.Context, fn func(q repository.Querier) error) error {
+	return fn(q)
+}
+
+func (q *querier) QueueEmail(ctx context.Context, arg repository.QueueEmailParams) error {
+	return q.Called(arg).Error(0)
+}
+
+func (q *querier) ListPendingEmails(ctx context.Context, limit int32) ([]repository.OutgoingEmail, error) {
+	args := q.Called(limit)
+	return args.Get(0).([]repository.OutgoingEmail), args.Error(1)
+}
+
+func (q *querier) MarkEmailSent(ctx context.Context, id int64) error {
+	return q.Called(id).Error(0)
+}
+
+func (q *querier) MarkEmailFailed(ctx context.Context, arg repository.MarkEmailFailedParams) (bool, error) {
+	args := q.Called(arg)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestEmailQueue(t *testing.T) {
+	q := &querier{}
+	q.On("QueueEmail", repository.QueueEmailParams{
+		UserID:  5,
+		Email:   "jane@mail.com",
+		Kind:    string(notification.KindWelcome),
+		Subject: "Welcome",
+		Body:    "Hi Jane",
+	}).Return(nil).Once()
+
+	err := notification.EmailQueue{}.Send(context.Background(), q, notification.Message{
+		UserID:  5,
+		Email:   "jane@mail.com",
+		Kind:    notification.KindWelcome,
+		Subject: "Welcome",
+		Body:    "Hi Jane",
+	})
+	require.NoError(t, err)
+	q.AssertExpectations(t)
+}
+
+func TestMailer(t *testing.T) {
+	q := &querier{}
+	q.On("ListPendingEmails", int32(notification.DefaultMailBatch)).Return([]repository.OutgoingEmail{
+		{ID: 1, UserID: 5, Email: "down@mail.com", Kind: string(notification.KindWelcome)},
+		{ID: 2, UserID: 6, Email: "jane@mail.com", Kind: string(notification.KindOrderPlaced), Subject: "Order #12"},
+	}, nil).Once()
+	q.On("MarkEmailFailed", repository.MarkEmailFailedParams{
+		ID:          1,
+		LastError:   sql.NullString{String: "mailbox is down", Valid: true},
+		MaxAttempts: notification.MaxMailAttempts,
+	}).Return(true, nil).Once()
+	q.On("MarkEmailSent", int64(2)).Return(nil).Once()
+
+	s := &failingSender{to: "down@mail.com", err: errors.New("mailbox is down")}
+	sent, err := notification.NewMailer(q, s).Deliver(context.Background())
+	require.EqualError(t, err, "mailbox is down")
+	// a failing email does not hold back the others
+	require.Equal(t, 1, sent)
+	require.Len(t, s.sent, 1)
+	require.Equal(t, "Order #12", s.sent[0].Subject)
+	q.AssertExpectations(t)
+}
+
+// failingSender fails the messages to one address and keeps the others.
+type failingSender struct {
+	to   string
+	err  error
+	sent []notification.Message
+}
+
+func (s *failingSender) Send(ctx context.Context, q repository.Querier, msg notification.Message) error {
+	if msg.Email == s.to {
+		return s.err
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
@@ -0,0 +1,188 @@
+// Package notification tells users about their account, orders and wishlist.
+// A notification is rendered from the template of its kind in the language of
+// the user and sent on every channel the user keeps on for its category:
+// email and the in-app inbox.
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+)
+
+// Category groups the kinds of notification a user turns on and off
+// together.
+type Category string
+
+const (
+	CategoryAccount  Category = "account"
+	CategoryOrders   Category = "orders"
+	CategoryWishlist Category = "wishlist"
+)
+
+// Categories are the categories a user has preferences for.
+var Categories = []Category{CategoryAccount, CategoryOrders, CategoryWishlist}
+
+// Kind names a notification and its template.
+type Kind string
+
+const (
+	KindWelcome        Kind = "welcome"
+	KindOrderPlaced    Kind = "order_placed"
+	KindOrderCancelled Kind = "order_cancelled"
+	KindPriceDropped   Kind = "price_dropped"
+	KindBackInStock    Kind = "back_in_stock"
+)
+
+var categories = map[Kind]Category{
+	KindWelcome:        CategoryAccount,
+	KindOrderPlaced:    CategoryOrders,
+	KindOrderCancelled: CategoryOrders,
+	KindPriceDropped:   CategoryWishlist,
+	KindBackInStock:    CategoryWishlist,
+}
+
+// Channel is a way of reaching a user.
+type Channel string
+
+const (
+	ChannelEmail Channel = "email"
+	ChannelInApp Channel = "in_app"
+)
+
+// channels is the order notifications are sent in.
+var channels = []Channel{ChannelInApp, ChannelEmail}
+
+var (
+	ErrUnknownKind     = errors.New("unknown notification kind")
+	ErrUnknownCategory = errors.New("unknown notification category")
+)
+
+// Notification is something to tell a user, Data is what the template of
+// the kind shows.
+type Notification struct {
+	UserID int32
+	Kind   Kind
+	Data   interface{}
+}
+
+// Message is a notification rendered for its recipient.
+type Message struct {
+	UserID  int32
+	Email   string
+	Kind    Kind
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages on a channel. q is the transaction the
+// notification is sent in, a sender that writes to the database uses it. A
+// sender that reaches outside the database, such as SMTP, goes behind the
+// EmailQueue so nothing leaves before the transaction commits.
+type Sender interface {
+	Send(ctx context.Context, q repository.Querier, msg Message) error
+}
+
+// Service renders notifications and sends them on the channels the
+// recipient wants them on. A channel without a sender is skipped.
+type Service struct {
+	Templates *Templates
+	Senders   map[Channel]Sender
+}
+
+func NewService(templates *Templates, senders map[Channel]Sender) *Service {
+	return &Service{Templates: templates, Senders: senders}
+}
+
+// Notify sends n with q. Nothing is sent to a user that is deleted or
+// deactivated, or has turned off every channel for the category of n.
+func (s *Service) Notify(ctx context.Context, q repository.Querier, n Notification) error {
+	category, ok := categories[n.Kind]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownKind, n.Kind)
+	}
+	recipient, err := q.GetNotificationRecipient(ctx, n.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	preference, err := Preference(ctx, q, n.UserID, category)
+	if err != nil {
+		return err
+	}
+	if !preference.Email && !preference.InApp {
+		return nil
+	}
+
+	name := recipient.DisplayName
+	if name == "" {
+		name = recipient.Username.String
+	}
+	subject, body, err := s.Templates.Render(n.Kind, recipient.Locale, map[string]interface{}{
+		"Name": name,
+		"Data": n.Data,
+	})
+	if err != nil {
+		return err
+	}
+	msg := Message{
+		UserID:  n.UserID,
+		Email:   recipient.Email.String,
+		Kind:    n.Kind,
+		Subject: subject,
+		Body:    body,
+	}
+	for _, channel := range channels {
+		sender := s.Senders[channel]
+		if sender == nil || !enabled(preference, channel) {
+			continue
+		}
+		if err = sender.Send(ctx, q, msg); err != nil {
+			return fmt.Errorf("%s: %w", channel, err)
+		}
+	}
+	return nil
+}
+
+// Preference returns the channels the user keeps on for the category, every
+// channel is on until the user changes it.
+func Preference(ctx context.Context, q repository.Querier, userID int32, category Category) (repository.NotificationPreference, error) {
+	preference, err := q.GetNotificationPreference(ctx, repository.GetNotificationPreferenceParams{
+		UserID:   userID,
+		Category: string(category),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultPreference(userID, category), nil
+	}
+	return preference, err
+}
+
+// DefaultPreference is the preference of a user that has not changed it.
+func DefaultPreference(userID int32, category Category) repository.NotificationPreference {
+	return repository.NotificationPreference{UserID: userID, Category: string(category), Email: true, InApp: true}
+}
+
+// ValidCategory tells whether c is one of Categories.
+func ValidCategory(c string) bool {
+	for _, category := range Categories {
+		if string(category) == c {
+			return true
+		}
+	}
+	return false
+}
+
+func enabled(preference repository.NotificationPreference, channel Channel) bool {
+	switch channel {
+	case ChannelEmail:
+		return preference.Email
+	case ChannelInApp:
+		return preference.InApp
+	}
+	return false
+}
@@ -0,0 +1,340 @@
+package notification_test
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ryanpujo/product-service/internal/notification"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// querier only answers the notification queries, the embedded interface
+// panics on any other query.
+type querier struct {
+	repository.Querier
+	mock.Mock
+}
+
+func (q *querier) GetNotificationRecipient(ctx context.Context, id int32) (repository.GetNotificationRecipientRow, error) {
+	args := q.Called(id)
+	return args.Get(0).(repository.GetNotificationRecipientRow), args.Error(1)
+}
+
+func (q *querier) GetNotificationPreference(ctx context.Context, arg repository.GetNotificationPreferenceParams) (repository.NotificationPreference, error) {
+	args := q.Called(arg)
+	return args.Get(0).(repository.NotificationPreference), args.Error(1)
+}
+
+func (q *querier) CreateNotification(ctx context.Context, arg repository.CreateNotificationParams) (repository.Notification, error) {
+	args := q.Called(arg)
+	return args.Get(0).(repository.Notification), args.Error(1)
+}
+
+// sender keeps the messages it is given.
+type sender struct {
+	sent []notification.Message
+	err  error
+}
+
+func (s *sender) Send(ctx context.Context, q repository.Querier, msg notification.Message) error {
+	s.sent = append(s.sent, msg)
+	return s.err
+}
+
+type order struct {
+	OrderId         int64
+	Items           []int
+	TotalMinorUnits int64
+	Currency        string
+	CouponCode      string
+}
+
+func TestRender(t *testing.T) {
+	templates, err := notification.DefaultTemplates()
+	require.NoError(t, err)
+	data := map[string]interface{}{
+		"Name": "Jane",
+		"Data": order{OrderId: 12, Items: []int{1, 2}, TotalMinorUnits: 125050, Currency: "USD", CouponCode: "SPRING"},
+	}
+	testTable := map[string]struct {
+		locale  string
+		subject string
+		body    []string
+	}{
+		"succes call": {
+			locale:  "en",
+			subject: "Order #12 is confirmed",
+			body:    []string{"Hi Jane,", "Items: 2", "Total: 1,250.50 USD", "Coupon: SPRING"},
+		},
+		"region of a locale": {
+			locale:  "id-ID",
+			subject: "Pesanan #12 sudah dikonfirmasi",
+			body:    []string{"Halo Jane,", "Total: 1.250,50 USD"},
+		},
+		"untranslated locale": {
+			locale:  "fr",
+			subject: "Order #12 is confirmed",
+		},
+		"no locale": {
+			subject: "Order #12 is confirmed",
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			subject, body, err := templates.Render(notification.KindOrderPlaced, v.locale, data)
+
+			require.NoError(t, err)
+			require.Equal(t, v.subject, subject)
+			for _, line := range v.body {
+				require.Contains(t, body, line)
+			}
+			require.True(t, strings.HasSuffix(body, "\n"))
+		})
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	welcome := &fstest.MapFile{Data: []byte(`{{define "subject"}}Hi {{.Name}}{{end}}{{define "body"}}Welcome{{end}}`)}
+	full := fstest.MapFS{}
+	for _, kind := range []string{"welcome", "order_placed", "order_cancelled", "price_dropped", "back_in_stock"} {
+		full["en/"+kind+".tmpl"] = welcome
+	}
+
+	t.Run("falls back per kind", func(t *testing.T) {
+		files := fstest.MapFS{"id/welcome.tmpl": &fstest.MapFile{Data: []byte(`{{define "subject"}}Halo {{.Name}}{{end}}{{define "body"}}Selamat datang{{end}}`)}}
+		for name, file := range full {
+			files[name] = file
+		}
+		templates, err := notification.LoadTemplates(files)
+		require.NoError(t, err)
+
+		subject, _, err := templates.Render(notification.KindWelcome, "id", map[string]string{"Name": "Jane"})
+		require.NoError(t, err)
+		require.Equal(t, "Halo Jane", subject)
+		subject, _, err = templates.Render(notification.KindOrderPlaced, "id", map[string]string{"Name": "Jane"})
+		require.NoError(t, err)
+		require.Equal(t, "Hi Jane", subject)
+	})
+	t.Run("missing default", func(t *testing.T) {
+		_, err := notification.LoadTemplates(fstest.MapFS{"en/welcome.tmpl": welcome})
+		require.ErrorContains(t, err, "no en template")
+	})
+	t.Run("unknown kind", func(t *testing.T) {
+		files := fstest.MapFS{"en/newsletter.tmpl": welcome}
+		_, err := notification.LoadTemplates(files)
+		require.ErrorIs(t, err, notification.ErrUnknownKind)
+	})
+	t.Run("no body", func(t *testing.T) {
+		files := fstest.MapFS{"en/welcome.tmpl": &fstest.MapFile{Data: []byte(`{{define "subject"}}Hi{{end}}`)}}
+		_, err := notification.LoadTemplates(files)
+		require.ErrorContains(t, err, "must define a subject and a body")
+	})
+}
+
+func TestNotify(t *testing.T) {
+	recipient := repository.GetNotificationRecipientRow{
+		ID:          5,
+		Username:    sql.NullString{String: "jane", Valid: true},
+		Email:       sql.NullString{String: "jane@mail.com", Valid: true},
+		DisplayName: "Jane Doe",
+		Locale:      "en",
+	}
+	preference := repository.GetNotificationPreferenceParams{UserID: 5, Category: "account"}
+	testTable := map[string]struct {
+		arrange func(q *querier)
+		inApp   int
+		email   int
+		assert  func(t *testing.T, email *sender, err error)
+	}{
+		"succes call": {
+			arrange: func(q *querier) {
+				q.On("GetNotificationRecipient", int32(5)).Return(recipient, nil).Once()
+				q.On("GetNotificationPreference", preference).Return(repository.NotificationPreference{}, sql.ErrNoRows).Once()
+			},
+			inApp: 1,
+			email: 1,
+			assert: func(t *testing.T, email *sender, err error) {
+				require.NoError(t, err)
+				msg := email.sent[0]
+				require.Equal(t, "jane@mail.com", msg.Email)
+				require.Equal(t, "Welcome to RPApp, Jane Doe", msg.Subject)
+			},
+		},
+		"in-app only": {
+			arrange: func(q *querier) {
+				q.On("GetNotificationRecipient", int32(5)).Return(recipient, nil).Once()
+				q.On("GetNotificationPreference", preference).Return(repository.NotificationPreference{UserID: 5, Category: "account", InApp: true}, nil).Once()
+			},
+			inApp: 1,
+			assert: func(t *testing.T, email *sender, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"everything off": {
+			arrange: func(q *querier) {
+				q.On("GetNotificationRecipient", int32(5)).Return(recipient, nil).Once()
+				q.On("GetNotificationPreference", preference).Return(repository.NotificationPreference{UserID: 5, Category: "account"}, nil).Once()
+			},
+			assert: func(t *testing.T, email *sender, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"gone user": {
+			arrange: func(q *querier) {
+				q.On("GetNotificationRecipient", int32(5)).Return(repository.GetNotificationRecipientRow{}, sql.ErrNoRows).Once()
+			},
+			assert: func(t *testing.T, email *sender, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"fail call": {
+			arrange: func(q *querier) {
+				q.On("GetNotificationRecipient", int32(5)).Return(repository.GetNotificationRecipientRow{}, errors.New("db is down")).Once()
+			},
+			assert: func(t *testing.T, email *sender, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			q := &querier{}
+			v.arrange(q)
+			templates, err := notification.DefaultTemplates()
+			require.NoError(t, err)
+			inApp, email := &sender{}, &sender{}
+			service := notification.NewService(templates, map[notification.Channel]notification.Sender{
+				notification.ChannelInApp: inApp,
+				notification.ChannelEmail: email,
+			})
+
+			err = service.Notify(context.Background(), q, notification.Notification{UserID: 5, Kind: notification.KindWelcome})
+
+			require.Len(t, inApp.sent, v.inApp)
+			require.Len(t, email.sent, v.email)
+			v.assert(t, email, err)
+			q.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNotifyUnknownKind(t *testing.T) {
+	service := notification.NewService(nil, nil)
+
+	err := service.Notify(context.Background(), &querier{}, notification.Notification{UserID: 5, Kind: "newsletter"})
+
+	require.ErrorIs(t, err, notification.ErrUnknownKind)
+}
+
+func TestInbox(t *testing.T) {
+	q := &querier{}
+	q.On("CreateNotification", repository.CreateNotificationParams{UserID: 5, Kind: "welcome", Subject: "Hi", Body: "Welcome\n"}).
+		Return(repository.Notification{ID: 1}, nil).Once()
+
+	err := notification.Inbox{}.Send(context.Background(), q, notification.Message{
+		UserID:  5,
+		Email:   "jane@mail.com",
+		Kind:    notification.KindWelcome,
+		Subject: "Hi",
+		Body:    "Welcome\n",
+	})
+
+	require.NoError(t, err)
+	q.AssertExpectations(t)
+}
+
+// smtpServer accepts one email and hands its data to the returned channel.
+func smtpServer(t *testing.T) (string, <-chan string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+	data := make(chan string, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		text.PrintfLine("220 localhost ready")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			switch verb := strings.ToUpper(strings.Fields(line)[0]); verb {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				text.PrintfLine("250 ok")
+			case "DATA":
+				text.PrintfLine("354 go ahead")
+				body, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				data <- string(body)
+				text.PrintfLine("250 queued")
+			case "QUIT":
+				text.PrintfLine("221 bye")
+				return
+			default:
+				text.PrintfLine("502 %s not implemented", verb)
+			}
+		}
+	}()
+	return lis.Addr().String(), data
+}
+
+func TestSMTP(t *testing.T) {
+	addr, data := smtpServer(t)
+	s, err := notification.NewSMTP("smtp://"+addr, "RPApp <no-reply@rpapp.io>")
+	require.NoError(t, err)
+
+	err = s.Send(context.Background(), nil, notification.Message{
+		UserID:  5,
+		Email:   "jane@mail.com",
+		Kind:    notification.KindWelcome,
+		Subject: "Selamat datang, Jöko\r\nBcc: everyone@mail.com",
+		Body:    "Halo Jöko,\n\nAkun kamu sudah siap.\n",
+	})
+	require.NoError(t, err)
+
+	email := <-data
+	header, body, ok := strings.Cut(email, "\n\n")
+	require.True(t, ok)
+	require.Contains(t, header, `From: "RPApp" <no-reply@rpapp.io>`)
+	require.Contains(t, header, "To: <jane@mail.com>")
+	require.Contains(t, header, "Subject: =?utf-8?q?Selamat_datang,_J=C3=B6ko_Bcc:_everyone@mail.com?=")
+	require.NotContains(t, header, "\nBcc:")
+	require.Contains(t, header, "Content-Type: text/plain; charset=utf-8")
+	reader := bufio.NewReader(strings.NewReader(body))
+	first, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "Halo J=C3=B6ko,\n", first)
+}
+
+func TestNewSMTP(t *testing.T) {
+	testTable := map[string]struct {
+		address string
+		from    string
+	}{
+		"wrong scheme": {address: "http://mail.rpapp.io", from: "no-reply@rpapp.io"},
+		"no host":      {address: "smtp://", from: "no-reply@rpapp.io"},
+		"bad sender":   {address: "smtp://mail.rpapp.io", from: "not an address"},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			_, err := notification.NewSMTP(v.address, v.from)
+
+			require.Error(t, err)
+		})
+	}
+}
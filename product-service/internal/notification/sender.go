@@ -0,0 +1,37 @@
+package notification
+
+import (
+	"context"
+	"log"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+)
+
+// Inbox keeps the messages in the notifications table, the user reads them
+// in the app.
+type Inbox struct{}
+
+func (Inbox) Send(ctx context.Context, q repository.Querier, msg Message) error {
+	_, err := q.CreateNotification(ctx, repository.CreateNotificationParams{
+		UserID:  msg.UserID,
+		Kind:    string(msg.Kind),
+		Subject: msg.Subject,
+		Body:    msg.Body,
+	})
+	return err
+}
+
+// LogSink writes the messages to Logger instead of sending them, it stands
+// in for email in development and tests. A nil Logger is the standard one.
+type LogSink struct {
+	Logger *log.Logger
+}
+
+func (s LogSink) Send(ctx context.Context, q repository.Querier, msg Message) error {
+	logger := s.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	logger.Printf("%s notification to %s: %s\n%s", msg.Kind, msg.Email, msg.Subject, msg.Body)
+	return nil
+}
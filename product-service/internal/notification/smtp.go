@@ -0,0 +1,132 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+)
+
+// DefaultSMTPTimeout bounds the whole exchange with the server for one
+// message.
+const DefaultSMTPTimeout = 10 * time.Second
+
+// SMTP sends the messages as plain text email. It switches to TLS when the
+// server offers STARTTLS, and only logs in once it has.
+type SMTP struct {
+	addr    string
+	host    string
+	user    string
+	pass    string
+	from    *mail.Address
+	timeout time.Duration
+}
+
+// NewSMTP takes the address of the server as smtp://[user:pass@]host:port
+// and the address the email is sent from, like "RPApp <no-reply@rpapp.io>".
+func NewSMTP(address, from string) (*SMTP, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "smtp" || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid SMTP address %q", address)
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", from, err)
+	}
+	s := &SMTP{addr: u.Host, host: u.Hostname(), from: sender, timeout: DefaultSMTPTimeout}
+	if u.Port() == "" {
+		s.addr = net.JoinHostPort(u.Hostname(), "587")
+	}
+	if u.User != nil {
+		s.user = u.User.Username()
+		s.pass, _ = u.User.Password()
+	}
+	return s, nil
+}
+
+func (s *SMTP) Send(ctx context.Context, q repository.Querier, msg Message) error {
+	to, err := mail.ParseAddress(msg.Email)
+	if err != nil {
+		return err
+	}
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.user != "" {
+		// PlainAuth refuses to send the password without TLS unless the
+		// server is on localhost
+		if err = c.Auth(smtp.PlainAuth("", s.user, s.pass, s.host)); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err = c.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if err = s.write(w, to, msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// write writes the headers and the quoted-printable body of the email.
+func (s *SMTP) write(w interface{ Write([]byte) (int, error) }, to *mail.Address, msg Message) error {
+	var header strings.Builder
+	fmt.Fprintf(&header, "From: %s\r\n", s.from)
+	fmt.Fprintf(&header, "To: %s\r\n", to)
+	// a subject is one line, whatever the template rendered
+	subject := strings.Join(strings.Fields(msg.Subject), " ")
+	fmt.Fprintf(&header, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&header, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	header.WriteString("MIME-Version: 1.0\r\n")
+	header.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	header.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	if _, err := w.Write([]byte(header.String())); err != nil {
+		return err
+	}
+	body := quotedprintable.NewWriter(w)
+	if _, err := body.Write([]byte(msg.Body)); err != nil {
+		return err
+	}
+	return body.Close()
+}
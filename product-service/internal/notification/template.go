@@ -0,0 +1,148 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/ryanpujo/product-service/internal/money"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+// DefaultLocale is used for users without a locale, or one there are no
+// templates for.
+var DefaultLocale = language.English
+
+// Templates keeps a template for every kind of notification in each language
+// there are translations for. A template file is templates/<locale>/<kind>.tmpl
+// and defines a "subject" and a "body".
+type Templates struct {
+	matcher language.Matcher
+	locales []language.Tag
+	byTag   map[language.Tag]map[Kind]*template.Template
+}
+
+// DefaultTemplates are the templates shipped with the service.
+func DefaultTemplates() (*Templates, error) {
+	files, err := fs.Sub(templateFiles, "templates")
+	if err != nil {
+		return nil, err
+	}
+	return LoadTemplates(files)
+}
+
+// LoadTemplates reads the templates of every locale directory of files. The
+// default locale must have a template for every kind, another locale falls
+// back to it for the kinds it does not translate.
+func LoadTemplates(files fs.FS) (*Templates, error) {
+	dirs, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+	t := &Templates{
+		locales: []language.Tag{DefaultLocale},
+		byTag:   map[language.Tag]map[Kind]*template.Template{},
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		tag, err := language.Parse(dir.Name())
+		if err != nil {
+			return nil, fmt.Errorf("template locale %q: %w", dir.Name(), err)
+		}
+		kinds, err := parseLocale(files, dir.Name(), tag)
+		if err != nil {
+			return nil, err
+		}
+		t.byTag[tag] = kinds
+		if tag != DefaultLocale {
+			t.locales = append(t.locales, tag)
+		}
+	}
+	for kind := range categories {
+		if t.byTag[DefaultLocale][kind] == nil {
+			return nil, fmt.Errorf("no %s template for %s", DefaultLocale, kind)
+		}
+	}
+	t.matcher = language.NewMatcher(t.locales)
+	return t, nil
+}
+
+func parseLocale(files fs.FS, dir string, tag language.Tag) (map[Kind]*template.Template, error) {
+	names, err := fs.Glob(files, path.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	printer := message.NewPrinter(tag)
+	funcs := template.FuncMap{
+		// money shows minor units of a currency the way the language writes
+		// numbers, like 1,250.50 USD or 1.250,50 USD.
+		"money": func(minor int64, currency string) (string, error) {
+			m, err := money.New(minor, currency)
+			if err != nil {
+				return "", err
+			}
+			exponent, _ := money.Exponent(currency)
+			// the printer only formats floats with decimals, the amount is
+			// shown and never computed with
+			amount := float64(m.Minor) / math.Pow10(exponent)
+			return printer.Sprintf("%v %s", number.Decimal(amount, number.Scale(exponent)), m.Currency), nil
+		},
+	}
+	kinds := make(map[Kind]*template.Template, len(names))
+	for _, name := range names {
+		kind := Kind(strings.TrimSuffix(path.Base(name), ".tmpl"))
+		if _, ok := categories[kind]; !ok {
+			return nil, fmt.Errorf("template %s: %w", name, ErrUnknownKind)
+		}
+		tmpl, err := template.New(path.Base(name)).Funcs(funcs).Option("missingkey=error").ParseFS(files, name)
+		if err != nil {
+			return nil, err
+		}
+		if tmpl.Lookup("subject") == nil || tmpl.Lookup("body") == nil {
+			return nil, fmt.Errorf("template %s must define a subject and a body", name)
+		}
+		kinds[kind] = tmpl
+	}
+	return kinds, nil
+}
+
+// Render returns the subject and body of a notification of the kind in the
+// language closest to locale.
+func (t *Templates) Render(kind Kind, locale string, data interface{}) (string, string, error) {
+	tmpl := t.lookup(kind, locale)
+	if tmpl == nil {
+		return "", "", fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+	}
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", err
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(subject.String()), strings.TrimSpace(body.String()) + "\n", nil
+}
+
+func (t *Templates) lookup(kind Kind, locale string) *template.Template {
+	if tag, err := language.Parse(locale); err == nil {
+		_, index, confidence := t.matcher.Match(tag)
+		if confidence != language.No {
+			if tmpl := t.byTag[t.locales[index]][kind]; tmpl != nil {
+				return tmpl
+			}
+		}
+	}
+	return t.byTag[DefaultLocale][kind]
+}
@@ -0,0 +1,261 @@
+// Package promotion prices a cart. It knows nothing about storage: the caller
+// loads the lines and the promotions that may apply and gets back the discount
+// of every line.
+//
+// Promotions do not stack with each other, except for a coupon: the automatic
+// promotion that saves the most is applied first and the coupon, if any, is
+// applied to what is left.
+package promotion
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ryanpujo/product-service/internal/money"
+)
+
+var (
+	ErrEmptyCart            = errors.New("cart is empty")
+	ErrInvalidQuantity      = errors.New("quantity must be positive")
+	ErrCouponNotApplicable  = errors.New("coupon does not apply to any item in the cart")
+	ErrMixedCartCurrencies  = errors.New("cart mixes currencies")
+	ErrInvalidPromotionRule = errors.New("invalid promotion rule")
+)
+
+type Kind string
+
+const (
+	Percentage Kind = "percentage"
+	Fixed      Kind = "fixed"
+)
+
+// BasisPoints is a percentage in hundredths of a percent, 1250 is 12.5%.
+const BasisPoints = 10000
+
+type Rule struct {
+	ID   int32
+	Name string
+	Kind Kind
+	// PercentOff is in basis points and is used by Percentage rules.
+	PercentOff int64
+	// AmountOff is taken once off the matching lines of Fixed rules.
+	AmountOff money.Money
+	// StoreID and CategoryID limit the rule to the matching products, zero
+	// matches any.
+	StoreID    int32
+	CategoryID int32
+}
+
+type Line struct {
+	ProductID  int32
+	VariantID  int32
+	StoreID    int32
+	CategoryID int32
+	Quantity   int64
+	UnitPrice  money.Money
+}
+
+type LineResult struct {
+	Line
+	Subtotal     money.Money
+	Discount     money.Money
+	Total        money.Money
+	PromotionIDs []int32
+}
+
+type Applied struct {
+	Rule     Rule
+	Discount money.Money
+}
+
+type Result struct {
+	Lines    []LineResult
+	Subtotal money.Money
+	Discount money.Money
+	Total    money.Money
+	Applied  []Applied
+}
+
+// Apply prices lines with the best of the automatic rules and then coupon,
+// which may be nil. A coupon that matches no line is an error, so the
+// customer learns why their code did nothing.
+func Apply(lines []Line, automatic []Rule, coupon *Rule) (Result, error) {
+	if len(lines) == 0 {
+		return Result{}, ErrEmptyCart
+	}
+	currency := lines[0].UnitPrice.Currency
+	result := Result{
+		Lines:    make([]LineResult, 0, len(lines)),
+		Subtotal: money.Money{Currency: currency},
+		Discount: money.Money{Currency: currency},
+	}
+	for _, l := range lines {
+		if l.Quantity <= 0 {
+			return Result{}, ErrInvalidQuantity
+		}
+		if l.UnitPrice.Currency != currency {
+			return Result{}, fmt.Errorf("%w: %s and %s", ErrMixedCartCurrencies, currency, l.UnitPrice.Currency)
+		}
+		subtotal, err := l.UnitPrice.Mul(l.Quantity)
+		if err != nil {
+			return Result{}, err
+		}
+		result.Lines = append(result.Lines, LineResult{
+			Line:     l,
+			Subtotal: subtotal,
+			Discount: money.Money{Currency: currency},
+		})
+		// discounts never exceed the subtotal, so only it can overflow
+		if result.Subtotal, err = result.Subtotal.Add(subtotal); err != nil {
+			return Result{}, err
+		}
+	}
+
+	var best []int64
+	var bestRule Rule
+	var bestTotal int64
+	for _, r := range automatic {
+		discounts, err := discountsFor(r, result.Lines)
+		if err != nil {
+			return Result{}, err
+		}
+		if total := sum(discounts); total > bestTotal {
+			best, bestRule, bestTotal = discounts, r, total
+		}
+	}
+	if bestTotal > 0 {
+		result.apply(bestRule, best)
+	}
+
+	if coupon != nil {
+		discounts, err := discountsFor(*coupon, result.Lines)
+		if err != nil {
+			return Result{}, err
+		}
+		if sum(discounts) == 0 {
+			return Result{}, ErrCouponNotApplicable
+		}
+		result.apply(*coupon, discounts)
+	}
+
+	for i := range result.Lines {
+		l := &result.Lines[i]
+		l.Total = money.Money{Minor: l.Subtotal.Minor - l.Discount.Minor, Currency: currency}
+	}
+	result.Total = money.Money{Minor: result.Subtotal.Minor - result.Discount.Minor, Currency: currency}
+	return result, nil
+}
+
+func (r *Result) apply(rule Rule, discounts []int64) {
+	applied := Applied{Rule: rule, Discount: money.Money{Currency: r.Subtotal.Currency}}
+	for i, d := range discounts {
+		if d == 0 {
+			continue
+		}
+		l := &r.Lines[i]
+		l.Discount.Minor += d
+		l.PromotionIDs = append(l.PromotionIDs, rule.ID)
+		applied.Discount.Minor += d
+	}
+	r.Discount.Minor += applied.Discount.Minor
+	r.Applied = append(r.Applied, applied)
+}
+
+func (r Rule) matches(l Line) bool {
+	return (r.StoreID == 0 || r.StoreID == l.StoreID) && (r.CategoryID == 0 || r.CategoryID == l.CategoryID)
+}
+
+// discountsFor returns what rule takes off each line, never more than what is
+// left of the line after the discounts already applied.
+func discountsFor(rule Rule, lines []LineResult) ([]int64, error) {
+	discounts := make([]int64, len(lines))
+	remaining := make([]int64, len(lines))
+	var matching int64
+	for i, l := range lines {
+		if !rule.matches(l.Line) {
+			continue
+		}
+		remaining[i] = l.Subtotal.Minor - l.Discount.Minor
+		matching += remaining[i]
+	}
+	if matching == 0 {
+		return discounts, nil
+	}
+
+	switch rule.Kind {
+	case Percentage:
+		if rule.PercentOff <= 0 || rule.PercentOff > BasisPoints {
+			return nil, fmt.Errorf("%w: promotion %d takes %d basis points off", ErrInvalidPromotionRule, rule.ID, rule.PercentOff)
+		}
+		for i, l := range lines {
+			if remaining[i] == 0 {
+				continue
+			}
+			discount, err := money.Money{Minor: remaining[i], Currency: l.Subtotal.Currency}.MulRat(rule.PercentOff, BasisPoints)
+			if err != nil {
+				return nil, err
+			}
+			discounts[i] = discount.Minor
+		}
+	case Fixed:
+		if rule.AmountOff.Minor <= 0 {
+			return nil, fmt.Errorf("%w: promotion %d takes nothing off", ErrInvalidPromotionRule, rule.ID)
+		}
+		// a fixed amount in another currency does not apply at all
+		if rule.AmountOff.Currency != lines[0].Subtotal.Currency {
+			return discounts, nil
+		}
+		total := rule.AmountOff.Minor
+		if total > matching {
+			total = matching
+		}
+		split(total, remaining, discounts)
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidPromotionRule, rule.Kind)
+	}
+	return discounts, nil
+}
+
+// split shares total between the lines in proportion to their weights. The
+// shares are rounded down and the units left over go one each to the lines
+// that lost the most to rounding, so the shares always add up to total.
+func split(total int64, weights, shares []int64) {
+	var sumWeights int64
+	for _, w := range weights {
+		sumWeights += w
+	}
+	remainders := make([]*big.Int, len(weights))
+	var allocated int64
+	for i, w := range weights {
+		q, r := new(big.Int).QuoRem(
+			new(big.Int).Mul(big.NewInt(total), big.NewInt(w)),
+			big.NewInt(sumWeights),
+			new(big.Int),
+		)
+		shares[i] = q.Int64()
+		remainders[i] = r
+		allocated += shares[i]
+	}
+	for left := total - allocated; left > 0; left-- {
+		largest := -1
+		for i, r := range remainders {
+			if weights[i] == 0 {
+				continue
+			}
+			if largest == -1 || r.Cmp(remainders[largest]) > 0 {
+				largest = i
+			}
+		}
+		shares[largest]++
+		remainders[largest] = big.NewInt(-1)
+	}
+}
+
+func sum(values []int64) int64 {
+	var total int64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
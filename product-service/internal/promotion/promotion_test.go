@@ -0,0 +1,132 @@
+package promotion_test
+
+import (
+	"testing"
+
+	"github.com/ryanpujo/product-service/internal/money"
+	"github.com/ryanpujo/product-service/internal/promotion"
+	"github.com/stretchr/testify/require"
+)
+
+func usd(minor int64) money.Money {
+	return money.Money{Minor: minor, Currency: "USD"}
+}
+
+func TestApply(t *testing.T) {
+	mug := promotion.Line{ProductID: 1, StoreID: 1, CategoryID: 10, Quantity: 2, UnitPrice: usd(1000)}
+	tee := promotion.Line{ProductID: 2, StoreID: 1, CategoryID: 20, Quantity: 1, UnitPrice: usd(3000)}
+	hat := promotion.Line{ProductID: 3, StoreID: 2, CategoryID: 20, Quantity: 3, UnitPrice: usd(333)}
+	tenPercent := promotion.Rule{ID: 1, Kind: promotion.Percentage, PercentOff: 1000}
+	shirts := promotion.Rule{ID: 2, Kind: promotion.Percentage, PercentOff: 2500, CategoryID: 20}
+	fiveOff := promotion.Rule{ID: 3, Kind: promotion.Fixed, AmountOff: usd(500), StoreID: 1}
+
+	testTable := map[string]struct {
+		lines     []promotion.Line
+		automatic []promotion.Rule
+		coupon    *promotion.Rule
+		assert    func(t *testing.T, actual promotion.Result, err error)
+	}{
+		"no promotions": {
+			lines: []promotion.Line{mug, tee},
+			assert: func(t *testing.T, actual promotion.Result, err error) {
+				require.NoError(t, err)
+				require.Equal(t, usd(5000), actual.Subtotal)
+				require.Equal(t, usd(0), actual.Discount)
+				require.Equal(t, usd(5000), actual.Total)
+				require.Empty(t, actual.Applied)
+			},
+		},
+		"best automatic promotion wins": {
+			lines:     []promotion.Line{mug, tee},
+			automatic: []promotion.Rule{tenPercent, shirts},
+			assert: func(t *testing.T, actual promotion.Result, err error) {
+				require.NoError(t, err)
+				// 25% of the tee is 750, more than 10% of everything
+				require.Equal(t, usd(750), actual.Discount)
+				require.Equal(t, usd(0), actual.Lines[0].Discount)
+				require.Equal(t, []int32{2}, actual.Lines[1].PromotionIDs)
+				require.Equal(t, usd(2250), actual.Lines[1].Total)
+			},
+		},
+		"coupon stacks on the automatic promotion": {
+			lines:     []promotion.Line{mug, tee},
+			automatic: []promotion.Rule{shirts},
+			coupon:    &fiveOff,
+			assert: func(t *testing.T, actual promotion.Result, err error) {
+				require.NoError(t, err)
+				// 500 is split 2000:2250 between the mug and what is left of the tee
+				require.Equal(t, usd(235), actual.Lines[0].Discount)
+				require.Equal(t, usd(750+265), actual.Lines[1].Discount)
+				require.Equal(t, usd(1250), actual.Discount)
+				require.Len(t, actual.Applied, 2)
+				require.Equal(t, usd(500), actual.Applied[1].Discount)
+			},
+		},
+		"fixed amount is split without losing a cent": {
+			lines:  []promotion.Line{{ProductID: 1, StoreID: 1, Quantity: 1, UnitPrice: usd(100)}, {ProductID: 2, StoreID: 1, Quantity: 1, UnitPrice: usd(100)}, {ProductID: 3, StoreID: 1, Quantity: 1, UnitPrice: usd(100)}},
+			coupon: &promotion.Rule{ID: 4, Kind: promotion.Fixed, AmountOff: usd(100)},
+			assert: func(t *testing.T, actual promotion.Result, err error) {
+				require.NoError(t, err)
+				require.Equal(t, usd(34), actual.Lines[0].Discount)
+				require.Equal(t, usd(33), actual.Lines[1].Discount)
+				require.Equal(t, usd(33), actual.Lines[2].Discount)
+			},
+		},
+		"fixed amount larger than the cart": {
+			lines:  []promotion.Line{mug},
+			coupon: &promotion.Rule{ID: 4, Kind: promotion.Fixed, AmountOff: usd(10000)},
+			assert: func(t *testing.T, actual promotion.Result, err error) {
+				require.NoError(t, err)
+				require.Equal(t, usd(0), actual.Total)
+			},
+		},
+		"store scoped coupon": {
+			lines:  []promotion.Line{mug, hat},
+			coupon: &promotion.Rule{ID: 5, Kind: promotion.Percentage, PercentOff: 5000, StoreID: 2},
+			assert: func(t *testing.T, actual promotion.Result, err error) {
+				require.NoError(t, err)
+				require.Equal(t, usd(0), actual.Lines[0].Discount)
+				// half of 999 rounds to the even 500
+				require.Equal(t, usd(500), actual.Lines[1].Discount)
+			},
+		},
+		"coupon matching nothing": {
+			lines:  []promotion.Line{mug},
+			coupon: &shirts,
+			assert: func(t *testing.T, actual promotion.Result, err error) {
+				require.ErrorIs(t, err, promotion.ErrCouponNotApplicable)
+			},
+		},
+		"coupon in another currency": {
+			lines:  []promotion.Line{mug},
+			coupon: &promotion.Rule{ID: 6, Kind: promotion.Fixed, AmountOff: money.Money{Minor: 500, Currency: "EUR"}},
+			assert: func(t *testing.T, actual promotion.Result, err error) {
+				require.ErrorIs(t, err, promotion.ErrCouponNotApplicable)
+			},
+		},
+		"mixed currencies": {
+			lines: []promotion.Line{mug, {ProductID: 4, Quantity: 1, UnitPrice: money.Money{Minor: 100, Currency: "JPY"}}},
+			assert: func(t *testing.T, actual promotion.Result, err error) {
+				require.ErrorIs(t, err, promotion.ErrMixedCartCurrencies)
+			},
+		},
+		"zero quantity": {
+			lines: []promotion.Line{{ProductID: 1, UnitPrice: usd(100)}},
+			assert: func(t *testing.T, actual promotion.Result, err error) {
+				require.ErrorIs(t, err, promotion.ErrInvalidQuantity)
+			},
+		},
+		"empty cart": {
+			assert: func(t *testing.T, actual promotion.Result, err error) {
+				require.ErrorIs(t, err, promotion.ErrEmptyCart)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			actual, err := promotion.Apply(v.lines, v.automatic, v.coupon)
+
+			v.assert(t, actual, err)
+		})
+	}
+}
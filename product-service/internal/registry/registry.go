@@ -0,0 +1,126 @@
+package registry
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ryanpujo/product-service/internal/audit"
+	"github.com/ryanpujo/product-service/internal/controller"
+	"github.com/ryanpujo/product-service/internal/interactor"
+	"github.com/ryanpujo/product-service/internal/messaging"
+	"github.com/ryanpujo/product-service/internal/notification"
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/ryanpujo/product-service/product-proto/grpc/product"
+)
+
+type Registry interface {
+	NewProductServer() product.ProductServiceServer
+	NewProductInteractor() interactor.ProductInteractor
+	NewEventBus(driver, url string) (messaging.Broker, error)
+	NewEventRelay(bus messaging.Publisher) *messaging.Relay
+	NewConsumers() []*messaging.Consumer
+	NewDeadLetters() *messaging.DeadLetters
+	SetupNotifications(email, smtpURL, smtpFrom string) error
+	NewMailer() *notification.Mailer
+}
+
+type registry struct {
+	DB            *sql.DB
+	Notifications *notification.Service
+	Mailer        *notification.Mailer
+}
+
+func New(db *sql.DB) *registry {
+	return &registry{DB: db}
+}
+
+func (r *registry) NewProductServer() product.ProductServiceServer {
+	return controller.NewProductServer(r.NewProductInteractor())
+}
+
+func (r *registry) newProductRepository() repository.TxQuerier {
+	return repository.NewTxQuerier(r.DB)
+}
+
+func (r *registry) NewProductInteractor() interactor.ProductInteractor {
+	repo := r.newProductRepository()
+	in := interactor.NewProductInteractor(repo)
+	in.Audit = audit.Log{}
+	if r.Notifications != nil {
+		in.Notifications = r.Notifications
+		in.Notifier = interactor.NotificationNotifier{Repo: repo, Service: r.Notifications}
+	}
+	return in
+}
+
+// SetupNotifications builds the notification service of the interactors made
+// after it, their wishlist alerts become notifications too. The emails are
+// queued and the mailer sends them, email is "log" to only log them, or
+// "smtp" to send them through smtpURL. The in-app inbox is always on.
+func (r *registry) SetupNotifications(email, smtpURL, smtpFrom string) error {
+	templates, err := notification.DefaultTemplates()
+	if err != nil {
+		return err
+	}
+	var mail notification.Sender
+	switch email {
+	case "", "log":
+		mail = notification.LogSink{}
+	case "smtp":
+		s, err := notification.NewSMTP(smtpURL, smtpFrom)
+		if err != nil {
+			return err
+		}
+		mail = s
+	default:
+		return fmt.Errorf("unknown notification email %q", email)
+	}
+	r.Notifications = notification.NewService(templates, map[notification.Channel]notification.Sender{
+		notification.ChannelInApp: notification.Inbox{},
+		notification.ChannelEmail: notification.EmailQueue{},
+	})
+	r.Mailer = notification.NewMailer(r.newProductRepository(), mail)
+	return nil
+}
+
+// NewMailer returns the mailer that sends the queued emails, nil before
+// SetupNotifications.
+func (r *registry) NewMailer() *notification.Mailer {
+	return r.Mailer
+}
+
+// NewEventBus returns the broker the driver names, the relay publishes to it
+// and the consumers listen on it. The memory bus only delivers the events of
+// this service, it is refused while a consumer waits for the events of
+// another one.
+func (r *registry) NewEventBus(driver, url string) (messaging.Broker, error) {
+	switch driver {
+	case "", "memory":
+		for _, consumer := range r.NewConsumers() {
+			if !messaging.Local(consumer.Topic) {
+				return nil, fmt.Errorf("the %s consumer needs the %s events of another service, which only the nats events driver delivers", consumer.Name, consumer.Topic)
+			}
+		}
+		return messaging.NewBus(), nil
+	case "nats":
+		return messaging.NewNATS(url, messaging.DefaultNATSTimeout)
+	default:
+		return nil, fmt.Errorf("unknown events driver %q", driver)
+	}
+}
+
+// NewEventRelay publishes the outbox to bus.
+func (r *registry) NewEventRelay(bus messaging.Publisher) *messaging.Relay {
+	return messaging.NewRelay(messaging.NewOutbox(r.newProductRepository()), bus)
+}
+
+// NewConsumers returns the consumers of the events the service reacts to.
+func (r *registry) NewConsumers() []*messaging.Consumer {
+	in := interactor.NewProductInteractor(r.newProductRepository())
+	in.Notifications = r.Notifications
+	return in.Consumers()
+}
+
+func (r *registry) NewDeadLetters() *messaging.DeadLetters {
+	return &messaging.DeadLetters{Repo: r.newProductRepository(), Consumers: r.NewConsumers()}
+}
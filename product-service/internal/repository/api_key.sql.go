@@ -0,0 +1,164 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: api_key.sql
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const createApiKey = `-- name: CreateApiKey :one
+INSERT INTO api_keys (
+  store_id,
+  name,
+  prefix,
+  key_hash,
+  scopes,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING id, store_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+`
+
+type CreateApiKeyParams struct {
+	StoreID   int32        `json:"store_id"`
+	Name      string       `json:"name"`
+	Prefix    string       `json:"prefix"`
+	KeyHash   string       `json:"key_hash"`
+	Scopes    []string     `json:"scopes"`
+	ExpiresAt sql.NullTime `json:"expires_at"`
+}
+
+func (q *Queries) CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, createApiKey,
+		arg.StoreID,
+		arg.Name,
+		arg.Prefix,
+		arg.KeyHash,
+		pq.Array(arg.Scopes),
+		arg.ExpiresAt,
+	)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.StoreID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getApiKeyByPrefix = `-- name: GetApiKeyByPrefix :one
+SELECT k.id, k.store_id, k.key_hash, k.scopes, k.expires_at, k.revoked_at, u.email AS owner_email
+FROM api_keys k
+JOIN stores s ON s.id = k.store_id
+JOIN users u ON u.id = s.owner_id
+WHERE k.prefix = $1
+`
+
+type GetApiKeyByPrefixRow struct {
+	ID         int32          `json:"id"`
+	StoreID    int32          `json:"store_id"`
+	KeyHash    string         `json:"key_hash"`
+	Scopes     []string       `json:"scopes"`
+	ExpiresAt  sql.NullTime   `json:"expires_at"`
+	RevokedAt  sql.NullTime   `json:"revoked_at"`
+	OwnerEmail sql.NullString `json:"owner_email"`
+}
+
+// The key together with the email of the store owner, whom requests made with
+// the key act for.
+func (q *Queries) GetApiKeyByPrefix(ctx context.Context, prefix string) (GetApiKeyByPrefixRow, error) {
+	row := q.db.QueryRowContext(ctx, getApiKeyByPrefix, prefix)
+	var i GetApiKeyByPrefixRow
+	err := row.Scan(
+		&i.ID,
+		&i.StoreID,
+		&i.KeyHash,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.OwnerEmail,
+	)
+	return i, err
+}
+
+const listStoreApiKeys = `-- name: ListStoreApiKeys :many
+SELECT id, store_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_keys
+WHERE store_id = $1 AND revoked_at IS NULL
+ORDER BY id DESC
+`
+
+func (q *Queries) ListStoreApiKeys(ctx context.Context, storeID int32) ([]ApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, listStoreApiKeys, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiKey
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.StoreID,
+			&i.Name,
+			&i.Prefix,
+			&i.KeyHash,
+			pq.Array(&i.Scopes),
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeApiKey = `-- name: RevokeApiKey :execrows
+UPDATE api_keys SET revoked_at = now()
+WHERE id = $1 AND store_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeApiKeyParams struct {
+	ID      int32 `json:"id"`
+	StoreID int32 `json:"store_id"`
+}
+
+func (q *Queries) RevokeApiKey(ctx context.Context, arg RevokeApiKeyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeApiKey, arg.ID, arg.StoreID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const touchApiKey = `-- name: TouchApiKey :exec
+UPDATE api_keys SET last_used_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) TouchApiKey(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, touchApiKey, id)
+	return err
+}
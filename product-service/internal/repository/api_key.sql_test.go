@@ -0,0 +1,71 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApiKeys(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var ownerID, storeID int32
+	err := testDb.QueryRowContext(ctx, "insert into users (email) values ('integrator@mail.com') returning id").Scan(&ownerID)
+	require.NoError(t, err)
+	err = testDb.QueryRowContext(ctx, "insert into stores (store_name, owner_id) values ('integrate', $1) returning id", ownerID).Scan(&storeID)
+	require.NoError(t, err)
+
+	erp, err := productRepo.CreateApiKey(ctx, repository.CreateApiKeyParams{
+		StoreID: storeID,
+		Name:    "ERP",
+		Prefix:  "rpk_erp00000",
+		KeyHash: "hash",
+		Scopes:  []string{"catalog:read", "catalog:write"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"catalog:read", "catalog:write"}, erp.Scopes)
+	require.False(t, erp.LastUsedAt.Valid)
+
+	_, err = productRepo.CreateApiKey(ctx, repository.CreateApiKeyParams{
+		StoreID: storeID,
+		Name:    "Again",
+		Prefix:  "rpk_erp00000",
+		KeyHash: "other",
+		Scopes:  []string{"catalog:read"},
+	})
+	require.Error(t, err, "prefixes are unique")
+
+	found, err := productRepo.GetApiKeyByPrefix(ctx, "rpk_erp00000")
+	require.NoError(t, err)
+	require.Equal(t, erp.ID, found.ID)
+	require.Equal(t, "integrator@mail.com", found.OwnerEmail.String)
+
+	err = productRepo.TouchApiKey(ctx, erp.ID)
+	require.NoError(t, err)
+	listed, err := productRepo.ListStoreApiKeys(ctx, storeID)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.True(t, listed[0].LastUsedAt.Valid)
+
+	revoked, err := productRepo.RevokeApiKey(ctx, repository.RevokeApiKeyParams{ID: erp.ID, StoreID: storeID + 1})
+	require.NoError(t, err)
+	require.Zero(t, revoked, "only through its own store")
+	revoked, err = productRepo.RevokeApiKey(ctx, repository.RevokeApiKeyParams{ID: erp.ID, StoreID: storeID})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), revoked)
+	listed, err = productRepo.ListStoreApiKeys(ctx, storeID)
+	require.NoError(t, err)
+	require.Empty(t, listed)
+	found, err = productRepo.GetApiKeyByPrefix(ctx, "rpk_erp00000")
+	require.NoError(t, err)
+	require.True(t, found.RevokedAt.Valid)
+
+	_, err = productRepo.GetApiKeyByPrefix(ctx, "rpk_unknown0")
+	require.True(t, errors.Is(err, sql.ErrNoRows))
+}
@@ -0,0 +1,59 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: audit.sql
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+const insertAuditEntry = `-- name: InsertAuditEntry :exec
+INSERT INTO audit_log (
+  service,
+  actor,
+  impersonator,
+  action,
+  target_type,
+  target_id,
+  before,
+  after,
+  request_id,
+  ip
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+`
+
+type InsertAuditEntryParams struct {
+	Service      string          `json:"service"`
+	Actor        sql.NullString  `json:"actor"`
+	Impersonator sql.NullString  `json:"impersonator"`
+	Action       string          `json:"action"`
+	TargetType   string          `json:"target_type"`
+	TargetID     string          `json:"target_id"`
+	Before       json.RawMessage `json:"before"`
+	After        json.RawMessage `json:"after"`
+	RequestID    sql.NullString  `json:"request_id"`
+	Ip           sql.NullString  `json:"ip"`
+}
+
+// audit_log is append-only, a trigger refuses updates and deletes.
+func (q *Queries) InsertAuditEntry(ctx context.Context, arg InsertAuditEntryParams) error {
+	_, err := q.db.ExecContext(ctx, insertAuditEntry,
+		arg.Service,
+		arg.Actor,
+		arg.Impersonator,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Before,
+		arg.After,
+		arg.RequestID,
+		arg.Ip,
+	)
+	return err
+}
@@ -0,0 +1,39 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertAuditEntry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	err := productRepo.InsertAuditEntry(ctx, repository.InsertAuditEntryParams{
+		Service:    "product",
+		Actor:      sql.NullString{String: "owner@mail.com", Valid: true},
+		Action:     "product.create",
+		TargetType: "product",
+		TargetID:   "1",
+		Before:     json.RawMessage(`{}`),
+		After:      json.RawMessage(`{"name":"Mug"}`),
+		RequestID:  sql.NullString{String: "req-1", Valid: true},
+	})
+	require.NoError(t, err)
+
+	var id int64
+	err = testDb.QueryRowContext(ctx, "select id from audit_log where request_id = 'req-1'").Scan(&id)
+	require.NoError(t, err)
+
+	// the log is append-only
+	_, err = testDb.ExecContext(ctx, "update audit_log set actor = 'someone' where id = $1", id)
+	require.Error(t, err)
+	_, err = testDb.ExecContext(ctx, "delete from audit_log where id = $1", id)
+	require.Error(t, err)
+}
@@ -0,0 +1,166 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: consumer.sql
+
+package repository
+
+import (
+	"context"
+)
+
+const createDeadLetter = `-- name: CreateDeadLetter :one
+INSERT INTO dead_letters (
+  consumer,
+  topic,
+  partition_key,
+  event_id,
+  payload,
+  error,
+  attempts
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING id, consumer, topic, partition_key, event_id, payload, error, attempts, created_at, replayed_at
+`
+
+type CreateDeadLetterParams struct {
+	Consumer     string `json:"consumer"`
+	Topic        string `json:"topic"`
+	PartitionKey string `json:"partition_key"`
+	EventID      string `json:"event_id"`
+	Payload      []byte `json:"payload"`
+	Error        string `json:"error"`
+	Attempts     int32  `json:"attempts"`
+}
+
+func (q *Queries) CreateDeadLetter(ctx context.Context, arg CreateDeadLetterParams) (DeadLetter, error) {
+	row := q.db.QueryRowContext(ctx, createDeadLetter,
+		arg.Consumer,
+		arg.Topic,
+		arg.PartitionKey,
+		arg.EventID,
+		arg.Payload,
+		arg.Error,
+		arg.Attempts,
+	)
+	var i DeadLetter
+	err := row.Scan(
+		&i.ID,
+		&i.Consumer,
+		&i.Topic,
+		&i.PartitionKey,
+		&i.EventID,
+		&i.Payload,
+		&i.Error,
+		&i.Attempts,
+		&i.CreatedAt,
+		&i.ReplayedAt,
+	)
+	return i, err
+}
+
+const getDeadLetter = `-- name: GetDeadLetter :one
+SELECT id, consumer, topic, partition_key, event_id, payload, error, attempts, created_at, replayed_at FROM dead_letters
+WHERE id = $1
+`
+
+func (q *Queries) GetDeadLetter(ctx context.Context, id int64) (DeadLetter, error) {
+	row := q.db.QueryRowContext(ctx, getDeadLetter, id)
+	var i DeadLetter
+	err := row.Scan(
+		&i.ID,
+		&i.Consumer,
+		&i.Topic,
+		&i.PartitionKey,
+		&i.EventID,
+		&i.Payload,
+		&i.Error,
+		&i.Attempts,
+		&i.CreatedAt,
+		&i.ReplayedAt,
+	)
+	return i, err
+}
+
+const listDeadLetters = `-- name: ListDeadLetters :many
+SELECT id, consumer, topic, partition_key, event_id, payload, error, attempts, created_at, replayed_at FROM dead_letters
+WHERE ($1::varchar = '' OR consumer = $1::varchar)
+  AND ($2::boolean OR replayed_at IS NULL)
+ORDER BY id
+LIMIT $3::integer
+`
+
+type ListDeadLettersParams struct {
+	Consumer        string `json:"consumer"`
+	IncludeReplayed bool   `json:"include_replayed"`
+	PageSize        int32  `json:"page_size"`
+}
+
+func (q *Queries) ListDeadLetters(ctx context.Context, arg ListDeadLettersParams) ([]DeadLetter, error) {
+	rows, err := q.db.QueryContext(ctx, listDeadLetters, arg.Consumer, arg.IncludeReplayed, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeadLetter
+	for rows.Next() {
+		var i DeadLetter
+		if err := rows.Scan(
+			&i.ID,
+			&i.Consumer,
+			&i.Topic,
+			&i.PartitionKey,
+			&i.EventID,
+			&i.Payload,
+			&i.Error,
+			&i.Attempts,
+			&i.CreatedAt,
+			&i.ReplayedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markDeadLetterReplayed = `-- name: MarkDeadLetterReplayed :exec
+UPDATE dead_letters SET replayed_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) MarkDeadLetterReplayed(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markDeadLetterReplayed, id)
+	return err
+}
+
+const markEventProcessed = `-- name: MarkEventProcessed :execrows
+INSERT INTO processed_events (
+  consumer,
+  event_id
+) VALUES (
+  $1, $2
+)
+ON CONFLICT DO NOTHING
+`
+
+type MarkEventProcessedParams struct {
+	Consumer string `json:"consumer"`
+	EventID  string `json:"event_id"`
+}
+
+// Affects no rows when the consumer has already handled the event.
+func (q *Queries) MarkEventProcessed(ctx context.Context, arg MarkEventProcessedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markEventProcessed, arg.Consumer, arg.EventID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
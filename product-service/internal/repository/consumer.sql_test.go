@@ -0,0 +1,64 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkEventProcessed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	processed := repository.MarkEventProcessedParams{Consumer: "consumer-test", EventID: "event-1"}
+	n, err := productRepo.MarkEventProcessed(ctx, processed)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	n, err = productRepo.MarkEventProcessed(ctx, processed)
+	require.NoError(t, err)
+	require.Zero(t, n, "an event is processed once per consumer")
+
+	n, err = productRepo.MarkEventProcessed(ctx, repository.MarkEventProcessedParams{Consumer: "other-test", EventID: "event-1"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+}
+
+func TestDeadLetters(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	letter, err := productRepo.CreateDeadLetter(ctx, repository.CreateDeadLetterParams{
+		Consumer:     "dead-letter-test",
+		Topic:        "order.cancelled",
+		PartitionKey: "12",
+		EventID:      "event-1",
+		Payload:      []byte("event"),
+		Error:        "stock is locked",
+		Attempts:     5,
+	})
+	require.NoError(t, err)
+	require.False(t, letter.ReplayedAt.Valid)
+
+	pending, err := productRepo.ListDeadLetters(ctx, repository.ListDeadLettersParams{Consumer: "dead-letter-test", PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, letter.ID, pending[0].ID)
+
+	err = productRepo.MarkDeadLetterReplayed(ctx, letter.ID)
+	require.NoError(t, err)
+
+	replayed, err := productRepo.GetDeadLetter(ctx, letter.ID)
+	require.NoError(t, err)
+	require.True(t, replayed.ReplayedAt.Valid)
+
+	pending, err = productRepo.ListDeadLetters(ctx, repository.ListDeadLettersParams{Consumer: "dead-letter-test", PageSize: 10})
+	require.NoError(t, err)
+	require.Empty(t, pending)
+	all, err := productRepo.ListDeadLetters(ctx, repository.ListDeadLettersParams{Consumer: "dead-letter-test", IncludeReplayed: true, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+}
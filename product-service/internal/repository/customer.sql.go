@@ -0,0 +1,240 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: customer.sql
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+const deleteCartItemsByUser = `-- name: DeleteCartItemsByUser :exec
+DELETE FROM cart
+WHERE user_id = $1::integer
+`
+
+func (q *Queries) DeleteCartItemsByUser(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteCartItemsByUser, userID)
+	return err
+}
+
+const deleteReviewsByUser = `-- name: DeleteReviewsByUser :many
+DELETE FROM reviews
+WHERE user_id = $1
+RETURNING product_id
+`
+
+// Returns the reviewed products so their rating can be refreshed.
+func (q *Queries) DeleteReviewsByUser(ctx context.Context, userID int32) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, deleteReviewsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var product_id int32
+		if err := rows.Scan(&product_id); err != nil {
+			return nil, err
+		}
+		items = append(items, product_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteWishlistItemsByUser = `-- name: DeleteWishlistItemsByUser :exec
+DELETE FROM wishlist_items
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteWishlistItemsByUser(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteWishlistItemsByUser, userID)
+	return err
+}
+
+const getAccountIDByEmail = `-- name: GetAccountIDByEmail :one
+SELECT id FROM users
+WHERE lower(email) = lower($1) AND purged_at IS NULL
+`
+
+// Unlike GetUserIDByEmail it also finds deactivated and deleted users, their
+// data can be asked for until the account is purged.
+func (q *Queries) GetAccountIDByEmail(ctx context.Context, email string) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getAccountIDByEmail, email)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const listCartItemsByUser = `-- name: ListCartItemsByUser :many
+SELECT id, user_id, product_id, variant_id, quantity, price FROM cart
+WHERE user_id = $1::integer
+ORDER BY id
+`
+
+func (q *Queries) ListCartItemsByUser(ctx context.Context, userID int32) ([]Cart, error) {
+	rows, err := q.db.QueryContext(ctx, listCartItemsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Cart
+	for rows.Next() {
+		var i Cart
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ProductID,
+			&i.VariantID,
+			&i.Quantity,
+			&i.Price,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrderItemsByUser = `-- name: ListOrderItemsByUser :many
+SELECT oi.order_id, oi.product_id, oi.variant_id, p.name, oi.quantity, oi.price, oi.discount
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+LEFT JOIN products p ON p.id = oi.product_id
+WHERE o.user_id = $1::integer
+ORDER BY oi.order_id, oi.id
+`
+
+type ListOrderItemsByUserRow struct {
+	OrderID   sql.NullInt32  `json:"order_id"`
+	ProductID sql.NullInt32  `json:"product_id"`
+	VariantID sql.NullInt32  `json:"variant_id"`
+	Name      sql.NullString `json:"name"`
+	Quantity  sql.NullInt32  `json:"quantity"`
+	Price     sql.NullString `json:"price"`
+	Discount  string         `json:"discount"`
+}
+
+func (q *Queries) ListOrderItemsByUser(ctx context.Context, userID int32) ([]ListOrderItemsByUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrderItemsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrderItemsByUserRow
+	for rows.Next() {
+		var i ListOrderItemsByUserRow
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.ProductID,
+			&i.VariantID,
+			&i.Name,
+			&i.Quantity,
+			&i.Price,
+			&i.Discount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrdersByUser = `-- name: ListOrdersByUser :many
+SELECT id, user_id, store_id, order_date, currency, subtotal_amount, discount_amount, total_amount, coupon_code, status FROM orders
+WHERE user_id = $1::integer
+ORDER BY order_date DESC, id DESC
+`
+
+func (q *Queries) ListOrdersByUser(ctx context.Context, userID int32) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, listOrdersByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.StoreID,
+			&i.OrderDate,
+			&i.Currency,
+			&i.SubtotalAmount,
+			&i.DiscountAmount,
+			&i.TotalAmount,
+			&i.CouponCode,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReviewsByUser = `-- name: ListReviewsByUser :many
+SELECT id, product_id, user_id, rating, body, verified_purchase, status, created_at, updated_at FROM reviews
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+`
+
+func (q *Queries) ListReviewsByUser(ctx context.Context, userID int32) ([]Review, error) {
+	rows, err := q.db.QueryContext(ctx, listReviewsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Review
+	for rows.Next() {
+		var i Review
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.UserID,
+			&i.Rating,
+			&i.Body,
+			&i.VerifiedPurchase,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,91 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomerData(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var userID int32
+	err := testDb.QueryRowContext(ctx, "insert into users (email, deactivated_at) values ('subject@mail.com', now()) returning id").Scan(&userID)
+	require.NoError(t, err)
+	_, err = productRepo.GetUserIDByEmail(ctx, "subject@mail.com")
+	require.ErrorIs(t, err, sql.ErrNoRows, "deactivated users can't shop")
+	id, err := productRepo.GetAccountIDByEmail(ctx, "SUBJECT@mail.com")
+	require.NoError(t, err)
+	require.Equal(t, userID, id, "but their data can be asked for")
+
+	created, err := productRepo.CreateProduct(ctx, repository.CreateProductParams{
+		Name:     sql.NullString{String: "Kettle", Valid: true},
+		Price:    sql.NullString{String: "40", Valid: true},
+		Currency: "USD",
+		Stock:    sql.NullInt32{Int32: 4, Valid: true},
+	})
+	require.NoError(t, err)
+	order, err := productRepo.CreateOrder(ctx, repository.CreateOrderParams{
+		UserID:         sql.NullInt32{Int32: userID, Valid: true},
+		Currency:       sql.NullString{String: "USD", Valid: true},
+		SubtotalAmount: sql.NullString{String: "40.00", Valid: true},
+		DiscountAmount: "0",
+		TotalAmount:    sql.NullString{String: "40.00", Valid: true},
+		Status:         sql.NullString{String: "pending", Valid: true},
+	})
+	require.NoError(t, err)
+	err = productRepo.CreateOrderItem(ctx, repository.CreateOrderItemParams{
+		OrderID:   sql.NullInt32{Int32: order.ID, Valid: true},
+		ProductID: sql.NullInt32{Int32: created.ID, Valid: true},
+		Quantity:  sql.NullInt32{Int32: 1, Valid: true},
+		Price:     sql.NullString{String: "40.00", Valid: true},
+		Discount:  "0",
+	})
+	require.NoError(t, err)
+	_, err = productRepo.CreateReview(ctx, repository.CreateReviewParams{ProductID: created.ID, UserID: userID, Rating: 5, Body: "boils fast"})
+	require.NoError(t, err)
+	_, err = productRepo.AddWishlistItem(ctx, repository.AddWishlistItemParams{UserID: userID, ProductID: created.ID})
+	require.NoError(t, err)
+	_, err = productRepo.AddCartItem(ctx, repository.AddCartItemParams{
+		UserID:    sql.NullInt32{Int32: userID, Valid: true},
+		ProductID: sql.NullInt32{Int32: created.ID, Valid: true},
+		Quantity:  sql.NullInt32{Int32: 1, Valid: true},
+		Price:     sql.NullString{String: "40.00", Valid: true},
+	})
+	require.NoError(t, err)
+
+	orders, err := productRepo.ListOrdersByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	items, err := productRepo.ListOrderItemsByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "Kettle", items[0].Name.String)
+	reviews, err := productRepo.ListReviewsByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	cart, err := productRepo.ListCartItemsByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, cart, 1)
+
+	require.NoError(t, productRepo.DeleteCartItemsByUser(ctx, userID))
+	require.NoError(t, productRepo.DeleteWishlistItemsByUser(ctx, userID))
+	reviewed, err := productRepo.DeleteReviewsByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, []int32{created.ID}, reviewed)
+
+	cart, err = productRepo.ListCartItemsByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Empty(t, cart)
+	wishlist, err := productRepo.ListWishlistItems(ctx, userID)
+	require.NoError(t, err)
+	require.Empty(t, wishlist)
+	orders, err = productRepo.ListOrdersByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, orders, 1, "orders are kept")
+}
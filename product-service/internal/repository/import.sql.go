@@ -0,0 +1,266 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: import.sql
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const createStockMovements = `-- name: CreateStockMovements :exec
+INSERT INTO stock_movements (
+  product_id,
+  quantity,
+  reason,
+  reference,
+  note
+)
+SELECT m.product_id, m.quantity, m.reason, $1::varchar, m.note
+FROM (
+  SELECT
+    unnest($2::integer[]) AS product_id,
+    unnest($3::integer[]) AS quantity,
+    unnest($4::varchar[]) AS reason,
+    unnest($5::varchar[]) AS note
+) m
+`
+
+type CreateStockMovementsParams struct {
+	Reference  string   `json:"reference"`
+	ProductIds []int32  `json:"product_ids"`
+	Quantities []int32  `json:"quantities"`
+	Reasons    []string `json:"reasons"`
+	Notes      []string `json:"notes"`
+}
+
+func (q *Queries) CreateStockMovements(ctx context.Context, arg CreateStockMovementsParams) error {
+	_, err := q.db.ExecContext(ctx, createStockMovements,
+		arg.Reference,
+		pq.Array(arg.ProductIds),
+		pq.Array(arg.Quantities),
+		pq.Array(arg.Reasons),
+		pq.Array(arg.Notes),
+	)
+	return err
+}
+
+const isStoreOwner = `-- name: IsStoreOwner :one
+SELECT EXISTS (
+  SELECT 1 FROM stores s
+  JOIN users u ON u.id = s.owner_id
+  WHERE s.id = $1::integer AND lower(u.email) = lower($2)
+)
+`
+
+type IsStoreOwnerParams struct {
+	StoreID int32  `json:"store_id"`
+	Email   string `json:"email"`
+}
+
+func (q *Queries) IsStoreOwner(ctx context.Context, arg IsStoreOwnerParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isStoreOwner, arg.StoreID, arg.Email)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listProductStockBySku = `-- name: ListProductStockBySku :many
+SELECT id, sku, coalesce(stock, 0)::integer AS stock
+FROM products
+WHERE store_id = $1::integer AND sku = ANY($2::varchar[])
+ORDER BY id
+FOR UPDATE
+`
+
+type ListProductStockBySkuParams struct {
+	StoreID int32    `json:"store_id"`
+	Skus    []string `json:"skus"`
+}
+
+type ListProductStockBySkuRow struct {
+	ID    int32          `json:"id"`
+	Sku   sql.NullString `json:"sku"`
+	Stock int32          `json:"stock"`
+}
+
+// Locks the rows until the import commits, so no sale or adjustment changes
+// the stock the import records its movements against. They are locked in id
+// order like the other stock locks to keep imports from deadlocking.
+func (q *Queries) ListProductStockBySku(ctx context.Context, arg ListProductStockBySkuParams) ([]ListProductStockBySkuRow, error) {
+	rows, err := q.db.QueryContext(ctx, listProductStockBySku, arg.StoreID, pq.Array(arg.Skus))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProductStockBySkuRow
+	for rows.Next() {
+		var i ListProductStockBySkuRow
+		if err := rows.Scan(&i.ID, &i.Sku, &i.Stock); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStoreProducts = `-- name: ListStoreProducts :many
+SELECT id, store_id, sku, name, description, price, currency, image_url, stock, category_id, rating_average, rating_count, created_at, search_vector FROM products
+WHERE store_id = $1::integer AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListStoreProductsParams struct {
+	StoreID  int32 `json:"store_id"`
+	AfterID  int32 `json:"after_id"`
+	RowLimit int32 `json:"row_limit"`
+}
+
+// Keyset pagination, pass the last id of the previous page as after_id.
+func (q *Queries) ListStoreProducts(ctx context.Context, arg ListStoreProductsParams) ([]Product, error) {
+	rows, err := q.db.QueryContext(ctx, listStoreProducts, arg.StoreID, arg.AfterID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.StoreID,
+			&i.Sku,
+			&i.Name,
+			&i.Description,
+			&i.Price,
+			&i.Currency,
+			&i.ImageUrl,
+			&i.Stock,
+			&i.CategoryID,
+			&i.RatingAverage,
+			&i.RatingCount,
+			&i.CreatedAt,
+			&i.SearchVector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertProducts = `-- name: UpsertProducts :many
+INSERT INTO products (
+  store_id,
+  sku,
+  name,
+  description,
+  price,
+  currency,
+  image_url,
+  stock,
+  category_id,
+  created_at
+)
+SELECT
+  $1::integer,
+  r.sku,
+  r.name,
+  nullif(r.description, ''),
+  r.price,
+  r.currency,
+  nullif(r.image_url, ''),
+  r.stock,
+  nullif(r.category_id, 0),
+  now()
+FROM (
+  SELECT
+    unnest($2::varchar[]) AS sku,
+    unnest($3::varchar[]) AS name,
+    unnest($4::varchar[]) AS description,
+    unnest($5::numeric[]) AS price,
+    unnest($6::varchar[]) AS currency,
+    unnest($7::varchar[]) AS image_url,
+    unnest($8::integer[]) AS stock,
+    unnest($9::integer[]) AS category_id
+) r
+ON CONFLICT (store_id, sku) DO UPDATE SET
+  name = excluded.name,
+  description = excluded.description,
+  price = excluded.price,
+  currency = excluded.currency,
+  image_url = excluded.image_url,
+  stock = excluded.stock,
+  category_id = excluded.category_id
+RETURNING id, sku
+`
+
+type UpsertProductsParams struct {
+	StoreID      int32    `json:"store_id"`
+	Skus         []string `json:"skus"`
+	Names        []string `json:"names"`
+	Descriptions []string `json:"descriptions"`
+	Prices       []string `json:"prices"`
+	Currencies   []string `json:"currencies"`
+	ImageUrls    []string `json:"image_urls"`
+	Stocks       []int32  `json:"stocks"`
+	CategoryIds  []int32  `json:"category_ids"`
+}
+
+type UpsertProductsRow struct {
+	ID  int32          `json:"id"`
+	Sku sql.NullString `json:"sku"`
+}
+
+// Inserts or updates a batch of products of one store by sku. The arrays are
+// parallel, element i of each one describes the same product; several unnest
+// calls in one select list are zipped together row by row.
+func (q *Queries) UpsertProducts(ctx context.Context, arg UpsertProductsParams) ([]UpsertProductsRow, error) {
+	rows, err := q.db.QueryContext(ctx, upsertProducts,
+		arg.StoreID,
+		pq.Array(arg.Skus),
+		pq.Array(arg.Names),
+		pq.Array(arg.Descriptions),
+		pq.Array(arg.Prices),
+		pq.Array(arg.Currencies),
+		pq.Array(arg.ImageUrls),
+		pq.Array(arg.Stocks),
+		pq.Array(arg.CategoryIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UpsertProductsRow
+	for rows.Next() {
+		var i UpsertProductsRow
+		if err := rows.Scan(&i.ID, &i.Sku); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,75 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var ownerID, storeID int32
+	err := testDb.QueryRowContext(ctx, "insert into users (email) values ('importer@mail.com') returning id").Scan(&ownerID)
+	require.NoError(t, err)
+	err = testDb.QueryRowContext(ctx, "insert into stores (store_name, owner_id) values ('import', $1) returning id", ownerID).Scan(&storeID)
+	require.NoError(t, err)
+
+	owner, err := productRepo.IsStoreOwner(ctx, repository.IsStoreOwnerParams{StoreID: storeID, Email: "Importer@mail.com"})
+	require.NoError(t, err)
+	require.True(t, owner)
+	owner, err = productRepo.IsStoreOwner(ctx, repository.IsStoreOwnerParams{StoreID: storeID, Email: "someone@mail.com"})
+	require.NoError(t, err)
+	require.False(t, owner)
+
+	params := repository.UpsertProductsParams{
+		StoreID:      storeID,
+		Skus:         []string{"MUG-1", "TEE-1"},
+		Names:        []string{"Mug", "Tee"},
+		Descriptions: []string{"", "Cotton"},
+		Prices:       []string{"12.50", "20.00"},
+		Currencies:   []string{"USD", "USD"},
+		ImageUrls:    []string{"", ""},
+		Stocks:       []int32{5, 0},
+		CategoryIds:  []int32{0, 0},
+	}
+	created, err := productRepo.UpsertProducts(ctx, params)
+	require.NoError(t, err)
+	require.Len(t, created, 2)
+
+	params.Skus, params.Names, params.Descriptions = params.Skus[1:], []string{"T-Shirt"}, []string{""}
+	params.Prices, params.Currencies, params.ImageUrls = []string{"22.00"}, []string{"USD"}, []string{""}
+	params.Stocks, params.CategoryIds = []int32{3}, []int32{0}
+	updated, err := productRepo.UpsertProducts(ctx, params)
+	require.NoError(t, err)
+	require.Equal(t, created[1].ID, updated[0].ID, "upserted by sku")
+
+	stock, err := productRepo.ListProductStockBySku(ctx, repository.ListProductStockBySkuParams{StoreID: storeID, Skus: []string{"TEE-1", "NOPE"}})
+	require.NoError(t, err)
+	require.Equal(t, []repository.ListProductStockBySkuRow{{ID: updated[0].ID, Sku: sql.NullString{String: "TEE-1", Valid: true}, Stock: 3}}, stock)
+
+	err = productRepo.CreateStockMovements(ctx, repository.CreateStockMovementsParams{
+		Reference:  "import",
+		ProductIds: []int32{created[0].ID, created[1].ID},
+		Quantities: []int32{5, 3},
+		Reasons:    []string{"restock", "adjustment"},
+		Notes:      []string{"initial stock", "import"},
+	})
+	require.NoError(t, err)
+
+	first, err := productRepo.ListStoreProducts(ctx, repository.ListStoreProductsParams{StoreID: storeID, RowLimit: 1})
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	require.Equal(t, "MUG-1", first[0].Sku.String)
+	require.False(t, first[0].Description.Valid)
+	rest, err := productRepo.ListStoreProducts(ctx, repository.ListStoreProductsParams{StoreID: storeID, AfterID: first[0].ID, RowLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	require.Equal(t, "T-Shirt", rest[0].Name.String)
+	require.Equal(t, "22.00", rest[0].Price.String)
+}
@@ -6,6 +6,8 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"time"
 )
 
 type Address struct {
@@ -19,10 +21,48 @@ type Address struct {
 	ZipCode       sql.NullString `json:"zip_code"`
 }
 
+type AdminAction struct {
+	ID        int32          `json:"id"`
+	ActorID   int32          `json:"actor_id"`
+	Action    string         `json:"action"`
+	TargetID  int32          `json:"target_id"`
+	Reason    sql.NullString `json:"reason"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type ApiKey struct {
+	ID         int32        `json:"id"`
+	StoreID    int32        `json:"store_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"key_hash"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type AuditLog struct {
+	ID           int64           `json:"id"`
+	Service      string          `json:"service"`
+	Actor        sql.NullString  `json:"actor"`
+	Impersonator sql.NullString  `json:"impersonator"`
+	Action       string          `json:"action"`
+	TargetType   string          `json:"target_type"`
+	TargetID     string          `json:"target_id"`
+	Before       json.RawMessage `json:"before"`
+	After        json.RawMessage `json:"after"`
+	RequestID    sql.NullString  `json:"request_id"`
+	Ip           sql.NullString  `json:"ip"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
 type Cart struct {
 	ID        int32          `json:"id"`
 	UserID    sql.NullInt32  `json:"user_id"`
 	ProductID sql.NullInt32  `json:"product_id"`
+	VariantID sql.NullInt32  `json:"variant_id"`
 	Quantity  sql.NullInt32  `json:"quantity"`
 	Price     sql.NullString `json:"price"`
 }
@@ -34,40 +74,226 @@ type Category struct {
 	ParentCategoryID sql.NullInt32  `json:"parent_category_id"`
 }
 
+type DeadLetter struct {
+	ID           int64        `json:"id"`
+	Consumer     string       `json:"consumer"`
+	Topic        string       `json:"topic"`
+	PartitionKey string       `json:"partition_key"`
+	EventID      string       `json:"event_id"`
+	Payload      []byte       `json:"payload"`
+	Error        string       `json:"error"`
+	Attempts     int32        `json:"attempts"`
+	CreatedAt    time.Time    `json:"created_at"`
+	ReplayedAt   sql.NullTime `json:"replayed_at"`
+}
+
+type LoginThrottle struct {
+	Scope        string       `json:"scope"`
+	Subject      string       `json:"subject"`
+	Failures     int32        `json:"failures"`
+	LastFailedAt sql.NullTime `json:"last_failed_at"`
+	LockedUntil  sql.NullTime `json:"locked_until"`
+}
+
+type Notification struct {
+	ID        int64        `json:"id"`
+	UserID    int32        `json:"user_id"`
+	Kind      string       `json:"kind"`
+	Subject   string       `json:"subject"`
+	Body      string       `json:"body"`
+	CreatedAt time.Time    `json:"created_at"`
+	ReadAt    sql.NullTime `json:"read_at"`
+}
+
+type NotificationPreference struct {
+	UserID    int32     `json:"user_id"`
+	Category  string    `json:"category"`
+	Email     bool      `json:"email"`
+	InApp     bool      `json:"in_app"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OptionType struct {
+	ID        int32  `json:"id"`
+	ProductID int32  `json:"product_id"`
+	Name      string `json:"name"`
+	Position  int32  `json:"position"`
+}
+
+type OptionValue struct {
+	ID           int32  `json:"id"`
+	OptionTypeID int32  `json:"option_type_id"`
+	Value        string `json:"value"`
+	Position     int32  `json:"position"`
+}
+
 type Order struct {
-	ID          int32          `json:"id"`
-	UserID      sql.NullInt32  `json:"user_id"`
-	StoreID     sql.NullInt32  `json:"store_id"`
-	OrderDate   sql.NullTime   `json:"order_date"`
-	TotalAmount sql.NullString `json:"total_amount"`
-	Status      sql.NullString `json:"status"`
+	ID             int32          `json:"id"`
+	UserID         sql.NullInt32  `json:"user_id"`
+	StoreID        sql.NullInt32  `json:"store_id"`
+	OrderDate      sql.NullTime   `json:"order_date"`
+	Currency       sql.NullString `json:"currency"`
+	SubtotalAmount sql.NullString `json:"subtotal_amount"`
+	DiscountAmount string         `json:"discount_amount"`
+	TotalAmount    sql.NullString `json:"total_amount"`
+	CouponCode     sql.NullString `json:"coupon_code"`
+	Status         sql.NullString `json:"status"`
 }
 
 type OrderItem struct {
 	ID        int32          `json:"id"`
 	OrderID   sql.NullInt32  `json:"order_id"`
 	ProductID sql.NullInt32  `json:"product_id"`
+	VariantID sql.NullInt32  `json:"variant_id"`
 	Quantity  sql.NullInt32  `json:"quantity"`
 	Price     sql.NullString `json:"price"`
+	Discount  string         `json:"discount"`
 	CreatedAt sql.NullTime   `json:"created_at"`
 }
 
+type Outbox struct {
+	ID           int64          `json:"id"`
+	Service      string         `json:"service"`
+	Topic        string         `json:"topic"`
+	PartitionKey string         `json:"partition_key"`
+	Payload      []byte         `json:"payload"`
+	CreatedAt    time.Time      `json:"created_at"`
+	PublishedAt  sql.NullTime   `json:"published_at"`
+	Attempts     int32          `json:"attempts"`
+	LastError    sql.NullString `json:"last_error"`
+	FailedAt     sql.NullTime   `json:"failed_at"`
+}
+
+type OutgoingEmail struct {
+	ID        int64          `json:"id"`
+	UserID    int32          `json:"user_id"`
+	Email     string         `json:"email"`
+	Kind      string         `json:"kind"`
+	Subject   string         `json:"subject"`
+	Body      string         `json:"body"`
+	CreatedAt time.Time      `json:"created_at"`
+	SentAt    sql.NullTime   `json:"sent_at"`
+	Attempts  int32          `json:"attempts"`
+	LastError sql.NullString `json:"last_error"`
+	FailedAt  sql.NullTime   `json:"failed_at"`
+}
+
 type ParentCategory struct {
 	ID          int32          `json:"id"`
 	Name        sql.NullString `json:"name"`
 	Description sql.NullString `json:"description"`
 }
 
+type ProcessedEvent struct {
+	Consumer    string    `json:"consumer"`
+	EventID     string    `json:"event_id"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
 type Product struct {
-	ID          int32          `json:"id"`
-	StoreID     sql.NullInt32  `json:"store_id"`
-	Name        sql.NullString `json:"name"`
-	Description sql.NullString `json:"description"`
-	Price       sql.NullString `json:"price"`
-	ImageUrl    sql.NullString `json:"image_url"`
-	Stock       sql.NullInt32  `json:"stock"`
-	CategoryID  sql.NullInt32  `json:"category_id"`
-	CreatedAt   sql.NullTime   `json:"created_at"`
+	ID            int32          `json:"id"`
+	StoreID       sql.NullInt32  `json:"store_id"`
+	Sku           sql.NullString `json:"sku"`
+	Name          sql.NullString `json:"name"`
+	Description   sql.NullString `json:"description"`
+	Price         sql.NullString `json:"price"`
+	Currency      string         `json:"currency"`
+	ImageUrl      sql.NullString `json:"image_url"`
+	Stock         sql.NullInt32  `json:"stock"`
+	CategoryID    sql.NullInt32  `json:"category_id"`
+	RatingAverage string         `json:"rating_average"`
+	RatingCount   int32          `json:"rating_count"`
+	CreatedAt     sql.NullTime   `json:"created_at"`
+	SearchVector  interface{}    `json:"search_vector"`
+}
+
+type ProductVariant struct {
+	ID        int32          `json:"id"`
+	ProductID int32          `json:"product_id"`
+	Sku       string         `json:"sku"`
+	Price     string         `json:"price"`
+	Currency  string         `json:"currency"`
+	Stock     int32          `json:"stock"`
+	ImageUrl  sql.NullString `json:"image_url"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type Promotion struct {
+	ID         int32          `json:"id"`
+	Name       string         `json:"name"`
+	Kind       string         `json:"kind"`
+	PercentOff sql.NullString `json:"percent_off"`
+	AmountOff  sql.NullString `json:"amount_off"`
+	Currency   sql.NullString `json:"currency"`
+	StoreID    sql.NullInt32  `json:"store_id"`
+	CategoryID sql.NullInt32  `json:"category_id"`
+	CouponCode sql.NullString `json:"coupon_code"`
+	UsageLimit sql.NullInt32  `json:"usage_limit"`
+	UsageCount int32          `json:"usage_count"`
+	StartsAt   time.Time      `json:"starts_at"`
+	ExpiresAt  sql.NullTime   `json:"expires_at"`
+	Active     bool           `json:"active"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type PromotionRedemption struct {
+	ID          int32     `json:"id"`
+	PromotionID int32     `json:"promotion_id"`
+	OrderID     int32     `json:"order_id"`
+	Amount      string    `json:"amount"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RecoveryCode struct {
+	ID       int32        `json:"id"`
+	UserID   int32        `json:"user_id"`
+	CodeHash string       `json:"code_hash"`
+	UsedAt   sql.NullTime `json:"used_at"`
+}
+
+type RefreshToken struct {
+	TokenHash string       `json:"token_hash"`
+	SessionID int32        `json:"session_id"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+type Review struct {
+	ID               int32     `json:"id"`
+	ProductID        int32     `json:"product_id"`
+	UserID           int32     `json:"user_id"`
+	Rating           int16     `json:"rating"`
+	Body             string    `json:"body"`
+	VerifiedPurchase bool      `json:"verified_purchase"`
+	Status           string    `json:"status"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type Session struct {
+	ID              int32          `json:"id"`
+	UserID          int32          `json:"user_id"`
+	Device          sql.NullString `json:"device"`
+	Ip              sql.NullString `json:"ip"`
+	UserAgent       sql.NullString `json:"user_agent"`
+	AccessHash      string         `json:"access_hash"`
+	AccessExpiresAt time.Time      `json:"access_expires_at"`
+	ExpiresAt       time.Time      `json:"expires_at"`
+	CreatedAt       sql.NullTime   `json:"created_at"`
+	LastSeenAt      sql.NullTime   `json:"last_seen_at"`
+	RevokedAt       sql.NullTime   `json:"revoked_at"`
+	ImpersonatorID  sql.NullInt32  `json:"impersonator_id"`
+}
+
+type StockMovement struct {
+	ID        int64          `json:"id"`
+	ProductID int32          `json:"product_id"`
+	VariantID sql.NullInt32  `json:"variant_id"`
+	Quantity  int32          `json:"quantity"`
+	Reason    string         `json:"reason"`
+	Reference sql.NullString `json:"reference"`
+	Note      sql.NullString `json:"note"`
+	CreatedAt time.Time      `json:"created_at"`
 }
 
 type Store struct {
@@ -79,11 +305,48 @@ type Store struct {
 }
 
 type User struct {
-	ID        int32          `json:"id"`
-	FirstName sql.NullString `json:"first_name"`
-	LastName  sql.NullString `json:"last_name"`
-	Email     sql.NullString `json:"email"`
-	Username  sql.NullString `json:"username"`
-	Password  sql.NullString `json:"password"`
-	CreatedAt sql.NullTime   `json:"created_at"`
+	ID                    int32          `json:"id"`
+	FirstName             sql.NullString `json:"first_name"`
+	LastName              sql.NullString `json:"last_name"`
+	Email                 sql.NullString `json:"email"`
+	Username              sql.NullString `json:"username"`
+	Password              sql.NullString `json:"password"`
+	CreatedAt             sql.NullTime   `json:"created_at"`
+	DeactivatedAt         sql.NullTime   `json:"deactivated_at"`
+	DeletedAt             sql.NullTime   `json:"deleted_at"`
+	PurgedAt              sql.NullTime   `json:"purged_at"`
+	TotpSecret            sql.NullString `json:"totp_secret"`
+	TotpEnabledAt         sql.NullTime   `json:"totp_enabled_at"`
+	Role                  string         `json:"role"`
+	SuspendedAt           sql.NullTime   `json:"suspended_at"`
+	SuspensionReason      sql.NullString `json:"suspension_reason"`
+	PasswordResetRequired bool           `json:"password_reset_required"`
+}
+
+type UserProfile struct {
+	UserID         int32          `json:"user_id"`
+	DisplayName    sql.NullString `json:"display_name"`
+	AvatarUrl      sql.NullString `json:"avatar_url"`
+	Phone          sql.NullString `json:"phone"`
+	Locale         sql.NullString `json:"locale"`
+	Timezone       sql.NullString `json:"timezone"`
+	MarketingEmail bool           `json:"marketing_email"`
+	MarketingSms   bool           `json:"marketing_sms"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+type VariantOptionValue struct {
+	VariantID     int32 `json:"variant_id"`
+	OptionValueID int32 `json:"option_value_id"`
+}
+
+type WishlistItem struct {
+	ID          int32          `json:"id"`
+	UserID      int32          `json:"user_id"`
+	ProductID   int32          `json:"product_id"`
+	VariantID   sql.NullInt32  `json:"variant_id"`
+	AddedPrice  sql.NullString `json:"added_price"`
+	SeenPrice   sql.NullString `json:"seen_price"`
+	SeenInStock bool           `json:"seen_in_stock"`
+	CreatedAt   time.Time      `json:"created_at"`
 }
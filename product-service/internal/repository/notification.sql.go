@@ -0,0 +1,422 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: notification.sql
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const countUnreadNotifications = `-- name: CountUnreadNotifications :one
+SELECT count(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL
+`
+
+func (q *Queries) CountUnreadNotifications(ctx context.Context, userID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnreadNotifications, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createNotification = `-- name: CreateNotification :one
+INSERT INTO notifications (
+  user_id,
+  kind,
+  subject,
+  body
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING id, user_id, kind, subject, body, created_at, read_at
+`
+
+type CreateNotificationParams struct {
+	UserID  int32  `json:"user_id"`
+	Kind    string `json:"kind"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error) {
+	row := q.db.QueryRowContext(ctx, createNotification,
+		arg.UserID,
+		arg.Kind,
+		arg.Subject,
+		arg.Body,
+	)
+	var i Notification
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Kind,
+		&i.Subject,
+		&i.Body,
+		&i.CreatedAt,
+		&i.ReadAt,
+	)
+	return i, err
+}
+
+const deleteEmailsByUser = `-- name: DeleteEmailsByUser :exec
+DELETE FROM outgoing_emails
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteEmailsByUser(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteEmailsByUser, userID)
+	return err
+}
+
+const deleteNotificationPreferencesByUser = `-- name: DeleteNotificationPreferencesByUser :exec
+DELETE FROM notification_preferences
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteNotificationPreferencesByUser(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteNotificationPreferencesByUser, userID)
+	return err
+}
+
+const deleteNotificationsByUser = `-- name: DeleteNotificationsByUser :exec
+DELETE FROM notifications
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteNotificationsByUser(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteNotificationsByUser, userID)
+	return err
+}
+
+const getNotificationPreference = `-- name: GetNotificationPreference :one
+SELECT user_id, category, email, in_app, updated_at FROM notification_preferences
+WHERE user_id = $1 AND category = $2
+`
+
+type GetNotificationPreferenceParams struct {
+	UserID   int32  `json:"user_id"`
+	Category string `json:"category"`
+}
+
+func (q *Queries) GetNotificationPreference(ctx context.Context, arg GetNotificationPreferenceParams) (NotificationPreference, error) {
+	row := q.db.QueryRowContext(ctx, getNotificationPreference, arg.UserID, arg.Category)
+	var i NotificationPreference
+	err := row.Scan(
+		&i.UserID,
+		&i.Category,
+		&i.Email,
+		&i.InApp,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getNotificationRecipient = `-- name: GetNotificationRecipient :one
+SELECT u.id, u.username, u.email, coalesce(p.display_name, '')::varchar AS display_name,
+  coalesce(p.locale, '')::varchar AS locale
+FROM users u
+LEFT JOIN user_profiles p ON p.user_id = u.id
+WHERE u.id = $1 AND u.email IS NOT NULL
+  AND u.deleted_at IS NULL AND u.deactivated_at IS NULL
+`
+
+type GetNotificationRecipientRow struct {
+	ID          int32          `json:"id"`
+	Username    sql.NullString `json:"username"`
+	Email       sql.NullString `json:"email"`
+	DisplayName string         `json:"display_name"`
+	Locale      string         `json:"locale"`
+}
+
+// Deleted and deactivated users are not sent anything.
+func (q *Queries) GetNotificationRecipient(ctx context.Context, id int32) (GetNotificationRecipientRow, error) {
+	row := q.db.QueryRowContext(ctx, getNotificationRecipient, id)
+	var i GetNotificationRecipientRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.DisplayName,
+		&i.Locale,
+	)
+	return i, err
+}
+
+const listNotificationPreferences = `-- name: ListNotificationPreferences :many
+SELECT user_id, category, email, in_app, updated_at FROM notification_preferences
+WHERE user_id = $1
+ORDER BY category
+`
+
+func (q *Queries) ListNotificationPreferences(ctx context.Context, userID int32) ([]NotificationPreference, error) {
+	rows, err := q.db.QueryContext(ctx, listNotificationPreferences, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationPreference
+	for rows.Next() {
+		var i NotificationPreference
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Category,
+			&i.Email,
+			&i.InApp,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotifications = `-- name: ListNotifications :many
+SELECT id, user_id, kind, subject, body, created_at, read_at FROM notifications
+WHERE user_id = $1
+  AND (NOT $2::boolean OR read_at IS NULL)
+  AND ($3::bigint = 0 OR id < $3::bigint)
+ORDER BY id DESC
+LIMIT $4::integer
+`
+
+type ListNotificationsParams struct {
+	UserID     int32 `json:"user_id"`
+	UnreadOnly bool  `json:"unread_only"`
+	BeforeID   int64 `json:"before_id"`
+	PageSize   int32 `json:"page_size"`
+}
+
+// Newest first, pass the id of the last one seen as before_id for the next
+// page.
+func (q *Queries) ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error) {
+	rows, err := q.db.QueryContext(ctx, listNotifications,
+		arg.UserID,
+		arg.UnreadOnly,
+		arg.BeforeID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Notification
+	for rows.Next() {
+		var i Notification
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Kind,
+			&i.Subject,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingEmails = `-- name: ListPendingEmails :many
+SELECT id, user_id, email, kind, subject, body, created_at, sent_at, attempts, last_error, failed_at FROM outgoing_emails
+WHERE sent_at IS NULL AND failed_at IS NULL
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+// Locks the emails so a second mailer skips them instead of sending them
+// twice. Parked emails are left out.
+func (q *Queries) ListPendingEmails(ctx context.Context, limit int32) ([]OutgoingEmail, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingEmails, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OutgoingEmail
+	for rows.Next() {
+		var i OutgoingEmail
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Email,
+			&i.Kind,
+			&i.Subject,
+			&i.Body,
+			&i.CreatedAt,
+			&i.SentAt,
+			&i.Attempts,
+			&i.LastError,
+			&i.FailedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markAllNotificationsRead = `-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications SET read_at = now()
+WHERE user_id = $1 AND read_at IS NULL
+`
+
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markAllNotificationsRead, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markEmailFailed = `-- name: MarkEmailFailed :one
+UPDATE outgoing_emails SET
+  attempts = attempts + 1,
+  last_error = $1,
+  failed_at = CASE WHEN attempts + 1 >= $2::integer THEN now() END
+WHERE id = $3
+RETURNING (failed_at IS NOT NULL)::boolean AS parked
+`
+
+type MarkEmailFailedParams struct {
+	LastError   sql.NullString `json:"last_error"`
+	MaxAttempts int32          `json:"max_attempts"`
+	ID          int64          `json:"id"`
+}
+
+// Parks the email once it failed max_attempts times and reports whether it
+// did.
+func (q *Queries) MarkEmailFailed(ctx context.Context, arg MarkEmailFailedParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, markEmailFailed, arg.LastError, arg.MaxAttempts, arg.ID)
+	var parked bool
+	err := row.Scan(&parked)
+	return parked, err
+}
+
+const markEmailSent = `-- name: MarkEmailSent :exec
+UPDATE outgoing_emails SET sent_at = now(), attempts = attempts + 1, last_error = NULL
+WHERE id = $1
+`
+
+func (q *Queries) MarkEmailSent(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markEmailSent, id)
+	return err
+}
+
+const markNotificationsRead = `-- name: MarkNotificationsRead :execrows
+UPDATE notifications SET read_at = now()
+WHERE user_id = $1 AND read_at IS NULL
+  AND id = ANY($2::bigint[])
+`
+
+type MarkNotificationsReadParams struct {
+	UserID int32   `json:"user_id"`
+	Ids    []int64 `json:"ids"`
+}
+
+func (q *Queries) MarkNotificationsRead(ctx context.Context, arg MarkNotificationsReadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markNotificationsRead, arg.UserID, pq.Array(arg.Ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const queueEmail = `-- name: QueueEmail :exec
+INSERT INTO outgoing_emails (
+  user_id,
+  email,
+  kind,
+  subject,
+  body
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+`
+
+type QueueEmailParams struct {
+	UserID  int32  `json:"user_id"`
+	Email   string `json:"email"`
+	Kind    string `json:"kind"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Written in the transaction of the notification, the mailer sends the email
+// once that transaction has committed.
+func (q *Queries) QueueEmail(ctx context.Context, arg QueueEmailParams) error {
+	_, err := q.db.ExecContext(ctx, queueEmail,
+		arg.UserID,
+		arg.Email,
+		arg.Kind,
+		arg.Subject,
+		arg.Body,
+	)
+	return err
+}
+
+const upsertNotificationPreference = `-- name: UpsertNotificationPreference :one
+INSERT INTO notification_preferences (
+  user_id,
+  category,
+  email,
+  in_app
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (user_id, category) DO UPDATE SET
+  email = excluded.email,
+  in_app = excluded.in_app,
+  updated_at = now()
+RETURNING user_id, category, email, in_app, updated_at
+`
+
+type UpsertNotificationPreferenceParams struct {
+	UserID   int32  `json:"user_id"`
+	Category string `json:"category"`
+	Email    bool   `json:"email"`
+	InApp    bool   `json:"in_app"`
+}
+
+func (q *Queries) UpsertNotificationPreference(ctx context.Context, arg UpsertNotificationPreferenceParams) (NotificationPreference, error) {
+	row := q.db.QueryRowContext(ctx, upsertNotificationPreference,
+		arg.UserID,
+		arg.Category,
+		arg.Email,
+		arg.InApp,
+	)
+	var i NotificationPreference
+	err := row.Scan(
+		&i.UserID,
+		&i.Category,
+		&i.Email,
+		&i.InApp,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
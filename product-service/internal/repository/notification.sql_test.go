@@ -0,0 +1,148 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var userID, gone int32
+	err := testDb.QueryRowContext(ctx, "insert into users (username, email) values ('reader', 'reader@mail.com') returning id").Scan(&userID)
+	require.NoError(t, err)
+	err = testDb.QueryRowContext(ctx, "insert into users (email, deactivated_at) values ('gone@mail.com', now()) returning id").Scan(&gone)
+	require.NoError(t, err)
+
+	recipient, err := productRepo.GetNotificationRecipient(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, "reader@mail.com", recipient.Email.String)
+	require.Empty(t, recipient.Locale, "no profile")
+	_, err = productRepo.GetNotificationRecipient(ctx, gone)
+	require.ErrorIs(t, err, sql.ErrNoRows, "deactivated users are not notified")
+
+	var ids []int64
+	for _, subject := range []string{"first", "second", "third"} {
+		n, err := productRepo.CreateNotification(ctx, repository.CreateNotificationParams{
+			UserID:  userID,
+			Kind:    "welcome",
+			Subject: subject,
+			Body:    "body\n",
+		})
+		require.NoError(t, err)
+		ids = append(ids, n.ID)
+	}
+
+	page, err := productRepo.ListNotifications(ctx, repository.ListNotificationsParams{UserID: userID, PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	require.Equal(t, "third", page[0].Subject, "newest first")
+	page, err = productRepo.ListNotifications(ctx, repository.ListNotificationsParams{UserID: userID, BeforeID: page[1].ID, PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.Equal(t, "first", page[0].Subject)
+
+	marked, err := productRepo.MarkNotificationsRead(ctx, repository.MarkNotificationsReadParams{UserID: userID, Ids: ids[:2]})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), marked)
+	marked, err = productRepo.MarkNotificationsRead(ctx, repository.MarkNotificationsReadParams{UserID: gone, Ids: ids})
+	require.NoError(t, err)
+	require.Zero(t, marked, "only the own notifications")
+	unread, err := productRepo.CountUnreadNotifications(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), unread)
+	page, err = productRepo.ListNotifications(ctx, repository.ListNotificationsParams{UserID: userID, UnreadOnly: true, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.Equal(t, ids[2], page[0].ID)
+
+	marked, err = productRepo.MarkAllNotificationsRead(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), marked)
+
+	err = productRepo.DeleteNotificationsByUser(ctx, userID)
+	require.NoError(t, err)
+	page, err = productRepo.ListNotifications(ctx, repository.ListNotificationsParams{UserID: userID, PageSize: 10})
+	require.NoError(t, err)
+	require.Empty(t, page)
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var userID int32
+	err := testDb.QueryRowContext(ctx, "insert into users (email) values ('picky@mail.com') returning id").Scan(&userID)
+	require.NoError(t, err)
+
+	_, err = productRepo.GetNotificationPreference(ctx, repository.GetNotificationPreferenceParams{UserID: userID, Category: "orders"})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	saved, err := productRepo.UpsertNotificationPreference(ctx, repository.UpsertNotificationPreferenceParams{UserID: userID, Category: "orders", InApp: true})
+	require.NoError(t, err)
+	require.False(t, saved.Email)
+	saved, err = productRepo.UpsertNotificationPreference(ctx, repository.UpsertNotificationPreferenceParams{UserID: userID, Category: "orders", Email: true})
+	require.NoError(t, err)
+	require.True(t, saved.Email)
+	require.False(t, saved.InApp)
+
+	preferences, err := productRepo.ListNotificationPreferences(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, preferences, 1)
+
+	err = productRepo.DeleteNotificationPreferencesByUser(ctx, userID)
+	require.NoError(t, err)
+	preferences, err = productRepo.ListNotificationPreferences(ctx, userID)
+	require.NoError(t, err)
+	require.Empty(t, preferences)
+}
+
+func TestOutgoingEmails(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var userID int32
+	err := testDb.QueryRowContext(ctx, "insert into users (username, email) values ('mailed', 'mailed@mail.com') returning id").Scan(&userID)
+	require.NoError(t, err)
+	for _, subject := range []string{"first", "second"} {
+		err = productRepo.QueueEmail(ctx, repository.QueueEmailParams{
+			UserID:  userID,
+			Email:   "mailed@mail.com",
+			Kind:    "welcome",
+			Subject: subject,
+			Body:    "body\n",
+		})
+		require.NoError(t, err)
+	}
+
+	pending, err := productRepo.ListPendingEmails(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	require.Equal(t, "first", pending[0].Subject, "oldest first")
+
+	err = productRepo.MarkEmailSent(ctx, pending[0].ID)
+	require.NoError(t, err)
+	parked, err := productRepo.MarkEmailFailed(ctx, repository.MarkEmailFailedParams{
+		ID:          pending[1].ID,
+		LastError:   sql.NullString{String: "mailbox is down", Valid: true},
+		MaxAttempts: 1,
+	})
+	require.NoError(t, err)
+	require.True(t, parked)
+	pending, err = productRepo.ListPendingEmails(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, pending, "sent and parked emails are not sent again")
+
+	err = productRepo.DeleteEmailsByUser(ctx, userID)
+	require.NoError(t, err)
+	var left int
+	err = testDb.QueryRowContext(ctx, "select count(*) from outgoing_emails where user_id = $1", userID).Scan(&left)
+	require.NoError(t, err)
+	require.Zero(t, left)
+}
@@ -0,0 +1,266 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: order.sql
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const cancelOrder = `-- name: CancelOrder :one
+UPDATE orders SET status = 'cancelled'
+WHERE id = $1 AND user_id = $2 AND status = 'pending'
+RETURNING id, user_id, store_id, order_date, currency, subtotal_amount, discount_amount, total_amount, coupon_code, status
+`
+
+type CancelOrderParams struct {
+	ID     int32         `json:"id"`
+	UserID sql.NullInt32 `json:"user_id"`
+}
+
+// Only a pending order of the user can be cancelled.
+func (q *Queries) CancelOrder(ctx context.Context, arg CancelOrderParams) (Order, error) {
+	row := q.db.QueryRowContext(ctx, cancelOrder, arg.ID, arg.UserID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.StoreID,
+		&i.OrderDate,
+		&i.Currency,
+		&i.SubtotalAmount,
+		&i.DiscountAmount,
+		&i.TotalAmount,
+		&i.CouponCode,
+		&i.Status,
+	)
+	return i, err
+}
+
+const createOrder = `-- name: CreateOrder :one
+INSERT INTO orders (
+  user_id,
+  store_id,
+  currency,
+  subtotal_amount,
+  discount_amount,
+  total_amount,
+  coupon_code,
+  status
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING id, user_id, store_id, order_date, currency, subtotal_amount, discount_amount, total_amount, coupon_code, status
+`
+
+type CreateOrderParams struct {
+	UserID         sql.NullInt32  `json:"user_id"`
+	StoreID        sql.NullInt32  `json:"store_id"`
+	Currency       sql.NullString `json:"currency"`
+	SubtotalAmount sql.NullString `json:"subtotal_amount"`
+	DiscountAmount string         `json:"discount_amount"`
+	TotalAmount    sql.NullString `json:"total_amount"`
+	CouponCode     sql.NullString `json:"coupon_code"`
+	Status         sql.NullString `json:"status"`
+}
+
+func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
+	row := q.db.QueryRowContext(ctx, createOrder,
+		arg.UserID,
+		arg.StoreID,
+		arg.Currency,
+		arg.SubtotalAmount,
+		arg.DiscountAmount,
+		arg.TotalAmount,
+		arg.CouponCode,
+		arg.Status,
+	)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.StoreID,
+		&i.OrderDate,
+		&i.Currency,
+		&i.SubtotalAmount,
+		&i.DiscountAmount,
+		&i.TotalAmount,
+		&i.CouponCode,
+		&i.Status,
+	)
+	return i, err
+}
+
+const createOrderItem = `-- name: CreateOrderItem :exec
+INSERT INTO order_items (
+  order_id,
+  product_id,
+  variant_id,
+  quantity,
+  price,
+  discount
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+`
+
+type CreateOrderItemParams struct {
+	OrderID   sql.NullInt32  `json:"order_id"`
+	ProductID sql.NullInt32  `json:"product_id"`
+	VariantID sql.NullInt32  `json:"variant_id"`
+	Quantity  sql.NullInt32  `json:"quantity"`
+	Price     sql.NullString `json:"price"`
+	Discount  string         `json:"discount"`
+}
+
+func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) error {
+	_, err := q.db.ExecContext(ctx, createOrderItem,
+		arg.OrderID,
+		arg.ProductID,
+		arg.VariantID,
+		arg.Quantity,
+		arg.Price,
+		arg.Discount,
+	)
+	return err
+}
+
+const listOrderItems = `-- name: ListOrderItems :many
+SELECT oi.order_id, oi.product_id, oi.variant_id, p.name, oi.quantity, oi.price, oi.discount
+FROM order_items oi
+LEFT JOIN products p ON p.id = oi.product_id
+WHERE oi.order_id = $1
+ORDER BY oi.id
+`
+
+type ListOrderItemsRow struct {
+	OrderID   sql.NullInt32  `json:"order_id"`
+	ProductID sql.NullInt32  `json:"product_id"`
+	VariantID sql.NullInt32  `json:"variant_id"`
+	Name      sql.NullString `json:"name"`
+	Quantity  sql.NullInt32  `json:"quantity"`
+	Price     sql.NullString `json:"price"`
+	Discount  string         `json:"discount"`
+}
+
+func (q *Queries) ListOrderItems(ctx context.Context, orderID sql.NullInt32) ([]ListOrderItemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrderItems, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrderItemsRow
+	for rows.Next() {
+		var i ListOrderItemsRow
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.ProductID,
+			&i.VariantID,
+			&i.Name,
+			&i.Quantity,
+			&i.Price,
+			&i.Discount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProductsForPricing = `-- name: ListProductsForPricing :many
+SELECT id, name, store_id, category_id, price, currency FROM products
+WHERE id = ANY($1::integer[])
+`
+
+type ListProductsForPricingRow struct {
+	ID         int32          `json:"id"`
+	Name       sql.NullString `json:"name"`
+	StoreID    sql.NullInt32  `json:"store_id"`
+	CategoryID sql.NullInt32  `json:"category_id"`
+	Price      sql.NullString `json:"price"`
+	Currency   string         `json:"currency"`
+}
+
+func (q *Queries) ListProductsForPricing(ctx context.Context, ids []int32) ([]ListProductsForPricingRow, error) {
+	rows, err := q.db.QueryContext(ctx, listProductsForPricing, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProductsForPricingRow
+	for rows.Next() {
+		var i ListProductsForPricingRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.StoreID,
+			&i.CategoryID,
+			&i.Price,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVariantsForPricing = `-- name: ListVariantsForPricing :many
+SELECT id, product_id, sku, price, currency FROM product_variants
+WHERE id = ANY($1::integer[])
+`
+
+type ListVariantsForPricingRow struct {
+	ID        int32  `json:"id"`
+	ProductID int32  `json:"product_id"`
+	Sku       string `json:"sku"`
+	Price     string `json:"price"`
+	Currency  string `json:"currency"`
+}
+
+func (q *Queries) ListVariantsForPricing(ctx context.Context, ids []int32) ([]ListVariantsForPricingRow, error) {
+	rows, err := q.db.QueryContext(ctx, listVariantsForPricing, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVariantsForPricingRow
+	for rows.Next() {
+		var i ListVariantsForPricingRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.Sku,
+			&i.Price,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,92 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrders(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var userID int32
+	err := testDb.QueryRowContext(ctx, "insert into users (email) values ('shopper@mail.com') returning id").Scan(&userID)
+	require.NoError(t, err)
+	created, err := productRepo.CreateProduct(ctx, repository.CreateProductParams{
+		Name:     sql.NullString{String: "Hoodie", Valid: true},
+		Price:    sql.NullString{String: "40", Valid: true},
+		Currency: "USD",
+		Stock:    sql.NullInt32{Int32: 5, Valid: true},
+	})
+	require.NoError(t, err)
+	variant, err := productRepo.CreateVariant(ctx, repository.CreateVariantParams{
+		ProductID: created.ID,
+		Sku:       "HOODIE-L",
+		Price:     "42.00",
+		Currency:  "USD",
+		Stock:     1,
+	})
+	require.NoError(t, err)
+
+	products, err := productRepo.ListProductsForPricing(ctx, []int32{created.ID, -1})
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	require.Equal(t, "40.00", products[0].Price.String)
+	variants, err := productRepo.ListVariantsForPricing(ctx, []int32{variant.ID})
+	require.NoError(t, err)
+	require.Equal(t, created.ID, variants[0].ProductID)
+
+	order, err := productRepo.CreateOrder(ctx, repository.CreateOrderParams{
+		UserID:         sql.NullInt32{Int32: userID, Valid: true},
+		Currency:       sql.NullString{String: "USD", Valid: true},
+		SubtotalAmount: sql.NullString{String: "82.00", Valid: true},
+		DiscountAmount: "8.20",
+		TotalAmount:    sql.NullString{String: "73.80", Valid: true},
+		Status:         sql.NullString{String: "pending", Valid: true},
+	})
+	require.NoError(t, err)
+	require.True(t, order.OrderDate.Valid)
+	require.Equal(t, "8.20", order.DiscountAmount)
+
+	err = productRepo.CreateOrderItem(ctx, repository.CreateOrderItemParams{
+		OrderID:   sql.NullInt32{Int32: order.ID, Valid: true},
+		ProductID: sql.NullInt32{Int32: created.ID, Valid: true},
+		Quantity:  sql.NullInt32{Int32: 1, Valid: true},
+		Price:     sql.NullString{String: "40.00", Valid: true},
+		Discount:  "4.00",
+	})
+	require.NoError(t, err)
+	err = productRepo.CreateOrderItem(ctx, repository.CreateOrderItemParams{
+		OrderID:   sql.NullInt32{Int32: order.ID, Valid: true},
+		ProductID: sql.NullInt32{Int32: created.ID, Valid: true},
+		VariantID: sql.NullInt32{Int32: variant.ID, Valid: true},
+		Quantity:  sql.NullInt32{Int32: 1, Valid: true},
+		Price:     sql.NullString{String: "42.00", Valid: true},
+		Discount:  "4.20",
+	})
+	require.NoError(t, err)
+
+	sale, err := productRepo.CreatePromotion(ctx, repository.CreatePromotionParams{
+		Name:       "Hoodie season",
+		Kind:       "percentage",
+		PercentOff: sql.NullString{String: "10", Valid: true},
+		CouponCode: sql.NullString{String: "HOODIE", Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = productRepo.RedeemPromotion(ctx, sale.ID)
+	require.NoError(t, err)
+	err = productRepo.CreatePromotionRedemption(ctx, repository.CreatePromotionRedemptionParams{PromotionID: sale.ID, OrderID: order.ID, Amount: "8.20"})
+	require.NoError(t, err)
+
+	// cancelling the order gives its use of the coupon back
+	err = productRepo.ReleaseOrderPromotions(ctx, order.ID)
+	require.NoError(t, err)
+	sale, err = productRepo.GetPromotionByCoupon(ctx, "HOODIE")
+	require.NoError(t, err)
+	require.Equal(t, int32(0), sale.UsageCount)
+}
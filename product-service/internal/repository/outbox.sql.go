@@ -0,0 +1,124 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: outbox.sql
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+const insertOutboxEvent = `-- name: InsertOutboxEvent :exec
+INSERT INTO outbox (
+  service,
+  topic,
+  partition_key,
+  payload
+) VALUES (
+  $1, $2, $3, $4
+)
+`
+
+type InsertOutboxEventParams struct {
+	Service      string `json:"service"`
+	Topic        string `json:"topic"`
+	PartitionKey string `json:"partition_key"`
+	Payload      []byte `json:"payload"`
+}
+
+// Written in the transaction of the change the event describes.
+func (q *Queries) InsertOutboxEvent(ctx context.Context, arg InsertOutboxEventParams) error {
+	_, err := q.db.ExecContext(ctx, insertOutboxEvent,
+		arg.Service,
+		arg.Topic,
+		arg.PartitionKey,
+		arg.Payload,
+	)
+	return err
+}
+
+const listPendingOutboxEvents = `-- name: ListPendingOutboxEvents :many
+SELECT id, topic, partition_key, payload FROM outbox
+WHERE service = $1 AND published_at IS NULL AND failed_at IS NULL
+ORDER BY id
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+type ListPendingOutboxEventsParams struct {
+	Service string `json:"service"`
+	Limit   int32  `json:"limit"`
+}
+
+type ListPendingOutboxEventsRow struct {
+	ID           int64  `json:"id"`
+	Topic        string `json:"topic"`
+	PartitionKey string `json:"partition_key"`
+	Payload      []byte `json:"payload"`
+}
+
+// Locks the events so a second relay skips them instead of sending them twice.
+// Parked events are left out.
+func (q *Queries) ListPendingOutboxEvents(ctx context.Context, arg ListPendingOutboxEventsParams) ([]ListPendingOutboxEventsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingOutboxEvents, arg.Service, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPendingOutboxEventsRow
+	for rows.Next() {
+		var i ListPendingOutboxEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Topic,
+			&i.PartitionKey,
+			&i.Payload,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOutboxEventFailed = `-- name: MarkOutboxEventFailed :one
+UPDATE outbox SET
+  attempts = attempts + 1,
+  last_error = $1,
+  failed_at = CASE WHEN attempts + 1 >= $2::integer THEN now() END
+WHERE id = $3
+RETURNING (failed_at IS NOT NULL)::boolean AS parked
+`
+
+type MarkOutboxEventFailedParams struct {
+	LastError   sql.NullString `json:"last_error"`
+	MaxAttempts int32          `json:"max_attempts"`
+	ID          int64          `json:"id"`
+}
+
+// Parks the event once it failed max_attempts times and reports whether it
+// did.
+func (q *Queries) MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, markOutboxEventFailed, arg.LastError, arg.MaxAttempts, arg.ID)
+	var parked bool
+	err := row.Scan(&parked)
+	return parked, err
+}
+
+const markOutboxEventPublished = `-- name: MarkOutboxEventPublished :exec
+UPDATE outbox SET published_at = now(), attempts = attempts + 1, last_error = NULL
+WHERE id = $1
+`
+
+func (q *Queries) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markOutboxEventPublished, id)
+	return err
+}
@@ -0,0 +1,60 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutbox(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for _, key := range []string{"1", "2"} {
+		err := productRepo.InsertOutboxEvent(ctx, repository.InsertOutboxEventParams{
+			Service:      "outbox-test",
+			Topic:        "order.placed",
+			PartitionKey: key,
+			Payload:      []byte("event " + key),
+		})
+		require.NoError(t, err)
+	}
+
+	pending, err := productRepo.ListPendingOutboxEvents(ctx, repository.ListPendingOutboxEventsParams{Service: "outbox-test", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	require.Equal(t, "1", pending[0].PartitionKey, "the oldest event comes first")
+	require.Equal(t, []byte("event 1"), pending[0].Payload)
+
+	err = productRepo.MarkOutboxEventPublished(ctx, pending[0].ID)
+	require.NoError(t, err)
+	failed := repository.MarkOutboxEventFailedParams{
+		ID:          pending[1].ID,
+		LastError:   sql.NullString{String: "bus is down", Valid: true},
+		MaxAttempts: 2,
+	}
+	parked, err := productRepo.MarkOutboxEventFailed(ctx, failed)
+	require.NoError(t, err)
+	require.False(t, parked)
+
+	pending, err = productRepo.ListPendingOutboxEvents(ctx, repository.ListPendingOutboxEventsParams{Service: "outbox-test", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, "2", pending[0].PartitionKey)
+	var attempts int
+	err = testDb.QueryRowContext(ctx, "select attempts from outbox where id = $1", pending[0].ID).Scan(&attempts)
+	require.NoError(t, err)
+	require.Equal(t, 1, attempts)
+
+	// the second failure parks the event
+	parked, err = productRepo.MarkOutboxEventFailed(ctx, failed)
+	require.NoError(t, err)
+	require.True(t, parked)
+	pending, err = productRepo.ListPendingOutboxEvents(ctx, repository.ListPendingOutboxEventsParams{Service: "outbox-test", Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
@@ -10,19 +10,40 @@ import (
 	"database/sql"
 )
 
+const addProductStock = `-- name: AddProductStock :one
+UPDATE products
+SET stock = coalesce(stock, 0) + $1::integer
+WHERE id = $2 AND coalesce(stock, 0) + $1 >= 0
+RETURNING stock
+`
+
+type AddProductStockParams struct {
+	Quantity int32 `json:"quantity"`
+	ID       int32 `json:"id"`
+}
+
+func (q *Queries) AddProductStock(ctx context.Context, arg AddProductStockParams) (sql.NullInt32, error) {
+	row := q.db.QueryRowContext(ctx, addProductStock, arg.Quantity, arg.ID)
+	var stock sql.NullInt32
+	err := row.Scan(&stock)
+	return stock, err
+}
+
 const createProduct = `-- name: CreateProduct :one
 INSERT INTO products (
   store_id,
   name, 
   description,
   price,
+  currency,
   image_url,
   stock,
-  category_id
+  category_id,
+  sku
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
-RETURNING id, store_id, name, description, price, image_url, stock, category_id, created_at
+RETURNING id, store_id, sku, name, description, price, currency, image_url, stock, category_id, rating_average, rating_count, created_at, search_vector
 `
 
 type CreateProductParams struct {
@@ -30,9 +51,11 @@ type CreateProductParams struct {
 	Name        sql.NullString `json:"name"`
 	Description sql.NullString `json:"description"`
 	Price       sql.NullString `json:"price"`
+	Currency    string         `json:"currency"`
 	ImageUrl    sql.NullString `json:"image_url"`
 	Stock       sql.NullInt32  `json:"stock"`
 	CategoryID  sql.NullInt32  `json:"category_id"`
+	Sku         sql.NullString `json:"sku"`
 }
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
@@ -41,21 +64,71 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		arg.Name,
 		arg.Description,
 		arg.Price,
+		arg.Currency,
 		arg.ImageUrl,
 		arg.Stock,
 		arg.CategoryID,
+		arg.Sku,
 	)
 	var i Product
 	err := row.Scan(
 		&i.ID,
 		&i.StoreID,
+		&i.Sku,
 		&i.Name,
 		&i.Description,
 		&i.Price,
+		&i.Currency,
 		&i.ImageUrl,
 		&i.Stock,
 		&i.CategoryID,
+		&i.RatingAverage,
+		&i.RatingCount,
 		&i.CreatedAt,
+		&i.SearchVector,
 	)
 	return i, err
 }
+
+const getProduct = `-- name: GetProduct :one
+SELECT id, store_id, sku, name, description, price, currency, image_url, stock, category_id, rating_average, rating_count, created_at, search_vector FROM products
+WHERE id = $1
+`
+
+func (q *Queries) GetProduct(ctx context.Context, id int32) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProduct, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.StoreID,
+		&i.Sku,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Currency,
+		&i.ImageUrl,
+		&i.Stock,
+		&i.CategoryID,
+		&i.RatingAverage,
+		&i.RatingCount,
+		&i.CreatedAt,
+		&i.SearchVector,
+	)
+	return i, err
+}
+
+const setProductStock = `-- name: SetProductStock :exec
+UPDATE products
+SET stock = $2
+WHERE id = $1
+`
+
+type SetProductStockParams struct {
+	ID    int32         `json:"id"`
+	Stock sql.NullInt32 `json:"stock"`
+}
+
+func (q *Queries) SetProductStock(ctx context.Context, arg SetProductStockParams) error {
+	_, err := q.db.ExecContext(ctx, setProductStock, arg.ID, arg.Stock)
+	return err
+}
@@ -12,8 +12,8 @@ import (
 	_ "github.com/jackc/pgx/v5"
 	_ "github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
-	"github.com/ory/dockertest"
-	"github.com/ory/dockertest/docker"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
 	"github.com/ryanpujo/product-service/internal/repository"
 	"github.com/stretchr/testify/require"
 )
@@ -114,6 +114,7 @@ func TestCreateProduct(t *testing.T) {
 		Name:        sql.NullString{String: "MacBook", Valid: true},
 		Description: sql.NullString{String: "good product", Valid: true},
 		Price:       sql.NullString{String: "2000", Valid: true},
+		Currency:    "IDR",
 		ImageUrl:    sql.NullString{String: "sjdnjdn.com", Valid: true},
 		Stock:       sql.NullInt32{Int32: 30, Valid: true},
 	}
@@ -0,0 +1,260 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: promotion.sql
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const createPromotion = `-- name: CreatePromotion :one
+INSERT INTO promotions (
+  name,
+  kind,
+  percent_off,
+  amount_off,
+  currency,
+  store_id,
+  category_id,
+  coupon_code,
+  usage_limit,
+  starts_at,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, coalesce($11::timestamp, now()), $10
+)
+RETURNING id, name, kind, percent_off, amount_off, currency, store_id, category_id, coupon_code, usage_limit, usage_count, starts_at, expires_at, active, created_at
+`
+
+type CreatePromotionParams struct {
+	Name       string         `json:"name"`
+	Kind       string         `json:"kind"`
+	PercentOff sql.NullString `json:"percent_off"`
+	AmountOff  sql.NullString `json:"amount_off"`
+	Currency   sql.NullString `json:"currency"`
+	StoreID    sql.NullInt32  `json:"store_id"`
+	CategoryID sql.NullInt32  `json:"category_id"`
+	CouponCode sql.NullString `json:"coupon_code"`
+	UsageLimit sql.NullInt32  `json:"usage_limit"`
+	ExpiresAt  sql.NullTime   `json:"expires_at"`
+	StartsAt   sql.NullTime   `json:"starts_at"`
+}
+
+func (q *Queries) CreatePromotion(ctx context.Context, arg CreatePromotionParams) (Promotion, error) {
+	row := q.db.QueryRowContext(ctx, createPromotion,
+		arg.Name,
+		arg.Kind,
+		arg.PercentOff,
+		arg.AmountOff,
+		arg.Currency,
+		arg.StoreID,
+		arg.CategoryID,
+		arg.CouponCode,
+		arg.UsageLimit,
+		arg.ExpiresAt,
+		arg.StartsAt,
+	)
+	var i Promotion
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Kind,
+		&i.PercentOff,
+		&i.AmountOff,
+		&i.Currency,
+		&i.StoreID,
+		&i.CategoryID,
+		&i.CouponCode,
+		&i.UsageLimit,
+		&i.UsageCount,
+		&i.StartsAt,
+		&i.ExpiresAt,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPromotionRedemption = `-- name: CreatePromotionRedemption :exec
+INSERT INTO promotion_redemptions (
+  promotion_id,
+  order_id,
+  amount
+) VALUES (
+  $1, $2, $3
+)
+`
+
+type CreatePromotionRedemptionParams struct {
+	PromotionID int32  `json:"promotion_id"`
+	OrderID     int32  `json:"order_id"`
+	Amount      string `json:"amount"`
+}
+
+func (q *Queries) CreatePromotionRedemption(ctx context.Context, arg CreatePromotionRedemptionParams) error {
+	_, err := q.db.ExecContext(ctx, createPromotionRedemption, arg.PromotionID, arg.OrderID, arg.Amount)
+	return err
+}
+
+const getPromotionByCoupon = `-- name: GetPromotionByCoupon :one
+SELECT id, name, kind, percent_off, amount_off, currency, store_id, category_id, coupon_code, usage_limit, usage_count, starts_at, expires_at, active, created_at FROM promotions
+WHERE upper(coupon_code) = upper($1::text)
+`
+
+func (q *Queries) GetPromotionByCoupon(ctx context.Context, code string) (Promotion, error) {
+	row := q.db.QueryRowContext(ctx, getPromotionByCoupon, code)
+	var i Promotion
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Kind,
+		&i.PercentOff,
+		&i.AmountOff,
+		&i.Currency,
+		&i.StoreID,
+		&i.CategoryID,
+		&i.CouponCode,
+		&i.UsageLimit,
+		&i.UsageCount,
+		&i.StartsAt,
+		&i.ExpiresAt,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAutomaticPromotions = `-- name: ListAutomaticPromotions :many
+SELECT id, name, kind, percent_off, amount_off, currency, store_id, category_id, coupon_code, usage_limit, usage_count, starts_at, expires_at, active, created_at FROM promotions
+WHERE active
+  AND coupon_code IS NULL
+  AND starts_at <= now()
+  AND (expires_at IS NULL OR expires_at > now())
+  AND (usage_limit IS NULL OR usage_count < usage_limit)
+  AND (store_id IS NULL OR store_id = ANY($1::integer[]))
+ORDER BY id
+`
+
+// Promotions without a coupon that currently apply to any of the stores, or to
+// every store.
+func (q *Queries) ListAutomaticPromotions(ctx context.Context, storeIds []int32) ([]Promotion, error) {
+	rows, err := q.db.QueryContext(ctx, listAutomaticPromotions, pq.Array(storeIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Promotion
+	for rows.Next() {
+		var i Promotion
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Kind,
+			&i.PercentOff,
+			&i.AmountOff,
+			&i.Currency,
+			&i.StoreID,
+			&i.CategoryID,
+			&i.CouponCode,
+			&i.UsageLimit,
+			&i.UsageCount,
+			&i.StartsAt,
+			&i.ExpiresAt,
+			&i.Active,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStorePromotions = `-- name: ListStorePromotions :many
+SELECT id, name, kind, percent_off, amount_off, currency, store_id, category_id, coupon_code, usage_limit, usage_count, starts_at, expires_at, active, created_at FROM promotions
+WHERE store_id = $1
+ORDER BY id DESC
+`
+
+func (q *Queries) ListStorePromotions(ctx context.Context, storeID sql.NullInt32) ([]Promotion, error) {
+	rows, err := q.db.QueryContext(ctx, listStorePromotions, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Promotion
+	for rows.Next() {
+		var i Promotion
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Kind,
+			&i.PercentOff,
+			&i.AmountOff,
+			&i.Currency,
+			&i.StoreID,
+			&i.CategoryID,
+			&i.CouponCode,
+			&i.UsageLimit,
+			&i.UsageCount,
+			&i.StartsAt,
+			&i.ExpiresAt,
+			&i.Active,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const redeemPromotion = `-- name: RedeemPromotion :one
+UPDATE promotions SET usage_count = usage_count + 1
+WHERE id = $1 AND (usage_limit IS NULL OR usage_count < usage_limit)
+RETURNING usage_count
+`
+
+// Counts one use, returns no rows once the usage limit is reached.
+func (q *Queries) RedeemPromotion(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRowContext(ctx, redeemPromotion, id)
+	var usage_count int32
+	err := row.Scan(&usage_count)
+	return usage_count, err
+}
+
+const releaseOrderPromotions = `-- name: ReleaseOrderPromotions :exec
+WITH released AS (
+  DELETE FROM promotion_redemptions
+  WHERE order_id = $1
+  RETURNING promotion_id
+)
+UPDATE promotions p SET usage_count = p.usage_count - r.uses
+FROM (
+  SELECT promotion_id, count(*)::integer AS uses FROM released GROUP BY promotion_id
+) r
+WHERE p.id = r.promotion_id
+`
+
+// Gives back the uses a cancelled order counted and drops its redemptions.
+func (q *Queries) ReleaseOrderPromotions(ctx context.Context, orderID int32) error {
+	_, err := q.db.ExecContext(ctx, releaseOrderPromotions, orderID)
+	return err
+}
@@ -0,0 +1,89 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromotions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var ownerID, storeID int32
+	err := testDb.QueryRowContext(ctx, "insert into users (email) values ('promoter@mail.com') returning id").Scan(&ownerID)
+	require.NoError(t, err)
+	err = testDb.QueryRowContext(ctx, "insert into stores (store_name, owner_id) values ('promote', $1) returning id", ownerID).Scan(&storeID)
+	require.NoError(t, err)
+	store := sql.NullInt32{Int32: storeID, Valid: true}
+
+	sale, err := productRepo.CreatePromotion(ctx, repository.CreatePromotionParams{
+		Name:       "Sale",
+		Kind:       "percentage",
+		PercentOff: sql.NullString{String: "12.50", Valid: true},
+		StoreID:    store,
+	})
+	require.NoError(t, err)
+	require.True(t, sale.Active)
+	require.False(t, sale.StartsAt.IsZero(), "starts now by default")
+
+	coupon, err := productRepo.CreatePromotion(ctx, repository.CreatePromotionParams{
+		Name:       "Five off",
+		Kind:       "fixed",
+		AmountOff:  sql.NullString{String: "5.00", Valid: true},
+		Currency:   sql.NullString{String: "USD", Valid: true},
+		StoreID:    store,
+		CouponCode: sql.NullString{String: "FIVE", Valid: true},
+		UsageLimit: sql.NullInt32{Int32: 1, Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = productRepo.CreatePromotion(ctx, repository.CreatePromotionParams{
+		Name:       "Five again",
+		Kind:       "percentage",
+		PercentOff: sql.NullString{String: "5", Valid: true},
+		CouponCode: sql.NullString{String: "five", Valid: true},
+	})
+	require.Error(t, err, "coupon codes are unique whatever the case")
+
+	_, err = productRepo.CreatePromotion(ctx, repository.CreatePromotionParams{
+		Name:     "Later",
+		Kind:     "percentage",
+		StoreID:  store,
+		StartsAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	require.Error(t, err, "a percentage promotion needs a percentage")
+
+	_, err = productRepo.CreatePromotion(ctx, repository.CreatePromotionParams{
+		Name:       "Later",
+		Kind:       "percentage",
+		PercentOff: sql.NullString{String: "50", Valid: true},
+		StoreID:    store,
+		StartsAt:   sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+
+	listed, err := productRepo.ListStorePromotions(ctx, store)
+	require.NoError(t, err)
+	require.Len(t, listed, 3)
+
+	automatic, err := productRepo.ListAutomaticPromotions(ctx, []int32{storeID})
+	require.NoError(t, err)
+	require.Len(t, automatic, 1, "neither coupons nor promotions that have not started yet")
+	require.Equal(t, sale.ID, automatic[0].ID)
+
+	found, err := productRepo.GetPromotionByCoupon(ctx, "five")
+	require.NoError(t, err)
+	require.Equal(t, coupon.ID, found.ID)
+
+	count, err := productRepo.RedeemPromotion(ctx, coupon.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), count)
+	_, err = productRepo.RedeemPromotion(ctx, coupon.ID)
+	require.True(t, errors.Is(err, sql.ErrNoRows), "usage limit reached")
+}
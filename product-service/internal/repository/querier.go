@@ -0,0 +1,164 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+type Querier interface {
+	// Adds quantity to the cart line of the product or variant, creating it when
+	// missing.
+	AddCartItem(ctx context.Context, arg AddCartItemParams) (Cart, error)
+	AddProductStock(ctx context.Context, arg AddProductStockParams) (sql.NullInt32, error)
+	AddVariantOptionValue(ctx context.Context, arg AddVariantOptionValueParams) error
+	AddVariantStock(ctx context.Context, arg AddVariantStockParams) (int32, error)
+	// Saves a product, or one of its variants, with its current price and stock.
+	// Adding an item again returns the saved one, no rows means the product or
+	// variant does not exist.
+	AddWishlistItem(ctx context.Context, arg AddWishlistItemParams) (WishlistItem, error)
+	// Only a pending order of the user can be cancelled.
+	CancelOrder(ctx context.Context, arg CancelOrderParams) (Order, error)
+	CountUnreadNotifications(ctx context.Context, userID int32) (int64, error)
+	CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (ApiKey, error)
+	CreateDeadLetter(ctx context.Context, arg CreateDeadLetterParams) (DeadLetter, error)
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error)
+	CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error)
+	CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) error
+	CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error)
+	CreatePromotion(ctx context.Context, arg CreatePromotionParams) (Promotion, error)
+	CreatePromotionRedemption(ctx context.Context, arg CreatePromotionRedemptionParams) error
+	// Returns no rows when the user already reviewed the product.
+	CreateReview(ctx context.Context, arg CreateReviewParams) (Review, error)
+	CreateStockMovement(ctx context.Context, arg CreateStockMovementParams) (StockMovement, error)
+	CreateStockMovements(ctx context.Context, arg CreateStockMovementsParams) error
+	CreateVariant(ctx context.Context, arg CreateVariantParams) (ProductVariant, error)
+	DeleteCartItemsByUser(ctx context.Context, userID int32) error
+	DeleteEmailsByUser(ctx context.Context, userID int32) error
+	DeleteNotificationPreferencesByUser(ctx context.Context, userID int32) error
+	DeleteNotificationsByUser(ctx context.Context, userID int32) error
+	// Returns the reviewed products so their rating can be refreshed.
+	DeleteReviewsByUser(ctx context.Context, userID int32) ([]int32, error)
+	DeleteWishlistItem(ctx context.Context, arg DeleteWishlistItemParams) (int64, error)
+	DeleteWishlistItemsByUser(ctx context.Context, userID int32) error
+	// Unlike GetUserIDByEmail it also finds deactivated and deleted users, their
+	// data can be asked for until the account is purged.
+	GetAccountIDByEmail(ctx context.Context, email string) (int32, error)
+	// The key together with the email of the store owner, whom requests made with
+	// the key act for.
+	GetApiKeyByPrefix(ctx context.Context, prefix string) (GetApiKeyByPrefixRow, error)
+	GetDeadLetter(ctx context.Context, id int64) (DeadLetter, error)
+	GetNotificationPreference(ctx context.Context, arg GetNotificationPreferenceParams) (NotificationPreference, error)
+	// Deleted and deactivated users are not sent anything.
+	GetNotificationRecipient(ctx context.Context, id int32) (GetNotificationRecipientRow, error)
+	GetProduct(ctx context.Context, id int32) (Product, error)
+	GetPromotionByCoupon(ctx context.Context, code string) (Promotion, error)
+	GetReview(ctx context.Context, id int32) (Review, error)
+	GetUserIDByEmail(ctx context.Context, email string) (int32, error)
+	GetVariant(ctx context.Context, id int32) (ProductVariant, error)
+	GetVariantBySku(ctx context.Context, sku string) (ProductVariant, error)
+	GetWishlistItem(ctx context.Context, id int32) (GetWishlistItemRow, error)
+	HasPurchased(ctx context.Context, arg HasPurchasedParams) (bool, error)
+	// audit_log is append-only, a trigger refuses updates and deletes.
+	InsertAuditEntry(ctx context.Context, arg InsertAuditEntryParams) error
+	// Written in the transaction of the change the event describes.
+	InsertOutboxEvent(ctx context.Context, arg InsertOutboxEventParams) error
+	IsStoreOwner(ctx context.Context, arg IsStoreOwnerParams) (bool, error)
+	// Promotions without a coupon that currently apply to any of the stores, or to
+	// every store.
+	ListAutomaticPromotions(ctx context.Context, storeIds []int32) ([]Promotion, error)
+	ListCartItemsByUser(ctx context.Context, userID int32) ([]Cart, error)
+	ListDeadLetters(ctx context.Context, arg ListDeadLettersParams) ([]DeadLetter, error)
+	ListNotificationPreferences(ctx context.Context, userID int32) ([]NotificationPreference, error)
+	// Newest first, pass the id of the last one seen as before_id for the next
+	// page.
+	ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error)
+	ListOptionTypes(ctx context.Context, productID int32) ([]OptionType, error)
+	ListOptionValues(ctx context.Context, productID int32) ([]OptionValue, error)
+	ListOrderItems(ctx context.Context, orderID sql.NullInt32) ([]ListOrderItemsRow, error)
+	ListOrderItemsByUser(ctx context.Context, userID int32) ([]ListOrderItemsByUserRow, error)
+	ListOrdersByUser(ctx context.Context, userID int32) ([]Order, error)
+	// Locks the emails so a second mailer skips them instead of sending them
+	// twice. Parked emails are left out.
+	ListPendingEmails(ctx context.Context, limit int32) ([]OutgoingEmail, error)
+	// Locks the events so a second relay skips them instead of sending them twice.
+	// Parked events are left out.
+	ListPendingOutboxEvents(ctx context.Context, arg ListPendingOutboxEventsParams) ([]ListPendingOutboxEventsRow, error)
+	// Locks the rows until the import commits, so no sale or adjustment changes
+	// the stock the import records its movements against. They are locked in id
+	// order like the other stock locks to keep imports from deadlocking.
+	ListProductStockBySku(ctx context.Context, arg ListProductStockBySkuParams) ([]ListProductStockBySkuRow, error)
+	ListProductsForPricing(ctx context.Context, ids []int32) ([]ListProductsForPricingRow, error)
+	ListReviews(ctx context.Context, arg ListReviewsParams) ([]ListReviewsRow, error)
+	ListReviewsByUser(ctx context.Context, userID int32) ([]Review, error)
+	// Products without variants keep their stock on the product row and report
+	// variant_id 0, each variant keeps its own stock and ledger.
+	ListStockDrift(ctx context.Context) ([]ListStockDriftRow, error)
+	ListStockMovements(ctx context.Context, arg ListStockMovementsParams) ([]StockMovement, error)
+	ListStoreApiKeys(ctx context.Context, storeID int32) ([]ApiKey, error)
+	// Keyset pagination, pass the last id of the previous page as after_id.
+	ListStoreProducts(ctx context.Context, arg ListStoreProductsParams) ([]Product, error)
+	ListStorePromotions(ctx context.Context, storeID sql.NullInt32) ([]Promotion, error)
+	ListVariantOptions(ctx context.Context, productID int32) ([]ListVariantOptionsRow, error)
+	ListVariants(ctx context.Context, productID int32) ([]ProductVariant, error)
+	ListVariantsForPricing(ctx context.Context, ids []int32) ([]ListVariantsForPricingRow, error)
+	ListWishlistItems(ctx context.Context, userID int32) ([]ListWishlistItemsRow, error)
+	// Holds the stock of every product until the transaction ends, so no
+	// adjustment lands between reading the drift and fixing it.
+	LockProductStock(ctx context.Context) error
+	LockVariantStock(ctx context.Context) error
+	MarkAllNotificationsRead(ctx context.Context, userID int32) (int64, error)
+	MarkDeadLetterReplayed(ctx context.Context, id int64) error
+	// Parks the email once it failed max_attempts times and reports whether it
+	// did.
+	MarkEmailFailed(ctx context.Context, arg MarkEmailFailedParams) (bool, error)
+	MarkEmailSent(ctx context.Context, id int64) error
+	// Affects no rows when the consumer has already handled the event.
+	MarkEventProcessed(ctx context.Context, arg MarkEventProcessedParams) (int64, error)
+	MarkNotificationsRead(ctx context.Context, arg MarkNotificationsReadParams) (int64, error)
+	// Parks the event once it failed max_attempts times and reports whether it
+	// did.
+	MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) (bool, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64) error
+	// Written in the transaction of the notification, the mailer sends the email
+	// once that transaction has committed.
+	QueueEmail(ctx context.Context, arg QueueEmailParams) error
+	// Counts one use, returns no rows once the usage limit is reached.
+	RedeemPromotion(ctx context.Context, id int32) (int32, error)
+	// Only approved reviews count towards the rating shown on the product.
+	RefreshProductRating(ctx context.Context, productID int32) error
+	// Gives back the uses a cancelled order counted and drops its redemptions.
+	ReleaseOrderPromotions(ctx context.Context, orderID int32) error
+	RevokeApiKey(ctx context.Context, arg RevokeApiKeyParams) (int64, error)
+	SearchCategoryFacets(ctx context.Context, arg SearchCategoryFacetsParams) ([]SearchCategoryFacetsRow, error)
+	// Splits the matched prices of each currency into equal-width buckets and
+	// reports the cheapest and most expensive price inside every bucket.
+	SearchPriceFacets(ctx context.Context, arg SearchPriceFacetsParams) ([]SearchPriceFacetsRow, error)
+	// Every search query takes the same filters. When fuzzy is false products are
+	// matched with full-text search over name and description, when it is true
+	// they are matched by trigram similarity of the name so typos still hit.
+	SearchProducts(ctx context.Context, arg SearchProductsParams) ([]SearchProductsRow, error)
+	SearchStoreFacets(ctx context.Context, arg SearchStoreFacetsParams) ([]SearchStoreFacetsRow, error)
+	SetProductStock(ctx context.Context, arg SetProductStockParams) error
+	SetReviewStatus(ctx context.Context, arg SetReviewStatusParams) (Review, error)
+	SetVariantStock(ctx context.Context, arg SetVariantStockParams) error
+	// Records the current price and stock of the wishlisted products and returns
+	// every item that changed along with what was seen before, so the caller can
+	// tell a price drop or a restock. Deleted and deactivated users are left out.
+	SyncWishlistItems(ctx context.Context, productIds []int32) ([]SyncWishlistItemsRow, error)
+	TouchApiKey(ctx context.Context, id int32) error
+	// An edited review goes back to moderation.
+	UpdateReview(ctx context.Context, arg UpdateReviewParams) (Review, error)
+	UpsertNotificationPreference(ctx context.Context, arg UpsertNotificationPreferenceParams) (NotificationPreference, error)
+	UpsertOptionType(ctx context.Context, arg UpsertOptionTypeParams) (OptionType, error)
+	UpsertOptionValue(ctx context.Context, arg UpsertOptionValueParams) (OptionValue, error)
+	// Inserts or updates a batch of products of one store by sku. The arrays are
+	// parallel, element i of each one describes the same product; several unnest
+	// calls in one select list are zipped together row by row.
+	UpsertProducts(ctx context.Context, arg UpsertProductsParams) ([]UpsertProductsRow, error)
+}
+
+var _ Querier = (*Queries)(nil)
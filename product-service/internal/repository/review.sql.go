@@ -0,0 +1,260 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: review.sql
+
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+const createReview = `-- name: CreateReview :one
+INSERT INTO reviews (
+  product_id,
+  user_id,
+  rating,
+  body,
+  verified_purchase
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (product_id, user_id) DO NOTHING
+RETURNING id, product_id, user_id, rating, body, verified_purchase, status, created_at, updated_at
+`
+
+type CreateReviewParams struct {
+	ProductID        int32  `json:"product_id"`
+	UserID           int32  `json:"user_id"`
+	Rating           int16  `json:"rating"`
+	Body             string `json:"body"`
+	VerifiedPurchase bool   `json:"verified_purchase"`
+}
+
+// Returns no rows when the user already reviewed the product.
+func (q *Queries) CreateReview(ctx context.Context, arg CreateReviewParams) (Review, error) {
+	row := q.db.QueryRowContext(ctx, createReview,
+		arg.ProductID,
+		arg.UserID,
+		arg.Rating,
+		arg.Body,
+		arg.VerifiedPurchase,
+	)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.UserID,
+		&i.Rating,
+		&i.Body,
+		&i.VerifiedPurchase,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getReview = `-- name: GetReview :one
+SELECT id, product_id, user_id, rating, body, verified_purchase, status, created_at, updated_at FROM reviews
+WHERE id = $1
+`
+
+func (q *Queries) GetReview(ctx context.Context, id int32) (Review, error) {
+	row := q.db.QueryRowContext(ctx, getReview, id)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.UserID,
+		&i.Rating,
+		&i.Body,
+		&i.VerifiedPurchase,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getUserIDByEmail = `-- name: GetUserIDByEmail :one
+SELECT id FROM users
+WHERE lower(email) = lower($1)
+  AND deleted_at IS NULL AND deactivated_at IS NULL
+`
+
+func (q *Queries) GetUserIDByEmail(ctx context.Context, email string) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getUserIDByEmail, email)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const hasPurchased = `-- name: HasPurchased :one
+SELECT EXISTS (
+  SELECT 1 FROM order_items oi
+  JOIN orders o ON o.id = oi.order_id
+  WHERE o.user_id = $1::integer
+    AND oi.product_id = $2::integer
+    AND coalesce(o.status, '') NOT IN ('cancelled', 'refunded')
+)
+`
+
+type HasPurchasedParams struct {
+	UserID    int32 `json:"user_id"`
+	ProductID int32 `json:"product_id"`
+}
+
+func (q *Queries) HasPurchased(ctx context.Context, arg HasPurchasedParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasPurchased, arg.UserID, arg.ProductID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listReviews = `-- name: ListReviews :many
+SELECT r.id, r.product_id, r.user_id, r.rating, r.body, r.verified_purchase, r.status, r.created_at, r.updated_at, coalesce(u.username, '')::varchar AS username
+FROM reviews r
+JOIN users u ON u.id = r.user_id
+WHERE r.product_id = $1 AND r.status = 'approved'
+ORDER BY r.created_at DESC, r.id DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListReviewsParams struct {
+	ProductID int32 `json:"product_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+type ListReviewsRow struct {
+	ID               int32     `json:"id"`
+	ProductID        int32     `json:"product_id"`
+	UserID           int32     `json:"user_id"`
+	Rating           int16     `json:"rating"`
+	Body             string    `json:"body"`
+	VerifiedPurchase bool      `json:"verified_purchase"`
+	Status           string    `json:"status"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	Username         string    `json:"username"`
+}
+
+func (q *Queries) ListReviews(ctx context.Context, arg ListReviewsParams) ([]ListReviewsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReviews, arg.ProductID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReviewsRow
+	for rows.Next() {
+		var i ListReviewsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.UserID,
+			&i.Rating,
+			&i.Body,
+			&i.VerifiedPurchase,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const refreshProductRating = `-- name: RefreshProductRating :exec
+UPDATE products p
+SET rating_count = s.count, rating_average = s.average
+FROM (
+  SELECT count(*)::integer AS count, coalesce(round(avg(rating), 2), 0)::numeric(3,2) AS average
+  FROM reviews
+  WHERE product_id = $1 AND status = 'approved'
+) s
+WHERE p.id = $1
+`
+
+// Only approved reviews count towards the rating shown on the product.
+func (q *Queries) RefreshProductRating(ctx context.Context, productID int32) error {
+	_, err := q.db.ExecContext(ctx, refreshProductRating, productID)
+	return err
+}
+
+const setReviewStatus = `-- name: SetReviewStatus :one
+UPDATE reviews
+SET status = $2, updated_at = now()
+WHERE id = $1
+RETURNING id, product_id, user_id, rating, body, verified_purchase, status, created_at, updated_at
+`
+
+type SetReviewStatusParams struct {
+	ID     int32  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) SetReviewStatus(ctx context.Context, arg SetReviewStatusParams) (Review, error) {
+	row := q.db.QueryRowContext(ctx, setReviewStatus, arg.ID, arg.Status)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.UserID,
+		&i.Rating,
+		&i.Body,
+		&i.VerifiedPurchase,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateReview = `-- name: UpdateReview :one
+UPDATE reviews
+SET rating = $3, body = $4, status = 'pending', updated_at = now()
+WHERE id = $1 AND user_id = $2
+RETURNING id, product_id, user_id, rating, body, verified_purchase, status, created_at, updated_at
+`
+
+type UpdateReviewParams struct {
+	ID     int32  `json:"id"`
+	UserID int32  `json:"user_id"`
+	Rating int16  `json:"rating"`
+	Body   string `json:"body"`
+}
+
+// An edited review goes back to moderation.
+func (q *Queries) UpdateReview(ctx context.Context, arg UpdateReviewParams) (Review, error) {
+	row := q.db.QueryRowContext(ctx, updateReview,
+		arg.ID,
+		arg.UserID,
+		arg.Rating,
+		arg.Body,
+	)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.UserID,
+		&i.Rating,
+		&i.Body,
+		&i.VerifiedPurchase,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,81 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviews(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var buyer, browser int32
+	err := testDb.QueryRowContext(ctx, "insert into users (username, email) values ('buyer', 'Buyer@Example.com') returning id").Scan(&buyer)
+	require.NoError(t, err)
+	err = testDb.QueryRowContext(ctx, "insert into users (username, email) values ('browser', 'browser@example.com') returning id").Scan(&browser)
+	require.NoError(t, err)
+
+	created, err := productRepo.CreateProduct(ctx, repository.CreateProductParams{
+		Name:     sql.NullString{String: "Mug", Valid: true},
+		Price:    sql.NullString{String: "8", Valid: true},
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	var orderID int32
+	err = testDb.QueryRowContext(ctx, "insert into orders (user_id, status) values ($1, 'delivered') returning id", buyer).Scan(&orderID)
+	require.NoError(t, err)
+	_, err = testDb.ExecContext(ctx, "insert into order_items (order_id, product_id, quantity) values ($1, $2, 1)", orderID, created.ID)
+	require.NoError(t, err)
+
+	id, err := productRepo.GetUserIDByEmail(ctx, "buyer@example.com")
+	require.NoError(t, err)
+	require.Equal(t, buyer, id)
+
+	purchased, err := productRepo.HasPurchased(ctx, repository.HasPurchasedParams{UserID: buyer, ProductID: created.ID})
+	require.NoError(t, err)
+	require.True(t, purchased)
+	purchased, err = productRepo.HasPurchased(ctx, repository.HasPurchasedParams{UserID: browser, ProductID: created.ID})
+	require.NoError(t, err)
+	require.False(t, purchased)
+
+	first, err := productRepo.CreateReview(ctx, repository.CreateReviewParams{ProductID: created.ID, UserID: buyer, Rating: 5, VerifiedPurchase: true})
+	require.NoError(t, err)
+	require.Equal(t, "pending", first.Status)
+	_, err = productRepo.CreateReview(ctx, repository.CreateReviewParams{ProductID: created.ID, UserID: buyer, Rating: 1})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	second, err := productRepo.CreateReview(ctx, repository.CreateReviewParams{ProductID: created.ID, UserID: browser, Rating: 2})
+	require.NoError(t, err)
+
+	_, err = productRepo.UpdateReview(ctx, repository.UpdateReviewParams{ID: first.ID, UserID: browser, Rating: 1})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	for _, id := range []int32{first.ID, second.ID} {
+		_, err = productRepo.SetReviewStatus(ctx, repository.SetReviewStatusParams{ID: id, Status: "approved"})
+		require.NoError(t, err)
+	}
+	require.NoError(t, productRepo.RefreshProductRating(ctx, created.ID))
+	rated, err := productRepo.GetProduct(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), rated.RatingCount)
+	require.Equal(t, "3.50", rated.RatingAverage)
+
+	edited, err := productRepo.UpdateReview(ctx, repository.UpdateReviewParams{ID: second.ID, UserID: browser, Rating: 3})
+	require.NoError(t, err)
+	require.Equal(t, "pending", edited.Status)
+	require.NoError(t, productRepo.RefreshProductRating(ctx, created.ID))
+	rated, err = productRepo.GetProduct(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), rated.RatingCount)
+	require.Equal(t, "5.00", rated.RatingAverage)
+
+	reviews, err := productRepo.ListReviews(ctx, repository.ListReviewsParams{ProductID: created.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	require.Equal(t, "buyer", reviews[0].Username)
+}
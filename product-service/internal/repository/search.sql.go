@@ -0,0 +1,337 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: search.sql
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+const searchCategoryFacets = `-- name: SearchCategoryFacets :many
+SELECT c.id, c.name, count(*) AS count
+FROM products p
+JOIN category c ON c.id = p.category_id
+WHERE (
+    ($1::boolean AND p.name % $2::text) OR
+    (NOT $1::boolean AND p.search_vector @@ websearch_to_tsquery('simple', $2::text))
+  )
+  AND ($3::integer IS NULL OR p.category_id = $3::integer)
+  AND ($4::integer IS NULL OR p.store_id = $4::integer)
+  AND ($5::char(3) IS NULL OR p.currency = $5::char(3))
+  AND ($6::numeric IS NULL OR p.price >= $6::numeric)
+  AND ($7::numeric IS NULL OR p.price <= $7::numeric)
+GROUP BY c.id, c.name
+ORDER BY count DESC, c.id
+`
+
+type SearchCategoryFacetsParams struct {
+	Fuzzy      bool           `json:"fuzzy"`
+	Query      string         `json:"query"`
+	CategoryID sql.NullInt32  `json:"category_id"`
+	StoreID    sql.NullInt32  `json:"store_id"`
+	Currency   sql.NullString `json:"currency"`
+	MinPrice   sql.NullString `json:"min_price"`
+	MaxPrice   sql.NullString `json:"max_price"`
+}
+
+type SearchCategoryFacetsRow struct {
+	ID    int32          `json:"id"`
+	Name  sql.NullString `json:"name"`
+	Count int64          `json:"count"`
+}
+
+func (q *Queries) SearchCategoryFacets(ctx context.Context, arg SearchCategoryFacetsParams) ([]SearchCategoryFacetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchCategoryFacets,
+		arg.Fuzzy,
+		arg.Query,
+		arg.CategoryID,
+		arg.StoreID,
+		arg.Currency,
+		arg.MinPrice,
+		arg.MaxPrice,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchCategoryFacetsRow
+	for rows.Next() {
+		var i SearchCategoryFacetsRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPriceFacets = `-- name: SearchPriceFacets :many
+WITH matches AS (
+  SELECT p.price, p.currency
+  FROM products p
+  WHERE p.price IS NOT NULL
+    AND (
+      ($1::boolean AND p.name % $2::text) OR
+      (NOT $1::boolean AND p.search_vector @@ websearch_to_tsquery('simple', $2::text))
+    )
+    AND ($3::integer IS NULL OR p.category_id = $3::integer)
+    AND ($4::integer IS NULL OR p.store_id = $4::integer)
+    AND ($5::char(3) IS NULL OR p.currency = $5::char(3))
+    AND ($6::numeric IS NULL OR p.price >= $6::numeric)
+    AND ($7::numeric IS NULL OR p.price <= $7::numeric)
+), bounds AS (
+  SELECT currency, min(price) AS low, max(price) + 0.01 AS high
+  FROM matches
+  GROUP BY currency
+), bucketed AS (
+  SELECT
+    m.currency,
+    m.price,
+    width_bucket(m.price, b.low, b.high, $8::integer)::integer AS bucket
+  FROM matches m
+  JOIN bounds b ON b.currency = m.currency
+)
+SELECT
+  currency,
+  bucket,
+  min(price)::text AS min_price,
+  max(price)::text AS max_price,
+  count(*) AS count
+FROM bucketed
+GROUP BY currency, bucket
+ORDER BY currency, bucket
+`
+
+type SearchPriceFacetsParams struct {
+	Fuzzy      bool           `json:"fuzzy"`
+	Query      string         `json:"query"`
+	CategoryID sql.NullInt32  `json:"category_id"`
+	StoreID    sql.NullInt32  `json:"store_id"`
+	Currency   sql.NullString `json:"currency"`
+	MinPrice   sql.NullString `json:"min_price"`
+	MaxPrice   sql.NullString `json:"max_price"`
+	Buckets    int32          `json:"buckets"`
+}
+
+type SearchPriceFacetsRow struct {
+	Currency string `json:"currency"`
+	Bucket   int32  `json:"bucket"`
+	MinPrice string `json:"min_price"`
+	MaxPrice string `json:"max_price"`
+	Count    int64  `json:"count"`
+}
+
+// Splits the matched prices of each currency into equal-width buckets and
+// reports the cheapest and most expensive price inside every bucket.
+func (q *Queries) SearchPriceFacets(ctx context.Context, arg SearchPriceFacetsParams) ([]SearchPriceFacetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPriceFacets,
+		arg.Fuzzy,
+		arg.Query,
+		arg.CategoryID,
+		arg.StoreID,
+		arg.Currency,
+		arg.MinPrice,
+		arg.MaxPrice,
+		arg.Buckets,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPriceFacetsRow
+	for rows.Next() {
+		var i SearchPriceFacetsRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.Bucket,
+			&i.MinPrice,
+			&i.MaxPrice,
+			&i.Count,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchProducts = `-- name: SearchProducts :many
+
+SELECT
+  p.id, p.store_id, p.name, p.description, p.price, p.currency,
+  p.image_url, p.stock, p.category_id, p.created_at, p.rating_average, p.rating_count,
+  (CASE
+    WHEN $1::boolean THEN similarity(p.name, $2::text)
+    ELSE ts_rank(p.search_vector, websearch_to_tsquery('simple', $2::text))
+  END)::real AS rank
+FROM products p
+WHERE (
+    ($1::boolean AND p.name % $2::text) OR
+    (NOT $1::boolean AND p.search_vector @@ websearch_to_tsquery('simple', $2::text))
+  )
+  AND ($3::integer IS NULL OR p.category_id = $3::integer)
+  AND ($4::integer IS NULL OR p.store_id = $4::integer)
+  AND ($5::char(3) IS NULL OR p.currency = $5::char(3))
+  AND ($6::numeric IS NULL OR p.price >= $6::numeric)
+  AND ($7::numeric IS NULL OR p.price <= $7::numeric)
+ORDER BY rank DESC, p.id
+LIMIT $9::integer
+OFFSET $8::integer
+`
+
+type SearchProductsParams struct {
+	Fuzzy      bool           `json:"fuzzy"`
+	Query      string         `json:"query"`
+	CategoryID sql.NullInt32  `json:"category_id"`
+	StoreID    sql.NullInt32  `json:"store_id"`
+	Currency   sql.NullString `json:"currency"`
+	MinPrice   sql.NullString `json:"min_price"`
+	MaxPrice   sql.NullString `json:"max_price"`
+	RowOffset  int32          `json:"row_offset"`
+	RowLimit   int32          `json:"row_limit"`
+}
+
+type SearchProductsRow struct {
+	ID            int32          `json:"id"`
+	StoreID       sql.NullInt32  `json:"store_id"`
+	Name          sql.NullString `json:"name"`
+	Description   sql.NullString `json:"description"`
+	Price         sql.NullString `json:"price"`
+	Currency      string         `json:"currency"`
+	ImageUrl      sql.NullString `json:"image_url"`
+	Stock         sql.NullInt32  `json:"stock"`
+	CategoryID    sql.NullInt32  `json:"category_id"`
+	CreatedAt     sql.NullTime   `json:"created_at"`
+	RatingAverage string         `json:"rating_average"`
+	RatingCount   int32          `json:"rating_count"`
+	Rank          float32        `json:"rank"`
+}
+
+// Every search query takes the same filters. When fuzzy is false products are
+// matched with full-text search over name and description, when it is true
+// they are matched by trigram similarity of the name so typos still hit.
+func (q *Queries) SearchProducts(ctx context.Context, arg SearchProductsParams) ([]SearchProductsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchProducts,
+		arg.Fuzzy,
+		arg.Query,
+		arg.CategoryID,
+		arg.StoreID,
+		arg.Currency,
+		arg.MinPrice,
+		arg.MaxPrice,
+		arg.RowOffset,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchProductsRow
+	for rows.Next() {
+		var i SearchProductsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.StoreID,
+			&i.Name,
+			&i.Description,
+			&i.Price,
+			&i.Currency,
+			&i.ImageUrl,
+			&i.Stock,
+			&i.CategoryID,
+			&i.CreatedAt,
+			&i.RatingAverage,
+			&i.RatingCount,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchStoreFacets = `-- name: SearchStoreFacets :many
+SELECT s.id, s.store_name, count(*) AS count
+FROM products p
+JOIN stores s ON s.id = p.store_id
+WHERE (
+    ($1::boolean AND p.name % $2::text) OR
+    (NOT $1::boolean AND p.search_vector @@ websearch_to_tsquery('simple', $2::text))
+  )
+  AND ($3::integer IS NULL OR p.category_id = $3::integer)
+  AND ($4::integer IS NULL OR p.store_id = $4::integer)
+  AND ($5::char(3) IS NULL OR p.currency = $5::char(3))
+  AND ($6::numeric IS NULL OR p.price >= $6::numeric)
+  AND ($7::numeric IS NULL OR p.price <= $7::numeric)
+GROUP BY s.id, s.store_name
+ORDER BY count DESC, s.id
+`
+
+type SearchStoreFacetsParams struct {
+	Fuzzy      bool           `json:"fuzzy"`
+	Query      string         `json:"query"`
+	CategoryID sql.NullInt32  `json:"category_id"`
+	StoreID    sql.NullInt32  `json:"store_id"`
+	Currency   sql.NullString `json:"currency"`
+	MinPrice   sql.NullString `json:"min_price"`
+	MaxPrice   sql.NullString `json:"max_price"`
+}
+
+type SearchStoreFacetsRow struct {
+	ID        int32          `json:"id"`
+	StoreName sql.NullString `json:"store_name"`
+	Count     int64          `json:"count"`
+}
+
+func (q *Queries) SearchStoreFacets(ctx context.Context, arg SearchStoreFacetsParams) ([]SearchStoreFacetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchStoreFacets,
+		arg.Fuzzy,
+		arg.Query,
+		arg.CategoryID,
+		arg.StoreID,
+		arg.Currency,
+		arg.MinPrice,
+		arg.MaxPrice,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchStoreFacetsRow
+	for rows.Next() {
+		var i SearchStoreFacetsRow
+		if err := rows.Scan(&i.ID, &i.StoreName, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,54 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchProducts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	for _, name := range []string{"Mechanical Keyboard", "Wireless Mouse"} {
+		_, err := productRepo.CreateProduct(ctx, repository.CreateProductParams{
+			Name:        sql.NullString{String: name, Valid: true},
+			Description: sql.NullString{String: "desk accessory", Valid: true},
+			Price:       sql.NullString{String: "25.00", Valid: true},
+			Currency:    "EUR",
+		})
+		require.NoError(t, err)
+	}
+
+	hits, err := productRepo.SearchProducts(ctx, repository.SearchProductsParams{Query: "keyboard", RowLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	require.Equal(t, "Mechanical Keyboard", hits[0].Name.String)
+
+	hits, err = productRepo.SearchProducts(ctx, repository.SearchProductsParams{Query: "accessory", RowLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+
+	hits, err = productRepo.SearchProducts(ctx, repository.SearchProductsParams{Query: "keybord", RowLimit: 10})
+	require.NoError(t, err)
+	require.Empty(t, hits)
+
+	hits, err = productRepo.SearchProducts(ctx, repository.SearchProductsParams{Fuzzy: true, Query: "keybord", RowLimit: 10})
+	require.NoError(t, err)
+	require.NotEmpty(t, hits)
+	require.Equal(t, "Mechanical Keyboard", hits[0].Name.String)
+
+	prices, err := productRepo.SearchPriceFacets(ctx, repository.SearchPriceFacetsParams{
+		Query:    "accessory",
+		Currency: sql.NullString{String: "EUR", Valid: true},
+		Buckets:  5,
+	})
+	require.NoError(t, err)
+	require.Len(t, prices, 1)
+	require.Equal(t, int64(2), prices[0].Count)
+	require.Equal(t, "25.00", prices[0].MinPrice)
+}
@@ -0,0 +1,183 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: stock_movement.sql
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createStockMovement = `-- name: CreateStockMovement :one
+INSERT INTO stock_movements (
+  product_id,
+  quantity,
+  reason,
+  reference,
+  note,
+  variant_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING id, product_id, variant_id, quantity, reason, reference, note, created_at
+`
+
+type CreateStockMovementParams struct {
+	ProductID int32          `json:"product_id"`
+	Quantity  int32          `json:"quantity"`
+	Reason    string         `json:"reason"`
+	Reference sql.NullString `json:"reference"`
+	Note      sql.NullString `json:"note"`
+	VariantID sql.NullInt32  `json:"variant_id"`
+}
+
+func (q *Queries) CreateStockMovement(ctx context.Context, arg CreateStockMovementParams) (StockMovement, error) {
+	row := q.db.QueryRowContext(ctx, createStockMovement,
+		arg.ProductID,
+		arg.Quantity,
+		arg.Reason,
+		arg.Reference,
+		arg.Note,
+		arg.VariantID,
+	)
+	var i StockMovement
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.VariantID,
+		&i.Quantity,
+		&i.Reason,
+		&i.Reference,
+		&i.Note,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listStockDrift = `-- name: ListStockDrift :many
+SELECT
+  p.id AS product_id,
+  0::integer AS variant_id,
+  coalesce(p.stock, 0)::integer AS stock,
+  coalesce(sum(m.quantity), 0)::integer AS ledger_stock
+FROM products p
+LEFT JOIN stock_movements m ON m.product_id = p.id AND m.variant_id IS NULL
+GROUP BY p.id
+HAVING coalesce(p.stock, 0) <> coalesce(sum(m.quantity), 0)
+UNION ALL
+SELECT
+  v.product_id,
+  v.id AS variant_id,
+  v.stock,
+  coalesce(sum(m.quantity), 0)::integer AS ledger_stock
+FROM product_variants v
+LEFT JOIN stock_movements m ON m.variant_id = v.id
+GROUP BY v.id
+HAVING v.stock <> coalesce(sum(m.quantity), 0)
+ORDER BY product_id, variant_id
+`
+
+type ListStockDriftRow struct {
+	ProductID   int32 `json:"product_id"`
+	VariantID   int32 `json:"variant_id"`
+	Stock       int32 `json:"stock"`
+	LedgerStock int32 `json:"ledger_stock"`
+}
+
+// Products without variants keep their stock on the product row and report
+// variant_id 0, each variant keeps its own stock and ledger.
+func (q *Queries) ListStockDrift(ctx context.Context) ([]ListStockDriftRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStockDrift)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStockDriftRow
+	for rows.Next() {
+		var i ListStockDriftRow
+		if err := rows.Scan(
+			&i.ProductID,
+			&i.VariantID,
+			&i.Stock,
+			&i.LedgerStock,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStockMovements = `-- name: ListStockMovements :many
+SELECT id, product_id, variant_id, quantity, reason, reference, note, created_at FROM stock_movements
+WHERE product_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListStockMovementsParams struct {
+	ProductID int32 `json:"product_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListStockMovements(ctx context.Context, arg ListStockMovementsParams) ([]StockMovement, error) {
+	rows, err := q.db.QueryContext(ctx, listStockMovements, arg.ProductID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StockMovement
+	for rows.Next() {
+		var i StockMovement
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.VariantID,
+			&i.Quantity,
+			&i.Reason,
+			&i.Reference,
+			&i.Note,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockProductStock = `-- name: LockProductStock :exec
+SELECT id FROM products ORDER BY id FOR UPDATE
+`
+
+// Holds the stock of every product until the transaction ends, so no
+// adjustment lands between reading the drift and fixing it.
+func (q *Queries) LockProductStock(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockProductStock)
+	return err
+}
+
+const lockVariantStock = `-- name: LockVariantStock :exec
+SELECT id FROM product_variants ORDER BY id FOR UPDATE
+`
+
+func (q *Queries) LockVariantStock(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockVariantStock)
+	return err
+}
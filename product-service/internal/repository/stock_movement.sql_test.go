@@ -0,0 +1,65 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStockMovements(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	created, err := productRepo.CreateProduct(ctx, repository.CreateProductParams{
+		Name:     sql.NullString{String: "Keyboard", Valid: true},
+		Price:    sql.NullString{String: "150", Valid: true},
+		Currency: "USD",
+		Stock:    sql.NullInt32{Int32: 0, Valid: true},
+	})
+	require.NoError(t, err)
+
+	stock, err := productRepo.AddProductStock(ctx, repository.AddProductStockParams{ID: created.ID, Quantity: 5})
+	require.NoError(t, err)
+	require.Equal(t, int32(5), stock.Int32)
+
+	_, err = productRepo.AddProductStock(ctx, repository.AddProductStockParams{ID: created.ID, Quantity: -6})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	movement, err := productRepo.CreateStockMovement(ctx, repository.CreateStockMovementParams{
+		ProductID: created.ID,
+		Quantity:  5,
+		Reason:    "restock",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(5), movement.Quantity)
+
+	_, err = productRepo.CreateStockMovement(ctx, repository.CreateStockMovementParams{
+		ProductID: created.ID,
+		Quantity:  1,
+		Reason:    "stolen",
+	})
+	require.Error(t, err)
+
+	_, err = testDb.ExecContext(ctx, "delete from stock_movements where id = $1", movement.ID)
+	require.Error(t, err)
+
+	movements, err := productRepo.ListStockMovements(ctx, repository.ListStockMovementsParams{ProductID: created.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, movements, 1)
+
+	drift, err := productRepo.ListStockDrift(ctx)
+	require.NoError(t, err)
+	for _, d := range drift {
+		require.NotEqual(t, created.ID, d.ProductID)
+	}
+
+	err = productRepo.SetProductStock(ctx, repository.SetProductStockParams{ID: created.ID, Stock: sql.NullInt32{Int32: 7, Valid: true}})
+	require.NoError(t, err)
+	drift, err = productRepo.ListStockDrift(ctx)
+	require.NoError(t, err)
+	require.Contains(t, drift, repository.ListStockDriftRow{ProductID: created.ID, Stock: 7, LedgerStock: 5})
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// TxQuerier provides all queries plus the ability to run several of them in a
+// single transaction.
+type TxQuerier interface {
+	Querier
+	ExecTx(ctx context.Context, fn func(q Querier) error) error
+}
+
+type txQueries struct {
+	*Queries
+	db *sql.DB
+}
+
+func NewTxQuerier(db *sql.DB) *txQueries {
+	return &txQueries{Queries: New(db), db: db}
+}
+
+// ExecTx runs fn inside a transaction, rolling it back when fn returns an error.
+func (t *txQueries) ExecTx(ctx context.Context, fn func(q Querier) error) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(t.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx err: %v, rollback err: %v", err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,359 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: variant.sql
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+const addVariantOptionValue = `-- name: AddVariantOptionValue :exec
+INSERT INTO variant_option_values (
+  variant_id,
+  option_value_id
+) VALUES (
+  $1, $2
+)
+`
+
+type AddVariantOptionValueParams struct {
+	VariantID     int32 `json:"variant_id"`
+	OptionValueID int32 `json:"option_value_id"`
+}
+
+func (q *Queries) AddVariantOptionValue(ctx context.Context, arg AddVariantOptionValueParams) error {
+	_, err := q.db.ExecContext(ctx, addVariantOptionValue, arg.VariantID, arg.OptionValueID)
+	return err
+}
+
+const addVariantStock = `-- name: AddVariantStock :one
+UPDATE product_variants
+SET stock = stock + $1::integer
+WHERE id = $2 AND product_id = $3 AND stock + $1 >= 0
+RETURNING stock
+`
+
+type AddVariantStockParams struct {
+	Quantity  int32 `json:"quantity"`
+	ID        int32 `json:"id"`
+	ProductID int32 `json:"product_id"`
+}
+
+func (q *Queries) AddVariantStock(ctx context.Context, arg AddVariantStockParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, addVariantStock, arg.Quantity, arg.ID, arg.ProductID)
+	var stock int32
+	err := row.Scan(&stock)
+	return stock, err
+}
+
+const createVariant = `-- name: CreateVariant :one
+INSERT INTO product_variants (
+  product_id,
+  sku,
+  price,
+  currency,
+  stock,
+  image_url
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING id, product_id, sku, price, currency, stock, image_url, created_at
+`
+
+type CreateVariantParams struct {
+	ProductID int32          `json:"product_id"`
+	Sku       string         `json:"sku"`
+	Price     string         `json:"price"`
+	Currency  string         `json:"currency"`
+	Stock     int32          `json:"stock"`
+	ImageUrl  sql.NullString `json:"image_url"`
+}
+
+func (q *Queries) CreateVariant(ctx context.Context, arg CreateVariantParams) (ProductVariant, error) {
+	row := q.db.QueryRowContext(ctx, createVariant,
+		arg.ProductID,
+		arg.Sku,
+		arg.Price,
+		arg.Currency,
+		arg.Stock,
+		arg.ImageUrl,
+	)
+	var i ProductVariant
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.Sku,
+		&i.Price,
+		&i.Currency,
+		&i.Stock,
+		&i.ImageUrl,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getVariant = `-- name: GetVariant :one
+SELECT id, product_id, sku, price, currency, stock, image_url, created_at FROM product_variants
+WHERE id = $1
+`
+
+func (q *Queries) GetVariant(ctx context.Context, id int32) (ProductVariant, error) {
+	row := q.db.QueryRowContext(ctx, getVariant, id)
+	var i ProductVariant
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.Sku,
+		&i.Price,
+		&i.Currency,
+		&i.Stock,
+		&i.ImageUrl,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getVariantBySku = `-- name: GetVariantBySku :one
+SELECT id, product_id, sku, price, currency, stock, image_url, created_at FROM product_variants
+WHERE sku = $1
+`
+
+func (q *Queries) GetVariantBySku(ctx context.Context, sku string) (ProductVariant, error) {
+	row := q.db.QueryRowContext(ctx, getVariantBySku, sku)
+	var i ProductVariant
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.Sku,
+		&i.Price,
+		&i.Currency,
+		&i.Stock,
+		&i.ImageUrl,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listOptionTypes = `-- name: ListOptionTypes :many
+SELECT id, product_id, name, position FROM option_types
+WHERE product_id = $1
+ORDER BY position, id
+`
+
+func (q *Queries) ListOptionTypes(ctx context.Context, productID int32) ([]OptionType, error) {
+	rows, err := q.db.QueryContext(ctx, listOptionTypes, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OptionType
+	for rows.Next() {
+		var i OptionType
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.Name,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOptionValues = `-- name: ListOptionValues :many
+SELECT ov.id, ov.option_type_id, ov.value, ov.position FROM option_values ov
+JOIN option_types ot ON ot.id = ov.option_type_id
+WHERE ot.product_id = $1
+ORDER BY ov.position, ov.id
+`
+
+func (q *Queries) ListOptionValues(ctx context.Context, productID int32) ([]OptionValue, error) {
+	rows, err := q.db.QueryContext(ctx, listOptionValues, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OptionValue
+	for rows.Next() {
+		var i OptionValue
+		if err := rows.Scan(
+			&i.ID,
+			&i.OptionTypeID,
+			&i.Value,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVariantOptions = `-- name: ListVariantOptions :many
+SELECT vov.variant_id, ot.name, ov.value
+FROM variant_option_values vov
+JOIN option_values ov ON ov.id = vov.option_value_id
+JOIN option_types ot ON ot.id = ov.option_type_id
+WHERE ot.product_id = $1
+ORDER BY vov.variant_id, ot.position, ot.id
+`
+
+type ListVariantOptionsRow struct {
+	VariantID int32  `json:"variant_id"`
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+}
+
+func (q *Queries) ListVariantOptions(ctx context.Context, productID int32) ([]ListVariantOptionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listVariantOptions, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVariantOptionsRow
+	for rows.Next() {
+		var i ListVariantOptionsRow
+		if err := rows.Scan(&i.VariantID, &i.Name, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVariants = `-- name: ListVariants :many
+SELECT id, product_id, sku, price, currency, stock, image_url, created_at FROM product_variants
+WHERE product_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListVariants(ctx context.Context, productID int32) ([]ProductVariant, error) {
+	rows, err := q.db.QueryContext(ctx, listVariants, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProductVariant
+	for rows.Next() {
+		var i ProductVariant
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.Sku,
+			&i.Price,
+			&i.Currency,
+			&i.Stock,
+			&i.ImageUrl,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setVariantStock = `-- name: SetVariantStock :exec
+UPDATE product_variants
+SET stock = $2
+WHERE id = $1
+`
+
+type SetVariantStockParams struct {
+	ID    int32 `json:"id"`
+	Stock int32 `json:"stock"`
+}
+
+func (q *Queries) SetVariantStock(ctx context.Context, arg SetVariantStockParams) error {
+	_, err := q.db.ExecContext(ctx, setVariantStock, arg.ID, arg.Stock)
+	return err
+}
+
+const upsertOptionType = `-- name: UpsertOptionType :one
+INSERT INTO option_types (
+  product_id,
+  name,
+  position
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (product_id, name) DO UPDATE SET name = excluded.name
+RETURNING id, product_id, name, position
+`
+
+type UpsertOptionTypeParams struct {
+	ProductID int32  `json:"product_id"`
+	Name      string `json:"name"`
+	Position  int32  `json:"position"`
+}
+
+func (q *Queries) UpsertOptionType(ctx context.Context, arg UpsertOptionTypeParams) (OptionType, error) {
+	row := q.db.QueryRowContext(ctx, upsertOptionType, arg.ProductID, arg.Name, arg.Position)
+	var i OptionType
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.Name,
+		&i.Position,
+	)
+	return i, err
+}
+
+const upsertOptionValue = `-- name: UpsertOptionValue :one
+INSERT INTO option_values (
+  option_type_id,
+  value,
+  position
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (option_type_id, value) DO UPDATE SET value = excluded.value
+RETURNING id, option_type_id, value, position
+`
+
+type UpsertOptionValueParams struct {
+	OptionTypeID int32  `json:"option_type_id"`
+	Value        string `json:"value"`
+	Position     int32  `json:"position"`
+}
+
+func (q *Queries) UpsertOptionValue(ctx context.Context, arg UpsertOptionValueParams) (OptionValue, error) {
+	row := q.db.QueryRowContext(ctx, upsertOptionValue, arg.OptionTypeID, arg.Value, arg.Position)
+	var i OptionValue
+	err := row.Scan(
+		&i.ID,
+		&i.OptionTypeID,
+		&i.Value,
+		&i.Position,
+	)
+	return i, err
+}
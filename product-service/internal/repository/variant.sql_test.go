@@ -0,0 +1,79 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariants(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	created, err := productRepo.CreateProduct(ctx, repository.CreateProductParams{
+		Name:     sql.NullString{String: "T-Shirt", Valid: true},
+		Price:    sql.NullString{String: "20", Valid: true},
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	size, err := productRepo.UpsertOptionType(ctx, repository.UpsertOptionTypeParams{ProductID: created.ID, Name: "size"})
+	require.NoError(t, err)
+	again, err := productRepo.UpsertOptionType(ctx, repository.UpsertOptionTypeParams{ProductID: created.ID, Name: "size"})
+	require.NoError(t, err)
+	require.Equal(t, size.ID, again.ID)
+
+	medium, err := productRepo.UpsertOptionValue(ctx, repository.UpsertOptionValueParams{OptionTypeID: size.ID, Value: "M"})
+	require.NoError(t, err)
+
+	variant, err := productRepo.CreateVariant(ctx, repository.CreateVariantParams{
+		ProductID: created.ID,
+		Sku:       "TSHIRT-M",
+		Price:     "21.50",
+		Currency:  "USD",
+		Stock:     2,
+	})
+	require.NoError(t, err)
+	require.NoError(t, productRepo.AddVariantOptionValue(ctx, repository.AddVariantOptionValueParams{VariantID: variant.ID, OptionValueID: medium.ID}))
+
+	_, err = productRepo.CreateVariant(ctx, repository.CreateVariantParams{ProductID: created.ID, Sku: "TSHIRT-M", Price: "1", Currency: "USD"})
+	require.Error(t, err, "sku is unique")
+
+	found, err := productRepo.GetVariantBySku(ctx, "TSHIRT-M")
+	require.NoError(t, err)
+	require.Equal(t, variant.ID, found.ID)
+
+	options, err := productRepo.ListVariantOptions(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, []repository.ListVariantOptionsRow{{VariantID: variant.ID, Name: "size", Value: "M"}}, options)
+
+	stock, err := productRepo.AddVariantStock(ctx, repository.AddVariantStockParams{ID: variant.ID, ProductID: created.ID, Quantity: 3})
+	require.NoError(t, err)
+	require.Equal(t, int32(5), stock)
+	_, err = productRepo.AddVariantStock(ctx, repository.AddVariantStockParams{ID: variant.ID, ProductID: created.ID, Quantity: -6})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = productRepo.AddVariantStock(ctx, repository.AddVariantStockParams{ID: variant.ID, ProductID: created.ID + 1, Quantity: 1})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = productRepo.CreateStockMovement(ctx, repository.CreateStockMovementParams{
+		ProductID: created.ID,
+		VariantID: sql.NullInt32{Int32: variant.ID, Valid: true},
+		Quantity:  5,
+		Reason:    "restock",
+	})
+	require.NoError(t, err)
+	drift, err := productRepo.ListStockDrift(ctx)
+	require.NoError(t, err)
+	for _, d := range drift {
+		require.NotEqual(t, created.ID, d.ProductID)
+	}
+
+	require.NoError(t, productRepo.SetVariantStock(ctx, repository.SetVariantStockParams{ID: variant.ID, Stock: 1}))
+	drift, err = productRepo.ListStockDrift(ctx)
+	require.NoError(t, err)
+	require.Contains(t, drift, repository.ListStockDriftRow{ProductID: created.ID, VariantID: variant.ID, Stock: 1, LedgerStock: 5})
+}
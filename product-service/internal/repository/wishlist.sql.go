@@ -0,0 +1,337 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.2
+// source: wishlist.sql
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const addCartItem = `-- name: AddCartItem :one
+INSERT INTO cart (
+  user_id,
+  product_id,
+  variant_id,
+  quantity,
+  price
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (user_id, product_id, coalesce(variant_id, 0)) DO UPDATE
+SET quantity = cart.quantity + EXCLUDED.quantity, price = EXCLUDED.price
+RETURNING id, user_id, product_id, variant_id, quantity, price
+`
+
+type AddCartItemParams struct {
+	UserID    sql.NullInt32  `json:"user_id"`
+	ProductID sql.NullInt32  `json:"product_id"`
+	VariantID sql.NullInt32  `json:"variant_id"`
+	Quantity  sql.NullInt32  `json:"quantity"`
+	Price     sql.NullString `json:"price"`
+}
+
+// Adds quantity to the cart line of the product or variant, creating it when
+// missing.
+func (q *Queries) AddCartItem(ctx context.Context, arg AddCartItemParams) (Cart, error) {
+	row := q.db.QueryRowContext(ctx, addCartItem,
+		arg.UserID,
+		arg.ProductID,
+		arg.VariantID,
+		arg.Quantity,
+		arg.Price,
+	)
+	var i Cart
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ProductID,
+		&i.VariantID,
+		&i.Quantity,
+		&i.Price,
+	)
+	return i, err
+}
+
+const addWishlistItem = `-- name: AddWishlistItem :one
+INSERT INTO wishlist_items (
+  user_id,
+  product_id,
+  variant_id,
+  added_price,
+  seen_price,
+  seen_in_stock
+)
+SELECT $1::integer, p.id, v.id, coalesce(v.price, p.price), coalesce(v.price, p.price), coalesce(v.stock, p.stock, 0) > 0
+FROM products p
+LEFT JOIN product_variants v ON v.id = $2::integer AND v.product_id = p.id
+WHERE p.id = $3::integer
+  AND ($2::integer IS NULL OR v.id IS NOT NULL)
+ON CONFLICT (user_id, product_id, coalesce(variant_id, 0)) DO UPDATE SET user_id = EXCLUDED.user_id
+RETURNING id, user_id, product_id, variant_id, added_price, seen_price, seen_in_stock, created_at
+`
+
+type AddWishlistItemParams struct {
+	UserID    int32         `json:"user_id"`
+	VariantID sql.NullInt32 `json:"variant_id"`
+	ProductID int32         `json:"product_id"`
+}
+
+// Saves a product, or one of its variants, with its current price and stock.
+// Adding an item again returns the saved one, no rows means the product or
+// variant does not exist.
+func (q *Queries) AddWishlistItem(ctx context.Context, arg AddWishlistItemParams) (WishlistItem, error) {
+	row := q.db.QueryRowContext(ctx, addWishlistItem, arg.UserID, arg.VariantID, arg.ProductID)
+	var i WishlistItem
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ProductID,
+		&i.VariantID,
+		&i.AddedPrice,
+		&i.SeenPrice,
+		&i.SeenInStock,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteWishlistItem = `-- name: DeleteWishlistItem :execrows
+DELETE FROM wishlist_items
+WHERE user_id = $1 AND product_id = $2 AND coalesce(variant_id, 0) = $3::integer
+`
+
+type DeleteWishlistItemParams struct {
+	UserID    int32 `json:"user_id"`
+	ProductID int32 `json:"product_id"`
+	VariantID int32 `json:"variant_id"`
+}
+
+func (q *Queries) DeleteWishlistItem(ctx context.Context, arg DeleteWishlistItemParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWishlistItem, arg.UserID, arg.ProductID, arg.VariantID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getWishlistItem = `-- name: GetWishlistItem :one
+SELECT
+  w.id,
+  w.product_id,
+  w.variant_id,
+  p.name,
+  p.price AS product_price,
+  p.currency AS product_currency,
+  p.stock AS product_stock,
+  v.price AS variant_price,
+  v.currency AS variant_currency,
+  v.stock AS variant_stock,
+  w.added_price,
+  w.created_at
+FROM wishlist_items w
+JOIN products p ON p.id = w.product_id
+LEFT JOIN product_variants v ON v.id = w.variant_id
+WHERE w.id = $1
+`
+
+type GetWishlistItemRow struct {
+	ID              int32          `json:"id"`
+	ProductID       int32          `json:"product_id"`
+	VariantID       sql.NullInt32  `json:"variant_id"`
+	Name            sql.NullString `json:"name"`
+	ProductPrice    sql.NullString `json:"product_price"`
+	ProductCurrency string         `json:"product_currency"`
+	ProductStock    sql.NullInt32  `json:"product_stock"`
+	VariantPrice    sql.NullString `json:"variant_price"`
+	VariantCurrency sql.NullString `json:"variant_currency"`
+	VariantStock    sql.NullInt32  `json:"variant_stock"`
+	AddedPrice      sql.NullString `json:"added_price"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+func (q *Queries) GetWishlistItem(ctx context.Context, id int32) (GetWishlistItemRow, error) {
+	row := q.db.QueryRowContext(ctx, getWishlistItem, id)
+	var i GetWishlistItemRow
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.VariantID,
+		&i.Name,
+		&i.ProductPrice,
+		&i.ProductCurrency,
+		&i.ProductStock,
+		&i.VariantPrice,
+		&i.VariantCurrency,
+		&i.VariantStock,
+		&i.AddedPrice,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listWishlistItems = `-- name: ListWishlistItems :many
+SELECT
+  w.id,
+  w.product_id,
+  w.variant_id,
+  p.name,
+  p.price AS product_price,
+  p.currency AS product_currency,
+  p.stock AS product_stock,
+  v.price AS variant_price,
+  v.currency AS variant_currency,
+  v.stock AS variant_stock,
+  w.added_price,
+  w.created_at
+FROM wishlist_items w
+JOIN products p ON p.id = w.product_id
+LEFT JOIN product_variants v ON v.id = w.variant_id
+WHERE w.user_id = $1
+ORDER BY w.created_at DESC, w.id DESC
+`
+
+type ListWishlistItemsRow struct {
+	ID              int32          `json:"id"`
+	ProductID       int32          `json:"product_id"`
+	VariantID       sql.NullInt32  `json:"variant_id"`
+	Name            sql.NullString `json:"name"`
+	ProductPrice    sql.NullString `json:"product_price"`
+	ProductCurrency string         `json:"product_currency"`
+	ProductStock    sql.NullInt32  `json:"product_stock"`
+	VariantPrice    sql.NullString `json:"variant_price"`
+	VariantCurrency sql.NullString `json:"variant_currency"`
+	VariantStock    sql.NullInt32  `json:"variant_stock"`
+	AddedPrice      sql.NullString `json:"added_price"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+func (q *Queries) ListWishlistItems(ctx context.Context, userID int32) ([]ListWishlistItemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listWishlistItems, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWishlistItemsRow
+	for rows.Next() {
+		var i ListWishlistItemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.VariantID,
+			&i.Name,
+			&i.ProductPrice,
+			&i.ProductCurrency,
+			&i.ProductStock,
+			&i.VariantPrice,
+			&i.VariantCurrency,
+			&i.VariantStock,
+			&i.AddedPrice,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const syncWishlistItems = `-- name: SyncWishlistItems :many
+WITH latest AS (
+  SELECT
+    w.id,
+    p.name,
+    v.price AS variant_price,
+    p.price AS product_price,
+    coalesce(v.currency, p.currency) AS currency,
+    coalesce(v.stock, p.stock, 0) > 0 AS in_stock,
+    w.seen_price,
+    w.seen_in_stock
+  FROM wishlist_items w
+  JOIN products p ON p.id = w.product_id
+  LEFT JOIN product_variants v ON v.id = w.variant_id
+  WHERE w.product_id = ANY($1::integer[])
+)
+UPDATE wishlist_items w
+SET seen_price = coalesce(latest.variant_price, latest.product_price), seen_in_stock = latest.in_stock
+FROM latest, users u
+WHERE w.id = latest.id
+  AND u.id = w.user_id
+  AND u.deleted_at IS NULL AND u.deactivated_at IS NULL
+  AND (w.seen_price IS DISTINCT FROM coalesce(latest.variant_price, latest.product_price) OR w.seen_in_stock <> latest.in_stock)
+RETURNING
+  w.id,
+  w.product_id,
+  w.variant_id,
+  u.email,
+  latest.name,
+  latest.currency,
+  latest.seen_price AS previous_price,
+  latest.seen_in_stock AS previous_in_stock,
+  latest.variant_price,
+  latest.product_price,
+  latest.in_stock
+`
+
+type SyncWishlistItemsRow struct {
+	ID              int32          `json:"id"`
+	ProductID       int32          `json:"product_id"`
+	VariantID       sql.NullInt32  `json:"variant_id"`
+	Email           sql.NullString `json:"email"`
+	Name            sql.NullString `json:"name"`
+	Currency        string         `json:"currency"`
+	PreviousPrice   sql.NullString `json:"previous_price"`
+	PreviousInStock bool           `json:"previous_in_stock"`
+	VariantPrice    sql.NullString `json:"variant_price"`
+	ProductPrice    sql.NullString `json:"product_price"`
+	InStock         bool           `json:"in_stock"`
+}
+
+// Records the current price and stock of the wishlisted products and returns
+// every item that changed along with what was seen before, so the caller can
+// tell a price drop or a restock. Deleted and deactivated users are left out.
+func (q *Queries) SyncWishlistItems(ctx context.Context, productIds []int32) ([]SyncWishlistItemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, syncWishlistItems, pq.Array(productIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SyncWishlistItemsRow
+	for rows.Next() {
+		var i SyncWishlistItemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.VariantID,
+			&i.Email,
+			&i.Name,
+			&i.Currency,
+			&i.PreviousPrice,
+			&i.PreviousInStock,
+			&i.VariantPrice,
+			&i.ProductPrice,
+			&i.InStock,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
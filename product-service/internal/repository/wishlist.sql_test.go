@@ -0,0 +1,83 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ryanpujo/product-service/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWishlist(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var userID int32
+	err := testDb.QueryRowContext(ctx, "insert into users (email) values ('wisher@mail.com') returning id").Scan(&userID)
+	require.NoError(t, err)
+	created, err := productRepo.CreateProduct(ctx, repository.CreateProductParams{
+		Name:     sql.NullString{String: "Lamp", Valid: true},
+		Price:    sql.NullString{String: "25", Valid: true},
+		Currency: "USD",
+		Stock:    sql.NullInt32{Int32: 0, Valid: true},
+	})
+	require.NoError(t, err)
+
+	saved, err := productRepo.AddWishlistItem(ctx, repository.AddWishlistItemParams{UserID: userID, ProductID: created.ID})
+	require.NoError(t, err)
+	require.Equal(t, "25.00", saved.AddedPrice.String)
+	require.False(t, saved.SeenInStock)
+	again, err := productRepo.AddWishlistItem(ctx, repository.AddWishlistItemParams{UserID: userID, ProductID: created.ID})
+	require.NoError(t, err)
+	require.Equal(t, saved.ID, again.ID, "saved once")
+	_, err = productRepo.AddWishlistItem(ctx, repository.AddWishlistItemParams{
+		UserID:    userID,
+		ProductID: created.ID,
+		VariantID: sql.NullInt32{Int32: -1, Valid: true},
+	})
+	require.True(t, errors.Is(err, sql.ErrNoRows), "unknown variant")
+
+	items, err := productRepo.ListWishlistItems(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "Lamp", items[0].Name.String)
+
+	changed, err := productRepo.SyncWishlistItems(ctx, []int32{created.ID})
+	require.NoError(t, err)
+	require.Empty(t, changed, "nothing changed since it was saved")
+
+	_, err = productRepo.AddProductStock(ctx, repository.AddProductStockParams{ID: created.ID, Quantity: 2})
+	require.NoError(t, err)
+	changed, err = productRepo.SyncWishlistItems(ctx, []int32{created.ID})
+	require.NoError(t, err)
+	require.Len(t, changed, 1)
+	require.False(t, changed[0].PreviousInStock)
+	require.True(t, changed[0].InStock)
+	require.Equal(t, "wisher@mail.com", changed[0].Email.String)
+	changed, err = productRepo.SyncWishlistItems(ctx, []int32{created.ID})
+	require.NoError(t, err)
+	require.Empty(t, changed, "the change is only reported once")
+
+	_, err = productRepo.AddCartItem(ctx, repository.AddCartItemParams{
+		UserID:    sql.NullInt32{Int32: userID, Valid: true},
+		ProductID: sql.NullInt32{Int32: created.ID, Valid: true},
+		Quantity:  sql.NullInt32{Int32: 1, Valid: true},
+		Price:     sql.NullString{String: "25.00", Valid: true},
+	})
+	require.NoError(t, err)
+	line, err := productRepo.AddCartItem(ctx, repository.AddCartItemParams{
+		UserID:    sql.NullInt32{Int32: userID, Valid: true},
+		ProductID: sql.NullInt32{Int32: created.ID, Valid: true},
+		Quantity:  sql.NullInt32{Int32: 2, Valid: true},
+		Price:     sql.NullString{String: "25.00", Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), line.Quantity.Int32)
+
+	removed, err := productRepo.DeleteWishlistItem(ctx, repository.DeleteWishlistItemParams{UserID: userID, ProductID: created.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), removed)
+}
@@ -0,0 +1,27 @@
+-- Products and variants that existed before the stock_movements ledger have
+-- stock but no movements, so reconcile -fix would reset them to zero. This
+-- records their stock as an opening balance. Run it once on databases created
+-- before the ledger, new databases start from sql/schema/schema.sql.
+BEGIN;
+
+-- no stock changes while the balances are taken
+LOCK TABLE products, product_variants IN SHARE ROW EXCLUSIVE MODE;
+
+INSERT INTO stock_movements (product_id, quantity, reason, reference, note)
+SELECT p.id, p.stock, 'adjustment', 'opening-balance', 'stock before the ledger'
+FROM products p
+WHERE coalesce(p.stock, 0) <> 0
+  AND NOT EXISTS (
+    SELECT 1 FROM stock_movements m
+    WHERE m.product_id = p.id AND m.variant_id IS NULL
+  );
+
+INSERT INTO stock_movements (product_id, variant_id, quantity, reason, reference, note)
+SELECT v.product_id, v.id, v.stock, 'adjustment', 'opening-balance', 'stock before the ledger'
+FROM product_variants v
+WHERE v.stock <> 0
+  AND NOT EXISTS (
+    SELECT 1 FROM stock_movements m WHERE m.variant_id = v.id
+  );
+
+COMMIT;
@@ -0,0 +1,12 @@
+-- The relay parks an event that failed messaging.MaxAttempts times so it no
+-- longer holds back the ones after it. Run it once on databases created
+-- before events could be parked, new databases start from
+-- sql/schema/schema.sql.
+BEGIN;
+
+ALTER TABLE outbox ADD COLUMN failed_at timestamp;
+
+DROP INDEX outbox_pending_idx;
+CREATE INDEX outbox_pending_idx ON outbox (service, id) WHERE published_at IS NULL AND failed_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,34 @@
+-- name: CreateApiKey :one
+INSERT INTO api_keys (
+  store_id,
+  name,
+  prefix,
+  key_hash,
+  scopes,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: ListStoreApiKeys :many
+SELECT * FROM api_keys
+WHERE store_id = $1 AND revoked_at IS NULL
+ORDER BY id DESC;
+
+-- name: RevokeApiKey :execrows
+UPDATE api_keys SET revoked_at = now()
+WHERE id = $1 AND store_id = $2 AND revoked_at IS NULL;
+
+-- name: GetApiKeyByPrefix :one
+-- The key together with the email of the store owner, whom requests made with
+-- the key act for.
+SELECT k.id, k.store_id, k.key_hash, k.scopes, k.expires_at, k.revoked_at, u.email AS owner_email
+FROM api_keys k
+JOIN stores s ON s.id = k.store_id
+JOIN users u ON u.id = s.owner_id
+WHERE k.prefix = $1;
+
+-- name: TouchApiKey :exec
+UPDATE api_keys SET last_used_at = now()
+WHERE id = $1;
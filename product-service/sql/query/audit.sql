@@ -0,0 +1,16 @@
+-- name: InsertAuditEntry :exec
+-- audit_log is append-only, a trigger refuses updates and deletes.
+INSERT INTO audit_log (
+  service,
+  actor,
+  impersonator,
+  action,
+  target_type,
+  target_id,
+  before,
+  after,
+  request_id,
+  ip
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+);
@@ -0,0 +1,38 @@
+-- name: MarkEventProcessed :execrows
+-- Affects no rows when the consumer has already handled the event.
+INSERT INTO processed_events (
+  consumer,
+  event_id
+) VALUES (
+  $1, $2
+)
+ON CONFLICT DO NOTHING;
+
+-- name: CreateDeadLetter :one
+INSERT INTO dead_letters (
+  consumer,
+  topic,
+  partition_key,
+  event_id,
+  payload,
+  error,
+  attempts
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetDeadLetter :one
+SELECT * FROM dead_letters
+WHERE id = $1;
+
+-- name: ListDeadLetters :many
+SELECT * FROM dead_letters
+WHERE (sqlc.arg(consumer)::varchar = '' OR consumer = sqlc.arg(consumer)::varchar)
+  AND (sqlc.arg(include_replayed)::boolean OR replayed_at IS NULL)
+ORDER BY id
+LIMIT sqlc.arg(page_size)::integer;
+
+-- name: MarkDeadLetterReplayed :exec
+UPDATE dead_letters SET replayed_at = now()
+WHERE id = $1;
@@ -0,0 +1,42 @@
+-- name: GetAccountIDByEmail :one
+-- Unlike GetUserIDByEmail it also finds deactivated and deleted users, their
+-- data can be asked for until the account is purged.
+SELECT id FROM users
+WHERE lower(email) = lower(sqlc.arg(email)) AND purged_at IS NULL;
+
+-- name: ListOrdersByUser :many
+SELECT * FROM orders
+WHERE user_id = sqlc.arg(user_id)::integer
+ORDER BY order_date DESC, id DESC;
+
+-- name: ListOrderItemsByUser :many
+SELECT oi.order_id, oi.product_id, oi.variant_id, p.name, oi.quantity, oi.price, oi.discount
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+LEFT JOIN products p ON p.id = oi.product_id
+WHERE o.user_id = sqlc.arg(user_id)::integer
+ORDER BY oi.order_id, oi.id;
+
+-- name: ListReviewsByUser :many
+SELECT * FROM reviews
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: ListCartItemsByUser :many
+SELECT * FROM cart
+WHERE user_id = sqlc.arg(user_id)::integer
+ORDER BY id;
+
+-- name: DeleteCartItemsByUser :exec
+DELETE FROM cart
+WHERE user_id = sqlc.arg(user_id)::integer;
+
+-- name: DeleteWishlistItemsByUser :exec
+DELETE FROM wishlist_items
+WHERE user_id = $1;
+
+-- name: DeleteReviewsByUser :many
+-- Returns the reviewed products so their rating can be refreshed.
+DELETE FROM reviews
+WHERE user_id = $1
+RETURNING product_id;
@@ -0,0 +1,88 @@
+-- name: IsStoreOwner :one
+SELECT EXISTS (
+  SELECT 1 FROM stores s
+  JOIN users u ON u.id = s.owner_id
+  WHERE s.id = sqlc.arg(store_id)::integer AND lower(u.email) = lower(sqlc.arg(email))
+);
+
+-- name: ListProductStockBySku :many
+-- Locks the rows until the import commits, so no sale or adjustment changes
+-- the stock the import records its movements against. They are locked in id
+-- order like the other stock locks to keep imports from deadlocking.
+SELECT id, sku, coalesce(stock, 0)::integer AS stock
+FROM products
+WHERE store_id = sqlc.arg(store_id)::integer AND sku = ANY(sqlc.arg(skus)::varchar[])
+ORDER BY id
+FOR UPDATE;
+
+-- name: UpsertProducts :many
+-- Inserts or updates a batch of products of one store by sku. The arrays are
+-- parallel, element i of each one describes the same product; several unnest
+-- calls in one select list are zipped together row by row.
+INSERT INTO products (
+  store_id,
+  sku,
+  name,
+  description,
+  price,
+  currency,
+  image_url,
+  stock,
+  category_id,
+  created_at
+)
+SELECT
+  sqlc.arg(store_id)::integer,
+  r.sku,
+  r.name,
+  nullif(r.description, ''),
+  r.price,
+  r.currency,
+  nullif(r.image_url, ''),
+  r.stock,
+  nullif(r.category_id, 0),
+  now()
+FROM (
+  SELECT
+    unnest(sqlc.arg(skus)::varchar[]) AS sku,
+    unnest(sqlc.arg(names)::varchar[]) AS name,
+    unnest(sqlc.arg(descriptions)::varchar[]) AS description,
+    unnest(sqlc.arg(prices)::numeric[]) AS price,
+    unnest(sqlc.arg(currencies)::varchar[]) AS currency,
+    unnest(sqlc.arg(image_urls)::varchar[]) AS image_url,
+    unnest(sqlc.arg(stocks)::integer[]) AS stock,
+    unnest(sqlc.arg(category_ids)::integer[]) AS category_id
+) r
+ON CONFLICT (store_id, sku) DO UPDATE SET
+  name = excluded.name,
+  description = excluded.description,
+  price = excluded.price,
+  currency = excluded.currency,
+  image_url = excluded.image_url,
+  stock = excluded.stock,
+  category_id = excluded.category_id
+RETURNING id, sku;
+
+-- name: CreateStockMovements :exec
+INSERT INTO stock_movements (
+  product_id,
+  quantity,
+  reason,
+  reference,
+  note
+)
+SELECT m.product_id, m.quantity, m.reason, sqlc.arg(reference)::varchar, m.note
+FROM (
+  SELECT
+    unnest(sqlc.arg(product_ids)::integer[]) AS product_id,
+    unnest(sqlc.arg(quantities)::integer[]) AS quantity,
+    unnest(sqlc.arg(reasons)::varchar[]) AS reason,
+    unnest(sqlc.arg(notes)::varchar[]) AS note
+) m;
+
+-- name: ListStoreProducts :many
+-- Keyset pagination, pass the last id of the previous page as after_id.
+SELECT * FROM products
+WHERE store_id = sqlc.arg(store_id)::integer AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(row_limit);
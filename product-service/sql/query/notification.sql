@@ -0,0 +1,114 @@
+-- name: GetNotificationRecipient :one
+-- Deleted and deactivated users are not sent anything.
+SELECT u.id, u.username, u.email, coalesce(p.display_name, '')::varchar AS display_name,
+  coalesce(p.locale, '')::varchar AS locale
+FROM users u
+LEFT JOIN user_profiles p ON p.user_id = u.id
+WHERE u.id = $1 AND u.email IS NOT NULL
+  AND u.deleted_at IS NULL AND u.deactivated_at IS NULL;
+
+-- name: CreateNotification :one
+INSERT INTO notifications (
+  user_id,
+  kind,
+  subject,
+  body
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: ListNotifications :many
+-- Newest first, pass the id of the last one seen as before_id for the next
+-- page.
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+  AND (NOT sqlc.arg(unread_only)::boolean OR read_at IS NULL)
+  AND (sqlc.arg(before_id)::bigint = 0 OR id < sqlc.arg(before_id)::bigint)
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size)::integer;
+
+-- name: CountUnreadNotifications :one
+SELECT count(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications SET read_at = now()
+WHERE user_id = sqlc.arg(user_id) AND read_at IS NULL
+  AND id = ANY(sqlc.arg(ids)::bigint[]);
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications SET read_at = now()
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: DeleteNotificationsByUser :exec
+DELETE FROM notifications
+WHERE user_id = $1;
+
+-- name: ListNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1
+ORDER BY category;
+
+-- name: GetNotificationPreference :one
+SELECT * FROM notification_preferences
+WHERE user_id = $1 AND category = $2;
+
+-- name: UpsertNotificationPreference :one
+INSERT INTO notification_preferences (
+  user_id,
+  category,
+  email,
+  in_app
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (user_id, category) DO UPDATE SET
+  email = excluded.email,
+  in_app = excluded.in_app,
+  updated_at = now()
+RETURNING *;
+
+-- name: DeleteNotificationPreferencesByUser :exec
+DELETE FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: QueueEmail :exec
+-- Written in the transaction of the notification, the mailer sends the email
+-- once that transaction has committed.
+INSERT INTO outgoing_emails (
+  user_id,
+  email,
+  kind,
+  subject,
+  body
+) VALUES (
+  $1, $2, $3, $4, $5
+);
+
+-- name: ListPendingEmails :many
+-- Locks the emails so a second mailer skips them instead of sending them
+-- twice. Parked emails are left out.
+SELECT * FROM outgoing_emails
+WHERE sent_at IS NULL AND failed_at IS NULL
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkEmailSent :exec
+UPDATE outgoing_emails SET sent_at = now(), attempts = attempts + 1, last_error = NULL
+WHERE id = $1;
+
+-- name: MarkEmailFailed :one
+-- Parks the email once it failed max_attempts times and reports whether it
+-- did.
+UPDATE outgoing_emails SET
+  attempts = attempts + 1,
+  last_error = sqlc.arg(last_error),
+  failed_at = CASE WHEN attempts + 1 >= sqlc.arg(max_attempts)::integer THEN now() END
+WHERE id = sqlc.arg(id)
+RETURNING (failed_at IS NOT NULL)::boolean AS parked;
+
+-- name: DeleteEmailsByUser :exec
+DELETE FROM outgoing_emails
+WHERE user_id = $1;
@@ -0,0 +1,47 @@
+-- name: ListProductsForPricing :many
+SELECT id, name, store_id, category_id, price, currency FROM products
+WHERE id = ANY(sqlc.arg(ids)::integer[]);
+
+-- name: ListVariantsForPricing :many
+SELECT id, product_id, sku, price, currency FROM product_variants
+WHERE id = ANY(sqlc.arg(ids)::integer[]);
+
+-- name: CreateOrder :one
+INSERT INTO orders (
+  user_id,
+  store_id,
+  currency,
+  subtotal_amount,
+  discount_amount,
+  total_amount,
+  coupon_code,
+  status
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: CreateOrderItem :exec
+INSERT INTO order_items (
+  order_id,
+  product_id,
+  variant_id,
+  quantity,
+  price,
+  discount
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+);
+
+-- name: CancelOrder :one
+-- Only a pending order of the user can be cancelled.
+UPDATE orders SET status = 'cancelled'
+WHERE id = $1 AND user_id = $2 AND status = 'pending'
+RETURNING *;
+
+-- name: ListOrderItems :many
+SELECT oi.order_id, oi.product_id, oi.variant_id, p.name, oi.quantity, oi.price, oi.discount
+FROM order_items oi
+LEFT JOIN products p ON p.id = oi.product_id
+WHERE oi.order_id = $1
+ORDER BY oi.id;
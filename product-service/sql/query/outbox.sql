@@ -0,0 +1,33 @@
+-- name: InsertOutboxEvent :exec
+-- Written in the transaction of the change the event describes.
+INSERT INTO outbox (
+  service,
+  topic,
+  partition_key,
+  payload
+) VALUES (
+  $1, $2, $3, $4
+);
+
+-- name: ListPendingOutboxEvents :many
+-- Locks the events so a second relay skips them instead of sending them twice.
+-- Parked events are left out.
+SELECT id, topic, partition_key, payload FROM outbox
+WHERE service = $1 AND published_at IS NULL AND failed_at IS NULL
+ORDER BY id
+LIMIT $2
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox SET published_at = now(), attempts = attempts + 1, last_error = NULL
+WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :one
+-- Parks the event once it failed max_attempts times and reports whether it
+-- did.
+UPDATE outbox SET
+  attempts = attempts + 1,
+  last_error = sqlc.arg(last_error),
+  failed_at = CASE WHEN attempts + 1 >= sqlc.arg(max_attempts)::integer THEN now() END
+WHERE id = sqlc.arg(id)
+RETURNING (failed_at IS NOT NULL)::boolean AS parked;
@@ -4,10 +4,27 @@ INSERT INTO products (
   name, 
   description,
   price,
+  currency,
   image_url,
   stock,
-  category_id
+  category_id,
+  sku
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetProduct :one
+SELECT * FROM products
+WHERE id = $1;
+
+-- name: AddProductStock :one
+UPDATE products
+SET stock = coalesce(stock, 0) + sqlc.arg(quantity)::integer
+WHERE id = sqlc.arg(id) AND coalesce(stock, 0) + sqlc.arg(quantity) >= 0
+RETURNING stock;
+
+-- name: SetProductStock :exec
+UPDATE products
+SET stock = $2
+WHERE id = $1;
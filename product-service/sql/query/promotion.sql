@@ -0,0 +1,66 @@
+-- name: CreatePromotion :one
+INSERT INTO promotions (
+  name,
+  kind,
+  percent_off,
+  amount_off,
+  currency,
+  store_id,
+  category_id,
+  coupon_code,
+  usage_limit,
+  starts_at,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, coalesce(sqlc.narg(starts_at)::timestamp, now()), $10
+)
+RETURNING *;
+
+-- name: ListStorePromotions :many
+SELECT * FROM promotions
+WHERE store_id = $1
+ORDER BY id DESC;
+
+-- name: ListAutomaticPromotions :many
+-- Promotions without a coupon that currently apply to any of the stores, or to
+-- every store.
+SELECT * FROM promotions
+WHERE active
+  AND coupon_code IS NULL
+  AND starts_at <= now()
+  AND (expires_at IS NULL OR expires_at > now())
+  AND (usage_limit IS NULL OR usage_count < usage_limit)
+  AND (store_id IS NULL OR store_id = ANY(sqlc.arg(store_ids)::integer[]))
+ORDER BY id;
+
+-- name: GetPromotionByCoupon :one
+SELECT * FROM promotions
+WHERE upper(coupon_code) = upper(sqlc.arg(code)::text);
+
+-- name: RedeemPromotion :one
+-- Counts one use, returns no rows once the usage limit is reached.
+UPDATE promotions SET usage_count = usage_count + 1
+WHERE id = $1 AND (usage_limit IS NULL OR usage_count < usage_limit)
+RETURNING usage_count;
+
+-- name: CreatePromotionRedemption :exec
+INSERT INTO promotion_redemptions (
+  promotion_id,
+  order_id,
+  amount
+) VALUES (
+  $1, $2, $3
+);
+
+-- name: ReleaseOrderPromotions :exec
+-- Gives back the uses a cancelled order counted and drops its redemptions.
+WITH released AS (
+  DELETE FROM promotion_redemptions
+  WHERE order_id = $1
+  RETURNING promotion_id
+)
+UPDATE promotions p SET usage_count = p.usage_count - r.uses
+FROM (
+  SELECT promotion_id, count(*)::integer AS uses FROM released GROUP BY promotion_id
+) r
+WHERE p.id = r.promotion_id;
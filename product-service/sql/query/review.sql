@@ -0,0 +1,64 @@
+-- name: GetUserIDByEmail :one
+SELECT id FROM users
+WHERE lower(email) = lower(sqlc.arg(email))
+  AND deleted_at IS NULL AND deactivated_at IS NULL;
+
+-- name: HasPurchased :one
+SELECT EXISTS (
+  SELECT 1 FROM order_items oi
+  JOIN orders o ON o.id = oi.order_id
+  WHERE o.user_id = sqlc.arg(user_id)::integer
+    AND oi.product_id = sqlc.arg(product_id)::integer
+    AND coalesce(o.status, '') NOT IN ('cancelled', 'refunded')
+);
+
+-- name: CreateReview :one
+-- Returns no rows when the user already reviewed the product.
+INSERT INTO reviews (
+  product_id,
+  user_id,
+  rating,
+  body,
+  verified_purchase
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (product_id, user_id) DO NOTHING
+RETURNING *;
+
+-- name: GetReview :one
+SELECT * FROM reviews
+WHERE id = $1;
+
+-- name: UpdateReview :one
+-- An edited review goes back to moderation.
+UPDATE reviews
+SET rating = $3, body = $4, status = 'pending', updated_at = now()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: SetReviewStatus :one
+UPDATE reviews
+SET status = $2, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListReviews :many
+SELECT r.*, coalesce(u.username, '')::varchar AS username
+FROM reviews r
+JOIN users u ON u.id = r.user_id
+WHERE r.product_id = $1 AND r.status = 'approved'
+ORDER BY r.created_at DESC, r.id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: RefreshProductRating :exec
+-- Only approved reviews count towards the rating shown on the product.
+UPDATE products p
+SET rating_count = s.count, rating_average = s.average
+FROM (
+  SELECT count(*)::integer AS count, coalesce(round(avg(rating), 2), 0)::numeric(3,2) AS average
+  FROM reviews
+  WHERE product_id = sqlc.arg(product_id) AND status = 'approved'
+) s
+WHERE p.id = sqlc.arg(product_id);
@@ -0,0 +1,95 @@
+-- Every search query takes the same filters. When fuzzy is false products are
+-- matched with full-text search over name and description, when it is true
+-- they are matched by trigram similarity of the name so typos still hit.
+
+-- name: SearchProducts :many
+SELECT
+  p.id, p.store_id, p.name, p.description, p.price, p.currency,
+  p.image_url, p.stock, p.category_id, p.created_at, p.rating_average, p.rating_count,
+  (CASE
+    WHEN sqlc.arg(fuzzy)::boolean THEN similarity(p.name, sqlc.arg(query)::text)
+    ELSE ts_rank(p.search_vector, websearch_to_tsquery('simple', sqlc.arg(query)::text))
+  END)::real AS rank
+FROM products p
+WHERE (
+    (sqlc.arg(fuzzy)::boolean AND p.name % sqlc.arg(query)::text) OR
+    (NOT sqlc.arg(fuzzy)::boolean AND p.search_vector @@ websearch_to_tsquery('simple', sqlc.arg(query)::text))
+  )
+  AND (sqlc.narg(category_id)::integer IS NULL OR p.category_id = sqlc.narg(category_id)::integer)
+  AND (sqlc.narg(store_id)::integer IS NULL OR p.store_id = sqlc.narg(store_id)::integer)
+  AND (sqlc.narg(currency)::char(3) IS NULL OR p.currency = sqlc.narg(currency)::char(3))
+  AND (sqlc.narg(min_price)::numeric IS NULL OR p.price >= sqlc.narg(min_price)::numeric)
+  AND (sqlc.narg(max_price)::numeric IS NULL OR p.price <= sqlc.narg(max_price)::numeric)
+ORDER BY rank DESC, p.id
+LIMIT sqlc.arg(row_limit)::integer
+OFFSET sqlc.arg(row_offset)::integer;
+
+-- name: SearchCategoryFacets :many
+SELECT c.id, c.name, count(*) AS count
+FROM products p
+JOIN category c ON c.id = p.category_id
+WHERE (
+    (sqlc.arg(fuzzy)::boolean AND p.name % sqlc.arg(query)::text) OR
+    (NOT sqlc.arg(fuzzy)::boolean AND p.search_vector @@ websearch_to_tsquery('simple', sqlc.arg(query)::text))
+  )
+  AND (sqlc.narg(category_id)::integer IS NULL OR p.category_id = sqlc.narg(category_id)::integer)
+  AND (sqlc.narg(store_id)::integer IS NULL OR p.store_id = sqlc.narg(store_id)::integer)
+  AND (sqlc.narg(currency)::char(3) IS NULL OR p.currency = sqlc.narg(currency)::char(3))
+  AND (sqlc.narg(min_price)::numeric IS NULL OR p.price >= sqlc.narg(min_price)::numeric)
+  AND (sqlc.narg(max_price)::numeric IS NULL OR p.price <= sqlc.narg(max_price)::numeric)
+GROUP BY c.id, c.name
+ORDER BY count DESC, c.id;
+
+-- name: SearchStoreFacets :many
+SELECT s.id, s.store_name, count(*) AS count
+FROM products p
+JOIN stores s ON s.id = p.store_id
+WHERE (
+    (sqlc.arg(fuzzy)::boolean AND p.name % sqlc.arg(query)::text) OR
+    (NOT sqlc.arg(fuzzy)::boolean AND p.search_vector @@ websearch_to_tsquery('simple', sqlc.arg(query)::text))
+  )
+  AND (sqlc.narg(category_id)::integer IS NULL OR p.category_id = sqlc.narg(category_id)::integer)
+  AND (sqlc.narg(store_id)::integer IS NULL OR p.store_id = sqlc.narg(store_id)::integer)
+  AND (sqlc.narg(currency)::char(3) IS NULL OR p.currency = sqlc.narg(currency)::char(3))
+  AND (sqlc.narg(min_price)::numeric IS NULL OR p.price >= sqlc.narg(min_price)::numeric)
+  AND (sqlc.narg(max_price)::numeric IS NULL OR p.price <= sqlc.narg(max_price)::numeric)
+GROUP BY s.id, s.store_name
+ORDER BY count DESC, s.id;
+
+-- name: SearchPriceFacets :many
+-- Splits the matched prices of each currency into equal-width buckets and
+-- reports the cheapest and most expensive price inside every bucket.
+WITH matches AS (
+  SELECT p.price, p.currency
+  FROM products p
+  WHERE p.price IS NOT NULL
+    AND (
+      (sqlc.arg(fuzzy)::boolean AND p.name % sqlc.arg(query)::text) OR
+      (NOT sqlc.arg(fuzzy)::boolean AND p.search_vector @@ websearch_to_tsquery('simple', sqlc.arg(query)::text))
+    )
+    AND (sqlc.narg(category_id)::integer IS NULL OR p.category_id = sqlc.narg(category_id)::integer)
+    AND (sqlc.narg(store_id)::integer IS NULL OR p.store_id = sqlc.narg(store_id)::integer)
+    AND (sqlc.narg(currency)::char(3) IS NULL OR p.currency = sqlc.narg(currency)::char(3))
+    AND (sqlc.narg(min_price)::numeric IS NULL OR p.price >= sqlc.narg(min_price)::numeric)
+    AND (sqlc.narg(max_price)::numeric IS NULL OR p.price <= sqlc.narg(max_price)::numeric)
+), bounds AS (
+  SELECT currency, min(price) AS low, max(price) + 0.01 AS high
+  FROM matches
+  GROUP BY currency
+), bucketed AS (
+  SELECT
+    m.currency,
+    m.price,
+    width_bucket(m.price, b.low, b.high, sqlc.arg(buckets)::integer)::integer AS bucket
+  FROM matches m
+  JOIN bounds b ON b.currency = m.currency
+)
+SELECT
+  currency,
+  bucket,
+  min(price)::text AS min_price,
+  max(price)::text AS max_price,
+  count(*) AS count
+FROM bucketed
+GROUP BY currency, bucket
+ORDER BY currency, bucket;
@@ -0,0 +1,51 @@
+-- name: CreateStockMovement :one
+INSERT INTO stock_movements (
+  product_id,
+  quantity,
+  reason,
+  reference,
+  note,
+  variant_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: ListStockMovements :many
+SELECT * FROM stock_movements
+WHERE product_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: LockProductStock :exec
+-- Holds the stock of every product until the transaction ends, so no
+-- adjustment lands between reading the drift and fixing it.
+SELECT id FROM products ORDER BY id FOR UPDATE;
+
+-- name: LockVariantStock :exec
+SELECT id FROM product_variants ORDER BY id FOR UPDATE;
+
+-- name: ListStockDrift :many
+-- Products without variants keep their stock on the product row and report
+-- variant_id 0, each variant keeps its own stock and ledger.
+SELECT
+  p.id AS product_id,
+  0::integer AS variant_id,
+  coalesce(p.stock, 0)::integer AS stock,
+  coalesce(sum(m.quantity), 0)::integer AS ledger_stock
+FROM products p
+LEFT JOIN stock_movements m ON m.product_id = p.id AND m.variant_id IS NULL
+GROUP BY p.id
+HAVING coalesce(p.stock, 0) <> coalesce(sum(m.quantity), 0)
+UNION ALL
+SELECT
+  v.product_id,
+  v.id AS variant_id,
+  v.stock,
+  coalesce(sum(m.quantity), 0)::integer AS ledger_stock
+FROM product_variants v
+LEFT JOIN stock_movements m ON m.variant_id = v.id
+GROUP BY v.id
+HAVING v.stock <> coalesce(sum(m.quantity), 0)
+ORDER BY product_id, variant_id;
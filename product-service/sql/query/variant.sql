@@ -0,0 +1,85 @@
+-- name: UpsertOptionType :one
+INSERT INTO option_types (
+  product_id,
+  name,
+  position
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (product_id, name) DO UPDATE SET name = excluded.name
+RETURNING *;
+
+-- name: UpsertOptionValue :one
+INSERT INTO option_values (
+  option_type_id,
+  value,
+  position
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (option_type_id, value) DO UPDATE SET value = excluded.value
+RETURNING *;
+
+-- name: ListOptionTypes :many
+SELECT * FROM option_types
+WHERE product_id = $1
+ORDER BY position, id;
+
+-- name: ListOptionValues :many
+SELECT ov.* FROM option_values ov
+JOIN option_types ot ON ot.id = ov.option_type_id
+WHERE ot.product_id = $1
+ORDER BY ov.position, ov.id;
+
+-- name: CreateVariant :one
+INSERT INTO product_variants (
+  product_id,
+  sku,
+  price,
+  currency,
+  stock,
+  image_url
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: AddVariantOptionValue :exec
+INSERT INTO variant_option_values (
+  variant_id,
+  option_value_id
+) VALUES (
+  $1, $2
+);
+
+-- name: GetVariant :one
+SELECT * FROM product_variants
+WHERE id = $1;
+
+-- name: GetVariantBySku :one
+SELECT * FROM product_variants
+WHERE sku = $1;
+
+-- name: ListVariants :many
+SELECT * FROM product_variants
+WHERE product_id = $1
+ORDER BY id;
+
+-- name: ListVariantOptions :many
+SELECT vov.variant_id, ot.name, ov.value
+FROM variant_option_values vov
+JOIN option_values ov ON ov.id = vov.option_value_id
+JOIN option_types ot ON ot.id = ov.option_type_id
+WHERE ot.product_id = $1
+ORDER BY vov.variant_id, ot.position, ot.id;
+
+-- name: AddVariantStock :one
+UPDATE product_variants
+SET stock = stock + sqlc.arg(quantity)::integer
+WHERE id = sqlc.arg(id) AND product_id = sqlc.arg(product_id) AND stock + sqlc.arg(quantity) >= 0
+RETURNING stock;
+
+-- name: SetVariantStock :exec
+UPDATE product_variants
+SET stock = $2
+WHERE id = $1;
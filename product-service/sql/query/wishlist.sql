@@ -0,0 +1,117 @@
+-- name: AddWishlistItem :one
+-- Saves a product, or one of its variants, with its current price and stock.
+-- Adding an item again returns the saved one, no rows means the product or
+-- variant does not exist.
+INSERT INTO wishlist_items (
+  user_id,
+  product_id,
+  variant_id,
+  added_price,
+  seen_price,
+  seen_in_stock
+)
+SELECT sqlc.arg(user_id)::integer, p.id, v.id, coalesce(v.price, p.price), coalesce(v.price, p.price), coalesce(v.stock, p.stock, 0) > 0
+FROM products p
+LEFT JOIN product_variants v ON v.id = sqlc.narg(variant_id)::integer AND v.product_id = p.id
+WHERE p.id = sqlc.arg(product_id)::integer
+  AND (sqlc.narg(variant_id)::integer IS NULL OR v.id IS NOT NULL)
+ON CONFLICT (user_id, product_id, coalesce(variant_id, 0)) DO UPDATE SET user_id = EXCLUDED.user_id
+RETURNING *;
+
+-- name: DeleteWishlistItem :execrows
+DELETE FROM wishlist_items
+WHERE user_id = $1 AND product_id = $2 AND coalesce(variant_id, 0) = sqlc.arg(variant_id)::integer;
+
+-- name: GetWishlistItem :one
+SELECT
+  w.id,
+  w.product_id,
+  w.variant_id,
+  p.name,
+  p.price AS product_price,
+  p.currency AS product_currency,
+  p.stock AS product_stock,
+  v.price AS variant_price,
+  v.currency AS variant_currency,
+  v.stock AS variant_stock,
+  w.added_price,
+  w.created_at
+FROM wishlist_items w
+JOIN products p ON p.id = w.product_id
+LEFT JOIN product_variants v ON v.id = w.variant_id
+WHERE w.id = $1;
+
+-- name: ListWishlistItems :many
+SELECT
+  w.id,
+  w.product_id,
+  w.variant_id,
+  p.name,
+  p.price AS product_price,
+  p.currency AS product_currency,
+  p.stock AS product_stock,
+  v.price AS variant_price,
+  v.currency AS variant_currency,
+  v.stock AS variant_stock,
+  w.added_price,
+  w.created_at
+FROM wishlist_items w
+JOIN products p ON p.id = w.product_id
+LEFT JOIN product_variants v ON v.id = w.variant_id
+WHERE w.user_id = $1
+ORDER BY w.created_at DESC, w.id DESC;
+
+-- name: SyncWishlistItems :many
+-- Records the current price and stock of the wishlisted products and returns
+-- every item that changed along with what was seen before, so the caller can
+-- tell a price drop or a restock. Deleted and deactivated users are left out.
+WITH latest AS (
+  SELECT
+    w.id,
+    p.name,
+    v.price AS variant_price,
+    p.price AS product_price,
+    coalesce(v.currency, p.currency) AS currency,
+    coalesce(v.stock, p.stock, 0) > 0 AS in_stock,
+    w.seen_price,
+    w.seen_in_stock
+  FROM wishlist_items w
+  JOIN products p ON p.id = w.product_id
+  LEFT JOIN product_variants v ON v.id = w.variant_id
+  WHERE w.product_id = ANY(sqlc.arg(product_ids)::integer[])
+)
+UPDATE wishlist_items w
+SET seen_price = coalesce(latest.variant_price, latest.product_price), seen_in_stock = latest.in_stock
+FROM latest, users u
+WHERE w.id = latest.id
+  AND u.id = w.user_id
+  AND u.deleted_at IS NULL AND u.deactivated_at IS NULL
+  AND (w.seen_price IS DISTINCT FROM coalesce(latest.variant_price, latest.product_price) OR w.seen_in_stock <> latest.in_stock)
+RETURNING
+  w.id,
+  w.product_id,
+  w.variant_id,
+  u.email,
+  latest.name,
+  latest.currency,
+  latest.seen_price AS previous_price,
+  latest.seen_in_stock AS previous_in_stock,
+  latest.variant_price,
+  latest.product_price,
+  latest.in_stock;
+
+-- name: AddCartItem :one
+-- Adds quantity to the cart line of the product or variant, creating it when
+-- missing.
+INSERT INTO cart (
+  user_id,
+  product_id,
+  variant_id,
+  quantity,
+  price
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (user_id, product_id, coalesce(variant_id, 0)) DO UPDATE
+SET quantity = cart.quantity + EXCLUDED.quantity, price = EXCLUDED.price
+RETURNING *;
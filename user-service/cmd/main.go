@@ -3,9 +3,12 @@ package main
 import (
 	"fmt"
 	"log"
+	// profiles name IANA time zones, the image may have no zoneinfo
+	_ "time/tzdata"
 
 	"github.com/spriigan/RPApp/infrastructure"
 	"github.com/spriigan/RPApp/registry"
+	"github.com/spriigan/RPApp/usecases/interactor"
 )
 
 func main() {
@@ -13,7 +16,23 @@ func main() {
 	db := infrastructure.ConnectToDB()
 	defer db.Close()
 	register := registry.New(db)
-	close, err := app.StartGrpcServer(register.NewUserServer())
+	stopPurger := app.StartPurger(register.NewUserPurger(app.Config.RETENTION))
+	defer stopPurger()
+	relay, err := register.NewEventRelay(app.Config.EVENTS_DRIVER, app.Config.EVENTS_URL)
+	if err != nil {
+		log.Fatal("invalid events config: ", err)
+	}
+	stopRelay := app.StartRelay(relay)
+	defer stopRelay()
+	passwords, err := interactor.NewPasswordHasher(app.Config.PASSWORDS)
+	if err != nil {
+		log.Fatal("invalid password hashing config: ", err)
+	}
+	server, err := register.NewUserServer(passwords, app.Config.PASSWORD_POLICY, app.Config.BREACHED_PASSWORDS)
+	if err != nil {
+		log.Fatal("failed to open the breached password file: ", err)
+	}
+	close, err := app.StartGrpcServer(server)
 	if err != nil {
 		close()
 		log.Fatal("failed to start the server", err)
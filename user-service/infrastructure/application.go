@@ -6,9 +6,12 @@ import (
 	_ "github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/spf13/viper"
+	"github.com/spriigan/RPApp/usecases/audit"
+	"github.com/spriigan/RPApp/usecases/interactor"
 	"github.com/spriigan/RPApp/user-proto/grpc/models"
 	"google.golang.org/grpc"
 	"net"
+	"time"
 )
 
 type application struct {
@@ -20,12 +23,53 @@ func Application() application {
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(".")
 	viper.AddConfigPath("/app/")
+	viper.SetDefault("retention", interactor.DefaultRetention)
+	viper.SetDefault("purge_interval", time.Hour)
+	passwords := interactor.DefaultPasswordParams
+	viper.SetDefault("password.algorithm", passwords.Algorithm)
+	viper.SetDefault("password.argon2id.memory", passwords.Argon2id.Memory)
+	viper.SetDefault("password.argon2id.iterations", passwords.Argon2id.Iterations)
+	viper.SetDefault("password.argon2id.parallelism", passwords.Argon2id.Parallelism)
+	viper.SetDefault("password.bcrypt_cost", passwords.BcryptCost)
+	policy := interactor.DefaultPasswordPolicy
+	viper.SetDefault("password.policy.min_length", policy.MinLength)
+	viper.SetDefault("password.policy.max_length", policy.MaxLength)
+	viper.SetDefault("password.policy.min_classes", policy.MinClasses)
+	viper.SetDefault("password.policy.reject_identity", policy.RejectIdentity)
+	viper.SetDefault("password.policy.reject_breached", policy.RejectBreached)
+	viper.SetDefault("events.driver", "nats")
+	viper.SetDefault("events.url", "nats://nats-srv:4222")
+	viper.SetDefault("events.relay_interval", time.Second)
 	if err := viper.ReadInConfig(); err != nil {
 		panic(err)
 	}
 	return application{
 		Config: config{
-			GRPC_PORT: viper.GetInt("port"),
+			GRPC_PORT:      viper.GetInt("port"),
+			RETENTION:      viper.GetDuration("retention"),
+			PURGE_INTERVAL: viper.GetDuration("purge_interval"),
+			PASSWORDS: interactor.PasswordParams{
+				Algorithm: viper.GetString("password.algorithm"),
+				Argon2id: interactor.Argon2idParams{
+					Memory:      viper.GetUint32("password.argon2id.memory"),
+					Iterations:  viper.GetUint32("password.argon2id.iterations"),
+					Parallelism: uint8(viper.GetUint("password.argon2id.parallelism")),
+					SaltLength:  passwords.Argon2id.SaltLength,
+					KeyLength:   passwords.Argon2id.KeyLength,
+				},
+				BcryptCost: viper.GetInt("password.bcrypt_cost"),
+			},
+			PASSWORD_POLICY: interactor.PasswordPolicy{
+				MinLength:      viper.GetInt("password.policy.min_length"),
+				MaxLength:      viper.GetInt("password.policy.max_length"),
+				MinClasses:     viper.GetInt("password.policy.min_classes"),
+				RejectIdentity: viper.GetBool("password.policy.reject_identity"),
+				RejectBreached: viper.GetBool("password.policy.reject_breached"),
+			},
+			BREACHED_PASSWORDS: viper.GetString("password.breached_file"),
+			EVENTS_DRIVER:      viper.GetString("events.driver"),
+			EVENTS_URL:         viper.GetString("events.url"),
+			RELAY_INTERVAL:     viper.GetDuration("events.relay_interval"),
 		},
 	}
 }
@@ -37,7 +81,7 @@ func (app *application) StartGrpcServer(server models.UserServiceServer) (func()
 			lis.Close()
 		}, err
 	}
-	s := grpc.NewServer()
+	s := grpc.NewServer(grpc.UnaryInterceptor(audit.UnaryServerInterceptor))
 	models.RegisterUserServiceServer(s, server)
 
 	if err = s.Serve(lis); err != nil {
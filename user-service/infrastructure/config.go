@@ -1,6 +1,25 @@
 package infrastructure
 
+import (
+	"time"
+
+	"github.com/spriigan/RPApp/usecases/interactor"
+)
+
 type config struct {
-	GRPC_PORT int
-	DSN       string
+	GRPC_PORT       int
+	DSN             string
+	RETENTION       time.Duration
+	PURGE_INTERVAL  time.Duration
+	PASSWORDS       interactor.PasswordParams
+	PASSWORD_POLICY interactor.PasswordPolicy
+	// BREACHED_PASSWORDS is the path of the offline Pwned Passwords file,
+	// empty to skip the breach check.
+	BREACHED_PASSWORDS string
+	// EVENTS_DRIVER picks where the relay publishes the outbox, "memory"
+	// keeps the events in the process and "nats" sends them to EVENTS_URL.
+	// The product service consumes them, so they only reach it with "nats".
+	EVENTS_DRIVER  string
+	EVENTS_URL     string
+	RELAY_INTERVAL time.Duration
 }
@@ -0,0 +1,34 @@
+package infrastructure
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/spriigan/RPApp/usecases/interactor"
+)
+
+// StartPurger purges the deleted users once every purge interval, the returned
+// func stops it.
+func (app *application) StartPurger(purger interactor.UserPurger) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(app.Config.PURGE_INTERVAL)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			purged, err := purger.PurgeDeletedUsers(ctx)
+			if err != nil {
+				log.Println("failed to purge deleted users:", err)
+			} else if purged > 0 {
+				log.Printf("purged %d deleted users", purged)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return cancel
+}
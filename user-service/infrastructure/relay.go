@@ -0,0 +1,35 @@
+package infrastructure
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/spriigan/RPApp/usecases/messaging"
+)
+
+// StartRelay publishes the events of the outbox once every relay interval,
+// the returned func stops it. An event that failed is tried again on the
+// next tick.
+func (app *application) StartRelay(relay *messaging.Relay) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(app.Config.RELAY_INTERVAL)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			if _, err := relay.Flush(ctx); err != nil && ctx.Err() == nil {
+				log.Println("failed to publish events:", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		relay.Publisher.Close()
+	}
+}
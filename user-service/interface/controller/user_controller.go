@@ -3,10 +3,13 @@ package controller
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/spriigan/RPApp/interface/repository"
 	"github.com/spriigan/RPApp/usecases/interactor"
+	usecases "github.com/spriigan/RPApp/usecases/repository"
 	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -24,11 +27,31 @@ func NewUserServer(i interactor.UserInteractor) *userServer {
 func (us *userServer) RegisterUser(ctx context.Context, payload *models.UserPayload) (*models.UserBio, error) {
 	bio, err := us.interactor.Create(ctx, payload)
 	if err != nil {
-		return nil, status.Error(codes.AlreadyExists, err.Error())
+		if errors.Is(err, interactor.ErrWeakPassword) {
+			return nil, passwordError(err)
+		}
+		if errors.Is(err, interactor.ErrUsernameRequired) || errors.Is(err, interactor.ErrPasswordTooLong) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, repository.ErrUserTaken) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
 	}
 	return bio, nil
 }
 
+func (us *userServer) IsUsernameAvailable(ctx context.Context, username *models.Username) (*models.UsernameAvailability, error) {
+	available, err := us.interactor.IsUsernameAvailable(ctx, username.Username)
+	if err != nil {
+		if errors.Is(err, interactor.ErrUsernameRequired) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &models.UsernameAvailability{Available: available}, nil
+}
+
 func (us *userServer) FindByUsername(ctx context.Context, username *models.Username) (*models.UserBio, error) {
 	input := username.GetUsername()
 	foundUser, err := us.interactor.FindByUsername(ctx, input)
@@ -38,28 +61,48 @@ func (us *userServer) FindByUsername(ctx context.Context, username *models.Usern
 		}
 		return nil, status.Error(codes.Unknown, err.Error())
 	}
-	bio := models.UserBio{
-		Id:       foundUser.Id,
-		Fname:    foundUser.Fname,
-		Lname:    foundUser.Lname,
-		Username: foundUser.Username,
-		Email:    foundUser.Email,
+	return toBio(foundUser), nil
+}
+
+func (us *userServer) FindById(ctx context.Context, id *models.UserId) (*models.UserBio, error) {
+	foundUser, err := us.interactor.FindById(ctx, id.GetId())
+	if err != nil {
+		if errors.Is(err, repository.ErrNoUserFound) {
+			return nil, status.Error(codes.NotFound, repository.ErrNoUserFound.Error())
+		}
+		return nil, status.Error(codes.Unknown, err.Error())
 	}
-	return &bio, nil
+	return toBio(foundUser), nil
 }
 
-func (us *userServer) FindUsers(ctx context.Context, empty *emptypb.Empty) (*models.Users, error) {
-	users, err := us.interactor.FindUsers(ctx)
+func (us *userServer) FindByEmail(ctx context.Context, email *models.Email) (*models.UserBio, error) {
+	foundUser, err := us.interactor.FindByEmail(ctx, email.GetEmail())
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		if errors.Is(err, repository.ErrNoUserFound) {
+			return nil, status.Error(codes.NotFound, repository.ErrNoUserFound.Error())
+		}
+		return nil, status.Error(codes.Unknown, err.Error())
 	}
+	return toBio(foundUser), nil
+}
 
+func (us *userServer) BatchGetUsers(ctx context.Context, ids *models.UserIds) (*models.Users, error) {
+	users, err := us.interactor.BatchGetUsers(ctx, ids.GetIds())
+	if err != nil {
+		if errors.Is(err, interactor.ErrTooManyIds) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
 	return users, nil
 }
 
 func (us *userServer) DeleteByUsername(ctx context.Context, username *models.Username) (*emptypb.Empty, error) {
 	err := us.interactor.DeleteByUsername(ctx, username.Username)
 	if err != nil {
+		if errors.Is(err, repository.ErrNoUserFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
 		return &emptypb.Empty{}, status.Error(codes.Internal, err.Error())
 	}
 	return &emptypb.Empty{}, nil
@@ -68,7 +111,357 @@ func (us *userServer) DeleteByUsername(ctx context.Context, username *models.Use
 func (us *userServer) Update(ctx context.Context, payload *models.UserPayload) (*emptypb.Empty, error) {
 	err := us.interactor.Update(ctx, payload)
 	if err != nil {
+		if errors.Is(err, interactor.ErrWeakPassword) {
+			return nil, passwordError(err)
+		}
+		if errors.Is(err, interactor.ErrPasswordTooLong) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, repository.ErrNoUserFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(err, repository.ErrUserTaken) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	return &emptypb.Empty{}, nil
 }
+
+func (us *userServer) DeactivateUser(ctx context.Context, username *models.Username) (*emptypb.Empty, error) {
+	err := us.interactor.DeactivateUser(ctx, username.Username)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoUserFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (us *userServer) ExportUserData(ctx context.Context, username *models.Username) (*models.UserData, error) {
+	data, err := us.interactor.ExportUserData(ctx, username.Username)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoUserFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return data, nil
+}
+
+func (us *userServer) EraseUser(ctx context.Context, username *models.Username) (*emptypb.Empty, error) {
+	err := us.interactor.EraseUser(ctx, username.Username)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoUserFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (us *userServer) Login(ctx context.Context, credentials *models.Credentials) (*models.LoginResult, error) {
+	user, tokens, err := us.interactor.Login(ctx, credentials)
+	if err != nil {
+		if errors.Is(err, interactor.ErrInvalidCredentials) || errors.Is(err, interactor.ErrInvalidOtp) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if errors.Is(err, interactor.ErrOtpRequired) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		if errors.Is(err, interactor.ErrLoginLocked) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		if errors.Is(err, interactor.ErrUserSuspended) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &models.LoginResult{User: toBio(user), Tokens: tokens, PasswordResetRequired: user.PasswordResetRequired}, nil
+}
+
+func (us *userServer) RefreshSession(ctx context.Context, token *models.RefreshToken) (*models.AuthTokens, error) {
+	tokens, err := us.interactor.RefreshSession(ctx, token.Token)
+	if err != nil {
+		if errors.Is(err, interactor.ErrInvalidRefreshToken) ||
+			errors.Is(err, interactor.ErrRefreshTokenReused) ||
+			errors.Is(err, repository.ErrSessionNotFound) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return tokens, nil
+}
+
+func (us *userServer) Authenticate(ctx context.Context, token *models.AccessToken) (*models.SessionUser, error) {
+	session, err := us.interactor.Authenticate(ctx, token.Token)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &models.SessionUser{
+		User:                  toBio(session.User),
+		SessionId:             session.ID,
+		Impersonator:          session.Impersonator,
+		PasswordResetRequired: session.User.PasswordResetRequired,
+	}, nil
+}
+
+// AuthenticateAccount is what the broker asks for every Firebase ID token, so
+// a deleted, deactivated or suspended account is turned away.
+func (us *userServer) AuthenticateAccount(ctx context.Context, email *models.Email) (*models.SessionUser, error) {
+	user, err := us.interactor.AuthenticateAccount(ctx, email.Email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoUserFound) || errors.Is(err, interactor.ErrUserSuspended) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &models.SessionUser{
+		User:                  toBio(user),
+		PasswordResetRequired: user.PasswordResetRequired,
+	}, nil
+}
+
+func (us *userServer) ListSessions(ctx context.Context, username *models.Username) (*models.Sessions, error) {
+	sessions, err := us.interactor.ListSessions(ctx, username.Username)
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return sessions, nil
+}
+
+func (us *userServer) RevokeSession(ctx context.Context, ref *models.SessionRef) (*emptypb.Empty, error) {
+	err := us.interactor.RevokeSession(ctx, ref.Username, ref.SessionId)
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (us *userServer) RevokeAllSessions(ctx context.Context, username *models.Username) (*emptypb.Empty, error) {
+	err := us.interactor.RevokeAllSessions(ctx, username.Username)
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func sessionError(err error) error {
+	if errors.Is(err, repository.ErrNoUserFound) || errors.Is(err, repository.ErrSessionNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func (us *userServer) UnlockUser(ctx context.Context, action *models.AdminAction) (*emptypb.Empty, error) {
+	err := us.interactor.UnlockUser(ctx, action.Actor, action.Username, action.Reason)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (us *userServer) EnrollTotp(ctx context.Context, username *models.Username) (*models.TotpEnrollment, error) {
+	enrollment, err := us.interactor.EnrollTotp(ctx, username.Username)
+	if err != nil {
+		return nil, totpError(err)
+	}
+	return enrollment, nil
+}
+
+func (us *userServer) ConfirmTotp(ctx context.Context, code *models.TotpCode) (*models.RecoveryCodes, error) {
+	recoveryCodes, err := us.interactor.ConfirmTotp(ctx, code.Username, code.Code)
+	if err != nil {
+		return nil, totpError(err)
+	}
+	return recoveryCodes, nil
+}
+
+func (us *userServer) DisableTotp(ctx context.Context, code *models.TotpCode) (*emptypb.Empty, error) {
+	err := us.interactor.DisableTotp(ctx, code.Username, code.Code)
+	if err != nil {
+		return nil, totpError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func totpError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNoUserFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, interactor.ErrTotpEnabled):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, interactor.ErrTotpNotEnrolled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, interactor.ErrInvalidOtp), errors.Is(err, interactor.ErrOtpRequired):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func (us *userServer) GetProfile(ctx context.Context, username *models.Username) (*models.Profile, error) {
+	profile, err := us.interactor.GetProfile(ctx, username.Username)
+	if err != nil {
+		return nil, profileError(err)
+	}
+	return profile, nil
+}
+
+func (us *userServer) UpdateProfile(ctx context.Context, update *models.ProfileUpdate) (*models.Profile, error) {
+	profile, err := us.interactor.UpdateProfile(ctx, update.Username, update.Profile, update.UpdateMask)
+	if err != nil {
+		return nil, profileError(err)
+	}
+	return profile, nil
+}
+
+func profileError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNoUserFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, interactor.ErrInvalidProfile):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func (us *userServer) SearchUsers(ctx context.Context, search *models.UserSearch) (*models.UserAccounts, error) {
+	accounts, err := us.interactor.SearchUsers(ctx, search.Actor, usecases.UserSearch{
+		Query:   search.Query,
+		Role:    search.Role,
+		Status:  search.Status,
+		Limit:   int(search.Limit),
+		AfterID: search.AfterId,
+	})
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return accounts, nil
+}
+
+func (us *userServer) SuspendUser(ctx context.Context, action *models.AdminAction) (*emptypb.Empty, error) {
+	err := us.interactor.SuspendUser(ctx, action.Actor, action.Username, action.Reason)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (us *userServer) ReactivateUser(ctx context.Context, action *models.AdminAction) (*emptypb.Empty, error) {
+	err := us.interactor.ReactivateUser(ctx, action.Actor, action.Username, action.Reason)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (us *userServer) UnsuspendUser(ctx context.Context, action *models.AdminAction) (*emptypb.Empty, error) {
+	err := us.interactor.UnsuspendUser(ctx, action.Actor, action.Username, action.Reason)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (us *userServer) ForcePasswordReset(ctx context.Context, action *models.AdminAction) (*emptypb.Empty, error) {
+	err := us.interactor.ForcePasswordReset(ctx, action.Actor, action.Username, action.Reason)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (us *userServer) Impersonate(ctx context.Context, action *models.AdminAction) (*models.AuthTokens, error) {
+	tokens, err := us.interactor.Impersonate(ctx, action.Actor, action.Username, action.Reason)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return tokens, nil
+}
+
+func (us *userServer) SetRole(ctx context.Context, change *models.RoleChange) (*emptypb.Empty, error) {
+	err := us.interactor.SetRole(ctx, change.Actor, change.Username, change.Role, change.Reason)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// SearchAuditLog leaves the time bounds that are not set open.
+func (us *userServer) SearchAuditLog(ctx context.Context, query *models.AuditQuery) (*models.AuditEntries, error) {
+	search := usecases.AuditQuery{
+		Actor:      query.PerformedBy,
+		Action:     query.Action,
+		TargetType: query.TargetType,
+		TargetID:   query.TargetId,
+		RequestID:  query.RequestId,
+		Limit:      int(query.Limit),
+		BeforeID:   query.BeforeId,
+	}
+	if query.From != nil {
+		search.From = query.From.AsTime()
+	}
+	if query.To != nil {
+		search.To = query.To.AsTime()
+	}
+	entries, err := us.interactor.SearchAuditLog(ctx, query.Actor, search)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	return entries, nil
+}
+
+func adminError(err error) error {
+	switch {
+	case errors.Is(err, interactor.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, repository.ErrNoUserFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, interactor.ErrInvalidRole), errors.Is(err, interactor.ErrInvalidStatus), errors.Is(err, interactor.ErrReasonRequired):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// passwordError is an InvalidArgument status whose details name every rule
+// the password broke: a BadRequest with a violation per rule and an ErrorInfo
+// listing the rules for clients that branch on them.
+func passwordError(err error) error {
+	var pe *interactor.PasswordError
+	if !errors.As(err, &pe) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	badRequest := &errdetails.BadRequest{}
+	rules := make([]string, 0, len(pe.Violations))
+	for _, v := range pe.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       "password",
+			Description: v.Description,
+		})
+		rules = append(rules, v.Rule)
+	}
+	info := &errdetails.ErrorInfo{
+		Reason:   "WEAK_PASSWORD",
+		Domain:   "user-service",
+		Metadata: map[string]string{"rules": strings.Join(rules, ",")},
+	}
+	st, detailErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(badRequest, info)
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return st.Err()
+}
+
+func toBio(user *models.User) *models.UserBio {
+	return &models.UserBio{
+		Id:       user.Id,
+		Fname:    user.Fname,
+		Lname:    user.Lname,
+		Username: user.Username,
+		Email:    user.Email,
+	}
+}
@@ -3,6 +3,7 @@ package controller_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net"
 	"os"
@@ -11,15 +12,19 @@ import (
 
 	"github.com/spriigan/RPApp/interface/controller"
 	"github.com/spriigan/RPApp/interface/repository"
+	"github.com/spriigan/RPApp/usecases/interactor"
+	usecases "github.com/spriigan/RPApp/usecases/repository"
 	"github.com/spriigan/RPApp/user-proto/grpc/models"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
-	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type interactorMock struct {
@@ -34,12 +39,142 @@ func (in *interactorMock) Create(ctx context.Context, payload *models.UserPayloa
 	return args.Get(0).(*models.UserBio), args.Error(1)
 }
 
-func (in *interactorMock) FindUsers(ctx context.Context) (*models.Users, error) {
-	args := in.Called()
+func (in *interactorMock) IsUsernameAvailable(ctx context.Context, username string) (bool, error) {
+	args := in.Called(username)
+	return args.Bool(0), args.Error(1)
+}
+
+func (in *interactorMock) Login(ctx context.Context, credentials *models.Credentials) (*models.User, *models.AuthTokens, error) {
+	args := in.Called(credentials.Username, credentials.Password, credentials.Ip)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.User), args.Get(1).(*models.AuthTokens), args.Error(2)
+}
+
+func (in *interactorMock) RefreshSession(ctx context.Context, refreshToken string) (*models.AuthTokens, error) {
+	args := in.Called(refreshToken)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.Users), args.Error(1)
+	return args.Get(0).(*models.AuthTokens), args.Error(1)
+}
+
+func (in *interactorMock) Authenticate(ctx context.Context, accessToken string) (usecases.ActiveSession, error) {
+	args := in.Called(accessToken)
+	return args.Get(0).(usecases.ActiveSession), args.Error(1)
+}
+
+func (in *interactorMock) AuthenticateAccount(ctx context.Context, email string) (*models.User, error) {
+	args := in.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (in *interactorMock) ListSessions(ctx context.Context, username string) (*models.Sessions, error) {
+	args := in.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Sessions), args.Error(1)
+}
+
+func (in *interactorMock) RevokeSession(ctx context.Context, username string, sessionID int64) error {
+	args := in.Called(username, sessionID)
+	return args.Error(0)
+}
+
+func (in *interactorMock) RevokeAllSessions(ctx context.Context, username string) error {
+	args := in.Called(username)
+	return args.Error(0)
+}
+
+func (in *interactorMock) UnlockUser(ctx context.Context, actor, username, reason string) error {
+	args := in.Called(actor, username, reason)
+	return args.Error(0)
+}
+
+func (in *interactorMock) EnrollTotp(ctx context.Context, username string) (*models.TotpEnrollment, error) {
+	args := in.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TotpEnrollment), args.Error(1)
+}
+
+func (in *interactorMock) ConfirmTotp(ctx context.Context, username, code string) (*models.RecoveryCodes, error) {
+	args := in.Called(username, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RecoveryCodes), args.Error(1)
+}
+
+func (in *interactorMock) DisableTotp(ctx context.Context, username, code string) error {
+	args := in.Called(username, code)
+	return args.Error(0)
+}
+
+func (in *interactorMock) GetProfile(ctx context.Context, username string) (*models.Profile, error) {
+	args := in.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Profile), args.Error(1)
+}
+
+func (in *interactorMock) UpdateProfile(ctx context.Context, username string, profile *models.Profile, mask *fieldmaskpb.FieldMask) (*models.Profile, error) {
+	args := in.Called(username, profile, mask.GetPaths())
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Profile), args.Error(1)
+}
+
+func (in *interactorMock) SearchUsers(ctx context.Context, actor string, search usecases.UserSearch) (*models.UserAccounts, error) {
+	args := in.Called(actor, search)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserAccounts), args.Error(1)
+}
+
+func (in *interactorMock) SuspendUser(ctx context.Context, actor, username, reason string) error {
+	args := in.Called(actor, username, reason)
+	return args.Error(0)
+}
+
+func (in *interactorMock) UnsuspendUser(ctx context.Context, actor, username, reason string) error {
+	args := in.Called(actor, username, reason)
+	return args.Error(0)
+}
+
+func (in *interactorMock) ForcePasswordReset(ctx context.Context, actor, username, reason string) error {
+	args := in.Called(actor, username, reason)
+	return args.Error(0)
+}
+
+func (in *interactorMock) Impersonate(ctx context.Context, actor, username, reason string) (*models.AuthTokens, error) {
+	args := in.Called(actor, username, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuthTokens), args.Error(1)
+}
+
+func (in *interactorMock) SetRole(ctx context.Context, actor, username, role, reason string) error {
+	args := in.Called(actor, username, role, reason)
+	return args.Error(0)
+}
+
+func (in *interactorMock) SearchAuditLog(ctx context.Context, actor string, query usecases.AuditQuery) (*models.AuditEntries, error) {
+	args := in.Called(actor, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuditEntries), args.Error(1)
 }
 
 func (in *interactorMock) FindByUsername(ctx context.Context, username string) (*models.User, error) {
@@ -50,6 +185,30 @@ func (in *interactorMock) FindByUsername(ctx context.Context, username string) (
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (in *interactorMock) FindById(ctx context.Context, id int64) (*models.User, error) {
+	args := in.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (in *interactorMock) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := in.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (in *interactorMock) BatchGetUsers(ctx context.Context, ids []int64) (*models.Users, error) {
+	args := in.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Users), args.Error(1)
+}
+
 func (in *interactorMock) DeleteByUsername(ctx context.Context, username string) error {
 	args := in.Called(username)
 	return args.Error(0)
@@ -60,6 +219,29 @@ func (in *interactorMock) Update(ctx context.Context, user *models.UserPayload)
 	return args.Error(0)
 }
 
+func (in *interactorMock) DeactivateUser(ctx context.Context, username string) error {
+	args := in.Called(username)
+	return args.Error(0)
+}
+
+func (in *interactorMock) ReactivateUser(ctx context.Context, actor, username, reason string) error {
+	args := in.Called(actor, username, reason)
+	return args.Error(0)
+}
+
+func (in *interactorMock) ExportUserData(ctx context.Context, username string) (*models.UserData, error) {
+	args := in.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserData), args.Error(1)
+}
+
+func (in *interactorMock) EraseUser(ctx context.Context, username string) error {
+	args := in.Called(username)
+	return args.Error(0)
+}
+
 var mockInteractor *interactorMock
 var client models.UserServiceClient
 var lis *bufconn.Listener
@@ -112,6 +294,40 @@ func TestRegisterUser(t *testing.T) {
 				require.Zero(t, actual)
 			},
 		},
+		"taken": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("Create", mock.Anything).Return(nil, repository.ErrUserTaken).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserBio, err error) {
+				require.Equal(t, codes.AlreadyExists, status.Code(err))
+			},
+		},
+		"no username": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("Create", mock.Anything).Return(nil, interactor.ErrUsernameRequired).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserBio, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		"weak password": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("Create", mock.Anything).Return(nil, &interactor.PasswordError{Violations: []interactor.PasswordViolation{
+					{Rule: interactor.RuleMinLength, Description: "must be at least 10 characters long"},
+					{Rule: interactor.RuleBreached, Description: "has appeared in a data breach, choose another one"},
+				}}).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserBio, err error) {
+				st := status.Convert(err)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+				require.Len(t, st.Details(), 2)
+				badRequest := st.Details()[0].(*errdetails.BadRequest)
+				require.Len(t, badRequest.FieldViolations, 2)
+				require.Equal(t, "password", badRequest.FieldViolations[0].Field)
+				info := st.Details()[1].(*errdetails.ErrorInfo)
+				require.Equal(t, "min_length,breached", info.Metadata["rules"])
+			},
+		},
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -126,6 +342,50 @@ func TestRegisterUser(t *testing.T) {
 	}
 }
 
+func TestIsUsernameAvailable(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.UsernameAvailability, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("IsUsernameAvailable", "dabi").Return(true, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.UsernameAvailability, err error) {
+				require.NoError(t, err)
+				require.True(t, actual.Available)
+			},
+		},
+		"no username": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("IsUsernameAvailable", "dabi").Return(false, interactor.ErrUsernameRequired).Once()
+			},
+			assert: func(t *testing.T, actual *models.UsernameAvailability, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("IsUsernameAvailable", "dabi").Return(false, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *models.UsernameAvailability, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.IsUsernameAvailable(ctx, &models.Username{Username: "dabi"})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
 func TestFindByUsername(t *testing.T) {
 	user := &models.User{
 		Fname: "dabi",
@@ -177,45 +437,109 @@ func TestFindByUsername(t *testing.T) {
 	}
 }
 
-func TestFindUsers(t *testing.T) {
-	bio := []*models.UserBio{
-		{},
-		{},
-		{},
+func TestFindById(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.UserBio, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("FindById", int64(7)).Return(&models.User{Id: 7, Fname: "dabi", Password: "secret"}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserBio, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(7), actual.Id)
+				require.Equal(t, "dabi", actual.Fname)
+			},
+		},
+		"unknown user": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("FindById", int64(7)).Return(nil, repository.ErrNoUserFound).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserBio, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.FindById(ctx, &models.UserId{Id: 7})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestFindByEmail(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.UserBio, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("FindByEmail", "dabi@mail.com").Return(&models.User{Email: "dabi@mail.com"}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserBio, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "dabi@mail.com", actual.Email)
+			},
+		},
+		"unknown user": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("FindByEmail", "dabi@mail.com").Return(nil, repository.ErrNoUserFound).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserBio, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.FindByEmail(ctx, &models.Email{Email: "dabi@mail.com"})
+
+			v.assert(t, result, err)
+		})
 	}
-	users := &models.Users{User: bio}
+}
+
+func TestBatchGetUsers(t *testing.T) {
 	testTable := map[string]struct {
 		arrange func(t *testing.T)
 		assert  func(t *testing.T, actual *models.Users, err error)
 	}{
 		"succes call": {
 			arrange: func(t *testing.T) {
-				mockInteractor.On("FindUsers").Return(users, nil).Once()
+				mockInteractor.On("BatchGetUsers", []int64{1, 2}).Return(&models.Users{User: []*models.UserBio{{Id: 1}, {Id: 2}}}, nil).Once()
 			},
 			assert: func(t *testing.T, actual *models.Users, err error) {
 				require.NoError(t, err)
-				require.Equal(t, users.User, actual.User)
-				require.Equal(t, len(users.User), len(actual.User))
+				require.Len(t, actual.User, 2)
 			},
 		},
-		"fail call": {
+		"too many ids": {
 			arrange: func(t *testing.T) {
-				mockInteractor.On("FindUsers", mock.Anything).Return(nil, errors.New("got an error")).Once()
+				mockInteractor.On("BatchGetUsers", []int64{1, 2}).Return(nil, interactor.ErrTooManyIds).Once()
 			},
 			assert: func(t *testing.T, actual *models.Users, err error) {
-				require.Error(t, err)
-				require.Zero(t, actual)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
 			},
 		},
 	}
-
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 	for k, v := range testTable {
 		t.Run(k, func(t *testing.T) {
 			v.arrange(t)
 
-			result, err := client.FindUsers(ctx, &emptypb.Empty{})
+			result, err := client.BatchGetUsers(ctx, &models.UserIds{Ids: []int64{1, 2}})
 
 			v.assert(t, result, err)
 		})
@@ -243,6 +567,14 @@ func TestDeleteByUsername(t *testing.T) {
 				require.Error(t, err)
 			},
 		},
+		"nothing deleted": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("DeleteByUsername", mock.Anything).Return(repository.ErrNoUserFound).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -293,3 +625,413 @@ func TestUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestDeactivateUser(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("DeactivateUser", "dabi").Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"unknown user": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("DeactivateUser", "dabi").Return(repository.ErrNoUserFound).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("DeactivateUser", "dabi").Return(errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			_, err := client.DeactivateUser(ctx, &models.Username{Username: "dabi"})
+
+			v.assert(t, err)
+		})
+	}
+}
+
+func TestReactivateUser(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ReactivateUser", "toga", "dabi", "asked by email").Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"unknown user": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ReactivateUser", "toga", "dabi", "asked by email").Return(repository.ErrNoUserFound).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		"not staff": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ReactivateUser", "toga", "dabi", "asked by email").Return(interactor.ErrForbidden).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			_, err := client.ReactivateUser(ctx, &models.AdminAction{Actor: "toga", Username: "dabi", Reason: "asked by email"})
+
+			v.assert(t, err)
+		})
+	}
+}
+
+func TestExportUserData(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.UserData, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ExportUserData", "dabi").Return(&models.UserData{Bio: &models.UserBio{Username: "dabi"}}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserData, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "dabi", actual.Bio.Username)
+			},
+		},
+		"unknown user": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("ExportUserData", "dabi").Return(nil, repository.ErrNoUserFound).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserData, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+				require.Nil(t, actual)
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.ExportUserData(ctx, &models.Username{Username: "dabi"})
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestEraseUser(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("EraseUser", "dabi").Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"unknown user": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("EraseUser", "dabi").Return(repository.ErrNoUserFound).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("EraseUser", "dabi").Return(errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			_, err := client.EraseUser(ctx, &models.Username{Username: "dabi"})
+
+			v.assert(t, err)
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	credentials := &models.Credentials{Username: "dabi", Password: "secret", Ip: "10.0.0.1"}
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.LoginResult, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("Login", "dabi", "secret", "10.0.0.1").Return(&models.User{Username: "dabi", Password: "hash"}, &models.AuthTokens{SessionId: 7, AccessToken: "at_token"}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.LoginResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "dabi", actual.User.Username)
+				require.Equal(t, "at_token", actual.Tokens.AccessToken)
+			},
+		},
+		"wrong password": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("Login", "dabi", "secret", "10.0.0.1").Return(nil, nil, interactor.ErrInvalidCredentials).Once()
+			},
+			assert: func(t *testing.T, actual *models.LoginResult, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		"second factor needed": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("Login", "dabi", "secret", "10.0.0.1").Return(nil, nil, interactor.ErrOtpRequired).Once()
+			},
+			assert: func(t *testing.T, actual *models.LoginResult, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		"locked": {
+			arrange: func(t *testing.T) {
+				mockInteractor.On("Login", "dabi", "secret", "10.0.0.1").Return(nil, nil, fmt.Errorf("%w, try again later", interactor.ErrLoginLocked)).Once()
+			},
+			assert: func(t *testing.T, actual *models.LoginResult, err error) {
+				require.Equal(t, codes.ResourceExhausted, status.Code(err))
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := client.Login(ctx, credentials)
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestUnlockUser(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	action := &models.AdminAction{Actor: "helper", Username: "dabi", Reason: "called support"}
+	mockInteractor.On("UnlockUser", "helper", "dabi", "called support").Return(nil).Once()
+	_, err := client.UnlockUser(ctx, action)
+	require.NoError(t, err)
+
+	mockInteractor.On("UnlockUser", "helper", "dabi", "called support").Return(interactor.ErrForbidden).Once()
+	_, err = client.UnlockUser(ctx, action)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	mockInteractor.On("UnlockUser", "helper", "dabi", "called support").Return(errors.New("got an error")).Once()
+	_, err = client.UnlockUser(ctx, action)
+	require.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestTotp(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	mockInteractor.On("EnrollTotp", "dabi").Return(&models.TotpEnrollment{Secret: "SECRET", Uri: "otpauth://totp/RPApp:dabi"}, nil).Once()
+	enrollment, err := client.EnrollTotp(ctx, &models.Username{Username: "dabi"})
+	require.NoError(t, err)
+	require.Equal(t, "SECRET", enrollment.Secret)
+
+	mockInteractor.On("EnrollTotp", "dabi").Return(nil, interactor.ErrTotpEnabled).Once()
+	_, err = client.EnrollTotp(ctx, &models.Username{Username: "dabi"})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	mockInteractor.On("ConfirmTotp", "dabi", "123456").Return(&models.RecoveryCodes{Codes: []string{"abcde-fghij"}}, nil).Once()
+	recoveryCodes, err := client.ConfirmTotp(ctx, &models.TotpCode{Username: "dabi", Code: "123456"})
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes.Codes, 1)
+
+	mockInteractor.On("ConfirmTotp", "dabi", "123456").Return(nil, interactor.ErrTotpNotEnrolled).Once()
+	_, err = client.ConfirmTotp(ctx, &models.TotpCode{Username: "dabi", Code: "123456"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	mockInteractor.On("DisableTotp", "dabi", "000000").Return(interactor.ErrInvalidOtp).Once()
+	_, err = client.DisableTotp(ctx, &models.TotpCode{Username: "dabi", Code: "000000"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSessions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	mockInteractor.On("RefreshSession", "rt_old").Return(&models.AuthTokens{SessionId: 7, RefreshToken: "rt_new"}, nil).Once()
+	tokens, err := client.RefreshSession(ctx, &models.RefreshToken{Token: "rt_old"})
+	require.NoError(t, err)
+	require.Equal(t, "rt_new", tokens.RefreshToken)
+
+	mockInteractor.On("RefreshSession", "rt_old").Return(nil, interactor.ErrRefreshTokenReused).Once()
+	_, err = client.RefreshSession(ctx, &models.RefreshToken{Token: "rt_old"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	mockInteractor.On("Authenticate", "at_token").Return(usecases.ActiveSession{
+		ID:           7,
+		User:         &models.User{Username: "dabi", Password: "hash", PasswordResetRequired: true},
+		Impersonator: "root",
+	}, nil).Once()
+	sessionUser, err := client.Authenticate(ctx, &models.AccessToken{Token: "at_token"})
+	require.NoError(t, err)
+	require.Equal(t, "dabi", sessionUser.User.Username)
+	require.Equal(t, int64(7), sessionUser.SessionId)
+	require.Equal(t, "root", sessionUser.Impersonator)
+	require.True(t, sessionUser.PasswordResetRequired)
+
+	mockInteractor.On("Authenticate", "at_token").Return(usecases.ActiveSession{}, repository.ErrSessionNotFound).Once()
+	_, err = client.Authenticate(ctx, &models.AccessToken{Token: "at_token"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err), "revoked sessions are rejected")
+
+	mockInteractor.On("AuthenticateAccount", "dabi@mail.com").Return(&models.User{Username: "dabi", Password: "hash", PasswordResetRequired: true}, nil).Once()
+	sessionUser, err = client.AuthenticateAccount(ctx, &models.Email{Email: "dabi@mail.com"})
+	require.NoError(t, err)
+	require.Equal(t, "dabi", sessionUser.User.Username)
+	require.True(t, sessionUser.PasswordResetRequired)
+
+	mockInteractor.On("AuthenticateAccount", "dabi@mail.com").Return(nil, interactor.ErrUserSuspended).Once()
+	_, err = client.AuthenticateAccount(ctx, &models.Email{Email: "dabi@mail.com"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err), "suspended accounts are rejected")
+
+	mockInteractor.On("ListSessions", "dabi").Return(&models.Sessions{Sessions: []*models.Session{{Id: 7, Device: "phone"}}}, nil).Once()
+	sessions, err := client.ListSessions(ctx, &models.Username{Username: "dabi"})
+	require.NoError(t, err)
+	require.Len(t, sessions.Sessions, 1)
+
+	mockInteractor.On("RevokeSession", "dabi", int64(8)).Return(repository.ErrSessionNotFound).Once()
+	_, err = client.RevokeSession(ctx, &models.SessionRef{Username: "dabi", SessionId: 8})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	mockInteractor.On("RevokeAllSessions", "dabi").Return(nil).Once()
+	_, err = client.RevokeAllSessions(ctx, &models.Username{Username: "dabi"})
+	require.NoError(t, err)
+}
+
+func TestProfile(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	mockInteractor.On("GetProfile", "dabi").Return(&models.Profile{DisplayName: "Dabi", Locale: "en-US"}, nil).Once()
+	profile, err := client.GetProfile(ctx, &models.Username{Username: "dabi"})
+	require.NoError(t, err)
+	require.Equal(t, "Dabi", profile.DisplayName)
+
+	mockInteractor.On("GetProfile", "nobody").Return(nil, repository.ErrNoUserFound).Once()
+	_, err = client.GetProfile(ctx, &models.Username{Username: "nobody"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	mockInteractor.On("UpdateProfile", "dabi", mock.Anything, []string{"phone"}).Return(&models.Profile{Phone: "+14155550123"}, nil).Once()
+	profile, err = client.UpdateProfile(ctx, &models.ProfileUpdate{
+		Username:   "dabi",
+		Profile:    &models.Profile{Phone: "+1 415 555 0123"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"phone"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "+14155550123", profile.Phone)
+
+	mockInteractor.On("UpdateProfile", "dabi", mock.Anything, []string{"timezone"}).Return(nil, fmt.Errorf("%w: bad zone", interactor.ErrInvalidProfile)).Once()
+	_, err = client.UpdateProfile(ctx, &models.ProfileUpdate{
+		Username:   "dabi",
+		Profile:    &models.Profile{Timezone: "Mars/Olympus"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"timezone"}},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAdmin(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	mockInteractor.On("SearchUsers", "root", usecases.UserSearch{Query: "dab", Status: "suspended", Limit: 10, AfterID: 4}).
+		Return(&models.UserAccounts{Accounts: []*models.UserAccount{{Bio: &models.UserBio{Username: "dabi"}, Role: "user"}}}, nil).Once()
+	accounts, err := client.SearchUsers(ctx, &models.UserSearch{Actor: "root", Query: "dab", Status: "suspended", Limit: 10, AfterId: 4})
+	require.NoError(t, err)
+	require.Len(t, accounts.Accounts, 1)
+
+	mockInteractor.On("SearchUsers", "dabi", mock.Anything).Return(nil, interactor.ErrForbidden).Once()
+	_, err = client.SearchUsers(ctx, &models.UserSearch{Actor: "dabi"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	mockInteractor.On("SuspendUser", "root", "dabi", "").Return(interactor.ErrReasonRequired).Once()
+	_, err = client.SuspendUser(ctx, &models.AdminAction{Actor: "root", Username: "dabi"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	mockInteractor.On("UnsuspendUser", "root", "nobody", "").Return(repository.ErrNoUserFound).Once()
+	_, err = client.UnsuspendUser(ctx, &models.AdminAction{Actor: "root", Username: "nobody"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	mockInteractor.On("ForcePasswordReset", "root", "dabi", "leaked").Return(nil).Once()
+	_, err = client.ForcePasswordReset(ctx, &models.AdminAction{Actor: "root", Username: "dabi", Reason: "leaked"})
+	require.NoError(t, err)
+
+	mockInteractor.On("Impersonate", "root", "dabi", "ticket 12").Return(&models.AuthTokens{SessionId: 3, AccessToken: "at_x"}, nil).Once()
+	tokens, err := client.Impersonate(ctx, &models.AdminAction{Actor: "root", Username: "dabi", Reason: "ticket 12"})
+	require.NoError(t, err)
+	require.Equal(t, "at_x", tokens.AccessToken)
+
+	mockInteractor.On("SetRole", "root", "dabi", "owner", "").Return(interactor.ErrInvalidRole).Once()
+	_, err = client.SetRole(ctx, &models.RoleChange{Actor: "root", Username: "dabi", Role: "owner"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSearchAuditLog(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	from := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	mockInteractor.On("SearchAuditLog", "root", usecases.AuditQuery{Actor: "helper@mail.com", TargetType: "user", From: from, Limit: 20}).
+		Return(&models.AuditEntries{Entries: []*models.AuditEntry{{Id: 7, Action: "user.suspend"}}}, nil).Once()
+	entries, err := client.SearchAuditLog(ctx, &models.AuditQuery{
+		Actor:       "root",
+		PerformedBy: "helper@mail.com",
+		TargetType:  "user",
+		From:        timestamppb.New(from),
+		Limit:       20,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 1)
+
+	mockInteractor.On("SearchAuditLog", "helper", mock.Anything).Return(nil, interactor.ErrForbidden).Once()
+	_, err = client.SearchAuditLog(ctx, &models.AuditQuery{Actor: "helper"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/spriigan/RPApp/usecases/repository"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SearchUsers returns at most search.Limit users after search.AfterID, ordered
+// by id. Deleted users are never returned.
+func (repo *userRepository) SearchUsers(ctx context.Context, search repository.UserSearch) ([]*models.UserAccount, error) {
+	conditions := []string{"deleted_at is null", "id > $1"}
+	args := []interface{}{search.AfterID}
+	if search.Query != "" {
+		args = append(args, escapeLike(strings.ToLower(search.Query)))
+		n := len(args)
+		conditions = append(conditions, fmt.Sprintf(`(lower(username) like $%[1]d::text || '%%' or lower(email) like $%[1]d::text || '%%'
+			or lower(first_name) like '%%' || $%[1]d::text || '%%' or lower(last_name) like '%%' || $%[1]d::text || '%%')`, n))
+	}
+	if search.Role != "" {
+		args = append(args, search.Role)
+		conditions = append(conditions, fmt.Sprintf("role=$%d", len(args)))
+	}
+	switch search.Status {
+	case repository.StatusActive:
+		conditions = append(conditions, "suspended_at is null and deactivated_at is null")
+	case repository.StatusSuspended:
+		conditions = append(conditions, "suspended_at is not null")
+	case repository.StatusDeactivated:
+		conditions = append(conditions, "deactivated_at is not null")
+	}
+	args = append(args, search.Limit)
+	statement := `select id, coalesce(first_name, ''), coalesce(last_name, ''), username, coalesce(email, ''),
+		role, created_at, suspended_at, coalesce(suspension_reason, ''), deactivated_at, password_reset_required
+		from users where ` + strings.Join(conditions, " and ") + fmt.Sprintf(" order by id limit $%d", len(args))
+
+	rows, err := repo.conn(ctx).QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	accounts := make([]*models.UserAccount, 0, search.Limit)
+	for rows.Next() {
+		account := models.UserAccount{Bio: &models.UserBio{}}
+		var createdAt, suspendedAt, deactivatedAt sql.NullTime
+		err = rows.Scan(
+			&account.Bio.Id,
+			&account.Bio.Fname,
+			&account.Bio.Lname,
+			&account.Bio.Username,
+			&account.Bio.Email,
+			&account.Role,
+			&createdAt,
+			&suspendedAt,
+			&account.SuspensionReason,
+			&deactivatedAt,
+			&account.PasswordResetRequired,
+		)
+		if err != nil {
+			return nil, err
+		}
+		account.CreatedAt = timestamp(createdAt)
+		account.SuspendedAt = timestamp(suspendedAt)
+		account.DeactivatedAt = timestamp(deactivatedAt)
+		accounts = append(accounts, &account)
+	}
+	return accounts, rows.Err()
+}
+
+// Suspend keeps the user from logging in and ends their sessions.
+func (repo *userRepository) Suspend(ctx context.Context, action repository.AdminAction) error {
+	return repo.adminUpdate(ctx, action, true,
+		"update users set suspended_at=coalesce(suspended_at, now()), suspension_reason=$2 where id=$1 and deleted_at is null",
+		action.TargetID, action.Reason)
+}
+
+func (repo *userRepository) Unsuspend(ctx context.Context, action repository.AdminAction) error {
+	return repo.adminUpdate(ctx, action, false,
+		"update users set suspended_at=null, suspension_reason=null where id=$1 and deleted_at is null",
+		action.TargetID)
+}
+
+// Reactivate brings back an account its user deactivated.
+func (repo *userRepository) Reactivate(ctx context.Context, action repository.AdminAction) error {
+	return repo.adminUpdate(ctx, action, false,
+		"update users set deactivated_at=null where id=$1 and deleted_at is null",
+		action.TargetID)
+}
+
+// RequirePasswordReset ends the user's sessions, the next login may only
+// change the password.
+func (repo *userRepository) RequirePasswordReset(ctx context.Context, action repository.AdminAction) error {
+	return repo.adminUpdate(ctx, action, true,
+		"update users set password_reset_required=true where id=$1 and deleted_at is null",
+		action.TargetID)
+}
+
+func (repo *userRepository) SetRole(ctx context.Context, role string, action repository.AdminAction) error {
+	return repo.adminUpdate(ctx, action, false,
+		"update users set role=$2 where id=$1 and deleted_at is null",
+		action.TargetID, role)
+}
+
+func (repo *userRepository) RecordAdminAction(ctx context.Context, action repository.AdminAction) error {
+	_, err := repo.conn(ctx).ExecContext(ctx, insertAdminAction, action.ActorID, action.Action, action.TargetID, nullable(action.Reason))
+	return err
+}
+
+const insertAdminAction = "insert into admin_actions (actor_id, action, target_id, reason) values ($1, $2, $3, $4)"
+
+// adminUpdate runs a statement that changes one user, records the action
+// along with it and, when revoke is set, ends the user's sessions.
+func (repo *userRepository) adminUpdate(ctx context.Context, action repository.AdminAction, revoke bool, statement string, args ...interface{}) error {
+	tx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, statement, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNoUserFound
+	}
+	if revoke {
+		_, err = tx.ExecContext(ctx, "update sessions set revoked_at=now() where user_id=$1 and revoked_at is null", action.TargetID)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, insertAdminAction, action.ActorID, action.Action, action.TargetID, nullable(action.Reason))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// escapeLike makes the wildcards of a like pattern match themselves.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func timestamp(t sql.NullTime) *timestamppb.Timestamp {
+	if !t.Valid {
+		return nil
+	}
+	return timestamppb.New(t.Time)
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spriigan/RPApp/usecases/audit"
+	"github.com/spriigan/RPApp/usecases/repository"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AuditService names the entries this service writes to the shared log.
+const AuditService = "user"
+
+// auditLog appends to the audit_log table within the transaction of ctx, a
+// trigger keeps the rows from being changed or deleted.
+type auditLog struct {
+	db *sql.DB
+}
+
+func NewAuditLog(db *sql.DB) *auditLog {
+	return &auditLog{db: db}
+}
+
+func (l *auditLog) Record(ctx context.Context, entry audit.Entry) error {
+	before, err := json.Marshal(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := json.Marshal(entry.After)
+	if err != nil {
+		return err
+	}
+	statement := `insert into audit_log (service, actor, impersonator, action, target_type, target_id, before, after, request_id, ip)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err = conn(ctx, l.db).ExecContext(ctx, statement,
+		AuditService,
+		nullString(entry.Actor),
+		nullString(entry.Impersonator),
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		before,
+		after,
+		nullString(entry.RequestID),
+		nullString(entry.IP),
+	)
+	return err
+}
+
+// SearchAuditLog returns at most query.Limit entries of every service before
+// query.BeforeID, the newest first.
+func (repo *userRepository) SearchAuditLog(ctx context.Context, query repository.AuditQuery) ([]*models.AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if query.Actor != "" {
+		where("lower(actor)=lower($%d)", query.Actor)
+	}
+	if query.Action != "" {
+		where("action=$%d", query.Action)
+	}
+	if query.TargetType != "" {
+		where("target_type=$%d", query.TargetType)
+	}
+	if query.TargetID != "" {
+		where("target_id=$%d", query.TargetID)
+	}
+	if query.RequestID != "" {
+		where("request_id=$%d", query.RequestID)
+	}
+	if !query.From.IsZero() {
+		where("created_at >= $%d", query.From.UTC())
+	}
+	if !query.To.IsZero() {
+		where("created_at < $%d", query.To.UTC())
+	}
+	if query.BeforeID > 0 {
+		where("id < $%d", query.BeforeID)
+	}
+	statement := `select id, service, coalesce(actor, ''), coalesce(impersonator, ''), action, target_type, target_id,
+		before, after, coalesce(request_id, ''), coalesce(ip, ''), created_at from audit_log`
+	if len(conditions) > 0 {
+		statement += " where " + strings.Join(conditions, " and ")
+	}
+	args = append(args, query.Limit)
+	statement += fmt.Sprintf(" order by id desc limit $%d", len(args))
+
+	rows, err := repo.conn(ctx).QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := make([]*models.AuditEntry, 0, query.Limit)
+	for rows.Next() {
+		var entry models.AuditEntry
+		var before, after []byte
+		var createdAt time.Time
+		err = rows.Scan(
+			&entry.Id,
+			&entry.Service,
+			&entry.Actor,
+			&entry.Impersonator,
+			&entry.Action,
+			&entry.TargetType,
+			&entry.TargetId,
+			&before,
+			&after,
+			&entry.RequestId,
+			&entry.Ip,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.Before, entry.After = string(before), string(after)
+		entry.CreatedAt = timestamppb.New(createdAt)
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
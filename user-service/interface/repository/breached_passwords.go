@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// hashPrefixLength is how many hex characters of a hash a range is asked for
+// with.
+const hashPrefixLength = 5
+
+var ErrInvalidHashPrefix = errors.New("hash prefix must be 5 hex characters")
+
+// breachedPasswordFile reads the offline Pwned Passwords dataset, the SHA-1
+// file ordered by hash with one "HASH:COUNT" line per password. The file is
+// searched in place, it is far too large to load.
+type breachedPasswordFile struct {
+	file *os.File
+	size int64
+}
+
+// NewBreachedPasswordFile opens the dataset at path, the file stays open for
+// as long as the service runs.
+func NewBreachedPasswordFile(path string) (*breachedPasswordFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &breachedPasswordFile{file: file, size: info.Size()}, nil
+}
+
+func (f *breachedPasswordFile) Close() error {
+	return f.file.Close()
+}
+
+// Range binary searches the file for the first line of the prefix and reads
+// the lines that share it.
+func (f *breachedPasswordFile) Range(ctx context.Context, prefix string) (map[string]int, error) {
+	prefix = strings.ToUpper(prefix)
+	if len(prefix) != hashPrefixLength || strings.Trim(prefix, "0123456789ABCDEF") != "" {
+		return nil, ErrInvalidHashPrefix
+	}
+
+	lo, hi := int64(0), f.size
+	for lo < hi {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		mid := lo + (hi-lo)/2
+		line, err := f.lineFrom(mid)
+		if err != nil {
+			return nil, err
+		}
+		if line == "" || strings.ToUpper(line) >= prefix {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	reader, err := f.readerFrom(lo)
+	if err != nil {
+		return nil, err
+	}
+	suffixes := make(map[string]int)
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(strings.ToUpper(line), prefix) {
+			return suffixes, nil
+		}
+		hash, count, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed line %q in the breached password file", line)
+		}
+		seen, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil {
+			return nil, fmt.Errorf("malformed count in line %q of the breached password file", line)
+		}
+		suffixes[strings.ToUpper(hash[hashPrefixLength:])] = seen
+	}
+}
+
+// lineFrom returns the first line that starts at offset or after it, empty
+// at the end of the file.
+func (f *breachedPasswordFile) lineFrom(offset int64) (string, error) {
+	reader, err := f.readerFrom(offset)
+	if err != nil {
+		return "", err
+	}
+	return readLine(reader)
+}
+
+// readerFrom reads the file from the start of the first line at offset or
+// after it.
+func (f *breachedPasswordFile) readerFrom(offset int64) (*bufio.Reader, error) {
+	if offset == 0 {
+		return bufio.NewReader(io.NewSectionReader(f.file, 0, f.size)), nil
+	}
+	reader := bufio.NewReader(io.NewSectionReader(f.file, offset-1, f.size-offset+1))
+	if _, err := reader.ReadString('\n'); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// readLine returns the next line without its line ending, empty at the end
+// of the file.
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
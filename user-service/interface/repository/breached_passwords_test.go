@@ -0,0 +1,52 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	repos "github.com/spriigan/RPApp/interface/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreachedPasswordFile(t *testing.T) {
+	lines := []string{
+		"00000AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA:3",
+		"21BD10018A45C4D1DEF81644B54AB7F969B88D65:12",
+		"21BD12DC183F740EE76F27B78EB39C8AD972A757:7",
+		"21BD1FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:1",
+		"21BD200000000000000000000000000000000000:5",
+		"FFFFFAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA:2",
+	}
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\r\n")), 0o600))
+	breached, err := repos.NewBreachedPasswordFile(path)
+	require.NoError(t, err)
+	defer breached.Close()
+	ctx := context.Background()
+
+	suffixes, err := breached.Range(ctx, "21bd1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{
+		"0018A45C4D1DEF81644B54AB7F969B88D65": 12,
+		"2DC183F740EE76F27B78EB39C8AD972A757": 7,
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF": 1,
+	}, suffixes)
+
+	suffixes, err = breached.Range(ctx, "00000")
+	require.NoError(t, err)
+	require.Len(t, suffixes, 1, "the first line")
+	suffixes, err = breached.Range(ctx, "FFFFF")
+	require.NoError(t, err)
+	require.Len(t, suffixes, 1, "the last line")
+	suffixes, err = breached.Range(ctx, "21BD3")
+	require.NoError(t, err)
+	require.Empty(t, suffixes)
+
+	_, err = breached.Range(ctx, "21BD")
+	require.ErrorIs(t, err, repos.ErrInvalidHashPrefix)
+	_, err = breached.Range(ctx, "XYZ12")
+	require.ErrorIs(t, err, repos.ErrInvalidHashPrefix)
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// LockedUntil returns when the scope and subject may try to log in again, the
+// zero time when nothing holds them back.
+func (repo *userRepository) LockedUntil(ctx context.Context, scope, subject string) (time.Time, error) {
+	statement := "select locked_until from login_throttles where scope=$1 and subject=$2"
+	var until sql.NullTime
+	err := repo.conn(ctx).QueryRowContext(ctx, statement, scope, subject).Scan(&until)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, err
+	}
+	return until.Time, nil
+}
+
+// RecordLoginFailure counts a failed login and returns how many came in a row.
+// The count starts over when the previous failure is older than since.
+func (repo *userRepository) RecordLoginFailure(ctx context.Context, scope, subject string, at, since time.Time) (int, error) {
+	statement := `insert into login_throttles (scope, subject, failures, last_failed_at)
+		values ($1, $2, 1, $3)
+		on conflict (scope, subject) do update set
+			failures = case when login_throttles.last_failed_at < $4 then 1 else login_throttles.failures + 1 end,
+			last_failed_at = excluded.last_failed_at
+		returning failures`
+	var failures int
+	err := repo.conn(ctx).QueryRowContext(ctx, statement, scope, subject, at.UTC(), since.UTC()).Scan(&failures)
+	return failures, err
+}
+
+func (repo *userRepository) LockLogin(ctx context.Context, scope, subject string, until time.Time) error {
+	statement := "update login_throttles set locked_until=$3 where scope=$1 and subject=$2"
+	_, err := repo.conn(ctx).ExecContext(ctx, statement, scope, subject, until.UTC())
+	return err
+}
+
+func (repo *userRepository) ClearLoginFailures(ctx context.Context, scope, subject string) error {
+	statement := "delete from login_throttles where scope=$1 and subject=$2"
+	_, err := repo.conn(ctx).ExecContext(ctx, statement, scope, subject)
+	return err
+}
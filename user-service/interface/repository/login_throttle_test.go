@@ -0,0 +1,43 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	now := time.Now()
+
+	until, err := userRepo.LockedUntil(ctx, "account", "guessed")
+	require.NoError(t, err)
+	require.True(t, until.IsZero())
+
+	for i := 1; i <= 3; i++ {
+		failures, err := userRepo.RecordLoginFailure(ctx, "account", "guessed", now, now.Add(-time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, i, failures)
+	}
+	failures, err := userRepo.RecordLoginFailure(ctx, "account", "guessed", now.Add(2*time.Hour), now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, failures, "the old failures are forgotten")
+	failures, err = userRepo.RecordLoginFailure(ctx, "address", "guessed", now, now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, failures, "scopes are counted apart")
+
+	err = userRepo.LockLogin(ctx, "account", "guessed", now.Add(time.Minute))
+	require.NoError(t, err)
+	until, err = userRepo.LockedUntil(ctx, "account", "guessed")
+	require.NoError(t, err)
+	require.WithinDuration(t, now.Add(time.Minute), until, time.Millisecond)
+
+	err = userRepo.ClearLoginFailures(ctx, "account", "guessed")
+	require.NoError(t, err)
+	until, err = userRepo.LockedUntil(ctx, "account", "guessed")
+	require.NoError(t, err)
+	require.True(t, until.IsZero())
+}
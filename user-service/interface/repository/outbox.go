@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/spriigan/RPApp/usecases/messaging"
+	"github.com/spriigan/RPApp/user-proto/grpc/events"
+)
+
+// outbox keeps the events of the user service in the outbox table until the
+// relay has published them.
+type outbox struct {
+	db *sql.DB
+}
+
+func NewOutbox(db *sql.DB) *outbox {
+	return &outbox{db: db}
+}
+
+func (o *outbox) Relay(ctx context.Context, limit int, publish func(ctx context.Context, msg messaging.Message) error) (int, error) {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// the rows stay locked until the commit, a second relay skips them
+	statement := `select id, topic, partition_key, payload from outbox
+		where service=$1 and published_at is null and failed_at is null
+		order by id limit $2 for update skip locked`
+	rows, err := tx.QueryContext(ctx, statement, messaging.Source, limit)
+	if err != nil {
+		return 0, err
+	}
+	var pending []messaging.Message
+	for rows.Next() {
+		var msg messaging.Message
+		if err = rows.Scan(&msg.ID, &msg.Topic, &msg.Key, &msg.Payload); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		pending = append(pending, msg)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	published := 0
+	var publishErr error
+	for _, msg := range pending {
+		if publishErr = publish(ctx, msg); publishErr != nil {
+			// parked once it failed MaxAttempts times
+			statement = `update outbox set attempts=attempts+1, last_error=$2,
+				failed_at=case when attempts+1 >= $3 then now() end
+				where id=$1 returning failed_at is not null`
+			var parked bool
+			err = tx.QueryRowContext(ctx, statement, msg.ID, publishErr.Error(), messaging.MaxAttempts).Scan(&parked)
+			if err != nil {
+				return 0, err
+			}
+			if parked {
+				log.Printf("parked outbox event %d on %s after %d attempts: %v", msg.ID, msg.Topic, messaging.MaxAttempts, publishErr)
+			}
+			break
+		}
+		statement = "update outbox set published_at=now(), attempts=attempts+1, last_error=null where id=$1"
+		if _, err = tx.ExecContext(ctx, statement, msg.ID); err != nil {
+			return 0, err
+		}
+		published++
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return published, publishErr
+}
+
+// enqueue writes an event to the outbox in the transaction of the change it
+// describes, so it is published exactly when the change is committed.
+func enqueue(ctx context.Context, tx dbtx, envelope *events.Envelope) error {
+	msg, err := messaging.Encode(envelope)
+	if err != nil {
+		return err
+	}
+	statement := "insert into outbox (service, topic, partition_key, payload) values ($1, $2, $3, $4)"
+	_, err = tx.ExecContext(ctx, statement, messaging.Source, msg.Topic, msg.Key, msg.Payload)
+	return err
+}
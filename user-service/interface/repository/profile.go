@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GetProfile returns an empty profile for users that never saved one.
+func (repo *userRepository) GetProfile(ctx context.Context, username string) (*models.Profile, error) {
+	statement := `select coalesce(p.display_name, ''), coalesce(p.avatar_url, ''), coalesce(p.phone, ''),
+		coalesce(p.locale, ''), coalesce(p.timezone, ''), coalesce(p.marketing_email, false),
+		coalesce(p.marketing_sms, false), p.updated_at
+		from users u left join user_profiles p on p.user_id = u.id
+		where lower(u.username)=lower($1) and u.deleted_at is null`
+	profile, err := scanProfile(repo.conn(ctx).QueryRowContext(ctx, statement, username))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoUserFound
+	}
+	return profile, err
+}
+
+// SaveProfile writes every field of the profile, creating it on the first
+// save.
+func (repo *userRepository) SaveProfile(ctx context.Context, username string, profile *models.Profile) (*models.Profile, error) {
+	statement := `insert into user_profiles (user_id, display_name, avatar_url, phone, locale, timezone,
+		marketing_email, marketing_sms, updated_at)
+		select id, $2, $3, $4, $5, $6, $7, $8, now() from users where lower(username)=lower($1) and deleted_at is null
+		on conflict (user_id) do update set
+			display_name=excluded.display_name,
+			avatar_url=excluded.avatar_url,
+			phone=excluded.phone,
+			locale=excluded.locale,
+			timezone=excluded.timezone,
+			marketing_email=excluded.marketing_email,
+			marketing_sms=excluded.marketing_sms,
+			updated_at=excluded.updated_at
+		returning coalesce(display_name, ''), coalesce(avatar_url, ''), coalesce(phone, ''),
+			coalesce(locale, ''), coalesce(timezone, ''), marketing_email, marketing_sms, updated_at`
+	saved, err := scanProfile(repo.conn(ctx).QueryRowContext(ctx, statement,
+		username,
+		nullable(profile.DisplayName),
+		nullable(profile.AvatarUrl),
+		nullable(profile.Phone),
+		nullable(profile.Locale),
+		nullable(profile.Timezone),
+		profile.GetMarketing().GetEmail(),
+		profile.GetMarketing().GetSms(),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoUserFound
+	}
+	return saved, err
+}
+
+func scanProfile(row *sql.Row) (*models.Profile, error) {
+	profile := models.Profile{Marketing: &models.MarketingPreferences{}}
+	var updatedAt sql.NullTime
+	err := row.Scan(
+		&profile.DisplayName,
+		&profile.AvatarUrl,
+		&profile.Phone,
+		&profile.Locale,
+		&profile.Timezone,
+		&profile.Marketing.Email,
+		&profile.Marketing.Sms,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if updatedAt.Valid {
+		profile.UpdatedAt = timestamppb.New(updatedAt.Time)
+	}
+	return &profile, nil
+}
+
+// nullable stores empty strings as null.
+func nullable(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func deleteProfile(ctx context.Context, tx dbtx, id int64) error {
+	_, err := tx.ExecContext(ctx, "delete from user_profiles where user_id=$1", id)
+	return err
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/spriigan/RPApp/usecases/repository"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var ErrSessionNotFound = errors.New("session is not found or no longer valid")
+
+// CreateSession stores a new session with its first refresh token.
+func (repo *userRepository) CreateSession(ctx context.Context, session repository.NewSession) (int64, error) {
+	tx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	statement := `insert into sessions (user_id, device, ip, user_agent, access_hash, access_expires_at, expires_at, impersonator_id)
+		values ($1, $2, $3, $4, $5, $6, $7, $8) returning id`
+	var id int64
+	err = tx.QueryRowContext(ctx, statement,
+		session.UserID,
+		session.Device,
+		session.Ip,
+		session.UserAgent,
+		session.Tokens.AccessHash,
+		session.Tokens.AccessExpiresAt.UTC(),
+		session.Tokens.RefreshExpiresAt.UTC(),
+		sql.NullInt64{Int64: session.ImpersonatorID, Valid: session.ImpersonatorID != 0},
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	if err = insertRefreshToken(ctx, tx, id, session.Tokens); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// AuthenticateSession finds the active user and session the access token
+// belongs to and marks the session as seen at the given time. Sessions of
+// suspended users are turned away.
+func (repo *userRepository) AuthenticateSession(ctx context.Context, accessHash string, at time.Time) (repository.ActiveSession, error) {
+	statement := `with seen as (
+			update sessions set last_seen_at=$2
+			where access_hash=$1 and revoked_at is null and access_expires_at > $2
+			returning id, user_id, impersonator_id
+		)
+		select seen.id, coalesce(i.username, ''), u.id, u.first_name, u.last_name, u.username, u.email,
+			u.role, u.password_reset_required
+		from seen join users u on u.id = seen.user_id
+		left join users i on i.id = seen.impersonator_id
+		where u.deleted_at is null and u.deactivated_at is null and u.suspended_at is null`
+	session := repository.ActiveSession{User: &models.User{}}
+	err := repo.conn(ctx).QueryRowContext(ctx, statement, accessHash, at.UTC()).Scan(
+		&session.ID,
+		&session.Impersonator,
+		&session.User.Id,
+		&session.User.Fname,
+		&session.User.Lname,
+		&session.User.Username,
+		&session.User.Email,
+		&session.User.Role,
+		&session.User.PasswordResetRequired,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ActiveSession{}, ErrSessionNotFound
+		}
+		return repository.ActiveSession{}, err
+	}
+	return session, nil
+}
+
+// FindRefreshToken returns the refresh token with the given hash, used or
+// not, as long as its user is still active.
+func (repo *userRepository) FindRefreshToken(ctx context.Context, hash string) (repository.RefreshToken, bool, error) {
+	statement := `select s.id, u.id, u.username, t.expires_at, t.used_at is not null, s.revoked_at is not null,
+		s.impersonator_id is not null
+		from refresh_tokens t
+		join sessions s on s.id = t.session_id
+		join users u on u.id = s.user_id
+		where t.token_hash=$1 and u.deleted_at is null and u.deactivated_at is null and u.suspended_at is null`
+	var token repository.RefreshToken
+	err := repo.conn(ctx).QueryRowContext(ctx, statement, hash).Scan(
+		&token.SessionID,
+		&token.UserID,
+		&token.Username,
+		&token.ExpiresAt,
+		&token.Used,
+		&token.Revoked,
+		&token.Impersonated,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.RefreshToken{}, false, nil
+	}
+	return token, err == nil, err
+}
+
+// RotateRefreshToken marks the used refresh token and gives the session the
+// new pair. ok is false when the token had been used already, then nothing
+// changes.
+func (repo *userRepository) RotateRefreshToken(ctx context.Context, sessionID int64, usedHash string, tokens repository.TokenPair) (bool, error) {
+	tx, err := repo.begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	statement := "update refresh_tokens set used_at=now() where token_hash=$1 and session_id=$2 and used_at is null"
+	result, err := tx.ExecContext(ctx, statement, usedHash, sessionID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+	statement = `update sessions set access_hash=$2, access_expires_at=$3, expires_at=$4, last_seen_at=now()
+		where id=$1 and revoked_at is null`
+	result, err = tx.ExecContext(ctx, statement,
+		sessionID,
+		tokens.AccessHash,
+		tokens.AccessExpiresAt.UTC(),
+		tokens.RefreshExpiresAt.UTC(),
+	)
+	if err != nil {
+		return false, err
+	}
+	if affected, err = result.RowsAffected(); err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, ErrSessionNotFound
+	}
+	if err = insertRefreshToken(ctx, tx, sessionID, tokens); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func insertRefreshToken(ctx context.Context, tx dbtx, sessionID int64, tokens repository.TokenPair) error {
+	statement := "insert into refresh_tokens (token_hash, session_id, expires_at) values ($1, $2, $3)"
+	_, err := tx.ExecContext(ctx, statement, tokens.RefreshHash, sessionID, tokens.RefreshExpiresAt.UTC())
+	return err
+}
+
+// ListSessions returns the user's sessions that are neither revoked nor
+// expired at the given time, the most recently seen first.
+func (repo *userRepository) ListSessions(ctx context.Context, userID int64, at time.Time) ([]*models.Session, error) {
+	statement := `select id, device, ip, user_agent, created_at, last_seen_at from sessions
+		where user_id=$1 and revoked_at is null and expires_at > $2
+		order by last_seen_at desc, id desc`
+	rows, err := repo.conn(ctx).QueryContext(ctx, statement, userID, at.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		var session models.Session
+		var device, ip, userAgent sql.NullString
+		var createdAt, lastSeenAt time.Time
+		if err = rows.Scan(&session.Id, &device, &ip, &userAgent, &createdAt, &lastSeenAt); err != nil {
+			return nil, err
+		}
+		session.Device, session.Ip, session.UserAgent = device.String, ip.String, userAgent.String
+		session.CreatedAt = timestamppb.New(createdAt)
+		session.LastSeenAt = timestamppb.New(lastSeenAt)
+		sessions = append(sessions, &session)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession ends one of the user's sessions, ErrSessionNotFound is
+// returned when the user has no such active session.
+func (repo *userRepository) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	statement := "update sessions set revoked_at=now() where id=$1 and user_id=$2 and revoked_at is null"
+	result, err := repo.conn(ctx).ExecContext(ctx, statement, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (repo *userRepository) RevokeAllSessions(ctx context.Context, userID int64) error {
+	statement := "update sessions set revoked_at=now() where user_id=$1 and revoked_at is null"
+	_, err := repo.conn(ctx).ExecContext(ctx, statement, userID)
+	return err
+}
+
+func deleteSessions(ctx context.Context, tx dbtx, userID int64) error {
+	statement := "delete from refresh_tokens where session_id in (select id from sessions where user_id=$1)"
+	if _, err := tx.ExecContext(ctx, statement, userID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "delete from sessions where user_id=$1", userID)
+	return err
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// FindTotp returns the user's TOTP secret and whether it was confirmed. The
+// secret is empty when the user never enrolled.
+func (repo *userRepository) FindTotp(ctx context.Context, id int64) (secret string, enabled bool, err error) {
+	statement := "select totp_secret, totp_enabled_at is not null from users where id=$1 and deleted_at is null"
+	var stored sql.NullString
+	err = repo.conn(ctx).QueryRowContext(ctx, statement, id).Scan(&stored, &enabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, ErrNoUserFound
+	}
+	return stored.String, enabled, err
+}
+
+// SetTotpSecret stores a secret waiting to be confirmed, it replaces an
+// earlier one that was never confirmed.
+func (repo *userRepository) SetTotpSecret(ctx context.Context, id int64, secret string) error {
+	statement := "update users set totp_secret=$2, totp_enabled_at=null where id=$1 and deleted_at is null"
+	return repo.execOne(ctx, statement, id, secret)
+}
+
+// EnableTotp confirms the secret and replaces the recovery codes with the
+// given hashes.
+func (repo *userRepository) EnableTotp(ctx context.Context, id int64, recoveryHashes []string) error {
+	tx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "update users set totp_enabled_at=now() where id=$1 and totp_secret is not null", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNoUserFound
+	}
+	if _, err = tx.ExecContext(ctx, "delete from recovery_codes where user_id=$1", id); err != nil {
+		return err
+	}
+	for _, hash := range recoveryHashes {
+		_, err = tx.ExecContext(ctx, "insert into recovery_codes (user_id, code_hash) values ($1, $2)", id, hash)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DisableTotp removes the secret and the recovery codes.
+func (repo *userRepository) DisableTotp(ctx context.Context, id int64) error {
+	tx, err := repo.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = disableTotp(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func disableTotp(ctx context.Context, tx dbtx, id int64) error {
+	_, err := tx.ExecContext(ctx, "delete from recovery_codes where user_id=$1", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "update users set totp_secret=null, totp_enabled_at=null where id=$1", id)
+	return err
+}
+
+// UseRecoveryCode marks the code with the given hash as used, ok is false
+// when the user has no such unused code.
+func (repo *userRepository) UseRecoveryCode(ctx context.Context, id int64, hash string) (bool, error) {
+	statement := "update recovery_codes set used_at=now() where user_id=$1 and code_hash=$2 and used_at is null"
+	result, err := repo.conn(ctx).ExecContext(ctx, statement, id, hash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
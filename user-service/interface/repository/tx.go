@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// dbtx runs the queries of a call, the database or the transaction of the
+// context.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txn is a transaction a call began or joined.
+type txn interface {
+	dbtx
+	Commit() error
+	Rollback() error
+}
+
+type txKey struct{}
+
+// InTx runs fn in a transaction, the calls fn makes with the context it is
+// given take part in it. It is committed when fn returns nil and rolled back
+// otherwise.
+func (repo *userRepository) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// conn returns the transaction of ctx, the database outside of one.
+func conn(ctx context.Context, db *sql.DB) dbtx {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+func (repo *userRepository) conn(ctx context.Context) dbtx {
+	return conn(ctx, repo.db)
+}
+
+// begin starts the transaction of a call that makes several changes. Inside
+// InTx it joins the transaction of ctx instead, whose commit or rollback is
+// left to InTx.
+func (repo *userRepository) begin(ctx context.Context) (txn, error) {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return joined{tx}, nil
+	}
+	return repo.db.BeginTx(ctx, nil)
+}
+
+type joined struct {
+	*sql.Tx
+}
+
+func (joined) Commit() error {
+	return nil
+}
+
+func (joined) Rollback() error {
+	return nil
+}
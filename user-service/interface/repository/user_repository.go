@@ -4,8 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/spriigan/RPApp/usecases/messaging"
+	"github.com/spriigan/RPApp/user-proto/grpc/events"
 	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type userRepository struct {
@@ -16,14 +21,27 @@ func NewUserRepository(db *sql.DB) *userRepository {
 	return &userRepository{db: db}
 }
 
-var ErrNoUserFound = errors.New("user is not registered yet")
+var (
+	ErrNoUserFound = errors.New("user is not registered yet")
+	ErrUserTaken   = errors.New("username or email is already taken")
+)
+
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
 
 func (repo *userRepository) Create(ctx context.Context, user *models.UserPayload) (int, error) {
+	tx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
 
 	statement := "insert into users (first_name, last_name, username, password, email) values ($1, $2, $3, $4, $5) returning id"
 	var id int
 
-	err := repo.db.QueryRowContext(ctx, statement,
+	err = tx.QueryRowContext(ctx, statement,
 		user.Bio.Fname,
 		user.Bio.Lname,
 		user.Bio.Username,
@@ -31,21 +49,64 @@ func (repo *userRepository) Create(ctx context.Context, user *models.UserPayload
 		user.Bio.Email,
 	).Scan(&id)
 	if err != nil {
+		return 0, taken(err)
+	}
+	envelope := messaging.NewEnvelope(ctx)
+	envelope.Event = &events.Envelope_UserRegistered{UserRegistered: &events.UserRegistered{
+		UserId:   int64(id),
+		Username: user.Bio.Username,
+		Email:    user.Bio.Email,
+	}}
+	if err = enqueue(ctx, tx, envelope); err != nil {
 		return 0, err
 	}
-	return id, nil
+	return id, tx.Commit()
+}
+
+// IsUsernameAvailable reports whether no user, deleted ones included, holds
+// the username in any casing.
+func (repo *userRepository) IsUsernameAvailable(ctx context.Context, username string) (bool, error) {
+	statement := "select not exists (select 1 from users where lower(username)=lower($1))"
+	var available bool
+	err := repo.conn(ctx).QueryRowContext(ctx, statement, username).Scan(&available)
+	return available, err
 }
 
-func (repo *userRepository) FindUsers(ctx context.Context) (*models.Users, error) {
-	statement := `select id, first_name, last_name, username, email from users order by first_name`
+// FindByUsername ignores the case of the username, as the unique
+// lower(username) index does.
+func (repo *userRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	return repo.findUser(ctx, "lower(username)=lower($1)", username)
+}
+
+// FindDeactivated returns a user who deactivated their account, the other
+// lookups leave them out.
+func (repo *userRepository) FindDeactivated(ctx context.Context, username string) (*models.User, error) {
+	return repo.queryUser(ctx, "lower(username)=lower($1) and deleted_at is null and deactivated_at is not null", username)
+}
+
+func (repo *userRepository) FindById(ctx context.Context, id int64) (*models.User, error) {
+	return repo.findUser(ctx, "id=$1", id)
+}
+
+// FindByEmail ignores the case of the email, the lookup uses the lower(email)
+// index.
+func (repo *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return repo.findUser(ctx, "lower(email)=lower($1)", email)
+}
 
-	rows, err := repo.db.QueryContext(ctx, statement)
+// FindByIds returns the users with the given ids in one query, ids that match
+// no user are left out.
+func (repo *userRepository) FindByIds(ctx context.Context, ids []int64) (*models.Users, error) {
+	statement := `select id, first_name, last_name, username, email from users
+		where id = any($1) and deleted_at is null and deactivated_at is null`
+
+	rows, err := repo.conn(ctx).QueryContext(ctx, statement, ids)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	users := models.Users{
-		User: make([]*models.UserBio, 0, 15),
+		User: make([]*models.UserBio, 0, len(ids)),
 	}
 
 	for rows.Next() {
@@ -62,21 +123,33 @@ func (repo *userRepository) FindUsers(ctx context.Context) (*models.Users, error
 		}
 		users.User = append(users.User, &bio)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 	return &users, nil
 }
 
-func (repo *userRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+func (repo *userRepository) findUser(ctx context.Context, condition string, arg interface{}) (*models.User, error) {
+	return repo.queryUser(ctx, condition+" and deleted_at is null and deactivated_at is null", arg)
+}
+
+func (repo *userRepository) queryUser(ctx context.Context, condition string, arg interface{}) (*models.User, error) {
 
-	statement := `select id, first_name, last_name, username, password, email from users where username=$1`
+	statement := `select id, first_name, last_name, username, password, email, role, suspended_at, password_reset_required
+		from users where ` + condition
 	var user models.User
+	var suspendedAt sql.NullTime
 
-	err := repo.db.QueryRowContext(ctx, statement, username).Scan(
+	err := repo.conn(ctx).QueryRowContext(ctx, statement, arg).Scan(
 		&user.Id,
 		&user.Fname,
 		&user.Lname,
 		&user.Username,
 		&user.Password,
 		&user.Email,
+		&user.Role,
+		&suspendedAt,
+		&user.PasswordResetRequired,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -84,18 +157,237 @@ func (repo *userRepository) FindByUsername(ctx context.Context, username string)
 		}
 		return nil, err
 	}
+	if suspendedAt.Valid {
+		user.SuspendedAt = timestamppb.New(suspendedAt.Time)
+	}
 	return &user, nil
 }
 
-func (repo *userRepository) DeleteByUsername(ctx context.Context, username string) error {
+// DeleteByUsername marks the user as deleted, the row is kept until
+// PurgeDeleted removes it.
+func (repo *userRepository) DeleteByUsername(ctx context.Context, username string) (int64, error) {
+	tx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	statement := "update users set deleted_at=now() where lower(username)=lower($1) and deleted_at is null returning id"
+	var id int64
+	if err = tx.QueryRowContext(ctx, statement, username).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNoUserFound
+		}
+		return 0, err
+	}
+	if err = enqueue(ctx, tx, userDeleted(ctx, id, username, false)); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func userDeleted(ctx context.Context, id int64, username string, erased bool) *events.Envelope {
+	envelope := messaging.NewEnvelope(ctx)
+	envelope.Event = &events.Envelope_UserDeleted{UserDeleted: &events.UserDeleted{
+		UserId:   id,
+		Username: username,
+		Erased:   erased,
+	}}
+	return envelope
+}
 
-	statement := "delete from users where username=$1"
+func (repo *userRepository) Deactivate(ctx context.Context, username string) (int64, error) {
+	statement := `update users set deactivated_at=coalesce(deactivated_at, now())
+		where lower(username)=lower($1) and deleted_at is null returning id`
 
-	_, err := repo.db.ExecContext(ctx, statement, username)
+	var id int64
+	err := repo.conn(ctx).QueryRowContext(ctx, statement, username).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNoUserFound
+	}
+	return id, err
+}
+
+// PurgeDeleted removes the users deleted before the given time and returns
+// how many were purged. A user that orders, stores or reviews still point to
+// can't be removed, their personal data is erased instead.
+func (repo *userRepository) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	statement := "select id from users where deleted_at < $1 and purged_at is null"
+
+	rows, err := repo.db.QueryContext(ctx, statement, before)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, id := range ids {
+		if err = repo.purge(ctx, id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func (repo *userRepository) purge(ctx context.Context, id int64) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	return nil
+	defer tx.Rollback()
+	if err = erase(ctx, tx, id); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	_, err = repo.db.ExecContext(ctx, "delete from users where id=$1", id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return nil
+	}
+	return err
+}
+
+// ExportData returns what is kept about a user that is not purged yet,
+// deactivated and deleted users included.
+func (repo *userRepository) ExportData(ctx context.Context, username string) (*models.UserData, error) {
+	statement := `select id, coalesce(first_name, ''), coalesce(last_name, ''), username, coalesce(email, ''),
+		created_at, deactivated_at is not null, deleted_at
+		from users where lower(username)=lower($1) and purged_at is null`
+
+	var bio models.UserBio
+	var data models.UserData
+	var createdAt, deletedAt sql.NullTime
+	err := repo.conn(ctx).QueryRowContext(ctx, statement, username).Scan(
+		&bio.Id,
+		&bio.Fname,
+		&bio.Lname,
+		&bio.Username,
+		&bio.Email,
+		&createdAt,
+		&data.Deactivated,
+		&deletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoUserFound
+		}
+		return nil, err
+	}
+	data.Bio = &bio
+	if createdAt.Valid {
+		data.CreatedAt = timestamppb.New(createdAt.Time)
+	}
+	if deletedAt.Valid {
+		data.DeletedAt = timestamppb.New(deletedAt.Time)
+	}
+
+	statement = `select id, coalesce(street_address, ''), coalesce(city, ''), coalesce(state, ''),
+		coalesce(country, ''), coalesce(zip_code, '')
+		from addresses where user_id=$1 order by id`
+	rows, err := repo.conn(ctx).QueryContext(ctx, statement, bio.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var address models.Address
+		err = rows.Scan(
+			&address.Id,
+			&address.StreetAddress,
+			&address.City,
+			&address.State,
+			&address.Country,
+			&address.ZipCode,
+		)
+		if err != nil {
+			return nil, err
+		}
+		data.Addresses = append(data.Addresses, &address)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statement = `select coalesce(display_name, ''), coalesce(avatar_url, ''), coalesce(phone, ''),
+		coalesce(locale, ''), coalesce(timezone, ''), marketing_email, marketing_sms, updated_at
+		from user_profiles where user_id=$1`
+	data.Profile, err = scanProfile(repo.conn(ctx).QueryRowContext(ctx, statement, bio.Id))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Erase wipes the personal data of a user and removes their addresses. The row
+// is kept, marked as deleted, so the orders pointing at it stay intact.
+func (repo *userRepository) Erase(ctx context.Context, username string) (int64, error) {
+	tx, err := repo.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	statement := "select id from users where lower(username)=lower($1) and purged_at is null for update"
+	err = tx.QueryRowContext(ctx, statement, username).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNoUserFound
+		}
+		return 0, err
+	}
+	if err = erase(ctx, tx, id); err != nil {
+		return 0, err
+	}
+	if err = enqueue(ctx, tx, userDeleted(ctx, id, username, true)); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func erase(ctx context.Context, tx dbtx, id int64) error {
+	_, err := tx.ExecContext(ctx, "delete from addresses where user_id=$1", id)
+	if err != nil {
+		return err
+	}
+	if err = disableTotp(ctx, tx, id); err != nil {
+		return err
+	}
+	if err = deleteSessions(ctx, tx, id); err != nil {
+		return err
+	}
+	if err = deleteProfile(ctx, tx, id); err != nil {
+		return err
+	}
+
+	statement := `update users set
+			first_name=null,
+			last_name=null,
+			username='deleted-' || id,
+			password=null,
+			email=null,
+			suspension_reason=null,
+			deleted_at=coalesce(deleted_at, now()),
+			purged_at=now()
+			where id=$1
+	`
+	_, err = tx.ExecContext(ctx, statement, id)
+	return err
 }
 
 func (repo *userRepository) Update(ctx context.Context, user *models.UserPayload) error {
@@ -106,11 +398,12 @@ func (repo *userRepository) Update(ctx context.Context, user *models.UserPayload
 			last_name=$2,
 			username=$3,
 			password=$4,
-			email=$5
-			where id=$6
+			email=$5,
+			password_reset_required=false
+			where id=$6 and deleted_at is null
 	`
 
-	_, err := repo.db.ExecContext(ctx, statement,
+	err := repo.execOne(ctx, statement,
 		payload.Fname,
 		payload.Lname,
 		payload.Username,
@@ -118,8 +411,38 @@ func (repo *userRepository) Update(ctx context.Context, user *models.UserPayload
 		payload.Email,
 		payload.Id,
 	)
+	return taken(err)
+}
+
+// SetPasswordHash replaces the password hash of the user, the login uses it
+// to move a password to new hashing parameters.
+func (repo *userRepository) SetPasswordHash(ctx context.Context, id int64, hash string) error {
+	statement := `update users set password=$1 where id=$2 and deleted_at is null`
+	return repo.execOne(ctx, statement, hash, id)
+}
+
+// taken turns a unique violation on the username or email into ErrUserTaken.
+func taken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrUserTaken
+	}
+	return err
+}
+
+// execOne runs a statement meant to change one user, ErrNoUserFound is
+// returned when it matched none.
+func (repo *userRepository) execOne(ctx context.Context, statement string, args ...interface{}) error {
+	result, err := repo.conn(ctx).ExecContext(ctx, statement, args...)
 	if err != nil {
 		return err
 	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNoUserFound
+	}
 	return nil
 }
@@ -3,6 +3,7 @@ package repository_test
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -15,6 +16,8 @@ import (
 	"github.com/ory/dockertest/v3"
 	"github.com/ory/dockertest/v3/docker"
 	repos "github.com/spriigan/RPApp/interface/repository"
+	"github.com/spriigan/RPApp/usecases/audit"
+	"github.com/spriigan/RPApp/usecases/messaging"
 	"github.com/spriigan/RPApp/usecases/repository"
 	"github.com/spriigan/RPApp/user-proto/grpc/models"
 	"github.com/stretchr/testify/require"
@@ -129,27 +132,6 @@ func TestCreate(t *testing.T) {
 	require.Equal(t, 1, id)
 }
 
-func TestFindUsers(t *testing.T) {
-	payload := models.UserPayload{
-		Bio: &models.UserBio{
-			Fname:    "ryan",
-			Lname:    "pujo",
-			Username: "ryanpujo1",
-			Email:    "ryanpujo1@gmail.com",
-		},
-		Password: "oke",
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-	defer cancel()
-	_, err := userRepo.Create(ctx, &payload)
-	require.NoError(t, err)
-
-	actual, err := userRepo.FindUsers(ctx)
-	require.NoError(t, err)
-	require.NotEmpty(t, actual)
-	require.Equal(t, 2, len(actual.User))
-}
-
 func TestFindByUsername(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
@@ -157,21 +139,61 @@ func TestFindByUsername(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, user)
 	require.Equal(t, user.Email, "ryanpujo1@gmail.com")
+	user, err = userRepo.FindByUsername(ctx, "RyanPujo1")
+	require.NoError(t, err, "any casing")
+	require.Equal(t, "ryanpujo1", user.Username)
 	user, err = userRepo.FindByUsername(ctx, "oke")
 	require.Error(t, err)
 	require.EqualError(t, err, repos.ErrNoUserFound.Error())
 	require.Nil(t, user)
 }
 
+func TestFindById(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	user, err := userRepo.FindById(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, "ryanpujo1", user.Username)
+	_, err = userRepo.FindById(ctx, 99)
+	require.ErrorIs(t, err, repos.ErrNoUserFound)
+}
+
+func TestFindByEmail(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	user, err := userRepo.FindByEmail(ctx, "RyanPujo1@Gmail.com")
+	require.NoError(t, err)
+	require.Equal(t, "ryanpujo1", user.Username)
+	_, err = userRepo.FindByEmail(ctx, "nobody@gmail.com")
+	require.ErrorIs(t, err, repos.ErrNoUserFound)
+}
+
+func TestFindByIds(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	users, err := userRepo.FindByIds(ctx, []int64{1, 2, 99})
+	require.NoError(t, err)
+	require.Len(t, users.User, 2)
+}
+
 func TestDeleteByUsername(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
-	err := userRepo.DeleteByUsername(ctx, "ryanpujo1")
+	id, err := userRepo.DeleteByUsername(ctx, "ryanpujo1")
 	require.NoError(t, err)
+	require.Equal(t, int64(2), id)
 	user, err := userRepo.FindByUsername(ctx, "ryanpujo1")
 	require.Error(t, err)
 	require.EqualError(t, err, repos.ErrNoUserFound.Error())
 	require.Nil(t, user)
+	users, err := userRepo.FindByIds(ctx, []int64{1, 2})
+	require.NoError(t, err)
+	require.Len(t, users.User, 1)
+
+	_, err = userRepo.DeleteByUsername(ctx, "ryanpujo1")
+	require.ErrorIs(t, err, repos.ErrNoUserFound, "already deleted")
+	_, err = userRepo.DeleteByUsername(ctx, "oke")
+	require.ErrorIs(t, err, repos.ErrNoUserFound)
 }
 
 func TestUpdate(t *testing.T) {
@@ -194,3 +216,477 @@ func TestUpdate(t *testing.T) {
 	require.NotNil(t, user)
 	require.Equal(t, payload.Bio.Lname, user.Lname)
 }
+
+func TestDeactivate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	id, err := userRepo.Deactivate(ctx, "ryanpujo")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), id)
+	_, err = userRepo.FindByUsername(ctx, "ryanpujo")
+	require.ErrorIs(t, err, repos.ErrNoUserFound)
+
+	deactivated, err := userRepo.FindDeactivated(ctx, "ryanpujo")
+	require.NoError(t, err)
+
+	err = userRepo.Reactivate(ctx, repository.AdminAction{ActorID: deactivated.Id, Action: "reactivate", TargetID: deactivated.Id})
+	require.NoError(t, err)
+	user, err := userRepo.FindByUsername(ctx, "ryanpujo")
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	_, err = userRepo.FindDeactivated(ctx, "ryanpujo")
+	require.ErrorIs(t, err, repos.ErrNoUserFound, "active again")
+
+	_, err = userRepo.Deactivate(ctx, "ryanpujo1")
+	require.ErrorIs(t, err, repos.ErrNoUserFound, "deleted users can't be deactivated")
+	_, err = userRepo.FindDeactivated(ctx, "ryanpujo1")
+	require.ErrorIs(t, err, repos.ErrNoUserFound, "nor brought back")
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	purged, err := userRepo.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, purged, "still within the retention window")
+
+	purged, err = userRepo.PurgeDeleted(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, purged)
+	var count int
+	err = testDb.QueryRowContext(ctx, "select count(*) from users").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+func TestExportData(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	_, err := testDb.ExecContext(ctx, "insert into addresses (user_id, street_address, city) values (1, 'jl. merdeka 1', 'jakarta')")
+	require.NoError(t, err)
+
+	data, err := userRepo.ExportData(ctx, "ryanpujo")
+	require.NoError(t, err)
+	require.Equal(t, "ryanpujo@gmail.com", data.Bio.Email)
+	require.NotNil(t, data.CreatedAt)
+	require.Nil(t, data.DeletedAt)
+	require.Len(t, data.Addresses, 1)
+	require.Equal(t, "jakarta", data.Addresses[0].City)
+
+	_, err = userRepo.ExportData(ctx, "oke")
+	require.ErrorIs(t, err, repos.ErrNoUserFound)
+}
+
+func TestErase(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	id, err := userRepo.Erase(ctx, "ryanpujo")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), id)
+
+	_, err = userRepo.FindByUsername(ctx, "ryanpujo")
+	require.ErrorIs(t, err, repos.ErrNoUserFound)
+	_, err = userRepo.ExportData(ctx, "ryanpujo")
+	require.ErrorIs(t, err, repos.ErrNoUserFound, "nothing left to export")
+	_, err = userRepo.Erase(ctx, "ryanpujo")
+	require.ErrorIs(t, err, repos.ErrNoUserFound)
+
+	var email sql.NullString
+	var addresses int
+	err = testDb.QueryRowContext(ctx, "select email from users where id=1").Scan(&email)
+	require.NoError(t, err, "the row is kept")
+	require.False(t, email.Valid)
+	err = testDb.QueryRowContext(ctx, "select count(*) from addresses where user_id=1").Scan(&addresses)
+	require.NoError(t, err)
+	require.Zero(t, addresses)
+}
+
+func TestUniqueness(t *testing.T) {
+	payload := models.UserPayload{
+		Bio: &models.UserBio{
+			Username: "Taken",
+			Email:    "taken@gmail.com",
+		},
+		Password: "oke",
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	_, err := userRepo.Create(ctx, &payload)
+	require.NoError(t, err)
+
+	available, err := userRepo.IsUsernameAvailable(ctx, "TAKEN")
+	require.NoError(t, err)
+	require.False(t, available)
+	available, err = userRepo.IsUsernameAvailable(ctx, "ryanpujo")
+	require.NoError(t, err)
+	require.True(t, available, "freed once erased")
+
+	payload.Bio.Username, payload.Bio.Email = "taken", "other@gmail.com"
+	_, err = userRepo.Create(ctx, &payload)
+	require.ErrorIs(t, err, repos.ErrUserTaken)
+	payload.Bio.Username, payload.Bio.Email = "other", "TAKEN@gmail.com"
+	_, err = userRepo.Create(ctx, &payload)
+	require.ErrorIs(t, err, repos.ErrUserTaken)
+}
+
+func TestTotp(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	id, err := userRepo.Create(ctx, &models.UserPayload{Bio: &models.UserBio{Username: "owner", Email: "owner@gmail.com"}})
+	require.NoError(t, err)
+	owner := int64(id)
+
+	secret, enabled, err := userRepo.FindTotp(ctx, owner)
+	require.NoError(t, err)
+	require.Empty(t, secret)
+	require.False(t, enabled)
+	err = userRepo.EnableTotp(ctx, owner, []string{"one"})
+	require.ErrorIs(t, err, repos.ErrNoUserFound, "nothing to confirm")
+
+	err = userRepo.SetTotpSecret(ctx, owner, "JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	err = userRepo.EnableTotp(ctx, owner, []string{"one", "two"})
+	require.NoError(t, err)
+	secret, enabled, err = userRepo.FindTotp(ctx, owner)
+	require.NoError(t, err)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", secret)
+	require.True(t, enabled)
+
+	used, err := userRepo.UseRecoveryCode(ctx, owner, "one")
+	require.NoError(t, err)
+	require.True(t, used)
+	used, err = userRepo.UseRecoveryCode(ctx, owner, "one")
+	require.NoError(t, err)
+	require.False(t, used, "a code works once")
+
+	err = userRepo.DisableTotp(ctx, owner)
+	require.NoError(t, err)
+	_, enabled, err = userRepo.FindTotp(ctx, owner)
+	require.NoError(t, err)
+	require.False(t, enabled)
+	used, err = userRepo.UseRecoveryCode(ctx, owner, "two")
+	require.NoError(t, err)
+	require.False(t, used, "the codes go with it")
+
+	_, _, err = userRepo.FindTotp(ctx, 999)
+	require.ErrorIs(t, err, repos.ErrNoUserFound)
+}
+
+func TestSessions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	id, err := userRepo.Create(ctx, &models.UserPayload{Bio: &models.UserBio{Username: "traveler", Email: "traveler@gmail.com"}})
+	require.NoError(t, err)
+	traveler := int64(id)
+	now := time.Now()
+	pair := func(access, refresh string) repository.TokenPair {
+		return repository.TokenPair{
+			AccessHash:       access,
+			AccessExpiresAt:  now.Add(time.Minute),
+			RefreshHash:      refresh,
+			RefreshExpiresAt: now.Add(time.Hour),
+		}
+	}
+
+	phone, err := userRepo.CreateSession(ctx, repository.NewSession{UserID: traveler, Device: "phone", Ip: "10.0.0.1", Tokens: pair("a1", "r1")})
+	require.NoError(t, err)
+	laptop, err := userRepo.CreateSession(ctx, repository.NewSession{UserID: traveler, Device: "laptop", Tokens: pair("a2", "r2")})
+	require.NoError(t, err)
+
+	session, err := userRepo.AuthenticateSession(ctx, "a1", now)
+	require.NoError(t, err)
+	require.Equal(t, phone, session.ID)
+	require.Equal(t, "traveler", session.User.Username)
+	require.Empty(t, session.Impersonator)
+	_, err = userRepo.AuthenticateSession(ctx, "a1", now.Add(2*time.Minute))
+	require.ErrorIs(t, err, repos.ErrSessionNotFound, "the access token expired")
+
+	token, found, err := userRepo.FindRefreshToken(ctx, "r1")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, phone, token.SessionID)
+	require.Equal(t, traveler, token.UserID)
+	require.False(t, token.Used)
+	rotated, err := userRepo.RotateRefreshToken(ctx, phone, "r1", pair("a3", "r3"))
+	require.NoError(t, err)
+	require.True(t, rotated)
+	rotated, err = userRepo.RotateRefreshToken(ctx, phone, "r1", pair("a4", "r4"))
+	require.NoError(t, err)
+	require.False(t, rotated, "a refresh token works once")
+	token, _, err = userRepo.FindRefreshToken(ctx, "r1")
+	require.NoError(t, err)
+	require.True(t, token.Used)
+	_, err = userRepo.AuthenticateSession(ctx, "a1", now)
+	require.ErrorIs(t, err, repos.ErrSessionNotFound, "the old access token is replaced")
+	_, err = userRepo.AuthenticateSession(ctx, "a3", now)
+	require.NoError(t, err)
+
+	sessions, err := userRepo.ListSessions(ctx, traveler, now)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	err = userRepo.RevokeSession(ctx, traveler, laptop)
+	require.NoError(t, err)
+	err = userRepo.RevokeSession(ctx, traveler, laptop)
+	require.ErrorIs(t, err, repos.ErrSessionNotFound)
+	_, err = userRepo.AuthenticateSession(ctx, "a2", now)
+	require.ErrorIs(t, err, repos.ErrSessionNotFound, "revoked sessions are rejected")
+	token, _, err = userRepo.FindRefreshToken(ctx, "r2")
+	require.NoError(t, err)
+	require.True(t, token.Revoked)
+
+	err = userRepo.RevokeAllSessions(ctx, traveler)
+	require.NoError(t, err)
+	sessions, err = userRepo.ListSessions(ctx, traveler, now)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+
+	_, err = userRepo.Erase(ctx, "traveler")
+	require.NoError(t, err, "the sessions go with the user")
+	_, found, err = userRepo.FindRefreshToken(ctx, "r3")
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestSetPasswordHash(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	id, err := userRepo.Create(ctx, &models.UserPayload{Bio: &models.UserBio{Username: "rehashed", Email: "rehashed@gmail.com"}, Password: "$2a$04$old"})
+	require.NoError(t, err)
+
+	err = userRepo.SetPasswordHash(ctx, int64(id), "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$a2V5")
+	require.NoError(t, err)
+	user, err := userRepo.FindByUsername(ctx, "rehashed")
+	require.NoError(t, err)
+	require.Equal(t, "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$a2V5", user.Password)
+
+	err = userRepo.SetPasswordHash(ctx, 9999, "$argon2id$")
+	require.ErrorIs(t, err, repos.ErrNoUserFound)
+}
+
+func TestProfiles(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	_, err := userRepo.Create(ctx, &models.UserPayload{Bio: &models.UserBio{Username: "profiled", Email: "profiled@gmail.com"}, Password: "$2a$04$hash"})
+	require.NoError(t, err)
+
+	profile, err := userRepo.GetProfile(ctx, "profiled")
+	require.NoError(t, err)
+	require.Empty(t, profile.DisplayName)
+	require.Nil(t, profile.UpdatedAt, "no profile was saved yet")
+
+	saved, err := userRepo.SaveProfile(ctx, "profiled", &models.Profile{
+		DisplayName: "Profiled",
+		Phone:       "+14155550123",
+		Timezone:    "Europe/Berlin",
+		Marketing:   &models.MarketingPreferences{Sms: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Profiled", saved.DisplayName)
+	require.True(t, saved.Marketing.Sms)
+	require.NotNil(t, saved.UpdatedAt)
+
+	saved, err = userRepo.SaveProfile(ctx, "profiled", &models.Profile{DisplayName: "Renamed"})
+	require.NoError(t, err)
+	require.Equal(t, "Renamed", saved.DisplayName)
+	require.Empty(t, saved.Phone)
+	require.False(t, saved.Marketing.Sms)
+
+	_, err = userRepo.SaveProfile(ctx, "nobody", &models.Profile{})
+	require.ErrorIs(t, err, repos.ErrNoUserFound)
+	_, err = userRepo.GetProfile(ctx, "nobody")
+	require.ErrorIs(t, err, repos.ErrNoUserFound)
+
+	_, err = userRepo.Erase(ctx, "profiled")
+	require.NoError(t, err, "the profile goes with the user")
+}
+
+func TestAdmin(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	root, err := userRepo.Create(ctx, &models.UserPayload{Bio: &models.UserBio{Username: "root", Email: "root@gmail.com"}, Password: "$2a$04$hash"})
+	require.NoError(t, err)
+	target, err := userRepo.Create(ctx, &models.UserPayload{Bio: &models.UserBio{Fname: "Sus", Lname: "Pended", Username: "suspect", Email: "suspect@gmail.com"}, Password: "$2a$04$hash"})
+	require.NoError(t, err)
+	action := func(name, reason string) repository.AdminAction {
+		return repository.AdminAction{ActorID: int64(root), Action: name, TargetID: int64(target), Reason: reason}
+	}
+	now := time.Now()
+	pair := func(access, refresh string) repository.TokenPair {
+		return repository.TokenPair{AccessHash: access, AccessExpiresAt: now.Add(time.Minute), RefreshHash: refresh, RefreshExpiresAt: now.Add(time.Minute)}
+	}
+
+	err = userRepo.SetRole(ctx, "admin", repository.AdminAction{ActorID: int64(root), Action: "set_role", TargetID: int64(root)})
+	require.NoError(t, err)
+	user, err := userRepo.FindByUsername(ctx, "root")
+	require.NoError(t, err)
+	require.Equal(t, "admin", user.Role)
+
+	session, err := userRepo.CreateSession(ctx, repository.NewSession{UserID: int64(target), Tokens: pair("suspect-a", "suspect-r")})
+	require.NoError(t, err)
+	err = userRepo.Suspend(ctx, action("suspend", "chargebacks"))
+	require.NoError(t, err)
+	user, err = userRepo.FindByUsername(ctx, "suspect")
+	require.NoError(t, err)
+	require.NotNil(t, user.SuspendedAt)
+	_, err = userRepo.AuthenticateSession(ctx, "suspect-a", now)
+	require.ErrorIs(t, err, repos.ErrSessionNotFound, "suspending ends the sessions")
+
+	accounts, err := userRepo.SearchUsers(ctx, repository.UserSearch{Query: "pend", Status: repository.StatusSuspended, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, "chargebacks", accounts[0].SuspensionReason)
+	accounts, err = userRepo.SearchUsers(ctx, repository.UserSearch{Query: "suspect", Status: repository.StatusActive, Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+	accounts, err = userRepo.SearchUsers(ctx, repository.UserSearch{Query: "%", Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, accounts, "wildcards match themselves")
+
+	err = userRepo.Unsuspend(ctx, action("unsuspend", ""))
+	require.NoError(t, err)
+	err = userRepo.RequirePasswordReset(ctx, action("force_password_reset", ""))
+	require.NoError(t, err)
+	user, err = userRepo.FindByUsername(ctx, "suspect")
+	require.NoError(t, err)
+	require.Nil(t, user.SuspendedAt)
+	require.True(t, user.PasswordResetRequired)
+
+	impersonated, err := userRepo.CreateSession(ctx, repository.NewSession{UserID: int64(target), Tokens: pair("imp-a", "imp-r"), ImpersonatorID: int64(root)})
+	require.NoError(t, err)
+	active, err := userRepo.AuthenticateSession(ctx, "imp-a", now)
+	require.NoError(t, err)
+	require.Equal(t, impersonated, active.ID)
+	require.Equal(t, "root", active.Impersonator)
+	require.True(t, active.User.PasswordResetRequired)
+	token, _, err := userRepo.FindRefreshToken(ctx, "imp-r")
+	require.NoError(t, err)
+	require.True(t, token.Impersonated)
+	require.NotEqual(t, session, impersonated)
+
+	err = userRepo.Suspend(ctx, repository.AdminAction{ActorID: int64(root), Action: "suspend", TargetID: 9999, Reason: "nobody"})
+	require.ErrorIs(t, err, repos.ErrNoUserFound)
+}
+
+func TestAuditLog(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	log := repos.NewAuditLog(testDb)
+
+	err := log.Record(ctx, audit.Entry{
+		Actor:      "root@gmail.com",
+		Action:     "user.update",
+		TargetType: "user",
+		TargetID:   "audited",
+		Before:     map[string]interface{}{"email": "old@gmail.com"},
+		After:      map[string]interface{}{"email": "new@gmail.com"},
+		RequestID:  "req-1",
+		IP:         "10.0.0.1",
+	})
+	require.NoError(t, err)
+	err = log.Record(ctx, audit.Entry{Actor: "root@gmail.com", Action: "user.delete", TargetType: "user", TargetID: "audited"})
+	require.NoError(t, err)
+
+	entries, err := userRepo.SearchAuditLog(ctx, repository.AuditQuery{TargetType: "user", TargetID: "audited", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "user.delete", entries[0].Action, "the newest entry comes first")
+	require.Equal(t, repos.AuditService, entries[1].Service)
+	require.JSONEq(t, `{"email": "new@gmail.com"}`, entries[1].After)
+	require.Equal(t, "10.0.0.1", entries[1].Ip)
+
+	entries, err = userRepo.SearchAuditLog(ctx, repository.AuditQuery{TargetID: "audited", BeforeID: entries[0].Id, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "user.update", entries[0].Action)
+
+	entries, err = userRepo.SearchAuditLog(ctx, repository.AuditQuery{Actor: "ROOT@gmail.com", RequestID: "req-1", From: time.Now().Add(-time.Minute), Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	_, err = testDb.ExecContext(ctx, "update audit_log set actor='someone else' where target_id='audited'")
+	require.Error(t, err, "entries can't be changed")
+	_, err = testDb.ExecContext(ctx, "delete from audit_log where target_id='audited'")
+	require.Error(t, err, "entries can't be removed")
+
+	// an entry written in a transaction that rolls back is gone with it
+	err = userRepo.InTx(ctx, func(ctx context.Context) error {
+		if err := log.Record(ctx, audit.Entry{Action: "user.update", TargetType: "user", TargetID: "rolled-back"}); err != nil {
+			return err
+		}
+		return errors.New("the change failed")
+	})
+	require.Error(t, err)
+	entries, err = userRepo.SearchAuditLog(ctx, repository.AuditQuery{TargetID: "rolled-back", Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestOutbox(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	outbox := repos.NewOutbox(testDb)
+	var published []messaging.Message
+	publish := func(ctx context.Context, msg messaging.Message) error {
+		published = append(published, msg)
+		return nil
+	}
+
+	// the users created, deleted and erased by the tests above
+	_, err := outbox.Relay(ctx, 100, publish)
+	require.NoError(t, err)
+	require.NotEmpty(t, published)
+	topics := map[string]bool{}
+	for _, msg := range published {
+		topics[msg.Topic] = true
+	}
+	require.True(t, topics[messaging.TopicUserRegistered])
+	require.True(t, topics[messaging.TopicUserDeleted])
+	n, err := outbox.Relay(ctx, 100, publish)
+	require.NoError(t, err)
+	require.Zero(t, n, "published events are not sent again")
+
+	// a failed delete leaves no event behind
+	_, err = userRepo.DeleteByUsername(ctx, "nobody")
+	require.ErrorIs(t, err, repos.ErrNoUserFound)
+
+	_, err = userRepo.Create(ctx, &models.UserPayload{Bio: &models.UserBio{Username: "evented", Email: "evented@gmail.com"}})
+	require.NoError(t, err)
+	n, err = outbox.Relay(ctx, 100, func(ctx context.Context, msg messaging.Message) error {
+		return errors.New("bus is down")
+	})
+	require.EqualError(t, err, "bus is down")
+	require.Zero(t, n)
+	var attempts int
+	var lastError string
+	err = testDb.QueryRowContext(ctx, "select attempts, last_error from outbox where published_at is null").Scan(&attempts, &lastError)
+	require.NoError(t, err)
+	require.Equal(t, 1, attempts)
+	require.Equal(t, "bus is down", lastError)
+
+	published = nil
+	n, err = outbox.Relay(ctx, 100, publish)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	envelope, err := messaging.Decode(published[0])
+	require.NoError(t, err)
+	require.Equal(t, "evented", envelope.GetUserRegistered().Username)
+
+	// an event that keeps failing is parked and no longer holds back the rest
+	_, err = userRepo.Create(ctx, &models.UserPayload{Bio: &models.UserBio{Username: "parked", Email: "parked@gmail.com"}})
+	require.NoError(t, err)
+	failing := func(ctx context.Context, msg messaging.Message) error {
+		return errors.New("bus is down")
+	}
+	for i := 0; i < messaging.MaxAttempts; i++ {
+		_, err = outbox.Relay(ctx, 100, failing)
+		require.EqualError(t, err, "bus is down")
+	}
+	n, err = outbox.Relay(ctx, 100, failing)
+	require.NoError(t, err)
+	require.Zero(t, n)
+	var parked bool
+	err = testDb.QueryRowContext(ctx, "select failed_at is not null from outbox where published_at is null").Scan(&parked)
+	require.NoError(t, err)
+	require.True(t, parked)
+}
@@ -2,16 +2,21 @@ package registry
 
 import (
 	"database/sql"
+	"fmt"
+	"time"
 
 	"github.com/spriigan/RPApp/interface/controller"
 	repo "github.com/spriigan/RPApp/interface/repository"
 	"github.com/spriigan/RPApp/usecases/interactor"
+	"github.com/spriigan/RPApp/usecases/messaging"
 	"github.com/spriigan/RPApp/usecases/repository"
 	"github.com/spriigan/RPApp/user-proto/grpc/models"
 )
 
 type Registry interface {
-	NewUserServer() models.UserServiceServer
+	NewUserServer(passwords interactor.PasswordHasher, policy interactor.PasswordPolicy, breachedFile string) (models.UserServiceServer, error)
+	NewUserPurger(retention time.Duration) interactor.UserPurger
+	NewEventRelay(driver, url string) (*messaging.Relay, error)
 }
 
 type registry struct {
@@ -22,13 +27,48 @@ func New(db *sql.DB) *registry {
 	return &registry{DB: db}
 }
 
-func (r *registry) NewUserServer() models.UserServiceServer {
-	return controller.NewUserServer(r.newUserInteractor())
+// NewUserServer checks new passwords against the breached password file when
+// breachedFile is set, the file stays open while the server runs.
+func (r *registry) NewUserServer(passwords interactor.PasswordHasher, policy interactor.PasswordPolicy, breachedFile string) (models.UserServiceServer, error) {
+	in := interactor.NewUserInteractor(r.newUserRepository())
+	in.Passwords = passwords
+	in.PasswordPolicy = policy
+	in.Audit = repo.NewAuditLog(r.DB)
+	if breachedFile != "" {
+		breached, err := repo.NewBreachedPasswordFile(breachedFile)
+		if err != nil {
+			return nil, err
+		}
+		in.Breached = breached
+	}
+	return controller.NewUserServer(in), nil
+}
+
+func (r *registry) NewUserPurger(retention time.Duration) interactor.UserPurger {
+	in := interactor.NewUserInteractor(r.newUserRepository())
+	in.Retention = retention
+	in.Audit = repo.NewAuditLog(r.DB)
+	return in
+}
+
+// NewEventRelay publishes the outbox with the publisher the driver names.
+func (r *registry) NewEventRelay(driver, url string) (*messaging.Relay, error) {
+	var publisher messaging.Publisher
+	switch driver {
+	case "", "memory":
+		publisher = messaging.NewBus()
+	case "nats":
+		nats, err := messaging.NewNATS(url, messaging.DefaultNATSTimeout)
+		if err != nil {
+			return nil, err
+		}
+		publisher = nats
+	default:
+		return nil, fmt.Errorf("unknown events driver %q", driver)
+	}
+	return messaging.NewRelay(repo.NewOutbox(r.DB), publisher), nil
 }
 
 func (r *registry) newUserRepository() repository.UserRepository {
 	return repo.NewUserRepository(r.DB)
 }
-func (r *registry) newUserInteractor() interactor.UserInteractor {
-	return interactor.NewUserInteractor(r.newUserRepository())
-}
@@ -1,3 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS "pg_trgm";
+
 CREATE TABLE "users" (
   "id" serial PRIMARY KEY,
   "first_name" varchar(100),
@@ -5,9 +7,159 @@ CREATE TABLE "users" (
   "email" varchar,
   "username" varchar,
   "password" varchar,
-  "created_at" timestamp DEFAULT (now())
+  "created_at" timestamp DEFAULT (now()),
+  "deactivated_at" timestamp,
+  "deleted_at" timestamp,
+  "purged_at" timestamp,
+  "totp_secret" varchar,
+  "totp_enabled_at" timestamp,
+  "role" varchar NOT NULL DEFAULT 'user',
+  "suspended_at" timestamp,
+  "suspension_reason" varchar,
+  "password_reset_required" boolean NOT NULL DEFAULT false
+);
+
+CREATE UNIQUE INDEX ON "users" (lower("username"));
+
+CREATE UNIQUE INDEX ON "users" (lower("email"));
+
+CREATE TABLE "login_throttles" (
+  "scope" varchar NOT NULL,
+  "subject" varchar NOT NULL,
+  "failures" int NOT NULL DEFAULT 0,
+  "last_failed_at" timestamp,
+  "locked_until" timestamp,
+  PRIMARY KEY ("scope", "subject")
+);
+
+CREATE TABLE "recovery_codes" (
+  "id" serial PRIMARY KEY,
+  "user_id" integer NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "used_at" timestamp
+);
+
+CREATE INDEX ON "recovery_codes" ("user_id");
+
+CREATE TABLE "sessions" (
+  "id" serial PRIMARY KEY,
+  "user_id" integer NOT NULL,
+  "device" varchar,
+  "ip" varchar,
+  "user_agent" varchar,
+  "access_hash" varchar NOT NULL,
+  "access_expires_at" timestamp NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "created_at" timestamp DEFAULT (now()),
+  "last_seen_at" timestamp DEFAULT (now()),
+  "revoked_at" timestamp,
+  "impersonator_id" integer
 );
 
+CREATE UNIQUE INDEX ON "sessions" ("access_hash");
+
+CREATE INDEX ON "sessions" ("user_id");
+
+CREATE TABLE "refresh_tokens" (
+  "token_hash" varchar PRIMARY KEY,
+  "session_id" integer NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "used_at" timestamp
+);
+
+CREATE INDEX ON "refresh_tokens" ("session_id");
+
+CREATE TABLE "user_profiles" (
+  "user_id" integer PRIMARY KEY,
+  "display_name" varchar,
+  "avatar_url" varchar,
+  "phone" varchar,
+  "locale" varchar,
+  "timezone" varchar,
+  "marketing_email" boolean NOT NULL DEFAULT false,
+  "marketing_sms" boolean NOT NULL DEFAULT false,
+  "updated_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "admin_actions" (
+  "id" serial PRIMARY KEY,
+  "actor_id" integer NOT NULL,
+  "action" varchar NOT NULL,
+  "target_id" integer NOT NULL,
+  "reason" varchar,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "admin_actions" ("target_id");
+
+CREATE TABLE "audit_log" (
+  "id" bigserial PRIMARY KEY,
+  "service" varchar NOT NULL,
+  "actor" varchar,
+  "impersonator" varchar,
+  "action" varchar NOT NULL,
+  "target_type" varchar NOT NULL,
+  "target_id" varchar NOT NULL,
+  "before" jsonb NOT NULL DEFAULT '{}',
+  "after" jsonb NOT NULL DEFAULT '{}',
+  "request_id" varchar,
+  "ip" varchar,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "audit_log" ("target_type", "target_id");
+
+CREATE INDEX ON "audit_log" ("actor");
+
+CREATE INDEX ON "audit_log" ("created_at");
+
+CREATE FUNCTION "reject_audit_log_change"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_log_append_only"
+  BEFORE UPDATE OR DELETE ON "audit_log"
+  FOR EACH ROW EXECUTE FUNCTION "reject_audit_log_change"();
+
+CREATE TABLE "outbox" (
+  "id" bigserial PRIMARY KEY,
+  "service" varchar NOT NULL,
+  "topic" varchar NOT NULL,
+  "partition_key" varchar NOT NULL,
+  "payload" bytea NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "published_at" timestamp,
+  "attempts" integer NOT NULL DEFAULT 0,
+  "last_error" varchar,
+  "failed_at" timestamp
+);
+
+CREATE INDEX "outbox_pending_idx" ON "outbox" ("service", "id") WHERE "published_at" IS NULL AND "failed_at" IS NULL;
+
+CREATE TABLE "processed_events" (
+  "consumer" varchar NOT NULL,
+  "event_id" varchar NOT NULL,
+  "processed_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("consumer", "event_id")
+);
+
+CREATE TABLE "dead_letters" (
+  "id" bigserial PRIMARY KEY,
+  "consumer" varchar NOT NULL,
+  "topic" varchar NOT NULL,
+  "partition_key" varchar NOT NULL,
+  "event_id" varchar NOT NULL,
+  "payload" bytea NOT NULL,
+  "error" varchar NOT NULL,
+  "attempts" integer NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "replayed_at" timestamp
+);
+
+CREATE INDEX "dead_letters_pending_idx" ON "dead_letters" ("consumer", "id") WHERE "replayed_at" IS NULL;
+
 CREATE TABLE "stores" (
   "id" serial PRIMARY KEY,
   "store_name" varchar,
@@ -30,15 +182,104 @@ CREATE TABLE "addresses" (
 CREATE TABLE "products" (
   "id" serial PRIMARY KEY,
   "store_id" integer,
+  "sku" varchar,
   "name" varchar,
   "description" varchar,
   "price" numeric(12,2),
+  "currency" char(3) NOT NULL DEFAULT 'IDR',
   "image_url" varchar,
   "stock" integer,
   "category_id" integer,
-  "created_at" timestamp
+  "rating_average" numeric(3,2) NOT NULL DEFAULT 0,
+  "rating_count" integer NOT NULL DEFAULT 0,
+  "created_at" timestamp,
+  "search_vector" tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce("name", '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce("description", '')), 'B')
+  ) STORED
+);
+
+CREATE UNIQUE INDEX ON "products" ("store_id", "sku");
+
+CREATE INDEX ON "products" USING GIN ("search_vector");
+
+CREATE INDEX ON "products" USING GIN ("name" gin_trgm_ops);
+
+CREATE TABLE "option_types" (
+  "id" serial PRIMARY KEY,
+  "product_id" integer NOT NULL,
+  "name" varchar NOT NULL,
+  "position" integer NOT NULL DEFAULT 0,
+  UNIQUE ("product_id", "name")
+);
+
+CREATE TABLE "option_values" (
+  "id" serial PRIMARY KEY,
+  "option_type_id" integer NOT NULL,
+  "value" varchar NOT NULL,
+  "position" integer NOT NULL DEFAULT 0,
+  UNIQUE ("option_type_id", "value")
+);
+
+CREATE TABLE "product_variants" (
+  "id" serial PRIMARY KEY,
+  "product_id" integer NOT NULL,
+  "sku" varchar NOT NULL UNIQUE,
+  "price" numeric(12,2) NOT NULL,
+  "currency" char(3) NOT NULL DEFAULT 'IDR',
+  "stock" integer NOT NULL DEFAULT 0 CHECK ("stock" >= 0),
+  "image_url" varchar,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "product_variants" ("product_id");
+
+CREATE TABLE "variant_option_values" (
+  "variant_id" integer NOT NULL,
+  "option_value_id" integer NOT NULL,
+  PRIMARY KEY ("variant_id", "option_value_id")
+);
+
+CREATE TABLE "stock_movements" (
+  "id" bigserial PRIMARY KEY,
+  "product_id" integer NOT NULL,
+  "variant_id" integer,
+  "quantity" integer NOT NULL,
+  "reason" varchar NOT NULL CHECK ("reason" IN ('restock', 'sale', 'reservation', 'adjustment', 'return')),
+  "reference" varchar,
+  "note" varchar,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "stock_movements" ("product_id", "created_at");
+
+CREATE INDEX ON "stock_movements" ("variant_id");
+
+CREATE FUNCTION "reject_stock_movement_change"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "stock_movements_append_only"
+  BEFORE UPDATE OR DELETE ON "stock_movements"
+  FOR EACH ROW EXECUTE FUNCTION "reject_stock_movement_change"();
+
+CREATE TABLE "reviews" (
+  "id" serial PRIMARY KEY,
+  "product_id" integer NOT NULL,
+  "user_id" integer NOT NULL,
+  "rating" smallint NOT NULL CHECK ("rating" BETWEEN 1 AND 5),
+  "body" text NOT NULL DEFAULT '',
+  "verified_purchase" boolean NOT NULL DEFAULT false,
+  "status" varchar NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'approved', 'rejected')),
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "updated_at" timestamp NOT NULL DEFAULT (now()),
+  UNIQUE ("product_id", "user_id")
 );
 
+CREATE INDEX ON "reviews" ("product_id", "status", "created_at");
+
 CREATE TABLE "parent_category" (
   "id" serial PRIMARY KEY,
   "name" varchar,
@@ -57,7 +298,11 @@ CREATE TABLE "orders" (
   "user_id" integer,
   "store_id" integer,
   "order_date" timestamp DEFAULT (now()),
+  "currency" char(3),
+  "subtotal_amount" numeric(12,2),
+  "discount_amount" numeric(12,2) NOT NULL DEFAULT 0,
   "total_amount" numeric(12,2),
+  "coupon_code" varchar,
   "status" varchar
 );
 
@@ -65,19 +310,136 @@ CREATE TABLE "order_items" (
   "id" serial PRIMARY KEY,
   "order_id" integer,
   "product_id" integer,
+  "variant_id" integer,
   "quantity" integer,
   "price" numeric(12,2),
+  "discount" numeric(12,2) NOT NULL DEFAULT 0,
   "created_at" timestamp DEFAULT (now())
 );
 
+CREATE TABLE "promotions" (
+  "id" serial PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "kind" varchar NOT NULL CHECK ("kind" IN ('percentage', 'fixed')),
+  "percent_off" numeric(5,2) CHECK ("percent_off" > 0 AND "percent_off" <= 100),
+  "amount_off" numeric(12,2) CHECK ("amount_off" > 0),
+  "currency" char(3),
+  "store_id" integer,
+  "category_id" integer,
+  "coupon_code" varchar,
+  "usage_limit" integer CHECK ("usage_limit" > 0),
+  "usage_count" integer NOT NULL DEFAULT 0,
+  "starts_at" timestamp NOT NULL DEFAULT (now()),
+  "expires_at" timestamp,
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  CHECK (("kind" = 'percentage' AND "percent_off" IS NOT NULL) OR ("kind" = 'fixed' AND "amount_off" IS NOT NULL AND "currency" IS NOT NULL))
+);
+
+CREATE UNIQUE INDEX ON "promotions" (upper("coupon_code"));
+
+CREATE TABLE "promotion_redemptions" (
+  "id" serial PRIMARY KEY,
+  "promotion_id" integer NOT NULL,
+  "order_id" integer NOT NULL,
+  "amount" numeric(12,2) NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
 CREATE TABLE "cart" (
   "id" serial PRIMARY KEY,
   "user_id" integer,
   "product_id" integer,
+  "variant_id" integer,
   "quantity" integer,
   "price" numeric(12,2)
 );
 
+CREATE UNIQUE INDEX ON "cart" ("user_id", "product_id", coalesce("variant_id", 0));
+
+CREATE TABLE "wishlist_items" (
+  "id" serial PRIMARY KEY,
+  "user_id" integer NOT NULL,
+  "product_id" integer NOT NULL,
+  "variant_id" integer,
+  "added_price" numeric(12,2),
+  "seen_price" numeric(12,2),
+  "seen_in_stock" boolean NOT NULL DEFAULT false,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "wishlist_items" ("user_id", "product_id", coalesce("variant_id", 0));
+
+CREATE TABLE "notifications" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" integer NOT NULL,
+  "kind" varchar NOT NULL,
+  "subject" varchar NOT NULL,
+  "body" text NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "read_at" timestamp
+);
+
+CREATE INDEX ON "notifications" ("user_id", "id");
+
+CREATE TABLE "notification_preferences" (
+  "user_id" integer NOT NULL,
+  "category" varchar NOT NULL,
+  "email" boolean NOT NULL DEFAULT true,
+  "in_app" boolean NOT NULL DEFAULT true,
+  "updated_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("user_id", "category")
+);
+
+CREATE TABLE "outgoing_emails" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" integer NOT NULL,
+  "email" varchar NOT NULL,
+  "kind" varchar NOT NULL,
+  "subject" varchar NOT NULL,
+  "body" text NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "sent_at" timestamp,
+  "attempts" integer NOT NULL DEFAULT 0,
+  "last_error" varchar,
+  "failed_at" timestamp
+);
+
+CREATE INDEX "outgoing_emails_pending_idx" ON "outgoing_emails" ("id") WHERE "sent_at" IS NULL AND "failed_at" IS NULL;
+
+CREATE INDEX ON "outgoing_emails" ("user_id");
+
+CREATE TABLE "api_keys" (
+  "id" serial PRIMARY KEY,
+  "store_id" integer NOT NULL,
+  "name" varchar NOT NULL,
+  "prefix" varchar NOT NULL,
+  "key_hash" varchar NOT NULL,
+  "scopes" varchar[] NOT NULL,
+  "expires_at" timestamp,
+  "last_used_at" timestamp,
+  "revoked_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "api_keys" ("prefix");
+
+CREATE INDEX ON "api_keys" ("store_id");
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "refresh_tokens" ADD FOREIGN KEY ("session_id") REFERENCES "sessions" ("id");
+
+ALTER TABLE "user_profiles" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("impersonator_id") REFERENCES "users" ("id");
+
+ALTER TABLE "admin_actions" ADD FOREIGN KEY ("actor_id") REFERENCES "users" ("id");
+
+ALTER TABLE "admin_actions" ADD FOREIGN KEY ("target_id") REFERENCES "users" ("id");
+
 ALTER TABLE "stores" ADD FOREIGN KEY ("owner_id") REFERENCES "users" ("id");
 
 ALTER TABLE "addresses" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
@@ -88,6 +450,24 @@ ALTER TABLE "products" ADD FOREIGN KEY ("store_id") REFERENCES "stores" ("id");
 
 ALTER TABLE "products" ADD FOREIGN KEY ("category_id") REFERENCES "category" ("id");
 
+ALTER TABLE "option_types" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id");
+
+ALTER TABLE "option_values" ADD FOREIGN KEY ("option_type_id") REFERENCES "option_types" ("id");
+
+ALTER TABLE "product_variants" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id");
+
+ALTER TABLE "variant_option_values" ADD FOREIGN KEY ("variant_id") REFERENCES "product_variants" ("id");
+
+ALTER TABLE "variant_option_values" ADD FOREIGN KEY ("option_value_id") REFERENCES "option_values" ("id");
+
+ALTER TABLE "stock_movements" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id");
+
+ALTER TABLE "stock_movements" ADD FOREIGN KEY ("variant_id") REFERENCES "product_variants" ("id");
+
+ALTER TABLE "reviews" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id");
+
+ALTER TABLE "reviews" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
 ALTER TABLE "category" ADD FOREIGN KEY ("parent_category_id") REFERENCES "parent_category" ("id");
 
 ALTER TABLE "orders" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
@@ -98,6 +478,32 @@ ALTER TABLE "order_items" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id"
 
 ALTER TABLE "order_items" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id");
 
+ALTER TABLE "order_items" ADD FOREIGN KEY ("variant_id") REFERENCES "product_variants" ("id");
+
+ALTER TABLE "promotions" ADD FOREIGN KEY ("store_id") REFERENCES "stores" ("id");
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("store_id") REFERENCES "stores" ("id");
+
+ALTER TABLE "promotions" ADD FOREIGN KEY ("category_id") REFERENCES "category" ("id");
+
+ALTER TABLE "promotion_redemptions" ADD FOREIGN KEY ("promotion_id") REFERENCES "promotions" ("id");
+
+ALTER TABLE "promotion_redemptions" ADD FOREIGN KEY ("order_id") REFERENCES "orders" ("id");
+
 ALTER TABLE "cart" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
 
 ALTER TABLE "cart" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id");
+
+ALTER TABLE "cart" ADD FOREIGN KEY ("variant_id") REFERENCES "product_variants" ("id");
+
+ALTER TABLE "wishlist_items" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "wishlist_items" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id");
+
+ALTER TABLE "wishlist_items" ADD FOREIGN KEY ("variant_id") REFERENCES "product_variants" ("id");
+
+ALTER TABLE "notifications" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "notification_preferences" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "outgoing_emails" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
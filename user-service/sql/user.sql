@@ -2,7 +2,123 @@ CREATE TABLE public.users (
   id bigserial NOT NULL PRIMARY KEY,
   first_name character varying(25),
   last_name character varying(25),
-  username character varying(25) NOT NULL UNIQUE,
+  username character varying(25) NOT NULL,
   password character varying(255),
-  email character varying(255)
-);
\ No newline at end of file
+  email character varying(255),
+  created_at timestamp DEFAULT now(),
+  deactivated_at timestamp,
+  deleted_at timestamp,
+  purged_at timestamp,
+  totp_secret character varying(64),
+  totp_enabled_at timestamp,
+  role character varying(10) NOT NULL DEFAULT 'user',
+  suspended_at timestamp,
+  suspension_reason character varying(500),
+  password_reset_required boolean NOT NULL DEFAULT false
+);
+CREATE UNIQUE INDEX users_username_lower_idx ON public.users (lower(username));
+CREATE UNIQUE INDEX users_email_lower_idx ON public.users (lower(email));
+CREATE TABLE public.addresses (
+  id bigserial NOT NULL PRIMARY KEY,
+  user_id bigint REFERENCES public.users (id),
+  store_id bigint,
+  street_address character varying,
+  city character varying,
+  state character varying,
+  country character varying,
+  zip_code character varying
+);
+CREATE TABLE public.login_throttles (
+  scope character varying(10) NOT NULL,
+  subject character varying(255) NOT NULL,
+  failures integer NOT NULL DEFAULT 0,
+  last_failed_at timestamp,
+  locked_until timestamp,
+  PRIMARY KEY (scope, subject)
+);
+CREATE TABLE public.recovery_codes (
+  id bigserial NOT NULL PRIMARY KEY,
+  user_id bigint NOT NULL REFERENCES public.users (id),
+  code_hash character varying(64) NOT NULL,
+  used_at timestamp
+);
+CREATE INDEX recovery_codes_user_id_idx ON public.recovery_codes (user_id);
+CREATE TABLE public.sessions (
+  id bigserial NOT NULL PRIMARY KEY,
+  user_id bigint NOT NULL REFERENCES public.users (id),
+  device character varying(255),
+  ip character varying(64),
+  user_agent character varying(512),
+  access_hash character varying(64) NOT NULL UNIQUE,
+  access_expires_at timestamp NOT NULL,
+  expires_at timestamp NOT NULL,
+  created_at timestamp DEFAULT now(),
+  last_seen_at timestamp DEFAULT now(),
+  revoked_at timestamp,
+  impersonator_id bigint REFERENCES public.users (id)
+);
+CREATE INDEX sessions_user_id_idx ON public.sessions (user_id);
+CREATE TABLE public.refresh_tokens (
+  token_hash character varying(64) NOT NULL PRIMARY KEY,
+  session_id bigint NOT NULL REFERENCES public.sessions (id),
+  expires_at timestamp NOT NULL,
+  used_at timestamp
+);
+CREATE INDEX refresh_tokens_session_id_idx ON public.refresh_tokens (session_id);
+CREATE TABLE public.user_profiles (
+  user_id bigint NOT NULL PRIMARY KEY REFERENCES public.users (id),
+  display_name character varying(100),
+  avatar_url character varying(2048),
+  phone character varying(16),
+  locale character varying(35),
+  timezone character varying(64),
+  marketing_email boolean NOT NULL DEFAULT false,
+  marketing_sms boolean NOT NULL DEFAULT false,
+  updated_at timestamp NOT NULL DEFAULT now()
+);
+CREATE TABLE public.admin_actions (
+  id bigserial NOT NULL PRIMARY KEY,
+  actor_id bigint NOT NULL REFERENCES public.users (id),
+  action character varying(30) NOT NULL,
+  target_id bigint NOT NULL REFERENCES public.users (id),
+  reason character varying(500),
+  created_at timestamp NOT NULL DEFAULT now()
+);
+CREATE INDEX admin_actions_target_id_idx ON public.admin_actions (target_id);
+CREATE TABLE public.audit_log (
+  id bigserial NOT NULL PRIMARY KEY,
+  service character varying(30) NOT NULL,
+  actor character varying,
+  impersonator character varying,
+  action character varying(60) NOT NULL,
+  target_type character varying(30) NOT NULL,
+  target_id character varying NOT NULL,
+  before jsonb NOT NULL DEFAULT '{}',
+  after jsonb NOT NULL DEFAULT '{}',
+  request_id character varying(64),
+  ip character varying(45),
+  created_at timestamp NOT NULL DEFAULT now()
+);
+CREATE INDEX audit_log_target_idx ON public.audit_log (target_type, target_id);
+CREATE INDEX audit_log_actor_idx ON public.audit_log (actor);
+CREATE INDEX audit_log_created_at_idx ON public.audit_log (created_at);
+CREATE FUNCTION public.reject_audit_log_change() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON public.audit_log
+  FOR EACH ROW EXECUTE FUNCTION public.reject_audit_log_change();
+CREATE TABLE public.outbox (
+  id bigserial NOT NULL PRIMARY KEY,
+  service character varying(30) NOT NULL,
+  topic character varying(60) NOT NULL,
+  partition_key character varying NOT NULL,
+  payload bytea NOT NULL,
+  created_at timestamp NOT NULL DEFAULT now(),
+  published_at timestamp,
+  attempts integer NOT NULL DEFAULT 0,
+  last_error character varying,
+  failed_at timestamp
+);
+CREATE INDEX outbox_pending_idx ON public.outbox (service, id) WHERE published_at IS NULL AND failed_at IS NULL;
@@ -0,0 +1,31 @@
+// Package audit keeps the append-only record of who changed what. The user
+// and product services write the same entries to the shared audit_log table,
+// the broker sends who made each request along with the call. An entry is
+// written in the transaction of the change it describes, so a change is
+// never made without its entry.
+package audit
+
+import (
+	"context"
+	"log"
+)
+
+// Auditor appends entries to the audit log within the transaction of ctx,
+// entries are never changed once written.
+type Auditor interface {
+	Record(ctx context.Context, entry Entry) error
+}
+
+// LogAuditor only logs the entries, it is used until a real log is set.
+type LogAuditor struct{}
+
+func (LogAuditor) Record(ctx context.Context, entry Entry) error {
+	log.Printf("audit %s on %s %s by %q from %s, request %s", entry.Action, entry.TargetType, entry.TargetID, entry.Actor, entry.IP, entry.RequestID)
+	return nil
+}
+
+// Record completes the entry and writes it. Call it inside the transaction
+// of the change and fail the change when it fails.
+func Record(ctx context.Context, auditor Auditor, entry Entry) error {
+	return auditor.Record(ctx, Complete(ctx, entry))
+}
@@ -0,0 +1,50 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spriigan/RPApp/usecases/audit"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	entries []audit.Entry
+	err     error
+}
+
+func (r *recorder) Record(ctx context.Context, entry audit.Entry) error {
+	r.entries = append(r.entries, entry)
+	return r.err
+}
+
+func TestRecord(t *testing.T) {
+	request := audit.Request{ID: "req-1", IP: "10.0.0.1", Actor: "root@mail.com", Impersonator: "root"}
+	r := &recorder{}
+	err := audit.Record(audit.WithRequest(context.Background(), request), r, audit.Entry{
+		Action:     "user.update",
+		TargetType: "user",
+		TargetID:   "dabi",
+		Before:     map[string]interface{}{"email": "dabi@mail.com", "fname": "dabi"},
+		After:      map[string]interface{}{"email": "touya@mail.com", "fname": "dabi"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []audit.Entry{{
+		Actor:        "root@mail.com",
+		Impersonator: "root",
+		Action:       "user.update",
+		TargetType:   "user",
+		TargetID:     "dabi",
+		Before:       map[string]interface{}{"email": "dabi@mail.com"},
+		After:        map[string]interface{}{"email": "touya@mail.com"},
+		RequestID:    "req-1",
+		IP:           "10.0.0.1",
+	}}, r.entries)
+
+	// a failing log fails the action it records, its transaction rolls back
+	failing := &recorder{err: errors.New("log is down")}
+	err = audit.Record(context.Background(), failing, audit.Entry{Actor: "system", Action: "user.delete"})
+	require.Error(t, err)
+	require.Equal(t, "system", failing.entries[0].Actor)
+}
@@ -0,0 +1,128 @@
+// The user service has a copy of this file, make sync_shared writes it from
+// this one. Change it here and run make sync_shared.
+
+package audit
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Entry is one security sensitive action. Before and After hold only the
+// fields the action changed, never secrets such as password hashes.
+type Entry struct {
+	Actor        string
+	Impersonator string
+	Action       string
+	TargetType   string
+	TargetID     string
+	Before       map[string]interface{}
+	After        map[string]interface{}
+	RequestID    string
+	IP           string
+}
+
+// The metadata keys the broker sends the request along with.
+const (
+	RequestIDKey    = "x-request-id"
+	ClientIPKey     = "x-client-ip"
+	ActorKey        = "x-actor"
+	ImpersonatorKey = "x-impersonator"
+)
+
+// Request is what the broker tells about the request a call serves. Actor is
+// the email of the signed in user, Impersonator the admin acting as them.
+type Request struct {
+	ID           string
+	IP           string
+	Actor        string
+	Impersonator string
+}
+
+type requestKey struct{}
+
+func WithRequest(ctx context.Context, request Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, request)
+}
+
+// RequestFrom returns the request of ctx, empty for calls that did not come
+// through the broker.
+func RequestFrom(ctx context.Context) Request {
+	request, _ := ctx.Value(requestKey{}).(Request)
+	return request
+}
+
+// UnaryServerInterceptor puts the request the broker sent into the context
+// of every call.
+func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(incoming(ctx), req)
+}
+
+// StreamServerInterceptor does what UnaryServerInterceptor does for the
+// streaming calls, such as the catalog import.
+func StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &requestStream{ServerStream: ss, ctx: incoming(ss.Context())})
+}
+
+type requestStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *requestStream) Context() context.Context {
+	return s.ctx
+}
+
+func incoming(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return WithRequest(ctx, Request{
+		ID:           first(md, RequestIDKey),
+		IP:           first(md, ClientIPKey),
+		Actor:        first(md, ActorKey),
+		Impersonator: first(md, ImpersonatorKey),
+	})
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// Complete fills in the request of ctx and leaves out the fields that did
+// not change, every entry goes through it before it is written.
+func Complete(ctx context.Context, entry Entry) Entry {
+	request := RequestFrom(ctx)
+	if entry.Actor == "" {
+		entry.Actor = request.Actor
+	}
+	if entry.Impersonator == "" {
+		entry.Impersonator = request.Impersonator
+	}
+	entry.RequestID, entry.IP = request.ID, request.IP
+	entry.Before, entry.After = Changes(entry.Before, entry.After)
+	return entry
+}
+
+// Changes returns the fields whose values differ between before and after,
+// a field missing on one side counts as changed.
+func Changes(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	b := make(map[string]interface{}, len(before))
+	a := make(map[string]interface{}, len(after))
+	for k, v := range before {
+		if w, ok := after[k]; !ok || !reflect.DeepEqual(v, w) {
+			b[k] = v
+		}
+	}
+	for k, v := range after {
+		if w, ok := before[k]; !ok || !reflect.DeepEqual(v, w) {
+			a[k] = v
+		}
+	}
+	return b, a
+}
@@ -0,0 +1,91 @@
+// The user service has a copy of this file, make sync_shared writes it from
+// this one. Change it here and run make sync_shared.
+
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type stream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *stream) Context() context.Context {
+	return s.ctx
+}
+
+func TestChanges(t *testing.T) {
+	before, after := Changes(
+		map[string]interface{}{"fname": "dabi", "email": "dabi@mail.com", "role": "user"},
+		map[string]interface{}{"fname": "dabi", "email": "touya@mail.com", "password": "set"},
+	)
+	require.Equal(t, map[string]interface{}{"email": "dabi@mail.com", "role": "user"}, before)
+	require.Equal(t, map[string]interface{}{"email": "touya@mail.com", "password": "set"}, after)
+
+	before, after = Changes(nil, nil)
+	require.Empty(t, before)
+	require.Empty(t, after)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	md := metadata.Pairs(
+		RequestIDKey, "req-1",
+		ClientIPKey, "10.0.0.1",
+		ActorKey, "root@mail.com",
+		ImpersonatorKey, "root",
+	)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	var request Request
+	_, err := UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		request = RequestFrom(ctx)
+		return nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, Request{ID: "req-1", IP: "10.0.0.1", Actor: "root@mail.com", Impersonator: "root"}, request)
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	md := metadata.Pairs(RequestIDKey, "req-1", ActorKey, "owner@mail.com")
+	ss := &stream{ctx: metadata.NewIncomingContext(context.Background(), md)}
+	var request Request
+	err := StreamServerInterceptor(nil, ss, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		request = RequestFrom(ss.Context())
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, Request{ID: "req-1", Actor: "owner@mail.com"}, request)
+}
+
+func TestComplete(t *testing.T) {
+	ctx := WithRequest(context.Background(), Request{ID: "req-1", IP: "10.0.0.1", Actor: "root@mail.com", Impersonator: "root"})
+	entry := Complete(ctx, Entry{
+		Action:     "user.update",
+		TargetType: "user",
+		TargetID:   "dabi",
+		Before:     map[string]interface{}{"email": "dabi@mail.com", "fname": "dabi"},
+		After:      map[string]interface{}{"email": "touya@mail.com", "fname": "dabi"},
+	})
+	require.Equal(t, Entry{
+		Actor:        "root@mail.com",
+		Impersonator: "root",
+		Action:       "user.update",
+		TargetType:   "user",
+		TargetID:     "dabi",
+		Before:       map[string]interface{}{"email": "dabi@mail.com"},
+		After:        map[string]interface{}{"email": "touya@mail.com"},
+		RequestID:    "req-1",
+		IP:           "10.0.0.1",
+	}, entry)
+
+	// an actor named by the caller, such as a job, is kept
+	entry = Complete(context.Background(), Entry{Actor: "system", Action: "user.delete"})
+	require.Equal(t, "system", entry.Actor)
+	require.Empty(t, entry.RequestID)
+}
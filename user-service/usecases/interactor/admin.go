@@ -0,0 +1,258 @@
+package interactor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spriigan/RPApp/usecases/repository"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrForbidden      = errors.New("not allowed to do this")
+	ErrInvalidRole    = errors.New("role must be user, support or admin")
+	ErrInvalidStatus  = errors.New("status must be active, suspended or deactivated")
+	ErrReasonRequired = errors.New("a reason is required")
+)
+
+// The roles a user can have. Support staff look after users, admins also
+// impersonate them and hand out roles.
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
+var roleRanks = map[string]int{RoleUser: 0, RoleSupport: 1, RoleAdmin: 2}
+
+// The actions kept in the record of what admins did.
+const (
+	ActionSuspend            = "suspend"
+	ActionUnsuspend          = "unsuspend"
+	ActionReactivate         = "reactivate"
+	ActionUnlock             = "unlock"
+	ActionForcePasswordReset = "force_password_reset"
+	ActionImpersonate        = "impersonate"
+	ActionSetRole            = "set_role"
+)
+
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 200
+	maxReasonLength    = 500
+)
+
+func (in *userInteractor) SearchUsers(ctx context.Context, actor string, search repository.UserSearch) (*models.UserAccounts, error) {
+	if _, err := in.staff(ctx, actor, RoleSupport); err != nil {
+		return nil, err
+	}
+	search.Query = strings.TrimSpace(search.Query)
+	if _, ok := roleRanks[search.Role]; search.Role != "" && !ok {
+		return nil, ErrInvalidRole
+	}
+	switch search.Status {
+	case "", repository.StatusActive, repository.StatusSuspended, repository.StatusDeactivated:
+	default:
+		return nil, ErrInvalidStatus
+	}
+	if search.Limit <= 0 {
+		search.Limit = DefaultSearchLimit
+	}
+	if search.Limit > MaxSearchLimit {
+		search.Limit = MaxSearchLimit
+	}
+	accounts, err := in.Repo.SearchUsers(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserAccounts{Accounts: accounts}, nil
+}
+
+// SuspendUser keeps the user from logging in until UnsuspendUser, their
+// sessions end straight away.
+func (in *userInteractor) SuspendUser(ctx context.Context, actor, username, reason string) error {
+	action, target, err := in.adminAction(ctx, actor, username, reason, RoleSupport, ActionSuspend)
+	if err != nil {
+		return err
+	}
+	if action.Reason == "" {
+		return ErrReasonRequired
+	}
+	return in.Repo.InTx(ctx, func(ctx context.Context) error {
+		if err := in.Repo.Suspend(ctx, action); err != nil {
+			return err
+		}
+		return in.auditAdmin(ctx, AuditUserSuspend, target, action,
+			map[string]interface{}{"suspended": target.SuspendedAt != nil},
+			map[string]interface{}{"suspended": true})
+	})
+}
+
+func (in *userInteractor) UnsuspendUser(ctx context.Context, actor, username, reason string) error {
+	action, target, err := in.adminAction(ctx, actor, username, reason, RoleSupport, ActionUnsuspend)
+	if err != nil {
+		return err
+	}
+	return in.Repo.InTx(ctx, func(ctx context.Context) error {
+		if err := in.Repo.Unsuspend(ctx, action); err != nil {
+			return err
+		}
+		return in.auditAdmin(ctx, AuditUserUnsuspend, target, action,
+			map[string]interface{}{"suspended": target.SuspendedAt != nil},
+			map[string]interface{}{"suspended": false})
+	})
+}
+
+// ReactivateUser brings back an account its user deactivated. A deactivated
+// user can't sign in, so staff do it for them.
+func (in *userInteractor) ReactivateUser(ctx context.Context, actor, username, reason string) error {
+	action, target, err := in.adminActionOn(ctx, in.Repo.FindDeactivated, actor, username, reason, RoleSupport, ActionReactivate)
+	if err != nil {
+		return err
+	}
+	return in.Repo.InTx(ctx, func(ctx context.Context) error {
+		if err := in.Repo.Reactivate(ctx, action); err != nil {
+			return err
+		}
+		return in.auditAdmin(ctx, AuditUserReactivate, target, action,
+			map[string]interface{}{"deactivated": true},
+			map[string]interface{}{"deactivated": false})
+	})
+}
+
+// ForcePasswordReset ends the user's sessions, after the next login the user
+// can do nothing but choose a new password.
+func (in *userInteractor) ForcePasswordReset(ctx context.Context, actor, username, reason string) error {
+	action, target, err := in.adminAction(ctx, actor, username, reason, RoleSupport, ActionForcePasswordReset)
+	if err != nil {
+		return err
+	}
+	return in.Repo.InTx(ctx, func(ctx context.Context) error {
+		if err := in.Repo.RequirePasswordReset(ctx, action); err != nil {
+			return err
+		}
+		return in.auditAdmin(ctx, AuditUserPasswordReset, target, action,
+			map[string]interface{}{"passwordResetRequired": target.PasswordResetRequired},
+			map[string]interface{}{"passwordResetRequired": true})
+	})
+}
+
+// Impersonate starts a session as the user for an admin to see what they
+// see. It lasts ImpersonationTTL, can't be refreshed and names the admin in
+// every request made with it.
+func (in *userInteractor) Impersonate(ctx context.Context, actor, username, reason string) (*models.AuthTokens, error) {
+	action, target, err := in.adminAction(ctx, actor, username, reason, RoleAdmin, ActionImpersonate)
+	if err != nil {
+		return nil, err
+	}
+	// a session as another admin would act with their rights under their name
+	if target.Role != RoleUser {
+		return nil, ErrForbidden
+	}
+	if action.Reason == "" {
+		return nil, ErrReasonRequired
+	}
+	now := time.Now()
+	tokens, pair, err := in.newTokens(now)
+	if err != nil {
+		return nil, err
+	}
+	pair.AccessExpiresAt = now.Add(in.Sessions.ImpersonationTTL)
+	pair.RefreshExpiresAt = pair.AccessExpiresAt
+	// a session nobody knows was impersonated must not exist, it is started
+	// in the transaction that records it
+	err = in.Repo.InTx(ctx, func(ctx context.Context) error {
+		if err := in.Repo.RecordAdminAction(ctx, action); err != nil {
+			return err
+		}
+		var err error
+		tokens.SessionId, err = in.Repo.CreateSession(ctx, repository.NewSession{
+			UserID:         action.TargetID,
+			Device:         "impersonated by " + strings.TrimSpace(actor),
+			Tokens:         pair,
+			ImpersonatorID: action.ActorID,
+		})
+		if err != nil {
+			return err
+		}
+		return in.auditAdmin(ctx, AuditUserImpersonate, target, action, nil, map[string]interface{}{
+			"sessionId": tokens.SessionId,
+			"expiresAt": pair.AccessExpiresAt.UTC().Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	tokens.AccessExpiresAt = timestamppb.New(pair.AccessExpiresAt)
+	tokens.RefreshToken, tokens.RefreshExpiresAt = "", nil
+	return tokens, nil
+}
+
+func (in *userInteractor) SetRole(ctx context.Context, actor, username, role, reason string) error {
+	if _, ok := roleRanks[role]; !ok {
+		return ErrInvalidRole
+	}
+	action, target, err := in.adminAction(ctx, actor, username, reason, RoleAdmin, ActionSetRole)
+	if err != nil {
+		return err
+	}
+	return in.Repo.InTx(ctx, func(ctx context.Context) error {
+		if err := in.Repo.SetRole(ctx, role, action); err != nil {
+			return err
+		}
+		return in.auditAdmin(ctx, AuditUserSetRole, target, action,
+			map[string]interface{}{"role": target.Role},
+			map[string]interface{}{"role": role})
+	})
+}
+
+// adminAction checks that actor has at least the given role and outranks the
+// user acted on, and returns the record of the action with that user.
+func (in *userInteractor) adminAction(ctx context.Context, actor, username, reason, role, name string) (repository.AdminAction, *models.User, error) {
+	return in.adminActionOn(ctx, in.Repo.FindByUsername, actor, username, reason, role, name)
+}
+
+// adminActionOn is adminAction with the target looked up by find.
+func (in *userInteractor) adminActionOn(ctx context.Context, find func(context.Context, string) (*models.User, error), actor, username, reason, role, name string) (repository.AdminAction, *models.User, error) {
+	staff, err := in.staff(ctx, actor, role)
+	if err != nil {
+		return repository.AdminAction{}, nil, err
+	}
+	target, err := find(ctx, strings.TrimSpace(username))
+	if err != nil {
+		return repository.AdminAction{}, nil, err
+	}
+	if !outranks(staff, target) {
+		return repository.AdminAction{}, nil, ErrForbidden
+	}
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > maxReasonLength {
+		reason = string([]rune(reason)[:maxReasonLength])
+	}
+	return repository.AdminAction{ActorID: staff.Id, Action: name, TargetID: target.Id, Reason: reason}, target, nil
+}
+
+// staff returns the acting user when their role is at least the given one.
+func (in *userInteractor) staff(ctx context.Context, actor, role string) (*models.User, error) {
+	user, err := in.Repo.FindByUsername(ctx, strings.TrimSpace(actor))
+	if err != nil {
+		return nil, err
+	}
+	if user.SuspendedAt != nil || roleRanks[user.Role] < roleRanks[role] {
+		return nil, ErrForbidden
+	}
+	return user, nil
+}
+
+// outranks reports whether staff may act on target. Nobody acts on
+// themselves, admins act on anybody else and support only on users.
+func outranks(staff, target *models.User) bool {
+	if staff.Id == target.Id {
+		return false
+	}
+	return staff.Role == RoleAdmin || roleRanks[staff.Role] > roleRanks[target.Role]
+}
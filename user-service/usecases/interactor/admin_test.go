@@ -0,0 +1,257 @@
+package interactor_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spriigan/RPApp/interface/repository"
+	"github.com/spriigan/RPApp/usecases/interactor"
+	usecases "github.com/spriigan/RPApp/usecases/repository"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	admin      = &models.User{Id: 1, Username: "root", Role: interactor.RoleAdmin}
+	support    = &models.User{Id: 2, Username: "helper", Role: interactor.RoleSupport}
+	customer   = &models.User{Id: 3, Username: "dabi", Role: interactor.RoleUser}
+	otherAdmin = &models.User{Id: 4, Username: "boss", Role: interactor.RoleAdmin}
+)
+
+func TestSearchUsers(t *testing.T) {
+	testTable := map[string]struct {
+		actor   string
+		search  usecases.UserSearch
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.UserAccounts, err error)
+	}{
+		"succes call": {
+			actor:  "helper",
+			search: usecases.UserSearch{Query: " dab ", Limit: 1000},
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "helper").Return(support, nil).Once()
+				mockRepo.On("SearchUsers", usecases.UserSearch{Query: "dab", Limit: interactor.MaxSearchLimit}).
+					Return([]*models.UserAccount{{Bio: &models.UserBio{Username: "dabi"}}}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserAccounts, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Accounts, 1)
+			},
+		},
+		"not staff": {
+			actor: "dabi",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "dabi").Return(customer, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserAccounts, err error) {
+				require.ErrorIs(t, err, interactor.ErrForbidden)
+			},
+		},
+		"unknown status": {
+			actor:  "root",
+			search: usecases.UserSearch{Status: "banned"},
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "root").Return(admin, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserAccounts, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidStatus)
+			},
+		},
+	}
+
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := userInteractor.SearchUsers(context.Background(), v.actor, v.search)
+
+			v.assert(t, actual, err)
+		})
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSuspendUser(t *testing.T) {
+	testTable := map[string]struct {
+		actor    string
+		username string
+		reason   string
+		arrange  func(t *testing.T)
+		assert   func(t *testing.T, err error)
+	}{
+		"succes call": {
+			actor:    "helper",
+			username: "dabi",
+			reason:   " chargebacks ",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "helper").Return(support, nil).Once()
+				mockRepo.On("FindByUsername", "dabi").Return(customer, nil).Once()
+				mockRepo.On("Suspend", usecases.AdminAction{ActorID: 2, Action: interactor.ActionSuspend, TargetID: 3, Reason: "chargebacks"}).
+					Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"support can not suspend an admin": {
+			actor:    "helper",
+			username: "root",
+			reason:   "testing",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "helper").Return(support, nil).Once()
+				mockRepo.On("FindByUsername", "root").Return(admin, nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, interactor.ErrForbidden)
+			},
+		},
+		"not themselves": {
+			actor:    "root",
+			username: "root",
+			reason:   "testing",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "root").Return(admin, nil).Twice()
+			},
+			assert: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, interactor.ErrForbidden)
+			},
+		},
+		"no reason": {
+			actor:    "root",
+			username: "dabi",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "root").Return(admin, nil).Once()
+				mockRepo.On("FindByUsername", "dabi").Return(customer, nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, interactor.ErrReasonRequired)
+			},
+		},
+		"no user found": {
+			actor:    "root",
+			username: "nobody",
+			reason:   "testing",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "root").Return(admin, nil).Once()
+				mockRepo.On("FindByUsername", "nobody").Return(nil, repository.ErrNoUserFound).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, repository.ErrNoUserFound)
+			},
+		},
+	}
+
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			err := userInteractor.SuspendUser(context.Background(), v.actor, v.username, v.reason)
+
+			v.assert(t, err)
+		})
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReactivateUser(t *testing.T) {
+	testTable := map[string]struct {
+		actor    string
+		username string
+		arrange  func(t *testing.T)
+		assert   func(t *testing.T, err error)
+	}{
+		"succes call": {
+			actor:    "helper",
+			username: "dabi",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "helper").Return(support, nil).Once()
+				mockRepo.On("FindDeactivated", "dabi").Return(customer, nil).Once()
+				mockRepo.On("Reactivate", usecases.AdminAction{ActorID: 2, Action: interactor.ActionReactivate, TargetID: 3}).
+					Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"not staff": {
+			actor:    "dabi",
+			username: "dabi",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "dabi").Return(customer, nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, interactor.ErrForbidden)
+			},
+		},
+		"not deactivated": {
+			actor:    "root",
+			username: "boss",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "root").Return(admin, nil).Once()
+				mockRepo.On("FindDeactivated", "boss").Return(nil, repository.ErrNoUserFound).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, repository.ErrNoUserFound)
+			},
+		},
+	}
+
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			err := userInteractor.ReactivateUser(context.Background(), v.actor, v.username, "")
+
+			v.assert(t, err)
+		})
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImpersonate(t *testing.T) {
+	mockRepo.On("FindByUsername", "root").Return(admin, nil).Once()
+	mockRepo.On("FindByUsername", "dabi").Return(customer, nil).Once()
+	mockRepo.On("RecordAdminAction", usecases.AdminAction{ActorID: 1, Action: interactor.ActionImpersonate, TargetID: 3, Reason: "ticket 12"}).
+		Return(nil).Once()
+	mockRepo.On("CreateSession", mock.MatchedBy(func(session usecases.NewSession) bool {
+		return session.UserID == 3 && session.ImpersonatorID == 1 &&
+			session.Tokens.RefreshExpiresAt.Equal(session.Tokens.AccessExpiresAt) &&
+			time.Until(session.Tokens.AccessExpiresAt) <= interactor.DefaultSessionPolicy.ImpersonationTTL
+	})).Return(int64(11), nil).Once()
+
+	tokens, err := userInteractor.Impersonate(context.Background(), "root", "dabi", "ticket 12")
+	require.NoError(t, err)
+	require.Equal(t, int64(11), tokens.SessionId)
+	require.True(t, strings.HasPrefix(tokens.AccessToken, interactor.AccessTokenPrefix))
+	require.Empty(t, tokens.RefreshToken, "impersonated sessions are not refreshed")
+
+	mockRepo.On("FindByUsername", "root").Return(admin, nil).Once()
+	mockRepo.On("FindByUsername", "boss").Return(otherAdmin, nil).Once()
+	_, err = userInteractor.Impersonate(context.Background(), "root", "boss", "ticket 13")
+	require.ErrorIs(t, err, interactor.ErrForbidden, "staff are not impersonated")
+
+	mockRepo.On("FindByUsername", "helper").Return(support, nil).Once()
+	_, err = userInteractor.Impersonate(context.Background(), "helper", "dabi", "ticket 14")
+	require.ErrorIs(t, err, interactor.ErrForbidden, "only admins impersonate")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSetRole(t *testing.T) {
+	mockRepo.On("FindByUsername", "root").Return(admin, nil).Once()
+	mockRepo.On("FindByUsername", "dabi").Return(customer, nil).Once()
+	mockRepo.On("SetRole", interactor.RoleSupport, usecases.AdminAction{ActorID: 1, Action: interactor.ActionSetRole, TargetID: 3}).
+		Return(nil).Once()
+	err := userInteractor.SetRole(context.Background(), "root", "dabi", interactor.RoleSupport, "")
+	require.NoError(t, err)
+
+	err = userInteractor.SetRole(context.Background(), "root", "dabi", "owner", "")
+	require.ErrorIs(t, err, interactor.ErrInvalidRole)
+
+	mockRepo.On("FindByUsername", "helper").Return(support, nil).Once()
+	err = userInteractor.SetRole(context.Background(), "helper", "dabi", interactor.RoleAdmin, "")
+	require.ErrorIs(t, err, interactor.ErrForbidden)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,150 @@
+package interactor
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/spriigan/RPApp/usecases/audit"
+	"github.com/spriigan/RPApp/usecases/repository"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+)
+
+// The actions this service writes to the audit log.
+const (
+	AuditUserCreate        = "user.create"
+	AuditUserUpdate        = "user.update"
+	AuditUserDelete        = "user.delete"
+	AuditUserDeactivate    = "user.deactivate"
+	AuditUserReactivate    = "user.reactivate"
+	AuditUserErase         = "user.erase"
+	AuditUserUnlock        = "user.unlock"
+	AuditUserSuspend       = "user.suspend"
+	AuditUserUnsuspend     = "user.unsuspend"
+	AuditUserPasswordReset = "user.force_password_reset"
+	AuditUserImpersonate   = "user.impersonate"
+	AuditUserSetRole       = "user.set_role"
+	AuditTotpEnable        = "totp.enable"
+	AuditTotpDisable       = "totp.disable"
+	AuditProfileUpdate     = "profile.update"
+	AuditSessionRevoke     = "session.revoke"
+	AuditSessionsRevokeAll = "session.revoke_all"
+	auditUser              = "user"
+	auditSession           = "session"
+)
+
+// SearchAuditLog lets admins look through what was done by whom, in this and
+// the other services.
+func (in *userInteractor) SearchAuditLog(ctx context.Context, actor string, query repository.AuditQuery) (*models.AuditEntries, error) {
+	if _, err := in.staff(ctx, actor, RoleAdmin); err != nil {
+		return nil, err
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultSearchLimit
+	}
+	if query.Limit > MaxSearchLimit {
+		query.Limit = MaxSearchLimit
+	}
+	entries, err := in.Repo.SearchAuditLog(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AuditEntries{Entries: entries}, nil
+}
+
+// audit records a change to the user with the given id, in the transaction
+// of ctx that makes the change. The log is append-only and EraseUser can't
+// take an entry back, so it keeps the user's id and only the names of the
+// personal fields that changed, never their values.
+func (in *userInteractor) audit(ctx context.Context, action string, userID int64, before, after map[string]interface{}) error {
+	before, after = withoutPersonal(before, after)
+	return audit.Record(ctx, in.Audit, audit.Entry{
+		Action:     action,
+		TargetType: auditUser,
+		TargetID:   strconv.FormatInt(userID, 10),
+		Before:     before,
+		After:      after,
+	})
+}
+
+// auditAdmin records an admin acting on target, the reason goes with the
+// changed fields.
+func (in *userInteractor) auditAdmin(ctx context.Context, name string, target *models.User, action repository.AdminAction, before, after map[string]interface{}) error {
+	if after == nil {
+		after = map[string]interface{}{}
+	}
+	if action.Reason != "" {
+		after["reason"] = action.Reason
+	}
+	return in.audit(ctx, name, target.Id, before, after)
+}
+
+func (in *userInteractor) auditSession(ctx context.Context, action string, sessionID int64, userID int64) error {
+	target := "all"
+	if sessionID != 0 {
+		target = strconv.FormatInt(sessionID, 10)
+	}
+	return audit.Record(ctx, in.Audit, audit.Entry{
+		Action:     action,
+		TargetType: auditSession,
+		TargetID:   target,
+		After:      map[string]interface{}{"userId": userID, "revoked": true},
+	})
+}
+
+// personalFields are the fields of a bio or profile that identify the user.
+var personalFields = map[string]bool{
+	"fname":       true,
+	"lname":       true,
+	"username":    true,
+	"email":       true,
+	"displayName": true,
+	"avatarUrl":   true,
+	"phone":       true,
+}
+
+// withoutPersonal keeps the fields that changed between before and after,
+// the personal ones by name only, listed under "changed".
+func withoutPersonal(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	before, after = audit.Changes(before, after)
+	seen := map[string]bool{}
+	for _, fields := range []map[string]interface{}{before, after} {
+		for k := range fields {
+			if personalFields[k] {
+				seen[k] = true
+				delete(fields, k)
+			}
+		}
+	}
+	if len(seen) > 0 {
+		changed := make([]string, 0, len(seen))
+		for k := range seen {
+			changed = append(changed, k)
+		}
+		sort.Strings(changed)
+		after["changed"] = changed
+	}
+	return before, after
+}
+
+// auditedBio is the bio as audit compares it, passwords are left out.
+func auditedBio(bio *models.UserBio) map[string]interface{} {
+	return map[string]interface{}{
+		"fname":    bio.GetFname(),
+		"lname":    bio.GetLname(),
+		"username": bio.GetUsername(),
+		"email":    bio.GetEmail(),
+	}
+}
+
+func auditedProfile(profile *models.Profile) map[string]interface{} {
+	return map[string]interface{}{
+		"displayName":    profile.GetDisplayName(),
+		"avatarUrl":      profile.GetAvatarUrl(),
+		"phone":          profile.GetPhone(),
+		"locale":         profile.GetLocale(),
+		"timezone":       profile.GetTimezone(),
+		"marketingEmail": profile.GetMarketing().GetEmail(),
+		"marketingSms":   profile.GetMarketing().GetSms(),
+	}
+}
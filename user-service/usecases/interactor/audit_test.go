@@ -0,0 +1,163 @@
+package interactor_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/spriigan/RPApp/usecases/audit"
+	"github.com/spriigan/RPApp/usecases/interactor"
+	usecases "github.com/spriigan/RPApp/usecases/repository"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+type auditRecorder struct {
+	entries []audit.Entry
+	err     error
+}
+
+func (r *auditRecorder) Record(ctx context.Context, entry audit.Entry) error {
+	r.entries = append(r.entries, entry)
+	return r.err
+}
+
+func TestAuditUpdate(t *testing.T) {
+	recorder := &auditRecorder{}
+	in := interactor.NewUserInteractor(mockRepo)
+	in.Audit = recorder
+	ctx := audit.WithRequest(context.Background(), audit.Request{ID: "req-1", IP: "10.0.0.1", Actor: "dabi@mail.com"})
+
+	mockRepo.On("FindById", int64(3)).Return(&models.User{Id: 3, Fname: "dabi", Username: "dabi", Email: "dabi@mail.com", Password: "hash"}, nil).Once()
+	mockRepo.On("Update", mock.Anything).Return(nil).Once()
+	err := in.Update(ctx, &models.UserPayload{
+		Bio:      &models.UserBio{Id: 3, Fname: "dabi", Username: "dabi", Email: "Touya@mail.com"},
+		Password: strongPassword,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, recorder.entries, 1)
+	entry := recorder.entries[0]
+	require.Equal(t, interactor.AuditUserUpdate, entry.Action)
+	require.Equal(t, "3", entry.TargetID)
+	require.Equal(t, "dabi@mail.com", entry.Actor)
+	require.Equal(t, "req-1", entry.RequestID)
+	// the log can't be erased, so it keeps only which personal fields changed
+	require.Equal(t, map[string]interface{}{}, entry.Before)
+	require.Equal(t, map[string]interface{}{"changed": []string{"email"}, "password": "set"}, entry.After)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuditProfile(t *testing.T) {
+	recorder := &auditRecorder{}
+	in := interactor.NewUserInteractor(mockRepo)
+	in.Audit = recorder
+
+	mockRepo.On("GetProfile", "dabi").Return(&models.Profile{DisplayName: "Dabi", Phone: "+14155550123", Locale: "en-US"}, nil).Once()
+	mockRepo.On("FindByUsername", "dabi").Return(customer, nil).Once()
+	mockRepo.On("SaveProfile", "dabi", mock.Anything).
+		Return(&models.Profile{DisplayName: "Touya", Phone: "+442079460958", Locale: "en-GB"}, nil).Once()
+	_, err := in.UpdateProfile(context.Background(), "dabi",
+		&models.Profile{DisplayName: "Touya", Phone: "+442079460958", Locale: "en-GB"},
+		&fieldmaskpb.FieldMask{Paths: []string{"displayName", "phone", "locale"}})
+	require.NoError(t, err)
+
+	require.Len(t, recorder.entries, 1)
+	entry := recorder.entries[0]
+	require.Equal(t, strconv.FormatInt(customer.Id, 10), entry.TargetID)
+	require.Equal(t, map[string]interface{}{"locale": "en-US"}, entry.Before)
+	require.Equal(t, map[string]interface{}{"locale": "en-GB", "changed": []string{"displayName", "phone"}}, entry.After)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuditSession(t *testing.T) {
+	recorder := &auditRecorder{}
+	in := interactor.NewUserInteractor(mockRepo)
+	in.Audit = recorder
+
+	mockRepo.On("FindByUsername", "dabi").Return(customer, nil).Once()
+	mockRepo.On("RevokeSession", customer.Id, int64(10)).Return(nil).Once()
+	require.NoError(t, in.RevokeSession(context.Background(), "dabi", 10))
+
+	require.Len(t, recorder.entries, 1)
+	require.Equal(t, "10", recorder.entries[0].TargetID)
+	require.Equal(t, map[string]interface{}{"userId": customer.Id, "revoked": true}, recorder.entries[0].After)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuditSetRole(t *testing.T) {
+	recorder := &auditRecorder{}
+	in := interactor.NewUserInteractor(mockRepo)
+	in.Audit = recorder
+
+	mockRepo.On("FindByUsername", "root").Return(admin, nil).Once()
+	mockRepo.On("FindByUsername", "dabi").Return(customer, nil).Once()
+	mockRepo.On("SetRole", interactor.RoleSupport, usecases.AdminAction{ActorID: 1, Action: interactor.ActionSetRole, TargetID: 3, Reason: "new hire"}).
+		Return(nil).Once()
+	require.NoError(t, in.SetRole(context.Background(), "root", "dabi", interactor.RoleSupport, "new hire"))
+
+	require.Len(t, recorder.entries, 1)
+	require.Equal(t, map[string]interface{}{"role": interactor.RoleUser}, recorder.entries[0].Before)
+	require.Equal(t, map[string]interface{}{"role": interactor.RoleSupport, "reason": "new hire"}, recorder.entries[0].After)
+
+	// nothing is recorded for an action that was refused
+	mockRepo.On("FindByUsername", "helper").Return(support, nil).Once()
+	require.ErrorIs(t, in.SetRole(context.Background(), "helper", "dabi", interactor.RoleSupport, ""), interactor.ErrForbidden)
+	require.Len(t, recorder.entries, 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuditFailureFailsTheAction(t *testing.T) {
+	in := interactor.NewUserInteractor(mockRepo)
+	in.Audit = &auditRecorder{err: errors.New("log is down")}
+
+	mockRepo.On("Deactivate", "dabi").Return(int64(3), nil).Once()
+	// the change is rolled back with the entry that could not be written
+	require.Error(t, in.DeactivateUser(context.Background(), "dabi"))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSearchAuditLog(t *testing.T) {
+	testTable := map[string]struct {
+		actor   string
+		query   usecases.AuditQuery
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.AuditEntries, err error)
+	}{
+		"succes call": {
+			actor: "root",
+			query: usecases.AuditQuery{TargetType: "user", TargetID: "dabi"},
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "root").Return(admin, nil).Once()
+				mockRepo.On("SearchAuditLog", usecases.AuditQuery{TargetType: "user", TargetID: "dabi", Limit: interactor.DefaultSearchLimit}).
+					Return([]*models.AuditEntry{{Id: 9, Action: interactor.AuditUserDelete}}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.AuditEntries, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Entries, 1)
+			},
+		},
+		"support staff": {
+			actor: "helper",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "helper").Return(support, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.AuditEntries, err error) {
+				require.ErrorIs(t, err, interactor.ErrForbidden)
+			},
+		},
+	}
+
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := userInteractor.SearchAuditLog(context.Background(), v.actor, v.query)
+
+			v.assert(t, actual, err)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package interactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("wrong username or password")
+	ErrLoginLocked        = errors.New("too many failed logins")
+	ErrUserSuspended      = errors.New("user is suspended")
+)
+
+// Failed logins are counted for the account and for the address they came
+// from, so neither guessing one password nor spraying many accounts works.
+const (
+	accountScope = "account"
+	addressScope = "address"
+)
+
+// LoginLimit is how many failures in a row a scope gets before it is slowed
+// down and before it is locked out.
+type LoginLimit struct {
+	Free    int
+	Lockout int
+}
+
+// LockoutPolicy decides how long an account or address waits after failed
+// logins. Past the free attempts the wait doubles from BaseDelay, and once
+// the lockout limit is hit it waits LockoutDuration.
+type LockoutPolicy struct {
+	Account         LoginLimit
+	Address         LoginLimit
+	BaseDelay       time.Duration
+	LockoutDuration time.Duration
+	// Window is how long a failure is remembered.
+	Window time.Duration
+}
+
+var DefaultLockoutPolicy = LockoutPolicy{
+	Account:         LoginLimit{Free: 3, Lockout: 10},
+	Address:         LoginLimit{Free: 20, Lockout: 100},
+	BaseDelay:       time.Second,
+	LockoutDuration: 15 * time.Minute,
+	Window:          time.Hour,
+}
+
+// wait is how long to hold back after the given number of failures.
+func (p LockoutPolicy) wait(limit LoginLimit, failures int) time.Duration {
+	if failures >= limit.Lockout {
+		return p.LockoutDuration
+	}
+	if failures <= limit.Free {
+		return 0
+	}
+	shift := failures - limit.Free - 1
+	if shift > 30 {
+		return p.LockoutDuration
+	}
+	wait := p.BaseDelay << shift
+	if wait > p.LockoutDuration {
+		return p.LockoutDuration
+	}
+	return wait
+}
+
+type SecurityEventKind string
+
+const (
+	AccountLocked   SecurityEventKind = "account_locked"
+	AddressLocked   SecurityEventKind = "address_locked"
+	AccountUnlocked SecurityEventKind = "account_unlocked"
+	// RefreshTokenReused means a refresh token came back after it was traded
+	// in, someone else holds a copy of it.
+	RefreshTokenReused SecurityEventKind = "refresh_token_reused"
+	SessionsRevoked    SecurityEventKind = "sessions_revoked"
+)
+
+type SecurityEvent struct {
+	Kind        SecurityEventKind
+	Subject     string
+	Failures    int
+	LockedUntil time.Time
+}
+
+// SecurityEvents is the hook that receives security events, it must not
+// block the login that raised them.
+type SecurityEvents interface {
+	Emit(ctx context.Context, event SecurityEvent)
+}
+
+// LogSecurityEvents only logs the events, it is used until a real sink is set.
+type LogSecurityEvents struct{}
+
+func (LogSecurityEvents) Emit(ctx context.Context, event SecurityEvent) {
+	log.Printf("security event %s for %s after %d failures, locked until %s", event.Kind, event.Subject, event.Failures, event.LockedUntil.Format(time.RFC3339))
+}
+
+// Login checks the password of the user and, when two-factor authentication
+// is on, the one-time code, then starts a session and returns its tokens.
+// Accounts and addresses with too many failed logins get ErrLoginLocked until
+// their wait is over, even with the right password.
+func (in *userInteractor) Login(ctx context.Context, credentials *models.Credentials) (*models.User, *models.AuthTokens, error) {
+	now := time.Now()
+	account := strings.ToLower(strings.TrimSpace(credentials.Username))
+	for _, key := range in.loginKeys(account, credentials.Ip) {
+		until, err := in.Repo.LockedUntil(ctx, key.scope, key.subject)
+		if err != nil {
+			return nil, nil, err
+		}
+		if until.After(now) {
+			return nil, nil, fmt.Errorf("%w, try again after %s", ErrLoginLocked, until.Format(time.RFC3339))
+		}
+	}
+
+	var match, rehash bool
+	user, err := in.Repo.FindByUsername(ctx, strings.TrimSpace(credentials.Username))
+	if err == nil {
+		match, rehash, err = in.Passwords.Verify(credentials.Password, user.Password)
+	} else {
+		// hashing the password anyway takes an unknown username as long as a
+		// wrong password, so the time of the answer does not tell which
+		// accounts exist
+		in.Passwords.Hash(credentials.Password)
+	}
+	if err != nil || !match {
+		return nil, nil, in.loginFailed(ctx, account, credentials.Ip, now, ErrInvalidCredentials)
+	}
+	if _, err = in.verifySecondFactor(ctx, user.Id, credentials.Otp); err != nil {
+		if errors.Is(err, ErrInvalidOtp) {
+			return nil, nil, in.loginFailed(ctx, account, credentials.Ip, now, err)
+		}
+		return nil, nil, err
+	}
+	// only told once the password is right, so it does not give away which
+	// accounts are suspended
+	if user.SuspendedAt != nil {
+		return nil, nil, ErrUserSuspended
+	}
+	if err = in.Repo.ClearLoginFailures(ctx, accountScope, account); err != nil {
+		return nil, nil, err
+	}
+	if rehash {
+		in.rehashPassword(ctx, user.Id, credentials.Password)
+	}
+	tokens, err := in.startSession(ctx, user, credentials)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// rehashPassword stores the password hashed the way new passwords are. It only
+// logs a failure, the old hash still works.
+func (in *userInteractor) rehashPassword(ctx context.Context, id int64, password string) {
+	hash, err := in.Passwords.Hash(password)
+	if err == nil {
+		err = in.Repo.SetPasswordHash(ctx, id, hash)
+	}
+	if err != nil {
+		log.Printf("could not rehash the password of user %d: %v", id, err)
+	}
+}
+
+// UnlockUser lets a locked out account log in again straight away, only
+// staff may do it.
+func (in *userInteractor) UnlockUser(ctx context.Context, actor, username, reason string) error {
+	action, target, err := in.adminAction(ctx, actor, username, reason, RoleSupport, ActionUnlock)
+	if err != nil {
+		return err
+	}
+	account := strings.ToLower(target.Username)
+	err = in.Repo.InTx(ctx, func(ctx context.Context) error {
+		if err := in.Repo.RecordAdminAction(ctx, action); err != nil {
+			return err
+		}
+		if err := in.Repo.ClearLoginFailures(ctx, accountScope, account); err != nil {
+			return err
+		}
+		return in.auditAdmin(ctx, AuditUserUnlock, target, action, nil, map[string]interface{}{"locked": false})
+	})
+	if err != nil {
+		return err
+	}
+	in.Events.Emit(ctx, SecurityEvent{Kind: AccountUnlocked, Subject: account})
+	return nil
+}
+
+type loginKey struct {
+	scope   string
+	subject string
+	limit   LoginLimit
+	event   SecurityEventKind
+}
+
+func (in *userInteractor) loginKeys(account, ip string) []loginKey {
+	keys := []loginKey{{scope: accountScope, subject: account, limit: in.Lockout.Account, event: AccountLocked}}
+	if ip != "" {
+		keys = append(keys, loginKey{scope: addressScope, subject: ip, limit: in.Lockout.Address, event: AddressLocked})
+	}
+	return keys
+}
+
+// loginFailed counts the failure and returns reason, or the error that kept it
+// from being counted.
+func (in *userInteractor) loginFailed(ctx context.Context, account, ip string, now time.Time, reason error) error {
+	for _, key := range in.loginKeys(account, ip) {
+		failures, err := in.Repo.RecordLoginFailure(ctx, key.scope, key.subject, now, now.Add(-in.Lockout.Window))
+		if err != nil {
+			return err
+		}
+		wait := in.Lockout.wait(key.limit, failures)
+		if wait == 0 {
+			continue
+		}
+		until := now.Add(wait)
+		if err = in.Repo.LockLogin(ctx, key.scope, key.subject, until); err != nil {
+			return err
+		}
+		if failures >= key.limit.Lockout {
+			in.Events.Emit(ctx, SecurityEvent{Kind: key.event, Subject: key.subject, Failures: failures, LockedUntil: until})
+		}
+	}
+	return reason
+}
@@ -0,0 +1,210 @@
+package interactor_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spriigan/RPApp/interface/repository"
+	"github.com/spriigan/RPApp/usecases/interactor"
+	usecases "github.com/spriigan/RPApp/usecases/repository"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// recordingEvents keeps the security events it is given.
+type recordingEvents struct {
+	events []interactor.SecurityEvent
+}
+
+func (r *recordingEvents) Emit(ctx context.Context, event interactor.SecurityEvent) {
+	r.events = append(r.events, event)
+}
+
+// countingHasher counts the passwords it hashes.
+type countingHasher struct {
+	interactor.PasswordHasher
+	hashed int
+}
+
+func (h *countingHasher) Hash(password string) (string, error) {
+	h.hashed++
+	return h.PasswordHasher.Hash(password)
+}
+
+func TestLogin(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &models.User{Id: 3, Username: "Dabi", Password: string(hash)}
+	unlocked := func() {
+		mockRepo.On("LockedUntil", "account", "dabi").Return(time.Time{}, nil).Once()
+		mockRepo.On("LockedUntil", "address", "10.0.0.1").Return(time.Time{}, nil).Once()
+	}
+	events := &recordingEvents{}
+	in := interactor.NewUserInteractor(mockRepo)
+	in.Events = events
+	hasher := &countingHasher{PasswordHasher: in.Passwords}
+	in.Passwords = hasher
+
+	testTable := map[string]struct {
+		password string
+		arrange  func(t *testing.T)
+		assert   func(t *testing.T, actual *models.User, err error)
+	}{
+		"succes call": {
+			password: "secret",
+			arrange: func(t *testing.T) {
+				unlocked()
+				mockRepo.On("FindByUsername", "Dabi").Return(user, nil).Once()
+				mockRepo.On("FindTotp", int64(3)).Return("", false, nil).Once()
+				mockRepo.On("ClearLoginFailures", "account", "dabi").Return(nil).Once()
+				mockRepo.On("SetPasswordHash", int64(3), mock.MatchedBy(func(hash string) bool {
+					return strings.HasPrefix(hash, "$argon2id$")
+				})).Return(nil).Once()
+				mockRepo.On("CreateSession", mock.MatchedBy(func(session usecases.NewSession) bool {
+					return session.UserID == 3 && session.Ip == "10.0.0.1" && session.Device == "phone"
+				})).Return(int64(9), nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(3), actual.Id)
+			},
+		},
+		"wrong password": {
+			password: "guess",
+			arrange: func(t *testing.T) {
+				unlocked()
+				mockRepo.On("FindByUsername", "Dabi").Return(user, nil).Once()
+				mockRepo.On("RecordLoginFailure", "account", "dabi").Return(2, nil).Once()
+				mockRepo.On("RecordLoginFailure", "address", "10.0.0.1").Return(2, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidCredentials)
+				require.Nil(t, actual)
+			},
+		},
+		"suspended": {
+			password: "secret",
+			arrange: func(t *testing.T) {
+				unlocked()
+				suspended := &models.User{Id: 3, Username: "Dabi", Password: user.Password, SuspendedAt: timestamppb.Now()}
+				mockRepo.On("FindByUsername", "Dabi").Return(suspended, nil).Once()
+				mockRepo.On("FindTotp", int64(3)).Return("", false, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.ErrorIs(t, err, interactor.ErrUserSuspended)
+				require.Nil(t, actual)
+			},
+		},
+		"unknown user": {
+			password: "secret",
+			arrange: func(t *testing.T) {
+				unlocked()
+				mockRepo.On("FindByUsername", "Dabi").Return(nil, repository.ErrNoUserFound).Once()
+				mockRepo.On("RecordLoginFailure", "account", "dabi").Return(1, nil).Once()
+				mockRepo.On("RecordLoginFailure", "address", "10.0.0.1").Return(1, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidCredentials)
+				// as slow as a wrong password
+				require.Equal(t, 1, hasher.hashed)
+			},
+		},
+		"backing off": {
+			password: "guess",
+			arrange: func(t *testing.T) {
+				unlocked()
+				mockRepo.On("FindByUsername", "Dabi").Return(user, nil).Once()
+				mockRepo.On("RecordLoginFailure", "account", "dabi").Return(6, nil).Once()
+				mockRepo.On("RecordLoginFailure", "address", "10.0.0.1").Return(6, nil).Once()
+				mockRepo.On("LockLogin", "account", "dabi", mock.MatchedBy(func(until time.Time) bool {
+					wait := time.Until(until)
+					return wait > 3*time.Second && wait <= 4*time.Second
+				})).Return(nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidCredentials)
+				require.Empty(t, events.events, "a delay is no lockout")
+			},
+		},
+		"locked out": {
+			password: "guess",
+			arrange: func(t *testing.T) {
+				unlocked()
+				mockRepo.On("FindByUsername", "Dabi").Return(user, nil).Once()
+				mockRepo.On("RecordLoginFailure", "account", "dabi").Return(10, nil).Once()
+				mockRepo.On("RecordLoginFailure", "address", "10.0.0.1").Return(10, nil).Once()
+				mockRepo.On("LockLogin", "account", "dabi", mock.Anything).Return(nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidCredentials)
+				require.Len(t, events.events, 1)
+				require.Equal(t, interactor.AccountLocked, events.events[0].Kind)
+				require.Equal(t, "dabi", events.events[0].Subject)
+			},
+		},
+		"still locked": {
+			password: "secret",
+			arrange: func(t *testing.T) {
+				mockRepo.On("LockedUntil", "account", "dabi").Return(time.Now().Add(time.Minute), nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.ErrorIs(t, err, interactor.ErrLoginLocked, "even with the right password")
+			},
+		},
+		"fail call": {
+			password: "secret",
+			arrange: func(t *testing.T) {
+				mockRepo.On("LockedUntil", "account", "dabi").Return(time.Time{}, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.Error(t, err)
+				require.NotErrorIs(t, err, interactor.ErrInvalidCredentials)
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			events.events = nil
+			hasher.hashed = 0
+			v.arrange(t)
+
+			result, _, err := in.Login(ctx, &models.Credentials{Username: " Dabi", Password: v.password, Ip: "10.0.0.1", Device: "phone"})
+
+			v.assert(t, result, err)
+		})
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUnlockUser(t *testing.T) {
+	events := &recordingEvents{}
+	in := interactor.NewUserInteractor(mockRepo)
+	in.Events = events
+	helper := &models.User{Id: 2, Username: "helper", Role: interactor.RoleSupport}
+	mockRepo.On("FindByUsername", "helper").Return(helper, nil).Once()
+	mockRepo.On("FindByUsername", "Dabi").Return(&models.User{Id: 3, Username: "Dabi", Role: interactor.RoleUser}, nil).Once()
+	mockRepo.On("RecordAdminAction", usecases.AdminAction{ActorID: 2, Action: interactor.ActionUnlock, TargetID: 3, Reason: "called support"}).Return(nil).Once()
+	mockRepo.On("ClearLoginFailures", "account", "dabi").Return(nil).Once()
+
+	err := in.UnlockUser(context.Background(), "helper", "Dabi", "called support")
+
+	require.NoError(t, err)
+	require.Len(t, events.events, 1)
+	require.Equal(t, interactor.AccountUnlocked, events.events[0].Kind)
+
+	mockRepo.On("FindByUsername", "Dabi").Return(&models.User{Id: 3, Username: "Dabi", Role: interactor.RoleUser}, nil).Once()
+	err = in.UnlockUser(context.Background(), "Dabi", "Dabi", "")
+
+	require.ErrorIs(t, err, interactor.ErrForbidden, "users can't unlock accounts")
+	require.Len(t, events.events, 1)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,184 @@
+package interactor
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrPasswordTooLong = errors.New("password is too long")
+	// ErrUnknownPasswordHash means a stored hash is in no format a
+	// PasswordHasher reads, such a password can not be verified.
+	ErrUnknownPasswordHash = errors.New("password hash is in an unknown format")
+)
+
+// MaxPasswordLength is the longest password in bytes that is hashed, longer
+// ones are refused rather than cut.
+const MaxPasswordLength = 1024
+
+// bcryptMaxLength is as many bytes of a password as bcrypt looks at.
+const bcryptMaxLength = 72
+
+// The algorithms passwords can be hashed with.
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+// PasswordHasher turns passwords into encoded hashes that carry their
+// algorithm and parameters, in the PHC string format for argon2id and the
+// modular crypt format for bcrypt.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches the encoded hash and whether
+	// the hash should be replaced, because it was made with another algorithm
+	// or other parameters than new hashes are.
+	Verify(password, encoded string) (match bool, rehash bool, err error)
+}
+
+// Argon2idParams are the cost parameters of argon2id, Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// PasswordParams choose the algorithm new passwords are hashed with and its
+// cost. Hashes of either algorithm are verified whichever is chosen.
+type PasswordParams struct {
+	Algorithm  string
+	Argon2id   Argon2idParams
+	BcryptCost int
+}
+
+// DefaultPasswordParams hash with argon2id at the cost OWASP recommends.
+var DefaultPasswordParams = PasswordParams{
+	Algorithm: Argon2id,
+	Argon2id: Argon2idParams{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	},
+	BcryptCost: bcrypt.DefaultCost,
+}
+
+type passwordHasher struct {
+	params PasswordParams
+}
+
+// NewPasswordHasher fails when params name an unknown algorithm or a cost
+// it can not hash with.
+func NewPasswordHasher(params PasswordParams) (PasswordHasher, error) {
+	switch params.Algorithm {
+	case Argon2id:
+		p := params.Argon2id
+		if p.Memory < 8*uint32(p.Parallelism) || p.Iterations == 0 || p.Parallelism == 0 || p.SaltLength < 8 || p.KeyLength < 16 {
+			return nil, fmt.Errorf("argon2id parameters %+v are too weak", p)
+		}
+	case Bcrypt:
+		if params.BcryptCost < bcrypt.MinCost || params.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost %d is out of range", params.BcryptCost)
+		}
+	default:
+		return nil, fmt.Errorf("unknown password hashing algorithm %q", params.Algorithm)
+	}
+	return &passwordHasher{params: params}, nil
+}
+
+func (h *passwordHasher) Hash(password string) (string, error) {
+	if len(password) > MaxPasswordLength {
+		return "", ErrPasswordTooLong
+	}
+	if h.params.Algorithm == Bcrypt {
+		if len(password) > bcryptMaxLength {
+			return "", fmt.Errorf("%w for bcrypt, at most %d bytes are allowed", ErrPasswordTooLong, bcryptMaxLength)
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.params.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+
+	p := h.params.Argon2id
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *passwordHasher) Verify(password, encoded string) (bool, bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return h.verifyArgon2id(password, encoded)
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return h.verifyBcrypt(password, encoded)
+	}
+	return false, false, ErrUnknownPasswordHash
+}
+
+func (h *passwordHasher) verifyArgon2id(password, encoded string) (bool, bool, error) {
+	var version int
+	var p Argon2idParams
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return false, false, ErrUnknownPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, ErrUnknownPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return false, false, ErrUnknownPasswordHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, ErrUnknownPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 || p.Iterations == 0 || p.Parallelism == 0 {
+		return false, false, ErrUnknownPasswordHash
+	}
+	p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+	if len(password) > MaxPasswordLength {
+		return false, false, nil
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return false, false, nil
+	}
+	return true, h.params.Algorithm != Argon2id || p != h.params.Argon2id, nil
+}
+
+func (h *passwordHasher) verifyBcrypt(password, encoded string) (bool, bool, error) {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return false, false, ErrUnknownPasswordHash
+	}
+	// bcrypt refuses to hash longer passwords, so none can match
+	if len(password) > bcryptMaxLength {
+		return false, false, nil
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, ErrUnknownPasswordHash
+	}
+	return true, h.params.Algorithm != Bcrypt || cost != h.params.BcryptCost, nil
+}
@@ -0,0 +1,174 @@
+package interactor
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+)
+
+// ErrWeakPassword is matched by every PasswordError.
+var ErrWeakPassword = errors.New("password does not meet the policy")
+
+// The rules a password can break.
+const (
+	RuleMinLength        = "min_length"
+	RuleMaxLength        = "max_length"
+	RuleCharacterClasses = "character_classes"
+	RuleContainsIdentity = "contains_identity"
+	RuleBreached         = "breached"
+)
+
+// PasswordViolation is one rule a password broke.
+type PasswordViolation struct {
+	Rule        string
+	Description string
+}
+
+// PasswordError lists every rule a password broke, so they can all be fixed
+// at once.
+type PasswordError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordError) Error() string {
+	descriptions := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		descriptions = append(descriptions, v.Description)
+	}
+	return fmt.Sprintf("%s: %s", ErrWeakPassword, strings.Join(descriptions, "; "))
+}
+
+func (e *PasswordError) Is(target error) bool {
+	return target == ErrWeakPassword
+}
+
+// PasswordPolicy is what a new password must satisfy. Lengths count
+// characters, not bytes. MinClasses is how many of lower case letters, upper
+// case letters, digits and symbols a password mixes at least.
+type PasswordPolicy struct {
+	MinLength  int
+	MaxLength  int
+	MinClasses int
+	// RejectIdentity refuses passwords that contain the username or the
+	// email address.
+	RejectIdentity bool
+	// RejectBreached refuses passwords found in the breached password
+	// dataset, when one is configured.
+	RejectBreached bool
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:      10,
+	MaxLength:      128,
+	MinClasses:     2,
+	RejectIdentity: true,
+	RejectBreached: true,
+}
+
+// minIdentityLength keeps very short usernames from ruling out most
+// passwords.
+const minIdentityLength = 3
+
+// check returns the rules the password breaks for the user it belongs to.
+func (p PasswordPolicy) check(password string, bio *models.UserBio) []PasswordViolation {
+	var violations []PasswordViolation
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, PasswordViolation{
+			Rule:        RuleMinLength,
+			Description: fmt.Sprintf("must be at least %d characters long", p.MinLength),
+		})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, PasswordViolation{
+			Rule:        RuleMaxLength,
+			Description: fmt.Sprintf("must be at most %d characters long", p.MaxLength),
+		})
+	}
+	if characterClasses(password) < p.MinClasses {
+		violations = append(violations, PasswordViolation{
+			Rule:        RuleCharacterClasses,
+			Description: fmt.Sprintf("must mix at least %d of lower case letters, upper case letters, digits and symbols", p.MinClasses),
+		})
+	}
+	if p.RejectIdentity && containsIdentity(password, bio) {
+		violations = append(violations, PasswordViolation{
+			Rule:        RuleContainsIdentity,
+			Description: "must not contain the username or email address",
+		})
+	}
+	return violations
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+func containsIdentity(password string, bio *models.UserBio) bool {
+	password = strings.ToLower(password)
+	email := strings.ToLower(bio.GetEmail())
+	local, _, _ := strings.Cut(email, "@")
+	for _, identity := range []string{strings.ToLower(bio.GetUsername()), email, local} {
+		if utf8.RuneCountInString(identity) >= minIdentityLength && strings.Contains(password, identity) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPassword returns a PasswordError when the password breaks the policy
+// or was found in a breach.
+func (in *userInteractor) checkPassword(ctx context.Context, password string, bio *models.UserBio) error {
+	violations := in.PasswordPolicy.check(password, bio)
+	if in.PasswordPolicy.RejectBreached && in.Breached != nil {
+		breached, err := in.isBreached(ctx, password)
+		if err != nil {
+			// the dataset only adds to the policy, a password is not refused
+			// because it could not be read
+			log.Printf("could not check the password against the breached passwords: %v", err)
+		}
+		if breached {
+			violations = append(violations, PasswordViolation{
+				Rule:        RuleBreached,
+				Description: "has appeared in a data breach, choose another one",
+			})
+		}
+	}
+	if len(violations) > 0 {
+		return &PasswordError{Violations: violations}
+	}
+	return nil
+}
+
+// isBreached asks for the range of the password's SHA-1 prefix and looks for
+// the rest of the hash in it.
+func (in *userInteractor) isBreached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes, err := in.Breached.Range(ctx, hash[:5])
+	if err != nil {
+		return false, err
+	}
+	return suffixes[hash[5:]] > 0, nil
+}
@@ -0,0 +1,97 @@
+package interactor_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spriigan/RPApp/usecases/interactor"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// breachedRanges serves the range of the one breached password it holds and
+// remembers which prefixes it was asked for.
+type breachedRanges struct {
+	password string
+	asked    []string
+	err      error
+}
+
+func (b *breachedRanges) Range(ctx context.Context, prefix string) (map[string]int, error) {
+	b.asked = append(b.asked, prefix)
+	if b.err != nil {
+		return nil, b.err
+	}
+	sum := sha1.Sum([]byte(b.password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	if hash[:5] != prefix {
+		return map[string]int{}, nil
+	}
+	return map[string]int{hash[5:]: 42, "0000000000000000000000000000000000A": 1}, nil
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	breached := &breachedRanges{password: "Password123!"}
+	in := interactor.NewUserInteractor(mockRepo)
+	in.Breached = breached
+	bio := func() *models.UserBio {
+		return &models.UserBio{Username: "dabi", Email: "Touya.Todoroki@mail.com"}
+	}
+
+	testTable := map[string]struct {
+		password string
+		rules    []string
+	}{
+		"strong":           {password: strongPassword},
+		"too short":        {password: "aB3$", rules: []string{interactor.RuleMinLength}},
+		"too long":         {password: strings.Repeat("aB3$", 33), rules: []string{interactor.RuleMaxLength}},
+		"one class":        {password: "lowercaseonly", rules: []string{interactor.RuleCharacterClasses}},
+		"empty":            {password: "", rules: []string{interactor.RuleMinLength, interactor.RuleCharacterClasses}},
+		"has the username": {password: "my-DABI-password", rules: []string{interactor.RuleContainsIdentity}},
+		"has the email":    {password: "touya.todoroki-7", rules: []string{interactor.RuleContainsIdentity}},
+		"breached":         {password: "Password123!", rules: []string{interactor.RuleBreached}},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			if v.rules == nil {
+				mockRepo.On("Create", mock.Anything).Return(1, nil).Once()
+			}
+
+			_, err := in.Create(context.Background(), &models.UserPayload{Bio: bio(), Password: v.password})
+
+			if v.rules == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, interactor.ErrWeakPassword)
+			var pe *interactor.PasswordError
+			require.True(t, errors.As(err, &pe))
+			rules := make([]string, 0, len(pe.Violations))
+			for _, violation := range pe.Violations {
+				rules = append(rules, violation.Rule)
+			}
+			require.Equal(t, v.rules, rules)
+		})
+	}
+	for _, prefix := range breached.asked {
+		require.Len(t, prefix, 5, "only the prefix of the hash leaves the interactor")
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPasswordPolicyWithoutDataset(t *testing.T) {
+	in := interactor.NewUserInteractor(mockRepo)
+	in.Breached = &breachedRanges{err: errors.New("file is gone")}
+	mockRepo.On("FindById", int64(0)).Return(&models.User{Username: "dabi"}, nil).Once()
+	mockRepo.On("Update", mock.Anything).Return(nil).Once()
+
+	err := in.Update(context.Background(), &models.UserPayload{Bio: &models.UserBio{Username: "dabi"}, Password: strongPassword})
+
+	require.NoError(t, err, "a password is not refused because the dataset could not be read")
+	mockRepo.AssertExpectations(t)
+}
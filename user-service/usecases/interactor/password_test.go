@@ -0,0 +1,101 @@
+package interactor_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spriigan/RPApp/usecases/interactor"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// weakArgon2id keeps the tests fast.
+var weakArgon2id = interactor.Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestPasswordHasher(t *testing.T) {
+	argon, err := interactor.NewPasswordHasher(interactor.PasswordParams{Algorithm: interactor.Argon2id, Argon2id: weakArgon2id})
+	require.NoError(t, err)
+	stronger := weakArgon2id
+	stronger.Iterations = 2
+	strongerArgon, err := interactor.NewPasswordHasher(interactor.PasswordParams{Algorithm: interactor.Argon2id, Argon2id: stronger})
+	require.NoError(t, err)
+	bcryptHasher, err := interactor.NewPasswordHasher(interactor.PasswordParams{Algorithm: interactor.Bcrypt, BcryptCost: bcrypt.MinCost})
+	require.NoError(t, err)
+
+	argonHash, err := argon.Hash("secret")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(argonHash, "$argon2id$v=19$m=64,t=1,p=1$"))
+	other, err := argon.Hash("secret")
+	require.NoError(t, err)
+	require.NotEqual(t, argonHash, other, "every hash gets its own salt")
+	bcryptHash, err := bcryptHasher.Hash("secret")
+	require.NoError(t, err)
+
+	testTable := map[string]struct {
+		hasher   interactor.PasswordHasher
+		password string
+		encoded  string
+		match    bool
+		rehash   bool
+		err      error
+	}{
+		"argon2id":                    {hasher: argon, password: "secret", encoded: argonHash, match: true},
+		"argon2id wrong password":     {hasher: argon, password: "guess", encoded: argonHash},
+		"argon2id with old params":    {hasher: strongerArgon, password: "secret", encoded: argonHash, match: true, rehash: true},
+		"bcrypt":                      {hasher: bcryptHasher, password: "secret", encoded: bcryptHash, match: true},
+		"bcrypt moved to argon2id":    {hasher: argon, password: "secret", encoded: bcryptHash, match: true, rehash: true},
+		"bcrypt wrong password":       {hasher: argon, password: "guess", encoded: bcryptHash},
+		"bcrypt past 72 bytes":        {hasher: argon, password: "secret" + strings.Repeat("x", 80), encoded: bcryptHash},
+		"empty hash":                  {hasher: argon, password: "secret", encoded: "", err: interactor.ErrUnknownPasswordHash},
+		"malformed argon2id":          {hasher: argon, password: "secret", encoded: "$argon2id$v=19$m=64$salt", err: interactor.ErrUnknownPasswordHash},
+		"argon2id of another version": {hasher: argon, password: "secret", encoded: strings.Replace(argonHash, "v=19", "v=16", 1), err: interactor.ErrUnknownPasswordHash},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			match, rehash, err := v.hasher.Verify(v.password, v.encoded)
+
+			require.ErrorIs(t, err, v.err)
+			require.Equal(t, v.match, match)
+			require.Equal(t, v.rehash, rehash)
+		})
+	}
+}
+
+func TestPasswordTooLong(t *testing.T) {
+	bcryptHasher, err := interactor.NewPasswordHasher(interactor.PasswordParams{Algorithm: interactor.Bcrypt, BcryptCost: bcrypt.MinCost})
+	require.NoError(t, err)
+	_, err = bcryptHasher.Hash(strings.Repeat("x", 73))
+	require.ErrorIs(t, err, interactor.ErrPasswordTooLong)
+
+	argon, err := interactor.NewPasswordHasher(interactor.PasswordParams{Algorithm: interactor.Argon2id, Argon2id: weakArgon2id})
+	require.NoError(t, err)
+	_, err = argon.Hash(strings.Repeat("x", 73))
+	require.NoError(t, err, "argon2id has no such limit")
+	_, err = argon.Hash(strings.Repeat("x", interactor.MaxPasswordLength+1))
+	require.ErrorIs(t, err, interactor.ErrPasswordTooLong)
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	_, err := interactor.NewPasswordHasher(interactor.DefaultPasswordParams)
+	require.NoError(t, err)
+	_, err = interactor.NewPasswordHasher(interactor.PasswordParams{Algorithm: "md5"})
+	require.Error(t, err)
+	_, err = interactor.NewPasswordHasher(interactor.PasswordParams{Algorithm: interactor.Bcrypt, BcryptCost: 2})
+	require.Error(t, err)
+	_, err = interactor.NewPasswordHasher(interactor.PasswordParams{Algorithm: interactor.Argon2id})
+	require.Error(t, err)
+}
+
+func TestCreateHashesPassword(t *testing.T) {
+	mockRepo.On("Create", mock.MatchedBy(func(user *models.UserPayload) bool {
+		return strings.HasPrefix(user.Password, "$argon2id$") && !strings.Contains(user.Password, strongPassword)
+	})).Return(1, nil).Once()
+
+	_, err := userInteractor.Create(context.Background(), &models.UserPayload{Bio: &models.UserBio{Username: "ryan"}, Password: strongPassword})
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,154 @@
+package interactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"golang.org/x/text/language"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+var ErrInvalidProfile = errors.New("invalid profile")
+
+const (
+	maxDisplayName = 100
+	maxAvatarUrl   = 2048
+	// avatarDir is the directory the broker stores uploaded avatars in, its
+	// AvatarPrefix. An avatar anywhere else was not uploaded through it.
+	avatarDir = "avatars"
+)
+
+// e164 is a plus sign and up to 15 digits, the first of them not a zero.
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// The fields of a profile an update mask can name.
+var profileFields = map[string]func(dst, src *models.Profile){
+	"displayName": func(dst, src *models.Profile) { dst.DisplayName = src.DisplayName },
+	"avatarUrl":   func(dst, src *models.Profile) { dst.AvatarUrl = src.AvatarUrl },
+	"phone":       func(dst, src *models.Profile) { dst.Phone = src.Phone },
+	"locale":      func(dst, src *models.Profile) { dst.Locale = src.Locale },
+	"timezone":    func(dst, src *models.Profile) { dst.Timezone = src.Timezone },
+	"marketing":   func(dst, src *models.Profile) { dst.Marketing = src.Marketing },
+}
+
+func (in *userInteractor) GetProfile(ctx context.Context, username string) (*models.Profile, error) {
+	return in.Repo.GetProfile(ctx, strings.TrimSpace(username))
+}
+
+// UpdateProfile changes the fields named in mask, or every field when mask is
+// empty, and checks the profile they make before it is saved.
+func (in *userInteractor) UpdateProfile(ctx context.Context, username string, profile *models.Profile, mask *fieldmaskpb.FieldMask) (*models.Profile, error) {
+	username = strings.TrimSpace(username)
+	if profile == nil {
+		profile = &models.Profile{}
+	}
+	paths := mask.GetPaths()
+	for _, path := range paths {
+		if profileFields[path] == nil {
+			return nil, fmt.Errorf("%w: %q is not a field of the profile", ErrInvalidProfile, path)
+		}
+	}
+
+	updated := profile
+	var before map[string]interface{}
+	if len(paths) > 0 {
+		current, err := in.Repo.GetProfile(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		before = auditedProfile(current)
+		for _, path := range paths {
+			profileFields[path](current, profile)
+		}
+		updated = current
+	}
+	if err := normalizeProfile(updated); err != nil {
+		return nil, err
+	}
+	// the audit log knows the user by id
+	user, err := in.Repo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	var saved *models.Profile
+	err = in.Repo.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		saved, err = in.Repo.SaveProfile(ctx, username, updated)
+		if err != nil {
+			return err
+		}
+		return in.audit(ctx, AuditProfileUpdate, user.Id, before, auditedProfile(saved))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// normalizeProfile trims the profile, puts its phone, locale and time zone in
+// their canonical form and rejects it when any of them is not valid.
+func normalizeProfile(profile *models.Profile) error {
+	profile.DisplayName = strings.TrimSpace(profile.DisplayName)
+	if utf8.RuneCountInString(profile.DisplayName) > maxDisplayName {
+		return fmt.Errorf("%w: display name must be at most %d characters long", ErrInvalidProfile, maxDisplayName)
+	}
+
+	profile.AvatarUrl = strings.TrimSpace(profile.AvatarUrl)
+	if profile.AvatarUrl != "" {
+		u, err := url.Parse(profile.AvatarUrl)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || len(profile.AvatarUrl) > maxAvatarUrl {
+			return fmt.Errorf("%w: avatar must be an http or https url", ErrInvalidProfile)
+		}
+		if !isUploadedAvatar(u) {
+			return fmt.Errorf("%w: avatar must be uploaded", ErrInvalidProfile)
+		}
+	}
+
+	profile.Phone = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "").Replace(profile.Phone)
+	if profile.Phone != "" && !e164.MatchString(profile.Phone) {
+		return fmt.Errorf("%w: phone must be in E.164 format, like +14155550123", ErrInvalidProfile)
+	}
+
+	profile.Locale = strings.TrimSpace(profile.Locale)
+	if profile.Locale != "" {
+		tag, err := language.Parse(profile.Locale)
+		if err != nil {
+			return fmt.Errorf("%w: locale must be a language tag, like en-US", ErrInvalidProfile)
+		}
+		profile.Locale = tag.String()
+	}
+
+	profile.Timezone = strings.TrimSpace(profile.Timezone)
+	if profile.Timezone != "" {
+		// Local names the zone of the server, not one the user lives in
+		if _, err := time.LoadLocation(profile.Timezone); err != nil || profile.Timezone == "Local" {
+			return fmt.Errorf("%w: timezone must be an IANA time zone, like Europe/Berlin", ErrInvalidProfile)
+		}
+	}
+
+	if profile.Marketing == nil {
+		profile.Marketing = &models.MarketingPreferences{}
+	}
+	if profile.Marketing.Sms && profile.Phone == "" {
+		return fmt.Errorf("%w: text message marketing needs a phone number", ErrInvalidProfile)
+	}
+	return nil
+}
+
+// isUploadedAvatar reports whether u names a file in the avatar directory of
+// the broker, without a query or a path that climbs out of it.
+func isUploadedAvatar(u *url.URL) bool {
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" || path.Clean(u.Path) != u.Path {
+		return false
+	}
+	dir, file := path.Split(u.Path)
+	return file != "" && path.Base(dir) == avatarDir
+}
@@ -0,0 +1,156 @@
+package interactor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spriigan/RPApp/interface/repository"
+	"github.com/spriigan/RPApp/usecases/interactor"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	current := func() *models.Profile {
+		return &models.Profile{
+			DisplayName: "Dabi",
+			Phone:       "+14155550123",
+			Locale:      "en-US",
+			Timezone:    "America/Los_Angeles",
+			Marketing:   &models.MarketingPreferences{Email: true},
+		}
+	}
+	testTable := map[string]struct {
+		profile *models.Profile
+		paths   []string
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.Profile, err error)
+	}{
+		"succes call": {
+			profile: &models.Profile{Phone: "+44 (20) 7946-0958", Locale: "pt_br"},
+			paths:   []string{"phone"},
+			arrange: func(t *testing.T) {
+				mockRepo.On("GetProfile", "dabi").Return(current(), nil).Once()
+				mockRepo.On("FindByUsername", "dabi").Return(customer, nil).Once()
+				mockRepo.On("SaveProfile", "dabi", mock.MatchedBy(func(p *models.Profile) bool {
+					// only the masked field changes
+					return p.Phone == "+442079460958" && p.Locale == "en-US" && p.DisplayName == "Dabi"
+				})).Return(&models.Profile{Phone: "+442079460958"}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.Profile, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "+442079460958", actual.Phone)
+			},
+		},
+		"no mask replaces the profile": {
+			profile: &models.Profile{DisplayName: "  Dabi  ", Locale: "en-gb", Timezone: "Europe/London"},
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "dabi").Return(customer, nil).Once()
+				mockRepo.On("SaveProfile", "dabi", mock.MatchedBy(func(p *models.Profile) bool {
+					return p.DisplayName == "Dabi" && p.Locale == "en-GB" && p.Phone == "" && p.Marketing != nil
+				})).Return(&models.Profile{DisplayName: "Dabi"}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.Profile, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "Dabi", actual.DisplayName)
+			},
+		},
+		"unknown field": {
+			profile: &models.Profile{},
+			paths:   []string{"username"},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *models.Profile, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidProfile)
+				require.Nil(t, actual)
+			},
+		},
+		"invalid phone": {
+			profile: &models.Profile{Phone: "0123"},
+			paths:   []string{"phone"},
+			arrange: func(t *testing.T) {
+				mockRepo.On("GetProfile", "dabi").Return(current(), nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.Profile, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidProfile)
+			},
+		},
+		"invalid timezone": {
+			profile: &models.Profile{Timezone: "Mars/Olympus"},
+			paths:   []string{"timezone"},
+			arrange: func(t *testing.T) {
+				mockRepo.On("GetProfile", "dabi").Return(current(), nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.Profile, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidProfile)
+			},
+		},
+		"invalid avatar": {
+			profile: &models.Profile{AvatarUrl: "javascript:alert(1)"},
+			paths:   []string{"avatarUrl"},
+			arrange: func(t *testing.T) {
+				mockRepo.On("GetProfile", "dabi").Return(current(), nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.Profile, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidProfile)
+			},
+		},
+		"avatar not uploaded": {
+			profile: &models.Profile{AvatarUrl: "https://tracker.example.com/avatars/../pixel.gif"},
+			paths:   []string{"avatarUrl"},
+			arrange: func(t *testing.T) {
+				mockRepo.On("GetProfile", "dabi").Return(current(), nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.Profile, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidProfile)
+			},
+		},
+		"uploaded avatar": {
+			profile: &models.Profile{AvatarUrl: "https://api.rpapp.io/images/avatars/9f86d081.jpg"},
+			paths:   []string{"avatarUrl"},
+			arrange: func(t *testing.T) {
+				mockRepo.On("GetProfile", "dabi").Return(current(), nil).Once()
+				mockRepo.On("FindByUsername", "dabi").Return(customer, nil).Once()
+				mockRepo.On("SaveProfile", "dabi", mock.MatchedBy(func(p *models.Profile) bool {
+					return p.AvatarUrl == "https://api.rpapp.io/images/avatars/9f86d081.jpg"
+				})).Return(&models.Profile{AvatarUrl: "https://api.rpapp.io/images/avatars/9f86d081.jpg"}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.Profile, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"sms without a phone": {
+			profile: &models.Profile{Marketing: &models.MarketingPreferences{Sms: true}},
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *models.Profile, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidProfile)
+			},
+		},
+		"no user found": {
+			profile: &models.Profile{},
+			paths:   []string{"displayName"},
+			arrange: func(t *testing.T) {
+				mockRepo.On("GetProfile", "dabi").Return(nil, repository.ErrNoUserFound).Once()
+			},
+			assert: func(t *testing.T, actual *models.Profile, err error) {
+				require.ErrorIs(t, err, repository.ErrNoUserFound)
+			},
+		},
+	}
+
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			var mask *fieldmaskpb.FieldMask
+			if v.paths != nil {
+				mask = &fieldmaskpb.FieldMask{Paths: v.paths}
+			}
+			actual, err := userInteractor.UpdateProfile(context.Background(), "dabi", v.profile, mask)
+
+			v.assert(t, actual, err)
+		})
+	}
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,212 @@
+package interactor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/spriigan/RPApp/usecases/repository"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used, the session is revoked")
+)
+
+const (
+	// AccessTokenPrefix marks the access tokens this service issues, so they
+	// can be told apart from other bearer tokens.
+	AccessTokenPrefix  = "at_"
+	RefreshTokenPrefix = "rt_"
+)
+
+// SessionPolicy decides how long the tokens of a session live. A session
+// stays alive as long as its refresh token is traded in before RefreshTTL.
+type SessionPolicy struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	// ImpersonationTTL is how long a session an admin started on behalf of
+	// a user lives, such sessions are not refreshed.
+	ImpersonationTTL time.Duration
+}
+
+var DefaultSessionPolicy = SessionPolicy{
+	AccessTTL:        15 * time.Minute,
+	RefreshTTL:       30 * 24 * time.Hour,
+	ImpersonationTTL: 30 * time.Minute,
+}
+
+// startSession records the session of a successful login and returns its
+// tokens.
+func (in *userInteractor) startSession(ctx context.Context, user *models.User, credentials *models.Credentials) (*models.AuthTokens, error) {
+	tokens, pair, err := in.newTokens(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	tokens.SessionId, err = in.Repo.CreateSession(ctx, repository.NewSession{
+		UserID:    user.Id,
+		Device:    credentials.Device,
+		Ip:        credentials.Ip,
+		UserAgent: credentials.UserAgent,
+		Tokens:    pair,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RefreshSession trades a refresh token for a new pair. Every refresh token
+// works once, when one comes back a second time it was copied, so the whole
+// session is revoked and neither party can use it anymore.
+func (in *userInteractor) RefreshSession(ctx context.Context, refreshToken string) (*models.AuthTokens, error) {
+	now := time.Now()
+	hash := hashToken(refreshToken)
+	stored, found, err := in.Repo.FindRefreshToken(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !found || stored.Revoked || stored.Impersonated || !stored.ExpiresAt.After(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if stored.Used {
+		return nil, in.refreshTokenReused(ctx, stored)
+	}
+
+	tokens, pair, err := in.newTokens(now)
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := in.Repo.RotateRefreshToken(ctx, stored.SessionID, hash, pair)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// another request traded the same token in first
+		return nil, in.refreshTokenReused(ctx, stored)
+	}
+	tokens.SessionId = stored.SessionID
+	return tokens, nil
+}
+
+// refreshTokenReused revokes the session a stolen refresh token belongs to.
+func (in *userInteractor) refreshTokenReused(ctx context.Context, stored repository.RefreshToken) error {
+	if err := in.Repo.RevokeSession(ctx, stored.UserID, stored.SessionID); err != nil {
+		return err
+	}
+	in.Events.Emit(ctx, SecurityEvent{Kind: RefreshTokenReused, Subject: stored.Username})
+	return ErrRefreshTokenReused
+}
+
+// Authenticate returns the session an access token belongs to with its user,
+// it fails once the session is revoked, the token expired or the user was
+// suspended.
+func (in *userInteractor) Authenticate(ctx context.Context, accessToken string) (repository.ActiveSession, error) {
+	return in.Repo.AuthenticateSession(ctx, hashToken(accessToken), time.Now())
+}
+
+// AuthenticateAccount checks the account a Firebase ID token signs in to, a
+// suspended account is refused as it is at login.
+func (in *userInteractor) AuthenticateAccount(ctx context.Context, email string) (*models.User, error) {
+	user, err := in.Repo.FindByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return nil, err
+	}
+	if user.SuspendedAt != nil {
+		return nil, ErrUserSuspended
+	}
+	return user, nil
+}
+
+func (in *userInteractor) ListSessions(ctx context.Context, username string) (*models.Sessions, error) {
+	user, err := in.Repo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := in.Repo.ListSessions(ctx, user.Id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &models.Sessions{Sessions: sessions}, nil
+}
+
+func (in *userInteractor) RevokeSession(ctx context.Context, username string, sessionID int64) error {
+	user, err := in.Repo.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	return in.Repo.InTx(ctx, func(ctx context.Context) error {
+		if err := in.Repo.RevokeSession(ctx, user.Id, sessionID); err != nil {
+			return err
+		}
+		return in.auditSession(ctx, AuditSessionRevoke, sessionID, user.Id)
+	})
+}
+
+// RevokeAllSessions logs the user out everywhere.
+func (in *userInteractor) RevokeAllSessions(ctx context.Context, username string) error {
+	user, err := in.Repo.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	err = in.Repo.InTx(ctx, func(ctx context.Context) error {
+		if err := in.Repo.RevokeAllSessions(ctx, user.Id); err != nil {
+			return err
+		}
+		return in.auditSession(ctx, AuditSessionsRevokeAll, 0, user.Id)
+	})
+	if err != nil {
+		return err
+	}
+	in.Events.Emit(ctx, SecurityEvent{Kind: SessionsRevoked, Subject: user.Username})
+	return nil
+}
+
+// newTokens makes a fresh access and refresh token, tokens goes to the client
+// and pair, which only has their hashes, to the repository.
+func (in *userInteractor) newTokens(now time.Time) (tokens *models.AuthTokens, pair repository.TokenPair, err error) {
+	access, err := newToken(AccessTokenPrefix)
+	if err != nil {
+		return nil, pair, err
+	}
+	refresh, err := newToken(RefreshTokenPrefix)
+	if err != nil {
+		return nil, pair, err
+	}
+	pair = repository.TokenPair{
+		AccessHash:       hashToken(access),
+		AccessExpiresAt:  now.Add(in.Sessions.AccessTTL),
+		RefreshHash:      hashToken(refresh),
+		RefreshExpiresAt: now.Add(in.Sessions.RefreshTTL),
+	}
+	tokens = &models.AuthTokens{
+		AccessToken:      access,
+		AccessExpiresAt:  timestamppb.New(pair.AccessExpiresAt),
+		RefreshToken:     refresh,
+		RefreshExpiresAt: timestamppb.New(pair.RefreshExpiresAt),
+	}
+	return tokens, pair, nil
+}
+
+// newToken returns 256 random bits behind the given prefix.
+func newToken(prefix string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashToken is what is stored in place of a token, the tokens are random
+// enough that a plain hash is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,190 @@
+package interactor_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spriigan/RPApp/interface/repository"
+	"github.com/spriigan/RPApp/usecases/interactor"
+	usecases "github.com/spriigan/RPApp/usecases/repository"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func hashOf(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLoginStartsSession(t *testing.T) {
+	hasher, err := interactor.NewPasswordHasher(interactor.DefaultPasswordParams)
+	require.NoError(t, err)
+	hash, err := hasher.Hash("secret")
+	require.NoError(t, err)
+	var stored usecases.NewSession
+	mockRepo.On("LockedUntil", "account", "owner").Return(time.Time{}, nil).Once()
+	mockRepo.On("FindByUsername", "owner").Return(&models.User{Id: 5, Username: "owner", Password: hash}, nil).Once()
+	mockRepo.On("FindTotp", int64(5)).Return("", false, nil).Once()
+	mockRepo.On("ClearLoginFailures", "account", "owner").Return(nil).Once()
+	mockRepo.On("CreateSession", mock.MatchedBy(func(session usecases.NewSession) bool {
+		stored = session
+		return true
+	})).Return(int64(9), nil).Once()
+
+	_, tokens, err := userInteractor.Login(context.Background(), &models.Credentials{Username: "owner", Password: "secret", UserAgent: "firefox"})
+
+	require.NoError(t, err)
+	require.Equal(t, int64(9), tokens.SessionId)
+	require.True(t, strings.HasPrefix(tokens.AccessToken, interactor.AccessTokenPrefix))
+	require.True(t, strings.HasPrefix(tokens.RefreshToken, interactor.RefreshTokenPrefix))
+	require.Equal(t, "firefox", stored.UserAgent)
+	require.Equal(t, hashOf(tokens.AccessToken), stored.Tokens.AccessHash, "only the hashes are stored")
+	require.Equal(t, hashOf(tokens.RefreshToken), stored.Tokens.RefreshHash)
+	require.WithinDuration(t, time.Now().Add(interactor.DefaultSessionPolicy.AccessTTL), tokens.AccessExpiresAt.AsTime(), time.Second)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRefreshSession(t *testing.T) {
+	valid := usecases.RefreshToken{SessionID: 9, UserID: 5, Username: "owner", ExpiresAt: time.Now().Add(time.Hour)}
+	events := &recordingEvents{}
+	in := interactor.NewUserInteractor(mockRepo)
+	in.Events = events
+
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.AuthTokens, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindRefreshToken", hashOf("rt_old")).Return(valid, true, nil).Once()
+				mockRepo.On("RotateRefreshToken", int64(9), hashOf("rt_old"), mock.AnythingOfType("repository.TokenPair")).Return(true, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.AuthTokens, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(9), actual.SessionId)
+				require.NotEqual(t, "rt_old", actual.RefreshToken)
+				require.Empty(t, events.events)
+			},
+		},
+		"reused": {
+			arrange: func(t *testing.T) {
+				used := valid
+				used.Used = true
+				mockRepo.On("FindRefreshToken", hashOf("rt_old")).Return(used, true, nil).Once()
+				mockRepo.On("RevokeSession", int64(5), int64(9)).Return(nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.AuthTokens, err error) {
+				require.ErrorIs(t, err, interactor.ErrRefreshTokenReused)
+				require.Len(t, events.events, 1)
+				require.Equal(t, interactor.RefreshTokenReused, events.events[0].Kind)
+				require.Equal(t, "owner", events.events[0].Subject)
+			},
+		},
+		"reused at the same time": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindRefreshToken", hashOf("rt_old")).Return(valid, true, nil).Once()
+				mockRepo.On("RotateRefreshToken", int64(9), hashOf("rt_old"), mock.AnythingOfType("repository.TokenPair")).Return(false, nil).Once()
+				mockRepo.On("RevokeSession", int64(5), int64(9)).Return(nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.AuthTokens, err error) {
+				require.ErrorIs(t, err, interactor.ErrRefreshTokenReused)
+				require.Nil(t, actual)
+			},
+		},
+		"expired": {
+			arrange: func(t *testing.T) {
+				expired := valid
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				mockRepo.On("FindRefreshToken", hashOf("rt_old")).Return(expired, true, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.AuthTokens, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidRefreshToken)
+			},
+		},
+		"revoked": {
+			arrange: func(t *testing.T) {
+				revoked := valid
+				revoked.Revoked, revoked.Used = true, true
+				mockRepo.On("FindRefreshToken", hashOf("rt_old")).Return(revoked, true, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.AuthTokens, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidRefreshToken, "nothing left to revoke")
+				require.Empty(t, events.events)
+			},
+		},
+		"unknown": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindRefreshToken", hashOf("rt_old")).Return(usecases.RefreshToken{}, false, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.AuthTokens, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidRefreshToken)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			events.events = nil
+			v.arrange(t)
+
+			actual, err := in.RefreshSession(context.Background(), "rt_old")
+
+			v.assert(t, actual, err)
+		})
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthenticate(t *testing.T) {
+	mockRepo.On("AuthenticateSession", hashOf("at_token")).
+		Return(usecases.ActiveSession{ID: 9, User: &models.User{Id: 5, Username: "owner"}}, nil).Once()
+	session, err := userInteractor.Authenticate(context.Background(), "at_token")
+	require.NoError(t, err)
+	require.Equal(t, "owner", session.User.Username)
+	require.Equal(t, int64(9), session.ID)
+
+	mockRepo.On("AuthenticateSession", hashOf("at_token")).Return(usecases.ActiveSession{}, repository.ErrSessionNotFound).Once()
+	_, err = userInteractor.Authenticate(context.Background(), "at_token")
+	require.ErrorIs(t, err, repository.ErrSessionNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthenticateAccount(t *testing.T) {
+	mockRepo.On("FindByEmail", "owner@mail.com").Return(&models.User{Id: 5, Username: "owner", PasswordResetRequired: true}, nil).Once()
+	user, err := userInteractor.AuthenticateAccount(context.Background(), " owner@mail.com ")
+	require.NoError(t, err)
+	require.True(t, user.PasswordResetRequired)
+
+	mockRepo.On("FindByEmail", "owner@mail.com").Return(&models.User{Id: 5, Username: "owner", SuspendedAt: timestamppb.Now()}, nil).Once()
+	_, err = userInteractor.AuthenticateAccount(context.Background(), "owner@mail.com")
+	require.ErrorIs(t, err, interactor.ErrUserSuspended)
+
+	mockRepo.On("FindByEmail", "gone@mail.com").Return(nil, repository.ErrNoUserFound).Once()
+	_, err = userInteractor.AuthenticateAccount(context.Background(), "gone@mail.com")
+	require.ErrorIs(t, err, repository.ErrNoUserFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRevokeSessions(t *testing.T) {
+	events := &recordingEvents{}
+	in := interactor.NewUserInteractor(mockRepo)
+	in.Events = events
+	mockRepo.On("FindByUsername", "owner").Return(totpUser, nil).Times(3)
+	mockRepo.On("ListSessions", int64(5)).Return([]*models.Session{{Id: 9}, {Id: 10}}, nil).Once()
+	mockRepo.On("RevokeSession", int64(5), int64(10)).Return(nil).Once()
+	mockRepo.On("RevokeAllSessions", int64(5)).Return(nil).Once()
+
+	sessions, err := in.ListSessions(context.Background(), "owner")
+	require.NoError(t, err)
+	require.Len(t, sessions.Sessions, 2)
+	require.NoError(t, in.RevokeSession(context.Background(), "owner", 10))
+	require.NoError(t, in.RevokeAllSessions(context.Background(), "owner"))
+	require.Len(t, events.events, 1)
+	require.Equal(t, interactor.SessionsRevoked, events.events[0].Kind)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,170 @@
+package interactor
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"image/png"
+	"strings"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+)
+
+var (
+	ErrTotpEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTotpNotEnrolled = errors.New("two-factor authentication is not set up")
+	ErrOtpRequired     = errors.New("a one-time code is required")
+	ErrInvalidOtp      = errors.New("wrong one-time code")
+)
+
+const (
+	// DefaultTotpIssuer is the name authenticator apps show next to the code.
+	DefaultTotpIssuer = "RPApp"
+	RecoveryCodeCount = 10
+	qrSize            = 256
+)
+
+// EnrollTotp starts setting up two-factor authentication. The secret only
+// counts once ConfirmTotp saw a code made from it.
+func (in *userInteractor) EnrollTotp(ctx context.Context, username string) (*models.TotpEnrollment, error) {
+	user, err := in.Repo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	_, enabled, err := in.Repo.FindTotp(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTotpEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: in.TotpIssuer, AccountName: user.Username})
+	if err != nil {
+		return nil, err
+	}
+	img, err := key.Image(qrSize, qrSize)
+	if err != nil {
+		return nil, err
+	}
+	var qr bytes.Buffer
+	if err = png.Encode(&qr, img); err != nil {
+		return nil, err
+	}
+	if err = in.Repo.SetTotpSecret(ctx, user.Id, key.Secret()); err != nil {
+		return nil, err
+	}
+	return &models.TotpEnrollment{Secret: key.Secret(), Uri: key.URL(), QrPng: qr.Bytes()}, nil
+}
+
+// ConfirmTotp turns two-factor authentication on and returns the recovery
+// codes. They are only stored hashed, so this is the one time they are seen.
+func (in *userInteractor) ConfirmTotp(ctx context.Context, username, code string) (*models.RecoveryCodes, error) {
+	user, err := in.Repo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	secret, enabled, err := in.Repo.FindTotp(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTotpEnabled
+	}
+	if secret == "" {
+		return nil, ErrTotpNotEnrolled
+	}
+	if !totp.Validate(strings.TrimSpace(code), secret) {
+		return nil, ErrInvalidOtp
+	}
+
+	codes := models.RecoveryCodes{Codes: make([]string, RecoveryCodeCount)}
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes.Codes {
+		if codes.Codes[i], err = newRecoveryCode(); err != nil {
+			return nil, err
+		}
+		hashes[i] = hashRecoveryCode(codes.Codes[i])
+	}
+	err = in.Repo.InTx(ctx, func(ctx context.Context) error {
+		if err := in.Repo.EnableTotp(ctx, user.Id, hashes); err != nil {
+			return err
+		}
+		return in.audit(ctx, AuditTotpEnable, user.Id, map[string]interface{}{"totp": false}, map[string]interface{}{"totp": true})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &codes, nil
+}
+
+// DisableTotp turns two-factor authentication off, it takes a code or a
+// recovery code so a stolen session alone can't do it.
+func (in *userInteractor) DisableTotp(ctx context.Context, username, code string) error {
+	user, err := in.Repo.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	enabled, err := in.verifySecondFactor(ctx, user.Id, code)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrTotpNotEnrolled
+	}
+	return in.Repo.InTx(ctx, func(ctx context.Context) error {
+		if err := in.Repo.DisableTotp(ctx, user.Id); err != nil {
+			return err
+		}
+		return in.audit(ctx, AuditTotpDisable, user.Id, map[string]interface{}{"totp": true}, map[string]interface{}{"totp": false})
+	})
+}
+
+// verifySecondFactor checks the code when the user has two-factor
+// authentication on, enabled tells whether it is. A recovery code works once.
+func (in *userInteractor) verifySecondFactor(ctx context.Context, id int64, code string) (enabled bool, err error) {
+	secret, enabled, err := in.Repo.FindTotp(ctx, id)
+	if err != nil || !enabled {
+		return enabled, err
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return true, ErrOtpRequired
+	}
+	if totp.Validate(code, secret) {
+		return true, nil
+	}
+	used, err := in.Repo.UseRecoveryCode(ctx, id, hashRecoveryCode(code))
+	if err != nil {
+		return true, err
+	}
+	if !used {
+		return true, ErrInvalidOtp
+	}
+	return true, nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCode returns 50 random bits written as two groups of five.
+func newRecoveryCode() (string, error) {
+	raw := make([]byte, 7)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(recoveryEncoding.EncodeToString(raw))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes so the code can be typed
+// the way it reads.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
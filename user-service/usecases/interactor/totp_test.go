@@ -0,0 +1,254 @@
+package interactor_test
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/spriigan/RPApp/usecases/interactor"
+	"github.com/spriigan/RPApp/user-proto/grpc/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const totpSecret = "JBSWY3DPEHPK3PXP"
+
+var totpUser = &models.User{Id: 5, Username: "owner"}
+
+func TestEnrollTotp(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.TotpEnrollment, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "owner").Return(totpUser, nil).Once()
+				mockRepo.On("FindTotp", int64(5)).Return("", false, nil).Once()
+				mockRepo.On("SetTotpSecret", int64(5), mock.AnythingOfType("string")).Return(nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.TotpEnrollment, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, actual.Secret)
+				require.True(t, strings.HasPrefix(actual.Uri, "otpauth://totp/RPApp:owner?"))
+				_, err = png.Decode(bytes.NewReader(actual.QrPng))
+				require.NoError(t, err)
+			},
+		},
+		"already enabled": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "owner").Return(totpUser, nil).Once()
+				mockRepo.On("FindTotp", int64(5)).Return(totpSecret, true, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.TotpEnrollment, err error) {
+				require.ErrorIs(t, err, interactor.ErrTotpEnabled)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := userInteractor.EnrollTotp(context.Background(), "owner")
+
+			v.assert(t, actual, err)
+		})
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestConfirmTotp(t *testing.T) {
+	code, err := totp.GenerateCode(totpSecret, time.Now())
+	require.NoError(t, err)
+
+	testTable := map[string]struct {
+		code    string
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.RecoveryCodes, err error)
+	}{
+		"succes call": {
+			code: code,
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "owner").Return(totpUser, nil).Once()
+				mockRepo.On("FindTotp", int64(5)).Return(totpSecret, false, nil).Once()
+				mockRepo.On("EnableTotp", int64(5), mock.MatchedBy(func(hashes []string) bool {
+					return len(hashes) == interactor.RecoveryCodeCount
+				})).Return(nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.RecoveryCodes, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.Codes, interactor.RecoveryCodeCount)
+				require.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, actual.Codes[0])
+				require.NotEqual(t, actual.Codes[0], actual.Codes[1])
+			},
+		},
+		"wrong code": {
+			code: "000000",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "owner").Return(totpUser, nil).Once()
+				mockRepo.On("FindTotp", int64(5)).Return(totpSecret, false, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.RecoveryCodes, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidOtp)
+			},
+		},
+		"not enrolled": {
+			code: code,
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "owner").Return(totpUser, nil).Once()
+				mockRepo.On("FindTotp", int64(5)).Return("", false, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.RecoveryCodes, err error) {
+				require.ErrorIs(t, err, interactor.ErrTotpNotEnrolled)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, err := userInteractor.ConfirmTotp(context.Background(), "owner", v.code)
+
+			v.assert(t, actual, err)
+		})
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDisableTotp(t *testing.T) {
+	testTable := map[string]struct {
+		code    string
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, err error)
+	}{
+		"recovery code": {
+			code: "ABCDE-FGHIJ",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "owner").Return(totpUser, nil).Once()
+				mockRepo.On("FindTotp", int64(5)).Return(totpSecret, true, nil).Once()
+				mockRepo.On("UseRecoveryCode", int64(5), mock.AnythingOfType("string")).Return(true, nil).Once()
+				mockRepo.On("DisableTotp", int64(5)).Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"wrong code": {
+			code: "000000",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "owner").Return(totpUser, nil).Once()
+				mockRepo.On("FindTotp", int64(5)).Return(totpSecret, true, nil).Once()
+				mockRepo.On("UseRecoveryCode", int64(5), mock.AnythingOfType("string")).Return(false, nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidOtp)
+			},
+		},
+		"not enabled": {
+			code: "000000",
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", "owner").Return(totpUser, nil).Once()
+				mockRepo.On("FindTotp", int64(5)).Return("", false, nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, interactor.ErrTotpNotEnrolled)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			err := userInteractor.DisableTotp(context.Background(), "owner", v.code)
+
+			v.assert(t, err)
+		})
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRecoveryCodeHashing(t *testing.T) {
+	var hashes []string
+	mockRepo.On("FindByUsername", "owner").Return(totpUser, nil).Twice()
+	mockRepo.On("FindTotp", int64(5)).Return(totpSecret, true, nil).Twice()
+	mockRepo.On("UseRecoveryCode", int64(5), mock.MatchedBy(func(hash string) bool {
+		hashes = append(hashes, hash)
+		return true
+	})).Return(true, nil).Twice()
+	mockRepo.On("DisableTotp", int64(5)).Return(nil).Twice()
+
+	require.NoError(t, userInteractor.DisableTotp(context.Background(), "owner", "abcde-fghij"))
+	require.NoError(t, userInteractor.DisableTotp(context.Background(), "owner", " ABCDE FGHIJ "))
+
+	require.Len(t, hashes, 2)
+	require.Equal(t, hashes[0], hashes[1], "typed either way")
+	require.NotContains(t, hashes[0], "abcde", "only the hash is stored")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLoginWithTotp(t *testing.T) {
+	hasher, err := interactor.NewPasswordHasher(interactor.DefaultPasswordParams)
+	require.NoError(t, err)
+	hash, err := hasher.Hash("secret")
+	require.NoError(t, err)
+	owner := &models.User{Id: 5, Username: "owner", Password: hash}
+	code, err := totp.GenerateCode(totpSecret, time.Now())
+	require.NoError(t, err)
+	passwordOk := func() {
+		mockRepo.On("LockedUntil", "account", "owner").Return(time.Time{}, nil).Once()
+		mockRepo.On("FindByUsername", "owner").Return(owner, nil).Once()
+		mockRepo.On("FindTotp", int64(5)).Return(totpSecret, true, nil).Once()
+	}
+
+	testTable := map[string]struct {
+		otp     string
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.User, err error)
+	}{
+		"succes call": {
+			otp: code,
+			arrange: func(t *testing.T) {
+				passwordOk()
+				mockRepo.On("ClearLoginFailures", "account", "owner").Return(nil).Once()
+				mockRepo.On("CreateSession", mock.Anything).Return(int64(9), nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(5), actual.Id)
+			},
+		},
+		"code missing": {
+			arrange: func(t *testing.T) {
+				passwordOk()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.ErrorIs(t, err, interactor.ErrOtpRequired, "not a failed login")
+			},
+		},
+		"wrong code": {
+			otp: "000000",
+			arrange: func(t *testing.T) {
+				passwordOk()
+				mockRepo.On("UseRecoveryCode", int64(5), mock.AnythingOfType("string")).Return(false, nil).Once()
+				mockRepo.On("RecordLoginFailure", "account", "owner").Return(1, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.ErrorIs(t, err, interactor.ErrInvalidOtp)
+				require.Nil(t, actual)
+			},
+		},
+	}
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			actual, _, err := userInteractor.Login(context.Background(), &models.Credentials{Username: "owner", Password: "secret", Otp: v.otp})
+
+			v.assert(t, actual, err)
+		})
+	}
+	mockRepo.AssertExpectations(t)
+}
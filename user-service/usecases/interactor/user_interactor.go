@@ -3,46 +3,129 @@ package interactor
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/spriigan/RPApp/usecases/audit"
 	"github.com/spriigan/RPApp/usecases/repository"
 	"github.com/spriigan/RPApp/user-proto/grpc/models"
-	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 type UserInteractor interface {
 	Create(ctx context.Context, user *models.UserPayload) (*models.UserBio, error)
-	FindUsers(ctx context.Context) (*models.Users, error)
+	IsUsernameAvailable(ctx context.Context, username string) (bool, error)
 	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	FindById(ctx context.Context, id int64) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	BatchGetUsers(ctx context.Context, ids []int64) (*models.Users, error)
 	DeleteByUsername(ctx context.Context, username string) error
 	Update(ctx context.Context, user *models.UserPayload) error
+	DeactivateUser(ctx context.Context, username string) error
+	ExportUserData(ctx context.Context, username string) (*models.UserData, error)
+	EraseUser(ctx context.Context, username string) error
+	Login(ctx context.Context, credentials *models.Credentials) (*models.User, *models.AuthTokens, error)
+	RefreshSession(ctx context.Context, refreshToken string) (*models.AuthTokens, error)
+	Authenticate(ctx context.Context, accessToken string) (repository.ActiveSession, error)
+	AuthenticateAccount(ctx context.Context, email string) (*models.User, error)
+	ListSessions(ctx context.Context, username string) (*models.Sessions, error)
+	RevokeSession(ctx context.Context, username string, sessionID int64) error
+	RevokeAllSessions(ctx context.Context, username string) error
+	UnlockUser(ctx context.Context, actor, username, reason string) error
+	EnrollTotp(ctx context.Context, username string) (*models.TotpEnrollment, error)
+	ConfirmTotp(ctx context.Context, username, code string) (*models.RecoveryCodes, error)
+	DisableTotp(ctx context.Context, username, code string) error
+	GetProfile(ctx context.Context, username string) (*models.Profile, error)
+	UpdateProfile(ctx context.Context, username string, profile *models.Profile, mask *fieldmaskpb.FieldMask) (*models.Profile, error)
+	SearchUsers(ctx context.Context, actor string, search repository.UserSearch) (*models.UserAccounts, error)
+	SuspendUser(ctx context.Context, actor, username, reason string) error
+	UnsuspendUser(ctx context.Context, actor, username, reason string) error
+	ReactivateUser(ctx context.Context, actor, username, reason string) error
+	ForcePasswordReset(ctx context.Context, actor, username, reason string) error
+	Impersonate(ctx context.Context, actor, username, reason string) (*models.AuthTokens, error)
+	SetRole(ctx context.Context, actor, username, role, reason string) error
+	SearchAuditLog(ctx context.Context, actor string, query repository.AuditQuery) (*models.AuditEntries, error)
 }
 
-var ErrDuplicateKeyInDatabase = errors.New("duplicate key in database")
+// UserPurger removes the users that were deleted longer than the retention
+// window ago.
+type UserPurger interface {
+	PurgeDeletedUsers(ctx context.Context) (int, error)
+}
+
+var (
+	ErrDuplicateKeyInDatabase = errors.New("duplicate key in database")
+	ErrUsernameRequired       = errors.New("username is required")
+	ErrTooManyIds             = fmt.Errorf("at most %d users can be fetched at once", MaxBatchSize)
+)
+
+// MaxBatchSize is how many users BatchGetUsers returns at most.
+const MaxBatchSize = 100
+
+// DefaultRetention is how long a deleted user is kept before it is purged.
+const DefaultRetention = 30 * 24 * time.Hour
 
 type userInteractor struct {
-	Repo repository.UserRepository
+	Repo           repository.UserRepository
+	Retention      time.Duration
+	Lockout        LockoutPolicy
+	Events         SecurityEvents
+	TotpIssuer     string
+	Sessions       SessionPolicy
+	Passwords      PasswordHasher
+	PasswordPolicy PasswordPolicy
+	Breached       repository.BreachedPasswords
+	// Audit keeps the record of who changed which account.
+	Audit audit.Auditor
 }
 
 func NewUserInteractor(repo repository.UserRepository) *userInteractor {
-	return &userInteractor{Repo: repo}
+	return &userInteractor{
+		Repo:           repo,
+		Retention:      DefaultRetention,
+		Lockout:        DefaultLockoutPolicy,
+		Events:         LogSecurityEvents{},
+		TotpIssuer:     DefaultTotpIssuer,
+		Sessions:       DefaultSessionPolicy,
+		Passwords:      &passwordHasher{params: DefaultPasswordParams},
+		PasswordPolicy: DefaultPasswordPolicy,
+		Audit:          audit.LogAuditor{},
+	}
 }
 
 func (in *userInteractor) Create(ctx context.Context, user *models.UserPayload) (*models.UserBio, error) {
-	hash, _ := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-	user.Password = string(hash)
-	_, err := in.Repo.Create(ctx, user)
+	canonicalize(user.GetBio())
+	if user.GetBio().GetUsername() == "" {
+		return nil, ErrUsernameRequired
+	}
+	if err := in.checkPassword(ctx, user.Password, user.GetBio()); err != nil {
+		return nil, err
+	}
+	hash, err := in.Passwords.Hash(user.Password)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = hash
+	err = in.Repo.InTx(ctx, func(ctx context.Context) error {
+		id, err := in.Repo.Create(ctx, user)
+		if err != nil {
+			return err
+		}
+		return in.audit(ctx, AuditUserCreate, int64(id), nil, auditedBio(user.GetBio()))
+	})
 	if err != nil {
 		return nil, err
 	}
 	return user.GetBio(), nil
 }
 
-func (in *userInteractor) FindUsers(ctx context.Context) (*models.Users, error) {
-	users, err := in.Repo.FindUsers(ctx)
-	if err != nil {
-		return nil, err
+func (in *userInteractor) IsUsernameAvailable(ctx context.Context, username string) (bool, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return false, ErrUsernameRequired
 	}
-	return users, nil
+	return in.Repo.IsUsernameAvailable(ctx, username)
 }
 
 func (in *userInteractor) FindByUsername(ctx context.Context, username string) (*models.User, error) {
@@ -53,20 +136,123 @@ func (in *userInteractor) FindByUsername(ctx context.Context, username string) (
 	return user, nil
 }
 
-func (in *userInteractor) DeleteByUsername(ctx context.Context, username string) error {
-	err := in.Repo.DeleteByUsername(ctx, username)
+func (in *userInteractor) FindById(ctx context.Context, id int64) (*models.User, error) {
+	return in.Repo.FindById(ctx, id)
+}
+
+func (in *userInteractor) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return in.Repo.FindByEmail(ctx, strings.TrimSpace(email))
+}
+
+// BatchGetUsers returns the users in the order their ids were asked for, each
+// once. Ids that match no user are skipped.
+func (in *userInteractor) BatchGetUsers(ctx context.Context, ids []int64) (*models.Users, error) {
+	unique := make([]int64, 0, len(ids))
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) > MaxBatchSize {
+		return nil, ErrTooManyIds
+	}
+	if len(unique) == 0 {
+		return &models.Users{User: []*models.UserBio{}}, nil
+	}
+
+	found, err := in.Repo.FindByIds(ctx, unique)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	byId := make(map[int64]*models.UserBio, len(found.User))
+	for _, bio := range found.User {
+		byId[bio.Id] = bio
 	}
-	return nil
+	users := models.Users{User: make([]*models.UserBio, 0, len(found.User))}
+	for _, id := range unique {
+		if bio, ok := byId[id]; ok {
+			users.User = append(users.User, bio)
+		}
+	}
+	return &users, nil
+}
+
+func (in *userInteractor) DeleteByUsername(ctx context.Context, username string) error {
+	return in.Repo.InTx(ctx, func(ctx context.Context) error {
+		id, err := in.Repo.DeleteByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
+		return in.audit(ctx, AuditUserDelete, id, nil, map[string]interface{}{"deleted": true})
+	})
 }
 
 func (in *userInteractor) Update(ctx context.Context, user *models.UserPayload) error {
-	hash, _ := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-	user.Password = string(hash)
-	err := in.Repo.Update(ctx, user)
+	canonicalize(user.GetBio())
+	if err := in.checkPassword(ctx, user.Password, user.GetBio()); err != nil {
+		return err
+	}
+	hash, err := in.Passwords.Hash(user.Password)
+	if err != nil {
+		return err
+	}
+	before, err := in.Repo.FindById(ctx, user.GetBio().GetId())
 	if err != nil {
 		return err
 	}
-	return nil
+	user.Password = hash
+	return in.Repo.InTx(ctx, func(ctx context.Context) error {
+		if err := in.Repo.Update(ctx, user); err != nil {
+			return err
+		}
+		after := auditedBio(user.GetBio())
+		after["password"] = "set"
+		return in.audit(ctx, AuditUserUpdate, before.Id, auditedBio(&models.UserBio{
+			Fname:    before.Fname,
+			Lname:    before.Lname,
+			Username: before.Username,
+			Email:    before.Email,
+		}), after)
+	})
+}
+
+func (in *userInteractor) DeactivateUser(ctx context.Context, username string) error {
+	return in.Repo.InTx(ctx, func(ctx context.Context) error {
+		id, err := in.Repo.Deactivate(ctx, username)
+		if err != nil {
+			return err
+		}
+		return in.audit(ctx, AuditUserDeactivate, id, nil, map[string]interface{}{"deactivated": true})
+	})
+}
+
+func (in *userInteractor) ExportUserData(ctx context.Context, username string) (*models.UserData, error) {
+	return in.Repo.ExportData(ctx, username)
+}
+
+func (in *userInteractor) EraseUser(ctx context.Context, username string) error {
+	return in.Repo.InTx(ctx, func(ctx context.Context) error {
+		id, err := in.Repo.Erase(ctx, username)
+		if err != nil {
+			return err
+		}
+		return in.audit(ctx, AuditUserErase, id, nil, map[string]interface{}{"erased": true})
+	})
+}
+
+func (in *userInteractor) PurgeDeletedUsers(ctx context.Context) (int, error) {
+	return in.Repo.PurgeDeleted(ctx, time.Now().Add(-in.Retention))
+}
+
+// canonicalize trims the username and stores emails lower cased, so both
+// compare the way their unique indexes do. Usernames keep their casing for
+// display.
+func canonicalize(bio *models.UserBio) {
+	if bio == nil {
+		return
+	}
+	bio.Username = strings.TrimSpace(bio.Username)
+	bio.Email = strings.ToLower(strings.TrimSpace(bio.Email))
 }
@@ -7,7 +7,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/spriigan/RPApp/interface/repository"
 	"github.com/spriigan/RPApp/usecases/interactor"
+	usecases "github.com/spriigan/RPApp/usecases/repository"
 	"github.com/spriigan/RPApp/user-proto/grpc/models"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -17,32 +19,147 @@ type mockUserRepo struct {
 	mock.Mock
 }
 
+func (in *mockUserRepo) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 func (in *mockUserRepo) Create(ctx context.Context, user *models.UserPayload) (int, error) {
 	args := in.Called(user)
 	return args.Int(0), args.Error(1)
 }
 
-func (in *mockUserRepo) FindUsers(ctx context.Context) (*models.Users, error) {
-	args := in.Called()
+func (in *mockUserRepo) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	args := in.Called(username)
 	arg1 := args.Get(0)
 	if arg1 == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.Users), args.Error(1)
+	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (in *mockUserRepo) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+func (in *mockUserRepo) IsUsernameAvailable(ctx context.Context, username string) (bool, error) {
 	args := in.Called(username)
-	arg1 := args.Get(0)
-	if arg1 == nil {
+	return args.Bool(0), args.Error(1)
+}
+
+func (in *mockUserRepo) LockedUntil(ctx context.Context, scope, subject string) (time.Time, error) {
+	args := in.Called(scope, subject)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (in *mockUserRepo) RecordLoginFailure(ctx context.Context, scope, subject string, at, since time.Time) (int, error) {
+	args := in.Called(scope, subject)
+	return args.Int(0), args.Error(1)
+}
+
+func (in *mockUserRepo) LockLogin(ctx context.Context, scope, subject string, until time.Time) error {
+	args := in.Called(scope, subject, until)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) ClearLoginFailures(ctx context.Context, scope, subject string) error {
+	args := in.Called(scope, subject)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) FindTotp(ctx context.Context, id int64) (string, bool, error) {
+	args := in.Called(id)
+	return args.String(0), args.Bool(1), args.Error(2)
+}
+
+func (in *mockUserRepo) SetTotpSecret(ctx context.Context, id int64, secret string) error {
+	args := in.Called(id, secret)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) EnableTotp(ctx context.Context, id int64, recoveryHashes []string) error {
+	args := in.Called(id, recoveryHashes)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) DisableTotp(ctx context.Context, id int64) error {
+	args := in.Called(id)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) UseRecoveryCode(ctx context.Context, id int64, hash string) (bool, error) {
+	args := in.Called(id, hash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (in *mockUserRepo) CreateSession(ctx context.Context, session usecases.NewSession) (int64, error) {
+	args := in.Called(session)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (in *mockUserRepo) AuthenticateSession(ctx context.Context, accessHash string, at time.Time) (usecases.ActiveSession, error) {
+	args := in.Called(accessHash)
+	return args.Get(0).(usecases.ActiveSession), args.Error(1)
+}
+
+func (in *mockUserRepo) FindRefreshToken(ctx context.Context, hash string) (usecases.RefreshToken, bool, error) {
+	args := in.Called(hash)
+	return args.Get(0).(usecases.RefreshToken), args.Bool(1), args.Error(2)
+}
+
+func (in *mockUserRepo) RotateRefreshToken(ctx context.Context, sessionID int64, usedHash string, tokens usecases.TokenPair) (bool, error) {
+	args := in.Called(sessionID, usedHash, tokens)
+	return args.Bool(0), args.Error(1)
+}
+
+func (in *mockUserRepo) ListSessions(ctx context.Context, userID int64, at time.Time) ([]*models.Session, error) {
+	args := in.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+
+func (in *mockUserRepo) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	args := in.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) RevokeAllSessions(ctx context.Context, userID int64) error {
+	args := in.Called(userID)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) FindById(ctx context.Context, id int64) (*models.User, error) {
+	args := in.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (in *mockUserRepo) FindDeactivated(ctx context.Context, username string) (*models.User, error) {
+	args := in.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (in *mockUserRepo) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := in.Called(email)
+	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (in *mockUserRepo) DeleteByUsername(ctx context.Context, username string) error {
+func (in *mockUserRepo) FindByIds(ctx context.Context, ids []int64) (*models.Users, error) {
+	args := in.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Users), args.Error(1)
+}
+
+func (in *mockUserRepo) DeleteByUsername(ctx context.Context, username string) (int64, error) {
 	args := in.Called()
-	return args.Error(0)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 func (in *mockUserRepo) Update(ctx context.Context, user *models.UserPayload) error {
@@ -50,6 +167,99 @@ func (in *mockUserRepo) Update(ctx context.Context, user *models.UserPayload) er
 	return args.Error(0)
 }
 
+func (in *mockUserRepo) SetPasswordHash(ctx context.Context, id int64, hash string) error {
+	args := in.Called(id, hash)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) GetProfile(ctx context.Context, username string) (*models.Profile, error) {
+	args := in.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Profile), args.Error(1)
+}
+
+func (in *mockUserRepo) SaveProfile(ctx context.Context, username string, profile *models.Profile) (*models.Profile, error) {
+	args := in.Called(username, profile)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Profile), args.Error(1)
+}
+
+func (in *mockUserRepo) SearchUsers(ctx context.Context, search usecases.UserSearch) ([]*models.UserAccount, error) {
+	args := in.Called(search)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.UserAccount), args.Error(1)
+}
+
+func (in *mockUserRepo) Suspend(ctx context.Context, action usecases.AdminAction) error {
+	args := in.Called(action)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) Unsuspend(ctx context.Context, action usecases.AdminAction) error {
+	args := in.Called(action)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) RequirePasswordReset(ctx context.Context, action usecases.AdminAction) error {
+	args := in.Called(action)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) SetRole(ctx context.Context, role string, action usecases.AdminAction) error {
+	args := in.Called(role, action)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) RecordAdminAction(ctx context.Context, action usecases.AdminAction) error {
+	args := in.Called(action)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) SearchAuditLog(ctx context.Context, query usecases.AuditQuery) ([]*models.AuditEntry, error) {
+	args := in.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.AuditEntry), args.Error(1)
+}
+
+func (in *mockUserRepo) Deactivate(ctx context.Context, username string) (int64, error) {
+	args := in.Called(username)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (in *mockUserRepo) Reactivate(ctx context.Context, action usecases.AdminAction) error {
+	args := in.Called(action)
+	return args.Error(0)
+}
+
+func (in *mockUserRepo) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	args := in.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
+func (in *mockUserRepo) ExportData(ctx context.Context, username string) (*models.UserData, error) {
+	args := in.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserData), args.Error(1)
+}
+
+func (in *mockUserRepo) Erase(ctx context.Context, username string) (int64, error) {
+	args := in.Called(username)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// strongPassword meets the default password policy.
+const strongPassword = "plum-Harbor-42"
+
 var userInteractor interactor.UserInteractor
 var mockRepo *mockUserRepo
 
@@ -89,78 +299,173 @@ func TestCreate(t *testing.T) {
 		t.Run(k, func(t *testing.T) {
 			v.arrange(t)
 
-			result, err := userInteractor.Create(ctx, &models.UserPayload{Bio: &models.UserBio{Username: "ryan"}})
+			result, err := userInteractor.Create(ctx, &models.UserPayload{Bio: &models.UserBio{Username: "ryan"}, Password: strongPassword})
 
 			v.assert(t, result, err)
 		})
 	}
 }
 
-func TestFindUsers(t *testing.T) {
-	users := &models.Users{
-		User: []*models.UserBio{
-			{},
-			{},
+func TestFindByUsername(t *testing.T) {
+	user := &models.User{Fname: "dabi", Username: "endeavour"}
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.User, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", mock.Anything, mock.Anything).Return(user, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, actual)
+				require.Equal(t, user.Fname, actual.Fname)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByUsername", mock.Anything).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
 		},
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := userInteractor.FindByUsername(ctx, "endeavour")
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestCreateCanonicalizes(t *testing.T) {
+	mockRepo.On("Create", mock.MatchedBy(func(user *models.UserPayload) bool {
+		return user.Bio.Username == "Dabi" && user.Bio.Email == "dabi@mail.com"
+	})).Return(1, nil).Once()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	bio, err := userInteractor.Create(ctx, &models.UserPayload{Bio: &models.UserBio{Username: " Dabi ", Email: " Dabi@Mail.COM"}, Password: strongPassword})
+
+	require.NoError(t, err)
+	require.Equal(t, "Dabi", bio.Username, "the casing is kept")
+	_, err = userInteractor.Create(ctx, &models.UserPayload{Bio: &models.UserBio{Username: "  "}})
+	require.ErrorIs(t, err, interactor.ErrUsernameRequired)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIsUsernameAvailable(t *testing.T) {
 	testTable := map[string]struct {
-		arrange func(t *testing.T)
-		assert  func(t *testing.T, actual *models.Users, err error)
+		username string
+		arrange  func(t *testing.T)
+		assert   func(t *testing.T, actual bool, err error)
 	}{
 		"succes call": {
+			username: " Dabi ",
 			arrange: func(t *testing.T) {
-				mockRepo.On("FindUsers").Return(users, nil).Once()
+				mockRepo.On("IsUsernameAvailable", "Dabi").Return(true, nil).Once()
 			},
-			assert: func(t *testing.T, actual *models.Users, err error) {
+			assert: func(t *testing.T, actual bool, err error) {
 				require.NoError(t, err)
-				require.Equal(t, users, actual)
-				require.Equal(t, len(users.User), len(actual.User))
+				require.True(t, actual)
+			},
+		},
+		"no username": {
+			username: " ",
+			arrange:  func(t *testing.T) {},
+			assert: func(t *testing.T, actual bool, err error) {
+				require.ErrorIs(t, err, interactor.ErrUsernameRequired)
+				require.False(t, actual)
 			},
 		},
 		"fail call": {
+			username: "dabi",
 			arrange: func(t *testing.T) {
-				mockRepo.On("FindUsers", mock.Anything).Return(nil, errors.New("got an error")).Once()
+				mockRepo.On("IsUsernameAvailable", "dabi").Return(false, errors.New("got an error")).Once()
 			},
-			assert: func(t *testing.T, actual *models.Users, err error) {
+			assert: func(t *testing.T, actual bool, err error) {
 				require.Error(t, err)
-				require.Zero(t, actual)
 			},
 		},
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := userInteractor.IsUsernameAvailable(ctx, v.username)
 
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestFindById(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.User, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindById", int64(4)).Return(&models.User{Id: 4, Username: "endeavour"}, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(4), actual.Id)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindById", int64(4)).Return(nil, repository.ErrNoUserFound).Once()
+			},
+			assert: func(t *testing.T, actual *models.User, err error) {
+				require.ErrorIs(t, err, repository.ErrNoUserFound)
+				require.Nil(t, actual)
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
 	for k, v := range testTable {
 		t.Run(k, func(t *testing.T) {
 			v.arrange(t)
 
-			result, err := userInteractor.FindUsers(ctx)
+			result, err := userInteractor.FindById(ctx, 4)
 
 			v.assert(t, result, err)
 		})
 	}
 }
 
-func TestFindByUsername(t *testing.T) {
-	user := &models.User{Fname: "dabi", Username: "endeavour"}
+func TestFindByEmail(t *testing.T) {
 	testTable := map[string]struct {
 		arrange func(t *testing.T)
 		assert  func(t *testing.T, actual *models.User, err error)
 	}{
 		"succes call": {
 			arrange: func(t *testing.T) {
-				mockRepo.On("FindByUsername", mock.Anything, mock.Anything).Return(user, nil).Once()
+				mockRepo.On("FindByEmail", "Dabi@Mail.com").Return(&models.User{Email: "dabi@mail.com"}, nil).Once()
 			},
 			assert: func(t *testing.T, actual *models.User, err error) {
 				require.NoError(t, err)
-				require.NotNil(t, actual)
-				require.Equal(t, user.Fname, actual.Fname)
+				require.Equal(t, "dabi@mail.com", actual.Email)
 			},
 		},
 		"fail call": {
 			arrange: func(t *testing.T) {
-				mockRepo.On("FindByUsername", mock.Anything).Return(nil, errors.New("got an error")).Once()
+				mockRepo.On("FindByEmail", "Dabi@Mail.com").Return(nil, errors.New("got an error")).Once()
 			},
 			assert: func(t *testing.T, actual *models.User, err error) {
 				require.Error(t, err)
@@ -171,16 +476,79 @@ func TestFindByUsername(t *testing.T) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := userInteractor.FindByEmail(ctx, " Dabi@Mail.com ")
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestBatchGetUsers(t *testing.T) {
+	found := &models.Users{User: []*models.UserBio{{Id: 3}, {Id: 1}}}
+	tooMany := make([]int64, interactor.MaxBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = int64(i + 1)
+	}
+	testTable := map[string]struct {
+		ids     []int64
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.Users, err error)
+	}{
+		"succes call": {
+			ids: []int64{1, 2, 3, 1},
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByIds", []int64{1, 2, 3}).Return(found, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.Users, err error) {
+				require.NoError(t, err)
+				require.Len(t, actual.User, 2, "unknown ids are skipped")
+				require.Equal(t, int64(1), actual.User[0].Id, "in the order asked for")
+				require.Equal(t, int64(3), actual.User[1].Id)
+			},
+		},
+		"no ids": {
+			ids:     nil,
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *models.Users, err error) {
+				require.NoError(t, err)
+				require.Empty(t, actual.User)
+			},
+		},
+		"too many ids": {
+			ids:     tooMany,
+			arrange: func(t *testing.T) {},
+			assert: func(t *testing.T, actual *models.Users, err error) {
+				require.ErrorIs(t, err, interactor.ErrTooManyIds)
+			},
+		},
+		"fail call": {
+			ids: []int64{1},
+			arrange: func(t *testing.T) {
+				mockRepo.On("FindByIds", []int64{1}).Return(nil, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, actual *models.Users, err error) {
+				require.Error(t, err)
+				require.Nil(t, actual)
+			},
+		},
+	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
 	for k, v := range testTable {
 		t.Run(k, func(t *testing.T) {
 			v.arrange(t)
 
-			result, err := userInteractor.FindByUsername(ctx, "endeavour")
+			result, err := userInteractor.BatchGetUsers(ctx, v.ids)
 
 			v.assert(t, result, err)
 		})
 	}
+	mockRepo.AssertExpectations(t)
 }
 
 func TestDeleteByUsername(t *testing.T) {
@@ -190,7 +558,7 @@ func TestDeleteByUsername(t *testing.T) {
 	}{
 		"succes call": {
 			arrange: func(t *testing.T) {
-				mockRepo.On("DeleteByUsername", mock.Anything).Return(nil).Once()
+				mockRepo.On("DeleteByUsername", mock.Anything).Return(int64(3), nil).Once()
 			},
 			assert: func(t *testing.T, err error) {
 				require.NoError(t, err)
@@ -198,7 +566,7 @@ func TestDeleteByUsername(t *testing.T) {
 		},
 		"fail call": {
 			arrange: func(t *testing.T) {
-				mockRepo.On("DeleteByUsername", mock.Anything).Return(errors.New("got an error")).Once()
+				mockRepo.On("DeleteByUsername", mock.Anything).Return(int64(0), errors.New("got an error")).Once()
 			},
 			assert: func(t *testing.T, err error) {
 				require.Error(t, err)
@@ -226,6 +594,7 @@ func TestUpdate(t *testing.T) {
 	}{
 		"succes call": {
 			arrange: func(t *testing.T) {
+				mockRepo.On("FindById", int64(0)).Return(&models.User{Username: "dabi"}, nil).Once()
 				mockRepo.On("Update", mock.Anything).Return(nil).Once()
 			},
 			assert: func(t *testing.T, err error) {
@@ -234,6 +603,7 @@ func TestUpdate(t *testing.T) {
 		},
 		"fail call": {
 			arrange: func(t *testing.T) {
+				mockRepo.On("FindById", int64(0)).Return(&models.User{Username: "dabi"}, nil).Once()
 				mockRepo.On("Update", mock.Anything).Return(errors.New("got an error")).Once()
 			},
 			assert: func(t *testing.T, err error) {
@@ -249,7 +619,164 @@ func TestUpdate(t *testing.T) {
 		t.Run(k, func(t *testing.T) {
 			v.arrange(t)
 
-			err := userInteractor.Update(ctx, &models.UserPayload{})
+			err := userInteractor.Update(ctx, &models.UserPayload{Password: strongPassword})
+
+			v.assert(t, err)
+		})
+	}
+}
+
+func TestDeactivateUser(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("Deactivate", "endeavour").Return(int64(3), nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("Deactivate", "endeavour").Return(int64(0), repository.ErrNoUserFound).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, repository.ErrNoUserFound)
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			err := userInteractor.DeactivateUser(ctx, "endeavour")
+
+			v.assert(t, err)
+		})
+	}
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	purger := interactor.NewUserInteractor(mockRepo)
+	purger.Retention = 24 * time.Hour
+	cutoff := mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) > 24*time.Hour && time.Since(before) < 25*time.Hour
+	})
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, purged int, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("PurgeDeleted", cutoff).Return(2, nil).Once()
+			},
+			assert: func(t *testing.T, purged int, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 2, purged)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("PurgeDeleted", cutoff).Return(0, errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, purged int, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			purged, err := purger.PurgeDeletedUsers(ctx)
+
+			v.assert(t, purged, err)
+		})
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestExportUserData(t *testing.T) {
+	data := &models.UserData{
+		Bio:       &models.UserBio{Username: "endeavour"},
+		Addresses: []*models.Address{{City: "Musutafu"}},
+	}
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, actual *models.UserData, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("ExportData", "endeavour").Return(data, nil).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserData, err error) {
+				require.NoError(t, err)
+				require.Equal(t, data, actual)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("ExportData", "endeavour").Return(nil, repository.ErrNoUserFound).Once()
+			},
+			assert: func(t *testing.T, actual *models.UserData, err error) {
+				require.ErrorIs(t, err, repository.ErrNoUserFound)
+				require.Nil(t, actual)
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			result, err := userInteractor.ExportUserData(ctx, "endeavour")
+
+			v.assert(t, result, err)
+		})
+	}
+}
+
+func TestEraseUser(t *testing.T) {
+	testTable := map[string]struct {
+		arrange func(t *testing.T)
+		assert  func(t *testing.T, err error)
+	}{
+		"succes call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("Erase", "endeavour").Return(int64(3), nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"fail call": {
+			arrange: func(t *testing.T) {
+				mockRepo.On("Erase", "endeavour").Return(int64(0), errors.New("got an error")).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for k, v := range testTable {
+		t.Run(k, func(t *testing.T) {
+			v.arrange(t)
+
+			err := userInteractor.EraseUser(ctx, "endeavour")
 
 			v.assert(t, err)
 		})
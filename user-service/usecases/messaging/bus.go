@@ -0,0 +1,46 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+)
+
+// Handler handles the messages of a topic, an error has the relay publish
+// the message again later.
+type Handler func(ctx context.Context, msg Message) error
+
+// Bus delivers the messages within the process, to the handlers subscribed
+// to their topic. It is used when no broker is configured.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: map[string][]Handler{}}
+}
+
+func (b *Bus) Subscribe(topic string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], handler)
+}
+
+// Publish runs every handler of the topic one after another and returns the
+// first error. A message nobody subscribed to is dropped.
+func (b *Bus) Publish(ctx context.Context, msg Message) error {
+	b.mu.RLock()
+	handlers := b.handlers[msg.Topic]
+	b.mu.RUnlock()
+	var first error
+	for _, handle := range handlers {
+		if err := handle(ctx, msg); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (b *Bus) Close() error {
+	return nil
+}
@@ -0,0 +1,164 @@
+package messaging_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spriigan/RPApp/usecases/audit"
+	"github.com/spriigan/RPApp/usecases/messaging"
+	"github.com/spriigan/RPApp/user-proto/grpc/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	ctx := audit.WithRequest(context.Background(), audit.Request{ID: "req-1", Actor: "root@mail.com"})
+	envelope := messaging.NewEnvelope(ctx)
+	envelope.Event = &events.Envelope_UserDeleted{UserDeleted: &events.UserDeleted{UserId: 7, Username: "dabi"}}
+
+	msg, err := messaging.Encode(envelope)
+	require.NoError(t, err)
+	require.Equal(t, messaging.TopicUserDeleted, msg.Topic)
+	require.Equal(t, "7", msg.Key)
+
+	decoded, err := messaging.Decode(msg)
+	require.NoError(t, err)
+	require.Equal(t, envelope.Id, decoded.Id)
+	require.Equal(t, messaging.Source, decoded.Source)
+	require.Equal(t, "req-1", decoded.RequestId)
+	require.Equal(t, "root@mail.com", decoded.Actor)
+	require.Equal(t, "dabi", decoded.GetUserDeleted().Username)
+
+	_, err = messaging.Encode(messaging.NewEnvelope(context.Background()))
+	require.ErrorIs(t, err, messaging.ErrUnknownEvent)
+}
+
+func TestBus(t *testing.T) {
+	bus := messaging.NewBus()
+	var got []string
+	bus.Subscribe(messaging.TopicUserDeleted, func(ctx context.Context, msg messaging.Message) error {
+		got = append(got, "first "+msg.Key)
+		return errors.New("cart service is down")
+	})
+	bus.Subscribe(messaging.TopicUserDeleted, func(ctx context.Context, msg messaging.Message) error {
+		got = append(got, "second "+msg.Key)
+		return nil
+	})
+
+	err := bus.Publish(context.Background(), messaging.Message{Topic: messaging.TopicUserDeleted, Key: "7"})
+	require.EqualError(t, err, "cart service is down")
+	// a failing handler does not keep the others from the message
+	require.Equal(t, []string{"first 7", "second 7"}, got)
+
+	require.NoError(t, bus.Publish(context.Background(), messaging.Message{Topic: messaging.TopicUserRegistered}))
+}
+
+// outbox hands out its messages in batches and keeps those publish refused.
+type outbox struct {
+	pending   []messaging.Message
+	published []messaging.Message
+}
+
+func (o *outbox) Relay(ctx context.Context, limit int, publish func(ctx context.Context, msg messaging.Message) error) (int, error) {
+	n := 0
+	for len(o.pending) > 0 && n < limit {
+		if err := publish(ctx, o.pending[0]); err != nil {
+			return n, err
+		}
+		o.published = append(o.published, o.pending[0])
+		o.pending = o.pending[1:]
+		n++
+	}
+	return n, nil
+}
+
+func TestRelay(t *testing.T) {
+	box := &outbox{}
+	for i := int64(1); i <= 5; i++ {
+		box.pending = append(box.pending, messaging.Message{ID: i, Topic: messaging.TopicUserRegistered})
+	}
+	bus := messaging.NewBus()
+	fail := true
+	bus.Subscribe(messaging.TopicUserRegistered, func(ctx context.Context, msg messaging.Message) error {
+		if msg.ID == 4 && fail {
+			return errors.New("not now")
+		}
+		return nil
+	})
+	relay := messaging.NewRelay(box, bus)
+	relay.Batch = 2
+
+	published, err := relay.Flush(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 3, published)
+	require.Len(t, box.pending, 2)
+
+	fail = false
+	published, err = relay.Flush(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, published)
+	require.Len(t, box.published, 5)
+}
+
+// natsServer answers like a NATS server and hands over what it is sent.
+func natsServer(t *testing.T, reject string) (string, <-chan string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+	lines := make(chan string, 16)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			switch {
+			case line == "PING":
+				conn.Write([]byte("PONG\r\n"))
+			case strings.HasPrefix(line, "PUB "):
+				payload, _ := r.ReadString('\n')
+				lines <- line + " " + strings.TrimSpace(payload)
+				if reject != "" {
+					conn.Write([]byte("-ERR '" + reject + "'\r\n"))
+				}
+			case strings.HasPrefix(line, "CONNECT "):
+				lines <- line
+			}
+		}
+	}()
+	return lis.Addr().String(), lines
+}
+
+func TestNATS(t *testing.T) {
+	addr, lines := natsServer(t, "")
+	nats, err := messaging.NewNATS("nats://app:secret@"+addr, time.Second)
+	require.NoError(t, err)
+	defer nats.Close()
+
+	err = nats.Publish(context.Background(), messaging.Message{Topic: messaging.TopicUserDeleted, Payload: []byte("event")})
+	require.NoError(t, err)
+	require.Contains(t, <-lines, `"user":"app"`)
+	require.Equal(t, "PUB user.deleted 5 event", <-lines)
+
+	addr, _ = natsServer(t, "Permissions Violation for Publish")
+	nats, err = messaging.NewNATS("nats://"+addr, time.Second)
+	require.NoError(t, err)
+	defer nats.Close()
+	err = nats.Publish(context.Background(), messaging.Message{Topic: messaging.TopicUserDeleted, Payload: []byte("event")})
+	require.EqualError(t, err, "NATS: Permissions Violation for Publish")
+
+	_, err = messaging.NewNATS("http://localhost:4222", time.Second)
+	require.Error(t, err)
+}
@@ -0,0 +1,181 @@
+package messaging
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATS publishes the messages to a NATS server, each topic is a subject. It
+// speaks the text protocol of the server directly and waits for the server
+// to answer a PING after every message, so a message it reports as
+// published has reached the server. A lost connection is dialed again on
+// the next message.
+type NATS struct {
+	addr    string
+	user    string
+	pass    string
+	token   string
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// DefaultNATSTimeout bounds each exchange with the server when no timeout is
+// given.
+const DefaultNATSTimeout = 5 * time.Second
+
+// NewNATS takes the address of the server as nats://[user:pass@]host:port,
+// or nats://token@host:port.
+func NewNATS(address string, timeout time.Duration) (*NATS, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "nats" || u.Host == "" {
+		return nil, fmt.Errorf("invalid NATS address %q", address)
+	}
+	if timeout <= 0 {
+		timeout = DefaultNATSTimeout
+	}
+	n := &NATS{addr: u.Host, timeout: timeout}
+	if u.Port() == "" {
+		n.addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			n.user, n.pass = u.User.Username(), pass
+		} else {
+			n.token = u.User.Username()
+		}
+	}
+	return n, nil
+}
+
+func (n *NATS) Publish(ctx context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return err
+		}
+	}
+	err := n.publish(ctx, msg)
+	if err != nil {
+		n.conn.Close()
+		n.conn = nil
+	}
+	return err
+}
+
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn = nil
+	return err
+}
+
+func (n *NATS) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: n.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	n.conn, n.r = conn, bufio.NewReader(conn)
+	n.deadline(ctx)
+	// the server greets with its INFO first
+	line, err := n.r.ReadString('\n')
+	if err == nil && !strings.HasPrefix(line, "INFO ") {
+		err = fmt.Errorf("unexpected greeting from NATS: %q", strings.TrimSpace(line))
+	}
+	if err == nil {
+		err = n.handshake()
+	}
+	if err != nil {
+		conn.Close()
+		n.conn = nil
+	}
+	return err
+}
+
+func (n *NATS) handshake() error {
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     Source,
+		"lang":     "go",
+		"protocol": 0,
+	}
+	if n.user != "" {
+		options["user"], options["pass"] = n.user, n.pass
+	}
+	if n.token != "" {
+		options["auth_token"] = n.token
+	}
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(n.conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return err
+	}
+	return n.pong()
+}
+
+func (n *NATS) publish(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.Topic, " \t\r\n") || msg.Topic == "" {
+		return fmt.Errorf("invalid subject %q", msg.Topic)
+	}
+	n.deadline(ctx)
+	w := bufio.NewWriter(n.conn)
+	fmt.Fprintf(w, "PUB %s %d\r\n", msg.Topic, len(msg.Payload))
+	w.Write(msg.Payload)
+	w.WriteString("\r\nPING\r\n")
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return n.pong()
+}
+
+// pong reads until the server answers the PING, it answers the PINGs of the
+// server on the way.
+func (n *NATS) pong() error {
+	for {
+		line, err := n.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err = n.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New("NATS: " + strings.Trim(strings.TrimSpace(line[4:]), "'"))
+		}
+	}
+}
+
+func (n *NATS) deadline(ctx context.Context) {
+	deadline := time.Now().Add(n.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	n.conn.SetDeadline(deadline)
+}
@@ -0,0 +1,48 @@
+// The user service has a copy of this file, make sync_shared writes it from
+// this one. Change it here and run make sync_shared.
+
+package messaging
+
+import "context"
+
+// DefaultBatch is how many messages a relay takes from the outbox at a time.
+const DefaultBatch = 100
+
+// MaxAttempts is how often a message is tried before the outbox parks it. A
+// parked message keeps its last error and is not handed out again, so it no
+// longer holds back the messages after it. Clearing its failed_at sends it
+// again.
+const MaxAttempts = 10
+
+// Outbox keeps the messages until they are published.
+type Outbox interface {
+	// Relay hands up to limit of the oldest unpublished messages to publish in
+	// order and marks the ones it accepted as published. It stops at the first
+	// message publish fails on, records the error with it and returns it. A
+	// message that failed MaxAttempts times is parked.
+	Relay(ctx context.Context, limit int, publish func(ctx context.Context, msg Message) error) (int, error)
+}
+
+// Relay moves the messages from the outbox to the publisher.
+type Relay struct {
+	Outbox    Outbox
+	Publisher Publisher
+	Batch     int
+}
+
+func NewRelay(outbox Outbox, publisher Publisher) *Relay {
+	return &Relay{Outbox: outbox, Publisher: publisher, Batch: DefaultBatch}
+}
+
+// Flush publishes until the outbox is empty or a message fails, it returns
+// how many were published.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		n, err := r.Outbox.Relay(ctx, r.Batch, r.Publisher.Publish)
+		published += n
+		if err != nil || n < r.Batch {
+			return published, err
+		}
+	}
+}
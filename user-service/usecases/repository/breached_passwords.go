@@ -0,0 +1,11 @@
+package repository
+
+import "context"
+
+// BreachedPasswords answers the k-anonymity question of the Pwned Passwords
+// range API: given the first five hex characters of a SHA-1 hash it returns
+// the remaining 35 of every breached hash with that prefix and how often it
+// was seen, so the hash of a password never has to be looked up whole.
+type BreachedPasswords interface {
+	Range(ctx context.Context, prefix string) (map[string]int, error)
+}
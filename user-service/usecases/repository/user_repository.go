@@ -2,14 +2,139 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/spriigan/RPApp/user-proto/grpc/models"
 )
 
 type UserRepository interface {
+	// InTx runs fn in a transaction, the calls fn makes with the context it
+	// is given take part in it.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
 	Create(ctx context.Context, user *models.UserPayload) (int, error)
-	FindUsers(ctx context.Context) (*models.Users, error)
+	IsUsernameAvailable(ctx context.Context, username string) (bool, error)
 	FindByUsername(ctx context.Context, username string) (*models.User, error)
-	DeleteByUsername(ctx context.Context, username string) error
+	FindById(ctx context.Context, id int64) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindDeactivated(ctx context.Context, username string) (*models.User, error)
+	FindByIds(ctx context.Context, ids []int64) (*models.Users, error)
+	// DeleteByUsername, Deactivate and Erase return the id of the user they
+	// changed.
+	DeleteByUsername(ctx context.Context, username string) (int64, error)
 	Update(ctx context.Context, user *models.UserPayload) error
+	SetPasswordHash(ctx context.Context, id int64, hash string) error
+	Deactivate(ctx context.Context, username string) (int64, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+	ExportData(ctx context.Context, username string) (*models.UserData, error)
+	Erase(ctx context.Context, username string) (int64, error)
+	LockedUntil(ctx context.Context, scope, subject string) (time.Time, error)
+	RecordLoginFailure(ctx context.Context, scope, subject string, at, since time.Time) (int, error)
+	LockLogin(ctx context.Context, scope, subject string, until time.Time) error
+	ClearLoginFailures(ctx context.Context, scope, subject string) error
+	FindTotp(ctx context.Context, id int64) (secret string, enabled bool, err error)
+	SetTotpSecret(ctx context.Context, id int64, secret string) error
+	EnableTotp(ctx context.Context, id int64, recoveryHashes []string) error
+	DisableTotp(ctx context.Context, id int64) error
+	UseRecoveryCode(ctx context.Context, id int64, hash string) (bool, error)
+	CreateSession(ctx context.Context, session NewSession) (int64, error)
+	AuthenticateSession(ctx context.Context, accessHash string, at time.Time) (ActiveSession, error)
+	FindRefreshToken(ctx context.Context, hash string) (token RefreshToken, found bool, err error)
+	RotateRefreshToken(ctx context.Context, sessionID int64, usedHash string, tokens TokenPair) (bool, error)
+	ListSessions(ctx context.Context, userID int64, at time.Time) ([]*models.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID int64) error
+	RevokeAllSessions(ctx context.Context, userID int64) error
+	GetProfile(ctx context.Context, username string) (*models.Profile, error)
+	SaveProfile(ctx context.Context, username string, profile *models.Profile) (*models.Profile, error)
+	SearchUsers(ctx context.Context, search UserSearch) ([]*models.UserAccount, error)
+	Suspend(ctx context.Context, action AdminAction) error
+	Unsuspend(ctx context.Context, action AdminAction) error
+	Reactivate(ctx context.Context, action AdminAction) error
+	RequirePasswordReset(ctx context.Context, action AdminAction) error
+	SetRole(ctx context.Context, role string, action AdminAction) error
+	RecordAdminAction(ctx context.Context, action AdminAction) error
+	SearchAuditLog(ctx context.Context, query AuditQuery) ([]*models.AuditEntry, error)
+}
+
+// TokenPair holds the hashes of a session's access and refresh token, the
+// tokens themselves are never stored.
+type TokenPair struct {
+	AccessHash       string
+	AccessExpiresAt  time.Time
+	RefreshHash      string
+	RefreshExpiresAt time.Time
+}
+
+// NewSession is the session a login starts.
+type NewSession struct {
+	UserID    int64
+	Device    string
+	Ip        string
+	UserAgent string
+	Tokens    TokenPair
+	// ImpersonatorID is the admin that started the session on behalf of the
+	// user, zero for a login.
+	ImpersonatorID int64
+}
+
+// ActiveSession is the session an access token belongs to and its user.
+type ActiveSession struct {
+	ID   int64
+	User *models.User
+	// Impersonator is the username of the admin that started the session,
+	// empty for a login.
+	Impersonator string
+}
+
+// RefreshToken is a stored refresh token and the session it belongs to.
+type RefreshToken struct {
+	SessionID int64
+	UserID    int64
+	Username  string
+	ExpiresAt time.Time
+	Used      bool
+	Revoked   bool
+	// Impersonated sessions end with their access token.
+	Impersonated bool
+}
+
+// UserSearch filters the users an admin looks through, empty fields match
+// every user.
+type UserSearch struct {
+	// Query matches the start of the username or email, or any part of the
+	// name.
+	Query   string
+	Role    string
+	Status  string
+	Limit   int
+	AfterID int64
+}
+
+// The statuses a UserSearch can filter on.
+const (
+	StatusActive      = "active"
+	StatusSuspended   = "suspended"
+	StatusDeactivated = "deactivated"
+)
+
+// AdminAction is the record kept of an admin acting on a user. The
+// repository writes it along with the change it describes.
+type AdminAction struct {
+	ActorID  int64
+	Action   string
+	TargetID int64
+	Reason   string
+}
+
+// AuditQuery filters the audit log, empty fields match every entry. Entries
+// come newest first, BeforeID continues after the last page.
+type AuditQuery struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	From       time.Time
+	To         time.Time
+	Limit      int
+	BeforeID   int64
 }